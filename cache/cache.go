@@ -0,0 +1,280 @@
+package cache
+
+import (
+	"bytes"
+	"container/list"
+	"io/ioutil"
+	"net/http"
+	"strconv"
+	"sync"
+	"time"
+
+	"github.com/zalando/skipper/metrics"
+)
+
+const (
+	// DefaultMaxSize is the default maximum of the total size of the
+	// stored responses in bytes.
+	DefaultMaxSize = 64 << 20
+
+	// DefaultMaxEntrySize is the default maximum size of a single
+	// response body that can be stored.
+	DefaultMaxEntrySize = 1 << 20
+)
+
+// Options to initialize a cache.
+type Options struct {
+
+	// MaxSize is the maximum total size of the stored responses in
+	// bytes. Defaults to DefaultMaxSize.
+	MaxSize int64
+
+	// MaxEntrySize is the maximum size of a single response body in
+	// bytes. Larger responses are not stored. Defaults to
+	// DefaultMaxEntrySize.
+	MaxEntrySize int64
+
+	// Metrics receives the hit, miss and eviction counters, and the
+	// size gauges. Defaults to metrics.Default.
+	Metrics metrics.Metrics
+}
+
+// Entry is a stored response.
+type Entry struct {
+	StatusCode int
+	Header     http.Header
+	Body       []byte
+
+	// Created is the time when the response was stored.
+	Created time.Time
+
+	// Expires is the time after which the response is not served
+	// anymore.
+	Expires time.Time
+
+	key  string
+	vary http.Header
+	size int64
+}
+
+// Cache stores the responses, and evicts the least recently used ones
+// when the configured maximum size is reached. It is safe for concurrent
+// use.
+type Cache struct {
+	mx           sync.Mutex
+	maxSize      int64
+	maxEntrySize int64
+	size         int64
+	lru          *list.List
+	variants     map[string][]*list.Element
+	metrics      metrics.Metrics
+	now          func() time.Time
+}
+
+// New creates a cache.
+func New(o Options) *Cache {
+	if o.MaxSize <= 0 {
+		o.MaxSize = DefaultMaxSize
+	}
+
+	if o.MaxEntrySize <= 0 {
+		o.MaxEntrySize = DefaultMaxEntrySize
+	}
+
+	if o.MaxEntrySize > o.MaxSize {
+		o.MaxEntrySize = o.MaxSize
+	}
+
+	if o.Metrics == nil {
+		o.Metrics = metrics.Default
+	}
+
+	return &Cache{
+		maxSize:      o.MaxSize,
+		maxEntrySize: o.MaxEntrySize,
+		lru:          list.New(),
+		variants:     make(map[string][]*list.Element),
+		metrics:      o.Metrics,
+		now:          time.Now,
+	}
+}
+
+// Key returns the cache key of a request matched by the route with
+// the given ID. Since the cache is shared, the route ID and the scheme
+// keep apart the responses of the routes that handle the same URLs with
+// different filters or backends.
+func Key(routeID string, r *http.Request) string {
+	scheme := "http"
+	if r.TLS != nil {
+		scheme = "https"
+	}
+
+	return routeID + " " + r.Method + " " + scheme + "://" + r.Host + r.URL.RequestURI()
+}
+
+// MaxEntrySize returns the maximum size of a response body that can be
+// stored.
+func (c *Cache) MaxEntrySize() int64 {
+	return c.maxEntrySize
+}
+
+// Get returns the fresh response stored with the key, whose variant
+// matches the request header, or nil.
+func (c *Cache) Get(key string, h http.Header) *Entry {
+	c.mx.Lock()
+	defer c.mx.Unlock()
+
+	now := c.now()
+	for _, el := range c.variants[key] {
+		e := el.Value.(*Entry)
+		if !matchVary(e.vary, h) {
+			continue
+		}
+
+		if !now.Before(e.Expires) {
+			c.remove(el)
+			c.updateGauges()
+			break
+		}
+
+		c.lru.MoveToFront(el)
+		c.metrics.IncCounter("cache.hit")
+		return e
+	}
+
+	c.metrics.IncCounter("cache.miss")
+	return nil
+}
+
+// Put stores a response with the key, as the variant selected by its
+// Vary header from the request header. It replaces the previously stored
+// variant, and evicts the least recently used responses when necessary.
+// Responses larger than the maximum entry size are ignored.
+func (c *Cache) Put(key string, h http.Header, e *Entry) {
+	e.key = key
+	e.vary = varyValues(e.Header, h)
+	e.size = entrySize(e)
+	if int64(len(e.Body)) > c.maxEntrySize || e.size > c.maxSize {
+		return
+	}
+
+	c.mx.Lock()
+	defer c.mx.Unlock()
+
+	for _, el := range c.variants[key] {
+		if sameVary(el.Value.(*Entry).vary, e.vary) {
+			c.remove(el)
+			break
+		}
+	}
+
+	for c.size+e.size > c.maxSize {
+		c.remove(c.lru.Back())
+		c.metrics.IncCounter("cache.eviction")
+	}
+
+	c.variants[key] = append(c.variants[key], c.lru.PushFront(e))
+	c.size += e.size
+	c.updateGauges()
+}
+
+func (c *Cache) remove(el *list.Element) {
+	e := c.lru.Remove(el).(*Entry)
+	c.size -= e.size
+
+	v := c.variants[e.key]
+	for i := range v {
+		if v[i] == el {
+			v = append(v[:i], v[i+1:]...)
+			break
+		}
+	}
+
+	if len(v) == 0 {
+		delete(c.variants, e.key)
+	} else {
+		c.variants[e.key] = v
+	}
+}
+
+func (c *Cache) updateGauges() {
+	c.metrics.UpdateGauge("cache.size", float64(c.size))
+	c.metrics.UpdateGauge("cache.entries", float64(c.lru.Len()))
+}
+
+// Response creates a new response from the stored entry, with the Age
+// header set.
+func (e *Entry) Response(now time.Time) *http.Response {
+	h := e.Header.Clone()
+	h.Set("Age", strconv.Itoa(int(now.Sub(e.Created)/time.Second)))
+	return &http.Response{
+		StatusCode:    e.StatusCode,
+		Header:        h,
+		Body:          ioutil.NopCloser(bytes.NewReader(e.Body)),
+		ContentLength: int64(len(e.Body)),
+	}
+}
+
+func varyValues(rsp, req http.Header) http.Header {
+	names := headerValues(rsp, "Vary")
+	if len(names) == 0 {
+		return nil
+	}
+
+	v := make(http.Header)
+	for _, n := range names {
+		v[http.CanonicalHeaderKey(n)] = req[http.CanonicalHeaderKey(n)]
+	}
+
+	return v
+}
+
+func matchVary(vary, h http.Header) bool {
+	for n, values := range vary {
+		if !equalValues(values, h[n]) {
+			return false
+		}
+	}
+
+	return true
+}
+
+func sameVary(left, right http.Header) bool {
+	if len(left) != len(right) {
+		return false
+	}
+
+	for n, values := range left {
+		if rv, ok := right[n]; !ok || !equalValues(values, rv) {
+			return false
+		}
+	}
+
+	return true
+}
+
+func equalValues(left, right []string) bool {
+	if len(left) != len(right) {
+		return false
+	}
+
+	for i := range left {
+		if left[i] != right[i] {
+			return false
+		}
+	}
+
+	return true
+}
+
+func entrySize(e *Entry) int64 {
+	size := int64(len(e.key) + len(e.Body))
+	for n, values := range e.Header {
+		size += int64(len(n))
+		for _, v := range values {
+			size += int64(len(v))
+		}
+	}
+
+	return size
+}
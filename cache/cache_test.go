@@ -0,0 +1,176 @@
+package cache
+
+import (
+	"crypto/tls"
+	"net/http"
+	"testing"
+	"time"
+
+	"github.com/zalando/skipper/metrics/metricstest"
+)
+
+func testEntry(body string, ttl time.Duration, header http.Header) *Entry {
+	if header == nil {
+		header = make(http.Header)
+	}
+
+	now := time.Now()
+	return &Entry{
+		StatusCode: http.StatusOK,
+		Header:     header,
+		Body:       []byte(body),
+		Created:    now,
+		Expires:    now.Add(ttl),
+	}
+}
+
+func counter(m *metricstest.MockMetrics, key string) int64 {
+	var v int64
+	m.WithCounters(func(c map[string]int64) { v = c[key] })
+	return v
+}
+
+func TestKey(t *testing.T) {
+	r, err := http.NewRequest("GET", "http://www.example.org/foo?bar=baz", nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	k := Key("route1", r)
+	if k != "route1 GET http://www.example.org/foo?bar=baz" {
+		t.Errorf("unexpected key: %s", k)
+	}
+
+	if Key("route2", r) == k {
+		t.Error("the key does not contain the route")
+	}
+
+	r.TLS = &tls.ConnectionState{}
+	if Key("route1", r) == k {
+		t.Error("the key does not contain the scheme")
+	}
+}
+
+func TestGetPut(t *testing.T) {
+	m := &metricstest.MockMetrics{}
+	c := New(Options{Metrics: m})
+
+	if e := c.Get("foo", nil); e != nil {
+		t.Fatal("unexpected entry")
+	}
+
+	c.Put("foo", nil, testEntry("bar", time.Minute, nil))
+	e := c.Get("foo", nil)
+	if e == nil || string(e.Body) != "bar" {
+		t.Fatalf("failed to get the stored entry: %v", e)
+	}
+
+	if hits, misses := counter(m, "cache.hit"), counter(m, "cache.miss"); hits != 1 || misses != 1 {
+		t.Errorf("unexpected counters, hits: %d, misses: %d", hits, misses)
+	}
+
+	if entries, ok := m.Gauge("cache.entries"); !ok || entries != 1 {
+		t.Errorf("unexpected number of entries: %v", entries)
+	}
+}
+
+func TestExpired(t *testing.T) {
+	c := New(Options{Metrics: &metricstest.MockMetrics{}})
+
+	now := time.Now()
+	c.now = func() time.Time { return now }
+	c.Put("foo", nil, testEntry("bar", time.Minute, nil))
+
+	now = now.Add(2 * time.Minute)
+	if e := c.Get("foo", nil); e != nil {
+		t.Fatal("expired entry returned")
+	}
+
+	if c.size != 0 || c.lru.Len() != 0 || len(c.variants) != 0 {
+		t.Error("expired entry not removed")
+	}
+}
+
+func TestVary(t *testing.T) {
+	c := New(Options{Metrics: &metricstest.MockMetrics{}})
+
+	rsp := http.Header{"Vary": []string{"Accept-Encoding"}}
+	gzip := http.Header{"Accept-Encoding": []string{"gzip"}}
+	identity := http.Header{}
+
+	c.Put("foo", gzip, testEntry("gzipped", time.Minute, rsp))
+	if e := c.Get("foo", identity); e != nil {
+		t.Fatal("variant returned for a different request header")
+	}
+
+	c.Put("foo", identity, testEntry("plain", time.Minute, rsp))
+	if e := c.Get("foo", gzip); e == nil || string(e.Body) != "gzipped" {
+		t.Errorf("failed to get the gzip variant: %v", e)
+	}
+
+	if e := c.Get("foo", identity); e == nil || string(e.Body) != "plain" {
+		t.Errorf("failed to get the plain variant: %v", e)
+	}
+
+	c.Put("foo", gzip, testEntry("gzipped again", time.Minute, rsp))
+	if e := c.Get("foo", gzip); e == nil || string(e.Body) != "gzipped again" {
+		t.Errorf("failed to replace the gzip variant: %v", e)
+	}
+
+	if n := c.lru.Len(); n != 2 {
+		t.Errorf("unexpected number of entries: %d", n)
+	}
+}
+
+func TestEviction(t *testing.T) {
+	m := &metricstest.MockMetrics{}
+	c := New(Options{MaxSize: 20, Metrics: m})
+
+	// the size of each entry is 8, the length of the key and the body
+	c.Put("foo1", nil, testEntry("bar1", time.Minute, nil))
+	c.Put("foo2", nil, testEntry("bar2", time.Minute, nil))
+
+	// makes foo2 the least recently used
+	c.Get("foo1", nil)
+
+	c.Put("foo3", nil, testEntry("bar3", time.Minute, nil))
+	if c.Get("foo2", nil) != nil {
+		t.Error("least recently used entry not evicted")
+	}
+
+	if c.Get("foo1", nil) == nil || c.Get("foo3", nil) == nil {
+		t.Error("recently used entry evicted")
+	}
+
+	if evictions := counter(m, "cache.eviction"); evictions != 1 {
+		t.Errorf("unexpected number of evictions: %d", evictions)
+	}
+
+	if size, ok := m.Gauge("cache.size"); !ok || size != 16 {
+		t.Errorf("unexpected size: %v", size)
+	}
+}
+
+func TestMaxEntrySize(t *testing.T) {
+	c := New(Options{MaxSize: 100, MaxEntrySize: 3, Metrics: &metricstest.MockMetrics{}})
+	c.Put("foo", nil, testEntry("barbaz", time.Minute, nil))
+	if c.Get("foo", nil) != nil {
+		t.Error("entry larger than the maximum stored")
+	}
+}
+
+func TestResponse(t *testing.T) {
+	e := testEntry("bar", time.Minute, http.Header{"Content-Type": []string{"text/plain"}})
+	rsp := e.Response(e.Created.Add(3 * time.Second))
+	if rsp.StatusCode != http.StatusOK || rsp.ContentLength != 3 {
+		t.Errorf("unexpected response: %d, %d", rsp.StatusCode, rsp.ContentLength)
+	}
+
+	if age := rsp.Header.Get("Age"); age != "3" {
+		t.Errorf("unexpected age: %s", age)
+	}
+
+	if e.Header.Get("Age") != "" {
+		t.Error("stored header modified")
+	}
+}
@@ -0,0 +1,157 @@
+package cache
+
+import (
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+)
+
+type directives map[string]string
+
+func parseCacheControl(h http.Header) directives {
+	d := make(directives)
+	for _, v := range headerValues(h, "Cache-Control") {
+		kv := strings.SplitN(v, "=", 2)
+		name := strings.ToLower(strings.TrimSpace(kv[0]))
+		if len(kv) == 2 {
+			d[name] = strings.Trim(strings.TrimSpace(kv[1]), `"`)
+		} else {
+			d[name] = ""
+		}
+	}
+
+	return d
+}
+
+func (d directives) has(name string) bool {
+	_, ok := d[name]
+	return ok
+}
+
+func (d directives) seconds(name string) (time.Duration, bool) {
+	v, ok := d[name]
+	if !ok {
+		return 0, false
+	}
+
+	s, err := strconv.Atoi(v)
+	if err != nil || s < 0 {
+		return 0, false
+	}
+
+	return time.Duration(s) * time.Second, true
+}
+
+// headerValues returns the comma separated values of a header, from all
+// of its occurrences.
+func headerValues(h http.Header, name string) []string {
+	var values []string
+	for _, hv := range h[http.CanonicalHeaderKey(name)] {
+		for _, v := range strings.Split(hv, ",") {
+			if v = strings.TrimSpace(v); v != "" {
+				values = append(values, v)
+			}
+		}
+	}
+
+	return values
+}
+
+// Storable tells whether the response to the request may be stored,
+// based on the request method and Cache-Control.
+func Storable(r *http.Request) bool {
+	return r.Method == "GET" && !parseCacheControl(r.Header).has("no-store")
+}
+
+// Lookup tells whether the request may be served from the cache. Requests
+// with the no-cache directive, max-age=0, or Pragma: no-cache need to be
+// validated by the backend.
+func Lookup(r *http.Request) bool {
+	if !Storable(r) {
+		return false
+	}
+
+	d := parseCacheControl(r.Header)
+	if d.has("no-cache") {
+		return false
+	}
+
+	if maxAge, ok := d.seconds("max-age"); ok && maxAge == 0 {
+		return false
+	}
+
+	for _, p := range headerValues(r.Header, "Pragma") {
+		if strings.EqualFold(p, "no-cache") {
+			return false
+		}
+	}
+
+	return true
+}
+
+// Freshness returns how long the response may be served from the cache,
+// based on the response status, Cache-Control and Expires headers. The
+// request header is used to check for the Authorization header. When the
+// response has no explicit freshness information, defaultTTL is used. It
+// returns false when the response must not be stored.
+func Freshness(req http.Header, rsp *http.Response, defaultTTL time.Duration, now time.Time) (time.Duration, bool) {
+	switch rsp.StatusCode {
+	case http.StatusOK,
+		http.StatusNonAuthoritativeInfo,
+		http.StatusNoContent,
+		http.StatusMultipleChoices,
+		http.StatusMovedPermanently,
+		http.StatusNotFound,
+		http.StatusMethodNotAllowed,
+		http.StatusGone,
+		http.StatusRequestURITooLong,
+		http.StatusNotImplemented:
+	default:
+		return 0, false
+	}
+
+	d := parseCacheControl(rsp.Header)
+	if d.has("no-store") || d.has("no-cache") || d.has("private") {
+		return 0, false
+	}
+
+	if len(rsp.Header["Set-Cookie"]) > 0 {
+		return 0, false
+	}
+
+	for _, v := range headerValues(rsp.Header, "Vary") {
+		if v == "*" {
+			return 0, false
+		}
+	}
+
+	if req.Get("Authorization") != "" && !d.has("public") && !d.has("s-maxage") {
+		return 0, false
+	}
+
+	var ttl time.Duration
+	if s, ok := d.seconds("s-maxage"); ok {
+		ttl = s
+	} else if s, ok := d.seconds("max-age"); ok {
+		ttl = s
+	} else if e := rsp.Header.Get("Expires"); e != "" {
+		expires, err := http.ParseTime(e)
+		if err != nil {
+			return 0, false
+		}
+
+		date := now
+		if ds := rsp.Header.Get("Date"); ds != "" {
+			if dt, err := http.ParseTime(ds); err == nil {
+				date = dt
+			}
+		}
+
+		ttl = expires.Sub(date)
+	} else {
+		ttl = defaultTTL
+	}
+
+	return ttl, ttl > 0
+}
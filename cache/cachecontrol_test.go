@@ -0,0 +1,166 @@
+package cache
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+)
+
+func TestLookup(t *testing.T) {
+	for _, ti := range []struct {
+		msg      string
+		method   string
+		header   http.Header
+		storable bool
+		lookup   bool
+	}{{
+		msg:      "plain get",
+		method:   "GET",
+		storable: true,
+		lookup:   true,
+	}, {
+		msg:    "post",
+		method: "POST",
+	}, {
+		msg:    "no-store",
+		method: "GET",
+		header: http.Header{"Cache-Control": []string{"no-store"}},
+	}, {
+		msg:      "no-cache",
+		method:   "GET",
+		header:   http.Header{"Cache-Control": []string{"no-cache"}},
+		storable: true,
+	}, {
+		msg:      "max-age=0",
+		method:   "GET",
+		header:   http.Header{"Cache-Control": []string{"max-age=0"}},
+		storable: true,
+	}, {
+		msg:      "pragma no-cache",
+		method:   "GET",
+		header:   http.Header{"Pragma": []string{"no-cache"}},
+		storable: true,
+	}} {
+		t.Run(ti.msg, func(t *testing.T) {
+			r := httptest.NewRequest(ti.method, "/", nil)
+			for k, v := range ti.header {
+				r.Header[k] = v
+			}
+
+			if s := Storable(r); s != ti.storable {
+				t.Errorf("unexpected storable, expected: %v, got: %v", ti.storable, s)
+			}
+
+			if l := Lookup(r); l != ti.lookup {
+				t.Errorf("unexpected lookup, expected: %v, got: %v", ti.lookup, l)
+			}
+		})
+	}
+}
+
+func TestFreshness(t *testing.T) {
+	now := time.Date(2020, 1, 1, 12, 0, 0, 0, time.UTC)
+	for _, ti := range []struct {
+		msg        string
+		status     int
+		header     http.Header
+		request    http.Header
+		defaultTTL time.Duration
+		ttl        time.Duration
+		storable   bool
+	}{{
+		msg:    "no freshness information",
+		status: http.StatusOK,
+	}, {
+		msg:        "default ttl",
+		status:     http.StatusOK,
+		defaultTTL: time.Minute,
+		ttl:        time.Minute,
+		storable:   true,
+	}, {
+		msg:      "max-age",
+		status:   http.StatusOK,
+		header:   http.Header{"Cache-Control": []string{"public, max-age=60"}},
+		ttl:      time.Minute,
+		storable: true,
+	}, {
+		msg:      "s-maxage overrides max-age",
+		status:   http.StatusOK,
+		header:   http.Header{"Cache-Control": []string{"max-age=60, s-maxage=120"}},
+		ttl:      2 * time.Minute,
+		storable: true,
+	}, {
+		msg:    "expires",
+		status: http.StatusOK,
+		header: http.Header{
+			"Date":    []string{now.Format(http.TimeFormat)},
+			"Expires": []string{now.Add(time.Hour).Format(http.TimeFormat)},
+		},
+		ttl:      time.Hour,
+		storable: true,
+	}, {
+		msg:    "invalid expires",
+		status: http.StatusOK,
+		header: http.Header{"Expires": []string{"0"}},
+	}, {
+		msg:    "not cacheable status",
+		status: http.StatusInternalServerError,
+		header: http.Header{"Cache-Control": []string{"max-age=60"}},
+	}, {
+		msg:    "no-store",
+		status: http.StatusOK,
+		header: http.Header{"Cache-Control": []string{"no-store, max-age=60"}},
+	}, {
+		msg:    "private",
+		status: http.StatusOK,
+		header: http.Header{"Cache-Control": []string{"private, max-age=60"}},
+	}, {
+		msg:    "set-cookie",
+		status: http.StatusOK,
+		header: http.Header{
+			"Cache-Control": []string{"max-age=60"},
+			"Set-Cookie":    []string{"foo=bar"},
+		},
+	}, {
+		msg:    "vary all",
+		status: http.StatusOK,
+		header: http.Header{
+			"Cache-Control": []string{"max-age=60"},
+			"Vary":          []string{"*"},
+		},
+	}, {
+		msg:     "authorization",
+		status:  http.StatusOK,
+		header:  http.Header{"Cache-Control": []string{"max-age=60"}},
+		request: http.Header{"Authorization": []string{"Bearer foo"}},
+	}, {
+		msg:      "authorization with public",
+		status:   http.StatusOK,
+		header:   http.Header{"Cache-Control": []string{"public, max-age=60"}},
+		request:  http.Header{"Authorization": []string{"Bearer foo"}},
+		ttl:      time.Minute,
+		storable: true,
+	}} {
+		t.Run(ti.msg, func(t *testing.T) {
+			h := ti.header
+			if h == nil {
+				h = make(http.Header)
+			}
+
+			req := ti.request
+			if req == nil {
+				req = make(http.Header)
+			}
+
+			ttl, ok := Freshness(req, &http.Response{StatusCode: ti.status, Header: h}, ti.defaultTTL, now)
+			if ok != ti.storable {
+				t.Fatalf("unexpected storable, expected: %v, got: %v", ti.storable, ok)
+			}
+
+			if ttl != ti.ttl {
+				t.Errorf("unexpected ttl, expected: %v, got: %v", ti.ttl, ttl)
+			}
+		})
+	}
+}
@@ -0,0 +1,48 @@
+/*
+Package cache implements an in-memory HTTP response cache for the proxy.
+
+The cache stores complete responses, and evicts the least recently used
+ones when the total size of the stored responses would exceed the
+configured maximum. Expired responses are removed when they are looked
+up.
+
+Cache-Control
+
+The cache acts as a shared cache, and follows the Cache-Control
+directives of the requests and the responses:
+
+Only responses to GET requests are stored. Requests with the no-store
+directive are neither served from the cache nor stored. Requests with
+the no-cache directive, the max-age=0 directive or the Pragma: no-cache
+header are not served from the cache, but their responses can be
+stored.
+
+Responses with the no-store, no-cache or private directives, responses
+setting cookies, and responses with the Vary: * header are not stored.
+Responses to requests with an Authorization header are stored only
+when they contain the public or the s-maxage directive.
+
+The freshness of the responses is defined by the s-maxage, max-age
+directives or the Expires header, in this order. When none of them is
+present, a default TTL can be applied.
+
+Vary
+
+The responses with the Vary header are stored as separate variants,
+and they are served only to requests with matching values of the
+headers listed by Vary.
+
+Metrics
+
+The cache counts the lookups with the cache.hit and the cache.miss
+keys, the hit ratio can be calculated from these. The evictions are
+counted with the cache.eviction key, while the total size of the stored
+responses and their number is reported with the cache.size and the
+cache.entries gauges.
+
+Usage
+
+The cache is used by the cacheResponse filter, see
+https://godoc.org/github.com/zalando/skipper/filters/cache.
+*/
+package cache
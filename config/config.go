@@ -5,7 +5,9 @@ import (
 	"flag"
 	"fmt"
 	"io/ioutil"
+	"net"
 	"net/http"
+	"net/url"
 	"sort"
 	"strconv"
 	"strings"
@@ -17,8 +19,10 @@ import (
 
 	"github.com/prometheus/client_golang/prometheus"
 	"github.com/zalando/skipper"
+	"github.com/zalando/skipper/cache"
 	"github.com/zalando/skipper/dataclients/kubernetes"
 	"github.com/zalando/skipper/eskip"
+	snet "github.com/zalando/skipper/net"
 	"github.com/zalando/skipper/proxy"
 	"github.com/zalando/skipper/ratelimit"
 	"github.com/zalando/skipper/swarm"
@@ -33,6 +37,9 @@ type Config struct {
 	ExpectedBytesPerRequest         int            `yaml:"expected-bytes-per-request"`
 	MaxTCPListenerConcurrency       int            `yaml:"max-tcp-listener-concurrency"`
 	MaxTCPListenerQueue             int            `yaml:"max-tcp-listener-queue"`
+	EnableProxyProtocol             bool           `yaml:"enable-proxy-protocol"`
+	ProxyProtocolTrustedCIDRs       *listFlag      `yaml:"proxy-protocol-trusted-cidrs"`
+	Listeners                       listenerFlags  `yaml:"listener"`
 	IgnoreTrailingSlash             bool           `yaml:"ignore-trailing-slash"`
 	Insecure                        bool           `yaml:"insecure"`
 	ProxyPreserveHost               bool           `yaml:"proxy-preserve-host"`
@@ -41,27 +48,49 @@ type Config struct {
 	DebugListener                   string         `yaml:"debug-listener"`
 	CertPathTLS                     string         `yaml:"tls-cert"`
 	KeyPathTLS                      string         `yaml:"tls-key"`
+	ClientCAPathTLS                 string         `yaml:"tls-client-ca"`
+	EnableHTTP3                     bool           `yaml:"enable-http3"`
+	HTTP3Address                    string         `yaml:"http3-address"`
 	StatusChecks                    *listFlag      `yaml:"status-checks"`
 	PrintVersion                    bool           `yaml:"version"`
 	MaxLoopbacks                    int            `yaml:"max-loopbacks"`
+	MaxLBRetries                    int            `yaml:"max-lb-retries"`
 	DefaultHTTPStatus               int            `yaml:"default-http-status"`
 	PluginDir                       string         `yaml:"plugindir"`
 	LoadBalancerHealthCheckInterval time.Duration  `yaml:"lb-healthcheck-interval"`
 	ReverseSourcePredicate          bool           `yaml:"reverse-source-predicate"`
 	RemoveHopHeaders                bool           `yaml:"remove-hop-headers"`
+	ForwardedForString              string         `yaml:"forwarded-for"`
+	ForwardedProtoString            string         `yaml:"forwarded-proto"`
+	ForwardedHostString             string         `yaml:"forwarded-host"`
+	ForwardedHeader                 bool           `yaml:"forwarded-header"`
+	ForwardedTrustedCIDRs           *listFlag      `yaml:"forwarded-trusted-cidrs"`
+	ClientIPForwardedDepth          int            `yaml:"client-ip-forwarded-depth"`
 	RfcPatchPath                    bool           `yaml:"rfc-patch-path"`
 	MaxAuditBody                    int            `yaml:"max-audit-body"`
+	ResponseCacheMaxSize            int64          `yaml:"response-cache-max-size"`
+	ResponseCacheMaxEntrySize       int64          `yaml:"response-cache-max-entry-size"`
+	GeoIPCountryDatabase            string         `yaml:"geoip-country-database"`
+	GeoIPASNDatabase                string         `yaml:"geoip-asn-database"`
+	GeoIPReloadInterval             time.Duration  `yaml:"geoip-reload-interval"`
 	EnableBreakers                  bool           `yaml:"enable-breakers"`
 	Breakers                        breakerFlags   `yaml:"breaker"`
 	EnableRatelimiters              bool           `yaml:"enable-ratelimits"`
 	Ratelimits                      ratelimitFlags `yaml:"ratelimits"`
+	RatelimitResponseHeaders        bool           `yaml:"ratelimit-response-headers"`
+	RatelimitLegacyHeaders          bool           `yaml:"ratelimit-legacy-headers"`
 	EnableRouteLIFOMetrics          bool           `yaml:"enable-route-lifo-metrics"`
+	EnableRouteFIFOMetrics          bool           `yaml:"enable-route-fifo-metrics"`
 	MetricsFlavour                  *listFlag      `yaml:"metrics-flavour"`
 	FilterPlugins                   *pluginFlag    `yaml:"filter-plugin"`
 	PredicatePlugins                *pluginFlag    `yaml:"predicate-plugin"`
 	DataclientPlugins               *pluginFlag    `yaml:"dataclient-plugin"`
 	MultiPlugins                    *pluginFlag    `yaml:"multi-plugin"`
 
+	ForwardedHeaders             snet.ForwardedHeaders `yaml:"-"`
+	EgressProxyURL               *url.URL              `yaml:"-"`
+	ProxyProtocolTrustedNetworks []*net.IPNet          `yaml:"-"`
+
 	// logging, metrics, tracing:
 	EnablePrometheusMetrics             bool      `yaml:"enable-prometheus-metrics"`
 	OpenTracing                         string    `yaml:"opentracing"`
@@ -80,6 +109,7 @@ type Config struct {
 	AllFiltersMetrics                   bool      `yaml:"all-filters-metrics"`
 	CombinedResponseMetrics             bool      `yaml:"combined-response-metrics"`
 	RouteResponseMetrics                bool      `yaml:"route-response-metrics"`
+	GRPCStatusMetrics                   bool      `yaml:"grpc-status-metrics"`
 	RouteBackendErrorCounters           bool      `yaml:"route-backend-error-counters"`
 	RouteStreamErrorCounters            bool      `yaml:"route-stream-error-counters"`
 	RouteBackendMetrics                 bool      `yaml:"route-backend-metrics"`
@@ -131,6 +161,8 @@ type Config struct {
 	KubernetesNamespace         string              `yaml:"kubernetes-namespace"`
 	KubernetesEnableEastWest    bool                `yaml:"enable-kubernetes-east-west"`
 	KubernetesEastWestDomain    string              `yaml:"kubernetes-east-west-domain"`
+	KubernetesEnableGatewayAPI  bool                `yaml:"enable-kubernetes-gateway-api"`
+	KubernetesGatewayController string              `yaml:"kubernetes-gateway-controller-name"`
 
 	// Default filters
 	DefaultFiltersDir string `yaml:"default-filters-dir"`
@@ -142,8 +174,15 @@ type Config struct {
 	Oauth2TokeninfoURL              string        `yaml:"oauth2-tokeninfo-url"`
 	Oauth2TokeninfoTimeout          time.Duration `yaml:"oauth2-tokeninfo-timeout"`
 	Oauth2TokenintrospectionTimeout time.Duration `yaml:"oauth2-tokenintrospect-timeout"`
+	Oauth2TokeninfoCacheSize        int           `yaml:"oauth2-tokeninfo-cache-size"`
+	Oauth2TokeninfoCacheTTL         time.Duration `yaml:"oauth2-tokeninfo-cache-ttl"`
+	Oauth2TokenintrospectCacheSize  int           `yaml:"oauth2-tokenintrospect-cache-size"`
+	Oauth2TokenintrospectCacheTTL   time.Duration `yaml:"oauth2-tokenintrospect-cache-ttl"`
 	WebhookTimeout                  time.Duration `yaml:"webhook-timeout"`
+	OpaTimeout                      time.Duration `yaml:"opa-timeout"`
 	OidcSecretsFile                 string        `yaml:"oidc-secrets-file"`
+	OidcSessionStore                string        `yaml:"oidc-session-store"`
+	CSRFSecretsFile                 string        `yaml:"csrf-secrets-file"`
 	CredentialPaths                 *listFlag     `yaml:"credentials-paths"`
 	CredentialsUpdateInterval       time.Duration `yaml:"credentials-update-interval"`
 
@@ -161,11 +200,15 @@ type Config struct {
 
 	// connections, timeouts:
 	WaitForHealthcheckInterval   time.Duration `yaml:"wait-for-healthcheck-interval"`
+	ShutdownTimeout              time.Duration `yaml:"shutdown-timeout"`
 	IdleConnsPerHost             int           `yaml:"idle-conns-num"`
 	CloseIdleConnsPeriod         time.Duration `yaml:"close-idle-conns-period"`
 	BackendFlushInterval         time.Duration `yaml:"backend-flush-interval"`
+	ResponseFlushInterval        time.Duration `yaml:"response-flush-interval"`
+	ServerSentEventsIdleTimeout  time.Duration `yaml:"server-sent-events-idle-timeout"`
 	ExperimentalUpgrade          bool          `yaml:"experimental-upgrade"`
 	ExperimentalUpgradeAudit     bool          `yaml:"experimental-upgrade-audit"`
+	WebSocketIdleTimeout         time.Duration `yaml:"websocket-idle-timeout"`
 	ReadTimeoutServer            time.Duration `yaml:"read-timeout-server"`
 	ReadHeaderTimeoutServer      time.Duration `yaml:"read-header-timeout-server"`
 	WriteTimeoutServer           time.Duration `yaml:"write-timeout-server"`
@@ -180,6 +223,8 @@ type Config struct {
 	ExpectContinueTimeoutBackend time.Duration `yaml:"expect-continue-timeout-backend"`
 	MaxIdleConnsBackend          int           `yaml:"max-idle-connection-backend"`
 	DisableHTTPKeepalives        bool          `yaml:"disable-http-keepalives"`
+	EnableHTTP2Backends          bool          `yaml:"enable-http2-backends"`
+	EgressProxy                  string        `yaml:"egress-proxy"`
 
 	// swarm:
 	EnableSwarm bool `yaml:"enable-swarm"`
@@ -212,6 +257,7 @@ const (
 	defaultBackendFlushInterval            = 20 * time.Millisecond
 	defaultLoadBalancerHealthCheckInterval = 0 // disabled
 	defaultMaxAuditBody                    = 1024
+	defaultGeoIPReloadInterval             = time.Minute
 
 	// metrics, logging:
 	defaultMetricsListener      = ":9911" // deprecated
@@ -235,7 +281,10 @@ const (
 	// Auth:
 	defaultOAuthTokeninfoTimeout          = 2 * time.Second
 	defaultOAuthTokenintrospectionTimeout = 2 * time.Second
+	defaultOAuthTokenCacheTTL             = 10 * time.Second
 	defaultWebhookTimeout                 = 2 * time.Second
+	defaultOpaTimeout                     = 2 * time.Second
+	defaultOidcSessionStore               = "cookie"
 	defaultCredentialsUpdateInterval      = 10 * time.Minute
 
 	// API Monitoring
@@ -253,6 +302,8 @@ const (
 	expectedBytesPerRequestUsage         = "bytes per request, that is used to calculate concurrency limits to buffer connection spikes"
 	maxTCPListenerConcurrencyUsage       = "sets hardcoded max for TCP listener concurrency, normally calculated based on available memory cgroups with max TODO"
 	maxTCPListenerQueueUsage             = "sets hardcoded max queue size for TCP listener, normally calculated 10x concurrency with max TODO:50k"
+	enableProxyProtocolUsage             = "enable accepting the PROXY protocol on the proxy listener, to preserve the client address behind TCP load balancers"
+	proxyProtocolTrustedCIDRsUsage       = "comma separated list of the networks of the load balancers, the PROXY protocol header is accepted only from these peers"
 	ignoreTrailingSlashUsage             = "flag indicating to ignore trailing slashes in paths when routing"
 	insecureUsage                        = "flag indicating to ignore the verification of the TLS certificates of the backend services"
 	proxyPreserveHostUsage               = "flag indicating to preserve the incoming request 'Host' header in the outgoing requests"
@@ -261,16 +312,32 @@ const (
 	debugEndpointUsage                   = "when this address is set, skipper starts an additional listener returning the original and transformed requests"
 	certPathTLSUsage                     = "the path on the local filesystem to the certificate file(s) (including any intermediates), multiple may be given comma separated"
 	keyPathTLSUsage                      = "the path on the local filesystem to the certificate's private key file(s), multiple keys may be given comma separated - the order must match the certs"
+	clientCAPathTLSUsage                 = "the path on the local filesystem to the CA certificate file(s) verifying the optional TLS client certificates, that can be matched by the ClientCert predicates, multiple may be given comma separated"
+	enableHTTP3Usage                     = "enable the experimental HTTP/3 listener next to the TLS listener, it is started only when a QUIC server is set with the HTTP3Server option of the skipper package"
+	http3AddressUsage                    = "the UDP address of the HTTP/3 listener, defaults to the proxy address"
 	versionUsage                         = "print Skipper version"
 	maxLoopbacksUsage                    = "maximum number of loopbacks for an incoming request, set to -1 to disable loopbacks"
+	maxLBRetriesUsage                    = "maximum number of retries against the next endpoint of a load balanced route when dialing the backend failed, set to -1 to disable retries"
 	defaultHTTPStatusUsage               = "default HTTP status used when no route is found for a request"
 	pluginDirUsage                       = "set the directory to load plugins from, default is ./"
 	loadBalancerHealthCheckIntervalUsage = "use to set the health checker interval to check healthiness of former dead or unhealthy routes"
 	reverseSourcePredicateUsage          = "reverse the order of finding the client IP from X-Forwarded-For header"
 	enableHopHeadersRemovalUsage         = "enables removal of Hop-Headers according to RFC-2616"
+	forwardedForUsage                    = "handling of the X-Forwarded-For header of the backend requests: keep, append, overwrite or drop"
+	forwardedProtoUsage                  = "handling of the X-Forwarded-Proto header of the backend requests: keep, append, overwrite or drop"
+	forwardedHostUsage                   = "handling of the X-Forwarded-Host header of the backend requests: keep, append, overwrite or drop"
+	forwardedHeaderUsage                 = "enables setting the RFC 7239 Forwarded header of the backend requests"
+	forwardedTrustedCIDRsUsage           = "comma separated list of the networks of trusted proxies, the forwarded headers received from other addresses are removed"
+	clientIPForwardedDepthUsage          = "when greater than zero, the ClientIP predicate takes the client address from the X-Forwarded-For header at this depth, instead of skipping the trusted proxies"
 	rfcPatchPathUsage                    = "patches the incoming request path to preserve uncoded reserved characters according to RFC 2616 and RFC 3986"
 	maxAuditBodyUsage                    = "sets the max body to read to log in the audit log body"
+	responseCacheMaxSizeUsage            = "sets the maximum total size in bytes of the responses stored by the cacheResponse filter"
+	responseCacheMaxEntrySizeUsage       = "sets the maximum size in bytes of a single response body stored by the cacheResponse filter"
+	geoIPCountryDatabaseUsage            = "path of the MaxMind database with the countries, e.g. GeoLite2-Country.mmdb, used by the Country predicate and the setGeoHeaders filter"
+	geoIPASNDatabaseUsage                = "path of the MaxMind database with the autonomous systems, e.g. GeoLite2-ASN.mmdb, used by the setGeoHeaders filter"
+	geoIPReloadIntervalUsage             = "sets how often the GeoIP database files are checked for changes"
 	enableRouteLIFOMetricsUsage          = "enable metrics for the individual route LIFO queues"
+	enableRouteFIFOMetricsUsage          = "enable metrics for the individual route FIFO queues"
 
 	// logging, metrics, tracing:
 	enablePrometheusMetricsUsage             = "switch to Prometheus metrics format to expose metrics. *Deprecated*: use metrics-flavour"
@@ -290,6 +357,7 @@ const (
 	allFiltersMetricsUsage                   = "enables reporting combined filter metrics for each route"
 	combinedResponseMetricsUsage             = "enables reporting combined response time metrics"
 	routeResponseMetricsUsage                = "enables reporting response time metrics for each route"
+	grpcStatusMetricsUsage                   = "enables reporting response time metrics of gRPC responses for each route and grpc-status"
 	routeBackendErrorCountersUsage           = "enables counting backend errors for each route"
 	routeStreamErrorCountersUsage            = "enables counting streaming errors for each route"
 	routeBackendMetricsUsage                 = "enables reporting backend response time metrics for each route"
@@ -337,6 +405,8 @@ const (
 	kubernetesNamespaceUsage         = "watch only this namespace for ingresses"
 	kubernetesEnableEastWestUsage    = "enables east-west communication, which automatically adds routes for Ingress objects with hostname <name>.<namespace>.skipper.cluster.local"
 	kubernetesEastWestDomainUsage    = "set the east-west domain, defaults to .skipper.cluster.local"
+	kubernetesEnableGatewayAPIUsage  = "enables the Gateway API resources, GatewayClass, Gateway and HTTPRoute, as a source of routes"
+	kubernetesGatewayControllerUsage = "the controller name of the GatewayClasses handled by skipper, defaults to zalando.org/skipper"
 
 	// Auth:
 	oauthURLUsage                        = "OAuth2 URL for Innkeeper authentication"
@@ -345,8 +415,15 @@ const (
 	oauth2TokeninfoURLUsage              = "sets the default tokeninfo URL to query information about an incoming OAuth2 token in oauth2Tokeninfo filters"
 	oauth2TokeninfoTimeoutUsage          = "sets the default tokeninfo request timeout duration to 2000ms"
 	oauth2TokenintrospectionTimeoutUsage = "sets the default tokenintrospection request timeout duration to 2000ms"
+	oauth2TokeninfoCacheSizeUsage        = "maximum number of tokeninfo responses cached, 0 disables the cache"
+	oauth2TokeninfoCacheTTLUsage         = "maximum duration a tokeninfo response is cached, invalid tokens are cached too, defaults to 10s"
+	oauth2TokenintrospectCacheSizeUsage  = "maximum number of tokenintrospection responses cached, 0 disables the cache"
+	oauth2TokenintrospectCacheTTLUsage   = "maximum duration a tokenintrospection response is cached, invalid tokens are cached too, defaults to 10s"
 	webhookTimeoutUsage                  = "sets the webhook request timeout duration, defaults to 2s"
+	opaTimeoutUsage                      = "sets the timeout duration of the requests to the OPA server in the opaAuthorizeRequest filters, defaults to 2s"
 	oidcSecretsFileUsage                 = "file storing the encryption key of the OID Connect token"
+	csrfSecretsFileUsage                 = "file storing the encryption key of the CSRF tokens, defaults to the file set by -oidc-secrets-file"
+	oidcSessionStoreUsage                = "storage of the OpenID Connect sessions, cookie or redis. The redis store uses the shards set by -swarm-redis-urls, and falls back to the cookies, when redis is not available"
 	credentialPathsUsage                 = "directories or files to watch for credentials to use by bearerinjector filter"
 	credentialsUpdateIntervalUsage       = "sets the interval to update secrets"
 
@@ -366,11 +443,15 @@ const (
 
 	// connections, timeouts:
 	waitForHealthcheckIntervalUsage   = "period waiting to become unhealthy in the loadbalancer pool in front of this instance, before shutdown triggered by SIGINT or SIGTERM"
+	shutdownTimeoutUsage              = "maximum time to wait for in-flight requests to complete after the listener was closed during shutdown, 0 means no limit"
 	idleConnsPerHostUsage             = "maximum idle connections per backend host"
 	closeIdleConnsPeriodUsage         = "sets the time interval of closing all idle connections. Not closing when 0"
 	backendFlushIntervalUsage         = "flush interval for upgraded proxy connections"
+	responseFlushIntervalUsage        = "flush interval for response bodies, when 0 the response is flushed after every read from the backend"
+	serverSentEventsIdleTimeoutUsage  = "maximum time a stream of server-sent events can be idle, before it is closed, 0 means no timeout"
 	experimentalUpgradeUsage          = "enable experimental feature to handle upgrade protocol requests"
 	experimentalUpgradeAuditUsage     = "enable audit logging of the request line and the messages during the experimental web socket upgrades"
+	webSocketIdleTimeoutUsage         = "maximum time an upgraded websocket connection can be idle, before it is closed, 0 means no timeout"
 	readTimeoutServerUsage            = "set ReadTimeout for http server connections"
 	readHeaderTimeoutServerUsage      = "set ReadHeaderTimeout for http server connections"
 	writeTimeoutServerUsage           = "set WriteTimeout for http server connections"
@@ -385,6 +466,8 @@ const (
 	expectContinueTimeoutBackendUsage = "sets the HTTP expect continue timeout for backend connections"
 	maxIdleConnsBackendUsage          = "sets the maximum idle connections for all backend connections"
 	disableHTTPKeepalivesUsage        = "forces backend to always create a new connection"
+	enableHTTP2BackendsUsage          = "enables HTTP/2 for TLS backend connections, e.g. for proxying gRPC"
+	egressProxyUsage                  = "URL of an HTTP proxy, through which the backend requests are sent, e.g. http://egress.example.org:3128. Requests to TLS backends are tunneled with CONNECT"
 
 	// swarm:
 	enableSwarmUsage                       = "enable swarm communication between nodes in a skipper fleet"
@@ -408,6 +491,8 @@ func NewConfig() *Config {
 	cfg := new(Config)
 	cfg.MetricsFlavour = commaListFlag("codahale", "prometheus")
 	cfg.StatusChecks = commaListFlag()
+	cfg.ForwardedTrustedCIDRs = commaListFlag()
+	cfg.ProxyProtocolTrustedCIDRs = commaListFlag()
 	cfg.FilterPlugins = newPluginFlag()
 	cfg.PredicatePlugins = newPluginFlag()
 	cfg.DataclientPlugins = newPluginFlag()
@@ -425,6 +510,9 @@ func NewConfig() *Config {
 	flag.IntVar(&cfg.ExpectedBytesPerRequest, "expected-bytes-per-request", defaultExpectedBytesPerRequest, expectedBytesPerRequestUsage)
 	flag.IntVar(&cfg.MaxTCPListenerConcurrency, "max-tcp-listener-concurrency", 0, maxTCPListenerConcurrencyUsage)
 	flag.IntVar(&cfg.MaxTCPListenerQueue, "max-tcp-listener-queue", 0, maxTCPListenerQueueUsage)
+	flag.BoolVar(&cfg.EnableProxyProtocol, "enable-proxy-protocol", false, enableProxyProtocolUsage)
+	flag.Var(cfg.ProxyProtocolTrustedCIDRs, "proxy-protocol-trusted-cidrs", proxyProtocolTrustedCIDRsUsage)
+	flag.Var(&cfg.Listeners, "listener", listenerUsage)
 	flag.BoolVar(&cfg.IgnoreTrailingSlash, "ignore-trailing-slash", false, ignoreTrailingSlashUsage)
 	flag.BoolVar(&cfg.Insecure, "insecure", false, insecureUsage)
 	flag.BoolVar(&cfg.ProxyPreserveHost, "proxy-preserve-host", false, proxyPreserveHostUsage)
@@ -433,21 +521,39 @@ func NewConfig() *Config {
 	flag.StringVar(&cfg.DebugListener, "debug-listener", "", debugEndpointUsage)
 	flag.StringVar(&cfg.CertPathTLS, "tls-cert", "", certPathTLSUsage)
 	flag.StringVar(&cfg.KeyPathTLS, "tls-key", "", keyPathTLSUsage)
+	flag.StringVar(&cfg.ClientCAPathTLS, "tls-client-ca", "", clientCAPathTLSUsage)
+	flag.BoolVar(&cfg.EnableHTTP3, "enable-http3", false, enableHTTP3Usage)
+	flag.StringVar(&cfg.HTTP3Address, "http3-address", "", http3AddressUsage)
 	flag.Var(cfg.StatusChecks, "status-checks", startupChecksUsage)
 	flag.BoolVar(&cfg.PrintVersion, "version", false, versionUsage)
 	flag.IntVar(&cfg.MaxLoopbacks, "max-loopbacks", proxy.DefaultMaxLoopbacks, maxLoopbacksUsage)
+	flag.IntVar(&cfg.MaxLBRetries, "max-lb-retries", proxy.DefaultMaxLBRetries, maxLBRetriesUsage)
 	flag.IntVar(&cfg.DefaultHTTPStatus, "default-http-status", http.StatusNotFound, defaultHTTPStatusUsage)
 	flag.StringVar(&cfg.PluginDir, "plugindir", "", pluginDirUsage)
 	flag.DurationVar(&cfg.LoadBalancerHealthCheckInterval, "lb-healthcheck-interval", defaultLoadBalancerHealthCheckInterval, loadBalancerHealthCheckIntervalUsage)
 	flag.BoolVar(&cfg.ReverseSourcePredicate, "reverse-source-predicate", false, reverseSourcePredicateUsage)
 	flag.BoolVar(&cfg.RemoveHopHeaders, "remove-hop-headers", false, enableHopHeadersRemovalUsage)
+	flag.StringVar(&cfg.ForwardedForString, "forwarded-for", "keep", forwardedForUsage)
+	flag.StringVar(&cfg.ForwardedProtoString, "forwarded-proto", "keep", forwardedProtoUsage)
+	flag.StringVar(&cfg.ForwardedHostString, "forwarded-host", "keep", forwardedHostUsage)
+	flag.BoolVar(&cfg.ForwardedHeader, "forwarded-header", false, forwardedHeaderUsage)
+	flag.Var(cfg.ForwardedTrustedCIDRs, "forwarded-trusted-cidrs", forwardedTrustedCIDRsUsage)
+	flag.IntVar(&cfg.ClientIPForwardedDepth, "client-ip-forwarded-depth", 0, clientIPForwardedDepthUsage)
 	flag.BoolVar(&cfg.RfcPatchPath, "rfc-patch-path", false, rfcPatchPathUsage)
 	flag.IntVar(&cfg.MaxAuditBody, "max-audit-body", defaultMaxAuditBody, maxAuditBodyUsage)
+	flag.Int64Var(&cfg.ResponseCacheMaxSize, "response-cache-max-size", cache.DefaultMaxSize, responseCacheMaxSizeUsage)
+	flag.Int64Var(&cfg.ResponseCacheMaxEntrySize, "response-cache-max-entry-size", cache.DefaultMaxEntrySize, responseCacheMaxEntrySizeUsage)
+	flag.StringVar(&cfg.GeoIPCountryDatabase, "geoip-country-database", "", geoIPCountryDatabaseUsage)
+	flag.StringVar(&cfg.GeoIPASNDatabase, "geoip-asn-database", "", geoIPASNDatabaseUsage)
+	flag.DurationVar(&cfg.GeoIPReloadInterval, "geoip-reload-interval", defaultGeoIPReloadInterval, geoIPReloadIntervalUsage)
 	flag.BoolVar(&cfg.EnableBreakers, "enable-breakers", false, enableBreakersUsage)
 	flag.Var(&cfg.Breakers, "breaker", breakerUsage)
 	flag.BoolVar(&cfg.EnableRatelimiters, "enable-ratelimits", false, enableRatelimitUsage)
 	flag.Var(&cfg.Ratelimits, "ratelimits", ratelimitUsage)
+	flag.BoolVar(&cfg.RatelimitResponseHeaders, "ratelimit-response-headers", false, ratelimitResponseHeadersUsage)
+	flag.BoolVar(&cfg.RatelimitLegacyHeaders, "ratelimit-legacy-headers", false, ratelimitLegacyHeadersUsage)
 	flag.BoolVar(&cfg.EnableRouteLIFOMetrics, "enable-route-lifo-metrics", false, enableRouteLIFOMetricsUsage)
+	flag.BoolVar(&cfg.EnableRouteFIFOMetrics, "enable-route-fifo-metrics", false, enableRouteFIFOMetricsUsage)
 	flag.Var(cfg.MetricsFlavour, "metrics-flavour", metricsFlavourUsage)
 	flag.Var(cfg.FilterPlugins, "filter-plugin", filterPluginUsage)
 	flag.Var(cfg.PredicatePlugins, "predicate-plugin", predicatePluginUsage)
@@ -472,6 +578,7 @@ func NewConfig() *Config {
 	flag.BoolVar(&cfg.AllFiltersMetrics, "all-filters-metrics", false, allFiltersMetricsUsage)
 	flag.BoolVar(&cfg.CombinedResponseMetrics, "combined-response-metrics", false, combinedResponseMetricsUsage)
 	flag.BoolVar(&cfg.RouteResponseMetrics, "route-response-metrics", false, routeResponseMetricsUsage)
+	flag.BoolVar(&cfg.GRPCStatusMetrics, "grpc-status-metrics", false, grpcStatusMetricsUsage)
 	flag.BoolVar(&cfg.RouteBackendErrorCounters, "route-backend-error-counters", false, routeBackendErrorCountersUsage)
 	flag.BoolVar(&cfg.RouteStreamErrorCounters, "route-stream-error-counters", false, routeStreamErrorCountersUsage)
 	flag.BoolVar(&cfg.RouteBackendMetrics, "route-backend-metrics", false, routeBackendMetricsUsage)
@@ -520,6 +627,8 @@ func NewConfig() *Config {
 	flag.StringVar(&cfg.KubernetesNamespace, "kubernetes-namespace", "", kubernetesNamespaceUsage)
 	flag.BoolVar(&cfg.KubernetesEnableEastWest, "enable-kubernetes-east-west", false, kubernetesEnableEastWestUsage)
 	flag.StringVar(&cfg.KubernetesEastWestDomain, "kubernetes-east-west-domain", "", kubernetesEastWestDomainUsage)
+	flag.BoolVar(&cfg.KubernetesEnableGatewayAPI, "enable-kubernetes-gateway-api", false, kubernetesEnableGatewayAPIUsage)
+	flag.StringVar(&cfg.KubernetesGatewayController, "kubernetes-gateway-controller-name", "", kubernetesGatewayControllerUsage)
 
 	// Auth:
 	flag.StringVar(&cfg.OauthURL, "oauth-url", "", oauthURLUsage)
@@ -528,8 +637,15 @@ func NewConfig() *Config {
 	flag.StringVar(&cfg.Oauth2TokeninfoURL, "oauth2-tokeninfo-url", "", oauth2TokeninfoURLUsage)
 	flag.DurationVar(&cfg.Oauth2TokeninfoTimeout, "oauth2-tokeninfo-timeout", defaultOAuthTokeninfoTimeout, oauth2TokeninfoTimeoutUsage)
 	flag.DurationVar(&cfg.Oauth2TokenintrospectionTimeout, "oauth2-tokenintrospect-timeout", defaultOAuthTokenintrospectionTimeout, oauth2TokenintrospectionTimeoutUsage)
+	flag.IntVar(&cfg.Oauth2TokeninfoCacheSize, "oauth2-tokeninfo-cache-size", 0, oauth2TokeninfoCacheSizeUsage)
+	flag.DurationVar(&cfg.Oauth2TokeninfoCacheTTL, "oauth2-tokeninfo-cache-ttl", defaultOAuthTokenCacheTTL, oauth2TokeninfoCacheTTLUsage)
+	flag.IntVar(&cfg.Oauth2TokenintrospectCacheSize, "oauth2-tokenintrospect-cache-size", 0, oauth2TokenintrospectCacheSizeUsage)
+	flag.DurationVar(&cfg.Oauth2TokenintrospectCacheTTL, "oauth2-tokenintrospect-cache-ttl", defaultOAuthTokenCacheTTL, oauth2TokenintrospectCacheTTLUsage)
 	flag.DurationVar(&cfg.WebhookTimeout, "webhook-timeout", defaultWebhookTimeout, webhookTimeoutUsage)
+	flag.DurationVar(&cfg.OpaTimeout, "opa-timeout", defaultOpaTimeout, opaTimeoutUsage)
 	flag.StringVar(&cfg.OidcSecretsFile, "oidc-secrets-file", "", oidcSecretsFileUsage)
+	flag.StringVar(&cfg.OidcSessionStore, "oidc-session-store", defaultOidcSessionStore, oidcSessionStoreUsage)
+	flag.StringVar(&cfg.CSRFSecretsFile, "csrf-secrets-file", "", csrfSecretsFileUsage)
 	flag.Var(cfg.CredentialPaths, "credentials-paths", credentialPathsUsage)
 	flag.DurationVar(&cfg.CredentialsUpdateInterval, "credentials-update-interval", defaultCredentialsUpdateInterval, credentialsUpdateIntervalUsage)
 
@@ -549,11 +665,15 @@ func NewConfig() *Config {
 
 	// Connections, timeouts:
 	flag.DurationVar(&cfg.WaitForHealthcheckInterval, "wait-for-healthcheck-interval", defaultWaitForHealthcheckInterval, waitForHealthcheckIntervalUsage)
+	flag.DurationVar(&cfg.ShutdownTimeout, "shutdown-timeout", 0, shutdownTimeoutUsage)
 	flag.IntVar(&cfg.IdleConnsPerHost, "idle-conns-num", proxy.DefaultIdleConnsPerHost, idleConnsPerHostUsage)
 	flag.DurationVar(&cfg.CloseIdleConnsPeriod, "close-idle-conns-period", proxy.DefaultCloseIdleConnsPeriod, closeIdleConnsPeriodUsage)
 	flag.DurationVar(&cfg.BackendFlushInterval, "backend-flush-interval", defaultBackendFlushInterval, backendFlushIntervalUsage)
+	flag.DurationVar(&cfg.ResponseFlushInterval, "response-flush-interval", 0, responseFlushIntervalUsage)
+	flag.DurationVar(&cfg.ServerSentEventsIdleTimeout, "server-sent-events-idle-timeout", 0, serverSentEventsIdleTimeoutUsage)
 	flag.BoolVar(&cfg.ExperimentalUpgrade, "experimental-upgrade", false, experimentalUpgradeUsage)
 	flag.BoolVar(&cfg.ExperimentalUpgradeAudit, "experimental-upgrade-audit", false, experimentalUpgradeAuditUsage)
+	flag.DurationVar(&cfg.WebSocketIdleTimeout, "websocket-idle-timeout", 0, webSocketIdleTimeoutUsage)
 	flag.DurationVar(&cfg.ReadTimeoutServer, "read-timeout-server", defaultReadTimeoutServer, readTimeoutServerUsage)
 	flag.DurationVar(&cfg.ReadHeaderTimeoutServer, "read-header-timeout-server", defaultReadHeaderTimeoutServer, readHeaderTimeoutServerUsage)
 	flag.DurationVar(&cfg.WriteTimeoutServer, "write-timeout-server", defaultWriteTimeoutServer, writeTimeoutServerUsage)
@@ -568,6 +688,8 @@ func NewConfig() *Config {
 	flag.DurationVar(&cfg.ExpectContinueTimeoutBackend, "expect-continue-timeout-backend", defaultExpectContinueTimeoutBackend, expectContinueTimeoutBackendUsage)
 	flag.IntVar(&cfg.MaxIdleConnsBackend, "max-idle-connection-backend", defaultMaxIdleConnsBackend, maxIdleConnsBackendUsage)
 	flag.BoolVar(&cfg.DisableHTTPKeepalives, "disable-http-keepalives", false, disableHTTPKeepalivesUsage)
+	flag.BoolVar(&cfg.EnableHTTP2Backends, "enable-http2-backends", false, enableHTTP2BackendsUsage)
+	flag.StringVar(&cfg.EgressProxy, "egress-proxy", "", egressProxyUsage)
 
 	// Swarm:
 	flag.BoolVar(&cfg.EnableSwarm, "enable-swarm", false, enableSwarmUsage)
@@ -630,9 +752,27 @@ func (c *Config) Parse() error {
 		return err
 	}
 
+	forwardedHeaders, err := c.parseForwardedHeaders()
+	if err != nil {
+		return err
+	}
+
+	egressProxyURL, err := c.parseEgressProxy()
+	if err != nil {
+		return err
+	}
+
+	if c.ProxyProtocolTrustedCIDRs != nil && len(c.ProxyProtocolTrustedCIDRs.values) > 0 {
+		if c.ProxyProtocolTrustedNetworks, err = snet.ParseCIDRs(c.ProxyProtocolTrustedCIDRs.values); err != nil {
+			return err
+		}
+	}
+
 	c.ApplicationLogLevel = logLevel
 	c.KubernetesPathMode = kubernetesPathMode
 	c.HistogramMetricBuckets = histogramBuckets
+	c.ForwardedHeaders = forwardedHeaders
+	c.EgressProxyURL = egressProxyURL
 
 	if c.ClientKeyFile != "" && c.ClientCertFile != "" {
 		certsFiles := strings.Split(c.ClientCertFile, ",")
@@ -673,22 +813,39 @@ func (c *Config) ToOptions() skipper.Options {
 		ExpectedBytesPerRequest:         c.ExpectedBytesPerRequest,
 		MaxTCPListenerConcurrency:       c.MaxTCPListenerConcurrency,
 		MaxTCPListenerQueue:             c.MaxTCPListenerQueue,
+		EnableProxyProtocol:             c.EnableProxyProtocol,
+		ProxyProtocolTrustedNetworks:    c.ProxyProtocolTrustedNetworks,
+		Listeners:                       c.Listeners,
 		IgnoreTrailingSlash:             c.IgnoreTrailingSlash,
 		DevMode:                         c.DevMode,
 		SupportListener:                 c.SupportListener,
 		DebugListener:                   c.DebugListener,
 		CertPathTLS:                     c.CertPathTLS,
 		KeyPathTLS:                      c.KeyPathTLS,
+		ClientCAPathTLS:                 c.ClientCAPathTLS,
+		EnableHTTP3:                     c.EnableHTTP3,
+		HTTP3Address:                    c.HTTP3Address,
 		MaxLoopbacks:                    c.MaxLoopbacks,
+		MaxLBRetries:                    c.MaxLBRetries,
 		DefaultHTTPStatus:               c.DefaultHTTPStatus,
+		ForwardedHeaders:                c.ForwardedHeaders,
+		ClientIPForwardedDepth:          c.ClientIPForwardedDepth,
 		LoadBalancerHealthCheckInterval: c.LoadBalancerHealthCheckInterval,
 		ReverseSourcePredicate:          c.ReverseSourcePredicate,
 		MaxAuditBody:                    c.MaxAuditBody,
+		ResponseCacheMaxSize:            c.ResponseCacheMaxSize,
+		ResponseCacheMaxEntrySize:       c.ResponseCacheMaxEntrySize,
+		GeoIPCountryDatabase:            c.GeoIPCountryDatabase,
+		GeoIPASNDatabase:                c.GeoIPASNDatabase,
+		GeoIPReloadInterval:             c.GeoIPReloadInterval,
 		EnableBreakers:                  c.EnableBreakers,
 		BreakerSettings:                 c.Breakers,
 		EnableRatelimiters:              c.EnableRatelimiters,
 		RatelimitSettings:               c.Ratelimits,
+		RatelimitResponseHeaders:        c.RatelimitResponseHeaders,
+		RatelimitLegacyHeaders:          c.RatelimitLegacyHeaders,
 		EnableRouteLIFOMetrics:          c.EnableRouteLIFOMetrics,
+		EnableRouteFIFOMetrics:          c.EnableRouteFIFOMetrics,
 		MetricsFlavours:                 c.MetricsFlavour.values,
 		FilterPlugins:                   c.FilterPlugins.values,
 		PredicatePlugins:                c.PredicatePlugins.values,
@@ -714,6 +871,7 @@ func (c *Config) ToOptions() skipper.Options {
 		EnableAllFiltersMetrics:             c.AllFiltersMetrics,
 		EnableCombinedResponseMetrics:       c.CombinedResponseMetrics,
 		EnableRouteResponseMetrics:          c.RouteResponseMetrics,
+		EnableGRPCStatusMetrics:             c.GRPCStatusMetrics,
 		EnableRouteBackendErrorsCounters:    c.RouteBackendErrorCounters,
 		EnableRouteStreamingErrorsCounters:  c.RouteStreamErrorCounters,
 		EnableRouteBackendMetrics:           c.RouteBackendMetrics,
@@ -763,6 +921,8 @@ func (c *Config) ToOptions() skipper.Options {
 		KubernetesNamespace:         c.KubernetesNamespace,
 		KubernetesEnableEastWest:    c.KubernetesEnableEastWest,
 		KubernetesEastWestDomain:    c.KubernetesEastWestDomain,
+		KubernetesEnableGatewayAPI:  c.KubernetesEnableGatewayAPI,
+		KubernetesGatewayController: c.KubernetesGatewayController,
 
 		// API Monitoring:
 		ApiUsageMonitoringEnable:                c.ApiUsageMonitoringEnable,
@@ -780,18 +940,29 @@ func (c *Config) ToOptions() skipper.Options {
 		OAuthTokeninfoURL:              c.Oauth2TokeninfoURL,
 		OAuthTokeninfoTimeout:          c.Oauth2TokeninfoTimeout,
 		OAuthTokenintrospectionTimeout: c.Oauth2TokenintrospectionTimeout,
+		OAuthTokeninfoCacheSize:        c.Oauth2TokeninfoCacheSize,
+		OAuthTokeninfoCacheTTL:         c.Oauth2TokeninfoCacheTTL,
+		OAuthTokenintrospectCacheSize:  c.Oauth2TokenintrospectCacheSize,
+		OAuthTokenintrospectCacheTTL:   c.Oauth2TokenintrospectCacheTTL,
 		WebhookTimeout:                 c.WebhookTimeout,
+		OpaTimeout:                     c.OpaTimeout,
 		OIDCSecretsFile:                c.OidcSecretsFile,
+		OIDCSessionStore:               c.OidcSessionStore,
+		CSRFSecretsFile:                c.CSRFSecretsFile,
 		CredentialsPaths:               c.CredentialPaths.values,
 		CredentialsUpdateInterval:      c.CredentialsUpdateInterval,
 
 		// connections, timeouts:
 		WaitForHealthcheckInterval:   c.WaitForHealthcheckInterval,
+		ShutdownTimeout:              c.ShutdownTimeout,
 		IdleConnectionsPerHost:       c.IdleConnsPerHost,
 		CloseIdleConnsPeriod:         c.CloseIdleConnsPeriod,
 		BackendFlushInterval:         c.BackendFlushInterval,
+		ResponseFlushInterval:        c.ResponseFlushInterval,
+		ServerSentEventsIdleTimeout:  c.ServerSentEventsIdleTimeout,
 		ExperimentalUpgrade:          c.ExperimentalUpgrade,
 		ExperimentalUpgradeAudit:     c.ExperimentalUpgradeAudit,
+		WebSocketIdleTimeout:         c.WebSocketIdleTimeout,
 		ReadTimeoutServer:            c.ReadTimeoutServer,
 		ReadHeaderTimeoutServer:      c.ReadHeaderTimeoutServer,
 		WriteTimeoutServer:           c.WriteTimeoutServer,
@@ -806,6 +977,8 @@ func (c *Config) ToOptions() skipper.Options {
 		ExpectContinueTimeoutBackend: c.ExpectContinueTimeoutBackend,
 		MaxIdleConnsBackend:          c.MaxIdleConnsBackend,
 		DisableHTTPKeepalives:        c.DisableHTTPKeepalives,
+		EnableHTTP2Backends:          c.EnableHTTP2Backends,
+		EgressProxy:                  c.EgressProxyURL,
 
 		// swarm:
 		EnableSwarm: c.EnableSwarm,
@@ -874,3 +1047,43 @@ func (c *Config) parseHistogramBuckets() ([]float64, error) {
 	sort.Float64s(result)
 	return result, nil
 }
+
+func (c *Config) parseForwardedHeaders() (h snet.ForwardedHeaders, err error) {
+	if h.For, err = snet.ParseForwardedHeaderMode(c.ForwardedForString); err != nil {
+		return
+	}
+
+	if h.Proto, err = snet.ParseForwardedHeaderMode(c.ForwardedProtoString); err != nil {
+		return
+	}
+
+	if h.Host, err = snet.ParseForwardedHeaderMode(c.ForwardedHostString); err != nil {
+		return
+	}
+
+	if c.ForwardedTrustedCIDRs != nil && len(c.ForwardedTrustedCIDRs.values) > 0 {
+		if h.TrustedProxies, err = snet.ParseCIDRs(c.ForwardedTrustedCIDRs.values); err != nil {
+			return
+		}
+	}
+
+	h.Forwarded = c.ForwardedHeader
+	return
+}
+
+func (c *Config) parseEgressProxy() (*url.URL, error) {
+	if c.EgressProxy == "" {
+		return nil, nil
+	}
+
+	u, err := url.Parse(c.EgressProxy)
+	if err != nil {
+		return nil, fmt.Errorf("invalid egress-proxy: %v", err)
+	}
+
+	if (u.Scheme != "http" && u.Scheme != "https") || u.Host == "" {
+		return nil, fmt.Errorf("invalid egress-proxy, expected http or https URL: %s", c.EgressProxy)
+	}
+
+	return u, nil
+}
@@ -37,11 +37,20 @@ func Test_NewConfig(t *testing.T) {
 				ConfigFile:                              "test.yaml",
 				Address:                                 "localhost:8080",
 				StatusChecks:                            nil,
+				ForwardedForString:                      "keep",
+				ForwardedProtoString:                    "keep",
+				ForwardedHostString:                     "keep",
+				ForwardedTrustedCIDRs:                   commaListFlag(),
+				ProxyProtocolTrustedCIDRs:               commaListFlag(),
 				ExpectedBytesPerRequest:                 50 * 1024,
 				SupportListener:                         ":9911",
 				MaxLoopbacks:                            12,
+				MaxLBRetries:                            1,
 				DefaultHTTPStatus:                       404,
 				MaxAuditBody:                            1024,
+				ResponseCacheMaxSize:                    64 << 20,
+				ResponseCacheMaxEntrySize:               1 << 20,
+				GeoIPReloadInterval:                     time.Minute,
 				MetricsFlavour:                          commaListFlag("codahale", "prometheus"),
 				FilterPlugins:                           newPluginFlag(),
 				PredicatePlugins:                        newPluginFlag(),
@@ -69,7 +78,11 @@ func Test_NewConfig(t *testing.T) {
 				KubernetesPathModeString:                "kubernetes-ingress",
 				Oauth2TokeninfoTimeout:                  2 * time.Second,
 				Oauth2TokenintrospectionTimeout:         2 * time.Second,
+				Oauth2TokeninfoCacheTTL:                 10 * time.Second,
+				Oauth2TokenintrospectCacheTTL:           10 * time.Second,
 				WebhookTimeout:                          2 * time.Second,
+				OpaTimeout:                              2 * time.Second,
+				OidcSessionStore:                        "cookie",
 				CredentialPaths:                         commaListFlag(),
 				CredentialsUpdateInterval:               10 * time.Minute,
 				ApiUsageMonitoringClientKeys:            "sub",
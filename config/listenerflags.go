@@ -0,0 +1,77 @@
+package config
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+
+	"github.com/zalando/skipper"
+)
+
+const listenerUsage = `set an additional proxy listener, e.g. -listener name=internal,address=:9443,tls-cert=/path/cert.pem,tls-key=/path/key.pem
+	possible listener properties:
+	name: the name of the listener, routes can be scoped to it with the Listener("name") predicate
+	address: the network address to listen on
+	tls-cert: path of the TLS certificate, when not set, the listener serves plain HTTP
+	tls-key: path of the TLS key`
+
+type listenerFlags []skipper.ListenerOptions
+
+var errInvalidListenerConfig = errors.New("invalid listener config (name and address are required, tls-cert and tls-key need to be set together)")
+
+func (l listenerFlags) String() string {
+	s := make([]string, len(l))
+	for i, li := range l {
+		s[i] = fmt.Sprintf("name=%s,address=%s", li.Name, li.Address)
+		if li.CertPathTLS != "" {
+			s[i] += fmt.Sprintf(",tls-cert=%s,tls-key=%s", li.CertPathTLS, li.KeyPathTLS)
+		}
+	}
+
+	return strings.Join(s, "\n")
+}
+
+func (l *listenerFlags) Set(value string) error {
+	var lo skipper.ListenerOptions
+
+	vs := strings.Split(value, ",")
+	for _, vi := range vs {
+		kv := strings.Split(vi, "=")
+		if len(kv) != 2 {
+			return errInvalidListenerConfig
+		}
+
+		switch kv[0] {
+		case "name":
+			lo.Name = kv[1]
+		case "address":
+			lo.Address = kv[1]
+		case "tls-cert":
+			lo.CertPathTLS = kv[1]
+		case "tls-key":
+			lo.KeyPathTLS = kv[1]
+		default:
+			return errInvalidListenerConfig
+		}
+	}
+
+	return l.add(lo)
+}
+
+func (l *listenerFlags) UnmarshalYAML(unmarshal func(interface{}) error) error {
+	var lo skipper.ListenerOptions
+	if err := unmarshal(&lo); err != nil {
+		return err
+	}
+
+	return l.add(lo)
+}
+
+func (l *listenerFlags) add(lo skipper.ListenerOptions) error {
+	if lo.Name == "" || lo.Address == "" || (lo.CertPathTLS == "") != (lo.KeyPathTLS == "") {
+		return errInvalidListenerConfig
+	}
+
+	*l = append(*l, lo)
+	return nil
+}
@@ -0,0 +1,125 @@
+package config
+
+import (
+	"testing"
+
+	"gopkg.in/yaml.v2"
+
+	"github.com/google/go-cmp/cmp"
+	"github.com/zalando/skipper"
+)
+
+func Test_listenerFlags_String(t *testing.T) {
+	l := &listenerFlags{
+		skipper.ListenerOptions{Name: "internal", Address: ":9090"},
+		skipper.ListenerOptions{Name: "public", Address: ":9443", CertPathTLS: "cert.pem", KeyPathTLS: "key.pem"},
+	}
+
+	want := "name=internal,address=:9090\nname=public,address=:9443,tls-cert=cert.pem,tls-key=key.pem"
+	if got := l.String(); got != want {
+		t.Errorf("listenerFlags.String() = %v, want %v", got, want)
+	}
+}
+
+func Test_listenerFlags_Set(t *testing.T) {
+	tests := []struct {
+		name    string
+		args    string
+		wantErr bool
+		want    skipper.ListenerOptions
+	}{
+		{
+			name: "test plain listener",
+			args: "name=internal,address=:9090",
+			want: skipper.ListenerOptions{Name: "internal", Address: ":9090"},
+		},
+		{
+			name: "test tls listener",
+			args: "name=public,address=:9443,tls-cert=cert.pem,tls-key=key.pem",
+			want: skipper.ListenerOptions{Name: "public", Address: ":9443", CertPathTLS: "cert.pem", KeyPathTLS: "key.pem"},
+		},
+		{
+			name:    "test missing name",
+			args:    "address=:9090",
+			wantErr: true,
+		},
+		{
+			name:    "test missing address",
+			args:    "name=internal",
+			wantErr: true,
+		},
+		{
+			name:    "test missing tls key",
+			args:    "name=public,address=:9443,tls-cert=cert.pem",
+			wantErr: true,
+		},
+		{
+			name:    "test unknown property",
+			args:    "name=internal,address=:9090,foo=bar",
+			wantErr: true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			lp := &listenerFlags{}
+
+			if err := lp.Set(tt.args); (err != nil) != tt.wantErr {
+				t.Errorf("listenerFlags.Set() error = %v, wantErr %v", err, tt.wantErr)
+			}
+
+			if !tt.wantErr {
+				l := *lp
+				if len(l) != 1 {
+					t.Fatalf("Failed to have listener created: %d != 1", len(l))
+				}
+
+				if cmp.Equal(l[0], tt.want) == false {
+					t.Errorf("listenerFlags.Set() got v, want v, %v", cmp.Diff(l[0], tt.want))
+				}
+			}
+		})
+	}
+}
+
+func Test_listenerFlags_UnmarshalYAML(t *testing.T) {
+	tests := []struct {
+		name    string
+		yml     string
+		wantErr bool
+		want    skipper.ListenerOptions
+	}{
+		{
+			name: "test tls listener",
+			yml: `name: public
+address: :9443
+tls-cert: cert.pem
+tls-key: key.pem`,
+			want: skipper.ListenerOptions{Name: "public", Address: ":9443", CertPathTLS: "cert.pem", KeyPathTLS: "key.pem"},
+		},
+		{
+			name:    "test missing address",
+			yml:     `name: public`,
+			wantErr: true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			lp := &listenerFlags{}
+
+			if err := yaml.Unmarshal([]byte(tt.yml), lp); (err != nil) != tt.wantErr {
+				t.Errorf("listenerFlags.UnmarshalYAML() error = %v, wantErr %v", err, tt.wantErr)
+			}
+
+			if !tt.wantErr {
+				l := *lp
+				if len(l) != 1 {
+					t.Fatalf("Failed to have listener created: %d != 1", len(l))
+				}
+
+				if cmp.Equal(l[0], tt.want) == false {
+					t.Errorf("listenerFlags.UnmarshalYAML() got v, want v, %v", cmp.Diff(l[0], tt.want))
+				}
+			}
+		})
+	}
+}
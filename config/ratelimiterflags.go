@@ -20,6 +20,10 @@ const ratelimitUsage = `set global rate limit settings, e.g. -ratelimit type=loc
 
 const enableRatelimitUsage = `enable ratelimit`
 
+const ratelimitResponseHeadersUsage = `set the RateLimit-Limit, RateLimit-Remaining and RateLimit-Reset headers in the responses to the requests allowed by the route rate limits, when the rate limit can tell the remaining quota. The rejected requests get these headers in either case`
+
+const ratelimitLegacyHeadersUsage = `set the X-RateLimit-Limit, X-RateLimit-Remaining and X-RateLimit-Reset headers in addition to the RateLimit headers`
+
 type ratelimitFlags []ratelimit.Settings
 
 var errInvalidRatelimitConfig = errors.New("invalid ratelimit config (allowed values are: client, service or disabled)")
@@ -17,23 +17,28 @@ import (
 )
 
 type namespace struct {
-	services    []byte
-	ingresses   []byte
-	routeGroups []byte
-	endpoints   []byte
+	services       []byte
+	ingresses      []byte
+	routeGroups    []byte
+	endpoints      []byte
+	gatewayClasses []byte
+	gateways       []byte
+	httpRoutes     []byte
 }
 
 type testAPIOptions struct {
 	FailOn             []string `yaml:"failOn"`
 	DisableRouteGroups bool     `yaml:"disableRouteGroups"`
+	DisableGatewayAPI  bool     `yaml:"disableGatewayAPI"`
 }
 
 type api struct {
-	failOn       map[string]bool
-	namespaces   map[string]namespace
-	all          namespace
-	pathRx       *regexp.Regexp
-	resourceList []byte
+	failOn                 map[string]bool
+	namespaces             map[string]namespace
+	all                    namespace
+	pathRx                 *regexp.Regexp
+	resourceList           []byte
+	gatewayAPIResourceList []byte
 }
 
 var errInvalidFixture = errors.New("invalid fixture")
@@ -69,6 +74,18 @@ func initNamespace(kinds map[string][]interface{}) (ns namespace, err error) {
 		return
 	}
 
+	if err = itemsJSON(&ns.gatewayClasses, kinds["GatewayClass"]); err != nil {
+		return
+	}
+
+	if err = itemsJSON(&ns.gateways, kinds["Gateway"]); err != nil {
+		return
+	}
+
+	if err = itemsJSON(&ns.httpRoutes, kinds["HTTPRoute"]); err != nil {
+		return
+	}
+
 	return
 }
 
@@ -87,7 +104,7 @@ func newAPI(o testAPIOptions, specs ...io.Reader) (*api, error) {
 	a := &api{
 		namespaces: make(map[string]namespace),
 		pathRx: regexp.MustCompile(
-			"(/namespaces/([^/]+))?/(services|ingresses|routegroups|endpoints)",
+			"(/namespaces/([^/]+))?/(services|ingresses|routegroups|endpoints|gatewayclasses|gateways|httproutes)",
 		),
 		failOn: make(map[string]bool),
 	}
@@ -108,6 +125,18 @@ func newAPI(o testAPIOptions, specs ...io.Reader) (*api, error) {
 
 	a.resourceList = clrb
 
+	var gwr clusterResourceList
+	if !o.DisableGatewayAPI {
+		gwr.Items = append(gwr.Items, &clusterResource{Name: httpRoutesName})
+	}
+
+	gwrb, err := json.Marshal(gwr)
+	if err != nil {
+		return nil, err
+	}
+
+	a.gatewayAPIResourceList = gwrb
+
 	namespaces := make(map[string]map[string][]interface{})
 	all := make(map[string][]interface{})
 
@@ -182,6 +211,11 @@ func (a *api) ServeHTTP(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
+	if r.URL.Path == gatewayAPIResourcesURI {
+		w.Write(a.gatewayAPIResourceList)
+		return
+	}
+
 	parts := a.pathRx.FindStringSubmatch(r.URL.Path)
 	if len(parts) == 0 {
 		w.WriteHeader(http.StatusNotFound)
@@ -203,6 +237,12 @@ func (a *api) ServeHTTP(w http.ResponseWriter, r *http.Request) {
 		b = ns.routeGroups
 	case "endpoints":
 		b = ns.endpoints
+	case "gatewayclasses":
+		b = ns.gatewayClasses
+	case "gateways":
+		b = ns.gateways
+	case "httproutes":
+		b = ns.httpRoutes
 	default:
 		w.WriteHeader(http.StatusNotFound)
 		return
@@ -29,6 +29,13 @@ const (
 	defaultKubernetesURL       = "http://localhost:8001"
 	ingressesNamespaceFmt      = "/apis/extensions/v1beta1/namespaces/%s/ingresses"
 	routeGroupsNamespaceFmt    = "/apis/zalando.org/v1/namespaces/%s/routegroups"
+	gatewayAPIResourcesURI     = "/apis/gateway.networking.k8s.io/v1beta1"
+	httpRoutesName             = "httproutes"
+	gatewayClassesClusterURI   = "/apis/gateway.networking.k8s.io/v1beta1/gatewayclasses"
+	gatewaysClusterURI         = "/apis/gateway.networking.k8s.io/v1beta1/gateways"
+	httpRoutesClusterURI       = "/apis/gateway.networking.k8s.io/v1beta1/httproutes"
+	gatewaysNamespaceFmt       = "/apis/gateway.networking.k8s.io/v1beta1/namespaces/%s/gateways"
+	httpRoutesNamespaceFmt     = "/apis/gateway.networking.k8s.io/v1beta1/namespaces/%s/httproutes"
 	servicesNamespaceFmt       = "/api/v1/namespaces/%s/services"
 	endpointsNamespaceFmt      = "/api/v1/namespaces/%s/endpoints"
 	serviceAccountDir          = "/var/run/secrets/kubernetes.io/serviceaccount/"
@@ -37,14 +44,17 @@ const (
 )
 
 type clusterClient struct {
-	ingressesURI   string
-	routeGroupsURI string
-	servicesURI    string
-	endpointsURI   string
-	ingressClass   *regexp.Regexp
-	token          string
-	httpClient     *http.Client
-	apiURL         string
+	ingressesURI     string
+	routeGroupsURI   string
+	gatewaysURI      string
+	httpRoutesURI    string
+	servicesURI      string
+	endpointsURI     string
+	ingressClass     *regexp.Regexp
+	enableGatewayAPI bool
+	token            string
+	httpClient       *http.Client
+	apiURL           string
 }
 
 var (
@@ -133,14 +143,17 @@ func newClusterClient(o Options, apiURL, ingCls string, quit <-chan struct{}) (*
 	}
 
 	c := &clusterClient{
-		ingressesURI:   ingressesClusterURI,
-		routeGroupsURI: routeGroupsClusterURI,
-		servicesURI:    servicesClusterURI,
-		endpointsURI:   endpointsClusterURI,
-		ingressClass:   ingClsRx,
-		httpClient:     httpClient,
-		token:          token,
-		apiURL:         apiURL,
+		ingressesURI:     ingressesClusterURI,
+		routeGroupsURI:   routeGroupsClusterURI,
+		gatewaysURI:      gatewaysClusterURI,
+		httpRoutesURI:    httpRoutesClusterURI,
+		servicesURI:      servicesClusterURI,
+		endpointsURI:     endpointsClusterURI,
+		ingressClass:     ingClsRx,
+		enableGatewayAPI: o.KubernetesEnableGatewayAPI,
+		httpClient:       httpClient,
+		token:            token,
+		apiURL:           apiURL,
 	}
 
 	if o.KubernetesNamespace != "" {
@@ -153,6 +166,8 @@ func newClusterClient(o Options, apiURL, ingCls string, quit <-chan struct{}) (*
 func (c *clusterClient) setNamespace(namespace string) {
 	c.ingressesURI = fmt.Sprintf(ingressesNamespaceFmt, namespace)
 	c.routeGroupsURI = fmt.Sprintf(routeGroupsNamespaceFmt, namespace)
+	c.gatewaysURI = fmt.Sprintf(gatewaysNamespaceFmt, namespace)
+	c.httpRoutesURI = fmt.Sprintf(httpRoutesNamespaceFmt, namespace)
 	c.servicesURI = fmt.Sprintf(servicesNamespaceFmt, namespace)
 	c.endpointsURI = fmt.Sprintf(endpointsNamespaceFmt, namespace)
 }
@@ -225,6 +240,25 @@ func (c *clusterClient) clusterHasRouteGroups() (bool, error) {
 	return false, nil
 }
 
+func (c *clusterClient) clusterHasGatewayAPI() (bool, error) {
+	var crl clusterResourceList
+	if err := c.getJSON(gatewayAPIResourcesURI, &crl); err != nil {
+		if err == errResourceNotFound {
+			return false, nil
+		}
+
+		return false, err
+	}
+
+	for _, cr := range crl.Items {
+		if cr.Name == httpRoutesName {
+			return true, nil
+		}
+	}
+
+	return false, nil
+}
+
 // filterIngressesByClass will filter only the ingresses that have the valid class, these are
 // the defined one, empty string class or not class at all
 func (c *clusterClient) filterIngressesByClass(items []*ingressItem) []*ingressItem {
@@ -297,6 +331,39 @@ func (c *clusterClient) loadRouteGroups() ([]*routeGroupItem, error) {
 	return rgs, nil
 }
 
+// loadGatewayAPI loads the Gateway API resources. The GatewayClasses are
+// cluster scoped, so they are loaded from the whole cluster even when the
+// client is limited to a namespace.
+func (c *clusterClient) loadGatewayAPI() ([]*gatewayClassItem, []*gatewayItem, []*httpRouteItem, error) {
+	var gcl gatewayClassList
+	if err := c.getJSON(gatewayClassesClusterURI, &gcl); err != nil {
+		return nil, nil, nil, err
+	}
+
+	var gl gatewayList
+	if err := c.getJSON(c.gatewaysURI, &gl); err != nil {
+		return nil, nil, nil, err
+	}
+
+	var hrl httpRouteList
+	if err := c.getJSON(c.httpRoutesURI, &hrl); err != nil {
+		return nil, nil, nil, err
+	}
+
+	routes := make([]*httpRouteItem, 0, len(hrl.Items))
+	for _, i := range hrl.Items {
+		if err := i.validate(); err != nil {
+			log.Errorf("[httproute] %v", err)
+			continue
+		}
+
+		routes = append(routes, i)
+	}
+
+	sortByMetadata(routes, func(i int) *metadata { return routes[i].Metadata })
+	return gcl.Items, gl.Items, routes, nil
+}
+
 func (c *clusterClient) loadServices() (map[resourceID]*service, error) {
 	var services serviceList
 	if err := c.getJSON(c.servicesURI, &services); err != nil {
@@ -354,6 +421,22 @@ func (c *clusterClient) fetchClusterState() (*clusterState, error) {
 		}
 	}
 
+	var (
+		gatewayClasses []*gatewayClassItem
+		gateways       []*gatewayItem
+		httpRoutes     []*httpRouteItem
+	)
+
+	if c.enableGatewayAPI {
+		if hasGatewayAPI, err := c.clusterHasGatewayAPI(); err != nil {
+			log.Errorf("Error while checking the Gateway API resource types: %v.", err)
+		} else if hasGatewayAPI {
+			if gatewayClasses, gateways, httpRoutes, err = c.loadGatewayAPI(); err != nil {
+				return nil, err
+			}
+		}
+	}
+
 	services, err := c.loadServices()
 	if err != nil {
 		return nil, err
@@ -367,6 +450,9 @@ func (c *clusterClient) fetchClusterState() (*clusterState, error) {
 	return &clusterState{
 		ingresses:       ingresses,
 		routeGroups:     routeGroups,
+		gatewayClasses:  gatewayClasses,
+		gateways:        gateways,
+		httpRoutes:      httpRoutes,
 		services:        services,
 		endpoints:       endpoints,
 		cachedEndpoints: make(map[endpointID][]string),
@@ -10,6 +10,9 @@ import (
 type clusterState struct {
 	ingresses       []*ingressItem
 	routeGroups     []*routeGroupItem
+	gatewayClasses  []*gatewayClassItem
+	gateways        []*gatewayItem
+	httpRoutes      []*httpRouteItem
 	services        map[resourceID]*service
 	endpoints       map[resourceID]*endpoint
 	cachedEndpoints map[endpointID][]string
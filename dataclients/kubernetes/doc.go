@@ -24,6 +24,13 @@ the healthcheck of the cluster, and the Skipper process receives the TERM signal
 immediately, but will start reporting failures on healthcheck requests. Until it gets killed by the kubelet,
 Skipper keeps serving the requests in this case.
 
+Gateway API
+
+When the KubernetesEnableGatewayAPI option is set, the client generates routes also from the HTTPRoute resources
+attached to the Gateways, whose GatewayClass has the configured controller name, by default zalando.org/skipper.
+
+See: https://opensource.zalando.com/skipper/kubernetes/gateway-api/
+
 Example - Ingress
 
 A basic ingress specification:
@@ -31,10 +31,12 @@ type fixtureSet struct {
 }
 
 type kubeOptionsParser struct {
-	EastWest          bool   `yaml:"eastWest"`
-	EastWestDomain    string `yaml:"eastWestDomain"`
-	HTTPSRedirect     bool   `yaml:"httpsRedirect"`
-	HTTPSRedirectCode int    `yaml:"httpsRedirectCode"`
+	EastWest              bool   `yaml:"eastWest"`
+	EastWestDomain        string `yaml:"eastWestDomain"`
+	HTTPSRedirect         bool   `yaml:"httpsRedirect"`
+	HTTPSRedirectCode     int    `yaml:"httpsRedirectCode"`
+	GatewayAPI            bool   `yaml:"gatewayAPI"`
+	GatewayControllerName string `yaml:"gatewayControllerName"`
 }
 
 func baseNoExt(n string) string {
@@ -185,6 +187,8 @@ func testFixture(t *testing.T, f fixtureSet) {
 		o.KubernetesEastWestDomain = kop.EastWestDomain
 		o.ProvideHTTPSRedirect = kop.HTTPSRedirect
 		o.HTTPSRedirectCode = kop.HTTPSRedirectCode
+		o.KubernetesEnableGatewayAPI = kop.GatewayAPI
+		o.GatewayControllerName = kop.GatewayControllerName
 	}
 
 	o.KubernetesURL = s.URL
@@ -0,0 +1,521 @@
+package kubernetes
+
+import (
+	"fmt"
+	"net"
+	"regexp"
+	"strconv"
+	"strings"
+
+	log "github.com/sirupsen/logrus"
+	"github.com/zalando/skipper/eskip"
+)
+
+const defaultGatewayControllerName = "zalando.org/skipper"
+
+type gatewayAPI struct {
+	controllerName string
+}
+
+type httpRouteContext struct {
+	clusterState   *clusterState
+	defaultFilters defaultFilters
+	route          *httpRouteItem
+	namespace      string
+	hostRx         string
+}
+
+func newGatewayAPI(o Options) *gatewayAPI {
+	controllerName := o.GatewayControllerName
+	if controllerName == "" {
+		controllerName = defaultGatewayControllerName
+	}
+
+	return &gatewayAPI{controllerName: controllerName}
+}
+
+func gwRouteID(m *metadata, ruleIndex, matchIndex, backendIndex int) string {
+	return fmt.Sprintf(
+		"kube_gw__%s__%s__%d_%d_%d",
+		toSymbol(namespaceString(m.Namespace)),
+		toSymbol(m.Name),
+		ruleIndex,
+		matchIndex,
+		backendIndex,
+	)
+}
+
+// gateways returns the Gateways of the classes handled by the controller
+func (g *gatewayAPI) gateways(s *clusterState) map[resourceID]*gatewayItem {
+	classes := make(map[string]bool)
+	for _, c := range s.gatewayClasses {
+		if c.Metadata != nil && c.Spec != nil && c.Spec.ControllerName == g.controllerName {
+			classes[c.Metadata.Name] = true
+		}
+	}
+
+	gateways := make(map[resourceID]*gatewayItem)
+	for _, gw := range s.gateways {
+		if gw.Metadata != nil && gw.Spec != nil && classes[gw.Spec.GatewayClassName] {
+			gateways[gw.Metadata.toResourceID()] = gw
+		}
+	}
+
+	return gateways
+}
+
+func (l *gatewayListener) allowsNamespace(gatewayNamespace, routeNamespace string) bool {
+	from := namespacesFromSame
+	if l.AllowedRoutes != nil && l.AllowedRoutes.Namespaces != nil && l.AllowedRoutes.Namespaces.From != "" {
+		from = l.AllowedRoutes.Namespaces.From
+	}
+
+	switch from {
+	case namespacesFromAll:
+		return true
+	case namespacesFromSame:
+		return gatewayNamespace == routeNamespace
+	default:
+		return false
+	}
+}
+
+// listeners returns the Gateway listeners that the route is attached to
+func listeners(gateways map[resourceID]*gatewayItem, r *httpRouteItem) []*gatewayListener {
+	routeNamespace := namespaceString(r.Metadata.Namespace)
+
+	var ls []*gatewayListener
+	for _, p := range r.Spec.ParentRefs {
+		if p.Group != "" && p.Group != gatewayAPIGroup || p.Kind != "" && p.Kind != "Gateway" {
+			continue
+		}
+
+		gatewayNamespace := routeNamespace
+		if p.Namespace != "" {
+			gatewayNamespace = p.Namespace
+		}
+
+		gw, ok := gateways[newResourceID(gatewayNamespace, p.Name)]
+		if !ok {
+			continue
+		}
+
+		for _, l := range gw.Spec.Listeners {
+			if l == nil ||
+				p.SectionName != "" && p.SectionName != l.Name ||
+				l.Protocol != "HTTP" && l.Protocol != "HTTPS" ||
+				!l.allowsNamespace(gatewayNamespace, routeNamespace) {
+				continue
+			}
+
+			ls = append(ls, l)
+		}
+	}
+
+	return ls
+}
+
+// hostnameMatches tells whether a hostname matches a hostname pattern,
+// that can start with a wildcard label
+func hostnameMatches(pattern, hostname string) bool {
+	if strings.HasPrefix(pattern, "*.") {
+		return strings.HasSuffix(hostname, pattern[1:]) && len(hostname) > len(pattern)-1
+	}
+
+	return pattern == hostname
+}
+
+// routeHostnames returns the hostnames of the route, restricted by the
+// hostnames of the listeners. When the returned flag is true, the route
+// matches all hostnames.
+func routeHostnames(hostnames []string, ls []*gatewayListener) ([]string, bool) {
+	var h []string
+	for _, l := range ls {
+		switch {
+		case l.Hostname == "" && len(hostnames) == 0:
+			return nil, true
+		case l.Hostname == "":
+			h = append(h, hostnames...)
+		case len(hostnames) == 0:
+			h = append(h, l.Hostname)
+		default:
+			for _, hi := range hostnames {
+				switch {
+				case hostnameMatches(l.Hostname, hi):
+					h = append(h, hi)
+				case hostnameMatches(hi, l.Hostname):
+					h = append(h, l.Hostname)
+				}
+			}
+		}
+	}
+
+	return uniqueStrings(h), false
+}
+
+// createGatewayHostRx creates the host regexp like createHostRx, in
+// addition supporting the wildcard hostnames, e.g. *.example.org
+func createGatewayHostRx(h ...string) string {
+	if len(h) == 0 {
+		return ""
+	}
+
+	hrx := make([]string, len(h))
+	for i := range h {
+		if strings.HasPrefix(h[i], "*.") {
+			hrx[i] = ".+" + rxDots(h[i][1:])
+		} else {
+			hrx[i] = rxDots(h[i])
+		}
+	}
+
+	return fmt.Sprintf("^(%s)$", strings.Join(hrx, "|"))
+}
+
+func appendFilter(f []*eskip.Filter, name string, args ...interface{}) []*eskip.Filter {
+	return append(f, &eskip.Filter{
+		Name: name,
+		Args: args,
+	})
+}
+
+func exactRx(v string) string {
+	return "^" + regexp.QuoteMeta(v) + "$"
+}
+
+func matchPredicates(ctx *httpRouteContext, m *httpRouteMatch) []*eskip.Predicate {
+	var p []*eskip.Predicate
+	path := m.Path
+	if path == nil {
+		path = &httpPathMatch{Value: "/"}
+	}
+
+	switch path.Type {
+	case pathMatchExact:
+		p = appendPredicate(p, "Path", path.Value)
+	case matchRegularExpression:
+		p = appendPredicate(p, "PathRegexp", path.Value)
+	default:
+		p = appendPredicate(p, "PathSubtree", path.Value)
+	}
+
+	if ctx.hostRx != "" {
+		p = appendPredicate(p, "Host", ctx.hostRx)
+	}
+
+	if m.Method != "" {
+		p = appendPredicate(p, "Method", strings.ToUpper(m.Method))
+	}
+
+	for _, h := range m.Headers {
+		if h.Type == matchRegularExpression {
+			p = appendPredicate(p, "HeaderRegexp", h.Name, h.Value)
+		} else {
+			p = appendPredicate(p, "Header", h.Name, h.Value)
+		}
+	}
+
+	for _, q := range m.QueryParams {
+		if q.Type == matchRegularExpression {
+			p = appendPredicate(p, "QueryParam", q.Name, q.Value)
+		} else {
+			p = appendPredicate(p, "QueryParam", q.Name, exactRx(q.Value))
+		}
+	}
+
+	return p
+}
+
+func headerFilters(f []*eskip.Filter, hf *httpHeaderFilter, set, add, drop string) []*eskip.Filter {
+	for _, h := range hf.Set {
+		f = appendFilter(f, set, h.Name, h.Value)
+	}
+
+	for _, h := range hf.Add {
+		f = appendFilter(f, add, h.Name, h.Value)
+	}
+
+	for _, h := range hf.Remove {
+		f = appendFilter(f, drop, h)
+	}
+
+	return f
+}
+
+// pathFilter modifies the request path. The prefix replacement is done
+// with modPath, expecting that the path prefix match of the rule is a
+// prefix of the request path.
+func pathFilter(f []*eskip.Filter, m *httpRouteMatch, p *httpPathModifier) []*eskip.Filter {
+	if p == nil {
+		return f
+	}
+
+	if p.Type == pathReplaceFull {
+		return appendFilter(f, "setPath", p.ReplaceFullPath)
+	}
+
+	prefix := "/"
+	if m.Path != nil {
+		prefix = m.Path.Value
+	}
+
+	return appendFilter(
+		f,
+		"modPath",
+		"^"+regexp.QuoteMeta(strings.TrimSuffix(prefix, "/"))+"(/|$)",
+		strings.TrimSuffix(p.ReplacePrefixMatch, "/")+"${1}",
+	)
+}
+
+func redirectLocation(r *httpRequestRedirectFilter) string {
+	var location string
+	if r.Scheme != "" {
+		location = r.Scheme + ":"
+	}
+
+	if r.Hostname != "" {
+		host := r.Hostname
+		if r.Port != 0 {
+			host = net.JoinHostPort(host, strconv.Itoa(r.Port))
+		}
+
+		location += "//" + host
+	}
+
+	if r.Path != nil && r.Path.Type == pathReplaceFull {
+		location += r.Path.ReplaceFullPath
+	}
+
+	return location
+}
+
+// ruleFilters returns the filters of a rule, and whether the rule
+// responds with a redirect
+func ruleFilters(rule *httpRouteRule, m *httpRouteMatch) ([]*eskip.Filter, bool) {
+	var f []*eskip.Filter
+	for _, fi := range rule.Filters {
+		switch fi.Type {
+		case filterRequestHeaderModifier:
+			f = headerFilters(f, fi.RequestHeaderModifier, "setRequestHeader", "appendRequestHeader", "dropRequestHeader")
+		case filterResponseHeaderModifier:
+			f = headerFilters(f, fi.ResponseHeaderModifier, "setResponseHeader", "appendResponseHeader", "dropResponseHeader")
+		case filterURLRewrite:
+			if fi.URLRewrite.Hostname != "" {
+				f = appendFilter(f, "setRequestHeader", "Host", fi.URLRewrite.Hostname)
+			}
+
+			f = pathFilter(f, m, fi.URLRewrite.Path)
+		case filterRequestRedirect:
+			rd := fi.RequestRedirect
+			if rd.Path != nil && rd.Path.Type == pathReplacePrefix {
+				f = pathFilter(f, m, rd.Path)
+			}
+
+			code := rd.StatusCode
+			if code == 0 {
+				code = 302
+			}
+
+			return appendFilter(f, "redirectTo", float64(code), redirectLocation(rd)), true
+		}
+	}
+
+	return f, false
+}
+
+func applyGatewayServiceBackend(ctx *httpRouteContext, b *httpBackendRef, r *eskip.Route) error {
+	s, err := ctx.clusterState.getServiceRG(ctx.namespace, b.Name)
+	if err != nil {
+		return err
+	}
+
+	if strings.ToLower(s.Spec.Type) != "clusterip" {
+		return notSupportedServiceType(s)
+	}
+
+	targetPort, ok := s.getTargetPortByValue(b.Port)
+	if !ok {
+		return targetPortNotFound(b.Name, b.Port)
+	}
+
+	eps := ctx.clusterState.getEndpointsByTarget(ctx.namespace, s.Meta.Name, targetPort)
+	switch len(eps) {
+	case 0:
+		if s.Spec.ClusterIP == "" {
+			return errMissingClusterIP
+		}
+
+		log.Infof(
+			"[httproute] Target endpoints not found, using service cluster IP as a fallback for %s/%s %s:%d",
+			ctx.namespace,
+			ctx.route.Metadata.Name,
+			b.Name,
+			b.Port,
+		)
+
+		r.BackendType = eskip.NetworkBackend
+		r.Backend = fmt.Sprintf("http://%s:%d", s.Spec.ClusterIP, b.Port)
+	case 1:
+		r.BackendType = eskip.NetworkBackend
+		r.Backend = eps[0]
+	default:
+		r.BackendType = eskip.LBBackend
+		r.LBEndpoints = eps
+		r.LBAlgorithm = defaultLoadBalancerAlgorithm
+	}
+
+	f, err := ctx.defaultFilters.getNamed(ctx.namespace, b.Name)
+	if err != nil {
+		log.Errorf("[httproute]: failed to retrieve default filters: %v.", err)
+		return nil
+	}
+
+	// safe to prepend as defaultFilters.get() copies the slice:
+	r.Filters = append(f, r.Filters...)
+	return nil
+}
+
+// weightedBackends returns the backends with a weight greater than zero,
+// and their traffic values, keyed by their index
+func weightedBackends(refs []*httpBackendRef) ([]*httpBackendRef, map[string]*calculatedTraffic) {
+	var (
+		backends []*httpBackendRef
+		weights  []*backendReference
+	)
+
+	for _, b := range refs {
+		if b.weight() == 0 {
+			continue
+		}
+
+		weights = append(weights, &backendReference{
+			BackendName: strconv.Itoa(len(backends)),
+			Weight:      b.weight(),
+		})
+
+		backends = append(backends, b)
+	}
+
+	return backends, calculateTraffic(weights)
+}
+
+func transformRule(ctx *httpRouteContext, ruleIndex int, rule *httpRouteRule) ([]*eskip.Route, error) {
+	matches := rule.Matches
+	if len(matches) == 0 {
+		matches = []*httpRouteMatch{{}}
+	}
+
+	backends, traffic := weightedBackends(rule.BackendRefs)
+
+	var routes []*eskip.Route
+	for matchIndex, m := range matches {
+		predicates := matchPredicates(ctx, m)
+		filters, redirect := ruleFilters(rule, m)
+
+		// the requests of the rules without valid backends are
+		// responded with 500, as defined by the specification
+		if redirect || len(backends) == 0 {
+			if !redirect {
+				filters = appendFilter(filters, "status", float64(500))
+			}
+
+			routes = append(routes, &eskip.Route{
+				Id:          gwRouteID(ctx.route.Metadata, ruleIndex, matchIndex, 0),
+				Predicates:  predicates,
+				Filters:     filters,
+				BackendType: eskip.ShuntBackend,
+			})
+
+			continue
+		}
+
+		for backendIndex, b := range backends {
+			r := &eskip.Route{
+				Id:         gwRouteID(ctx.route.Metadata, ruleIndex, matchIndex, backendIndex),
+				Predicates: eskip.CopyPredicates(predicates),
+				Filters:    eskip.CopyFilters(filters),
+			}
+
+			if err := applyGatewayServiceBackend(ctx, b, r); err != nil {
+				return nil, err
+			}
+
+			configureTraffic(r, traffic[strconv.Itoa(backendIndex)])
+			routes = append(routes, r)
+		}
+	}
+
+	return routes, nil
+}
+
+func transformHTTPRoute(ctx *httpRouteContext) ([]*eskip.Route, error) {
+	var routes []*eskip.Route
+	rules := ctx.route.Spec.Rules
+	if len(rules) == 0 {
+		rules = []*httpRouteRule{{}}
+	}
+
+	for i, rule := range rules {
+		ri, err := transformRule(ctx, i, rule)
+		if err != nil {
+			return nil, err
+		}
+
+		routes = append(routes, ri...)
+	}
+
+	return routes, nil
+}
+
+func (g *gatewayAPI) convert(s *clusterState, df defaultFilters) ([]*eskip.Route, error) {
+	var rs []*eskip.Route
+
+	gateways := g.gateways(s)
+	for _, r := range s.httpRoutes {
+		ls := listeners(gateways, r)
+		if len(ls) == 0 {
+			log.Debugf(
+				"[httproute] not attached to a listener: %s/%s.",
+				namespaceString(r.Metadata.Namespace),
+				r.Metadata.Name,
+			)
+
+			continue
+		}
+
+		hosts, allHosts := routeHostnames(r.Spec.Hostnames, ls)
+		if !allHosts && len(hosts) == 0 {
+			log.Debugf(
+				"[httproute] no hostname matching the listeners: %s/%s.",
+				namespaceString(r.Metadata.Namespace),
+				r.Metadata.Name,
+			)
+
+			continue
+		}
+
+		ctx := &httpRouteContext{
+			clusterState:   s,
+			defaultFilters: df,
+			route:          r,
+			namespace:      namespaceString(r.Metadata.Namespace),
+			hostRx:         createGatewayHostRx(hosts...),
+		}
+
+		ri, err := transformHTTPRoute(ctx)
+		if err != nil {
+			log.Errorf(
+				"[httproute] error transforming %s/%s: %v.",
+				namespaceString(r.Metadata.Namespace),
+				r.Metadata.Name,
+				err,
+			)
+
+			continue
+		}
+
+		rs = append(rs, ri...)
+	}
+
+	return rs, nil
+}
@@ -0,0 +1,19 @@
+package kubernetes
+
+import "testing"
+
+func TestGatewayAPIAttachment(t *testing.T) {
+	testFixtures(t, "testdata/gateway-api/attachment")
+}
+
+func TestGatewayAPIConvert(t *testing.T) {
+	testFixtures(t, "testdata/gateway-api/convert")
+}
+
+func TestGatewayAPITraffic(t *testing.T) {
+	testFixtures(t, "testdata/gateway-api/traffic")
+}
+
+func TestGatewayAPIValidation(t *testing.T) {
+	testFixtures(t, "testdata/gateway-api/validation")
+}
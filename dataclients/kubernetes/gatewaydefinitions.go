@@ -0,0 +1,540 @@
+package kubernetes
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+)
+
+// The supported subset of the Kubernetes Gateway API resources, version
+// v1beta1. See https://gateway-api.sigs.k8s.io/references/spec/
+
+type gatewayClassList struct {
+	Items []*gatewayClassItem `json:"items"`
+}
+
+type gatewayClassItem struct {
+	Metadata *metadata         `json:"metadata"`
+	Spec     *gatewayClassSpec `json:"spec"`
+}
+
+type gatewayClassSpec struct {
+	// ControllerName identifies the controller handling the Gateways
+	// of the class.
+	ControllerName string `json:"controllerName"`
+}
+
+type gatewayList struct {
+	Items []*gatewayItem `json:"items"`
+}
+
+type gatewayItem struct {
+	Metadata *metadata    `json:"metadata"`
+	Spec     *gatewaySpec `json:"spec"`
+}
+
+type gatewaySpec struct {
+	// GatewayClassName references the GatewayClass of the Gateway.
+	GatewayClassName string `json:"gatewayClassName"`
+
+	// Listeners define the hostnames and the protocols that the
+	// routes can be attached to.
+	Listeners []*gatewayListener `json:"listeners"`
+}
+
+type gatewayListener struct {
+	// Name is the name of the listener, that the routes can reference
+	// as sectionName.
+	Name string `json:"name"`
+
+	// Hostname restricts the hosts of the attached routes. It can
+	// start with a wildcard label, e.g. *.example.org. Empty means
+	// all hosts.
+	Hostname string `json:"hostname,omitempty"`
+
+	// Port is the network port of the listener. It is not used for
+	// the routing, skipper serves all the listeners on its own
+	// address.
+	Port int `json:"port"`
+
+	// Protocol is one of HTTP or HTTPS. The listeners with other
+	// protocols are ignored.
+	Protocol string `json:"protocol"`
+
+	// AllowedRoutes restricts the namespaces of the attached routes.
+	AllowedRoutes *allowedRoutes `json:"allowedRoutes,omitempty"`
+}
+
+type allowedRoutes struct {
+	Namespaces *routeNamespaces `json:"namespaces,omitempty"`
+}
+
+type routeNamespaces struct {
+	// From is one of Same or All. Defaults to Same. Selector is not
+	// supported.
+	From string `json:"from,omitempty"`
+}
+
+type httpRouteList struct {
+	Items []*httpRouteItem `json:"items"`
+}
+
+type httpRouteItem struct {
+	Metadata *metadata      `json:"metadata"`
+	Spec     *httpRouteSpec `json:"spec"`
+}
+
+type httpRouteSpec struct {
+	// ParentRefs reference the Gateways, and optionally their
+	// listeners, that the route is attached to.
+	ParentRefs []*parentReference `json:"parentRefs"`
+
+	// Hostnames specifies the host headers matched by the route. No
+	// hostnames mean the hostnames of the listeners.
+	Hostnames []string `json:"hostnames,omitempty"`
+
+	// Rules define the matching conditions, the filters and the
+	// backends. No rules mean a single rule without matches, filters
+	// and backends.
+	Rules []*httpRouteRule `json:"rules,omitempty"`
+}
+
+type parentReference struct {
+	Group       string `json:"group,omitempty"`
+	Kind        string `json:"kind,omitempty"`
+	Namespace   string `json:"namespace,omitempty"`
+	Name        string `json:"name"`
+	SectionName string `json:"sectionName,omitempty"`
+}
+
+type httpRouteRule struct {
+	// Matches are alternative conditions of the rule. No matches
+	// mean the path prefix /.
+	Matches []*httpRouteMatch `json:"matches,omitempty"`
+
+	// Filters are applied to the requests matching the rule.
+	Filters []*httpRouteFilter `json:"filters,omitempty"`
+
+	// BackendRefs are the weighted backend services of the rule.
+	BackendRefs []*httpBackendRef `json:"backendRefs,omitempty"`
+}
+
+type httpRouteMatch struct {
+	Path        *httpPathMatch         `json:"path,omitempty"`
+	Headers     []*httpHeaderMatch     `json:"headers,omitempty"`
+	QueryParams []*httpQueryParamMatch `json:"queryParams,omitempty"`
+	Method      string                 `json:"method,omitempty"`
+}
+
+type httpPathMatch struct {
+	// Type is one of Exact, PathPrefix or RegularExpression. Defaults
+	// to PathPrefix.
+	Type  string `json:"type,omitempty"`
+	Value string `json:"value,omitempty"`
+}
+
+type httpHeaderMatch struct {
+	// Type is one of Exact or RegularExpression. Defaults to Exact.
+	Type  string `json:"type,omitempty"`
+	Name  string `json:"name"`
+	Value string `json:"value"`
+}
+
+type httpQueryParamMatch struct {
+	// Type is one of Exact or RegularExpression. Defaults to Exact.
+	Type  string `json:"type,omitempty"`
+	Name  string `json:"name"`
+	Value string `json:"value"`
+}
+
+type httpRouteFilter struct {
+	// Type is one of RequestHeaderModifier, ResponseHeaderModifier,
+	// RequestRedirect or URLRewrite.
+	Type string `json:"type"`
+
+	RequestHeaderModifier  *httpHeaderFilter          `json:"requestHeaderModifier,omitempty"`
+	ResponseHeaderModifier *httpHeaderFilter          `json:"responseHeaderModifier,omitempty"`
+	RequestRedirect        *httpRequestRedirectFilter `json:"requestRedirect,omitempty"`
+	URLRewrite             *httpURLRewriteFilter      `json:"urlRewrite,omitempty"`
+}
+
+type httpHeader struct {
+	Name  string `json:"name"`
+	Value string `json:"value"`
+}
+
+type httpHeaderFilter struct {
+	Set    []*httpHeader `json:"set,omitempty"`
+	Add    []*httpHeader `json:"add,omitempty"`
+	Remove []string      `json:"remove,omitempty"`
+}
+
+type httpPathModifier struct {
+	// Type is one of ReplaceFullPath or ReplacePrefixMatch.
+	Type               string `json:"type"`
+	ReplaceFullPath    string `json:"replaceFullPath,omitempty"`
+	ReplacePrefixMatch string `json:"replacePrefixMatch,omitempty"`
+}
+
+type httpRequestRedirectFilter struct {
+	Scheme   string            `json:"scheme,omitempty"`
+	Hostname string            `json:"hostname,omitempty"`
+	Path     *httpPathModifier `json:"path,omitempty"`
+	Port     int               `json:"port,omitempty"`
+
+	// StatusCode is one of 301 or 302. Defaults to 302.
+	StatusCode int `json:"statusCode,omitempty"`
+}
+
+type httpURLRewriteFilter struct {
+	Hostname string            `json:"hostname,omitempty"`
+	Path     *httpPathModifier `json:"path,omitempty"`
+}
+
+type httpBackendRef struct {
+	// Group and Kind must reference a Service, they default to the
+	// Service kind of the core group.
+	Group string `json:"group,omitempty"`
+	Kind  string `json:"kind,omitempty"`
+
+	// Namespace must be empty, or the namespace of the route.
+	// References to other namespaces are not supported.
+	Namespace string `json:"namespace,omitempty"`
+
+	Name string `json:"name"`
+	Port int    `json:"port"`
+
+	// Weight defines the proportion of the traffic forwarded to the
+	// backend. Defaults to 1.
+	Weight *int `json:"weight,omitempty"`
+}
+
+const (
+	gatewayAPIGroup = "gateway.networking.k8s.io"
+
+	pathMatchExact               = "Exact"
+	pathMatchPrefix              = "PathPrefix"
+	matchRegularExpression       = "RegularExpression"
+	filterRequestHeaderModifier  = "RequestHeaderModifier"
+	filterResponseHeaderModifier = "ResponseHeaderModifier"
+	filterRequestRedirect        = "RequestRedirect"
+	filterURLRewrite             = "URLRewrite"
+	pathReplaceFull              = "ReplaceFullPath"
+	pathReplacePrefix            = "ReplacePrefixMatch"
+	namespacesFromSame           = "Same"
+	namespacesFromAll            = "All"
+)
+
+var (
+	errHTTPRouteWithoutName       = errors.New("HTTP route without name")
+	errHTTPRouteWithoutSpec       = errors.New("HTTP route without spec")
+	errHTTPRouteWithoutParent     = errors.New("HTTP route without parent reference")
+	errInvalidParentReference     = errors.New("invalid parent reference")
+	errInvalidHostname            = errors.New("invalid hostname")
+	errInvalidHTTPRouteRule       = errors.New("invalid rule")
+	errInvalidHeaderMatch         = errors.New("invalid header match")
+	errInvalidQueryParamMatch     = errors.New("invalid query param match")
+	errInvalidHTTPFilter          = errors.New("invalid filter")
+	errInvalidPathModifier        = errors.New("invalid path modifier")
+	errPrefixRewriteWithoutPrefix = errors.New("prefix replacement without path prefix match")
+	errInvalidBackendRef          = errors.New("invalid backend reference")
+)
+
+func httpRouteError(m *metadata, err error) error {
+	return fmt.Errorf("error in HTTP route %s/%s: %w", namespaceString(m.Namespace), m.Name, err)
+}
+
+func invalidPathMatch(t, v string) error {
+	return fmt.Errorf("invalid path match: %s, %s", t, v)
+}
+
+func invalidMatchType(t string) error {
+	return fmt.Errorf("invalid match type: %s", t)
+}
+
+func unsupportedHTTPFilter(t string) error {
+	return fmt.Errorf("unsupported filter type: %s", t)
+}
+
+func invalidRedirectStatus(code int) error {
+	return fmt.Errorf("invalid redirect status code: %d", code)
+}
+
+func unsupportedBackendKind(group, kind string) error {
+	return fmt.Errorf("unsupported backend kind: %s/%s", group, kind)
+}
+
+func crossNamespaceBackend(namespace, name string) error {
+	return fmt.Errorf("backend in other namespace not supported: %s/%s", namespace, name)
+}
+
+func invalidBackendPort(name string, p int) error {
+	return fmt.Errorf("invalid port of backend: %s, %d", name, p)
+}
+
+func invalidRule(index int, err error) error {
+	return fmt.Errorf("invalid rule at %d, %w", index, err)
+}
+
+func (r *httpRouteItem) validate() error {
+	if r == nil || r.Metadata == nil || r.Metadata.Name == "" {
+		return errHTTPRouteWithoutName
+	}
+
+	if r.Spec == nil {
+		return httpRouteError(r.Metadata, errHTTPRouteWithoutSpec)
+	}
+
+	if err := r.Spec.validate(namespaceString(r.Metadata.Namespace)); err != nil {
+		return httpRouteError(r.Metadata, err)
+	}
+
+	return nil
+}
+
+func (s *httpRouteSpec) validate(namespace string) error {
+	if len(s.ParentRefs) == 0 {
+		return errHTTPRouteWithoutParent
+	}
+
+	for _, p := range s.ParentRefs {
+		if p == nil || p.Name == "" {
+			return errInvalidParentReference
+		}
+	}
+
+	if hasEmpty(s.Hostnames) {
+		return errInvalidHostname
+	}
+
+	for i, r := range s.Rules {
+		if err := r.validate(namespace); err != nil {
+			return invalidRule(i, err)
+		}
+	}
+
+	return nil
+}
+
+func (r *httpRouteRule) validate(namespace string) error {
+	if r == nil {
+		return errInvalidHTTPRouteRule
+	}
+
+	for _, m := range r.Matches {
+		if err := m.validate(); err != nil {
+			return err
+		}
+	}
+
+	var redirect bool
+	for _, f := range r.Filters {
+		if err := f.validate(); err != nil {
+			return err
+		}
+
+		if f.Type == filterRequestRedirect {
+			redirect = true
+		}
+
+		if p := f.pathModifier(); p != nil && p.Type == pathReplacePrefix && !r.prefixMatchesOnly() {
+			return errPrefixRewriteWithoutPrefix
+		}
+	}
+
+	for _, b := range r.BackendRefs {
+		if err := b.validate(namespace); err != nil {
+			return err
+		}
+	}
+
+	if redirect && len(r.BackendRefs) > 0 {
+		return errInvalidHTTPRouteRule
+	}
+
+	return nil
+}
+
+// prefixMatchesOnly tells whether all the path matches of the rule are
+// prefix matches, required by the prefix replacing path modifiers.
+func (r *httpRouteRule) prefixMatchesOnly() bool {
+	for _, m := range r.Matches {
+		if m.Path != nil && m.Path.Type != "" && m.Path.Type != pathMatchPrefix {
+			return false
+		}
+	}
+
+	return true
+}
+
+func (m *httpRouteMatch) validate() error {
+	if m == nil {
+		return errInvalidHTTPRouteRule
+	}
+
+	if m.Path != nil {
+		switch m.Path.Type {
+		case "", pathMatchExact, pathMatchPrefix:
+			if !strings.HasPrefix(m.Path.Value, "/") {
+				return invalidPathMatch(m.Path.Type, m.Path.Value)
+			}
+		case matchRegularExpression:
+			if m.Path.Value == "" {
+				return invalidPathMatch(m.Path.Type, m.Path.Value)
+			}
+		default:
+			return invalidPathMatch(m.Path.Type, m.Path.Value)
+		}
+	}
+
+	for _, h := range m.Headers {
+		if h == nil || h.Name == "" {
+			return errInvalidHeaderMatch
+		}
+
+		if err := validateMatchType(h.Type); err != nil {
+			return err
+		}
+	}
+
+	for _, q := range m.QueryParams {
+		if q == nil || q.Name == "" {
+			return errInvalidQueryParamMatch
+		}
+
+		if err := validateMatchType(q.Type); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+func validateMatchType(t string) error {
+	switch t {
+	case "", pathMatchExact, matchRegularExpression:
+		return nil
+	default:
+		return invalidMatchType(t)
+	}
+}
+
+func (f *httpRouteFilter) pathModifier() *httpPathModifier {
+	switch {
+	case f.RequestRedirect != nil:
+		return f.RequestRedirect.Path
+	case f.URLRewrite != nil:
+		return f.URLRewrite.Path
+	default:
+		return nil
+	}
+}
+
+func (f *httpRouteFilter) validate() error {
+	if f == nil {
+		return errInvalidHTTPFilter
+	}
+
+	switch f.Type {
+	case filterRequestHeaderModifier:
+		if f.RequestHeaderModifier == nil {
+			return errInvalidHTTPFilter
+		}
+
+		return f.RequestHeaderModifier.validate()
+	case filterResponseHeaderModifier:
+		if f.ResponseHeaderModifier == nil {
+			return errInvalidHTTPFilter
+		}
+
+		return f.ResponseHeaderModifier.validate()
+	case filterRequestRedirect:
+		if f.RequestRedirect == nil {
+			return errInvalidHTTPFilter
+		}
+
+		switch f.RequestRedirect.StatusCode {
+		case 0, 301, 302:
+		default:
+			return invalidRedirectStatus(f.RequestRedirect.StatusCode)
+		}
+
+		return f.RequestRedirect.Path.validate()
+	case filterURLRewrite:
+		if f.URLRewrite == nil {
+			return errInvalidHTTPFilter
+		}
+
+		return f.URLRewrite.Path.validate()
+	default:
+		return unsupportedHTTPFilter(f.Type)
+	}
+}
+
+func (f *httpHeaderFilter) validate() error {
+	for _, h := range append(f.Set, f.Add...) {
+		if h == nil || h.Name == "" {
+			return errInvalidHTTPFilter
+		}
+	}
+
+	if hasEmpty(f.Remove) {
+		return errInvalidHTTPFilter
+	}
+
+	return nil
+}
+
+// validate accepts the nil path modifier, because it is optional
+func (p *httpPathModifier) validate() error {
+	if p == nil {
+		return nil
+	}
+
+	switch p.Type {
+	case pathReplaceFull:
+		if !strings.HasPrefix(p.ReplaceFullPath, "/") {
+			return errInvalidPathModifier
+		}
+	case pathReplacePrefix:
+		if !strings.HasPrefix(p.ReplacePrefixMatch, "/") {
+			return errInvalidPathModifier
+		}
+	default:
+		return errInvalidPathModifier
+	}
+
+	return nil
+}
+
+func (b *httpBackendRef) validate(namespace string) error {
+	if b == nil || b.Name == "" {
+		return errInvalidBackendRef
+	}
+
+	if b.Group != "" || b.Kind != "" && b.Kind != "Service" {
+		return unsupportedBackendKind(b.Group, b.Kind)
+	}
+
+	if b.Namespace != "" && b.Namespace != namespace {
+		return crossNamespaceBackend(b.Namespace, b.Name)
+	}
+
+	if b.Port <= 0 || b.Port != int(uint16(b.Port)) {
+		return invalidBackendPort(b.Name, b.Port)
+	}
+
+	if b.Weight != nil && *b.Weight < 0 {
+		return invalidBackendWeight(b.Name, *b.Weight)
+	}
+
+	return nil
+}
+
+func (b *httpBackendRef) weight() int {
+	if b.Weight == nil {
+		return 1
+	}
+
+	return *b.Weight
+}
@@ -151,6 +151,14 @@ type Options struct {
 
 	// If the OriginMarker should be added as a filter
 	OriginMarker bool
+
+	// KubernetesEnableGatewayAPI enables creating routes from the Gateway API resources:
+	// GatewayClass, Gateway and HTTPRoute.
+	KubernetesEnableGatewayAPI bool
+
+	// GatewayControllerName sets the controller name of the GatewayClasses, whose Gateways
+	// are handled by skipper. Defaults to zalando.org/skipper.
+	GatewayControllerName string
 }
 
 // Client is a Skipper DataClient implementation used to create routes based on Kubernetes Ingress settings.
@@ -158,6 +166,7 @@ type Client struct {
 	clusterClient          *clusterClient
 	ingress                *ingress
 	routeGroups            *routeGroups
+	gatewayAPI             *gatewayAPI
 	provideHealthcheck     bool
 	healthy                bool
 	provideHTTPSRedirect   bool
@@ -225,11 +234,13 @@ func New(o Options) (*Client, error) {
 
 	ing := newIngress(o)
 	rg := newRouteGroups(o)
+	gw := newGatewayAPI(o)
 
 	return &Client{
 		clusterClient:          clusterClient,
 		ingress:                ing,
 		routeGroups:            rg,
+		gatewayAPI:             gw,
 		provideHealthcheck:     o.ProvideHealthcheck,
 		provideHTTPSRedirect:   o.ProvideHTTPSRedirect,
 		httpsRedirectCode:      o.HTTPSRedirectCode,
@@ -313,7 +324,12 @@ func (c *Client) loadAndConvert() (*clusterState, []*eskip.Route, error) {
 		return nil, nil, err
 	}
 
-	return state, append(ri, rg...), nil
+	gw, err := c.gatewayAPI.convert(state, defaultFilters)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	return state, append(append(ri, rg...), gw...), nil
 }
 
 func healthcheckRoute(healthy, reverseSourcePredicate bool) *eskip.Route {
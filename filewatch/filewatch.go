@@ -0,0 +1,114 @@
+/*
+Package filewatch implements loading the configuration files of filters
+and other components, and reloading them when they change, without
+restarting skipper.
+
+The files are checked for changes by their modification time and size,
+at most once per check interval. When a changed file cannot be loaded,
+the last successfully loaded version stays in use.
+*/
+package filewatch
+
+import (
+	"io/ioutil"
+	"os"
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+// File holds the parsed content of a file, and reloads it when the file
+// changes.
+type File struct {
+	path          string
+	checkInterval time.Duration
+	parse         func([]byte) (interface{}, error)
+
+	value atomic.Value
+
+	mu      sync.Mutex
+	loaded  bool
+	modTime time.Time
+	size    int64
+	checked time.Time
+}
+
+type loadedValue struct {
+	v interface{}
+}
+
+// New creates a File. The file is loaded on the first call to Get or
+// Check, and the content is parsed with the parse function. With a zero
+// check interval, the file is checked on every call to Get.
+func New(path string, checkInterval time.Duration, parse func([]byte) (interface{}, error)) *File {
+	return &File{path: path, checkInterval: checkInterval, parse: parse}
+}
+
+// Path returns the path of the file.
+func (f *File) Path() string { return f.path }
+
+// Value returns the last successfully loaded content, or nil, without
+// checking the file.
+func (f *File) Value() interface{} {
+	if v, ok := f.value.Load().(loadedValue); ok {
+		return v.v
+	}
+
+	return nil
+}
+
+// Get returns the last successfully loaded content, and reloads the file
+// first, when the check interval passed since the last check and the
+// file changed. The returned error tells that the file could not be
+// loaded during this call. In this case, the previous content is
+// returned with it, or nil, when the file was never loaded.
+func (f *File) Get() (interface{}, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	now := time.Now()
+	if !f.checked.IsZero() && now.Sub(f.checked) < f.checkInterval {
+		return f.Value(), nil
+	}
+
+	f.checked = now
+	_, err := f.check()
+	return f.Value(), err
+}
+
+// Check checks the file regardless of the check interval, and reloads
+// it, when it changed. It tells whether the file was reloaded.
+func (f *File) Check() (bool, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	f.checked = time.Now()
+	return f.check()
+}
+
+func (f *File) check() (bool, error) {
+	info, err := os.Stat(f.path)
+	if err != nil {
+		return false, err
+	}
+
+	if f.loaded && info.ModTime().Equal(f.modTime) && info.Size() == f.size {
+		return false, nil
+	}
+
+	b, err := ioutil.ReadFile(f.path)
+	if err != nil {
+		return false, err
+	}
+
+	v, err := f.parse(b)
+	if err != nil {
+		return false, err
+	}
+
+	f.value.Store(loadedValue{v: v})
+	f.loaded = true
+	f.modTime = info.ModTime()
+	f.size = info.Size()
+	return true, nil
+}
@@ -0,0 +1,108 @@
+package filewatch
+
+import (
+	"errors"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+var errInvalid = errors.New("invalid content")
+
+func parseTest(b []byte) (interface{}, error) {
+	if string(b) == "invalid" {
+		return nil, errInvalid
+	}
+
+	return string(b), nil
+}
+
+func writeTest(t *testing.T, path, content string, modTime time.Time) {
+	if err := ioutil.WriteFile(path, []byte(content), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	if err := os.Chtimes(path, modTime, modTime); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func tempFile(t *testing.T, content string) (string, func()) {
+	dir, err := ioutil.TempDir("", "filewatch")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	path := filepath.Join(dir, "file")
+	writeTest(t, path, content, time.Now())
+	return path, func() { os.RemoveAll(dir) }
+}
+
+func TestGet(t *testing.T) {
+	path, cleanup := tempFile(t, "foo")
+	defer cleanup()
+
+	f := New(path, 0, parseTest)
+	if v, err := f.Get(); err != nil || v != "foo" {
+		t.Fatalf("failed to load the file: %v, %v", v, err)
+	}
+
+	writeTest(t, path, "bar", time.Now().Add(time.Minute))
+	if v, err := f.Get(); err != nil || v != "bar" {
+		t.Fatalf("failed to reload the file: %v, %v", v, err)
+	}
+
+	// the previous content is kept when the update is invalid
+	writeTest(t, path, "invalid", time.Now().Add(2*time.Minute))
+	if v, err := f.Get(); err != errInvalid || v != "bar" {
+		t.Fatalf("unexpected result of an invalid update: %v, %v", v, err)
+	}
+
+	if err := os.Remove(path); err != nil {
+		t.Fatal(err)
+	}
+
+	if v, err := f.Get(); err == nil || v != "bar" {
+		t.Fatalf("unexpected result of a removed file: %v, %v", v, err)
+	}
+}
+
+func TestGetCheckInterval(t *testing.T) {
+	path, cleanup := tempFile(t, "foo")
+	defer cleanup()
+
+	f := New(path, time.Hour, parseTest)
+	if v, err := f.Get(); err != nil || v != "foo" {
+		t.Fatalf("failed to load the file: %v, %v", v, err)
+	}
+
+	writeTest(t, path, "bar", time.Now().Add(time.Minute))
+	if v, err := f.Get(); err != nil || v != "foo" {
+		t.Fatalf("file checked before the interval passed: %v, %v", v, err)
+	}
+
+	if reloaded, err := f.Check(); err != nil || !reloaded {
+		t.Fatalf("failed to check the file: %t, %v", reloaded, err)
+	}
+
+	if reloaded, err := f.Check(); err != nil || reloaded {
+		t.Fatalf("unchanged file reloaded: %t, %v", reloaded, err)
+	}
+
+	if v := f.Value(); v != "bar" {
+		t.Errorf("unexpected value: %v", v)
+	}
+}
+
+func TestGetMissingFile(t *testing.T) {
+	f := New("testdata/missing", time.Hour, parseTest)
+	if v, err := f.Get(); err == nil || v != nil {
+		t.Fatalf("failed to fail: %v, %v", v, err)
+	}
+
+	if v := f.Value(); v != nil {
+		t.Errorf("unexpected value: %v", v)
+	}
+}
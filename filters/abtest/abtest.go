@@ -0,0 +1,227 @@
+/*
+Package abtest provides the abTest filter, that assigns the requests to the
+variants of an experiment, e.g. for A/B testing.
+
+The filter expects the name of the experiment, the source of the
+assignment, and pairs of variant names and weights:
+
+	r: * -> abTest("checkout", "random", "control", 90, "onepage", 10) -> "https://shop.example.org";
+
+The source can be:
+
+	random           random assignment, kept by the cookie
+	header:<name>    the hash of the request header, e.g. a user id
+	cookie:<name>    the hash of a request cookie, e.g. a session id
+
+The assignment by a hash is deterministic, the same value is assigned to
+the same variant, as long as the variants and their weights don't change.
+When the header or the cookie is missing, the variant is chosen randomly.
+
+The assigned variant is stored in the abtest-<experiment> response cookie,
+and the requests that already have a valid variant in this cookie keep it.
+The variant is passed to the backend in the X-Abtest-<experiment> request
+header.
+
+The assignments are counted in the <experiment>.<variant> custom metrics of
+the filter, for the analysis of the experiment.
+*/
+package abtest
+
+import (
+	"hash/fnv"
+	"math/rand"
+	"net/http"
+	"strings"
+
+	"github.com/zalando/skipper/filters"
+)
+
+const (
+	Name = "abTest"
+
+	CookiePrefix = "abtest-"
+	HeaderPrefix = "X-Abtest-"
+
+	// the assignment cookie is kept for 30 days
+	cookieMaxAge = 30 * 24 * 60 * 60
+
+	sourceRandom       = "random"
+	sourceHeaderPrefix = "header:"
+	sourceCookiePrefix = "cookie:"
+)
+
+type (
+	spec struct{}
+
+	variant struct {
+		name   string
+		weight float64
+	}
+
+	filter struct {
+		experiment   string
+		cookieName   string
+		headerName   string
+		sourceHeader string
+		sourceCookie string
+		variants     []variant
+		totalWeight  float64
+		stateBagKey  string
+	}
+)
+
+// New creates the filter spec of the abTest filter.
+func New() filters.Spec { return spec{} }
+
+func (spec) Name() string { return Name }
+
+func (spec) CreateFilter(args []interface{}) (filters.Filter, error) {
+	if len(args) < 4 || len(args)%2 != 0 {
+		return nil, filters.ErrInvalidFilterParameters
+	}
+
+	experiment, ok := args[0].(string)
+	if !ok || experiment == "" {
+		return nil, filters.ErrInvalidFilterParameters
+	}
+
+	source, ok := args[1].(string)
+	if !ok {
+		return nil, filters.ErrInvalidFilterParameters
+	}
+
+	f := &filter{
+		experiment:  experiment,
+		cookieName:  CookiePrefix + experiment,
+		headerName:  HeaderPrefix + experiment,
+		stateBagKey: "filter." + Name + "." + experiment,
+	}
+
+	switch {
+	case source == sourceRandom:
+	case strings.HasPrefix(source, sourceHeaderPrefix) && len(source) > len(sourceHeaderPrefix):
+		f.sourceHeader = source[len(sourceHeaderPrefix):]
+	case strings.HasPrefix(source, sourceCookiePrefix) && len(source) > len(sourceCookiePrefix):
+		f.sourceCookie = source[len(sourceCookiePrefix):]
+	default:
+		return nil, filters.ErrInvalidFilterParameters
+	}
+
+	names := make(map[string]bool)
+	for i := 2; i < len(args); i += 2 {
+		name, ok := args[i].(string)
+		if !ok || name == "" || names[name] {
+			return nil, filters.ErrInvalidFilterParameters
+		}
+
+		weight, ok := args[i+1].(float64)
+		if !ok || weight < 0 {
+			return nil, filters.ErrInvalidFilterParameters
+		}
+
+		names[name] = true
+		f.variants = append(f.variants, variant{name: name, weight: weight})
+		f.totalWeight += weight
+	}
+
+	if f.totalWeight <= 0 {
+		return nil, filters.ErrInvalidFilterParameters
+	}
+
+	return f, nil
+}
+
+// valid tells whether the variant is still part of the experiment
+func (f *filter) valid(name string) bool {
+	for _, v := range f.variants {
+		if v.name == name && v.weight > 0 {
+			return true
+		}
+	}
+
+	return false
+}
+
+// choose returns the variant at the position x, which is between 0 and 1,
+// on the cumulated weights
+func (f *filter) choose(x float64) string {
+	target := x * f.totalWeight
+	var sum float64
+	for _, v := range f.variants {
+		sum += v.weight
+		if v.weight > 0 && target < sum {
+			return v.name
+		}
+	}
+
+	// rounding errors
+	for i := len(f.variants) - 1; i >= 0; i-- {
+		if f.variants[i].weight > 0 {
+			return f.variants[i].name
+		}
+	}
+
+	return ""
+}
+
+// sourceValue returns the value of the header or the cookie used for the
+// deterministic assignment
+func (f *filter) sourceValue(r *http.Request) string {
+	switch {
+	case f.sourceHeader != "":
+		return r.Header.Get(f.sourceHeader)
+	case f.sourceCookie != "":
+		if c, err := r.Cookie(f.sourceCookie); err == nil {
+			return c.Value
+		}
+	}
+
+	return ""
+}
+
+func (f *filter) assign(r *http.Request) string {
+	if v := f.sourceValue(r); v != "" {
+		h := fnv.New32a()
+		h.Write([]byte(f.experiment))
+		h.Write([]byte{0})
+		h.Write([]byte(v))
+		return f.choose(float64(h.Sum32()) / (1 << 32))
+	}
+
+	return f.choose(rand.Float64())
+}
+
+func (f *filter) Request(ctx filters.FilterContext) {
+	r := ctx.Request()
+
+	var name string
+	if c, err := r.Cookie(f.cookieName); err == nil && f.valid(c.Value) {
+		name = c.Value
+	} else {
+		name = f.assign(r)
+		ctx.StateBag()[f.stateBagKey] = name
+	}
+
+	r.Header.Set(f.headerName, name)
+	if m := ctx.Metrics(); m != nil {
+		m.IncCounter(f.experiment + "." + name)
+	}
+}
+
+// Response sets the cookie of the new assignments.
+func (f *filter) Response(ctx filters.FilterContext) {
+	name, ok := ctx.StateBag()[f.stateBagKey].(string)
+	if !ok {
+		return
+	}
+
+	c := &http.Cookie{
+		Name:     f.cookieName,
+		Value:    name,
+		Path:     "/",
+		MaxAge:   cookieMaxAge,
+		SameSite: http.SameSiteLaxMode,
+	}
+
+	ctx.Response().Header.Add("Set-Cookie", c.String())
+}
@@ -0,0 +1,157 @@
+package abtest
+
+import (
+	"fmt"
+	"net/http"
+	"testing"
+
+	"github.com/zalando/skipper/filters"
+	"github.com/zalando/skipper/filters/filtertest"
+	"github.com/zalando/skipper/metrics/metricstest"
+)
+
+func TestArgs(t *testing.T) {
+	for _, tt := range []struct {
+		name    string
+		args    []interface{}
+		wantErr bool
+	}{
+		{name: "no args", wantErr: true},
+		{name: "no variants", args: []interface{}{"checkout", "random"}, wantErr: true},
+		{name: "missing weight", args: []interface{}{"checkout", "random", "a", 1.0, "b"}, wantErr: true},
+		{name: "invalid source", args: []interface{}{"checkout", "foo", "a", 1.0, "b", 1.0}, wantErr: true},
+		{name: "header source without name", args: []interface{}{"checkout", "header:", "a", 1.0, "b", 1.0}, wantErr: true},
+		{name: "duplicate variant", args: []interface{}{"checkout", "random", "a", 1.0, "a", 1.0}, wantErr: true},
+		{name: "negative weight", args: []interface{}{"checkout", "random", "a", 1.0, "b", -1.0}, wantErr: true},
+		{name: "zero weights", args: []interface{}{"checkout", "random", "a", 0.0, "b", 0.0}, wantErr: true},
+		{name: "random", args: []interface{}{"checkout", "random", "a", 90.0, "b", 10.0}},
+		{name: "header", args: []interface{}{"checkout", "header:X-User-Id", "a", 1.0, "b", 1.0}},
+		{name: "cookie", args: []interface{}{"checkout", "cookie:session", "a", 1.0, "b", 1.0}},
+	} {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := New().CreateFilter(tt.args)
+			if tt.wantErr && err == nil {
+				t.Error("failed to fail")
+			} else if !tt.wantErr && err != nil {
+				t.Error(err)
+			}
+		})
+	}
+}
+
+func request(t *testing.T, f filters.Filter, m filters.Metrics, prepare func(*http.Request)) *filtertest.Context {
+	req, err := http.NewRequest("GET", "https://www.example.org", nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if prepare != nil {
+		prepare(req)
+	}
+
+	ctx := &filtertest.Context{
+		FRequest:  req,
+		FResponse: &http.Response{Header: http.Header{}},
+		FStateBag: map[string]interface{}{},
+		FMetrics:  m,
+	}
+
+	f.Request(ctx)
+	f.Response(ctx)
+	return ctx
+}
+
+func TestRandomAssignment(t *testing.T) {
+	f, err := New().CreateFilter([]interface{}{"checkout", "random", "control", 80.0, "new", 20.0})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	m := &metricstest.MockMetrics{}
+	for i := 0; i < 1000; i++ {
+		ctx := request(t, f, m, nil)
+		variant := ctx.FRequest.Header.Get("X-Abtest-Checkout")
+		cookies := (&http.Response{Header: ctx.FResponse.Header}).Cookies()
+		if len(cookies) != 1 || cookies[0].Name != "abtest-checkout" || cookies[0].Value != variant {
+			t.Fatalf("assignment cookie not set: %v", cookies)
+		}
+	}
+
+	m.WithCounters(func(counters map[string]int64) {
+		if counters["checkout.control"]+counters["checkout.new"] != 1000 ||
+			counters["checkout.new"] < 120 || counters["checkout.new"] > 280 {
+			t.Errorf("unexpected assignments: %v", counters)
+		}
+	})
+}
+
+func TestStickyCookie(t *testing.T) {
+	f, err := New().CreateFilter([]interface{}{"checkout", "random", "control", 50.0, "new", 50.0})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	for i := 0; i < 100; i++ {
+		ctx := request(t, f, nil, func(r *http.Request) {
+			r.AddCookie(&http.Cookie{Name: "abtest-checkout", Value: "new"})
+		})
+
+		if v := ctx.FRequest.Header.Get("X-Abtest-Checkout"); v != "new" {
+			t.Fatalf("assignment not kept: %s", v)
+		}
+
+		if len(ctx.FResponse.Header["Set-Cookie"]) != 0 {
+			t.Fatal("assignment cookie set again")
+		}
+	}
+
+	// a variant that is not part of the experiment anymore is reassigned
+	ctx := request(t, f, nil, func(r *http.Request) {
+		r.AddCookie(&http.Cookie{Name: "abtest-checkout", Value: "old"})
+	})
+
+	if v := ctx.FRequest.Header.Get("X-Abtest-Checkout"); v != "control" && v != "new" {
+		t.Errorf("invalid variant not reassigned: %s", v)
+	}
+}
+
+func TestHashAssignment(t *testing.T) {
+	for _, tt := range []struct {
+		source string
+		set    func(*http.Request, string)
+	}{{
+		source: "header:X-User-Id",
+		set:    func(r *http.Request, v string) { r.Header.Set("X-User-Id", v) },
+	}, {
+		source: "cookie:session",
+		set:    func(r *http.Request, v string) { r.AddCookie(&http.Cookie{Name: "session", Value: v}) },
+	}} {
+		t.Run(tt.source, func(t *testing.T) {
+			f, err := New().CreateFilter([]interface{}{"checkout", tt.source, "a", 1.0, "b", 1.0, "c", 2.0})
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			counts := make(map[string]int)
+			for i := 0; i < 1000; i++ {
+				user := fmt.Sprintf("user-%d", i)
+				variant := ""
+				for j := 0; j < 3; j++ {
+					ctx := request(t, f, nil, func(r *http.Request) { tt.set(r, user) })
+					v := ctx.FRequest.Header.Get("X-Abtest-Checkout")
+					if variant != "" && v != variant {
+						t.Fatalf("assignment of %s not deterministic: %s, %s", user, variant, v)
+					}
+
+					variant = v
+				}
+
+				counts[variant]++
+			}
+
+			if counts["c"] < 400 || counts["c"] > 600 {
+				t.Errorf("unexpected assignments: %v", counts)
+			}
+		})
+	}
+}
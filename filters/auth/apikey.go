@@ -0,0 +1,299 @@
+package auth
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"net/http"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	log "github.com/sirupsen/logrus"
+	"github.com/zalando/skipper/filters"
+	"github.com/zalando/skipper/secrets"
+	"gopkg.in/yaml.v2"
+)
+
+const (
+	ApiKeyAuthName = "apiKeyAuth"
+
+	apiKeyCacheKey = "apikey"
+
+	defaultApiKeyHeader = "X-Api-Key"
+
+	apiKeySourceHeader = "header:"
+	apiKeySourceQuery  = "query:"
+
+	// interval of checking whether the secret of the keys changed
+	defaultApiKeyCheckInterval = 10 * time.Second
+)
+
+const (
+	missingApiKey rejectReason = "missing-api-key"
+	invalidApiKey rejectReason = "invalid-api-key"
+)
+
+var errInvalidApiKeyEntry = errors.New("either key or sha256 needs to be set")
+
+type (
+	apiKeyAuthSpec struct {
+		secretsReader secrets.SecretsReader
+		checkInterval time.Duration
+
+		mu     sync.Mutex
+		stores map[string]*apiKeyStore
+	}
+
+	apiKeyAuthFilter struct {
+		store  *apiKeyStore
+		header string
+		query  string
+		scopes []string
+	}
+
+	// apiKeyEntry is an entry of the key file
+	apiKeyEntry struct {
+		Key     string    `yaml:"key"`
+		SHA256  string    `yaml:"sha256"`
+		Owner   string    `yaml:"owner"`
+		Scopes  []string  `yaml:"scopes"`
+		Expires time.Time `yaml:"expires"`
+	}
+
+	// apiKeyStore holds the parsed keys of a secret, indexed by the
+	// SHA256 hash of the keys. The secret is checked for changes once
+	// per check interval, and parsed again only when it changed.
+	apiKeyStore struct {
+		// first, to be 64-bit aligned for the atomic access
+		checked int64
+
+		name          string
+		secretsReader secrets.SecretsReader
+		checkInterval time.Duration
+		keys          atomic.Value
+
+		mu     sync.Mutex
+		secret []byte
+	}
+)
+
+// NewApiKeyAuth creates a filter spec for the apiKeyAuth filter. The
+// filter validates the API keys of the requests against the keys of a
+// YAML file, read from the secrets reader, that reloads it when it
+// changes. The first argument is the name of the file. The key is taken
+// from the X-Api-Key header by default, or from the header or the query
+// parameter set as the second argument. The rest of the arguments are the
+// scopes required from the key:
+//
+//	r: * -> apiKeyAuth("apikeys.yaml", "query:api_key", "orders.read") -> "https://www.example.org";
+//
+// The file contains a YAML list of the keys, with the fields key, owner,
+// scopes and expires. Instead of the plain text key, the sha256 field can
+// be set to the hex encoded SHA256 hash of the key.
+//
+// The owner and the scopes of the key are stored in the state bag, so
+// that they can be forwarded to the backend by the forwardToken filter,
+// and the owner is used as the user in the access log. The requests are
+// counted by owner in the custom metrics of the filter. The header or the
+// query parameter of the key is removed from the request forwarded to the
+// backend.
+func NewApiKeyAuth(sr secrets.SecretsReader) filters.Spec {
+	return newApiKeyAuth(sr, defaultApiKeyCheckInterval)
+}
+
+func newApiKeyAuth(sr secrets.SecretsReader, checkInterval time.Duration) *apiKeyAuthSpec {
+	return &apiKeyAuthSpec{
+		secretsReader: sr,
+		checkInterval: checkInterval,
+		stores:        make(map[string]*apiKeyStore),
+	}
+}
+
+func (*apiKeyAuthSpec) Name() string { return ApiKeyAuthName }
+
+func (s *apiKeyAuthSpec) CreateFilter(args []interface{}) (filters.Filter, error) {
+	sargs, err := getStrings(args)
+	if err != nil {
+		return nil, err
+	}
+
+	if len(sargs) == 0 || sargs[0] == "" {
+		return nil, filters.ErrInvalidFilterParameters
+	}
+
+	f := &apiKeyAuthFilter{header: defaultApiKeyHeader}
+	if len(sargs) > 1 {
+		switch source := sargs[1]; {
+		case strings.HasPrefix(source, apiKeySourceHeader):
+			f.header = http.CanonicalHeaderKey(strings.TrimPrefix(source, apiKeySourceHeader))
+		case strings.HasPrefix(source, apiKeySourceQuery):
+			f.header = ""
+			f.query = strings.TrimPrefix(source, apiKeySourceQuery)
+		default:
+			return nil, filters.ErrInvalidFilterParameters
+		}
+
+		if f.header == "" && f.query == "" {
+			return nil, filters.ErrInvalidFilterParameters
+		}
+
+		f.scopes = sargs[2:]
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	// the stores are shared between the routes, so that the keys are
+	// parsed only once
+	store, ok := s.stores[sargs[0]]
+	if !ok {
+		store = &apiKeyStore{
+			name:          sargs[0],
+			secretsReader: s.secretsReader,
+			checkInterval: s.checkInterval,
+		}
+
+		s.stores[sargs[0]] = store
+	}
+
+	f.store = store
+	return f, nil
+}
+
+func parseApiKeys(data []byte) (map[string]*apiKeyEntry, error) {
+	var entries []*apiKeyEntry
+	if err := yaml.Unmarshal(data, &entries); err != nil {
+		return nil, err
+	}
+
+	keys := make(map[string]*apiKeyEntry)
+	for i, e := range entries {
+		var hash string
+		switch {
+		case e.Key != "":
+			h := sha256.Sum256([]byte(e.Key))
+			hash = hex.EncodeToString(h[:])
+		case e.SHA256 != "":
+			hash = strings.ToLower(e.SHA256)
+		default:
+			return nil, fmt.Errorf("invalid entry %d: %v", i, errInvalidApiKeyEntry)
+		}
+
+		// the plain text keys are not kept in the memory
+		e.Key = ""
+		keys[hash] = e
+	}
+
+	return keys, nil
+}
+
+// refresh parses the keys again, when the check interval passed and the
+// secret has changed. When the secret cannot be parsed, the last valid
+// keys are kept.
+func (s *apiKeyStore) refresh() {
+	now := time.Now().UnixNano()
+	if now-atomic.LoadInt64(&s.checked) < int64(s.checkInterval) {
+		return
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	// checked by a concurrent request meanwhile
+	if now-s.checked < int64(s.checkInterval) {
+		return
+	}
+
+	atomic.StoreInt64(&s.checked, now)
+	secret, ok := s.secretsReader.GetSecret(s.name)
+	if !ok {
+		log.Errorf("Failed to read API keys from %s.", s.name)
+		return
+	}
+
+	if s.keys.Load() != nil && bytes.Equal(secret, s.secret) {
+		return
+	}
+
+	// not parsing the invalid secret again on every check
+	s.secret = secret
+	keys, err := parseApiKeys(secret)
+	if err != nil {
+		log.Errorf("Failed to parse API keys from %s: %v.", s.name, err)
+		return
+	}
+
+	s.keys.Store(keys)
+}
+
+// get returns the entry of the key
+func (s *apiKeyStore) get(key string) (*apiKeyEntry, bool) {
+	s.refresh()
+	keys, _ := s.keys.Load().(map[string]*apiKeyEntry)
+	h := sha256.Sum256([]byte(key))
+	e, ok := keys[hex.EncodeToString(h[:])]
+	return e, ok
+}
+
+func (f *apiKeyAuthFilter) key(r *http.Request) string {
+	if f.query != "" {
+		return r.URL.Query().Get(f.query)
+	}
+
+	return r.Header.Get(f.header)
+}
+
+func (f *apiKeyAuthFilter) Request(ctx filters.FilterContext) {
+	key := f.key(ctx.Request())
+	if key == "" {
+		unauthorized(ctx, "", missingApiKey, "", "")
+		return
+	}
+
+	e, ok := f.store.get(key)
+	if !ok || !e.Expires.IsZero() && time.Now().After(e.Expires) {
+		if m := ctx.Metrics(); m != nil {
+			m.IncCounter("rejected")
+		}
+
+		unauthorized(ctx, "", invalidApiKey, "", "")
+		return
+	}
+
+	if !all(f.scopes, e.Scopes) {
+		forbidden(ctx, e.Owner, invalidScope, "")
+		return
+	}
+
+	if m := ctx.Metrics(); m != nil && e.Owner != "" {
+		m.IncCounter("owner." + e.Owner)
+	}
+
+	scopes := make([]interface{}, len(e.Scopes))
+	for i, s := range e.Scopes {
+		scopes[i] = s
+	}
+
+	ctx.StateBag()[apiKeyCacheKey] = map[string]interface{}{
+		"owner":  e.Owner,
+		"scopes": scopes,
+	}
+
+	// the key is not passed to the backend
+	req := ctx.Request()
+	if f.query != "" {
+		params := req.URL.Query()
+		params.Del(f.query)
+		req.URL.RawQuery = params.Encode()
+	} else {
+		req.Header.Del(f.header)
+	}
+
+	authorized(ctx, e.Owner)
+}
+
+func (*apiKeyAuthFilter) Response(filters.FilterContext) {}
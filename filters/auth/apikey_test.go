@@ -0,0 +1,323 @@
+package auth
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/zalando/skipper/eskip"
+	"github.com/zalando/skipper/filters"
+	"github.com/zalando/skipper/filters/filtertest"
+	"github.com/zalando/skipper/metrics/metricstest"
+	"github.com/zalando/skipper/proxy/proxytest"
+)
+
+const testApiKeys = `
+- key: foo-key
+  owner: team-foo
+  scopes: [orders.read, orders.write]
+- key: expired-key
+  owner: team-expired
+  expires: 2019-01-01T00:00:00Z
+- sha256: %s
+  owner: team-bar
+  scopes: [orders.read]
+`
+
+func testApiKeysSecret() []byte {
+	h := sha256.Sum256([]byte("bar-key"))
+	return []byte(fmt.Sprintf(testApiKeys, hex.EncodeToString(h[:])))
+}
+
+func TestApiKeyAuthArgs(t *testing.T) {
+	for _, tt := range []struct {
+		name    string
+		args    []interface{}
+		wantErr bool
+	}{{
+		name:    "no args",
+		wantErr: true,
+	}, {
+		name:    "empty secret name",
+		args:    []interface{}{""},
+		wantErr: true,
+	}, {
+		name:    "not a string",
+		args:    []interface{}{42},
+		wantErr: true,
+	}, {
+		name:    "invalid source",
+		args:    []interface{}{"apikeys.yaml", "cookie:key"},
+		wantErr: true,
+	}, {
+		name:    "empty header",
+		args:    []interface{}{"apikeys.yaml", "header:"},
+		wantErr: true,
+	}, {
+		name: "secret name",
+		args: []interface{}{"apikeys.yaml"},
+	}, {
+		name: "query source and scopes",
+		args: []interface{}{"apikeys.yaml", "query:api_key", "orders.read"},
+	}} {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := NewApiKeyAuth(mapSecretsReader{}).CreateFilter(tt.args)
+			if tt.wantErr && err == nil {
+				t.Error("failed to fail")
+			} else if !tt.wantErr && err != nil {
+				t.Error(err)
+			}
+		})
+	}
+}
+
+func TestApiKeyAuth(t *testing.T) {
+	sr := mapSecretsReader{"apikeys.yaml": testApiKeysSecret()}
+	for _, tt := range []struct {
+		name       string
+		args       []interface{}
+		header     string
+		query      string
+		wantStatus int
+		wantOwner  string
+	}{{
+		name:       "missing key",
+		args:       []interface{}{"apikeys.yaml"},
+		wantStatus: http.StatusUnauthorized,
+	}, {
+		name:       "unknown key",
+		args:       []interface{}{"apikeys.yaml"},
+		header:     "baz-key",
+		wantStatus: http.StatusUnauthorized,
+	}, {
+		name:       "expired key",
+		args:       []interface{}{"apikeys.yaml"},
+		header:     "expired-key",
+		wantStatus: http.StatusUnauthorized,
+	}, {
+		name:      "valid key",
+		args:      []interface{}{"apikeys.yaml"},
+		header:    "foo-key",
+		wantOwner: "team-foo",
+	}, {
+		name:      "hashed key",
+		args:      []interface{}{"apikeys.yaml"},
+		header:    "bar-key",
+		wantOwner: "team-bar",
+	}, {
+		name:      "key in the query",
+		args:      []interface{}{"apikeys.yaml", "query:api_key"},
+		query:     "foo-key",
+		wantOwner: "team-foo",
+	}, {
+		name:       "key in another header",
+		args:       []interface{}{"apikeys.yaml", "header:X-Other-Key"},
+		header:     "foo-key",
+		wantStatus: http.StatusUnauthorized,
+	}, {
+		name:      "scopes granted",
+		args:      []interface{}{"apikeys.yaml", "header:X-Api-Key", "orders.read", "orders.write"},
+		header:    "foo-key",
+		wantOwner: "team-foo",
+	}, {
+		name:       "scopes missing",
+		args:       []interface{}{"apikeys.yaml", "header:X-Api-Key", "orders.read", "orders.write"},
+		header:     "bar-key",
+		wantStatus: http.StatusForbidden,
+	}, {
+		name:       "missing secret",
+		args:       []interface{}{"other.yaml"},
+		header:     "foo-key",
+		wantStatus: http.StatusUnauthorized,
+	}} {
+		t.Run(tt.name, func(t *testing.T) {
+			f, err := NewApiKeyAuth(sr).CreateFilter(tt.args)
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			req, err := http.NewRequest("GET", "https://www.example.org/orders", nil)
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			if tt.header != "" {
+				req.Header.Set(defaultApiKeyHeader, tt.header)
+			}
+
+			if tt.query != "" {
+				req.URL.RawQuery = "api_key=" + tt.query + "&page=2"
+			}
+
+			m := &metricstest.MockMetrics{}
+			ctx := &filtertest.Context{FRequest: req, FStateBag: make(map[string]interface{}), FMetrics: m}
+			f.Request(ctx)
+			if tt.wantStatus != 0 {
+				if !ctx.FServed || ctx.FResponse.StatusCode != tt.wantStatus {
+					t.Errorf("unexpected response, served: %t, response: %v", ctx.FServed, ctx.FResponse)
+				}
+
+				return
+			}
+
+			if ctx.FServed {
+				t.Fatalf("request rejected: %d", ctx.FResponse.StatusCode)
+			}
+
+			info, ok := ctx.StateBag()[apiKeyCacheKey].(map[string]interface{})
+			if !ok || info["owner"] != tt.wantOwner {
+				t.Errorf("owner not stored: %v", ctx.StateBag()[apiKeyCacheKey])
+			}
+
+			if tt.query != "" && req.URL.RawQuery != "page=2" {
+				t.Errorf("key not removed from the query: %s", req.URL.RawQuery)
+			}
+
+			m.WithCounters(func(counters map[string]int64) {
+				if counters["owner."+tt.wantOwner] != 1 {
+					t.Errorf("request not counted: %v", counters)
+				}
+			})
+		})
+	}
+}
+
+func TestApiKeyAuthBackendRequest(t *testing.T) {
+	for _, tt := range []struct {
+		name   string
+		args   []interface{}
+		header string
+		query  string
+	}{{
+		name:   "key in the header",
+		args:   []interface{}{"apikeys.yaml"},
+		header: defaultApiKeyHeader,
+	}, {
+		name:   "key in another header",
+		args:   []interface{}{"apikeys.yaml", "header:X-Other-Key"},
+		header: "X-Other-Key",
+	}, {
+		name:  "key in the query",
+		args:  []interface{}{"apikeys.yaml", "query:api_key"},
+		query: "api_key",
+	}} {
+		t.Run(tt.name, func(t *testing.T) {
+			backend := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				if r.Header.Get(defaultApiKeyHeader) != "" || r.Header.Get("X-Other-Key") != "" {
+					t.Errorf("key passed to the backend in the header: %v", r.Header)
+				}
+
+				if r.URL.RawQuery != "page=2" {
+					t.Errorf("unexpected query at the backend: %s", r.URL.RawQuery)
+				}
+			}))
+			defer backend.Close()
+
+			fr := make(filters.Registry)
+			fr.Register(NewApiKeyAuth(mapSecretsReader{"apikeys.yaml": testApiKeysSecret()}))
+			proxy := proxytest.New(fr, &eskip.Route{
+				Filters: []*eskip.Filter{{Name: ApiKeyAuthName, Args: tt.args}},
+				Backend: backend.URL,
+			})
+			defer proxy.Close()
+
+			req, err := http.NewRequest("GET", proxy.URL+"/orders?page=2", nil)
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			if tt.header != "" {
+				req.Header.Set(tt.header, "foo-key")
+			} else {
+				req.URL.RawQuery = tt.query + "=foo-key&page=2"
+			}
+
+			rsp, err := http.DefaultClient.Do(req)
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			defer rsp.Body.Close()
+			if rsp.StatusCode != http.StatusOK {
+				t.Errorf("unexpected status: %d", rsp.StatusCode)
+			}
+		})
+	}
+}
+
+func TestApiKeyAuthReload(t *testing.T) {
+	sr := mapSecretsReader{"apikeys.yaml": testApiKeysSecret()}
+	f, err := newApiKeyAuth(sr, 0).CreateFilter([]interface{}{"apikeys.yaml"})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	request := func(key string) int {
+		req, err := http.NewRequest("GET", "https://www.example.org", nil)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		req.Header.Set(defaultApiKeyHeader, key)
+		ctx := &filtertest.Context{FRequest: req, FStateBag: make(map[string]interface{})}
+		f.Request(ctx)
+		if ctx.FServed {
+			return ctx.FResponse.StatusCode
+		}
+
+		return http.StatusOK
+	}
+
+	if s := request("foo-key"); s != http.StatusOK {
+		t.Fatalf("unexpected status before the reload: %d", s)
+	}
+
+	sr["apikeys.yaml"] = []byte("- key: rotated-key\n  owner: team-foo\n")
+	if s := request("foo-key"); s != http.StatusUnauthorized {
+		t.Errorf("removed key accepted after the reload: %d", s)
+	}
+
+	if s := request("rotated-key"); s != http.StatusOK {
+		t.Errorf("new key rejected after the reload: %d", s)
+	}
+
+	// invalid files don't drop the last valid keys
+	sr["apikeys.yaml"] = []byte("- owner: team-foo\n")
+	if s := request("rotated-key"); s != http.StatusOK {
+		t.Errorf("key rejected after an invalid reload: %d", s)
+	}
+}
+
+func TestApiKeyAuthCheckInterval(t *testing.T) {
+	sr := mapSecretsReader{"apikeys.yaml": testApiKeysSecret()}
+	f, err := newApiKeyAuth(sr, time.Hour).CreateFilter([]interface{}{"apikeys.yaml"})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	request := func(key string) bool {
+		req, err := http.NewRequest("GET", "https://www.example.org", nil)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		req.Header.Set(defaultApiKeyHeader, key)
+		ctx := &filtertest.Context{FRequest: req, FStateBag: make(map[string]interface{})}
+		f.Request(ctx)
+		return !ctx.FServed
+	}
+
+	if !request("foo-key") {
+		t.Fatal("key rejected")
+	}
+
+	// the secret is not read again before the check interval passed
+	delete(sr, "apikeys.yaml")
+	if !request("foo-key") {
+		t.Error("key rejected before the check interval passed")
+	}
+}
@@ -28,6 +28,10 @@ const (
 type authClient struct {
 	url *url.URL
 	tr  *net.Transport
+
+	// cache of the token validation results, when enabled
+	cache    *tokenCache
+	cacheTTL time.Duration
 }
 
 func newAuthClient(baseURL, spanName string, timeout time.Duration, maxIdleConns int, tracer opentracing.Tracer) (*authClient, error) {
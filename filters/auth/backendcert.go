@@ -0,0 +1,120 @@
+package auth
+
+import (
+	"bytes"
+	"crypto/tls"
+	"net/http"
+	"sync"
+
+	log "github.com/sirupsen/logrus"
+	"github.com/zalando/skipper/filters"
+	"github.com/zalando/skipper/secrets"
+)
+
+const (
+	BackendClientCertificateName = "backendClientCertificate"
+)
+
+type (
+	backendClientCertificateSpec struct {
+		secretsReader secrets.SecretsReader
+	}
+	backendClientCertificateFilter struct {
+		certName      string
+		keyName       string
+		secretsReader secrets.SecretsReader
+
+		mu      sync.Mutex
+		certPEM []byte
+		keyPEM  []byte
+		keyPair *tls.Certificate
+	}
+)
+
+// NewBackendClientCertificate creates a filter spec for the
+// backendClientCertificate() filter. The filter sets the client
+// certificate that the proxy presents to the backend, so that
+// mutually-authenticated backends can be called without a global
+// client certificate. The arguments are the names of the secrets
+// holding the PEM encoded certificate and private key, read from the
+// secrets reader:
+//
+//	r: * -> backendClientCertificate("client.crt", "client.key") -> "https://mtls.example.org";
+//
+// When the secrets are updated, the new certificate is used. When the
+// certificate can not be loaded, the request is not forwarded to the
+// backend, and it is responded with 502 Bad Gateway.
+func NewBackendClientCertificate(sr secrets.SecretsReader) filters.Spec {
+	return &backendClientCertificateSpec{
+		secretsReader: sr,
+	}
+}
+
+func (*backendClientCertificateSpec) Name() string {
+	return BackendClientCertificateName
+}
+
+func (s *backendClientCertificateSpec) CreateFilter(args []interface{}) (filters.Filter, error) {
+	if len(args) != 2 {
+		return nil, filters.ErrInvalidFilterParameters
+	}
+
+	certName, ok := args[0].(string)
+	if !ok {
+		return nil, filters.ErrInvalidFilterParameters
+	}
+
+	keyName, ok := args[1].(string)
+	if !ok {
+		return nil, filters.ErrInvalidFilterParameters
+	}
+
+	return &backendClientCertificateFilter{
+		certName:      certName,
+		keyName:       keyName,
+		secretsReader: s.secretsReader,
+	}, nil
+}
+
+// returns the parsed key pair, parsing it again only when the secrets
+// have changed
+func (f *backendClientCertificateFilter) certificate() (*tls.Certificate, bool) {
+	certPEM, ok := f.secretsReader.GetSecret(f.certName)
+	if !ok {
+		log.Errorf("Failed to load backend client certificate %s: secret not found.", f.certName)
+		return nil, false
+	}
+
+	keyPEM, ok := f.secretsReader.GetSecret(f.keyName)
+	if !ok {
+		log.Errorf("Failed to load backend client certificate key %s: secret not found.", f.keyName)
+		return nil, false
+	}
+
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	if f.keyPair != nil && bytes.Equal(certPEM, f.certPEM) && bytes.Equal(keyPEM, f.keyPEM) {
+		return f.keyPair, true
+	}
+
+	keyPair, err := tls.X509KeyPair(certPEM, keyPEM)
+	if err != nil {
+		log.Errorf("Failed to load backend client certificate %s: %v.", f.certName, err)
+		return nil, false
+	}
+
+	f.certPEM, f.keyPEM, f.keyPair = certPEM, keyPEM, &keyPair
+	return f.keyPair, true
+}
+
+func (f *backendClientCertificateFilter) Request(ctx filters.FilterContext) {
+	c, ok := f.certificate()
+	if !ok {
+		ctx.Serve(&http.Response{StatusCode: http.StatusBadGateway})
+		return
+	}
+
+	ctx.StateBag()[filters.BackendClientCertificateKey] = c
+}
+
+func (*backendClientCertificateFilter) Response(filters.FilterContext) {}
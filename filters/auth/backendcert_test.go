@@ -0,0 +1,96 @@
+package auth
+
+import (
+	"crypto/tls"
+	"io/ioutil"
+	"net/http"
+	"testing"
+
+	"github.com/zalando/skipper/filters"
+	"github.com/zalando/skipper/filters/filtertest"
+)
+
+type mapSecretsReader map[string][]byte
+
+func (m mapSecretsReader) GetSecret(name string) ([]byte, bool) {
+	s, ok := m[name]
+	return s, ok
+}
+
+func Test_backendClientCertificateSpec_CreateFilter(t *testing.T) {
+	for _, args := range [][]interface{}{
+		nil,
+		{"client.crt"},
+		{"client.crt", 42},
+		{42, "client.key"},
+		{"client.crt", "client.key", "foo"},
+	} {
+		if _, err := NewBackendClientCertificate(mapSecretsReader{}).CreateFilter(args); err == nil {
+			t.Errorf("expected error for args: %v", args)
+		}
+	}
+}
+
+func Test_backendClientCertificateFilter_Request(t *testing.T) {
+	certPEM, err := ioutil.ReadFile("../../fixtures/test.crt")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	keyPEM, err := ioutil.ReadFile("../../fixtures/test.key")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	for _, tt := range []struct {
+		name    string
+		secrets mapSecretsReader
+		want    bool
+	}{{
+		name:    "missing secrets",
+		secrets: mapSecretsReader{},
+		want:    false,
+	}, {
+		name:    "missing key",
+		secrets: mapSecretsReader{"client.crt": certPEM},
+		want:    false,
+	}, {
+		name:    "invalid key pair",
+		secrets: mapSecretsReader{"client.crt": certPEM, "client.key": []byte("invalid")},
+		want:    false,
+	}, {
+		name:    "valid key pair",
+		secrets: mapSecretsReader{"client.crt": certPEM, "client.key": keyPEM},
+		want:    true,
+	}} {
+		t.Run(tt.name, func(t *testing.T) {
+			f, err := NewBackendClientCertificate(tt.secrets).CreateFilter([]interface{}{"client.crt", "client.key"})
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			ctx := &filtertest.Context{FStateBag: make(map[string]interface{})}
+			f.Request(ctx)
+
+			cert, ok := ctx.FStateBag[filters.BackendClientCertificateKey].(*tls.Certificate)
+			if ok != tt.want {
+				t.Fatalf("expected certificate: %v", tt.want)
+			}
+
+			if !ok {
+				if !ctx.Served() || ctx.Response().StatusCode != http.StatusBadGateway {
+					t.Error("expected the request to be responded with 502")
+				}
+
+				return
+			}
+
+			// the parsed certificate is reused while the secrets do not change
+			ctx = &filtertest.Context{FStateBag: make(map[string]interface{})}
+			f.Request(ctx)
+			if ctx.FStateBag[filters.BackendClientCertificateKey] != cert {
+				t.Error("expected the same certificate")
+			}
+		})
+	}
+}
@@ -1,9 +1,18 @@
 package auth
 
 import (
+	"bufio"
+	"bytes"
+	"fmt"
+	"net/http"
+	"strings"
+	"sync"
+	"time"
+
 	auth "github.com/abbot/go-http-auth"
+	log "github.com/sirupsen/logrus"
+	"github.com/zalando/skipper/filewatch"
 	"github.com/zalando/skipper/filters"
-	"net/http"
 )
 
 const (
@@ -11,9 +20,18 @@ const (
 	ForceBasicAuthHeaderName  = "WWW-Authenticate"
 	ForceBasicAuthHeaderValue = "Basic realm="
 	DefaultRealmName          = "Basic Realm"
+
+	// DefaultHtpasswdCheckInterval is the default interval of checking
+	// whether the htpasswd files changed.
+	DefaultHtpasswdCheckInterval = 5 * time.Second
 )
 
-type basicSpec struct{}
+type basicSpec struct {
+	checkInterval time.Duration
+
+	mu    sync.Mutex
+	files map[string]*filewatch.File
+}
 
 type basic struct {
 	authenticator   *auth.BasicAuth
@@ -21,7 +39,18 @@ type basic struct {
 }
 
 func NewBasicAuth() *basicSpec {
-	return &basicSpec{}
+	return NewBasicAuthWithCheckInterval(DefaultHtpasswdCheckInterval)
+}
+
+// NewBasicAuthWithCheckInterval creates the basicAuth filter spec, that
+// checks the htpasswd files for changes with the given interval. The
+// files are reloaded without a restart, and when a changed file can't be
+// read, the last valid users are kept.
+func NewBasicAuthWithCheckInterval(checkInterval time.Duration) *basicSpec {
+	return &basicSpec{
+		checkInterval: checkInterval,
+		files:         make(map[string]*filewatch.File),
+	}
 }
 
 //We do not touch response at all
@@ -39,7 +68,70 @@ func (a *basic) Request(ctx filters.FilterContext) {
 			StatusCode: http.StatusUnauthorized,
 			Header:     header,
 		})
+
+		return
+	}
+
+	authorized(ctx, username)
+}
+
+// parseHtpasswd parses the user:hash lines of an htpasswd file. The
+// hashes are checked by the authenticator, it supports bcrypt, apr1 MD5
+// and SHA1.
+func parseHtpasswd(content []byte) (map[string]string, error) {
+	users := make(map[string]string)
+	scanner := bufio.NewScanner(bytes.NewReader(content))
+	for line := 1; scanner.Scan(); line++ {
+		l := strings.TrimSpace(scanner.Text())
+		if l == "" || strings.HasPrefix(l, "#") {
+			continue
+		}
+
+		userHash := strings.SplitN(l, ":", 2)
+		if len(userHash) != 2 || userHash[0] == "" || userHash[1] == "" {
+			return nil, fmt.Errorf("invalid htpasswd entry in line %d", line)
+		}
+
+		users[userHash[0]] = userHash[1]
 	}
+
+	return users, scanner.Err()
+}
+
+func parseHtpasswdFile(content []byte) (interface{}, error) {
+	return parseHtpasswd(content)
+}
+
+// htpasswdSecret returns the function providing the password hashes of
+// the users from the htpasswd file, that is reloaded when it changed
+// since the last check. It implements auth.SecretProvider.
+func htpasswdSecret(f *filewatch.File) auth.SecretProvider {
+	return func(user, _ string) string {
+		users, err := f.Get()
+		if err != nil {
+			log.Errorf("Failed to load htpasswd file %s: %v", f.Path(), err)
+		}
+
+		if users == nil {
+			return ""
+		}
+
+		return users.(map[string]string)[user]
+	}
+}
+
+// htpasswdFile returns the shared users of the file
+func (spec *basicSpec) htpasswdFile(path string) *filewatch.File {
+	spec.mu.Lock()
+	defer spec.mu.Unlock()
+
+	f, ok := spec.files[path]
+	if !ok {
+		f = filewatch.New(path, spec.checkInterval, parseHtpasswdFile)
+		spec.files[path] = f
+	}
+
+	return f
 }
 
 // Creates out basicAuth Filter
@@ -63,8 +155,8 @@ func (spec *basicSpec) CreateFilter(config []interface{}) (filters.Filter, error
 		}
 	}
 
-	htpasswd := auth.HtpasswdFileProvider(configFile)
-	authenticator := auth.NewBasicAuthenticator(realmName, htpasswd)
+	htpasswd := spec.htpasswdFile(configFile)
+	authenticator := auth.NewBasicAuthenticator(realmName, htpasswdSecret(htpasswd))
 
 	return &basic{
 		authenticator:   authenticator,
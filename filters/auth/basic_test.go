@@ -1,11 +1,16 @@
 package auth
 
 import (
+	"io/ioutil"
 	"net/http"
+	"os"
+	"path/filepath"
 	"testing"
+	"time"
 
 	"github.com/zalando/skipper/filters"
 	"github.com/zalando/skipper/filters/filtertest"
+	"golang.org/x/crypto/bcrypt"
 )
 
 func TestWithMissingAuth(t *testing.T) {
@@ -22,7 +27,7 @@ func TestWithMissingAuth(t *testing.T) {
 
 	expectedBasicAuthHeaderValue := ForceBasicAuthHeaderValue + `"Basic Realm"`
 
-	ctx := &filtertest.Context{FRequest: req}
+	ctx := &filtertest.Context{FRequest: req, FStateBag: make(map[string]interface{})}
 	f.Request(ctx)
 	if ctx.Response().Header.Get(ForceBasicAuthHeaderName) != expectedBasicAuthHeaderValue && ctx.Response().StatusCode == 401 && ctx.Served() {
 		t.Error("Authentication header wrong/missing")
@@ -44,7 +49,7 @@ func TestWithWrongAuth(t *testing.T) {
 
 	expectedBasicAuthHeaderValue := ForceBasicAuthHeaderValue + `"My Website"`
 
-	ctx := &filtertest.Context{FRequest: req}
+	ctx := &filtertest.Context{FRequest: req, FStateBag: make(map[string]interface{})}
 	f.Request(ctx)
 	if ctx.Response().Header.Get(ForceBasicAuthHeaderName) != expectedBasicAuthHeaderValue && ctx.Response().StatusCode == 401 && ctx.Served() {
 		t.Error("Authentication header wrong/missing")
@@ -64,7 +69,7 @@ func TestWithSuccessfulAuth(t *testing.T) {
 		t.Error(err)
 	}
 
-	ctx := &filtertest.Context{FRequest: req}
+	ctx := &filtertest.Context{FRequest: req, FStateBag: make(map[string]interface{})}
 	f.Request(ctx)
 	if ctx.Served() && ctx.Response().StatusCode != 401 {
 		t.Error("Authentication not successful")
@@ -102,3 +107,101 @@ func TestCreateFilterBasicAuthErrorCases(t *testing.T) {
 	}
 
 }
+
+func basicAuthRequest(t *testing.T, f filters.Filter, user, password string) bool {
+	req, err := http.NewRequest("GET", "https://www.example.org/", nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	req.SetBasicAuth(user, password)
+	ctx := &filtertest.Context{FRequest: req, FStateBag: make(map[string]interface{})}
+	f.Request(ctx)
+	return !ctx.Served()
+}
+
+func writeHtpasswd(t *testing.T, path string, users map[string]string, modTime time.Time) {
+	var content string
+	for user, password := range users {
+		hash, err := bcrypt.GenerateFromPassword([]byte(password), bcrypt.MinCost)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		content += user + ":" + string(hash) + "\n"
+	}
+
+	if err := ioutil.WriteFile(path, []byte(content), 0600); err != nil {
+		t.Fatal(err)
+	}
+
+	// the modification time is set explicitly, because the resolution of
+	// the file system may not reflect the quick changes of the test
+	if err := os.Chtimes(path, modTime, modTime); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestBasicAuthBcryptAndReload(t *testing.T) {
+	dir, err := ioutil.TempDir("", "htpasswd")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	defer os.RemoveAll(dir)
+
+	path := filepath.Join(dir, "htpasswd")
+	now := time.Now()
+	writeHtpasswd(t, path, map[string]string{"jdoe": "secret"}, now.Add(-time.Hour))
+
+	f, err := NewBasicAuthWithCheckInterval(0).CreateFilter([]interface{}{path, "dashboards"})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if !basicAuthRequest(t, f, "jdoe", "secret") {
+		t.Error("bcrypt entry not accepted")
+	}
+
+	if basicAuthRequest(t, f, "jdoe", "wrong") {
+		t.Error("wrong password accepted")
+	}
+
+	writeHtpasswd(t, path, map[string]string{"jdoe": "rotated", "alice": "password"}, now)
+	if basicAuthRequest(t, f, "jdoe", "secret") {
+		t.Error("old password accepted after the reload")
+	}
+
+	if !basicAuthRequest(t, f, "jdoe", "rotated") || !basicAuthRequest(t, f, "alice", "password") {
+		t.Error("new entries not accepted after the reload")
+	}
+
+	// invalid files don't drop the last valid users
+	if err := ioutil.WriteFile(path, []byte("invalid\n"), 0600); err != nil {
+		t.Fatal(err)
+	}
+
+	os.Chtimes(path, now.Add(time.Hour), now.Add(time.Hour))
+	if !basicAuthRequest(t, f, "alice", "password") {
+		t.Error("user rejected after an invalid reload")
+	}
+
+	if err := os.Remove(path); err != nil {
+		t.Fatal(err)
+	}
+
+	if !basicAuthRequest(t, f, "alice", "password") {
+		t.Error("user rejected after the file was removed")
+	}
+}
+
+func TestBasicAuthMissingFile(t *testing.T) {
+	f, err := NewBasicAuth().CreateFilter([]interface{}{"testdata/missing-htpasswd"})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if basicAuthRequest(t, f, "myName", "myPassword") {
+		t.Error("request accepted without users")
+	}
+}
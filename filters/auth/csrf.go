@@ -0,0 +1,178 @@
+package auth
+
+import (
+	"bytes"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/base64"
+	"net/http"
+	"time"
+
+	log "github.com/sirupsen/logrus"
+	"github.com/zalando/skipper/filters"
+	"github.com/zalando/skipper/secrets"
+)
+
+const (
+	CSRFProtectionName = "csrfProtection"
+
+	// DefaultCSRFHeaderName is the default name of the request header
+	// containing the CSRF token.
+	DefaultCSRFHeaderName = "X-Csrf-Token"
+
+	// DefaultCSRFCookieName is the default name of the cookie containing
+	// the CSRF token.
+	DefaultCSRFCookieName = "skipperCsrfToken"
+
+	csrfTokenStateBag = "filter.csrfProtection.token"
+	invalidCSRFToken  = rejectReason("invalid-csrf-token")
+)
+
+type (
+	csrfSpec struct {
+		secretsFile     string
+		secretsRegistry secrets.EncrypterCreator
+	}
+
+	csrfFilter struct {
+		headerName string
+		cookieName string
+		encrypter  secrets.Encryption
+	}
+)
+
+// NewCSRFProtection creates the csrfProtection filter spec. The tokens are
+// encrypted with the secrets of the secrets file.
+//
+// The filter implements the double submit cookie pattern: the requests
+// with safe methods receive a token in a cookie, when they don't have a
+// valid one, and the requests with other methods are rejected, unless
+// they send the same, valid token in a header, too. The token is also
+// passed to the backend in the header, so it can be embedded in the forms
+// of the server rendered pages, like with the synchronizer token pattern.
+//
+// When the filter is used after an OpenID Connect filter, the tokens are
+// bound to the session, and they become invalid when the user logs in
+// again.
+func NewCSRFProtection(secretsFile string, secretsRegistry secrets.EncrypterCreator) filters.Spec {
+	return &csrfSpec{secretsFile: secretsFile, secretsRegistry: secretsRegistry}
+}
+
+func (*csrfSpec) Name() string { return CSRFProtectionName }
+
+// CreateFilter accepts the name of the header and the name of the cookie
+// containing the token as optional arguments.
+func (s *csrfSpec) CreateFilter(args []interface{}) (filters.Filter, error) {
+	sargs, err := getStrings(args)
+	if err != nil || len(sargs) > 2 {
+		return nil, filters.ErrInvalidFilterParameters
+	}
+
+	f := &csrfFilter{headerName: DefaultCSRFHeaderName, cookieName: DefaultCSRFCookieName}
+	if len(sargs) > 0 && sargs[0] != "" {
+		f.headerName = sargs[0]
+	}
+
+	if len(sargs) > 1 && sargs[1] != "" {
+		f.cookieName = sargs[1]
+	}
+
+	f.encrypter, err = s.secretsRegistry.GetEncrypter(1*time.Minute, s.secretsFile)
+	if err != nil {
+		return nil, err
+	}
+
+	return f, nil
+}
+
+func isSafeMethod(method string) bool {
+	switch method {
+	case "GET", "HEAD", "OPTIONS", "TRACE":
+		return true
+	default:
+		return false
+	}
+}
+
+// csrfSessionHash identifies the OpenID Connect session of the request,
+// if there is one, to bind the tokens to it
+func csrfSessionHash(ctx filters.FilterContext) []byte {
+	session, _ := ctx.StateBag()[oidcSessionStateBagKey].(string)
+	h := sha256.Sum256([]byte(session))
+	return h[:]
+}
+
+// newToken creates a token for the session. The encryption uses a random
+// nonce, so the tokens of the same session are different.
+func (f *csrfFilter) newToken(sessionHash []byte) (string, error) {
+	enc, err := f.encrypter.Encrypt(sessionHash)
+	if err != nil {
+		return "", err
+	}
+
+	return base64.RawURLEncoding.EncodeToString(enc), nil
+}
+
+// validToken checks that the token was issued by the filter, for the
+// current session
+func (f *csrfFilter) validToken(token string, sessionHash []byte) bool {
+	enc, err := base64.RawURLEncoding.DecodeString(token)
+	if err != nil {
+		return false
+	}
+
+	plain, err := f.encrypter.Decrypt(enc)
+	return err == nil && bytes.Equal(plain, sessionHash)
+}
+
+func (f *csrfFilter) Request(ctx filters.FilterContext) {
+	r := ctx.Request()
+	sessionHash := csrfSessionHash(ctx)
+
+	var cookieToken string
+	if c, err := r.Cookie(f.cookieName); err == nil && f.validToken(c.Value, sessionHash) {
+		cookieToken = c.Value
+	}
+
+	if !isSafeMethod(r.Method) {
+		headerToken := r.Header.Get(f.headerName)
+		if cookieToken == "" || !hmac.Equal([]byte(headerToken), []byte(cookieToken)) {
+			forbidden(ctx, "", invalidCSRFToken, "")
+		}
+
+		return
+	}
+
+	if cookieToken == "" {
+		token, err := f.newToken(sessionHash)
+		if err != nil {
+			log.Errorf("Failed to create CSRF token: %v.", err)
+			reject(ctx, http.StatusInternalServerError, "", invalidCSRFToken, r.Host, "")
+			return
+		}
+
+		cookieToken = token
+		ctx.StateBag()[csrfTokenStateBag] = token
+	}
+
+	r.Header.Set(f.headerName, cookieToken)
+}
+
+// Response sets the cookie of the newly issued token. The cookie is not
+// HttpOnly, because the scripts need to read it, to send it in the header.
+func (f *csrfFilter) Response(ctx filters.FilterContext) {
+	token, ok := ctx.StateBag()[csrfTokenStateBag].(string)
+	if !ok {
+		return
+	}
+
+	c := &http.Cookie{
+		Name:     f.cookieName,
+		Value:    token,
+		Path:     "/",
+		Secure:   true,
+		SameSite: http.SameSiteStrictMode,
+	}
+
+	ctx.Response().Header.Add("Set-Cookie", c.String())
+}
@@ -0,0 +1,132 @@
+package auth
+
+import (
+	"net/http"
+	"testing"
+
+	"github.com/zalando/skipper/filters"
+	"github.com/zalando/skipper/filters/filtertest"
+	"github.com/zalando/skipper/secrets/secrettest"
+)
+
+func csrfRequest(t *testing.T, f filters.Filter, method, cookie, header, session string) *filtertest.Context {
+	req, err := http.NewRequest(method, "https://www.example.org/orders", nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if cookie != "" {
+		req.AddCookie(&http.Cookie{Name: DefaultCSRFCookieName, Value: cookie})
+	}
+
+	if header != "" {
+		req.Header.Set(DefaultCSRFHeaderName, header)
+	}
+
+	ctx := &filtertest.Context{
+		FRequest:  req,
+		FResponse: &http.Response{Header: http.Header{}},
+		FStateBag: map[string]interface{}{},
+	}
+
+	if session != "" {
+		ctx.FStateBag[oidcSessionStateBagKey] = session
+	}
+
+	f.Request(ctx)
+	if !ctx.FServed {
+		f.Response(ctx)
+	}
+
+	return ctx
+}
+
+func TestCSRFProtectionArgs(t *testing.T) {
+	spec := NewCSRFProtection("secret", secrettest.NewTestRegistry())
+	for _, args := range [][]interface{}{
+		{42},
+		{"X-Token", "token", "foo"},
+	} {
+		if _, err := spec.CreateFilter(args); err == nil {
+			t.Errorf("failed to fail: %v", args)
+		}
+	}
+
+	f, err := spec.CreateFilter([]interface{}{"X-Token", "token"})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if cf := f.(*csrfFilter); cf.headerName != "X-Token" || cf.cookieName != "token" {
+		t.Errorf("unexpected names: %s, %s", cf.headerName, cf.cookieName)
+	}
+}
+
+func TestCSRFProtection(t *testing.T) {
+	f, err := NewCSRFProtection("secret", secrettest.NewTestRegistry()).CreateFilter(nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	ctx := csrfRequest(t, f, "GET", "", "", "session-a")
+	cookies := (&http.Response{Header: ctx.FResponse.Header}).Cookies()
+	if len(cookies) != 1 || cookies[0].Name != DefaultCSRFCookieName || cookies[0].HttpOnly {
+		t.Fatalf("token cookie not set: %v", cookies)
+	}
+
+	token := cookies[0].Value
+	if h := ctx.FRequest.Header.Get(DefaultCSRFHeaderName); h != token {
+		t.Errorf("token not passed to the backend: %q", h)
+	}
+
+	ctx = csrfRequest(t, f, "GET", token, "", "session-a")
+	if len(ctx.FResponse.Header["Set-Cookie"]) != 0 {
+		t.Error("valid token issued again")
+	}
+
+	for _, tt := range []struct {
+		name    string
+		cookie  string
+		header  string
+		session string
+		allowed bool
+	}{{
+		name:    "valid token",
+		cookie:  token,
+		header:  token,
+		session: "session-a",
+		allowed: true,
+	}, {
+		name:    "missing header",
+		cookie:  token,
+		session: "session-a",
+	}, {
+		name:    "missing cookie",
+		header:  token,
+		session: "session-a",
+	}, {
+		name:    "different tokens",
+		cookie:  token,
+		header:  token + "x",
+		session: "session-a",
+	}, {
+		name:    "forged token",
+		cookie:  "Zm9vYmFy",
+		header:  "Zm9vYmFy",
+		session: "session-a",
+	}, {
+		name:    "token of another session",
+		cookie:  token,
+		header:  token,
+		session: "session-b",
+	}} {
+		t.Run(tt.name, func(t *testing.T) {
+			ctx := csrfRequest(t, f, "POST", tt.cookie, tt.header, tt.session)
+			if tt.allowed && ctx.FServed {
+				t.Errorf("request rejected: %d", ctx.FResponse.StatusCode)
+			} else if !tt.allowed && (!ctx.FServed || ctx.FResponse.StatusCode != http.StatusForbidden) {
+				t.Error("request not rejected")
+			}
+		})
+	}
+}
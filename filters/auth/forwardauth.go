@@ -0,0 +1,211 @@
+package auth
+
+import (
+	"bytes"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/opentracing/opentracing-go"
+	log "github.com/sirupsen/logrus"
+	"golang.org/x/net/http/httpguts"
+
+	"github.com/zalando/skipper/filters"
+	logfilter "github.com/zalando/skipper/filters/log"
+	snet "github.com/zalando/skipper/net"
+)
+
+const (
+	ForwardAuthName = "forwardAuth"
+
+	forwardAuthSpanName = "forwardauth"
+
+	// maximum size of the body of the auth service responses passed to
+	// the clients
+	maxForwardAuthBody = 1 << 20
+)
+
+// headers of the auth service responses not passed to the clients
+var forwardAuthHopHeaders = map[string]bool{
+	"Connection":        true,
+	"Content-Length":    true,
+	"Keep-Alive":        true,
+	"Transfer-Encoding": true,
+	"Upgrade":           true,
+	"Trailer":           true,
+}
+
+// headers of the auth service responses carrying the authenticated user,
+// in the order of precedence
+var forwardAuthUserHeaders = []string{"X-Forwarded-User", "Remote-User"}
+
+type (
+	forwardAuthSpec struct {
+		options WebhookOptions
+	}
+	forwardAuthFilter struct {
+		authClient                *authClient
+		forwardResponseHeaderKeys []string
+	}
+)
+
+// NewForwardAuth creates a new auth filter specification to authorize
+// the requests by an external service, the same way as the forward auth
+// of traefik, or the auth_request module of nginx.
+func NewForwardAuth(timeout time.Duration) filters.Spec {
+	return ForwardAuthWithOptions(WebhookOptions{Timeout: timeout, Tracer: opentracing.NoopTracer{}})
+}
+
+// ForwardAuthWithOptions creates a new auth filter specification to
+// authorize the requests by an external service. The service receives a
+// GET request with the headers of the original request, and the method,
+// protocol, host, URI and client address of the original request in the
+// X-Forwarded-Method, X-Forwarded-Proto, X-Forwarded-Host,
+// X-Forwarded-Uri and X-Forwarded-For headers.
+//
+// When the service responds with a 2xx status code, the request is
+// forwarded to the backend, and the response headers listed in the
+// optional second argument are copied to it. Otherwise, the response of
+// the service is returned to the client, e.g. a redirect to a login page.
+// The headers to copy are always removed from the incoming request, so
+// the clients can't set them. The authenticated user is taken from the
+// X-Forwarded-User or Remote-User header of the response.
+//
+//	r: * -> forwardAuth("https://auth.example.org/check", "X-Auth-User,X-Auth-Roles") -> "https://www.example.org";
+func ForwardAuthWithOptions(o WebhookOptions) filters.Spec {
+	return &forwardAuthSpec{options: o}
+}
+
+func (*forwardAuthSpec) Name() string {
+	return ForwardAuthName
+}
+
+func (s *forwardAuthSpec) CreateFilter(args []interface{}) (filters.Filter, error) {
+	if l := len(args); l == 0 || l > 2 {
+		return nil, filters.ErrInvalidFilterParameters
+	}
+
+	u, ok := args[0].(string)
+	if !ok {
+		return nil, filters.ErrInvalidFilterParameters
+	}
+
+	var forwardResponseHeaderKeys []string
+	if len(args) > 1 {
+		headerKeysOption, ok := args[1].(string)
+		if !ok {
+			return nil, filters.ErrInvalidFilterParameters
+		}
+
+		for _, header := range strings.Split(headerKeysOption, ",") {
+			header = strings.TrimSpace(header)
+			if !httpguts.ValidHeaderFieldName(header) {
+				return nil, fmt.Errorf("header %s is invalid", header)
+			}
+
+			forwardResponseHeaderKeys = append(forwardResponseHeaderKeys, http.CanonicalHeaderKey(header))
+		}
+	}
+
+	ac, err := newAuthClient(u, forwardAuthSpanName, s.options.Timeout, s.options.MaxIdleConns, s.options.Tracer)
+	if err != nil || ac.url.Host == "" {
+		return nil, filters.ErrInvalidFilterParameters
+	}
+
+	return &forwardAuthFilter{authClient: ac, forwardResponseHeaderKeys: forwardResponseHeaderKeys}, nil
+}
+
+func (ac *authClient) getForwardAuth(ctx filters.FilterContext) (*http.Response, []byte, error) {
+	req, err := http.NewRequest("GET", ac.url.String(), nil)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	r := ctx.Request()
+	copyHeader(req.Header, r.Header)
+	req.Header.Del("Content-Length")
+
+	proto := "http"
+	if r.TLS != nil {
+		proto = "https"
+	}
+
+	req.Header.Set("X-Forwarded-Method", r.Method)
+	req.Header.Set("X-Forwarded-Proto", proto)
+	req.Header.Set("X-Forwarded-Host", r.Host)
+	req.Header.Set("X-Forwarded-Uri", r.URL.RequestURI())
+	if ip := snet.RemoteHost(r); ip != nil {
+		req.Header.Set("X-Forwarded-For", ip.String())
+	}
+
+	rsp, err := ac.tr.RoundTrip(req)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	defer rsp.Body.Close()
+
+	// the body is passed to the client only when the request is denied
+	if rsp.StatusCode >= 200 && rsp.StatusCode < 300 {
+		return rsp, nil, nil
+	}
+
+	body, err := ioutil.ReadAll(io.LimitReader(rsp.Body, maxForwardAuthBody))
+	return rsp, body, err
+}
+
+func (f *forwardAuthFilter) Request(ctx filters.FilterContext) {
+	rsp, body, err := f.authClient.getForwardAuth(ctx)
+	if err != nil {
+		log.Errorf("Failed to make forward auth request: %v.", err)
+		reject(ctx, http.StatusServiceUnavailable, "", authServiceAccess, "", ForwardAuthName)
+		return
+	}
+
+	if rsp.StatusCode < 200 || rsp.StatusCode >= 300 {
+		ctx.StateBag()[logfilter.AuthRejectReasonKey] = string(invalidAccess)
+		deny := &http.Response{
+			StatusCode:    rsp.StatusCode,
+			Header:        make(http.Header),
+			ContentLength: int64(len(body)),
+			Body:          ioutil.NopCloser(bytes.NewReader(body)),
+		}
+
+		for k, v := range rsp.Header {
+			if !forwardAuthHopHeaders[k] {
+				deny.Header[k] = v
+			}
+		}
+
+		ctx.Serve(deny)
+		return
+	}
+
+	// the values sent by the client must not reach the backend
+	// when the service doesn't set them
+	for _, hk := range f.forwardResponseHeaderKeys {
+		ctx.Request().Header.Del(hk)
+		if h, ok := rsp.Header[hk]; ok {
+			ctx.Request().Header[hk] = h
+		}
+	}
+
+	var user string
+	for _, hk := range forwardAuthUserHeaders {
+		if user = rsp.Header.Get(hk); user != "" {
+			break
+		}
+	}
+
+	authorized(ctx, user)
+}
+
+func (*forwardAuthFilter) Response(filters.FilterContext) {}
+
+// Close cleans-up the authClient
+func (f *forwardAuthFilter) Close() {
+	f.authClient.Close()
+}
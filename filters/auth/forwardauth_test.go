@@ -0,0 +1,219 @@
+package auth
+
+import (
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/zalando/skipper/filters/filtertest"
+	logfilter "github.com/zalando/skipper/filters/log"
+)
+
+func TestForwardAuthArgs(t *testing.T) {
+	for _, tt := range []struct {
+		name    string
+		args    []interface{}
+		wantErr bool
+	}{{
+		name:    "no args",
+		wantErr: true,
+	}, {
+		name:    "not a string",
+		args:    []interface{}{42},
+		wantErr: true,
+	}, {
+		name:    "invalid url",
+		args:    []interface{}{"auth"},
+		wantErr: true,
+	}, {
+		name:    "invalid header",
+		args:    []interface{}{"https://auth.example.org/check", "X-Foo,X Bar"},
+		wantErr: true,
+	}, {
+		name:    "too many args",
+		args:    []interface{}{"https://auth.example.org/check", "X-Foo", "X-Bar"},
+		wantErr: true,
+	}, {
+		name: "url",
+		args: []interface{}{"https://auth.example.org/check"},
+	}, {
+		name: "url and headers",
+		args: []interface{}{"https://auth.example.org/check", "X-Auth-User, X-Auth-Roles"},
+	}} {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := NewForwardAuth(0).CreateFilter(tt.args)
+			if tt.wantErr && err == nil {
+				t.Error("failed to fail")
+			} else if !tt.wantErr && err != nil {
+				t.Error(err)
+			}
+		})
+	}
+}
+
+func TestForwardAuth(t *testing.T) {
+	for _, tt := range []struct {
+		name           string
+		status         int
+		wantServed     bool
+		wantUser       string
+		wantDeniedBody string
+	}{{
+		name:     "allowed",
+		status:   http.StatusOK,
+		wantUser: "jdoe",
+	}, {
+		name:     "allowed with no content",
+		status:   http.StatusNoContent,
+		wantUser: "jdoe",
+	}, {
+		name:           "unauthorized",
+		status:         http.StatusUnauthorized,
+		wantServed:     true,
+		wantDeniedBody: "denied",
+	}, {
+		name:           "redirect to login",
+		status:         http.StatusFound,
+		wantServed:     true,
+		wantDeniedBody: "denied",
+	}} {
+		t.Run(tt.name, func(t *testing.T) {
+			service := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				for k, v := range map[string]string{
+					"X-Forwarded-Method": "POST",
+					"X-Forwarded-Proto":  "http",
+					"X-Forwarded-Host":   "www.example.org",
+					"X-Forwarded-Uri":    "/orders?id=42",
+					"X-Forwarded-For":    "192.168.0.1",
+					"Cookie":             "session=abc",
+				} {
+					if h := r.Header.Get(k); h != v {
+						t.Errorf("unexpected header %s, expected: %s, got: %s", k, v, h)
+					}
+				}
+
+				if r.Method != "GET" {
+					t.Errorf("unexpected method: %s", r.Method)
+				}
+
+				w.Header().Set("X-Auth-User", "jdoe")
+				w.Header().Set("X-Internal", "secret")
+				w.Header().Set("Location", "https://login.example.org")
+				w.WriteHeader(tt.status)
+				if tt.status != http.StatusNoContent {
+					w.Write([]byte("denied"))
+				}
+			}))
+			defer service.Close()
+
+			f, err := NewForwardAuth(0).CreateFilter([]interface{}{service.URL, "X-Auth-User"})
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			req, err := http.NewRequest("POST", "http://www.example.org/orders?id=42", nil)
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			req.RemoteAddr = "192.168.0.1:1234"
+			req.Header.Set("Cookie", "session=abc")
+			ctx := &filtertest.Context{FRequest: req, FStateBag: make(map[string]interface{})}
+			f.Request(ctx)
+
+			if ctx.FServed != tt.wantServed {
+				t.Fatalf("unexpected served state: %t", ctx.FServed)
+			}
+
+			if !tt.wantServed {
+				if u := req.Header.Get("X-Auth-User"); u != tt.wantUser {
+					t.Errorf("unexpected user header: %s", u)
+				}
+
+				if req.Header.Get("X-Internal") != "" {
+					t.Error("unlisted header copied")
+				}
+
+				return
+			}
+
+			if ctx.FResponse.StatusCode != tt.status {
+				t.Errorf("unexpected status, expected: %d, got: %d", tt.status, ctx.FResponse.StatusCode)
+			}
+
+			if l := ctx.FResponse.Header.Get("Location"); l != "https://login.example.org" {
+				t.Errorf("unexpected location: %s", l)
+			}
+
+			b, err := ioutil.ReadAll(ctx.FResponse.Body)
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			if string(b) != tt.wantDeniedBody {
+				t.Errorf("unexpected body: %s", b)
+			}
+		})
+	}
+}
+
+func TestForwardAuthUnavailable(t *testing.T) {
+	service := httptest.NewServer(http.NotFoundHandler())
+	url := service.URL
+	service.Close()
+
+	f, err := NewForwardAuth(0).CreateFilter([]interface{}{url})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	req, err := http.NewRequest("GET", "http://www.example.org", nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	ctx := &filtertest.Context{FRequest: req, FStateBag: make(map[string]interface{})}
+	f.Request(ctx)
+	if !ctx.FServed || ctx.FResponse.StatusCode != http.StatusServiceUnavailable {
+		t.Errorf("unexpected response, served: %t, response: %v", ctx.FServed, ctx.FResponse)
+	}
+}
+
+func TestForwardAuthResponseHeaders(t *testing.T) {
+	service := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("X-Auth-User", "jdoe")
+		w.Header().Set("Remote-User", "jdoe")
+	}))
+	defer service.Close()
+
+	f, err := NewForwardAuth(0).CreateFilter([]interface{}{service.URL, "X-Auth-User,X-Auth-Roles"})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	req, err := http.NewRequest("GET", "http://www.example.org/orders", nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	req.Header.Set("X-Auth-User", "admin")
+	req.Header.Set("X-Auth-Roles", "admin")
+	ctx := &filtertest.Context{FRequest: req, FStateBag: make(map[string]interface{})}
+	f.Request(ctx)
+	if ctx.FServed {
+		t.Fatal("request denied")
+	}
+
+	if u := req.Header.Get("X-Auth-User"); u != "jdoe" {
+		t.Errorf("unexpected user header: %s", u)
+	}
+
+	if r, ok := req.Header["X-Auth-Roles"]; ok {
+		t.Errorf("header of the client kept: %v", r)
+	}
+
+	if u := ctx.FStateBag[logfilter.AuthUserKey]; u != "jdoe" {
+		t.Errorf("unexpected authenticated user: %v", u)
+	}
+}
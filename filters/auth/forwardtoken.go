@@ -22,8 +22,9 @@ type (
 	}
 )
 
-// NewForwardToken creates a filter to forward the result of token info or
-// token introspection to the backend server.
+// NewForwardToken creates a filter to forward the result of token info,
+// token introspection, JWT validation or API key authentication to the
+// backend server.
 func NewForwardToken() filters.Spec {
 	return &forwardTokenSpec{}
 }
@@ -71,6 +72,12 @@ func (f *forwardTokenFilter) Request(ctx filters.FilterContext) {
 	if tiMap == nil {
 		tiMap = getTokenPayload(ctx, tokenintrospectionCacheKey)
 	}
+	if tiMap == nil {
+		tiMap = getTokenPayload(ctx, jwtValidationCacheKey)
+	}
+	if tiMap == nil {
+		tiMap = getTokenPayload(ctx, apiKeyCacheKey)
+	}
 	if tiMap == nil {
 		return
 	}
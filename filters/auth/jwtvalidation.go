@@ -0,0 +1,376 @@
+package auth
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/url"
+	"sync"
+	"time"
+
+	log "github.com/sirupsen/logrus"
+	"github.com/zalando/skipper/filters"
+	jose "gopkg.in/square/go-jose.v2"
+	"gopkg.in/square/go-jose.v2/jwt"
+)
+
+const (
+	JwtValidationName = "jwtValidation"
+
+	jwtValidationCacheKey = "jwtvalidation"
+
+	defaultJwksTimeout         = 5 * time.Second
+	defaultJwksRefreshInterval = 10 * time.Minute
+
+	// minimum time between two refreshes triggered by an unknown key id,
+	// to protect the JWKS endpoint from tokens with random key ids
+	minJwksMissRefreshInterval = 10 * time.Second
+
+	// accepted difference between the clocks of the issuer and the proxy
+	jwtValidationLeeway = time.Minute
+)
+
+var (
+	errJwksUnavailable   = errors.New("JWKS not available")
+	errJwtUnknownKey     = errors.New("unknown key id")
+	errJwtUnsupportedAlg = errors.New("unsupported signing algorithm")
+)
+
+// only asymmetric algorithms are accepted, because the keys are public
+var jwtValidationAlgorithms = map[string]bool{
+	string(jose.RS256): true,
+	string(jose.RS384): true,
+	string(jose.RS512): true,
+	string(jose.PS256): true,
+	string(jose.PS384): true,
+	string(jose.PS512): true,
+	string(jose.ES256): true,
+	string(jose.ES384): true,
+	string(jose.ES512): true,
+	string(jose.EdDSA): true,
+}
+
+// JwtValidationOptions contains the options of the jwtValidation filter.
+type JwtValidationOptions struct {
+
+	// Timeout of fetching the key set. Defaults to 5s.
+	Timeout time.Duration
+
+	// RefreshInterval defines how often the key sets are refreshed in
+	// the background. Defaults to 10m.
+	RefreshInterval time.Duration
+}
+
+// JwtValidationSpec is the filter spec of the jwtValidation filter. It
+// needs to be closed to stop refreshing the key sets.
+type JwtValidationSpec struct {
+	options JwtValidationOptions
+
+	mu      sync.Mutex
+	keySets map[string]*jwksCache
+	closed  bool
+}
+
+type (
+	jwtValidationFilter struct {
+		keySet   *jwksCache
+		expected jwt.Expected
+	}
+
+	// jwksCache holds the keys of a JWKS endpoint, and refreshes them in
+	// the background, and when a token references an unknown key id
+	jwksCache struct {
+		url    string
+		client *http.Client
+
+		mu          sync.RWMutex
+		keys        map[string][]jose.JSONWebKey
+		lastRefresh time.Time
+		refreshing  sync.Mutex
+		quit        chan struct{}
+	}
+)
+
+// NewJwtValidation creates a filter spec for the jwtValidation filter,
+// with the default options.
+func NewJwtValidation() *JwtValidationSpec {
+	return NewJwtValidationWithOptions(JwtValidationOptions{})
+}
+
+// NewJwtValidationWithOptions creates a filter spec for the
+// jwtValidation filter. The filter validates the signature of the Bearer
+// JWT tokens locally, with the keys fetched from the JWKS endpoint passed
+// as the first argument. The key is selected by the key id of the token.
+// The key sets are cached and refreshed periodically, so the requests
+// don't need to wait for a remote call.
+//
+// Optionally, the expected issuer and audience of the token can be set:
+//
+//	r: * -> jwtValidation("https://issuer.example.org/.well-known/jwks.json", "https://issuer.example.org", "my-service") -> "https://www.example.org";
+//
+// The expiry and not-before claims are always validated.
+//
+// The key sets are fetched in the background, and the requests arriving
+// before the first fetch completed wait for it.
+func NewJwtValidationWithOptions(o JwtValidationOptions) *JwtValidationSpec {
+	if o.Timeout <= 0 {
+		o.Timeout = defaultJwksTimeout
+	}
+
+	if o.RefreshInterval <= 0 {
+		o.RefreshInterval = defaultJwksRefreshInterval
+	}
+
+	return &JwtValidationSpec{
+		options: o,
+		keySets: make(map[string]*jwksCache),
+	}
+}
+
+func (s *JwtValidationSpec) Name() string { return JwtValidationName }
+
+func (s *JwtValidationSpec) CreateFilter(args []interface{}) (filters.Filter, error) {
+	sargs, err := getStrings(args)
+	if err != nil {
+		return nil, err
+	}
+
+	if len(sargs) == 0 || len(sargs) > 3 {
+		return nil, filters.ErrInvalidFilterParameters
+	}
+
+	u, err := url.Parse(sargs[0])
+	if err != nil || u.Host == "" || (u.Scheme != "http" && u.Scheme != "https") {
+		return nil, filters.ErrInvalidFilterParameters
+	}
+
+	f := &jwtValidationFilter{keySet: s.keySet(sargs[0])}
+	if len(sargs) > 1 {
+		f.expected.Issuer = sargs[1]
+	}
+
+	if len(sargs) > 2 && sargs[2] != "" {
+		f.expected.Audience = jwt.Audience{sargs[2]}
+	}
+
+	return f, nil
+}
+
+// keySet returns the shared cache of the JWKS endpoint, and starts
+// refreshing it, when it was not used before
+func (s *JwtValidationSpec) keySet(jwksURL string) *jwksCache {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if c, ok := s.keySets[jwksURL]; ok {
+		return c
+	}
+
+	c := &jwksCache{
+		url:    jwksURL,
+		client: &http.Client{Timeout: s.options.Timeout},
+		quit:   make(chan struct{}),
+	}
+
+	if !s.closed {
+		go c.refreshPeriodically(s.options.RefreshInterval)
+	}
+
+	s.keySets[jwksURL] = c
+	return c
+}
+
+// Close stops refreshing the key sets.
+func (s *JwtValidationSpec) Close() error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if s.closed {
+		return nil
+	}
+
+	s.closed = true
+	for _, c := range s.keySets {
+		close(c.quit)
+	}
+
+	return nil
+}
+
+func (c *jwksCache) refreshPeriodically(interval time.Duration) {
+	// the initial fetch is skipped, when a request already triggered it
+	if err := c.refresh(minJwksMissRefreshInterval); err != nil {
+		log.Errorf("Failed to fetch JWKS from %s: %v", c.url, err)
+	}
+
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ticker.C:
+			if err := c.refresh(0); err != nil {
+				log.Errorf("Failed to refresh JWKS from %s: %v", c.url, err)
+			}
+		case <-c.quit:
+			return
+		}
+	}
+}
+
+// refresh fetches the key set, unless it was fetched within minAge, and
+// replaces the cached keys only when it succeeded
+func (c *jwksCache) refresh(minAge time.Duration) error {
+	c.refreshing.Lock()
+	defer c.refreshing.Unlock()
+
+	c.mu.Lock()
+	if minAge > 0 && time.Since(c.lastRefresh) < minAge {
+		c.mu.Unlock()
+		return nil
+	}
+
+	c.lastRefresh = time.Now()
+	c.mu.Unlock()
+
+	rsp, err := c.client.Get(c.url)
+	if err != nil {
+		return err
+	}
+
+	defer rsp.Body.Close()
+	if rsp.StatusCode != http.StatusOK {
+		return fmt.Errorf("unexpected status code: %d", rsp.StatusCode)
+	}
+
+	var set jose.JSONWebKeySet
+	if err := json.NewDecoder(rsp.Body).Decode(&set); err != nil {
+		return err
+	}
+
+	keys := make(map[string][]jose.JSONWebKey)
+	for _, k := range set.Keys {
+		if k.Use != "" && k.Use != "sig" || !k.IsPublic() {
+			continue
+		}
+
+		keys[k.KeyID] = append(keys[k.KeyID], k)
+	}
+
+	c.mu.Lock()
+	c.keys = keys
+	c.mu.Unlock()
+	return nil
+}
+
+func (c *jwksCache) lookup(kid string) ([]jose.JSONWebKey, bool) {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+
+	if c.keys == nil {
+		return nil, false
+	}
+
+	// tokens without a key id can be validated only when the key set
+	// contains a single key
+	if kid == "" && len(c.keys) == 1 {
+		for _, k := range c.keys {
+			return k, true
+		}
+	}
+
+	k, ok := c.keys[kid]
+	return k, ok
+}
+
+// get returns the keys matching the key id. When the key id is not
+// known, the key set is refreshed, because the issuer may have rotated
+// the keys.
+func (c *jwksCache) get(kid string) ([]jose.JSONWebKey, error) {
+	if k, ok := c.lookup(kid); ok {
+		return k, nil
+	}
+
+	if err := c.refresh(minJwksMissRefreshInterval); err != nil {
+		log.Errorf("Failed to refresh JWKS from %s: %v", c.url, err)
+	}
+
+	if k, ok := c.lookup(kid); ok {
+		return k, nil
+	}
+
+	c.mu.RLock()
+	loaded := c.keys != nil
+	c.mu.RUnlock()
+
+	if !loaded {
+		return nil, errJwksUnavailable
+	}
+
+	return nil, errJwtUnknownKey
+}
+
+func (f *jwtValidationFilter) validate(token string) (map[string]interface{}, error) {
+	t, err := jwt.ParseSigned(token)
+	if err != nil {
+		return nil, err
+	}
+
+	if len(t.Headers) != 1 || !jwtValidationAlgorithms[t.Headers[0].Algorithm] {
+		return nil, errJwtUnsupportedAlg
+	}
+
+	keys, err := f.keySet.get(t.Headers[0].KeyID)
+	if err != nil {
+		return nil, err
+	}
+
+	for _, k := range keys {
+		if k.Algorithm != "" && k.Algorithm != t.Headers[0].Algorithm {
+			continue
+		}
+
+		var (
+			standard jwt.Claims
+			claims   map[string]interface{}
+		)
+
+		if err := t.Claims(k.Key, &standard, &claims); err != nil {
+			continue
+		}
+
+		if err := standard.ValidateWithLeeway(f.expected.WithTime(time.Now()), jwtValidationLeeway); err != nil {
+			return nil, err
+		}
+
+		return claims, nil
+	}
+
+	return nil, errInvalidToken
+}
+
+func (f *jwtValidationFilter) Request(ctx filters.FilterContext) {
+	token, ok := getToken(ctx.Request())
+	if !ok || token == "" {
+		unauthorized(ctx, "", missingBearerToken, "", "")
+		return
+	}
+
+	claims, err := f.validate(token)
+	if err == errJwksUnavailable {
+		reject(ctx, http.StatusServiceUnavailable, "", authServiceAccess, "", err.Error())
+		return
+	}
+
+	if err != nil {
+		unauthorized(ctx, "", invalidToken, "", err.Error())
+		return
+	}
+
+	sub, _ := claims["sub"].(string)
+	ctx.StateBag()[jwtValidationCacheKey] = claims
+	authorized(ctx, sub)
+}
+
+func (f *jwtValidationFilter) Response(filters.FilterContext) {}
@@ -0,0 +1,371 @@
+package auth
+
+import (
+	"crypto/rand"
+	"crypto/rsa"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/zalando/skipper/filters/filtertest"
+	jose "gopkg.in/square/go-jose.v2"
+	"gopkg.in/square/go-jose.v2/jwt"
+)
+
+type testJwks struct {
+	mu       sync.Mutex
+	keys     []jose.JSONWebKey
+	requests int
+}
+
+func (j *testJwks) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	j.mu.Lock()
+	defer j.mu.Unlock()
+	j.requests++
+	json.NewEncoder(w).Encode(jose.JSONWebKeySet{Keys: j.keys})
+}
+
+func (j *testJwks) setKeys(keys ...*rsa.PrivateKey) {
+	j.mu.Lock()
+	defer j.mu.Unlock()
+	j.keys = nil
+	for i, k := range keys {
+		j.keys = append(j.keys, jose.JSONWebKey{Key: &k.PublicKey, KeyID: testKeyID(i), Algorithm: "RS256", Use: "sig"})
+	}
+}
+
+func testKeyID(i int) string {
+	return string(rune('a' + i))
+}
+
+func generateTestKey(t *testing.T) *rsa.PrivateKey {
+	k, err := rsa.GenerateKey(rand.Reader, 2048)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	return k
+}
+
+func signTestToken(t *testing.T, alg jose.SignatureAlgorithm, key interface{}, kid string, claims interface{}) string {
+	opts := (&jose.SignerOptions{}).WithType("JWT")
+	if kid != "" {
+		opts = opts.WithHeader("kid", kid)
+	}
+
+	s, err := jose.NewSigner(jose.SigningKey{Algorithm: alg, Key: key}, opts)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	token, err := jwt.Signed(s).Claims(claims).CompactSerialize()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	return token
+}
+
+func TestJwtValidationArgs(t *testing.T) {
+	jwks := &testJwks{}
+	server := httptest.NewServer(jwks)
+	defer server.Close()
+
+	for _, tt := range []struct {
+		name    string
+		args    []interface{}
+		wantErr bool
+	}{{
+		name:    "no args",
+		wantErr: true,
+	}, {
+		name:    "invalid url",
+		args:    []interface{}{"foo"},
+		wantErr: true,
+	}, {
+		name:    "not a string",
+		args:    []interface{}{42},
+		wantErr: true,
+	}, {
+		name:    "too many args",
+		args:    []interface{}{server.URL, "issuer", "audience", "foo"},
+		wantErr: true,
+	}, {
+		name: "jwks url",
+		args: []interface{}{server.URL},
+	}, {
+		name: "issuer and audience",
+		args: []interface{}{server.URL, "issuer", "audience"},
+	}} {
+		t.Run(tt.name, func(t *testing.T) {
+			spec := NewJwtValidation()
+			defer spec.Close()
+
+			_, err := spec.CreateFilter(tt.args)
+			if tt.wantErr && err == nil {
+				t.Error("failed to fail")
+			} else if !tt.wantErr && err != nil {
+				t.Error(err)
+			}
+		})
+	}
+}
+
+func TestJwtValidation(t *testing.T) {
+	key := generateTestKey(t)
+	otherKey := generateTestKey(t)
+
+	jwks := &testJwks{}
+	jwks.setKeys(key)
+	server := httptest.NewServer(jwks)
+	defer server.Close()
+
+	now := time.Now()
+	valid := jwt.Claims{
+		Subject:  "jdoe",
+		Issuer:   "https://issuer.example.org",
+		Audience: jwt.Audience{"my-service"},
+		Expiry:   jwt.NewNumericDate(now.Add(time.Hour)),
+	}
+
+	expired := valid
+	expired.Expiry = jwt.NewNumericDate(now.Add(-time.Hour))
+
+	otherIssuer := valid
+	otherIssuer.Issuer = "https://other.example.org"
+
+	spec := NewJwtValidation()
+	defer spec.Close()
+
+	f, err := spec.CreateFilter([]interface{}{server.URL, "https://issuer.example.org", "my-service"})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	for _, tt := range []struct {
+		name           string
+		token          string
+		wantStatus     int
+		wantAuthorized bool
+	}{{
+		name:       "missing token",
+		wantStatus: http.StatusUnauthorized,
+	}, {
+		name:       "malformed token",
+		token:      "foo.bar.baz",
+		wantStatus: http.StatusUnauthorized,
+	}, {
+		name:           "valid token",
+		token:          signTestToken(t, jose.RS256, key, "a", valid),
+		wantAuthorized: true,
+	}, {
+		name:       "expired token",
+		token:      signTestToken(t, jose.RS256, key, "a", expired),
+		wantStatus: http.StatusUnauthorized,
+	}, {
+		name:       "wrong issuer",
+		token:      signTestToken(t, jose.RS256, key, "a", otherIssuer),
+		wantStatus: http.StatusUnauthorized,
+	}, {
+		name:       "signed with another key",
+		token:      signTestToken(t, jose.RS256, otherKey, "a", valid),
+		wantStatus: http.StatusUnauthorized,
+	}, {
+		name:       "symmetric algorithm",
+		token:      signTestToken(t, jose.HS256, []byte("secret"), "a", valid),
+		wantStatus: http.StatusUnauthorized,
+	}, {
+		name:           "without key id, single key",
+		token:          signTestToken(t, jose.RS256, key, "", valid),
+		wantAuthorized: true,
+	}} {
+		t.Run(tt.name, func(t *testing.T) {
+			req, err := http.NewRequest("GET", "https://www.example.org", nil)
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			if tt.token != "" {
+				req.Header.Set(authHeaderName, authHeaderPrefix+tt.token)
+			}
+
+			ctx := &filtertest.Context{FRequest: req, FStateBag: make(map[string]interface{})}
+			f.Request(ctx)
+			if tt.wantAuthorized {
+				if ctx.FServed {
+					t.Fatalf("request rejected: %d", ctx.FResponse.StatusCode)
+				}
+
+				claims, ok := ctx.StateBag()[jwtValidationCacheKey].(map[string]interface{})
+				if !ok || claims["sub"] != "jdoe" {
+					t.Errorf("claims not stored: %v", ctx.StateBag()[jwtValidationCacheKey])
+				}
+
+				return
+			}
+
+			if !ctx.FServed || ctx.FResponse.StatusCode != tt.wantStatus {
+				t.Errorf("unexpected response, served: %t, response: %v", ctx.FServed, ctx.FResponse)
+			}
+		})
+	}
+}
+
+func TestJwtValidationKeyRotation(t *testing.T) {
+	key := generateTestKey(t)
+	rotated := generateTestKey(t)
+
+	jwks := &testJwks{}
+	jwks.setKeys(key)
+	server := httptest.NewServer(jwks)
+	defer server.Close()
+
+	spec := NewJwtValidation()
+	defer spec.Close()
+
+	f, err := spec.CreateFilter([]interface{}{server.URL})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// wait for the initial fetch
+	keySet := f.(*jwtValidationFilter).keySet
+	if _, err := keySet.get("a"); err != nil {
+		t.Fatal(err)
+	}
+
+	// the new key is published with the next key id
+	jwks.setKeys(key, rotated)
+	claims := jwt.Claims{Subject: "jdoe", Expiry: jwt.NewNumericDate(time.Now().Add(time.Hour))}
+	req, err := http.NewRequest("GET", "https://www.example.org", nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	req.Header.Set(authHeaderName, authHeaderPrefix+signTestToken(t, jose.RS256, rotated, "b", claims))
+	ctx := &filtertest.Context{FRequest: req, FStateBag: make(map[string]interface{})}
+
+	// the initial fetch counts as a recent refresh, which would skip the
+	// refresh triggered by the unknown key id
+	keySet.mu.Lock()
+	keySet.lastRefresh = time.Time{}
+	keySet.mu.Unlock()
+
+	f.Request(ctx)
+	if ctx.FServed {
+		t.Fatalf("token signed with the rotated key rejected: %d", ctx.FResponse.StatusCode)
+	}
+
+	// unknown key ids don't cause a refresh on every request
+	for i := 0; i < 3; i++ {
+		req.Header.Set(authHeaderName, authHeaderPrefix+signTestToken(t, jose.RS256, rotated, "unknown", claims))
+		ctx := &filtertest.Context{FRequest: req, FStateBag: make(map[string]interface{})}
+		f.Request(ctx)
+		if !ctx.FServed || ctx.FResponse.StatusCode != http.StatusUnauthorized {
+			t.Error("unknown key id accepted")
+		}
+	}
+
+	jwks.mu.Lock()
+	defer jwks.mu.Unlock()
+	if jwks.requests != 2 {
+		t.Errorf("unexpected number of JWKS requests: %d", jwks.requests)
+	}
+}
+
+func TestJwtValidationUnavailable(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusInternalServerError)
+	}))
+	defer server.Close()
+
+	spec := NewJwtValidation()
+	defer spec.Close()
+
+	f, err := spec.CreateFilter([]interface{}{server.URL})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	claims := jwt.Claims{Subject: "jdoe", Expiry: jwt.NewNumericDate(time.Now().Add(time.Hour))}
+	req, err := http.NewRequest("GET", "https://www.example.org", nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	req.Header.Set(authHeaderName, authHeaderPrefix+signTestToken(t, jose.RS256, generateTestKey(t), "a", claims))
+	ctx := &filtertest.Context{FRequest: req, FStateBag: make(map[string]interface{})}
+	f.Request(ctx)
+	if !ctx.FServed || ctx.FResponse.StatusCode != http.StatusServiceUnavailable {
+		t.Errorf("unexpected response, served: %t, response: %v", ctx.FServed, ctx.FResponse)
+	}
+}
+
+func TestJwtValidationFetchedInTheBackground(t *testing.T) {
+	release := make(chan struct{})
+	jwks := &testJwks{}
+	jwks.setKeys(generateTestKey(t))
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		<-release
+		jwks.ServeHTTP(w, r)
+	}))
+	defer server.Close()
+	defer close(release)
+
+	spec := NewJwtValidation()
+	defer spec.Close()
+
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		if _, err := spec.CreateFilter([]interface{}{server.URL}); err != nil {
+			t.Error(err)
+		}
+	}()
+
+	select {
+	case <-done:
+	case <-time.After(time.Second):
+		t.Fatal("creating the filter waits for the JWKS endpoint")
+	}
+}
+
+func TestJwtValidationClose(t *testing.T) {
+	jwks := &testJwks{}
+	jwks.setKeys(generateTestKey(t))
+	server := httptest.NewServer(jwks)
+	defer server.Close()
+
+	spec := NewJwtValidationWithOptions(JwtValidationOptions{RefreshInterval: 10 * time.Millisecond})
+	f, err := spec.CreateFilter([]interface{}{server.URL})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if _, err := f.(*jwtValidationFilter).keySet.get("a"); err != nil {
+		t.Fatal(err)
+	}
+
+	time.Sleep(50 * time.Millisecond)
+	spec.Close()
+
+	// a refresh may be in progress while closing
+	time.Sleep(20 * time.Millisecond)
+	jwks.mu.Lock()
+	requests := jwks.requests
+	jwks.mu.Unlock()
+
+	if requests < 2 {
+		t.Errorf("key set not refreshed: %d", requests)
+	}
+
+	time.Sleep(50 * time.Millisecond)
+	jwks.mu.Lock()
+	defer jwks.mu.Unlock()
+	if jwks.requests != requests {
+		t.Errorf("key set refreshed after closing: %d, %d", requests, jwks.requests)
+	}
+}
@@ -31,6 +31,11 @@ const (
 	stateValidity       = 1 * time.Minute
 	oidcInfoHeader      = "Skipper-Oidc-Info"
 	cookieMaxSize       = 4093 // common cookie size limit http://browsercookielimits.squawky.net/
+
+	// the state bag key of the session cookie value of the authorized
+	// requests, used by the csrfProtection filter to bind the tokens to
+	// the session
+	oidcSessionStateBagKey = "filter.oidc.session"
 )
 
 type (
@@ -38,6 +43,7 @@ type (
 		typ             roleCheckType
 		SecretsFile     string
 		secretsRegistry secrets.EncrypterCreator
+		options         OidcOptions
 	}
 
 	tokenOidcFilter struct {
@@ -51,6 +57,7 @@ type (
 		redirectPath    string
 		encrypter       secrets.Encryption
 		authCodeOptions []oauth2.AuthCodeOption
+		sessionStore    OidcSessionStore
 	}
 
 	userInfoContainer struct {
@@ -67,21 +74,48 @@ type (
 	}
 )
 
+// OidcOptions contains the options of the OpenID Connect filters.
+type OidcOptions struct {
+
+	// SessionStore stores the sessions on the server side. When not set,
+	// or when storing a session fails, the encrypted session is stored
+	// in the cookies.
+	SessionStore OidcSessionStore
+}
+
 // NewOAuthOidcUserInfos creates filter spec which tests user info.
 func NewOAuthOidcUserInfos(secretsFile string, secretsRegistry *secrets.Registry) filters.Spec {
-	return &tokenOidcSpec{typ: checkOIDCUserInfo, SecretsFile: secretsFile, secretsRegistry: secretsRegistry}
+	return NewOAuthOidcUserInfosWithOptions(secretsFile, secretsRegistry, OidcOptions{})
 }
 
 // NewOAuthOidcAnyClaims creates a filter spec which verifies that the token
 // has one of the claims specified
 func NewOAuthOidcAnyClaims(secretsFile string, secretsRegistry *secrets.Registry) filters.Spec {
-	return &tokenOidcSpec{typ: checkOIDCAnyClaims, SecretsFile: secretsFile, secretsRegistry: secretsRegistry}
+	return NewOAuthOidcAnyClaimsWithOptions(secretsFile, secretsRegistry, OidcOptions{})
 }
 
 // NewOAuthOidcAllClaims creates a filter spec which verifies that the token
 // has all the claims specified
 func NewOAuthOidcAllClaims(secretsFile string, secretsRegistry *secrets.Registry) filters.Spec {
-	return &tokenOidcSpec{typ: checkOIDCAllClaims, SecretsFile: secretsFile, secretsRegistry: secretsRegistry}
+	return NewOAuthOidcAllClaimsWithOptions(secretsFile, secretsRegistry, OidcOptions{})
+}
+
+// NewOAuthOidcUserInfosWithOptions creates filter spec which tests user
+// info, with the given options.
+func NewOAuthOidcUserInfosWithOptions(secretsFile string, secretsRegistry *secrets.Registry, o OidcOptions) filters.Spec {
+	return &tokenOidcSpec{typ: checkOIDCUserInfo, SecretsFile: secretsFile, secretsRegistry: secretsRegistry, options: o}
+}
+
+// NewOAuthOidcAnyClaimsWithOptions creates a filter spec which verifies
+// that the token has one of the claims specified, with the given options.
+func NewOAuthOidcAnyClaimsWithOptions(secretsFile string, secretsRegistry *secrets.Registry, o OidcOptions) filters.Spec {
+	return &tokenOidcSpec{typ: checkOIDCAnyClaims, SecretsFile: secretsFile, secretsRegistry: secretsRegistry, options: o}
+}
+
+// NewOAuthOidcAllClaimsWithOptions creates a filter spec which verifies
+// that the token has all the claims specified, with the given options.
+func NewOAuthOidcAllClaimsWithOptions(secretsFile string, secretsRegistry *secrets.Registry, o OidcOptions) filters.Spec {
+	return &tokenOidcSpec{typ: checkOIDCAllClaims, SecretsFile: secretsFile, secretsRegistry: secretsRegistry, options: o}
 }
 
 // CreateFilter creates an OpenID Connect authorization filter.
@@ -147,9 +181,10 @@ func (s *tokenOidcSpec) CreateFilter(args []interface{}) (filters.Filter, error)
 		verifier: provider.Verifier(&oidc.Config{
 			ClientID: sargs[1],
 		}),
-		validity:   1 * time.Hour,
-		cookiename: generatedCookieName,
-		encrypter:  encrypter,
+		validity:     1 * time.Hour,
+		cookiename:   generatedCookieName,
+		encrypter:    encrypter,
+		sessionStore: s.options.SessionStore,
 	}
 
 	// user defined scopes
@@ -399,7 +434,7 @@ func (f *tokenOidcFilter) doDownstreamRedirect(ctx filters.FilterContext, oidcSt
 
 	oidcCookies := chunkCookie(http.Cookie{
 		Name:     f.cookiename,
-		Value:    base64.StdEncoding.EncodeToString(oidcState),
+		Value:    f.storeSession(oidcState),
 		Path:     "/",
 		Secure:   true,
 		HttpOnly: true,
@@ -412,15 +447,58 @@ func (f *tokenOidcFilter) doDownstreamRedirect(ctx filters.FilterContext, oidcSt
 	ctx.Serve(r)
 }
 
+// storeSession returns the cookie value of the session. When a session
+// store is configured, the encrypted session is stored there, and the
+// cookie references it. Otherwise, or when the store fails, the cookie
+// contains the encrypted session.
+func (f *tokenOidcFilter) storeSession(encryptedData []byte) string {
+	if f.sessionStore != nil {
+		id, err := newOidcSessionID()
+		if err == nil {
+			err = f.sessionStore.Set(id, encryptedData, f.validity)
+		}
+
+		if err == nil {
+			return oidcSessionRefPrefix + id
+		}
+
+		log.Errorf("Failed to store oidc session, falling back to cookie: %v.", err)
+	}
+
+	return base64.StdEncoding.EncodeToString(encryptedData)
+}
+
+// loadSession returns the encrypted session referenced by the cookie
+// value, or contained by it.
+func (f *tokenOidcFilter) loadSession(value string) ([]byte, error) {
+	if !strings.HasPrefix(value, oidcSessionRefPrefix) {
+		return base64.StdEncoding.DecodeString(value)
+	}
+
+	if f.sessionStore == nil {
+		return nil, ErrOidcSessionNotFound
+	}
+
+	data, err := f.sessionStore.Get(strings.TrimPrefix(value, oidcSessionRefPrefix))
+	if err != nil && err != ErrOidcSessionNotFound {
+		log.Errorf("Failed to load oidc session: %v.", err)
+	}
+
+	return data, err
+}
+
 func (f *tokenOidcFilter) validateCookie(cookie *http.Cookie) ([]byte, bool) {
 	if cookie == nil {
 		log.Debugf("Cookie is nil")
 		return nil, false
 	}
 	log.Debugf("validate cookie name: %s", f.cookiename)
-	cookieStr, err := base64.StdEncoding.DecodeString(cookie.Value)
-	if err != nil {
-		log.Debugf("Base64 decoding the cookie failed: %v", err)
+	cookieStr, err := f.loadSession(cookie.Value)
+	if err == ErrOidcSessionNotFound {
+		log.Debugf("Session of the cookie not found")
+		return nil, false
+	} else if err != nil {
+		log.Debugf("Loading the session of the cookie failed: %v", err)
 		return nil, false
 	}
 	decryptedCookie, err := f.encrypter.Decrypt(cookieStr)
@@ -681,6 +759,7 @@ func (f *tokenOidcFilter) Request(ctx filters.FilterContext) {
 		return
 	}
 	ctx.Request().Header.Add(oidcInfoHeader, string(oidcInfoJson))
+	ctx.StateBag()[oidcSessionStateBagKey] = sessionCookie.Value
 }
 
 func (f *tokenOidcFilter) tokenClaims(ctx filters.FilterContext, oauth2Token *oauth2.Token) (map[string]interface{}, string, error) {
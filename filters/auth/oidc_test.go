@@ -826,3 +826,92 @@ func TestChunkAndMergerCookie(t *testing.T) {
 		})
 	}
 }
+
+type testOidcSessionStore struct {
+	sessions map[string][]byte
+	err      error
+}
+
+func (s *testOidcSessionStore) Get(id string) ([]byte, error) {
+	if s.err != nil {
+		return nil, s.err
+	}
+
+	data, ok := s.sessions[id]
+	if !ok {
+		return nil, ErrOidcSessionNotFound
+	}
+
+	return data, nil
+}
+
+func (s *testOidcSessionStore) Set(id string, data []byte, _ time.Duration) error {
+	if s.err != nil {
+		return s.err
+	}
+
+	s.sessions[id] = data
+	return nil
+}
+
+func TestOidcSessionStore(t *testing.T) {
+	for _, tc := range []struct {
+		msg       string
+		store     *testOidcSessionStore
+		expectRef bool
+	}{{
+		msg: "cookie store",
+	}, {
+		msg:       "server side store",
+		store:     &testOidcSessionStore{sessions: make(map[string][]byte)},
+		expectRef: true,
+	}, {
+		msg:   "fall back to cookie when the store fails",
+		store: &testOidcSessionStore{sessions: make(map[string][]byte), err: fmt.Errorf("connection refused")},
+	}} {
+		t.Run(tc.msg, func(t *testing.T) {
+			f, err := makeTestingFilter(nil)
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			if tc.store != nil {
+				f.sessionStore = tc.store
+			}
+
+			session := []byte(`{"subject":"jdoe"}`)
+			encrypted, err := f.encrypter.Encrypt(session)
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			value := f.storeSession(encrypted)
+			if strings.HasPrefix(value, oidcSessionRefPrefix) != tc.expectRef {
+				t.Fatalf("unexpected cookie value: %s", value)
+			}
+
+			data, ok := f.validateCookie(&http.Cookie{Value: value})
+			if !ok || string(data) != string(session) {
+				t.Errorf("failed to load session: %s", data)
+			}
+		})
+	}
+}
+
+func TestOidcSessionStoreMissingSession(t *testing.T) {
+	f, err := makeTestingFilter(nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	f.sessionStore = &testOidcSessionStore{sessions: make(map[string][]byte)}
+	if _, ok := f.validateCookie(&http.Cookie{Value: oidcSessionRefPrefix + "expired"}); ok {
+		t.Error("expired session accepted")
+	}
+
+	// references can't be resolved without a store
+	f.sessionStore = nil
+	if _, ok := f.validateCookie(&http.Cookie{Value: oidcSessionRefPrefix + "expired"}); ok {
+		t.Error("session reference accepted without a store")
+	}
+}
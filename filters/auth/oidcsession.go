@@ -0,0 +1,72 @@
+package auth
+
+import (
+	"crypto/rand"
+	"encoding/hex"
+	"errors"
+	"time"
+
+	"github.com/go-redis/redis/v7"
+)
+
+const (
+	// prefix of the cookie values referencing a server side session,
+	// instead of containing the encrypted session data. It is not part
+	// of the base64 alphabet, so the two kinds of values can't be mixed
+	// up.
+	oidcSessionRefPrefix = "session:"
+
+	oidcSessionIDSize = 32
+
+	redisOidcSessionKeyPrefix = "skipper:oidc:session:"
+)
+
+// ErrOidcSessionNotFound is returned by the OidcSessionStore
+// implementations, when a session doesn't exist or it expired.
+var ErrOidcSessionNotFound = errors.New("oidc session not found")
+
+// OidcSessionStore stores the data of the OpenID Connect sessions on the
+// server side, so that the cookies contain only the session id, instead
+// of the tokens and the claims, that may exceed the size limits of the
+// cookies. The data is encrypted by the filter before it is stored.
+type OidcSessionStore interface {
+
+	// Get returns the data of the session, or ErrOidcSessionNotFound.
+	Get(id string) ([]byte, error)
+
+	// Set stores the data of the session, until the ttl elapses.
+	Set(id string, data []byte, ttl time.Duration) error
+}
+
+type redisOidcSessionStore struct {
+	ring *redis.Ring
+}
+
+// NewRedisOidcSessionStore creates an OidcSessionStore, that stores the
+// sessions in the shards of a redis ring, with the validity of the
+// session as the expiration of the keys.
+func NewRedisOidcSessionStore(ring *redis.Ring) OidcSessionStore {
+	return &redisOidcSessionStore{ring: ring}
+}
+
+func (s *redisOidcSessionStore) Get(id string) ([]byte, error) {
+	data, err := s.ring.Get(redisOidcSessionKeyPrefix + id).Bytes()
+	if err == redis.Nil {
+		return nil, ErrOidcSessionNotFound
+	}
+
+	return data, err
+}
+
+func (s *redisOidcSessionStore) Set(id string, data []byte, ttl time.Duration) error {
+	return s.ring.Set(redisOidcSessionKeyPrefix+id, data, ttl).Err()
+}
+
+func newOidcSessionID() (string, error) {
+	b := make([]byte, oidcSessionIDSize)
+	if _, err := rand.Read(b); err != nil {
+		return "", err
+	}
+
+	return hex.EncodeToString(b), nil
+}
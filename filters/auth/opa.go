@@ -0,0 +1,269 @@
+package auth
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/opentracing/opentracing-go"
+	log "github.com/sirupsen/logrus"
+	"github.com/zalando/skipper/filters"
+	snet "github.com/zalando/skipper/net"
+	"golang.org/x/net/http/httpguts"
+)
+
+const (
+	OpaAuthorizeRequestName = "opaAuthorizeRequest"
+
+	opaSpanName = "opa"
+
+	// path of the Data API of the OPA server, https://www.openpolicyagent.org/docs/latest/rest-api/#data-api
+	opaDataAPIPath = "/v1/data/"
+
+	// maximum size of the decision document accepted from the OPA server
+	maxOpaResponseSize = 1 << 20
+)
+
+var errOpaUnexpectedResult = errors.New("unexpected result of the policy")
+
+// OpaOptions contains the options of the opaAuthorizeRequest filter.
+type OpaOptions struct {
+	Timeout      time.Duration
+	MaxIdleConns int
+	Tracer       opentracing.Tracer
+}
+
+type (
+	opaSpec struct {
+		options OpaOptions
+	}
+
+	opaFilter struct {
+		authClient *authClient
+		policy     string
+	}
+
+	// opaInput is the document passed to the policy as the input
+	opaInput struct {
+		Method     string                 `json:"method"`
+		Host       string                 `json:"host"`
+		Path       string                 `json:"path"`
+		Query      map[string][]string    `json:"query"`
+		Headers    map[string][]string    `json:"headers"`
+		RemoteAddr string                 `json:"remote_addr"`
+		Claims     map[string]interface{} `json:"claims,omitempty"`
+	}
+
+	opaResponse struct {
+		DecisionID string      `json:"decision_id"`
+		Result     interface{} `json:"result"`
+	}
+
+	opaDecision struct {
+		allow   bool
+		headers map[string]string
+	}
+)
+
+// NewOpaAuthorizeRequest creates a filter spec for the
+// opaAuthorizeRequest filter, with the given timeout of the requests to
+// the OPA server.
+func NewOpaAuthorizeRequest(timeout time.Duration) filters.Spec {
+	return OpaAuthorizeRequestWithOptions(OpaOptions{Timeout: timeout, Tracer: opentracing.NoopTracer{}})
+}
+
+// OpaAuthorizeRequestWithOptions creates a filter spec for the
+// opaAuthorizeRequest filter. The filter authorizes the requests by
+// querying a decision of a policy from an Open Policy Agent server, via its
+// Data API. The arguments are the base URL of the OPA server, and the path
+// of the decision in the policy:
+//
+//	r: * -> opaAuthorizeRequest("http://localhost:8181", "httpapi/authz/allow") -> "https://www.example.org";
+//
+// The policy receives the method, host, path, query, headers and remote
+// address of the request as the input, and the claims of the token, when
+// it was validated by a preceding auth filter, e.g. jwtValidation.
+//
+// The decision can be a boolean, or an object with a boolean allow field,
+// and an optional headers object, whose fields are set as headers of the
+// request sent to the backend. Requests are denied, when the decision is
+// undefined.
+func OpaAuthorizeRequestWithOptions(o OpaOptions) filters.Spec {
+	return &opaSpec{options: o}
+}
+
+func (*opaSpec) Name() string { return OpaAuthorizeRequestName }
+
+func (s *opaSpec) CreateFilter(args []interface{}) (filters.Filter, error) {
+	sargs, err := getStrings(args)
+	if err != nil {
+		return nil, err
+	}
+
+	if len(sargs) != 2 || sargs[0] == "" {
+		return nil, filters.ErrInvalidFilterParameters
+	}
+
+	policy := strings.Trim(sargs[1], "/")
+	if policy == "" {
+		return nil, filters.ErrInvalidFilterParameters
+	}
+
+	u := strings.TrimSuffix(sargs[0], "/") + opaDataAPIPath + policy
+	ac, err := newAuthClient(u, opaSpanName, s.options.Timeout, s.options.MaxIdleConns, s.options.Tracer)
+	if err != nil || ac.url.Host == "" {
+		return nil, filters.ErrInvalidFilterParameters
+	}
+
+	return &opaFilter{authClient: ac, policy: policy}, nil
+}
+
+// tokenClaims returns the result of the auth filters executed before,
+// when available
+func tokenClaims(ctx filters.FilterContext) map[string]interface{} {
+	for _, key := range []string{jwtValidationCacheKey, tokenintrospectionCacheKey, tokeninfoCacheKey, apiKeyCacheKey} {
+		switch v := ctx.StateBag()[key].(type) {
+		case map[string]interface{}:
+			return v
+		case tokenIntrospectionInfo:
+			return v
+		}
+	}
+
+	return nil
+}
+
+func newOpaInput(ctx filters.FilterContext) *opaInput {
+	r := ctx.Request()
+	in := &opaInput{
+		Method:  r.Method,
+		Host:    r.Host,
+		Path:    r.URL.Path,
+		Query:   r.URL.Query(),
+		Headers: make(map[string][]string),
+		Claims:  tokenClaims(ctx),
+	}
+
+	// the header names are lower case, so that the policies can reference
+	// them without knowing the canonical format
+	for k, v := range r.Header {
+		in.Headers[strings.ToLower(k)] = v
+	}
+
+	if ip := snet.RemoteHost(r); ip != nil {
+		in.RemoteAddr = ip.String()
+	}
+
+	return in
+}
+
+func parseOpaDecision(result interface{}) (opaDecision, error) {
+	switch v := result.(type) {
+	case nil:
+		// undefined decision
+		return opaDecision{}, nil
+	case bool:
+		return opaDecision{allow: v}, nil
+	case map[string]interface{}:
+		allow, ok := v["allow"].(bool)
+		if !ok {
+			return opaDecision{}, errOpaUnexpectedResult
+		}
+
+		d := opaDecision{allow: allow}
+		if h, ok := v["headers"].(map[string]interface{}); ok {
+			d.headers = make(map[string]string)
+			for name, value := range h {
+				s, ok := value.(string)
+				if !ok || !httpguts.ValidHeaderFieldName(name) || !httpguts.ValidHeaderFieldValue(s) {
+					return opaDecision{}, errOpaUnexpectedResult
+				}
+
+				d.headers[name] = s
+			}
+		}
+
+		return d, nil
+	default:
+		return opaDecision{}, errOpaUnexpectedResult
+	}
+}
+
+func (ac *authClient) getOpaDecision(input *opaInput) (*opaResponse, error) {
+	body, err := json.Marshal(map[string]interface{}{"input": input})
+	if err != nil {
+		return nil, err
+	}
+
+	req, err := http.NewRequest("POST", ac.url.String(), bytes.NewReader(body))
+	if err != nil {
+		return nil, err
+	}
+
+	req.Header.Set("Content-Type", "application/json")
+	rsp, err := ac.tr.RoundTrip(req)
+	if err != nil {
+		return nil, err
+	}
+
+	defer rsp.Body.Close()
+	if rsp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("unexpected status code: %d", rsp.StatusCode)
+	}
+
+	var decision opaResponse
+	err = json.NewDecoder(io.LimitReader(rsp.Body, maxOpaResponseSize)).Decode(&decision)
+	return &decision, err
+}
+
+func (f *opaFilter) Request(ctx filters.FilterContext) {
+	input := newOpaInput(ctx)
+	rsp, err := f.authClient.getOpaDecision(input)
+	if err != nil {
+		log.Errorf("Failed to query OPA decision %s: %v.", f.policy, err)
+		reject(ctx, http.StatusServiceUnavailable, "", authServiceAccess, "", OpaAuthorizeRequestName)
+		return
+	}
+
+	d, err := parseOpaDecision(rsp.Result)
+	if err != nil {
+		log.Errorf("Failed to evaluate OPA decision %s: %v.", f.policy, err)
+		reject(ctx, http.StatusServiceUnavailable, "", authServiceAccess, "", OpaAuthorizeRequestName)
+		return
+	}
+
+	user, _ := input.Claims["sub"].(string)
+	entry := log.WithFields(log.Fields{
+		"policy":      f.policy,
+		"decision_id": rsp.DecisionID,
+		"method":      input.Method,
+		"host":        input.Host,
+		"path":        input.Path,
+		"allow":       d.allow,
+	})
+
+	if !d.allow {
+		entry.Info("OPA decision")
+		forbidden(ctx, user, invalidAccess, OpaAuthorizeRequestName)
+		return
+	}
+
+	entry.Debug("OPA decision")
+	for name, value := range d.headers {
+		ctx.Request().Header.Set(name, value)
+	}
+
+	authorized(ctx, user)
+}
+
+func (*opaFilter) Response(filters.FilterContext) {}
+
+// Close cleans-up the authClient
+func (f *opaFilter) Close() {
+	f.authClient.Close()
+}
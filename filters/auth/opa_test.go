@@ -0,0 +1,172 @@
+package auth
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/zalando/skipper/filters/filtertest"
+)
+
+func TestOpaAuthorizeRequestArgs(t *testing.T) {
+	for _, tt := range []struct {
+		name    string
+		args    []interface{}
+		wantErr bool
+	}{{
+		name:    "no args",
+		wantErr: true,
+	}, {
+		name:    "missing policy",
+		args:    []interface{}{"http://localhost:8181"},
+		wantErr: true,
+	}, {
+		name:    "empty policy",
+		args:    []interface{}{"http://localhost:8181", "/"},
+		wantErr: true,
+	}, {
+		name:    "invalid url",
+		args:    []interface{}{"localhost", "authz/allow"},
+		wantErr: true,
+	}, {
+		name:    "not a string",
+		args:    []interface{}{"http://localhost:8181", 42},
+		wantErr: true,
+	}, {
+		name: "url and policy",
+		args: []interface{}{"http://localhost:8181/", "/authz/allow"},
+	}} {
+		t.Run(tt.name, func(t *testing.T) {
+			f, err := NewOpaAuthorizeRequest(0).CreateFilter(tt.args)
+			if tt.wantErr {
+				if err == nil {
+					t.Error("failed to fail")
+				}
+
+				return
+			}
+
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			if u := f.(*opaFilter).authClient.url.String(); u != "http://localhost:8181/v1/data/authz/allow" {
+				t.Errorf("unexpected url: %s", u)
+			}
+		})
+	}
+}
+
+func TestOpaAuthorizeRequest(t *testing.T) {
+	for _, tt := range []struct {
+		name          string
+		status        int
+		result        string
+		wantStatus    int
+		wantHeader    string
+		wantAuthorize bool
+	}{{
+		name:          "allowed",
+		result:        `{"decision_id": "1", "result": true}`,
+		wantAuthorize: true,
+	}, {
+		name:       "denied",
+		result:     `{"decision_id": "2", "result": false}`,
+		wantStatus: http.StatusForbidden,
+	}, {
+		name:       "undefined decision",
+		result:     `{"decision_id": "3"}`,
+		wantStatus: http.StatusForbidden,
+	}, {
+		name:          "allowed with headers",
+		result:        `{"result": {"allow": true, "headers": {"X-User-Role": "admin"}}}`,
+		wantAuthorize: true,
+		wantHeader:    "admin",
+	}, {
+		name:       "denied with object",
+		result:     `{"result": {"allow": false}}`,
+		wantStatus: http.StatusForbidden,
+	}, {
+		name:       "unexpected result",
+		result:     `{"result": "yes"}`,
+		wantStatus: http.StatusServiceUnavailable,
+	}, {
+		name:       "invalid header",
+		result:     `{"result": {"allow": true, "headers": {"X-User-Role": 42}}}`,
+		wantStatus: http.StatusServiceUnavailable,
+	}, {
+		name:       "server error",
+		status:     http.StatusInternalServerError,
+		wantStatus: http.StatusServiceUnavailable,
+	}} {
+		t.Run(tt.name, func(t *testing.T) {
+			var input opaInput
+			opa := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				if r.Method != "POST" || r.URL.Path != "/v1/data/authz/allow" {
+					t.Errorf("unexpected request: %s %s", r.Method, r.URL.Path)
+				}
+
+				var body struct {
+					Input *opaInput `json:"input"`
+				}
+
+				body.Input = &input
+				if err := json.NewDecoder(r.Body).Decode(&body); err != nil {
+					t.Error(err)
+				}
+
+				if tt.status != 0 {
+					w.WriteHeader(tt.status)
+					return
+				}
+
+				w.Write([]byte(tt.result))
+			}))
+			defer opa.Close()
+
+			f, err := NewOpaAuthorizeRequest(0).CreateFilter([]interface{}{opa.URL, "authz/allow"})
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			req, err := http.NewRequest("DELETE", "https://www.example.org/orders/42?force=true", nil)
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			req.RemoteAddr = "192.168.0.1:1234"
+			req.Header.Set("X-Tenant", "acme")
+			ctx := &filtertest.Context{FRequest: req, FStateBag: map[string]interface{}{
+				jwtValidationCacheKey: map[string]interface{}{"sub": "jdoe"},
+			}}
+
+			f.Request(ctx)
+			if input.Method != "DELETE" ||
+				input.Path != "/orders/42" ||
+				input.Host != "www.example.org" ||
+				input.RemoteAddr != "192.168.0.1" ||
+				len(input.Query["force"]) != 1 ||
+				len(input.Headers["x-tenant"]) != 1 ||
+				input.Claims["sub"] != "jdoe" {
+				t.Errorf("unexpected input: %+v", input)
+			}
+
+			if tt.wantAuthorize {
+				if ctx.FServed {
+					t.Fatalf("request rejected: %d", ctx.FResponse.StatusCode)
+				}
+
+				if h := req.Header.Get("X-User-Role"); h != tt.wantHeader {
+					t.Errorf("unexpected header, expected: %s, got: %s", tt.wantHeader, h)
+				}
+
+				return
+			}
+
+			if !ctx.FServed || ctx.FResponse.StatusCode != tt.wantStatus {
+				t.Errorf("unexpected response, served: %t, response: %v", ctx.FServed, ctx.FResponse)
+			}
+		})
+	}
+}
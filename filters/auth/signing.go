@@ -0,0 +1,382 @@
+package auth
+
+import (
+	"bytes"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"net/url"
+	"sort"
+	"strings"
+	"time"
+
+	log "github.com/sirupsen/logrus"
+	"github.com/zalando/skipper/filters"
+	snet "github.com/zalando/skipper/net"
+	"github.com/zalando/skipper/secrets"
+)
+
+const (
+	HmacSignRequestName = "hmacSignRequest"
+	AwsSigV4Name        = "awsSigV4"
+
+	hmacAlgorithm     = "HMAC-SHA256"
+	awsSigV4Algorithm = "AWS4-HMAC-SHA256"
+	signingTimeFormat = "20060102T150405Z"
+	unsignedPayload   = "UNSIGNED-PAYLOAD"
+
+	// maximum size of the request bodies that are read into memory in
+	// order to calculate their hash
+	maxSignedBodySize = 2 << 20
+)
+
+type signingType int
+
+const (
+	signHmac signingType = iota
+	signAwsSigV4
+)
+
+type (
+	signingSpec struct {
+		typ           signingType
+		secretsReader secrets.SecretsReader
+	}
+	signingFilter struct {
+		typ           signingType
+		secretsReader secrets.SecretsReader
+
+		// hmac
+		secretName string
+		keyID      string
+
+		// aws
+		region              string
+		service             string
+		accessKeyIDName     string
+		secretAccessKeyName string
+		sessionTokenName    string
+
+		now func() time.Time
+	}
+)
+
+// NewHmacSignRequest creates a filter spec for the hmacSignRequest()
+// filter. The filter signs the backend requests with HMAC-SHA256 over the
+// canonical method, path, query, host, timestamp and body hash, using
+// the secret read from the secrets reader, and an optional key id:
+//
+//	r: * -> hmacSignRequest("internal-api-key", "skipper") -> "https://internal.example.org";
+//
+// The timestamp and the body hash are set in the X-Date and
+// X-Content-Sha256 headers, and the signature in the Authorization
+// header:
+//
+//	Authorization: HMAC-SHA256 KeyId=skipper, SignedHeaders=host;x-content-sha256;x-date, Signature=<hex>
+func NewHmacSignRequest(sr secrets.SecretsReader) filters.Spec {
+	return &signingSpec{typ: signHmac, secretsReader: sr}
+}
+
+// NewAwsSigV4 creates a filter spec for the awsSigV4() filter. The
+// filter signs the backend requests with the AWS Signature Version 4.
+// The arguments are the region, the service, and the names of the
+// secrets holding the access key id, the secret access key, and
+// optionally the session token:
+//
+//	r: * -> awsSigV4("eu-central-1", "s3", "aws-access-key-id", "aws-secret-access-key") -> "https://bucket.s3.eu-central-1.amazonaws.com";
+//
+// The S3 request bodies are not hashed, and sent with the
+// UNSIGNED-PAYLOAD content hash.
+func NewAwsSigV4(sr secrets.SecretsReader) filters.Spec {
+	return &signingSpec{typ: signAwsSigV4, secretsReader: sr}
+}
+
+func (s *signingSpec) Name() string {
+	if s.typ == signAwsSigV4 {
+		return AwsSigV4Name
+	}
+
+	return HmacSignRequestName
+}
+
+func (s *signingSpec) CreateFilter(args []interface{}) (filters.Filter, error) {
+	sargs := make([]string, len(args))
+	for i, a := range args {
+		var ok bool
+		if sargs[i], ok = a.(string); !ok || sargs[i] == "" {
+			return nil, filters.ErrInvalidFilterParameters
+		}
+	}
+
+	f := &signingFilter{typ: s.typ, secretsReader: s.secretsReader, now: time.Now}
+	switch s.typ {
+	case signHmac:
+		if len(sargs) < 1 || len(sargs) > 2 {
+			return nil, filters.ErrInvalidFilterParameters
+		}
+
+		f.secretName = sargs[0]
+		if len(sargs) == 2 {
+			f.keyID = sargs[1]
+		}
+	case signAwsSigV4:
+		if len(sargs) < 4 || len(sargs) > 5 {
+			return nil, filters.ErrInvalidFilterParameters
+		}
+
+		f.region, f.service = sargs[0], sargs[1]
+		f.accessKeyIDName, f.secretAccessKeyName = sargs[2], sargs[3]
+		if len(sargs) == 5 {
+			f.sessionTokenName = sargs[4]
+		}
+	}
+
+	return f, nil
+}
+
+func (f *signingFilter) secret(name string) ([]byte, bool) {
+	s, ok := f.secretsReader.GetSecret(name)
+	if !ok {
+		log.Errorf("Failed to sign request, secret not found: %s.", name)
+		return nil, false
+	}
+
+	return bytes.TrimSpace(s), true
+}
+
+// uriEncode encodes a string as defined by the AWS signature, leaving
+// only the unreserved characters unescaped
+func uriEncode(s string, encodeSlash bool) string {
+	var b strings.Builder
+	for i := 0; i < len(s); i++ {
+		c := s[i]
+		switch {
+		case 'A' <= c && c <= 'Z', 'a' <= c && c <= 'z', '0' <= c && c <= '9',
+			c == '-', c == '.', c == '_', c == '~', c == '/' && !encodeSlash:
+			b.WriteByte(c)
+		default:
+			fmt.Fprintf(&b, "%%%02X", c)
+		}
+	}
+
+	return b.String()
+}
+
+func canonicalQuery(u *url.URL) string {
+	q := u.Query()
+	keys := make([]string, 0, len(q))
+	for k := range q {
+		keys = append(keys, k)
+	}
+
+	sort.Strings(keys)
+	var parts []string
+	for _, k := range keys {
+		values := append([]string(nil), q[k]...)
+		sort.Strings(values)
+		for _, v := range values {
+			parts = append(parts, uriEncode(k, true)+"="+uriEncode(v, true))
+		}
+	}
+
+	return strings.Join(parts, "&")
+}
+
+// canonicalRequest creates the canonical form of the request, that is
+// the base of both the HMAC and the AWS signatures. The signed headers
+// need to be in lower case and sorted.
+func canonicalRequest(req *http.Request, canonicalURI string, headers map[string]string, payloadHash string) (string, string) {
+	names := make([]string, 0, len(headers))
+	for name := range headers {
+		names = append(names, name)
+	}
+
+	sort.Strings(names)
+	var canonicalHeaders strings.Builder
+	for _, name := range names {
+		canonicalHeaders.WriteString(name + ":" + strings.TrimSpace(headers[name]) + "\n")
+	}
+
+	signedHeaders := strings.Join(names, ";")
+	return strings.Join([]string{
+		req.Method,
+		canonicalURI,
+		canonicalQuery(req.URL),
+		canonicalHeaders.String(),
+		signedHeaders,
+		payloadHash,
+	}, "\n"), signedHeaders
+}
+
+func hashHex(b []byte) string {
+	h := sha256.Sum256(b)
+	return hex.EncodeToString(h[:])
+}
+
+func hmacSHA256(key []byte, data string) []byte {
+	h := hmac.New(sha256.New, key)
+	h.Write([]byte(data))
+	return h.Sum(nil)
+}
+
+var errSignedBodyTooLarge = errors.New("request body too large")
+
+// payloadHash reads the request body, and returns its hash. When the body
+// is too large, it is passed on unchanged.
+func payloadHash(req *http.Request) (string, error) {
+	if req.Body == nil || req.Body == http.NoBody || req.ContentLength == 0 {
+		return hashHex(nil), nil
+	}
+
+	body, rest, err := snet.ReadBody(req.Body, maxSignedBodySize)
+	if rest != nil {
+		req.Body = rest
+		if err == nil {
+			err = errSignedBodyTooLarge
+		}
+
+		return "", err
+	}
+
+	req.Body = ioutil.NopCloser(bytes.NewReader(body))
+	req.ContentLength = int64(len(body))
+	return hashHex(body), nil
+}
+
+func (f *signingFilter) signHmac(req *http.Request, host string, now time.Time) {
+	secret, ok := f.secret(f.secretName)
+	if !ok {
+		return
+	}
+
+	hash, err := payloadHash(req)
+	if err != nil {
+		log.Errorf("Failed to sign request, %v.", err)
+		return
+	}
+
+	date := now.UTC().Format(signingTimeFormat)
+	req.Header.Set("X-Date", date)
+	req.Header.Set("X-Content-Sha256", hash)
+
+	canonical, signedHeaders := canonicalRequest(
+		req,
+		uriEncode(req.URL.Path, false),
+		map[string]string{"host": host, "x-content-sha256": hash, "x-date": date},
+		hash,
+	)
+
+	stringToSign := hmacAlgorithm + "\n" + date + "\n" + hashHex([]byte(canonical))
+	signature := hex.EncodeToString(hmacSHA256(secret, stringToSign))
+
+	var keyID string
+	if f.keyID != "" {
+		keyID = "KeyId=" + f.keyID + ", "
+	}
+
+	req.Header.Set(authHeaderName, fmt.Sprintf(
+		"%s %sSignedHeaders=%s, Signature=%s",
+		hmacAlgorithm,
+		keyID,
+		signedHeaders,
+		signature,
+	))
+}
+
+func (f *signingFilter) signAwsSigV4(req *http.Request, host string, now time.Time) {
+	accessKeyID, ok := f.secret(f.accessKeyIDName)
+	if !ok {
+		return
+	}
+
+	secretAccessKey, ok := f.secret(f.secretAccessKeyName)
+	if !ok {
+		return
+	}
+
+	headers := map[string]string{"host": host}
+	if f.sessionTokenName != "" {
+		token, ok := f.secret(f.sessionTokenName)
+		if !ok {
+			return
+		}
+
+		req.Header.Set("X-Amz-Security-Token", string(token))
+		headers["x-amz-security-token"] = string(token)
+	}
+
+	// S3 expects the path segments encoded once, the other services
+	// twice, and only S3 accepts unsigned payloads
+	var hash string
+	canonicalURI := uriEncode(req.URL.Path, false)
+	if f.service == "s3" {
+		hash = unsignedPayload
+		if req.ContentLength == 0 {
+			hash = hashHex(nil)
+		}
+
+		req.Header.Set("X-Amz-Content-Sha256", hash)
+		headers["x-amz-content-sha256"] = hash
+	} else {
+		canonicalURI = uriEncode(canonicalURI, false)
+
+		var err error
+		if hash, err = payloadHash(req); err != nil {
+			log.Errorf("Failed to sign request, %v.", err)
+			return
+		}
+	}
+
+	if canonicalURI == "" {
+		canonicalURI = "/"
+	}
+
+	date := now.UTC().Format(signingTimeFormat)
+	req.Header.Set("X-Amz-Date", date)
+	headers["x-amz-date"] = date
+
+	canonical, signedHeaders := canonicalRequest(req, canonicalURI, headers, hash)
+	scope := strings.Join([]string{date[:8], f.region, f.service, "aws4_request"}, "/")
+	stringToSign := strings.Join([]string{
+		awsSigV4Algorithm,
+		date,
+		scope,
+		hashHex([]byte(canonical)),
+	}, "\n")
+
+	key := hmacSHA256([]byte("AWS4"+string(secretAccessKey)), date[:8])
+	key = hmacSHA256(key, f.region)
+	key = hmacSHA256(key, f.service)
+	key = hmacSHA256(key, "aws4_request")
+	signature := hex.EncodeToString(hmacSHA256(key, stringToSign))
+
+	req.Header.Set(authHeaderName, fmt.Sprintf(
+		"%s Credential=%s/%s, SignedHeaders=%s, Signature=%s",
+		awsSigV4Algorithm,
+		accessKeyID,
+		scope,
+		signedHeaders,
+		signature,
+	))
+}
+
+func (f *signingFilter) Request(ctx filters.FilterContext) {
+	req := ctx.Request()
+	host := ctx.OutgoingHost()
+	if host == "" {
+		host = req.Host
+	}
+
+	switch f.typ {
+	case signHmac:
+		f.signHmac(req, host, f.now())
+	case signAwsSigV4:
+		f.signAwsSigV4(req, host, f.now())
+	}
+}
+
+func (*signingFilter) Response(filters.FilterContext) {}
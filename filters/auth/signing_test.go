@@ -0,0 +1,214 @@
+package auth
+
+import (
+	"bytes"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"io/ioutil"
+	"net/http"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/zalando/skipper/filters"
+	"github.com/zalando/skipper/filters/filtertest"
+)
+
+// test credentials from the AWS Signature Version 4 test suite
+var awsTestSecrets = mapSecretsReader{
+	"access-key-id":     []byte("AKIDEXAMPLE\n"),
+	"secret-access-key": []byte("wJalrXUtnFEMI/K7MDENG+bPxRfiCYEXAMPLEKEY\n"),
+	"hmac-secret":       []byte("secret"),
+}
+
+func testSigningFilter(t *testing.T, spec filters.Spec, args ...interface{}) *signingFilter {
+	f, err := spec.CreateFilter(args)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	sf := f.(*signingFilter)
+	sf.now = func() time.Time { return time.Date(2015, 8, 30, 12, 36, 0, 0, time.UTC) }
+	return sf
+}
+
+func TestSigningCreateFilter(t *testing.T) {
+	for _, ti := range []struct {
+		msg  string
+		spec filters.Spec
+		args []interface{}
+		err  bool
+	}{{
+		msg:  "hmac without secret",
+		spec: NewHmacSignRequest(awsTestSecrets),
+		err:  true,
+	}, {
+		msg:  "hmac with invalid key id",
+		spec: NewHmacSignRequest(awsTestSecrets),
+		args: []interface{}{"hmac-secret", 42.0},
+		err:  true,
+	}, {
+		msg:  "hmac",
+		spec: NewHmacSignRequest(awsTestSecrets),
+		args: []interface{}{"hmac-secret", "skipper"},
+	}, {
+		msg:  "aws without secrets",
+		spec: NewAwsSigV4(awsTestSecrets),
+		args: []interface{}{"us-east-1", "service"},
+		err:  true,
+	}, {
+		msg:  "aws with empty region",
+		spec: NewAwsSigV4(awsTestSecrets),
+		args: []interface{}{"", "service", "access-key-id", "secret-access-key"},
+		err:  true,
+	}, {
+		msg:  "aws",
+		spec: NewAwsSigV4(awsTestSecrets),
+		args: []interface{}{"us-east-1", "service", "access-key-id", "secret-access-key", "session-token"},
+	}} {
+		t.Run(ti.msg, func(t *testing.T) {
+			_, err := ti.spec.CreateFilter(ti.args)
+			if ti.err && err == nil {
+				t.Error("failed to fail")
+			} else if !ti.err && err != nil {
+				t.Error(err)
+			}
+		})
+	}
+}
+
+func TestAwsSigV4(t *testing.T) {
+	for _, ti := range []struct {
+		msg       string
+		url       string
+		signature string
+	}{{
+		msg:       "get-vanilla",
+		url:       "https://example.amazonaws.com/",
+		signature: "5fa00fa31553b73ebf1942676e86291e8372ff2a2260956d9b8aae1d763fbf31",
+	}, {
+		msg:       "get-vanilla-query-order-key-case",
+		url:       "https://example.amazonaws.com/?Param2=value2&Param1=value1",
+		signature: "b97d918cfa904a5beff61c982a1b6f458b799221646efd99d3219ec94cdf2500",
+	}} {
+		t.Run(ti.msg, func(t *testing.T) {
+			f := testSigningFilter(t, NewAwsSigV4(awsTestSecrets), "us-east-1", "service", "access-key-id", "secret-access-key")
+			req, err := http.NewRequest("GET", ti.url, nil)
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			f.Request(&filtertest.Context{FRequest: req, FOutgoingHost: "example.amazonaws.com"})
+
+			expected := "AWS4-HMAC-SHA256 Credential=AKIDEXAMPLE/20150830/us-east-1/service/aws4_request, " +
+				"SignedHeaders=host;x-amz-date, Signature=" + ti.signature
+			if a := req.Header.Get("Authorization"); a != expected {
+				t.Errorf("unexpected authorization, expected: %s, got: %s", expected, a)
+			}
+
+			if d := req.Header.Get("X-Amz-Date"); d != "20150830T123600Z" {
+				t.Errorf("unexpected date: %s", d)
+			}
+		})
+	}
+}
+
+func TestAwsSigV4S3(t *testing.T) {
+	f := testSigningFilter(t, NewAwsSigV4(awsTestSecrets), "us-east-1", "s3", "access-key-id", "secret-access-key")
+	req, err := http.NewRequest("PUT", "https://bucket.s3.amazonaws.com/some%20key", bytes.NewBufferString("content"))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	f.Request(&filtertest.Context{FRequest: req, FOutgoingHost: "bucket.s3.amazonaws.com"})
+	if h := req.Header.Get("X-Amz-Content-Sha256"); h != unsignedPayload {
+		t.Errorf("unexpected content hash: %s", h)
+	}
+
+	if a := req.Header.Get("Authorization"); !strings.Contains(a, "SignedHeaders=host;x-amz-content-sha256;x-amz-date,") {
+		t.Errorf("unexpected authorization: %s", a)
+	}
+
+	if b, err := ioutil.ReadAll(req.Body); err != nil || string(b) != "content" {
+		t.Errorf("body not preserved: %s, %v", b, err)
+	}
+}
+
+func TestAwsSigV4MissingSecret(t *testing.T) {
+	f := testSigningFilter(t, NewAwsSigV4(awsTestSecrets), "us-east-1", "service", "access-key-id", "secret-access-key", "session-token")
+	req, err := http.NewRequest("GET", "https://example.amazonaws.com/", nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	f.Request(&filtertest.Context{FRequest: req, FOutgoingHost: "example.amazonaws.com"})
+	if a := req.Header.Get("Authorization"); a != "" {
+		t.Errorf("request signed without the session token: %s", a)
+	}
+}
+
+func TestHmacSignRequest(t *testing.T) {
+	f := testSigningFilter(t, NewHmacSignRequest(awsTestSecrets), "hmac-secret", "skipper")
+	req, err := http.NewRequest("POST", "https://internal.example.org/api/items?b=2&a=1", bytes.NewBufferString(`{"id":1}`))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	f.Request(&filtertest.Context{FRequest: req, FOutgoingHost: "internal.example.org"})
+
+	bodyHash := sha256.Sum256([]byte(`{"id":1}`))
+	if h := req.Header.Get("X-Content-Sha256"); h != hex.EncodeToString(bodyHash[:]) {
+		t.Errorf("unexpected content hash: %s", h)
+	}
+
+	canonical := strings.Join([]string{
+		"POST",
+		"/api/items",
+		"a=1&b=2",
+		"host:internal.example.org\nx-content-sha256:" + hex.EncodeToString(bodyHash[:]) + "\nx-date:20150830T123600Z\n",
+		"host;x-content-sha256;x-date",
+		hex.EncodeToString(bodyHash[:]),
+	}, "\n")
+
+	canonicalHash := sha256.Sum256([]byte(canonical))
+	mac := hmac.New(sha256.New, []byte("secret"))
+	mac.Write([]byte("HMAC-SHA256\n20150830T123600Z\n" + hex.EncodeToString(canonicalHash[:])))
+	expected := "HMAC-SHA256 KeyId=skipper, SignedHeaders=host;x-content-sha256;x-date, Signature=" +
+		hex.EncodeToString(mac.Sum(nil))
+	if a := req.Header.Get("Authorization"); a != expected {
+		t.Errorf("unexpected authorization, expected: %s, got: %s", expected, a)
+	}
+
+	if b, err := ioutil.ReadAll(req.Body); err != nil || string(b) != `{"id":1}` {
+		t.Errorf("body not preserved: %s, %v", b, err)
+	}
+}
+
+func TestHmacSignRequestBodyTooLarge(t *testing.T) {
+	f := testSigningFilter(t, NewHmacSignRequest(awsTestSecrets), "hmac-secret")
+	body := bytes.Repeat([]byte("x"), maxSignedBodySize+1)
+	req, err := http.NewRequest("POST", "https://internal.example.org/upload", bytes.NewReader(body))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	f.Request(&filtertest.Context{FRequest: req, FOutgoingHost: "internal.example.org"})
+	if a := req.Header.Get("Authorization"); a != "" {
+		t.Errorf("unexpected authorization: %s", a)
+	}
+
+	if b, err := ioutil.ReadAll(req.Body); err != nil || !bytes.Equal(b, body) {
+		t.Errorf("body not preserved: %d, %v", len(b), err)
+	}
+}
+
+func TestUriEncode(t *testing.T) {
+	if s := uriEncode("/a b/ü~.-_", false); s != "/a%20b/%C3%BC~.-_" {
+		t.Errorf("unexpected path encoding: %s", s)
+	}
+
+	if s := uriEncode("a/b+c", true); s != "a%2Fb%2Bc" {
+		t.Errorf("unexpected query encoding: %s", s)
+	}
+}
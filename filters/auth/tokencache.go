@@ -0,0 +1,157 @@
+package auth
+
+import (
+	"container/list"
+	"crypto/sha256"
+	"encoding/hex"
+	"sync"
+	"time"
+
+	"github.com/zalando/skipper/filters"
+)
+
+type (
+	tokenCacheEntry struct {
+		key     string
+		value   interface{}
+		expires time.Time
+	}
+
+	// tokenCache holds the results of the remote token validations, or
+	// the exchanged tokens, until they expire, and evicts the least
+	// recently used ones when it is full
+	tokenCache struct {
+		maxSize int
+
+		mu      sync.Mutex
+		entries map[string]*list.Element
+		lru     *list.List
+	}
+)
+
+func newTokenCache(maxSize int) *tokenCache {
+	return &tokenCache{
+		maxSize: maxSize,
+		entries: make(map[string]*list.Element),
+		lru:     list.New(),
+	}
+}
+
+// tokenCacheKey hashes the parts of the key, so that the tokens are not
+// kept in the memory
+func tokenCacheKey(parts ...string) string {
+	h := sha256.New()
+	for _, p := range parts {
+		h.Write([]byte(p))
+		h.Write([]byte{0})
+	}
+
+	return hex.EncodeToString(h.Sum(nil))
+}
+
+func (c *tokenCache) get(key string, now time.Time) (interface{}, bool) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	e, ok := c.entries[key]
+	if !ok {
+		return nil, false
+	}
+
+	entry := e.Value.(*tokenCacheEntry)
+	if !now.Before(entry.expires) {
+		c.lru.Remove(e)
+		delete(c.entries, key)
+		return nil, false
+	}
+
+	c.lru.MoveToFront(e)
+	return entry.value, true
+}
+
+func (c *tokenCache) set(key string, value interface{}, expires time.Time) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if e, ok := c.entries[key]; ok {
+		entry := e.Value.(*tokenCacheEntry)
+		entry.value = value
+		entry.expires = expires
+		c.lru.MoveToFront(e)
+		return
+	}
+
+	c.entries[key] = c.lru.PushFront(&tokenCacheEntry{key: key, value: value, expires: expires})
+	for c.lru.Len() > c.maxSize {
+		e := c.lru.Back()
+		c.lru.Remove(e)
+		delete(c.entries, e.Value.(*tokenCacheEntry).key)
+	}
+}
+
+// countTokenCache counts the cache hits and misses in the custom metrics
+// of the filter
+func countTokenCache(ctx filters.FilterContext, key string) {
+	if m := ctx.Metrics(); m != nil {
+		m.IncCounter(key)
+	}
+}
+
+// tokenCacheExpiry returns when a token validation result expires in the
+// cache, the earlier of the TTL and the expiry of the token, when the
+// result contains it
+func tokenCacheExpiry(now time.Time, ttl time.Duration, info map[string]interface{}) time.Time {
+	expires := now.Add(ttl)
+
+	// tokeninfo
+	if v, ok := info["expires_in"].(float64); ok {
+		if e := now.Add(time.Duration(v) * time.Second); e.Before(expires) {
+			expires = e
+		}
+	}
+
+	// token introspection
+	if v, ok := info["exp"].(float64); ok {
+		if e := time.Unix(int64(v), 0); e.Before(expires) {
+			expires = e
+		}
+	}
+
+	return expires
+}
+
+// cachedTokenValidation returns the cached validation result of the token,
+// or calls validate, and caches its result. Invalid tokens are cached as
+// well, but failures of the remote service are not.
+func (ac *authClient) cachedTokenValidation(
+	ctx filters.FilterContext,
+	token string,
+	validate func() (map[string]interface{}, error),
+) (map[string]interface{}, error) {
+	if ac.cache == nil {
+		return validate()
+	}
+
+	key := tokenCacheKey(token)
+	now := time.Now()
+	if v, ok := ac.cache.get(key, now); ok {
+		if err, ok := v.(error); ok {
+			countTokenCache(ctx, "cache.negative")
+			return nil, err
+		}
+
+		countTokenCache(ctx, "cache.hit")
+		return v.(map[string]interface{}), nil
+	}
+
+	countTokenCache(ctx, "cache.miss")
+	info, err := validate()
+	switch err {
+	case nil:
+		ac.cache.set(key, info, tokenCacheExpiry(now, ac.cacheTTL, info))
+	case errInvalidToken:
+		ac.cache.set(key, err, now.Add(ac.cacheTTL))
+	}
+
+	return info, err
+}
@@ -0,0 +1,137 @@
+package auth
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/zalando/skipper/filters/filtertest"
+	"github.com/zalando/skipper/metrics/metricstest"
+)
+
+func TestTokenCacheEviction(t *testing.T) {
+	c := newTokenCache(2)
+	now := time.Now()
+	c.set("a", "token-a", now.Add(time.Minute))
+	c.set("b", "token-b", now.Add(time.Minute))
+	c.get("a", now)
+	c.set("c", "token-c", now.Add(time.Minute))
+
+	if _, ok := c.get("b", now); ok {
+		t.Error("least recently used token not evicted")
+	}
+
+	if token, ok := c.get("a", now); !ok || token.(string) != "token-a" {
+		t.Error("recently used token evicted")
+	}
+
+	if _, ok := c.get("c", now.Add(2*time.Minute)); ok {
+		t.Error("expired token returned")
+	}
+}
+
+func TestTokenCacheExpiry(t *testing.T) {
+	now := time.Now()
+	for _, tt := range []struct {
+		name string
+		info map[string]interface{}
+		want time.Time
+	}{{
+		name: "ttl",
+		info: map[string]interface{}{"uid": "jdoe"},
+		want: now.Add(time.Minute),
+	}, {
+		name: "tokeninfo expires earlier",
+		info: map[string]interface{}{"expires_in": float64(10)},
+		want: now.Add(10 * time.Second),
+	}, {
+		name: "tokeninfo expires later",
+		info: map[string]interface{}{"expires_in": float64(3600)},
+		want: now.Add(time.Minute),
+	}, {
+		name: "introspection expires earlier",
+		info: map[string]interface{}{"exp": float64(now.Add(30 * time.Second).Unix())},
+		want: time.Unix(now.Add(30*time.Second).Unix(), 0),
+	}} {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := tokenCacheExpiry(now, time.Minute, tt.info); !got.Equal(tt.want) {
+				t.Errorf("unexpected expiry, got: %v, want: %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestTokeninfoCache(t *testing.T) {
+	var mu sync.Mutex
+	requests := 0
+	authServer := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		mu.Lock()
+		requests++
+		mu.Unlock()
+
+		if token, _ := getToken(r); token != testToken {
+			w.WriteHeader(http.StatusUnauthorized)
+			return
+		}
+
+		json.NewEncoder(w).Encode(map[string]interface{}{
+			"uid":   testUID,
+			"scope": []string{testScope},
+		})
+	}))
+	defer authServer.Close()
+
+	spec := NewOAuthTokeninfoAnyScopeWithOptions(TokeninfoOptions{
+		URL:       authServer.URL + testAuthPath,
+		Timeout:   time.Second,
+		CacheSize: 10,
+		CacheTTL:  time.Minute,
+	})
+
+	f, err := spec.CreateFilter([]interface{}{testScope})
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer f.(*tokeninfoFilter).Close()
+
+	m := &metricstest.MockMetrics{}
+	request := func(token string) *filtertest.Context {
+		req, err := http.NewRequest("GET", "https://www.example.org", nil)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		req.Header.Set(authHeaderName, authHeaderPrefix+token)
+		ctx := &filtertest.Context{FRequest: req, FStateBag: make(map[string]interface{}), FMetrics: m}
+		f.Request(ctx)
+		return ctx
+	}
+
+	for i := 0; i < 3; i++ {
+		if ctx := request(testToken); ctx.FServed {
+			t.Fatalf("valid token rejected: %d", ctx.FResponse.StatusCode)
+		}
+
+		if ctx := request("invalid-token"); !ctx.FServed || ctx.FResponse.StatusCode != http.StatusUnauthorized {
+			t.Fatal("invalid token not rejected")
+		}
+	}
+
+	mu.Lock()
+	n := requests
+	mu.Unlock()
+	if n != 2 {
+		t.Errorf("tokeninfo responses not cached, requests: %d", n)
+	}
+
+	m.WithCounters(func(counters map[string]int64) {
+		for key, want := range map[string]int64{"cache.miss": 2, "cache.hit": 2, "cache.negative": 2} {
+			if counters[key] != want {
+				t.Errorf("unexpected %s count: %d, want: %d", key, counters[key], want)
+			}
+		}
+	})
+}
@@ -0,0 +1,219 @@
+package auth
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/zalando/skipper/filters"
+	"github.com/zalando/skipper/filters/filtertest"
+)
+
+type testTokenEndpoint struct {
+	mu       sync.Mutex
+	requests int
+	status   int
+	forms    []map[string][]string
+	users    []string
+}
+
+func (e *testTokenEndpoint) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+
+	e.requests++
+	r.ParseForm()
+	e.forms = append(e.forms, r.PostForm)
+	user, _, _ := r.BasicAuth()
+	e.users = append(e.users, user)
+
+	if e.status != 0 {
+		w.WriteHeader(e.status)
+		return
+	}
+
+	if r.PostForm.Get("grant_type") != tokenExchangeGrantType {
+		w.WriteHeader(http.StatusBadRequest)
+		return
+	}
+
+	json.NewEncoder(w).Encode(map[string]interface{}{
+		"access_token":      r.PostForm.Get("subject_token") + "@" + r.PostForm.Get("audience"),
+		"issued_token_type": tokenExchangeAccessToken,
+		"token_type":        "Bearer",
+		"expires_in":        3600,
+	})
+}
+
+func (e *testTokenEndpoint) count() int {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	return e.requests
+}
+
+func TestTokenExchangeArgs(t *testing.T) {
+	for _, tt := range []struct {
+		name    string
+		args    []interface{}
+		wantErr bool
+	}{{
+		name:    "no args",
+		wantErr: true,
+	}, {
+		name:    "no audience",
+		args:    []interface{}{"https://auth.example.org/token"},
+		wantErr: true,
+	}, {
+		name:    "invalid url",
+		args:    []interface{}{"foo", "orders"},
+		wantErr: true,
+	}, {
+		name:    "not a string",
+		args:    []interface{}{"https://auth.example.org/token", 42},
+		wantErr: true,
+	}, {
+		name: "audience",
+		args: []interface{}{"https://auth.example.org/token", "orders"},
+	}, {
+		name: "audience and scopes",
+		args: []interface{}{"https://auth.example.org/token", "orders", "orders.read", "orders.write"},
+	}} {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := NewOAuthTokenExchange(time.Second).CreateFilter(tt.args)
+			if tt.wantErr && err == nil {
+				t.Error("failed to fail")
+			} else if !tt.wantErr && err != nil {
+				t.Error(err)
+			}
+		})
+	}
+}
+
+func tokenExchangeRequest(t *testing.T, f filters.Filter, token string) *filtertest.Context {
+	req, err := http.NewRequest("GET", "https://www.example.org", nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if token != "" {
+		req.Header.Set(authHeaderName, authHeaderPrefix+token)
+	}
+
+	ctx := &filtertest.Context{FRequest: req, FStateBag: make(map[string]interface{})}
+	f.Request(ctx)
+	return ctx
+}
+
+func TestTokenExchange(t *testing.T) {
+	endpoint := &testTokenEndpoint{}
+	server := httptest.NewServer(endpoint)
+	defer server.Close()
+
+	spec := NewOAuthTokenExchange(time.Second)
+	f, err := spec.CreateFilter([]interface{}{
+		"http://client:secret@" + server.Listener.Addr().String() + "/token",
+		"orders",
+		"orders.read",
+		"orders.write",
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	other, err := spec.CreateFilter([]interface{}{server.URL + "/token", "payments"})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	ctx := tokenExchangeRequest(t, f, "")
+	if !ctx.FServed || ctx.FResponse.StatusCode != http.StatusUnauthorized {
+		t.Fatalf("request without token not rejected: %v", ctx.FResponse)
+	}
+
+	ctx = tokenExchangeRequest(t, f, "user-token")
+	if ctx.FServed {
+		t.Fatalf("request rejected: %d", ctx.FResponse.StatusCode)
+	}
+
+	if h := ctx.Request().Header.Get(authHeaderName); h != authHeaderPrefix+"user-token@orders" {
+		t.Errorf("unexpected authorization header: %s", h)
+	}
+
+	endpoint.mu.Lock()
+	form, user := endpoint.forms[0], endpoint.users[0]
+	endpoint.mu.Unlock()
+
+	if user != "client" {
+		t.Errorf("client credentials not sent: %q", user)
+	}
+
+	for k, v := range map[string]string{
+		"subject_token":      "user-token",
+		"subject_token_type": tokenExchangeAccessToken,
+		"audience":           "orders",
+		"scope":              "orders.read orders.write",
+	} {
+		if got := form[k]; len(got) != 1 || got[0] != v {
+			t.Errorf("unexpected %s: %v", k, got)
+		}
+	}
+
+	// the exchanged token is cached by the subject and the audience
+	tokenExchangeRequest(t, f, "user-token")
+	if n := endpoint.count(); n != 1 {
+		t.Errorf("exchanged token not cached, requests: %d", n)
+	}
+
+	ctx = tokenExchangeRequest(t, other, "user-token")
+	if h := ctx.Request().Header.Get(authHeaderName); h != authHeaderPrefix+"user-token@payments" {
+		t.Errorf("unexpected authorization header for another audience: %s", h)
+	}
+
+	tokenExchangeRequest(t, f, "other-user-token")
+	if n := endpoint.count(); n != 3 {
+		t.Errorf("unexpected number of token requests: %d", n)
+	}
+}
+
+func TestTokenExchangeErrors(t *testing.T) {
+	for _, tt := range []struct {
+		name       string
+		status     int
+		wantStatus int
+	}{{
+		name:       "subject token rejected",
+		status:     http.StatusBadRequest,
+		wantStatus: http.StatusUnauthorized,
+	}, {
+		name:       "exchange not allowed",
+		status:     http.StatusForbidden,
+		wantStatus: http.StatusForbidden,
+	}, {
+		name:       "invalid client credentials",
+		status:     http.StatusUnauthorized,
+		wantStatus: http.StatusServiceUnavailable,
+	}, {
+		name:       "token endpoint error",
+		status:     http.StatusInternalServerError,
+		wantStatus: http.StatusServiceUnavailable,
+	}} {
+		t.Run(tt.name, func(t *testing.T) {
+			endpoint := &testTokenEndpoint{status: tt.status}
+			server := httptest.NewServer(endpoint)
+			defer server.Close()
+
+			f, err := NewOAuthTokenExchange(time.Second).CreateFilter([]interface{}{server.URL, "orders"})
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			ctx := tokenExchangeRequest(t, f, "user-token")
+			if !ctx.FServed || ctx.FResponse.StatusCode != tt.wantStatus {
+				t.Errorf("unexpected response, served: %t, response: %v", ctx.FServed, ctx.FResponse)
+			}
+		})
+	}
+}
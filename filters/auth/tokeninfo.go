@@ -23,6 +23,15 @@ type TokeninfoOptions struct {
 	Timeout      time.Duration
 	MaxIdleConns int
 	Tracer       opentracing.Tracer
+
+	// CacheSize is the maximum number of tokeninfo responses cached,
+	// shared by the filters using the same tokeninfo URL. The cache is
+	// disabled when it is 0.
+	CacheSize int
+
+	// CacheTTL is the maximum time a tokeninfo response is cached. The
+	// responses are not cached beyond the expiry of the token.
+	CacheTTL time.Duration
 }
 
 type (
@@ -183,6 +192,11 @@ func (s *tokeninfoSpec) CreateFilter(args []interface{}) (filters.Filter, error)
 		tokeninfoAuthClient[s.options.URL] = ac
 	}
 
+	if ac.cache == nil && s.options.CacheSize > 0 && s.options.CacheTTL > 0 {
+		ac.cache = newTokenCache(s.options.CacheSize)
+		ac.cacheTTL = s.options.CacheTTL
+	}
+
 	f := &tokeninfoFilter{typ: s.typ, authClient: ac, kv: make(map[string][]string)}
 	switch f.typ {
 	// all scopes
@@ -315,7 +329,9 @@ func (f *tokeninfoFilter) Request(ctx filters.FilterContext) {
 		}
 
 		var err error
-		authMap, err = f.authClient.getTokeninfo(token, ctx)
+		authMap, err = f.authClient.cachedTokenValidation(ctx, token, func() (map[string]interface{}, error) {
+			return f.authClient.getTokeninfo(token, ctx)
+		})
 		if err != nil {
 			reason := authServiceAccess
 			if err == errInvalidToken {
@@ -32,6 +32,15 @@ type TokenintrospectionOptions struct {
 	Timeout      time.Duration
 	Tracer       opentracing.Tracer
 	MaxIdleConns int
+
+	// CacheSize is the maximum number of introspection responses cached,
+	// shared by the filters using the same issuer. The cache is disabled
+	// when it is 0.
+	CacheSize int
+
+	// CacheTTL is the maximum time an introspection response is cached.
+	// The responses are not cached beyond the expiry of the token.
+	CacheTTL time.Duration
 }
 
 type (
@@ -289,6 +298,11 @@ func (s *tokenIntrospectionSpec) CreateFilter(args []interface{}) (filters.Filte
 		issuerAuthClient[issuerURL] = ac
 	}
 
+	if ac.cache == nil && s.options.CacheSize > 0 && s.options.CacheTTL > 0 {
+		ac.cache = newTokenCache(s.options.CacheSize)
+		ac.cacheTTL = s.options.CacheTTL
+	}
+
 	if s.secure && clientId != "" && clientSecret != "" {
 		ac.url.User = url.UserPassword(clientId, clientSecret)
 	} else {
@@ -419,7 +433,11 @@ func (f *tokenintrospectFilter) Request(ctx filters.FilterContext) {
 		}
 
 		var err error
-		info, err = f.authClient.getTokenintrospect(token, ctx)
+		var infoMap map[string]interface{}
+		infoMap, err = f.authClient.cachedTokenValidation(ctx, token, func() (map[string]interface{}, error) {
+			return f.authClient.getTokenintrospect(token, ctx)
+		})
+		info = infoMap
 		if err != nil {
 			reason := authServiceAccess
 			if err == errInvalidToken {
@@ -0,0 +1,81 @@
+package builtin
+
+import (
+	"bytes"
+	"io"
+	"io/ioutil"
+
+	log "github.com/sirupsen/logrus"
+	"github.com/zalando/skipper/filters"
+	snet "github.com/zalando/skipper/net"
+)
+
+type bufferRequestBody struct {
+	maxSize int64
+}
+
+// NewBufferRequestBody creates a filter spec for the bufferRequestBody()
+// filter. The filter reads the request body into memory, up to the
+// maximum size in bytes given as the argument, so that the request can
+// be replayed, e.g. when retrying a load balanced backend, or when it is
+// teed to a shadow backend.
+//
+// Usage of the filter:
+//
+//	r: * -> bufferRequestBody(1048576) -> <roundRobin, "http://b1.example.org", "http://b2.example.org">;
+//
+// When the body is larger than the maximum size, it is forwarded without
+// being buffered, and it cannot be replayed.
+func NewBufferRequestBody() filters.Spec {
+	return &bufferRequestBody{}
+}
+
+func (b *bufferRequestBody) Name() string { return BufferRequestBodyName }
+
+func (b *bufferRequestBody) CreateFilter(args []interface{}) (filters.Filter, error) {
+	if len(args) != 1 {
+		return nil, filters.ErrInvalidFilterParameters
+	}
+
+	var maxSize int64
+	switch v := args[0].(type) {
+	case float64:
+		maxSize = int64(v)
+	case int:
+		maxSize = int64(v)
+	default:
+		return nil, filters.ErrInvalidFilterParameters
+	}
+
+	if maxSize <= 0 {
+		return nil, filters.ErrInvalidFilterParameters
+	}
+
+	return &bufferRequestBody{maxSize: maxSize}, nil
+}
+
+func (b *bufferRequestBody) Request(ctx filters.FilterContext) {
+	req := ctx.Request()
+	if req.Body == nil || req.GetBody != nil || req.ContentLength > b.maxSize {
+		return
+	}
+
+	buf, rest, err := snet.ReadBody(req.Body, b.maxSize)
+	if err != nil {
+		log.Errorf("Failed to buffer request body: %v.", err)
+	}
+
+	if rest != nil {
+		// forwarding the rest of the body, the error, if any, is
+		// returned to the proxy again
+		req.Body = rest
+		return
+	}
+
+	req.Body = ioutil.NopCloser(bytes.NewReader(buf))
+	req.GetBody = func() (io.ReadCloser, error) {
+		return ioutil.NopCloser(bytes.NewReader(buf)), nil
+	}
+}
+
+func (b *bufferRequestBody) Response(filters.FilterContext) {}
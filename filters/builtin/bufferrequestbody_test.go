@@ -0,0 +1,98 @@
+package builtin
+
+import (
+	"io/ioutil"
+	"net/http"
+	"strings"
+	"testing"
+
+	"github.com/zalando/skipper/filters/filtertest"
+)
+
+func TestBufferRequestBodyArgs(t *testing.T) {
+	for _, args := range [][]interface{}{
+		nil,
+		{"1024"},
+		{float64(0)},
+		{float64(-1)},
+		{float64(1024), float64(2048)},
+	} {
+		if _, err := NewBufferRequestBody().CreateFilter(args); err == nil {
+			t.Errorf("expected error for args: %v", args)
+		}
+	}
+}
+
+func TestBufferRequestBody(t *testing.T) {
+	for _, tt := range []struct {
+		msg        string
+		body       string
+		maxSize    float64
+		replayable bool
+	}{{
+		msg:        "buffered",
+		body:       "Hello, world!",
+		maxSize:    1024,
+		replayable: true,
+	}, {
+		msg:        "exact size",
+		body:       "Hello, world!",
+		maxSize:    13,
+		replayable: true,
+	}, {
+		msg:        "too large",
+		body:       "Hello, world!",
+		maxSize:    12,
+		replayable: false,
+	}} {
+		t.Run(tt.msg, func(t *testing.T) {
+			f, err := NewBufferRequestBody().CreateFilter([]interface{}{tt.maxSize})
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			req, err := http.NewRequest("POST", "http://www.example.org", nil)
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			// like incoming requests, with unknown length
+			req.Body = ioutil.NopCloser(strings.NewReader(tt.body))
+			req.ContentLength = -1
+
+			ctx := &filtertest.Context{FRequest: req}
+			f.Request(ctx)
+
+			if (req.GetBody != nil) != tt.replayable {
+				t.Fatalf("expected replayable: %v", tt.replayable)
+			}
+
+			b, err := ioutil.ReadAll(req.Body)
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			if string(b) != tt.body {
+				t.Errorf("unexpected body: %s", b)
+			}
+
+			if !tt.replayable {
+				return
+			}
+
+			replay, err := req.GetBody()
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			b, err = ioutil.ReadAll(replay)
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			if string(b) != tt.body {
+				t.Errorf("unexpected replayed body: %s", b)
+			}
+		})
+	}
+}
@@ -5,17 +5,22 @@ package builtin
 
 import (
 	"github.com/zalando/skipper/filters"
+	"github.com/zalando/skipper/filters/abtest"
 	"github.com/zalando/skipper/filters/accesslog"
 	"github.com/zalando/skipper/filters/auth"
 	"github.com/zalando/skipper/filters/circuit"
 	"github.com/zalando/skipper/filters/cookie"
 	"github.com/zalando/skipper/filters/cors"
 	"github.com/zalando/skipper/filters/diag"
+	"github.com/zalando/skipper/filters/fadein"
 	"github.com/zalando/skipper/filters/flowid"
+	"github.com/zalando/skipper/filters/jsonschema"
 	logfilter "github.com/zalando/skipper/filters/log"
 	"github.com/zalando/skipper/filters/ratelimit"
 	"github.com/zalando/skipper/filters/rfc"
 	"github.com/zalando/skipper/filters/scheduler"
+	"github.com/zalando/skipper/filters/sed"
+	"github.com/zalando/skipper/filters/shedder"
 	"github.com/zalando/skipper/filters/tee"
 	"github.com/zalando/skipper/filters/tracing"
 	"github.com/zalando/skipper/script"
@@ -37,6 +42,12 @@ const (
 	AppendResponseHeaderName = "appendResponseHeader"
 	DropRequestHeaderName    = "dropRequestHeader"
 	DropResponseHeaderName   = "dropResponseHeader"
+	SecurityHeadersName      = "securityHeaders"
+
+	SetRequestJsonFieldName  = "setRequestJsonField"
+	SetResponseJsonFieldName = "setResponseJsonField"
+	DelRequestJsonFieldName  = "delRequestJsonField"
+	DelResponseJsonFieldName = "delResponseJsonField"
 
 	SetDynamicBackendHostFromHeader   = "setDynamicBackendHostFromHeader"
 	SetDynamicBackendSchemeFromHeader = "setDynamicBackendSchemeFromHeader"
@@ -55,11 +66,28 @@ const (
 	PreserveHostName    = "preserveHost"
 	StatusName          = "status"
 	CompressName        = "compress"
+	DecompressName      = "decompress"
 	SetQueryName        = "setQuery"
 	DropQueryName       = "dropQuery"
 	InlineContentName   = "inlineContent"
+	ErrorPageName       = "errorPage"
+	ErrorPageFileName   = "errorPageFile"
 	HeaderToQueryName   = "headerToQuery"
 	QueryToHeaderName   = "queryToHeader"
+
+	FlushIntervalName                = "flushInterval"
+	BufferRequestBodyName            = "bufferRequestBody"
+	MultipartUploadLimitName         = "multipartUploadLimit"
+	BackendTimeoutName               = "backendTimeout"
+	BackendResponseHeaderTimeoutName = "backendResponseHeaderTimeout"
+	ReadTimeoutName                  = "readTimeout"
+	MaxRequestBodyName               = "maxRequestBody"
+	EgressProxyName                  = "egressProxy"
+	ServerSentEventsName             = "serverSentEvents"
+	BackendMaxIdleConnsPerHostName   = "backendMaxIdleConnsPerHost"
+	BackendDisableKeepAlivesName     = "backendDisableKeepAlives"
+	BackendIdleConnTimeoutName       = "backendIdleConnTimeout"
+	BackendProxyProtocolName         = "backendProxyProtocol"
 )
 
 // Returns a Registry object initialized with the default set of filter
@@ -69,6 +97,19 @@ func MakeRegistry() filters.Registry {
 	r := make(filters.Registry)
 	for _, s := range []filters.Spec{
 		NewBackendIsProxy(),
+		NewEgressProxy(),
+		NewFlushInterval(),
+		NewServerSentEvents(),
+		NewBufferRequestBody(),
+		NewMultipartUploadLimit(),
+		NewBackendTimeout(),
+		NewBackendResponseHeaderTimeout(),
+		NewReadTimeout(),
+		NewMaxRequestBody(),
+		NewBackendMaxIdleConnsPerHost(),
+		NewBackendDisableKeepAlives(),
+		NewBackendIdleConnTimeout(),
+		NewBackendProxyProtocol(),
 		NewRequestHeader(),
 		NewSetRequestHeader(),
 		NewAppendRequestHeader(),
@@ -77,6 +118,11 @@ func MakeRegistry() filters.Registry {
 		NewSetResponseHeader(),
 		NewAppendResponseHeader(),
 		NewDropResponseHeader(),
+		NewSecurityHeaders(),
+		NewSetRequestJsonField(),
+		NewSetResponseJsonField(),
+		NewDelRequestJsonField(),
+		NewDelResponseJsonField(),
 		NewModPath(),
 		NewSetPath(),
 		NewDropQuery(),
@@ -88,10 +134,14 @@ func MakeRegistry() filters.Registry {
 		NewRedirectLower(),
 		NewStripQuery(),
 		NewInlineContent(),
+		NewErrorPage(),
+		NewErrorPageFile(),
+		NewRequestCoalescing(),
 		flowid.New(),
 		PreserveHost(),
 		NewStatus(),
 		NewCompress(),
+		NewDecompress(),
 		NewCopyRequestHeader(),
 		NewCopyResponseHeader(),
 		NewHeaderToQuery(),
@@ -111,13 +161,21 @@ func MakeRegistry() filters.Registry {
 		diag.NewBackendBandwidth(),
 		diag.NewBackendChunks(),
 		diag.NewAbsorb(),
+		diag.NewFault(),
+		diag.NewAbortConnection(),
 		tee.NewTee(),
 		tee.NewTeeDeprecated(),
 		tee.NewTeeNoFollow(),
+		tee.NewMirrorTraffic(),
 		auth.NewBasicAuth(),
 		cookie.NewRequestCookie(),
 		cookie.NewResponseCookie(),
 		cookie.NewJSCookie(),
+		cookie.NewDropRequestCookie(),
+		cookie.NewDropResponseCookie(),
+		cookie.NewRewriteResponseCookieDomain(),
+		cookie.NewRewriteResponseCookiePath(),
+		cookie.NewEnforceResponseCookieFlags(),
 		circuit.NewConsecutiveBreaker(),
 		circuit.NewRateBreaker(),
 		circuit.NewDisableBreaker(),
@@ -129,6 +187,9 @@ func MakeRegistry() filters.Registry {
 		ratelimit.NewDisableRatelimit(),
 		script.NewLuaScript(),
 		cors.NewOrigin(),
+		cors.NewCors(),
+		fadein.NewFadeIn(),
+		fadein.NewEndpointCreated(),
 		logfilter.NewUnverifiedAuditLog(),
 		tracing.NewSpanName(),
 		tracing.NewBaggageToTagFilter(),
@@ -139,7 +200,13 @@ func MakeRegistry() filters.Registry {
 		auth.NewForwardToken(),
 		scheduler.NewLIFO(),
 		scheduler.NewLIFOGroup(),
+		scheduler.NewLIFOPriority(),
+		scheduler.NewFIFO(),
+		shedder.NewAdmissionControl(),
+		abtest.New(),
 		rfc.NewPath(),
+		sed.New(),
+		jsonschema.New(),
 	} {
 		r.Register(s)
 	}
@@ -0,0 +1,267 @@
+package builtin
+
+import (
+	"bytes"
+	"io"
+	"io/ioutil"
+	"net/http"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/zalando/skipper/filters"
+)
+
+const (
+	RequestCoalescingName = "requestCoalescing"
+
+	// the responses larger than this are not shared with the waiting
+	// requests, they are forwarded to the backend separately
+	maxCoalescedBodySize = 1 << 20
+
+	// the waiting requests are forwarded to the backend separately, when
+	// the response of the first request takes longer than this
+	maxCoalescingWait = 30 * time.Second
+
+	coalescingStateBagKey = "filter." + RequestCoalescingName
+)
+
+type (
+	coalescingSpec struct{}
+
+	coalescedResult struct {
+		statusCode int
+		header     http.Header
+		body       []byte
+	}
+
+	// the result is set only once, together with closing done, and it
+	// is read only after done was closed
+	coalescedCall struct {
+		once   sync.Once
+		done   chan struct{}
+		result *coalescedResult
+	}
+
+	coalescingLeader struct {
+		key  string
+		call *coalescedCall
+	}
+
+	coalescingFilter struct {
+		headers []string
+
+		mu    sync.Mutex
+		calls map[string]*coalescedCall
+	}
+
+	coalescedBody struct {
+		io.Reader
+		closer io.Closer
+	}
+)
+
+// NewRequestCoalescing creates a filter spec whose filter instances
+// coalesce the concurrent, identical GET and HEAD requests into a single
+// backend request, and share its response with all of them, to protect
+// expensive endpoints from thundering herds. The requests are identical,
+// when their method, host, path, query, credentials and the headers passed
+// as the arguments of the filter are the same. Eskip example:
+//
+// 	r: * -> requestCoalescing("Accept", "Accept-Language") -> "https://www.example.org";
+//
+// The requests waiting for the first one receive a copy of its response,
+// when it is not larger than 1MB. Otherwise, or when the first request was
+// canceled, or it takes longer than 30 seconds, or the response is private
+// or sets cookies, they are forwarded to the backend separately. The failed
+// backend requests are turned into responses with the status code of the
+// error, to share them, too.
+func NewRequestCoalescing() filters.Spec { return coalescingSpec{} }
+
+func (coalescingSpec) Name() string { return RequestCoalescingName }
+
+func (coalescingSpec) CreateFilter(args []interface{}) (filters.Filter, error) {
+	f := &coalescingFilter{calls: make(map[string]*coalescedCall)}
+	for _, a := range args {
+		h, ok := a.(string)
+		if !ok || h == "" {
+			return nil, filters.ErrInvalidFilterParameters
+		}
+
+		f.headers = append(f.headers, http.CanonicalHeaderKey(h))
+	}
+
+	return f, nil
+}
+
+func (b coalescedBody) Close() error { return b.closer.Close() }
+
+// the credentials are always part of the request identity, to avoid
+// sharing the responses between different users
+var coalescingCredentialHeaders = []string{"Authorization", "Cookie"}
+
+func writeCoalescingHeaders(k *strings.Builder, r *http.Request, headers []string) {
+	for _, h := range headers {
+		k.WriteByte(0)
+		k.WriteString(strings.Join(r.Header[h], ","))
+	}
+}
+
+func (f *coalescingFilter) key(r *http.Request) string {
+	var k strings.Builder
+	k.WriteString(r.Method)
+	k.WriteByte(0)
+	k.WriteString(r.Host)
+	k.WriteByte(0)
+	k.WriteString(r.URL.RequestURI())
+	writeCoalescingHeaders(&k, r, coalescingCredentialHeaders)
+	writeCoalescingHeaders(&k, r, f.headers)
+	return k.String()
+}
+
+// release removes the call from the pending ones, publishes the result,
+// when not nil, and lets the waiting requests continue. It is safe to call
+// it multiple times, only the first call has an effect.
+func (f *coalescingFilter) release(l *coalescingLeader, result *coalescedResult) {
+	l.call.once.Do(func() {
+		f.mu.Lock()
+		if f.calls[l.key] == l.call {
+			delete(f.calls, l.key)
+		}
+
+		f.mu.Unlock()
+		l.call.result = result
+		close(l.call.done)
+	})
+}
+
+func (f *coalescingFilter) Request(ctx filters.FilterContext) {
+	r := ctx.Request()
+	if r.Method != "GET" && r.Method != "HEAD" {
+		return
+	}
+
+	key := f.key(r)
+	f.mu.Lock()
+	c, ok := f.calls[key]
+	if !ok {
+		c = &coalescedCall{done: make(chan struct{})}
+		f.calls[key] = c
+		f.mu.Unlock()
+
+		l := &coalescingLeader{key: key, call: c}
+		ctx.StateBag()[coalescingStateBagKey] = l
+		ctx.StateBag()[filters.BackendErrorResponseKey] = true
+
+		// the response filters are skipped in some cases, e.g. when
+		// the request was rate limited, so the call is released at the
+		// latest when the request is done
+		go func() {
+			select {
+			case <-r.Context().Done():
+				f.release(l, nil)
+			case <-c.done:
+			}
+		}()
+
+		return
+	}
+
+	f.mu.Unlock()
+
+	timer := time.NewTimer(maxCoalescingWait)
+	defer timer.Stop()
+	select {
+	case <-c.done:
+	case <-r.Context().Done():
+		return
+	case <-timer.C:
+		return
+	}
+
+	result := c.result
+	if result == nil {
+		return
+	}
+
+	header := make(http.Header, len(result.header))
+	for k, v := range result.header {
+		header[k] = append([]string(nil), v...)
+	}
+
+	if m := ctx.Metrics(); m != nil {
+		m.IncCounter("coalesced")
+	}
+
+	ctx.Serve(&http.Response{
+		StatusCode:    result.statusCode,
+		Header:        header,
+		Body:          ioutil.NopCloser(bytes.NewReader(result.body)),
+		ContentLength: int64(len(result.body)),
+	})
+}
+
+// privateResponse tells whether a response is meant only for the client
+// of the first request.
+func privateResponse(rsp *http.Response) bool {
+	if len(rsp.Header["Set-Cookie"]) > 0 {
+		return true
+	}
+
+	for _, v := range rsp.Header["Cache-Control"] {
+		for _, d := range strings.Split(v, ",") {
+			d = strings.ToLower(strings.TrimSpace(d))
+			if d == "private" || strings.HasPrefix(d, "private=") {
+				return true
+			}
+		}
+	}
+
+	return false
+}
+
+// share reads the body of the response, when it is not too large, and
+// returns it with the response for the waiting requests, or nil, when
+// the response cannot be shared. It restores the body of the response.
+func share(rsp *http.Response) *coalescedResult {
+	if rsp.StatusCode == 499 {
+		// the client of the first request canceled it
+		return nil
+	}
+
+	if privateResponse(rsp) {
+		return nil
+	}
+
+	if rsp.Body == nil {
+		rsp.Body = http.NoBody
+	}
+
+	body, err := ioutil.ReadAll(io.LimitReader(rsp.Body, maxCoalescedBodySize+1))
+	if err != nil || len(body) > maxCoalescedBodySize {
+		rsp.Body = coalescedBody{Reader: io.MultiReader(bytes.NewReader(body), rsp.Body), closer: rsp.Body}
+		return nil
+	}
+
+	rsp.Body.Close()
+	rsp.Body = ioutil.NopCloser(bytes.NewReader(body))
+
+	header := make(http.Header, len(rsp.Header))
+	for k, v := range rsp.Header {
+		header[k] = append([]string(nil), v...)
+	}
+
+	return &coalescedResult{statusCode: rsp.StatusCode, header: header, body: body}
+}
+
+// Response shares the response of the first request with the waiting
+// ones.
+func (f *coalescingFilter) Response(ctx filters.FilterContext) {
+	l, ok := ctx.StateBag()[coalescingStateBagKey].(*coalescingLeader)
+	if !ok {
+		return
+	}
+
+	delete(ctx.StateBag(), coalescingStateBagKey)
+	f.release(l, share(ctx.Response()))
+}
@@ -0,0 +1,360 @@
+package builtin
+
+import (
+	"bytes"
+	"context"
+	"io/ioutil"
+	"net/http"
+	"net/url"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/zalando/skipper/filters"
+	"github.com/zalando/skipper/filters/filtertest"
+)
+
+func TestRequestCoalescingArgs(t *testing.T) {
+	for _, test := range []struct {
+		msg  string
+		args []interface{}
+		fail bool
+	}{{
+		msg: "no args",
+	}, {
+		msg:  "headers",
+		args: []interface{}{"Accept", "Accept-Language"},
+	}, {
+		msg:  "empty header",
+		args: []interface{}{""},
+		fail: true,
+	}, {
+		msg:  "invalid header",
+		args: []interface{}{42.0},
+		fail: true,
+	}} {
+		t.Run(test.msg, func(t *testing.T) {
+			_, err := NewRequestCoalescing().CreateFilter(test.args)
+			if test.fail && err == nil {
+				t.Error("failed to fail")
+			} else if !test.fail && err != nil {
+				t.Error(err)
+			}
+		})
+	}
+}
+
+func coalescingContext(method, path string, header http.Header) *filtertest.Context {
+	if header == nil {
+		header = make(http.Header)
+	}
+
+	return &filtertest.Context{
+		FRequest: &http.Request{
+			Method: method,
+			Host:   "www.example.org",
+			URL:    &url.URL{Path: path},
+			Header: header,
+		},
+		FStateBag: make(map[string]interface{}),
+	}
+}
+
+// startFollower executes the request part of the filter in the background,
+// and returns a channel signaling when it returned
+func startFollower(f filters.Filter, ctx filters.FilterContext) <-chan struct{} {
+	done := make(chan struct{})
+	go func() {
+		f.Request(ctx)
+		close(done)
+	}()
+
+	return done
+}
+
+func waitFollower(t *testing.T, done <-chan struct{}) {
+	select {
+	case <-done:
+	case <-time.After(3 * time.Second):
+		t.Fatal("timeout")
+	}
+}
+
+func TestRequestCoalescing(t *testing.T) {
+	for _, test := range []struct {
+		msg            string
+		args           []interface{}
+		leader         *filtertest.Context
+		follower       *filtertest.Context
+		status         int
+		header         http.Header
+		body           string
+		expectWait     bool
+		expectCoalesce bool
+	}{{
+		msg:            "identical requests",
+		leader:         coalescingContext("GET", "/foo", nil),
+		follower:       coalescingContext("GET", "/foo", nil),
+		status:         http.StatusOK,
+		body:           "foo",
+		expectWait:     true,
+		expectCoalesce: true,
+	}, {
+		msg:      "different path",
+		leader:   coalescingContext("GET", "/foo", nil),
+		follower: coalescingContext("GET", "/bar", nil),
+		status:   http.StatusOK,
+		body:     "foo",
+	}, {
+		msg:      "not coalesced method",
+		leader:   coalescingContext("POST", "/foo", nil),
+		follower: coalescingContext("POST", "/foo", nil),
+		status:   http.StatusOK,
+		body:     "foo",
+	}, {
+		msg:            "selected header matches",
+		args:           []interface{}{"accept"},
+		leader:         coalescingContext("GET", "/foo", http.Header{"Accept": []string{"text/html"}, "X-Foo": []string{"foo"}}),
+		follower:       coalescingContext("GET", "/foo", http.Header{"Accept": []string{"text/html"}, "X-Foo": []string{"bar"}}),
+		status:         http.StatusOK,
+		body:           "foo",
+		expectWait:     true,
+		expectCoalesce: true,
+	}, {
+		msg:      "selected header differs",
+		args:     []interface{}{"Accept"},
+		leader:   coalescingContext("GET", "/foo", http.Header{"Accept": []string{"text/html"}}),
+		follower: coalescingContext("GET", "/foo", http.Header{"Accept": []string{"application/json"}}),
+		status:   http.StatusOK,
+		body:     "foo",
+	}, {
+		msg:      "different credentials",
+		leader:   coalescingContext("GET", "/foo", http.Header{"Authorization": []string{"Bearer foo"}}),
+		follower: coalescingContext("GET", "/foo", http.Header{"Authorization": []string{"Bearer bar"}}),
+		status:   http.StatusOK,
+		body:     "foo",
+	}, {
+		msg:      "different cookies",
+		leader:   coalescingContext("GET", "/foo", http.Header{"Cookie": []string{"session=foo"}}),
+		follower: coalescingContext("GET", "/foo", nil),
+		status:   http.StatusOK,
+		body:     "foo",
+	}, {
+		msg:        "response sets cookie",
+		leader:     coalescingContext("GET", "/foo", nil),
+		follower:   coalescingContext("GET", "/foo", nil),
+		status:     http.StatusOK,
+		header:     http.Header{"Set-Cookie": []string{"session=foo"}},
+		body:       "foo",
+		expectWait: true,
+	}, {
+		msg:        "private response",
+		leader:     coalescingContext("GET", "/foo", nil),
+		follower:   coalescingContext("GET", "/foo", nil),
+		status:     http.StatusOK,
+		header:     http.Header{"Cache-Control": []string{"max-age=60, Private"}},
+		body:       "foo",
+		expectWait: true,
+	}, {
+		msg:            "backend error shared",
+		leader:         coalescingContext("GET", "/foo", nil),
+		follower:       coalescingContext("GET", "/foo", nil),
+		status:         http.StatusBadGateway,
+		expectWait:     true,
+		expectCoalesce: true,
+	}, {
+		msg:        "canceled leader",
+		leader:     coalescingContext("GET", "/foo", nil),
+		follower:   coalescingContext("GET", "/foo", nil),
+		status:     499,
+		expectWait: true,
+	}, {
+		msg:        "too large response",
+		leader:     coalescingContext("GET", "/foo", nil),
+		follower:   coalescingContext("GET", "/foo", nil),
+		status:     http.StatusOK,
+		body:       strings.Repeat("x", maxCoalescedBodySize+1),
+		expectWait: true,
+	}} {
+		t.Run(test.msg, func(t *testing.T) {
+			f, err := NewRequestCoalescing().CreateFilter(test.args)
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			f.Request(test.leader)
+			if test.leader.Served() {
+				t.Fatal("leader served")
+			}
+
+			done := startFollower(f, test.follower)
+			if !test.expectWait {
+				waitFollower(t, done)
+				if test.follower.Served() {
+					t.Fatal("unexpected coalescing")
+				}
+
+				return
+			}
+
+			if on, _ := test.leader.StateBag()[filters.BackendErrorResponseKey].(bool); !on {
+				t.Error("backend error response not requested")
+			}
+
+			select {
+			case <-done:
+				t.Fatal("follower didn't wait")
+			case <-time.After(30 * time.Millisecond):
+			}
+
+			header := http.Header{"Content-Type": []string{"text/plain"}}
+			for k, v := range test.header {
+				header[k] = v
+			}
+
+			test.leader.FResponse = &http.Response{
+				StatusCode: test.status,
+				Header:     header,
+				Body:       ioutil.NopCloser(bytes.NewBufferString(test.body)),
+			}
+
+			f.Response(test.leader)
+			waitFollower(t, done)
+
+			b, err := ioutil.ReadAll(test.leader.FResponse.Body)
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			if string(b) != test.body {
+				t.Error("leader response body changed")
+			}
+
+			if !test.expectCoalesce {
+				if test.follower.Served() {
+					t.Error("unexpected coalescing")
+				}
+
+				return
+			}
+
+			if !test.follower.Served() {
+				t.Fatal("follower not served")
+			}
+
+			rsp := test.follower.Response()
+			if rsp.StatusCode != test.status {
+				t.Errorf("invalid status, expected: %d, got: %d", test.status, rsp.StatusCode)
+			}
+
+			if rsp.Header.Get("Content-Type") != "text/plain" {
+				t.Error("header not shared")
+			}
+
+			b, err = ioutil.ReadAll(rsp.Body)
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			if string(b) != test.body {
+				t.Errorf("invalid body, expected: %s, got: %s", test.body, b)
+			}
+
+			rsp.Header.Set("X-Foo", "bar")
+			if test.leader.FResponse.Header.Get("X-Foo") != "" {
+				t.Error("header not copied")
+			}
+		})
+	}
+}
+
+func TestRequestCoalescingNextRound(t *testing.T) {
+	f, err := NewRequestCoalescing().CreateFilter(nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	first := coalescingContext("GET", "/foo", nil)
+	f.Request(first)
+	first.FResponse = &http.Response{StatusCode: http.StatusOK, Header: make(http.Header), Body: ioutil.NopCloser(strings.NewReader("foo"))}
+	f.Response(first)
+
+	second := coalescingContext("GET", "/foo", nil)
+	f.Request(second)
+	if second.Served() {
+		t.Error("completed response shared")
+	}
+}
+
+func TestRequestCoalescingReleasedWithoutResponse(t *testing.T) {
+	f, err := NewRequestCoalescing().CreateFilter(nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// the response filters are not executed e.g. when the request was
+	// rate limited, only the request is done
+	reqCtx, cancel := context.WithCancel(context.Background())
+	leader := coalescingContext("GET", "/foo", nil)
+	leader.FRequest = leader.FRequest.WithContext(reqCtx)
+	f.Request(leader)
+
+	follower := coalescingContext("GET", "/foo", nil)
+	done := startFollower(f, follower)
+	select {
+	case <-done:
+		t.Fatal("follower didn't wait")
+	case <-time.After(30 * time.Millisecond):
+	}
+
+	cancel()
+	waitFollower(t, done)
+	if follower.Served() {
+		t.Error("unexpected coalescing")
+	}
+
+	next := coalescingContext("GET", "/foo", nil)
+	done = startFollower(f, next)
+	waitFollower(t, done)
+	if next.Served() {
+		t.Error("released call shared")
+	}
+}
+
+func TestRequestCoalescingResponseAfterRelease(t *testing.T) {
+	f, err := NewRequestCoalescing().CreateFilter(nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	reqCtx, cancel := context.WithCancel(context.Background())
+	leader := coalescingContext("GET", "/foo", nil)
+	leader.FRequest = leader.FRequest.WithContext(reqCtx)
+	f.Request(leader)
+
+	follower := coalescingContext("GET", "/foo", nil)
+	done := startFollower(f, follower)
+
+	l := leader.StateBag()[coalescingStateBagKey].(*coalescingLeader)
+
+	// the response of the leader arrives after the call was released due
+	// to the canceled request
+	cancel()
+	<-l.call.done
+	leader.FResponse = &http.Response{StatusCode: http.StatusOK, Header: make(http.Header), Body: ioutil.NopCloser(strings.NewReader("foo"))}
+	f.Response(leader)
+
+	waitFollower(t, done)
+	if follower.Served() {
+		t.Error("unexpected coalescing")
+	}
+
+	// the released call is not changed by the late response
+	if l.call.result != nil {
+		t.Error("result set after the release")
+	}
+
+	if b, err := ioutil.ReadAll(leader.FResponse.Body); err != nil || string(b) != "foo" {
+		t.Errorf("failed to restore the body: %s, %v", b, err)
+	}
+}
@@ -14,13 +14,15 @@ import (
 	"sync"
 
 	"github.com/zalando/skipper/filters"
+	snet "github.com/zalando/skipper/net"
 )
 
 const bufferSize = 8192
 
 type encoding struct {
-	name string
-	q    float32
+	name       string
+	q          float32
+	preference int
 }
 
 type encodings []*encoding
@@ -37,6 +39,8 @@ type encoder interface {
 }
 
 var (
+	// the order of the encodings defines the preference, when the client
+	// accepts multiple encodings with the same quality
 	supportedEncodings  = []string{"gzip", "deflate"}
 	unsupportedEncoding = errors.New("unsupported encoding")
 )
@@ -79,9 +83,16 @@ func init() {
 	}
 }
 
-func (e encodings) Len() int           { return len(e) }
-func (e encodings) Less(i, j int) bool { return e[i].q > e[j].q } // higher first
-func (e encodings) Swap(i, j int)      { e[i], e[j] = e[j], e[i] }
+func (e encodings) Len() int { return len(e) }
+func (e encodings) Less(i, j int) bool {
+	// higher first, and with the same quality, by the preference
+	if e[i].q != e[j].q {
+		return e[i].q > e[j].q
+	}
+
+	return e[i].preference < e[j].preference
+}
+func (e encodings) Swap(i, j int) { e[i], e[j] = e[j], e[i] }
 
 // Returns a filter specification that is used to compress the response content.
 //
@@ -121,9 +132,10 @@ func (e encodings) Swap(i, j int)      { e[i], e[j] = e[j], e[i] }
 //
 // The filter also checks the incoming request, if it accepts the supported
 // encodings, explicitly stated in the Accept-Encoding header. The filter currently
-// supports gzip and deflate. It does not assume that the client accepts any
-// encoding if the Accept-Encoding header is not set. It ignores * in the
-// Accept-Encoding header.
+// supports gzip and deflate. It selects the encoding with the highest quality
+// value, and ignores the encodings with q=0. It does not assume
+// that the client accepts any encoding if the Accept-Encoding header is not set.
+// It ignores * in the Accept-Encoding header.
 //
 // When compressing the response, it updates the response header. It deletes the
 // the Content-Length value triggering the proxy to always return the response
@@ -132,6 +144,10 @@ func (e encodings) Swap(i, j int)      { e[i], e[j] = e[j], e[i] }
 //
 // The compression happens in a streaming way, using only a small internal buffer.
 //
+// Streams of Server-Sent Events, marked by the serverSentEvents filter, or having
+// the text/event-stream content type, are not compressed, so that the events are
+// not delayed.
+//
 func NewCompress() filters.Spec { return &compress{} }
 
 func (c *compress) Name() string {
@@ -230,12 +246,19 @@ func acceptedEncoding(r *http.Request) string {
 		}
 
 		name := strings.ToLower(strings.TrimSpace(sp[0]))
-		if !stringsContain(supportedEncodings, name) {
+		preference := -1
+		for i, si := range supportedEncodings {
+			if si == name {
+				preference = i
+				break
+			}
+		}
+
+		if preference < 0 {
 			continue
 		}
 
-		enc := &encoding{name, 1}
-		encs = append(encs, enc)
+		enc := &encoding{name: name, q: 1, preference: preference}
 
 		for _, spi := range sp[1:] {
 			spi = strings.TrimSpace(spi)
@@ -251,13 +274,18 @@ func acceptedEncoding(r *http.Request) string {
 			enc.q = float32(q)
 			break
 		}
+
+		// q=0 means not acceptable
+		if enc.q > 0 {
+			encs = append(encs, enc)
+		}
 	}
 
 	if len(encs) == 0 {
 		return ""
 	}
 
-	sort.Sort(encs)
+	sort.Stable(encs)
 	return encs[0].name
 }
 
@@ -370,7 +398,11 @@ func responseBody(rsp *http.Response, enc string, level int) {
 func (c *compress) Response(ctx filters.FilterContext) {
 	rsp := ctx.Response()
 
-	if !canEncodeEntity(rsp, c.mime) {
+	if _, ok := ctx.StateBag()[filters.ServerSentEventsKey]; ok {
+		return
+	}
+
+	if !canEncodeEntity(rsp, c.mime) || snet.IsEventStream(rsp) {
 		return
 	}
 
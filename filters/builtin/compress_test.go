@@ -11,6 +11,7 @@ import (
 	"net/http"
 	"net/http/httptest"
 	"strconv"
+	"strings"
 	"testing"
 	"time"
 
@@ -391,6 +392,40 @@ func TestCompress(t *testing.T) {
 		http.Header{
 			"Content-Encoding": []string{"deflate"},
 			"Vary":             []string{"Accept-Encoding"}},
+	}, {
+		"not acceptable encoding",
+		http.Header{},
+		3 * 8192,
+		nil,
+		"gzip; q=0, deflate; q=0.5",
+		http.Header{
+			"Content-Encoding": []string{"deflate"},
+			"Vary":             []string{"Accept-Encoding"}},
+	}, {
+		"brotli and zstd not supported",
+		http.Header{},
+		3 * 8192,
+		nil,
+		"br, zstd",
+		http.Header{},
+	}, {
+		"brotli and zstd preferred",
+		http.Header{},
+		3 * 8192,
+		nil,
+		"br; q=1, zstd; q=0.9, gzip; q=0.5",
+		http.Header{
+			"Content-Encoding": []string{"gzip"},
+			"Vary":             []string{"Accept-Encoding"}},
+	}, {
+		"same quality by preference",
+		http.Header{},
+		3 * 8192,
+		nil,
+		"deflate, gzip",
+		http.Header{
+			"Content-Encoding": []string{"gzip"},
+			"Vary":             []string{"Accept-Encoding"}},
 	}, {
 		"drops content length",
 		http.Header{"Content-Length": []string{strconv.Itoa(3 * 8192)}},
@@ -494,6 +529,40 @@ func TestForwardError(t *testing.T) {
 	}
 }
 
+func TestCompressEventStream(t *testing.T) {
+	f, err := NewCompress().CreateFilter([]interface{}{"...", "text/event-stream"})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	for _, ti := range []struct {
+		msg         string
+		contentType string
+		bag         map[string]interface{}
+	}{{
+		msg:         "event stream content type",
+		contentType: "text/event-stream; charset=utf-8",
+		bag:         map[string]interface{}{},
+	}, {
+		msg:         "marked as server-sent events",
+		contentType: "text/plain",
+		bag:         map[string]interface{}{filters.ServerSentEventsKey: time.Duration(0)},
+	}} {
+		t.Run(ti.msg, func(t *testing.T) {
+			req := &http.Request{Header: http.Header{"Accept-Encoding": []string{"gzip"}}}
+			rsp := &http.Response{
+				Header: http.Header{"Content-Type": []string{ti.contentType}},
+				Body:   ioutil.NopCloser(strings.NewReader("data: 1\n\n")),
+			}
+
+			f.Response(&filtertest.Context{FRequest: req, FResponse: rsp, FStateBag: ti.bag})
+			if enc := rsp.Header.Get("Content-Encoding"); enc != "" {
+				t.Errorf("unexpected content encoding: %s", enc)
+			}
+		})
+	}
+}
+
 func TestStreaming(t *testing.T) {
 	if testing.Short() {
 		t.Skip()
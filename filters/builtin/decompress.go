@@ -0,0 +1,123 @@
+package builtin
+
+import (
+	"bufio"
+	"compress/flate"
+	"compress/gzip"
+	"compress/zlib"
+	"io"
+	"net/http"
+	"strings"
+
+	"github.com/zalando/skipper/filters"
+)
+
+type decompress struct{}
+
+// NewDecompress returns a filter specification that decodes the
+// compressed request bodies, for backends that can't handle compressed
+// uploads. It supports gzip and deflate. Requests with other encodings
+// are rejected with 415 Unsupported Media Type.
+//
+// The body is decoded in a streaming way. The Content-Encoding and the
+// Content-Length headers are removed from the decoded requests.
+//
+// Example:
+//
+//	* -> decompress() -> "https://www.example.org"
+func NewDecompress() filters.Spec { return decompress{} }
+
+func (decompress) Name() string { return DecompressName }
+
+func (decompress) CreateFilter(args []interface{}) (filters.Filter, error) {
+	if len(args) != 0 {
+		return nil, filters.ErrInvalidFilterParameters
+	}
+
+	return decompress{}, nil
+}
+
+// decodedBody closes the decoders and the original body
+type decodedBody struct {
+	io.Reader
+	closers []io.Closer
+}
+
+func (b *decodedBody) Close() error {
+	var err error
+	for _, c := range b.closers {
+		if cerr := c.Close(); cerr != nil && err == nil {
+			err = cerr
+		}
+	}
+
+	return err
+}
+
+// newDeflateDecoder accepts both the zlib format, as defined for the
+// deflate content encoding, and the raw deflate format, that is sent by
+// many clients, and also by the compress filter
+func newDeflateDecoder(r io.Reader) (io.ReadCloser, error) {
+	br := bufio.NewReader(r)
+	h, err := br.Peek(2)
+	if err == nil && h[0]&0x0f == 8 && (uint16(h[0])<<8|uint16(h[1]))%31 == 0 {
+		return zlib.NewReader(br)
+	}
+
+	return flate.NewReader(br), nil
+}
+
+func newDecoder(enc string, r io.Reader) (io.ReadCloser, bool, error) {
+	switch enc {
+	case "gzip", "x-gzip":
+		d, err := gzip.NewReader(r)
+		return d, true, err
+	case "deflate":
+		d, err := newDeflateDecoder(r)
+		return d, true, err
+	default:
+		return nil, false, nil
+	}
+}
+
+func (decompress) Request(ctx filters.FilterContext) {
+	req := ctx.Request()
+	var encs []string
+	for _, h := range req.Header["Content-Encoding"] {
+		for _, e := range strings.Split(h, ",") {
+			if e = strings.ToLower(strings.TrimSpace(e)); e != "" && e != "identity" {
+				encs = append(encs, e)
+			}
+		}
+	}
+
+	if len(encs) == 0 || req.Body == nil || req.Body == http.NoBody {
+		return
+	}
+
+	// the encodings are listed in the order they were applied
+	body := &decodedBody{Reader: req.Body, closers: []io.Closer{req.Body}}
+	for i := len(encs) - 1; i >= 0; i-- {
+		d, ok, err := newDecoder(encs[i], body.Reader)
+		if !ok || err != nil {
+			body.Close()
+			status := http.StatusUnsupportedMediaType
+			if err != nil {
+				status = http.StatusBadRequest
+			}
+
+			ctx.Serve(&http.Response{StatusCode: status})
+			return
+		}
+
+		body.Reader = d
+		body.closers = append([]io.Closer{d}, body.closers...)
+	}
+
+	req.Body = body
+	req.ContentLength = -1
+	req.Header.Del("Content-Encoding")
+	req.Header.Del("Content-Length")
+}
+
+func (decompress) Response(filters.FilterContext) {}
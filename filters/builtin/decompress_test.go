@@ -0,0 +1,139 @@
+package builtin
+
+import (
+	"bytes"
+	"compress/flate"
+	"compress/gzip"
+	"compress/zlib"
+	"io"
+	"io/ioutil"
+	"net/http"
+	"testing"
+
+	"github.com/zalando/skipper/filters/filtertest"
+)
+
+func encodeTestBody(t *testing.T, enc string, body []byte) []byte {
+	var (
+		b bytes.Buffer
+		w io.WriteCloser
+	)
+
+	switch enc {
+	case "gzip":
+		w = gzip.NewWriter(&b)
+	case "deflate":
+		w = zlib.NewWriter(&b)
+	case "raw-deflate":
+		w, _ = flate.NewWriter(&b, flate.BestSpeed)
+	default:
+		t.Fatalf("unexpected encoding: %s", enc)
+	}
+
+	if _, err := w.Write(body); err != nil {
+		t.Fatal(err)
+	}
+
+	if err := w.Close(); err != nil {
+		t.Fatal(err)
+	}
+
+	return b.Bytes()
+}
+
+func TestDecompress(t *testing.T) {
+	content := testContent[:3*8192]
+	gzipped := encodeTestBody(t, "gzip", content)
+	for _, ti := range []struct {
+		msg            string
+		encoding       string
+		body           []byte
+		expectedStatus int
+	}{{
+		msg:  "not encoded",
+		body: content,
+	}, {
+		msg:      "identity",
+		encoding: "identity",
+		body:     content,
+	}, {
+		msg:      "gzip",
+		encoding: "gzip",
+		body:     gzipped,
+	}, {
+		msg:      "deflate",
+		encoding: "deflate",
+		body:     encodeTestBody(t, "deflate", content),
+	}, {
+		msg:      "raw deflate",
+		encoding: "deflate",
+		body:     encodeTestBody(t, "raw-deflate", content),
+	}, {
+		msg:      "multiple encodings",
+		encoding: "gzip, deflate",
+		body:     encodeTestBody(t, "deflate", gzipped),
+	}, {
+		msg:            "unsupported encoding",
+		encoding:       "br",
+		body:           content,
+		expectedStatus: http.StatusUnsupportedMediaType,
+	}, {
+		msg:            "invalid gzip",
+		encoding:       "gzip",
+		body:           content,
+		expectedStatus: http.StatusBadRequest,
+	}} {
+		t.Run(ti.msg, func(t *testing.T) {
+			f, err := NewDecompress().CreateFilter(nil)
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			req, err := http.NewRequest("POST", "https://www.example.org", bytes.NewReader(ti.body))
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			if ti.encoding != "" {
+				req.Header.Set("Content-Encoding", ti.encoding)
+			}
+
+			ctx := &filtertest.Context{FRequest: req}
+			f.Request(ctx)
+			if ti.expectedStatus != 0 {
+				if !ctx.FServed || ctx.FResponse.StatusCode != ti.expectedStatus {
+					t.Fatalf("expected status %d", ti.expectedStatus)
+				}
+
+				return
+			}
+
+			if ctx.FServed {
+				t.Fatalf("request rejected: %d", ctx.FResponse.StatusCode)
+			}
+
+			if enc := req.Header.Get("Content-Encoding"); enc != "" && enc != "identity" {
+				t.Errorf("content encoding not removed: %s", enc)
+			}
+
+			b, err := ioutil.ReadAll(req.Body)
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			if !bytes.Equal(b, content) {
+				t.Error("unexpected body")
+			}
+
+			if err := req.Body.Close(); err != nil {
+				t.Error(err)
+			}
+		})
+	}
+}
+
+func TestDecompressArgs(t *testing.T) {
+	if _, err := NewDecompress().CreateFilter([]interface{}{"gzip"}); err == nil {
+		t.Error("failed to fail")
+	}
+}
@@ -0,0 +1,56 @@
+package builtin
+
+import (
+	"net/url"
+
+	"github.com/zalando/skipper/filters"
+)
+
+type egressProxySpec struct{}
+
+type egressProxyFilter struct {
+	proxyURL string
+}
+
+// NewEgressProxy returns a filter specification that sends the backend
+// requests of a route through an HTTP proxy. The argument is the URL of
+// the proxy, with the scheme http or https. Requests to TLS backends are
+// tunneled with CONNECT. Credentials in the URL are sent to the proxy in
+// the Proxy-Authorization header.
+//
+// The filter overrides the globally configured egress proxy.
+//
+// Example:
+//
+//	api: Path("/api") -> egressProxy("http://egress.example.org:3128") -> "https://api.example.org";
+func NewEgressProxy() filters.Spec {
+	return &egressProxySpec{}
+}
+
+func (s *egressProxySpec) Name() string {
+	return EgressProxyName
+}
+
+func (s *egressProxySpec) CreateFilter(args []interface{}) (filters.Filter, error) {
+	if len(args) != 1 {
+		return nil, filters.ErrInvalidFilterParameters
+	}
+
+	rawURL, ok := args[0].(string)
+	if !ok {
+		return nil, filters.ErrInvalidFilterParameters
+	}
+
+	u, err := url.Parse(rawURL)
+	if err != nil || (u.Scheme != "http" && u.Scheme != "https") || u.Host == "" {
+		return nil, filters.ErrInvalidFilterParameters
+	}
+
+	return &egressProxyFilter{proxyURL: u.String()}, nil
+}
+
+func (f *egressProxyFilter) Request(ctx filters.FilterContext) {
+	ctx.StateBag()[filters.EgressProxyKey] = f.proxyURL
+}
+
+func (f *egressProxyFilter) Response(ctx filters.FilterContext) {}
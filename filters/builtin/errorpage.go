@@ -0,0 +1,255 @@
+package builtin
+
+import (
+	"bytes"
+	"encoding/json"
+	"html"
+	"io/ioutil"
+	"mime"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	log "github.com/sirupsen/logrus"
+	"github.com/zalando/skipper/filewatch"
+	"github.com/zalando/skipper/filters"
+	"github.com/zalando/skipper/filters/template"
+)
+
+const (
+	defaultErrorPageContentType   = "text/html; charset=utf-8"
+	defaultErrorPageCheckInterval = 10 * time.Second
+)
+
+// errorPageCodes matches the response status codes, either by an exact
+// code, or by a class, like 5xx
+type errorPageCodes struct {
+	codes   map[int]bool
+	classes map[int]bool
+}
+
+type errorPageSpec struct {
+	fromFile      bool
+	checkInterval time.Duration
+
+	mu    sync.Mutex
+	files map[string]*filewatch.File
+}
+
+type errorPageFilter struct {
+	codes       errorPageCodes
+	contentType string
+	escape      func(string) string
+	template    *template.Template
+	file        *filewatch.File
+}
+
+// NewErrorPage creates a filter specification for the errorPage()
+// filter, that replaces the responses with the matching status codes with
+// the provided content. The status codes can be set as a comma separated
+// list of codes or classes of codes:
+//
+//	r: * -> errorPage("5xx", "<h1>Something went wrong</h1>") -> "https://www.example.org";
+//
+// Or:
+//
+//	r: * -> errorPage("502,503,504", `{"status": ${response.status}}`, "application/json") -> "https://www.example.org";
+//
+// The content is a template, that accepts the placeholders of the
+// template package, e.g. ${response.status} and ${response.statusText}. When the content type is HTML or
+// JSON, the values of the placeholders are escaped accordingly. The
+// default content type is text/html.
+//
+// The filter also applies to the errors of the backend requests, e.g.
+// when the backend refuses the connection, so that the clients never
+// receive the raw errors.
+func NewErrorPage() filters.Spec {
+	return &errorPageSpec{}
+}
+
+// NewErrorPageFile creates a filter specification for the
+// errorPageFile() filter. It works the same way as the errorPage()
+// filter, but it loads the content from a file. The file is checked for
+// changes at most every 10 seconds, and reloaded when it was modified:
+//
+//	r: * -> errorPageFile("5xx", "/etc/skipper/error.html") -> "https://www.example.org";
+func NewErrorPageFile() filters.Spec {
+	return newErrorPageFile(defaultErrorPageCheckInterval)
+}
+
+func newErrorPageFile(checkInterval time.Duration) *errorPageSpec {
+	return &errorPageSpec{
+		fromFile:      true,
+		checkInterval: checkInterval,
+		files:         make(map[string]*filewatch.File),
+	}
+}
+
+func (s *errorPageSpec) Name() string {
+	if s.fromFile {
+		return ErrorPageFileName
+	}
+
+	return ErrorPageName
+}
+
+func parseErrorPageCodes(a interface{}) (errorPageCodes, error) {
+	c := errorPageCodes{codes: make(map[int]bool), classes: make(map[int]bool)}
+	switch v := a.(type) {
+	case float64:
+		c.codes[int(v)] = true
+		return c, nil
+	case int:
+		c.codes[v] = true
+		return c, nil
+	case string:
+		for _, code := range strings.Split(v, ",") {
+			code = strings.ToLower(strings.TrimSpace(code))
+			if len(code) == 3 && strings.HasSuffix(code, "xx") && code[0] >= '1' && code[0] <= '5' {
+				c.classes[int(code[0]-'0')] = true
+				continue
+			}
+
+			n, err := strconv.Atoi(code)
+			if err != nil || n < 100 || n > 599 {
+				return c, filters.ErrInvalidFilterParameters
+			}
+
+			c.codes[n] = true
+		}
+
+		return c, nil
+	default:
+		return c, filters.ErrInvalidFilterParameters
+	}
+}
+
+func (c errorPageCodes) match(code int) bool {
+	return c.codes[code] || c.classes[code/100]
+}
+
+// errorPageEscape returns the function escaping the values of the
+// placeholders based on the content type
+func errorPageEscape(contentType string) func(string) string {
+	mediaType, _, _ := mime.ParseMediaType(contentType)
+	switch {
+	case mediaType == "text/html" || mediaType == "application/xhtml+xml":
+		return html.EscapeString
+	case mediaType == "application/json" || strings.HasSuffix(mediaType, "+json"):
+		return func(s string) string {
+			b, _ := json.Marshal(s)
+			return string(b[1 : len(b)-1])
+		}
+	default:
+		return nil
+	}
+}
+
+func (s *errorPageSpec) CreateFilter(args []interface{}) (filters.Filter, error) {
+	if len(args) < 2 || len(args) > 3 {
+		return nil, filters.ErrInvalidFilterParameters
+	}
+
+	codes, err := parseErrorPageCodes(args[0])
+	if err != nil {
+		return nil, err
+	}
+
+	content, ok := args[1].(string)
+	if !ok {
+		return nil, filters.ErrInvalidFilterParameters
+	}
+
+	f := &errorPageFilter{codes: codes, contentType: defaultErrorPageContentType}
+	if len(args) == 3 {
+		if f.contentType, ok = args[2].(string); !ok || f.contentType == "" {
+			return nil, filters.ErrInvalidFilterParameters
+		}
+	}
+
+	f.escape = errorPageEscape(f.contentType)
+	if !s.fromFile {
+		f.template = template.New(content)
+		return f, nil
+	}
+
+	if content == "" {
+		return nil, filters.ErrInvalidFilterParameters
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	// the files are shared between the routes, so that they are checked
+	// only once per interval
+	t, ok := s.files[content]
+	if !ok {
+		t = filewatch.New(content, s.checkInterval, parseErrorPageFile)
+		if _, err := getErrorPage(t); err != nil {
+			return nil, err
+		}
+
+		s.files[content] = t
+	} else if _, err := getErrorPage(t); err != nil {
+		return nil, err
+	}
+
+	f.file = t
+	return f, nil
+}
+
+func parseErrorPageFile(content []byte) (interface{}, error) {
+	return template.New(string(content)), nil
+}
+
+// getErrorPage returns the current template of the file, and reloads it
+// when it changed since the last check
+func getErrorPage(f *filewatch.File) (*template.Template, error) {
+	t, err := f.Get()
+	if err != nil {
+		if t == nil {
+			return nil, err
+		}
+
+		log.Errorf("Failed to reload error page from %s: %v", f.Path(), err)
+	}
+
+	return t.(*template.Template), nil
+}
+
+func (f *errorPageFilter) Request(ctx filters.FilterContext) {
+	ctx.StateBag()[filters.BackendErrorResponseKey] = true
+}
+
+func (f *errorPageFilter) Response(ctx filters.FilterContext) {
+	rsp := ctx.Response()
+	if !f.codes.match(rsp.StatusCode) {
+		return
+	}
+
+	t := f.template
+	if f.file != nil {
+		var err error
+		if t, err = getErrorPage(f.file); err != nil {
+			log.Errorf("Failed to load error page: %v", err)
+			return
+		}
+	}
+
+	// the unresolved placeholders are rendered empty
+	content, _ := t.ApplyEscaped(ctx, f.escape)
+
+	if rsp.Body != nil {
+		rsp.Body.Close()
+	}
+
+	rsp.Header.Del("Content-Encoding")
+	rsp.Header.Del("Content-Range")
+	rsp.Header.Del("ETag")
+	rsp.Header.Del("Last-Modified")
+	rsp.Header.Set("Content-Type", f.contentType)
+	rsp.Header.Set("Content-Length", strconv.Itoa(len(content)))
+	rsp.ContentLength = int64(len(content))
+	rsp.Body = ioutil.NopCloser(bytes.NewBufferString(content))
+}
@@ -0,0 +1,302 @@
+package builtin
+
+import (
+	"io/ioutil"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/zalando/skipper/eskip"
+	"github.com/zalando/skipper/filters"
+	"github.com/zalando/skipper/filters/filtertest"
+	"github.com/zalando/skipper/proxy/proxytest"
+)
+
+func TestErrorPageArgs(t *testing.T) {
+	for _, test := range []struct {
+		msg  string
+		args []interface{}
+		fail bool
+	}{{
+		msg:  "no args",
+		fail: true,
+	}, {
+		msg:  "missing content",
+		args: []interface{}{"5xx"},
+		fail: true,
+	}, {
+		msg:  "invalid code",
+		args: []interface{}{"foo", "bar"},
+		fail: true,
+	}, {
+		msg:  "invalid class",
+		args: []interface{}{"6xx", "bar"},
+		fail: true,
+	}, {
+		msg:  "invalid content type",
+		args: []interface{}{"5xx", "bar", 42},
+		fail: true,
+	}, {
+		msg:  "too many args",
+		args: []interface{}{"5xx", "bar", "text/plain", "baz"},
+		fail: true,
+	}, {
+		msg:  "class",
+		args: []interface{}{"5xx", "bar"},
+	}, {
+		msg:  "numeric code",
+		args: []interface{}{float64(503), "bar"},
+	}, {
+		msg:  "list of codes and classes",
+		args: []interface{}{"404, 5xx", "bar", "text/plain"},
+	}} {
+		t.Run(test.msg, func(t *testing.T) {
+			_, err := NewErrorPage().CreateFilter(test.args)
+			if test.fail && err == nil {
+				t.Error("failed to fail")
+			} else if !test.fail && err != nil {
+				t.Error(err)
+			}
+		})
+	}
+}
+
+func TestErrorPage(t *testing.T) {
+	for _, test := range []struct {
+		msg                 string
+		args                []interface{}
+		status              int
+		path                string
+		expectedBody        string
+		expectedContentType string
+	}{{
+		msg:                 "not matching status",
+		args:                []interface{}{"5xx", "error"},
+		status:              http.StatusNotFound,
+		expectedBody:        "backend",
+		expectedContentType: "text/plain",
+	}, {
+		msg:                 "matching class",
+		args:                []interface{}{"5xx", "<h1>error</h1>"},
+		status:              http.StatusServiceUnavailable,
+		expectedBody:        "<h1>error</h1>",
+		expectedContentType: "text/html; charset=utf-8",
+	}, {
+		msg:                 "matching code",
+		args:                []interface{}{"404,502", "not found"},
+		status:              http.StatusNotFound,
+		expectedBody:        "not found",
+		expectedContentType: "text/html; charset=utf-8",
+	}, {
+		msg:                 "status placeholders",
+		args:                []interface{}{"5xx", "${response.status} ${response.statusText}", "text/plain"},
+		status:              http.StatusBadGateway,
+		expectedBody:        "502 Bad Gateway",
+		expectedContentType: "text/plain",
+	}, {
+		msg:                 "html escaped",
+		args:                []interface{}{"5xx", "<p>${request.path}</p>"},
+		status:              http.StatusInternalServerError,
+		path:                "/<script>",
+		expectedBody:        "<p>/&lt;script&gt;</p>",
+		expectedContentType: "text/html; charset=utf-8",
+	}, {
+		msg:                 "json escaped",
+		args:                []interface{}{"5xx", `{"path": "${request.path}", "status": ${response.status}}`, "application/json"},
+		status:              http.StatusInternalServerError,
+		path:                `/foo"bar`,
+		expectedBody:        `{"path": "/foo\"bar", "status": 500}`,
+		expectedContentType: "application/json",
+	}, {
+		msg:                 "unresolved placeholder",
+		args:                []interface{}{"5xx", "error${request.header.X-Missing}", "text/plain"},
+		status:              http.StatusInternalServerError,
+		expectedBody:        "error",
+		expectedContentType: "text/plain",
+	}} {
+		t.Run(test.msg, func(t *testing.T) {
+			f, err := NewErrorPage().CreateFilter(test.args)
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			path := test.path
+			if path == "" {
+				path = "/"
+			}
+
+			req := &http.Request{Method: "GET", URL: &url.URL{Path: path}, Header: make(http.Header)}
+			rsp := &http.Response{
+				StatusCode: test.status,
+				Header: http.Header{
+					"Content-Type":     []string{"text/plain"},
+					"Content-Encoding": []string{"gzip"},
+				},
+				Body: ioutil.NopCloser(strings.NewReader("backend")),
+			}
+
+			ctx := &filtertest.Context{FRequest: req, FResponse: rsp, FStateBag: make(map[string]interface{})}
+			f.Request(ctx)
+			if on, _ := ctx.StateBag()[filters.BackendErrorResponseKey].(bool); !on {
+				t.Error("backend error response not requested")
+			}
+
+			f.Response(ctx)
+			b, err := ioutil.ReadAll(rsp.Body)
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			if string(b) != test.expectedBody {
+				t.Errorf("unexpected body, expected: %s, got: %s", test.expectedBody, b)
+			}
+
+			if ct := rsp.Header.Get("Content-Type"); ct != test.expectedContentType {
+				t.Errorf("unexpected content type, expected: %s, got: %s", test.expectedContentType, ct)
+			}
+
+			if rsp.StatusCode != test.status {
+				t.Errorf("unexpected status, expected: %d, got: %d", test.status, rsp.StatusCode)
+			}
+
+			if test.expectedBody != "backend" && rsp.Header.Get("Content-Encoding") != "" {
+				t.Error("content encoding not removed")
+			}
+		})
+	}
+}
+
+func TestErrorPageFile(t *testing.T) {
+	dir, err := ioutil.TempDir("", "errorpage")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	defer os.RemoveAll(dir)
+
+	path := filepath.Join(dir, "error.html")
+	if err := ioutil.WriteFile(path, []byte("first"), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	s := newErrorPageFile(0)
+	if _, err := s.CreateFilter([]interface{}{"5xx", filepath.Join(dir, "missing.html")}); err == nil {
+		t.Fatal("failed to fail")
+	}
+
+	f, err := s.CreateFilter([]interface{}{"5xx", path})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	render := func() string {
+		rsp := &http.Response{StatusCode: http.StatusInternalServerError, Header: make(http.Header)}
+		f.Response(&filtertest.Context{FRequest: &http.Request{}, FResponse: rsp})
+		b, err := ioutil.ReadAll(rsp.Body)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		return string(b)
+	}
+
+	if b := render(); b != "first" {
+		t.Fatalf("unexpected content: %s", b)
+	}
+
+	if err := ioutil.WriteFile(path, []byte("second"), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	future := time.Now().Add(time.Hour)
+	if err := os.Chtimes(path, future, future); err != nil {
+		t.Fatal(err)
+	}
+
+	if b := render(); b != "second" {
+		t.Errorf("failed to reload the file, got: %s", b)
+	}
+
+	// the last valid content is kept when the file is removed
+	if err := os.Remove(path); err != nil {
+		t.Fatal(err)
+	}
+
+	if b := render(); b != "second" {
+		t.Errorf("unexpected content after the file was removed: %s", b)
+	}
+}
+
+func TestErrorPageBackendError(t *testing.T) {
+	l, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// the connection to the backend is refused
+	backendURL := "http://" + l.Addr().String()
+	l.Close()
+
+	p := proxytest.New(MakeRegistry(), &eskip.Route{
+		Filters: []*eskip.Filter{{
+			Name: ErrorPageName,
+			Args: []interface{}{"5xx", `{"status": ${response.status}}`, "application/json"},
+		}},
+		Backend: backendURL,
+	})
+	defer p.Close()
+
+	rsp, err := http.Get(p.URL)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	defer rsp.Body.Close()
+	b, err := ioutil.ReadAll(rsp.Body)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if rsp.StatusCode != http.StatusBadGateway {
+		t.Errorf("unexpected status: %d", rsp.StatusCode)
+	}
+
+	if string(b) != `{"status": 502}` {
+		t.Errorf("unexpected body: %s", b)
+	}
+}
+
+func TestErrorPageBackendResponse(t *testing.T) {
+	backend := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusServiceUnavailable)
+		w.Write([]byte("raw backend error"))
+	}))
+	defer backend.Close()
+
+	p := proxytest.New(MakeRegistry(), &eskip.Route{
+		Filters: []*eskip.Filter{{Name: ErrorPageName, Args: []interface{}{"5xx", "maintenance"}}},
+		Backend: backend.URL,
+	})
+	defer p.Close()
+
+	rsp, err := http.Get(p.URL)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	defer rsp.Body.Close()
+	b, err := ioutil.ReadAll(rsp.Body)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if rsp.StatusCode != http.StatusServiceUnavailable || string(b) != "maintenance" {
+		t.Errorf("unexpected response: %d, %s", rsp.StatusCode, b)
+	}
+}
@@ -0,0 +1,61 @@
+package builtin
+
+import (
+	"time"
+
+	"github.com/zalando/skipper/filters"
+)
+
+type flushIntervalSpec struct{}
+
+type flushIntervalFilter struct {
+	interval time.Duration
+}
+
+// NewFlushInterval returns a filter specification that overrides the
+// interval at which the proxy flushes the response body to the client.
+// The argument is either a duration string, e.g. "100ms", or a number
+// of milliseconds. Zero or a negative value flushes after every read
+// from the backend, which is what streaming responses, e.g. Server-Sent
+// Events, usually need.
+//
+// Example:
+//
+//	events: Path("/events") -> flushInterval(0) -> "http://events.example.org";
+func NewFlushInterval() filters.Spec {
+	return &flushIntervalSpec{}
+}
+
+func (s *flushIntervalSpec) Name() string {
+	return FlushIntervalName
+}
+
+func (s *flushIntervalSpec) CreateFilter(args []interface{}) (filters.Filter, error) {
+	if len(args) != 1 {
+		return nil, filters.ErrInvalidFilterParameters
+	}
+
+	var d time.Duration
+	switch v := args[0].(type) {
+	case string:
+		var err error
+		d, err = time.ParseDuration(v)
+		if err != nil {
+			return nil, filters.ErrInvalidFilterParameters
+		}
+	case float64:
+		d = time.Duration(v) * time.Millisecond
+	case int:
+		d = time.Duration(v) * time.Millisecond
+	default:
+		return nil, filters.ErrInvalidFilterParameters
+	}
+
+	return &flushIntervalFilter{interval: d}, nil
+}
+
+func (f *flushIntervalFilter) Request(ctx filters.FilterContext) {
+	ctx.StateBag()[filters.ResponseFlushIntervalKey] = f.interval
+}
+
+func (f *flushIntervalFilter) Response(ctx filters.FilterContext) {}
@@ -0,0 +1,75 @@
+package builtin
+
+import (
+	"net/http"
+	"testing"
+	"time"
+
+	"github.com/zalando/skipper/filters"
+	"github.com/zalando/skipper/filters/filtertest"
+)
+
+func TestFlushInterval(t *testing.T) {
+	for _, tt := range []struct {
+		msg      string
+		args     []interface{}
+		err      bool
+		expected time.Duration
+	}{{
+		msg:  "no args",
+		args: nil,
+		err:  true,
+	}, {
+		msg:  "too many args",
+		args: []interface{}{"1s", "2s"},
+		err:  true,
+	}, {
+		msg:  "invalid duration",
+		args: []interface{}{"foo"},
+		err:  true,
+	}, {
+		msg:  "invalid type",
+		args: []interface{}{true},
+		err:  true,
+	}, {
+		msg:      "duration string",
+		args:     []interface{}{"100ms"},
+		expected: 100 * time.Millisecond,
+	}, {
+		msg:      "milliseconds",
+		args:     []interface{}{float64(250)},
+		expected: 250 * time.Millisecond,
+	}, {
+		msg:      "immediate",
+		args:     []interface{}{float64(0)},
+		expected: 0,
+	}, {
+		msg:      "negative",
+		args:     []interface{}{"-1ms"},
+		expected: -time.Millisecond,
+	}} {
+		t.Run(tt.msg, func(t *testing.T) {
+			f, err := NewFlushInterval().CreateFilter(tt.args)
+			if tt.err {
+				if err == nil {
+					t.Fatal("expected error")
+				}
+				return
+			}
+
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			ctx := &filtertest.Context{
+				FRequest:  &http.Request{},
+				FStateBag: map[string]interface{}{},
+			}
+
+			f.Request(ctx)
+			if d, ok := ctx.FStateBag[filters.ResponseFlushIntervalKey].(time.Duration); !ok || d != tt.expected {
+				t.Errorf("expected %v, got %v", tt.expected, ctx.FStateBag[filters.ResponseFlushIntervalKey])
+			}
+		})
+	}
+}
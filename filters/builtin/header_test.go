@@ -174,6 +174,39 @@ func TestHeader(t *testing.T) {
 		args:           []interface{}{"X-Test-Name"},
 		valid:          true,
 		responseHeader: http.Header{"X-Test-Name": []string{"value0", "value1"}},
+	}, {
+		msg:           "set request header from template",
+		filterName:    "setRequestHeader",
+		args:          []interface{}{"X-Test-Name", "user ${request.header.X-Test-User}"},
+		valid:         true,
+		requestHeader: http.Header{"X-Test-User": []string{"alice"}},
+		expectedHeader: http.Header{
+			"X-Test-Request-Name": []string{"user alice"},
+			"X-Test-Request-User": []string{"alice"},
+		},
+	}, {
+		msg:        "template not resolved",
+		filterName: "appendRequestHeader",
+		args:       []interface{}{"X-Test-Name", "user ${request.header.X-Test-User}"},
+		valid:      true,
+	}, {
+		msg:        "literal placeholder kept",
+		filterName: "setRequestHeader",
+		args:       []interface{}{"X-Test-Name", "${name}"},
+		valid:      true,
+		expectedHeader: http.Header{
+			"X-Test-Request-Name": []string{"${name}"},
+		},
+	}, {
+		msg:           "set response header from template",
+		filterName:    "setResponseHeader",
+		args:          []interface{}{"X-Test-Echo", "${request.method} ${request.header.X-Test-User}"},
+		valid:         true,
+		requestHeader: http.Header{"X-Test-User": []string{"alice"}},
+		expectedHeader: http.Header{
+			"X-Test-Echo":         []string{"GET alice"},
+			"X-Test-Request-User": []string{"alice"},
+		},
 	}, {
 		msg:        "set outgoing host on set",
 		filterName: "setRequestHeader",
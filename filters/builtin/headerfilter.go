@@ -4,6 +4,7 @@ import (
 	"strings"
 
 	"github.com/zalando/skipper/filters"
+	"github.com/zalando/skipper/filters/template"
 )
 
 type headerType int
@@ -25,6 +26,7 @@ const (
 type headerFilter struct {
 	typ        headerType
 	key, value string
+	template   *template.Template
 }
 
 // verifies that the filter config has two string parameters
@@ -70,6 +72,11 @@ func NewResponseHeader() filters.Spec {
 // Instances expect two parameters: the header name and the header value.
 // Name: "setRequestHeader".
 //
+// The header value of the set and append filters can contain the
+// placeholders of the template package, e.g. ${request.query.name} or
+// ${jwt.sub}. When a placeholder cannot be resolved, the header is not set.
+// The values without these placeholders are set as they are.
+//
 // If the header name is 'Host', the filter uses the `SetOutgoingHost()`
 // method to set the header in addition to the standard `Request.Header`
 // map.
@@ -142,20 +149,46 @@ func (spec *headerFilter) Name() string {
 //lint:ignore ST1016 "spec" makes sense here and we reuse the type for the filter
 func (spec *headerFilter) CreateFilter(config []interface{}) (filters.Filter, error) {
 	key, value, err := headerFilterConfig(spec.typ, config)
-	return &headerFilter{typ: spec.typ, key: key, value: value}, err
+	f := &headerFilter{typ: spec.typ, key: key, value: value}
+	if t := template.New(value); t.HasPlaceholders() {
+		f.template = t
+	}
+
+	return f, err
+}
+
+// headerValue evaluates the template of the header value. It returns
+// false when a placeholder could not be resolved, and the header should
+// not be set.
+func (f *headerFilter) headerValue(ctx filters.FilterContext) (string, bool) {
+	if f.template == nil {
+		return f.value, true
+	}
+
+	return f.template.Apply(ctx)
 }
 
 func (f *headerFilter) Request(ctx filters.FilterContext) {
 	switch f.typ {
 	case setRequestHeader:
-		ctx.Request().Header.Set(f.key, f.value)
+		value, ok := f.headerValue(ctx)
+		if !ok {
+			return
+		}
+
+		ctx.Request().Header.Set(f.key, value)
 		if strings.ToLower(f.key) == "host" {
-			ctx.SetOutgoingHost(f.value)
+			ctx.SetOutgoingHost(value)
 		}
 	case appendRequestHeader, depRequestHeader:
-		ctx.Request().Header.Add(f.key, f.value)
+		value, ok := f.headerValue(ctx)
+		if !ok {
+			return
+		}
+
+		ctx.Request().Header.Add(f.key, value)
 		if strings.ToLower(f.key) == "host" {
-			ctx.SetOutgoingHost(f.value)
+			ctx.SetOutgoingHost(value)
 		}
 	case dropRequestHeader:
 		ctx.Request().Header.Del(f.key)
@@ -165,9 +198,13 @@ func (f *headerFilter) Request(ctx filters.FilterContext) {
 func (f *headerFilter) Response(ctx filters.FilterContext) {
 	switch f.typ {
 	case setResponseHeader:
-		ctx.Response().Header.Set(f.key, f.value)
+		if value, ok := f.headerValue(ctx); ok {
+			ctx.Response().Header.Set(f.key, value)
+		}
 	case appendResponseHeader, depResponseHeader:
-		ctx.Response().Header.Add(f.key, f.value)
+		if value, ok := f.headerValue(ctx); ok {
+			ctx.Response().Header.Add(f.key, value)
+		}
 	case dropResponseHeader:
 		ctx.Response().Header.Del(f.key)
 	}
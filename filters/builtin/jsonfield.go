@@ -0,0 +1,372 @@
+package builtin
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"io"
+	"io/ioutil"
+	"mime"
+	"net/http"
+	"strconv"
+	"strings"
+
+	log "github.com/sirupsen/logrus"
+	"github.com/zalando/skipper/filters"
+	snet "github.com/zalando/skipper/net"
+)
+
+type jsonFieldType int
+
+const (
+	setRequestJsonField jsonFieldType = iota
+	setResponseJsonField
+	delRequestJsonField
+	delResponseJsonField
+)
+
+// maximum size of the bodies modified by the JSON field filters, larger
+// bodies are passed on unchanged
+const maxJsonFieldBodySize = 2 << 20
+
+var errInvalidJsonPath = errors.New("invalid JSON path")
+
+// common structure for the JSON field filter specifications and filters
+type jsonFieldFilter struct {
+	typ   jsonFieldType
+	path  []interface{}
+	value interface{}
+}
+
+// NewSetRequestJsonField returns a filter specification that sets a field
+// in the JSON body of the request. The first argument is the path of the
+// field, in a JSONPath like syntax, e.g. "$.user.roles[0]" or
+// "user.roles[0]". The missing objects on the path are created. The
+// second argument is the value, strings are set as JSON strings, and
+// numbers as JSON numbers. With the optional third argument "json", the
+// value is parsed as JSON, e.g. to set objects, arrays, booleans or null.
+// Name: "setRequestJsonField".
+//
+// Only the bodies with a JSON content type are modified, and the bodies
+// larger than 2MiB are passed on unchanged. The modified body is encoded
+// again, and the order of the object fields is not preserved.
+//
+// Example:
+//
+//	* -> setRequestJsonField("$.client.version", 2) -> "https://www.example.org";
+func NewSetRequestJsonField() filters.Spec {
+	return &jsonFieldFilter{typ: setRequestJsonField}
+}
+
+// NewSetResponseJsonField returns a filter specification that sets a
+// field in the JSON body of the response, with the same arguments as
+// setRequestJsonField. Name: "setResponseJsonField".
+func NewSetResponseJsonField() filters.Spec {
+	return &jsonFieldFilter{typ: setResponseJsonField}
+}
+
+// NewDelRequestJsonField returns a filter specification that deletes a
+// field from the JSON body of the request. The argument is the path of
+// the field. Name: "delRequestJsonField".
+//
+// Example:
+//
+//	* -> delRequestJsonField("$.debug") -> "https://www.example.org";
+func NewDelRequestJsonField() filters.Spec {
+	return &jsonFieldFilter{typ: delRequestJsonField}
+}
+
+// NewDelResponseJsonField returns a filter specification that deletes a
+// field from the JSON body of the response. The argument is the path of
+// the field. Name: "delResponseJsonField".
+func NewDelResponseJsonField() filters.Spec {
+	return &jsonFieldFilter{typ: delResponseJsonField}
+}
+
+func (spec *jsonFieldFilter) Name() string {
+	switch spec.typ {
+	case setRequestJsonField:
+		return SetRequestJsonFieldName
+	case setResponseJsonField:
+		return SetResponseJsonFieldName
+	case delRequestJsonField:
+		return DelRequestJsonFieldName
+	default:
+		return DelResponseJsonFieldName
+	}
+}
+
+// parseJsonPath parses the path of a field into object keys (string) and
+// array indexes (int).
+func parseJsonPath(p string) ([]interface{}, error) {
+	p = strings.TrimPrefix(strings.TrimPrefix(p, "$"), ".")
+	if p == "" {
+		return nil, errInvalidJsonPath
+	}
+
+	var path []interface{}
+	for _, segment := range strings.Split(p, ".") {
+		key := segment
+		var indexes []int
+		if i := strings.Index(segment, "["); i >= 0 {
+			key = segment[:i]
+			for _, is := range strings.Split(segment[i+1:], "[") {
+				if !strings.HasSuffix(is, "]") {
+					return nil, errInvalidJsonPath
+				}
+
+				index, err := strconv.Atoi(strings.TrimSuffix(is, "]"))
+				if err != nil || index < 0 {
+					return nil, errInvalidJsonPath
+				}
+
+				indexes = append(indexes, index)
+			}
+		}
+
+		if key == "" && (len(indexes) == 0 || len(path) > 0) {
+			return nil, errInvalidJsonPath
+		}
+
+		if key != "" {
+			path = append(path, key)
+		}
+
+		for _, i := range indexes {
+			path = append(path, i)
+		}
+	}
+
+	return path, nil
+}
+
+func (spec *jsonFieldFilter) CreateFilter(args []interface{}) (filters.Filter, error) {
+	f := &jsonFieldFilter{typ: spec.typ}
+	switch spec.typ {
+	case setRequestJsonField, setResponseJsonField:
+		if len(args) < 2 || len(args) > 3 {
+			return nil, filters.ErrInvalidFilterParameters
+		}
+
+		switch v := args[1].(type) {
+		case string:
+			f.value = v
+		case float64:
+			f.value = json.Number(strconv.FormatFloat(v, 'f', -1, 64))
+		case int:
+			f.value = json.Number(strconv.Itoa(v))
+		default:
+			return nil, filters.ErrInvalidFilterParameters
+		}
+
+		if len(args) == 3 {
+			s, ok := args[1].(string)
+			if !ok || args[2] != "json" {
+				return nil, filters.ErrInvalidFilterParameters
+			}
+
+			d := json.NewDecoder(strings.NewReader(s))
+			d.UseNumber()
+			if err := d.Decode(&f.value); err != nil {
+				return nil, filters.ErrInvalidFilterParameters
+			}
+		}
+	default:
+		if len(args) != 1 {
+			return nil, filters.ErrInvalidFilterParameters
+		}
+	}
+
+	p, ok := args[0].(string)
+	if !ok {
+		return nil, filters.ErrInvalidFilterParameters
+	}
+
+	var err error
+	if f.path, err = parseJsonPath(p); err != nil {
+		return nil, filters.ErrInvalidFilterParameters
+	}
+
+	return f, nil
+}
+
+func isJsonContent(h http.Header) bool {
+	if ce := h.Get("Content-Encoding"); ce != "" && ce != "identity" {
+		return false
+	}
+
+	mt, _, err := mime.ParseMediaType(h.Get("Content-Type"))
+	return err == nil && (mt == "application/json" || strings.HasSuffix(mt, "+json"))
+}
+
+// setJsonField sets the value at the path, and returns the modified
+// document. It returns false, when the path doesn't fit the document.
+func setJsonField(doc interface{}, path []interface{}, value interface{}) (interface{}, bool) {
+	if len(path) == 0 {
+		return value, true
+	}
+
+	switch key := path[0].(type) {
+	case string:
+		if doc == nil {
+			doc = make(map[string]interface{})
+		}
+
+		o, ok := doc.(map[string]interface{})
+		if !ok {
+			return doc, false
+		}
+
+		v, ok := setJsonField(o[key], path[1:], value)
+		if ok {
+			o[key] = v
+		}
+
+		return o, ok
+	default:
+		a, ok := doc.([]interface{})
+		i := key.(int)
+		if !ok || i >= len(a) {
+			return doc, false
+		}
+
+		v, ok := setJsonField(a[i], path[1:], value)
+		if ok {
+			a[i] = v
+		}
+
+		return a, ok
+	}
+}
+
+// delJsonField deletes the value at the path, and returns the modified
+// document. It returns false, when the field doesn't exist.
+func delJsonField(doc interface{}, path []interface{}) (interface{}, bool) {
+	switch key := path[0].(type) {
+	case string:
+		o, ok := doc.(map[string]interface{})
+		if !ok {
+			return doc, false
+		}
+
+		if _, ok := o[key]; !ok {
+			return o, false
+		}
+
+		if len(path) == 1 {
+			delete(o, key)
+			return o, true
+		}
+
+		v, ok := delJsonField(o[key], path[1:])
+		o[key] = v
+		return o, ok
+	default:
+		a, ok := doc.([]interface{})
+		i := key.(int)
+		if !ok || i >= len(a) {
+			return doc, false
+		}
+
+		if len(path) == 1 {
+			return append(a[:i], a[i+1:]...), true
+		}
+
+		v, ok := delJsonField(a[i], path[1:])
+		a[i] = v
+		return a, ok
+	}
+}
+
+// modify applies the filter to the body, and returns the new body and
+// the modified content. When the body cannot be modified, it returns the
+// original content, and nil.
+func (f *jsonFieldFilter) modify(body io.ReadCloser) (io.ReadCloser, []byte) {
+	b, rest, err := snet.ReadBody(body, maxJsonFieldBodySize)
+	if err != nil {
+		log.Errorf("Failed to read the body for the JSON field filter: %v.", err)
+	}
+
+	if rest != nil {
+		return rest, nil
+	}
+
+	unchanged := func() (io.ReadCloser, []byte) {
+		return ioutil.NopCloser(bytes.NewReader(b)), nil
+	}
+
+	var doc interface{}
+	d := json.NewDecoder(bytes.NewReader(b))
+	d.UseNumber()
+	if err := d.Decode(&doc); err != nil {
+		log.Debugf("Failed to decode the body for the JSON field filter: %v.", err)
+		return unchanged()
+	}
+
+	var ok bool
+	switch f.typ {
+	case setRequestJsonField, setResponseJsonField:
+		doc, ok = setJsonField(doc, f.path, f.value)
+	default:
+		doc, ok = delJsonField(doc, f.path)
+	}
+
+	if !ok {
+		return unchanged()
+	}
+
+	var buf bytes.Buffer
+	e := json.NewEncoder(&buf)
+	e.SetEscapeHTML(false)
+	if err := e.Encode(doc); err != nil {
+		log.Errorf("Failed to encode the body for the JSON field filter: %v.", err)
+		return unchanged()
+	}
+
+	modified := bytes.TrimSuffix(buf.Bytes(), []byte("\n"))
+
+	return ioutil.NopCloser(bytes.NewReader(modified)), modified
+}
+
+func (f *jsonFieldFilter) Request(ctx filters.FilterContext) {
+	if f.typ != setRequestJsonField && f.typ != delRequestJsonField {
+		return
+	}
+
+	req := ctx.Request()
+	if req.Body == nil || req.Body == http.NoBody || !isJsonContent(req.Header) {
+		return
+	}
+
+	var modified []byte
+	req.Body, modified = f.modify(req.Body)
+	if modified == nil {
+		return
+	}
+
+	req.ContentLength = int64(len(modified))
+	req.Header.Set("Content-Length", strconv.Itoa(len(modified)))
+	if req.GetBody != nil {
+		req.GetBody = func() (io.ReadCloser, error) {
+			return ioutil.NopCloser(bytes.NewReader(modified)), nil
+		}
+	}
+}
+
+func (f *jsonFieldFilter) Response(ctx filters.FilterContext) {
+	if f.typ != setResponseJsonField && f.typ != delResponseJsonField {
+		return
+	}
+
+	rsp := ctx.Response()
+	if rsp.Body == nil || !isJsonContent(rsp.Header) {
+		return
+	}
+
+	var modified []byte
+	rsp.Body, modified = f.modify(rsp.Body)
+	if modified != nil {
+		rsp.ContentLength = int64(len(modified))
+		rsp.Header.Set("Content-Length", strconv.Itoa(len(modified)))
+	}
+}
@@ -0,0 +1,231 @@
+package builtin
+
+import (
+	"io/ioutil"
+	"net/http"
+	"reflect"
+	"strconv"
+	"strings"
+	"testing"
+
+	"github.com/zalando/skipper/filters/filtertest"
+)
+
+func TestParseJsonPath(t *testing.T) {
+	for _, tt := range []struct {
+		path     string
+		expected []interface{}
+		err      bool
+	}{
+		{path: "", err: true},
+		{path: "$", err: true},
+		{path: "$.", err: true},
+		{path: "a..b", err: true},
+		{path: "a[x]", err: true},
+		{path: "a[-1]", err: true},
+		{path: "a[1", err: true},
+		{path: "a.[1]", err: true},
+		{path: "a", expected: []interface{}{"a"}},
+		{path: "$.a.b", expected: []interface{}{"a", "b"}},
+		{path: "a.b[2].c", expected: []interface{}{"a", "b", 2, "c"}},
+		{path: "$[0][1].a", expected: []interface{}{0, 1, "a"}},
+	} {
+		t.Run(tt.path, func(t *testing.T) {
+			p, err := parseJsonPath(tt.path)
+			if tt.err {
+				if err == nil {
+					t.Error("failed to fail")
+				}
+
+				return
+			}
+
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			if !reflect.DeepEqual(p, tt.expected) {
+				t.Errorf("unexpected path, expected: %v, got: %v", tt.expected, p)
+			}
+		})
+	}
+}
+
+func TestJsonFieldFilterArgs(t *testing.T) {
+	for _, tt := range []struct {
+		msg  string
+		spec *jsonFieldFilter
+		args []interface{}
+		err  bool
+	}{{
+		msg:  "set, missing value",
+		spec: &jsonFieldFilter{typ: setRequestJsonField},
+		args: []interface{}{"a"},
+		err:  true,
+	}, {
+		msg:  "set, invalid path",
+		spec: &jsonFieldFilter{typ: setRequestJsonField},
+		args: []interface{}{"a..b", "x"},
+		err:  true,
+	}, {
+		msg:  "set, invalid raw JSON",
+		spec: &jsonFieldFilter{typ: setResponseJsonField},
+		args: []interface{}{"a", "{", "json"},
+		err:  true,
+	}, {
+		msg:  "set, invalid value type option",
+		spec: &jsonFieldFilter{typ: setResponseJsonField},
+		args: []interface{}{"a", "true", "yaml"},
+		err:  true,
+	}, {
+		msg:  "del, too many args",
+		spec: &jsonFieldFilter{typ: delRequestJsonField},
+		args: []interface{}{"a", "b"},
+		err:  true,
+	}, {
+		msg:  "set",
+		spec: &jsonFieldFilter{typ: setRequestJsonField},
+		args: []interface{}{"a", float64(1)},
+	}, {
+		msg:  "set raw JSON",
+		spec: &jsonFieldFilter{typ: setRequestJsonField},
+		args: []interface{}{"a", `{"b": true}`, "json"},
+	}, {
+		msg:  "del",
+		spec: &jsonFieldFilter{typ: delResponseJsonField},
+		args: []interface{}{"a"},
+	}} {
+		t.Run(tt.msg, func(t *testing.T) {
+			_, err := tt.spec.CreateFilter(tt.args)
+			if tt.err && err == nil {
+				t.Error("failed to fail")
+			} else if !tt.err && err != nil {
+				t.Error(err)
+			}
+		})
+	}
+}
+
+func TestJsonFieldFilters(t *testing.T) {
+	for _, tt := range []struct {
+		msg         string
+		spec        *jsonFieldFilter
+		args        []interface{}
+		contentType string
+		body        string
+		expected    string
+	}{{
+		msg:      "set string",
+		spec:     &jsonFieldFilter{typ: setRequestJsonField},
+		args:     []interface{}{"$.user.name", "<jane>"},
+		body:     `{"user": {"name": "john"}}`,
+		expected: `{"user":{"name":"<jane>"}}`,
+	}, {
+		msg:      "set number, creating the missing objects",
+		spec:     &jsonFieldFilter{typ: setRequestJsonField},
+		args:     []interface{}{"client.version", float64(2)},
+		body:     `{"id": 12345678901234567890}`,
+		expected: `{"client":{"version":2},"id":12345678901234567890}`,
+	}, {
+		msg:      "set raw JSON in array",
+		spec:     &jsonFieldFilter{typ: setResponseJsonField},
+		args:     []interface{}{"items[1].flags", `{"beta": true}`, "json"},
+		body:     `{"items": [{}, {"flags": null}]}`,
+		expected: `{"items":[{},{"flags":{"beta":true}}]}`,
+	}, {
+		msg:      "set, index out of range",
+		spec:     &jsonFieldFilter{typ: setResponseJsonField},
+		args:     []interface{}{"items[2]", "x"},
+		body:     `{"items": [1, 2]}`,
+		expected: `{"items": [1, 2]}`,
+	}, {
+		msg:      "delete field",
+		spec:     &jsonFieldFilter{typ: delResponseJsonField},
+		args:     []interface{}{"$.debug"},
+		body:     `{"debug": {"trace": "x"}, "data": 1}`,
+		expected: `{"data":1}`,
+	}, {
+		msg:      "delete array element",
+		spec:     &jsonFieldFilter{typ: delRequestJsonField},
+		args:     []interface{}{"items[0]"},
+		body:     `{"items": [1, 2]}`,
+		expected: `{"items":[2]}`,
+	}, {
+		msg:      "delete missing field",
+		spec:     &jsonFieldFilter{typ: delRequestJsonField},
+		args:     []interface{}{"a.b"},
+		body:     `{"a": 1}`,
+		expected: `{"a": 1}`,
+	}, {
+		msg:      "invalid JSON",
+		spec:     &jsonFieldFilter{typ: setRequestJsonField},
+		args:     []interface{}{"a", "b"},
+		body:     `{"a": `,
+		expected: `{"a": `,
+	}, {
+		msg:         "not JSON content",
+		spec:        &jsonFieldFilter{typ: setResponseJsonField},
+		args:        []interface{}{"a", "b"},
+		contentType: "text/plain",
+		body:        `{"a": 1}`,
+		expected:    `{"a": 1}`,
+	}, {
+		msg:         "JSON suffix content type",
+		spec:        &jsonFieldFilter{typ: setResponseJsonField},
+		args:        []interface{}{"a", "b"},
+		contentType: "application/problem+json; charset=utf-8",
+		body:        `{"a": 1}`,
+		expected:    `{"a":"b"}`,
+	}} {
+		t.Run(tt.msg, func(t *testing.T) {
+			f, err := tt.spec.CreateFilter(tt.args)
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			contentType := tt.contentType
+			if contentType == "" {
+				contentType = "application/json"
+			}
+
+			header := http.Header{
+				"Content-Type":   []string{contentType},
+				"Content-Length": []string{strconv.Itoa(len(tt.body))},
+			}
+
+			ctx := &filtertest.Context{
+				FRequest: &http.Request{
+					Header:        header,
+					Body:          ioutil.NopCloser(strings.NewReader(tt.body)),
+					ContentLength: int64(len(tt.body)),
+				},
+				FResponse: &http.Response{
+					Header:        header,
+					Body:          ioutil.NopCloser(strings.NewReader(tt.body)),
+					ContentLength: int64(len(tt.body)),
+				},
+			}
+
+			f.Request(ctx)
+			f.Response(ctx)
+
+			body, contentLength := ctx.FRequest.Body, ctx.FRequest.ContentLength
+			if tt.spec.typ == setResponseJsonField || tt.spec.typ == delResponseJsonField {
+				body, contentLength = ctx.FResponse.Body, ctx.FResponse.ContentLength
+			}
+
+			b, err := ioutil.ReadAll(body)
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			if string(b) != tt.expected {
+				t.Errorf("unexpected body, expected: %s, got: %s", tt.expected, string(b))
+			}
+
+			if contentLength != int64(len(b)) || header.Get("Content-Length") != strconv.Itoa(len(b)) {
+				t.Errorf("unexpected content length: %d, %s", contentLength, header.Get("Content-Length"))
+			}
+		})
+	}
+}
@@ -0,0 +1,89 @@
+package builtin
+
+import "github.com/zalando/skipper/filters"
+
+type maxIdleConnsPerHostSpec struct{}
+
+type maxIdleConnsPerHostFilter struct {
+	n int
+}
+
+type disableKeepAlivesSpec struct{}
+
+type disableKeepAlivesFilter struct{}
+
+// NewBackendMaxIdleConnsPerHost returns a filter specification that sets
+// the maximum number of idle connections kept open per backend host, for
+// the backend requests of the route. It overrides the global setting, so
+// that routes with many short requests, e.g. RPC, can reuse more
+// connections.
+//
+// Example:
+//
+//	rpc: Path("/rpc") -> backendMaxIdleConnsPerHost(256) -> "https://rpc.example.org";
+func NewBackendMaxIdleConnsPerHost() filters.Spec {
+	return &maxIdleConnsPerHostSpec{}
+}
+
+func (s *maxIdleConnsPerHostSpec) Name() string {
+	return BackendMaxIdleConnsPerHostName
+}
+
+func (s *maxIdleConnsPerHostSpec) CreateFilter(args []interface{}) (filters.Filter, error) {
+	if len(args) != 1 {
+		return nil, filters.ErrInvalidFilterParameters
+	}
+
+	var n int
+	switch v := args[0].(type) {
+	case float64:
+		n = int(v)
+	case int:
+		n = v
+	default:
+		return nil, filters.ErrInvalidFilterParameters
+	}
+
+	if n <= 0 {
+		return nil, filters.ErrInvalidFilterParameters
+	}
+
+	return &maxIdleConnsPerHostFilter{n: n}, nil
+}
+
+func (f *maxIdleConnsPerHostFilter) Request(ctx filters.FilterContext) {
+	ctx.StateBag()[filters.BackendMaxIdleConnsPerHostKey] = f.n
+}
+
+func (f *maxIdleConnsPerHostFilter) Response(ctx filters.FilterContext) {}
+
+// NewBackendDisableKeepAlives returns a filter specification that
+// disables reusing the backend connections of the route, and every
+// backend request opens a new connection. It can be used for routes with
+// long running requests, e.g. long polling, where the reuse of the
+// connections brings little benefit.
+//
+// Example:
+//
+//	poll: Path("/poll") -> backendDisableKeepAlives() -> "https://poll.example.org";
+func NewBackendDisableKeepAlives() filters.Spec {
+	return &disableKeepAlivesSpec{}
+}
+
+func (s *disableKeepAlivesSpec) Name() string {
+	return BackendDisableKeepAlivesName
+}
+
+func (s *disableKeepAlivesSpec) CreateFilter(args []interface{}) (filters.Filter, error) {
+	if len(args) != 0 {
+		return nil, filters.ErrInvalidFilterParameters
+	}
+
+	return &disableKeepAlivesFilter{}, nil
+}
+
+func (f *disableKeepAlivesFilter) Request(ctx filters.FilterContext) {
+	ctx.StateBag()[filters.BackendDisableKeepAlivesKey] = true
+}
+
+func (f *disableKeepAlivesFilter) Response(ctx filters.FilterContext) {}
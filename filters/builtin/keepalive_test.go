@@ -0,0 +1,75 @@
+package builtin
+
+import (
+	"net/http"
+	"testing"
+
+	"github.com/zalando/skipper/filters"
+	"github.com/zalando/skipper/filters/filtertest"
+)
+
+func TestBackendMaxIdleConnsPerHost(t *testing.T) {
+	for _, tt := range []struct {
+		msg      string
+		args     []interface{}
+		err      bool
+		expected int
+	}{{
+		msg: "no args",
+		err: true,
+	}, {
+		msg:  "too many args",
+		args: []interface{}{float64(1), float64(2)},
+		err:  true,
+	}, {
+		msg:  "not a number",
+		args: []interface{}{"10"},
+		err:  true,
+	}, {
+		msg:  "zero",
+		args: []interface{}{float64(0)},
+		err:  true,
+	}, {
+		msg:      "valid",
+		args:     []interface{}{float64(32)},
+		expected: 32,
+	}} {
+		t.Run(tt.msg, func(t *testing.T) {
+			f, err := NewBackendMaxIdleConnsPerHost().CreateFilter(tt.args)
+			if tt.err {
+				if err == nil {
+					t.Fatal("expected error")
+				}
+
+				return
+			}
+
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			ctx := &filtertest.Context{FRequest: &http.Request{}, FStateBag: map[string]interface{}{}}
+			f.Request(ctx)
+			if n, ok := ctx.FStateBag[filters.BackendMaxIdleConnsPerHostKey].(int); !ok || n != tt.expected {
+				t.Errorf("expected %d, got %v", tt.expected, ctx.FStateBag[filters.BackendMaxIdleConnsPerHostKey])
+			}
+		})
+	}
+}
+
+func TestBackendDisableKeepAlives(t *testing.T) {
+	if _, err := NewBackendDisableKeepAlives().CreateFilter([]interface{}{true}); err == nil {
+		t.Error("expected error")
+	}
+
+	f, err := NewBackendDisableKeepAlives().CreateFilter(nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	ctx := &filtertest.Context{FRequest: &http.Request{}, FStateBag: map[string]interface{}{}}
+	f.Request(ctx)
+	if d, ok := ctx.FStateBag[filters.BackendDisableKeepAlivesKey].(bool); !ok || !d {
+		t.Errorf("expected keep-alives disabled, got %v", ctx.FStateBag[filters.BackendDisableKeepAlivesKey])
+	}
+}
@@ -0,0 +1,78 @@
+package builtin
+
+import (
+	"math"
+	"net/http"
+
+	"github.com/zalando/skipper/filters"
+)
+
+type maxRequestBody struct {
+	limit int64
+}
+
+// NewMaxRequestBody returns a filter specification that limits the size
+// of the request body of the route. The argument is either a number of
+// bytes, or a string with a unit, e.g. "1MB". The units are binary, 1kB
+// means 1024 bytes.
+//
+// The requests with a Content-Length header exceeding the limit are
+// rejected with 413 Request Entity Too Large without contacting the
+// backend. The bodies of unknown length, e.g. with chunked transfer
+// encoding, are counted while they are streamed to the backend, and the
+// backend request is aborted with 413 Request Entity Too Large, when the
+// limit is exceeded.
+//
+// Example:
+//
+//	upload: Path("/upload") -> maxRequestBody("10MB") -> "https://upload.example.org";
+func NewMaxRequestBody() filters.Spec { return &maxRequestBody{} }
+
+func (m *maxRequestBody) Name() string { return MaxRequestBodyName }
+
+func parseSize(v interface{}) (int64, error) {
+	var n float64
+	switch vt := v.(type) {
+	case float64:
+		n = vt
+	case int:
+		n = float64(vt)
+	case string:
+		var err error
+		if n, err = filters.ParseSize(vt); err != nil {
+			return 0, err
+		}
+	default:
+		return 0, filters.ErrInvalidFilterParameters
+	}
+
+	if n <= 0 || n >= math.MaxInt64 {
+		return 0, filters.ErrInvalidFilterParameters
+	}
+
+	return int64(n), nil
+}
+
+func (m *maxRequestBody) CreateFilter(args []interface{}) (filters.Filter, error) {
+	if len(args) != 1 {
+		return nil, filters.ErrInvalidFilterParameters
+	}
+
+	limit, err := parseSize(args[0])
+	if err != nil {
+		return nil, err
+	}
+
+	return &maxRequestBody{limit: limit}, nil
+}
+
+func (m *maxRequestBody) Request(ctx filters.FilterContext) {
+	if ctx.Request().ContentLength > m.limit {
+		ctx.Serve(&http.Response{StatusCode: http.StatusRequestEntityTooLarge})
+		return
+	}
+
+	ctx.StateBag()[filters.MaxRequestBodyKey] = m.limit
+}
+
+func (m *maxRequestBody) Response(ctx filters.FilterContext) {}
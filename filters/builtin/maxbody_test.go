@@ -0,0 +1,123 @@
+package builtin
+
+import (
+	"net/http"
+	"testing"
+
+	"github.com/zalando/skipper/filters"
+	"github.com/zalando/skipper/filters/filtertest"
+)
+
+func TestMaxRequestBodyArgs(t *testing.T) {
+	for _, tt := range []struct {
+		msg      string
+		args     []interface{}
+		err      bool
+		expected int64
+	}{{
+		msg: "no args",
+		err: true,
+	}, {
+		msg:  "too many args",
+		args: []interface{}{float64(1), float64(2)},
+		err:  true,
+	}, {
+		msg:  "zero",
+		args: []interface{}{float64(0)},
+		err:  true,
+	}, {
+		msg:  "negative",
+		args: []interface{}{float64(-1)},
+		err:  true,
+	}, {
+		msg:  "no number",
+		args: []interface{}{"MB"},
+		err:  true,
+	}, {
+		msg:  "unknown unit",
+		args: []interface{}{"1TB"},
+		err:  true,
+	}, {
+		msg:  "no unit",
+		args: []interface{}{"1024"},
+		err:  true,
+	}, {
+		msg:      "bytes",
+		args:     []interface{}{float64(1024)},
+		expected: 1024,
+	}, {
+		msg:      "megabytes",
+		args:     []interface{}{"1MB"},
+		expected: 1 << 20,
+	}, {
+		msg:      "fraction",
+		args:     []interface{}{"1.5 KiB"},
+		expected: 1536,
+	}} {
+		t.Run(tt.msg, func(t *testing.T) {
+			f, err := NewMaxRequestBody().CreateFilter(tt.args)
+			if tt.err {
+				if err == nil {
+					t.Fatal("expected error")
+				}
+
+				return
+			}
+
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			if limit := f.(*maxRequestBody).limit; limit != tt.expected {
+				t.Errorf("expected %d, got %d", tt.expected, limit)
+			}
+		})
+	}
+}
+
+func TestMaxRequestBody(t *testing.T) {
+	f, err := NewMaxRequestBody().CreateFilter([]interface{}{"1kB"})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	for _, tt := range []struct {
+		msg           string
+		contentLength int64
+		served        bool
+	}{{
+		msg:           "unknown length",
+		contentLength: -1,
+	}, {
+		msg:           "within the limit",
+		contentLength: 1024,
+	}, {
+		msg:           "exceeding the limit",
+		contentLength: 1025,
+		served:        true,
+	}} {
+		t.Run(tt.msg, func(t *testing.T) {
+			ctx := &filtertest.Context{
+				FRequest:  &http.Request{ContentLength: tt.contentLength},
+				FStateBag: map[string]interface{}{},
+			}
+
+			f.Request(ctx)
+			if ctx.Served() != tt.served {
+				t.Fatalf("expected served: %t, got: %t", tt.served, ctx.Served())
+			}
+
+			if tt.served {
+				if ctx.Response().StatusCode != http.StatusRequestEntityTooLarge {
+					t.Errorf("unexpected status code: %d", ctx.Response().StatusCode)
+				}
+
+				return
+			}
+
+			if limit, ok := ctx.FStateBag[filters.MaxRequestBodyKey].(int64); !ok || limit != 1024 {
+				t.Errorf("unexpected limit: %v", ctx.FStateBag[filters.MaxRequestBodyKey])
+			}
+		})
+	}
+}
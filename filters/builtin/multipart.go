@@ -0,0 +1,272 @@
+package builtin
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"mime"
+	"mime/multipart"
+	"net/http"
+	"strings"
+	"sync"
+
+	"github.com/zalando/skipper/filters"
+	snet "github.com/zalando/skipper/net"
+)
+
+// allowance for the part headers and the boundaries, when limiting the
+// total size of the inspected body
+const multipartOverhead = 64 << 10
+
+type multipartUploadLimit struct {
+	maxFileSize  int64
+	maxParts     int
+	contentTypes []string
+}
+
+// NewMultipartUploadLimit creates a filter spec for the
+// multipartUploadLimit() filter. The filter inspects the
+// multipart/form-data request bodies, and rejects the uploads exceeding
+// the maximum size of a part in bytes or the maximum number of parts,
+// with 413 Request Entity Too Large. The optional further arguments list
+// the allowed content types of the file parts, e.g. image/png or
+// image/*, and the files with other content types are rejected with 415
+// Unsupported Media Type.
+//
+// Usage of the filter:
+//
+//	upload: Path("/upload") -> multipartUploadLimit(10485760, 4, "image/png", "image/jpeg") -> "https://upload.example.org"
+//
+// The parts are validated while the body is forwarded to the backend,
+// without buffering it. When a limit is exceeded, the backend request is
+// aborted, and the client receives the status code of the violation.
+// Requests with other content types are forwarded unchanged.
+func NewMultipartUploadLimit() filters.Spec {
+	return &multipartUploadLimit{}
+}
+
+func (m *multipartUploadLimit) Name() string { return MultipartUploadLimitName }
+
+func (m *multipartUploadLimit) CreateFilter(args []interface{}) (filters.Filter, error) {
+	if len(args) < 2 {
+		return nil, filters.ErrInvalidFilterParameters
+	}
+
+	f := &multipartUploadLimit{}
+	switch v := args[0].(type) {
+	case float64:
+		f.maxFileSize = int64(v)
+	case int:
+		f.maxFileSize = int64(v)
+	default:
+		return nil, filters.ErrInvalidFilterParameters
+	}
+
+	switch v := args[1].(type) {
+	case float64:
+		f.maxParts = int(v)
+	case int:
+		f.maxParts = v
+	default:
+		return nil, filters.ErrInvalidFilterParameters
+	}
+
+	if f.maxFileSize <= 0 || f.maxParts <= 0 {
+		return nil, filters.ErrInvalidFilterParameters
+	}
+
+	for _, a := range args[2:] {
+		ct, ok := a.(string)
+		if !ok || ct == "" {
+			return nil, filters.ErrInvalidFilterParameters
+		}
+
+		f.contentTypes = append(f.contentTypes, strings.ToLower(ct))
+	}
+
+	return f, nil
+}
+
+func (m *multipartUploadLimit) allowedContentType(contentType string) bool {
+	if len(m.contentTypes) == 0 {
+		return true
+	}
+
+	// the default content type of the parts, defined in RFC 7578
+	mt := "text/plain"
+	if contentType != "" {
+		var err error
+		if mt, _, err = mime.ParseMediaType(contentType); err != nil {
+			return false
+		}
+	}
+
+	for _, ct := range m.contentTypes {
+		if ct == mt || strings.HasSuffix(ct, "/*") && strings.HasPrefix(mt, ct[:len(ct)-1]) {
+			return true
+		}
+	}
+
+	return false
+}
+
+// validate reads the parts of the body, and returns the response status
+// when a limit was exceeded or the body is invalid
+func (m *multipartUploadLimit) validate(r *multipart.Reader) int {
+	var parts int
+	for {
+		p, err := r.NextPart()
+		if err == io.EOF {
+			return 0
+		}
+
+		if err != nil {
+			return http.StatusBadRequest
+		}
+
+		parts++
+		if parts > m.maxParts {
+			return http.StatusRequestEntityTooLarge
+		}
+
+		if p.FileName() != "" && !m.allowedContentType(p.Header.Get("Content-Type")) {
+			return http.StatusUnsupportedMediaType
+		}
+
+		n, err := io.Copy(ioutil.Discard, io.LimitReader(p, m.maxFileSize+1))
+		if err != nil {
+			return http.StatusBadRequest
+		}
+
+		if n > m.maxFileSize {
+			return http.StatusRequestEntityTooLarge
+		}
+	}
+}
+
+func (m *multipartUploadLimit) Request(ctx filters.FilterContext) {
+	req := ctx.Request()
+	if req.Body == nil || req.Body == http.NoBody {
+		return
+	}
+
+	mt, params, err := mime.ParseMediaType(req.Header.Get("Content-Type"))
+	if err != nil || mt != "multipart/form-data" {
+		return
+	}
+
+	if params["boundary"] == "" {
+		ctx.Serve(&http.Response{StatusCode: http.StatusBadRequest})
+		return
+	}
+
+	// the total size is limited, too, so that the parts cannot exceed
+	// the limits with large headers
+	maxSize := int64(m.maxParts) * (m.maxFileSize + multipartOverhead)
+	if req.ContentLength > maxSize {
+		ctx.Serve(&http.Response{StatusCode: http.StatusRequestEntityTooLarge})
+		return
+	}
+
+	req.Body = &multipartLimitBody{
+		limit:    m,
+		boundary: params["boundary"],
+		body:     snet.LimitBody(req.Body, maxSize, errMultipartBodyTooLarge),
+	}
+
+	// the validated body cannot be replayed
+	req.GetBody = nil
+}
+
+func (m *multipartUploadLimit) Response(filters.FilterContext) {}
+
+var (
+	errMultipartBodyClosed   = errors.New("multipart body closed")
+	errMultipartBodyTooLarge = errors.New("multipart body too large")
+)
+
+// multipartLimitBody forwards the request body, while the parts are
+// validated in a separate goroutine, reading the same data through a
+// pipe. The validation is started with the first read.
+type multipartLimitBody struct {
+	limit    *multipartUploadLimit
+	boundary string
+	body     io.ReadCloser
+	once     sync.Once
+	pw       *io.PipeWriter
+	status   chan int
+	err      error
+}
+
+func (b *multipartLimitBody) start() {
+	pr, pw := io.Pipe()
+	b.pw = pw
+	b.status = make(chan int, 1)
+	go func() {
+		status := b.limit.validate(multipart.NewReader(pr, b.boundary))
+		if status == 0 {
+			// reading the epilogue
+			if _, err := io.Copy(ioutil.Discard, pr); err != nil {
+				status = http.StatusBadRequest
+			}
+		}
+
+		// unblocks the writes when the validation failed
+		pr.CloseWithError(errMultipartBodyClosed)
+		b.status <- status
+	}()
+}
+
+func (b *multipartLimitBody) reject(status int) error {
+	if status == 0 {
+		status = http.StatusBadRequest
+	}
+
+	b.err = &filters.RequestBodyError{
+		StatusCode: status,
+		Err:        fmt.Errorf("multipart upload rejected: %s", http.StatusText(status)),
+	}
+
+	b.pw.CloseWithError(b.err)
+	return b.err
+}
+
+func (b *multipartLimitBody) Read(p []byte) (int, error) {
+	if b.err != nil {
+		return 0, b.err
+	}
+
+	b.once.Do(b.start)
+
+	n, err := b.body.Read(p)
+	if err == errMultipartBodyTooLarge {
+		return 0, b.reject(http.StatusRequestEntityTooLarge)
+	}
+
+	if n > 0 {
+		if _, werr := b.pw.Write(p[:n]); werr != nil {
+			return 0, b.reject(<-b.status)
+		}
+	}
+
+	switch {
+	case err == io.EOF:
+		b.pw.Close()
+		if status := <-b.status; status != 0 {
+			return 0, b.reject(status)
+		}
+
+		b.err = io.EOF
+	case err != nil:
+		b.pw.CloseWithError(err)
+	}
+
+	return n, err
+}
+
+func (b *multipartLimitBody) Close() error {
+	b.once.Do(b.start)
+	b.pw.CloseWithError(errMultipartBodyClosed)
+	return b.body.Close()
+}
@@ -0,0 +1,244 @@
+package builtin
+
+import (
+	"bytes"
+	"errors"
+	"io/ioutil"
+	"mime/multipart"
+	"net/http"
+	"net/http/httptest"
+	"net/textproto"
+	"strings"
+	"testing"
+
+	"github.com/zalando/skipper/eskip"
+	"github.com/zalando/skipper/filters"
+	"github.com/zalando/skipper/filters/filtertest"
+	"github.com/zalando/skipper/proxy/proxytest"
+)
+
+type testPart struct {
+	field, fileName, contentType string
+	size                         int
+}
+
+func multipartBody(t *testing.T, parts []testPart) (string, []byte) {
+	var b bytes.Buffer
+	w := multipart.NewWriter(&b)
+	for _, p := range parts {
+		h := make(textproto.MIMEHeader)
+		disposition := `form-data; name="` + p.field + `"`
+		if p.fileName != "" {
+			disposition += `; filename="` + p.fileName + `"`
+		}
+
+		h.Set("Content-Disposition", disposition)
+		if p.contentType != "" {
+			h.Set("Content-Type", p.contentType)
+		}
+
+		pw, err := w.CreatePart(h)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		if _, err := pw.Write(bytes.Repeat([]byte("x"), p.size)); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	if err := w.Close(); err != nil {
+		t.Fatal(err)
+	}
+
+	return w.FormDataContentType(), b.Bytes()
+}
+
+func TestMultipartUploadLimitArgs(t *testing.T) {
+	for _, args := range [][]interface{}{
+		nil,
+		{float64(1024)},
+		{"1024", float64(2)},
+		{float64(1024), float64(0)},
+		{float64(-1), float64(2)},
+		{float64(1024), float64(2), 42.0},
+		{float64(1024), float64(2), ""},
+	} {
+		if _, err := NewMultipartUploadLimit().CreateFilter(args); err == nil {
+			t.Errorf("failed to fail: %v", args)
+		}
+	}
+}
+
+func TestMultipartUploadLimit(t *testing.T) {
+	for _, ti := range []struct {
+		msg            string
+		parts          []testPart
+		contentType    string
+		body           string
+		expectedStatus int
+	}{{
+		msg: "valid",
+		parts: []testPart{
+			{field: "title", size: 10},
+			{field: "image", fileName: "a.png", contentType: "image/png", size: 1024},
+			{field: "doc", fileName: "a.pdf", contentType: "application/pdf", size: 512},
+		},
+	}, {
+		msg:         "not multipart",
+		contentType: "application/json",
+		body:        `{"foo": "bar"}`,
+	}, {
+		msg:            "file too large",
+		parts:          []testPart{{field: "image", fileName: "a.png", contentType: "image/png", size: 1025}},
+		expectedStatus: http.StatusRequestEntityTooLarge,
+	}, {
+		msg:            "field too large",
+		parts:          []testPart{{field: "title", size: 1025}},
+		expectedStatus: http.StatusRequestEntityTooLarge,
+	}, {
+		msg: "too many parts",
+		parts: []testPart{
+			{field: "a", size: 1},
+			{field: "b", size: 1},
+			{field: "c", size: 1},
+			{field: "d", size: 1},
+		},
+		expectedStatus: http.StatusRequestEntityTooLarge,
+	}, {
+		msg:            "content type not allowed",
+		parts:          []testPart{{field: "script", fileName: "a.sh", contentType: "text/x-shellscript", size: 10}},
+		expectedStatus: http.StatusUnsupportedMediaType,
+	}, {
+		msg:            "file without content type",
+		parts:          []testPart{{field: "file", fileName: "a.txt", size: 10}},
+		expectedStatus: http.StatusUnsupportedMediaType,
+	}, {
+		msg:            "missing boundary",
+		contentType:    "multipart/form-data",
+		body:           "foo",
+		expectedStatus: http.StatusBadRequest,
+	}, {
+		msg:            "invalid body",
+		contentType:    "multipart/form-data; boundary=foo",
+		body:           "--foo\r\nContent-Disposition: form-data; name=\"a\"\r\n\r\nbar",
+		expectedStatus: http.StatusBadRequest,
+	}} {
+		t.Run(ti.msg, func(t *testing.T) {
+			f, err := NewMultipartUploadLimit().CreateFilter([]interface{}{float64(1024), float64(3), "image/*", "application/pdf"})
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			contentType, body := ti.contentType, []byte(ti.body)
+			if ti.parts != nil {
+				contentType, body = multipartBody(t, ti.parts)
+			}
+
+			req, err := http.NewRequest("POST", "https://www.example.org/upload", bytes.NewReader(body))
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			req.Header.Set("Content-Type", contentType)
+			ctx := &filtertest.Context{FRequest: req}
+			f.Request(ctx)
+			if ctx.FServed {
+				if ctx.FResponse.StatusCode != ti.expectedStatus {
+					t.Fatalf("unexpected status, expected: %d, got: %d", ti.expectedStatus, ctx.FResponse.StatusCode)
+				}
+
+				return
+			}
+
+			b, err := ioutil.ReadAll(req.Body)
+			req.Body.Close()
+			if ti.expectedStatus != 0 {
+				var berr *filters.RequestBodyError
+				if !errors.As(err, &berr) || berr.StatusCode != ti.expectedStatus {
+					t.Fatalf("expected status %d, got: %v", ti.expectedStatus, err)
+				}
+
+				return
+			}
+
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			if !bytes.Equal(b, body) {
+				t.Error("body not preserved")
+			}
+
+			if strings.HasPrefix(contentType, "multipart/") && req.ContentLength != int64(len(body)) {
+				t.Errorf("unexpected content length: %d", req.ContentLength)
+			}
+		})
+	}
+}
+
+func TestMultipartUploadLimitProxy(t *testing.T) {
+	backend := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		b, err := ioutil.ReadAll(r.Body)
+		if err != nil {
+			return
+		}
+
+		w.Write(b)
+	}))
+	defer backend.Close()
+
+	p := proxytest.New(MakeRegistry(), &eskip.Route{
+		Filters: []*eskip.Filter{{Name: MultipartUploadLimitName, Args: []interface{}{float64(1024), float64(3)}}},
+		Backend: backend.URL,
+	})
+	defer p.Close()
+
+	for _, ti := range []struct {
+		msg            string
+		parts          []testPart
+		expectedStatus int
+	}{{
+		msg:            "valid",
+		parts:          []testPart{{field: "image", fileName: "a.png", contentType: "image/png", size: 1024}},
+		expectedStatus: http.StatusOK,
+	}, {
+		msg:            "file too large",
+		parts:          []testPart{{field: "image", fileName: "a.png", contentType: "image/png", size: 64 << 10}},
+		expectedStatus: http.StatusRequestEntityTooLarge,
+	}} {
+		t.Run(ti.msg, func(t *testing.T) {
+			contentType, body := multipartBody(t, ti.parts)
+
+			// the body is streamed with an unknown length
+			req, err := http.NewRequest("POST", p.URL, ioutil.NopCloser(bytes.NewReader(body)))
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			req.Header.Set("Content-Type", contentType)
+			rsp, err := http.DefaultClient.Do(req)
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			defer rsp.Body.Close()
+			if rsp.StatusCode != ti.expectedStatus {
+				t.Fatalf("unexpected status, expected: %d, got: %d", ti.expectedStatus, rsp.StatusCode)
+			}
+
+			if ti.expectedStatus != http.StatusOK {
+				return
+			}
+
+			b, err := ioutil.ReadAll(rsp.Body)
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			if !bytes.Equal(b, body) {
+				t.Error("body not forwarded")
+			}
+		})
+	}
+}
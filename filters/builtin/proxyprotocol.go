@@ -0,0 +1,59 @@
+package builtin
+
+import "github.com/zalando/skipper/filters"
+
+type backendProxyProtocolSpec struct{}
+
+type backendProxyProtocolFilter struct {
+	version int
+}
+
+// NewBackendProxyProtocol returns a filter specification that sends the
+// PROXY protocol header, version 1 or 2, on the backend connections of
+// the route, so that the backend receives the address of the client.
+// The version is optional, and defaults to 1. Since the header is sent
+// once per connection, the backend connections of the route are not
+// reused.
+//
+// Example:
+//
+//	r: * -> backendProxyProtocol(2) -> "http://haproxy.example.org";
+func NewBackendProxyProtocol() filters.Spec {
+	return &backendProxyProtocolSpec{}
+}
+
+func (s *backendProxyProtocolSpec) Name() string {
+	return BackendProxyProtocolName
+}
+
+func (s *backendProxyProtocolSpec) CreateFilter(args []interface{}) (filters.Filter, error) {
+	switch len(args) {
+	case 0:
+		return &backendProxyProtocolFilter{version: 1}, nil
+	case 1:
+	default:
+		return nil, filters.ErrInvalidFilterParameters
+	}
+
+	var version int
+	switch v := args[0].(type) {
+	case float64:
+		version = int(v)
+	case int:
+		version = v
+	default:
+		return nil, filters.ErrInvalidFilterParameters
+	}
+
+	if version != 1 && version != 2 {
+		return nil, filters.ErrInvalidFilterParameters
+	}
+
+	return &backendProxyProtocolFilter{version: version}, nil
+}
+
+func (f *backendProxyProtocolFilter) Request(ctx filters.FilterContext) {
+	ctx.StateBag()[filters.BackendProxyProtocolKey] = f.version
+}
+
+func (f *backendProxyProtocolFilter) Response(ctx filters.FilterContext) {}
@@ -0,0 +1,57 @@
+package builtin
+
+import (
+	"testing"
+
+	"github.com/zalando/skipper/filters"
+	"github.com/zalando/skipper/filters/filtertest"
+)
+
+func TestBackendProxyProtocol(t *testing.T) {
+	for _, tt := range []struct {
+		msg      string
+		args     []interface{}
+		err      bool
+		expected int
+	}{{
+		msg:      "default version",
+		expected: 1,
+	}, {
+		msg:      "version 2",
+		args:     []interface{}{float64(2)},
+		expected: 2,
+	}, {
+		msg:  "unsupported version",
+		args: []interface{}{float64(3)},
+		err:  true,
+	}, {
+		msg:  "string",
+		args: []interface{}{"v1"},
+		err:  true,
+	}, {
+		msg:  "too many args",
+		args: []interface{}{float64(1), float64(2)},
+		err:  true,
+	}} {
+		t.Run(tt.msg, func(t *testing.T) {
+			f, err := NewBackendProxyProtocol().CreateFilter(tt.args)
+			if tt.err {
+				if err == nil {
+					t.Fatal("expected error")
+				}
+
+				return
+			}
+
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			ctx := &filtertest.Context{FStateBag: make(map[string]interface{})}
+			f.Request(ctx)
+			if v := ctx.FStateBag[filters.BackendProxyProtocolKey]; v != tt.expected {
+				t.Errorf("expected version %d, got %v", tt.expected, v)
+			}
+		})
+	}
+}
@@ -0,0 +1,96 @@
+package builtin
+
+import "github.com/zalando/skipper/filters"
+
+const (
+	defaultStrictTransportSecurity = "max-age=31536000; includeSubDomains"
+	defaultContentTypeOptions      = "nosniff"
+	defaultFrameOptions            = "DENY"
+	defaultReferrerPolicy          = "strict-origin-when-cross-origin"
+)
+
+// options of the securityHeaders filter, mapped to the response headers
+var securityHeaderOptions = map[string]string{
+	"hsts":               "Strict-Transport-Security",
+	"contentTypeOptions": "X-Content-Type-Options",
+	"frameOptions":       "X-Frame-Options",
+	"referrerPolicy":     "Referrer-Policy",
+	"csp":                "Content-Security-Policy",
+	"cspReportOnly":      "Content-Security-Policy-Report-Only",
+}
+
+type securityHeadersSpec struct{}
+
+type securityHeadersFilter struct {
+	headers map[string]string
+}
+
+// NewSecurityHeaders returns a filter specification that sets a preset of
+// security related response headers: Strict-Transport-Security,
+// X-Content-Type-Options, X-Frame-Options and Referrer-Policy, and
+// optionally Content-Security-Policy.
+//
+// The defaults can be overridden per route with pairs of option names
+// and values. The options are: hsts, contentTypeOptions, frameOptions,
+// referrerPolicy, csp and cspReportOnly. An empty value disables the
+// header. The headers set by the backend are overwritten.
+//
+// Example:
+//
+//	* -> securityHeaders("csp", "default-src 'self'", "frameOptions", "SAMEORIGIN") -> "https://www.example.org";
+func NewSecurityHeaders() filters.Spec {
+	return &securityHeadersSpec{}
+}
+
+func (s *securityHeadersSpec) Name() string {
+	return SecurityHeadersName
+}
+
+func (s *securityHeadersSpec) CreateFilter(args []interface{}) (filters.Filter, error) {
+	if len(args)%2 != 0 {
+		return nil, filters.ErrInvalidFilterParameters
+	}
+
+	values := map[string]string{
+		"hsts":               defaultStrictTransportSecurity,
+		"contentTypeOptions": defaultContentTypeOptions,
+		"frameOptions":       defaultFrameOptions,
+		"referrerPolicy":     defaultReferrerPolicy,
+	}
+
+	for i := 0; i < len(args); i += 2 {
+		option, ok := args[i].(string)
+		if !ok {
+			return nil, filters.ErrInvalidFilterParameters
+		}
+
+		if _, ok := securityHeaderOptions[option]; !ok {
+			return nil, filters.ErrInvalidFilterParameters
+		}
+
+		value, ok := args[i+1].(string)
+		if !ok {
+			return nil, filters.ErrInvalidFilterParameters
+		}
+
+		values[option] = value
+	}
+
+	f := &securityHeadersFilter{headers: make(map[string]string)}
+	for option, value := range values {
+		if value != "" {
+			f.headers[securityHeaderOptions[option]] = value
+		}
+	}
+
+	return f, nil
+}
+
+func (f *securityHeadersFilter) Request(filters.FilterContext) {}
+
+func (f *securityHeadersFilter) Response(ctx filters.FilterContext) {
+	h := ctx.Response().Header
+	for key, value := range f.headers {
+		h.Set(key, value)
+	}
+}
@@ -0,0 +1,93 @@
+package builtin
+
+import (
+	"net/http"
+	"reflect"
+	"testing"
+
+	"github.com/zalando/skipper/filters/filtertest"
+)
+
+func TestSecurityHeaders(t *testing.T) {
+	defaults := http.Header{
+		"Strict-Transport-Security": []string{defaultStrictTransportSecurity},
+		"X-Content-Type-Options":    []string{defaultContentTypeOptions},
+		"X-Frame-Options":           []string{defaultFrameOptions},
+		"Referrer-Policy":           []string{defaultReferrerPolicy},
+	}
+
+	for _, tt := range []struct {
+		msg      string
+		args     []interface{}
+		backend  http.Header
+		err      bool
+		expected http.Header
+	}{{
+		msg:  "odd number of args",
+		args: []interface{}{"csp"},
+		err:  true,
+	}, {
+		msg:  "unknown option",
+		args: []interface{}{"foo", "bar"},
+		err:  true,
+	}, {
+		msg:  "value not a string",
+		args: []interface{}{"hsts", float64(3600)},
+		err:  true,
+	}, {
+		msg:      "defaults",
+		expected: defaults,
+	}, {
+		msg:     "overwrites the backend headers",
+		backend: http.Header{"X-Frame-Options": []string{"ALLOW-FROM https://www.example.org"}, "X-Foo": []string{"bar"}},
+		expected: http.Header{
+			"Strict-Transport-Security": []string{defaultStrictTransportSecurity},
+			"X-Content-Type-Options":    []string{defaultContentTypeOptions},
+			"X-Frame-Options":           []string{defaultFrameOptions},
+			"Referrer-Policy":           []string{defaultReferrerPolicy},
+			"X-Foo":                     []string{"bar"},
+		},
+	}, {
+		msg: "overrides",
+		args: []interface{}{
+			"csp", "default-src 'self'",
+			"cspReportOnly", "script-src 'none'",
+			"frameOptions", "SAMEORIGIN",
+			"hsts", "",
+			"referrerPolicy", "no-referrer",
+		},
+		expected: http.Header{
+			"Content-Security-Policy":             []string{"default-src 'self'"},
+			"Content-Security-Policy-Report-Only": []string{"script-src 'none'"},
+			"X-Content-Type-Options":              []string{defaultContentTypeOptions},
+			"X-Frame-Options":                     []string{"SAMEORIGIN"},
+			"Referrer-Policy":                     []string{"no-referrer"},
+		},
+	}} {
+		t.Run(tt.msg, func(t *testing.T) {
+			f, err := NewSecurityHeaders().CreateFilter(tt.args)
+			if tt.err {
+				if err == nil {
+					t.Fatal("expected error")
+				}
+
+				return
+			}
+
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			h := http.Header{}
+			for k, v := range tt.backend {
+				h[k] = v
+			}
+
+			ctx := &filtertest.Context{FResponse: &http.Response{Header: h}}
+			f.Response(ctx)
+			if !reflect.DeepEqual(h, tt.expected) {
+				t.Errorf("expected %v, got %v", tt.expected, h)
+			}
+		})
+	}
+}
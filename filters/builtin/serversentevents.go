@@ -0,0 +1,74 @@
+package builtin
+
+import (
+	"time"
+
+	"github.com/zalando/skipper/filters"
+)
+
+type serverSentEventsSpec struct{}
+
+type serverSentEventsFilter struct {
+	idleTimeout time.Duration
+}
+
+// NewServerSentEvents returns a filter specification that marks the
+// responses of the route as streams of Server-Sent Events. The proxy
+// flushes the events to the client as they are received, the response
+// is not compressed or cached, and the backend timeout only applies to
+// receiving the response header. Responses with the text/event-stream
+// content type are handled the same way without the filter.
+//
+// The optional argument is the idle timeout of the stream, either a
+// duration string or a number of milliseconds. When no data is received
+// from the backend within the timeout, the stream is closed. It
+// overrides the global idle timeout of the proxy.
+//
+// Example:
+//
+//	events: Path("/events") -> serverSentEvents("5m") -> "http://events.example.org";
+func NewServerSentEvents() filters.Spec {
+	return &serverSentEventsSpec{}
+}
+
+func (s *serverSentEventsSpec) Name() string {
+	return ServerSentEventsName
+}
+
+func (s *serverSentEventsSpec) CreateFilter(args []interface{}) (filters.Filter, error) {
+	if len(args) > 1 {
+		return nil, filters.ErrInvalidFilterParameters
+	}
+
+	f := &serverSentEventsFilter{}
+	if len(args) == 0 {
+		return f, nil
+	}
+
+	switch v := args[0].(type) {
+	case string:
+		var err error
+		f.idleTimeout, err = time.ParseDuration(v)
+		if err != nil {
+			return nil, filters.ErrInvalidFilterParameters
+		}
+	case float64:
+		f.idleTimeout = time.Duration(v) * time.Millisecond
+	case int:
+		f.idleTimeout = time.Duration(v) * time.Millisecond
+	default:
+		return nil, filters.ErrInvalidFilterParameters
+	}
+
+	if f.idleTimeout < 0 {
+		return nil, filters.ErrInvalidFilterParameters
+	}
+
+	return f, nil
+}
+
+func (f *serverSentEventsFilter) Request(ctx filters.FilterContext) {
+	ctx.StateBag()[filters.ServerSentEventsKey] = f.idleTimeout
+}
+
+func (f *serverSentEventsFilter) Response(ctx filters.FilterContext) {}
@@ -0,0 +1,66 @@
+package builtin
+
+import (
+	"net/http"
+	"testing"
+	"time"
+
+	"github.com/zalando/skipper/filters"
+	"github.com/zalando/skipper/filters/filtertest"
+)
+
+func TestServerSentEvents(t *testing.T) {
+	for _, tt := range []struct {
+		name     string
+		args     []interface{}
+		expected time.Duration
+		fail     bool
+	}{{
+		name: "no args",
+	}, {
+		name:     "duration string",
+		args:     []interface{}{"5m"},
+		expected: 5 * time.Minute,
+	}, {
+		name:     "milliseconds",
+		args:     []interface{}{1500.0},
+		expected: 1500 * time.Millisecond,
+	}, {
+		name: "invalid duration",
+		args: []interface{}{"foo"},
+		fail: true,
+	}, {
+		name: "negative",
+		args: []interface{}{"-1s"},
+		fail: true,
+	}, {
+		name: "too many args",
+		args: []interface{}{"1s", "2s"},
+		fail: true,
+	}} {
+		t.Run(tt.name, func(t *testing.T) {
+			f, err := NewServerSentEvents().CreateFilter(tt.args)
+			if tt.fail {
+				if err == nil {
+					t.Error("failed to fail")
+				}
+
+				return
+			}
+
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			ctx := &filtertest.Context{
+				FRequest:  &http.Request{},
+				FStateBag: map[string]interface{}{},
+			}
+
+			f.Request(ctx)
+			if d, ok := ctx.FStateBag[filters.ServerSentEventsKey].(time.Duration); !ok || d != tt.expected {
+				t.Errorf("unexpected idle timeout, expected: %v, got: %v", tt.expected, ctx.FStateBag[filters.ServerSentEventsKey])
+			}
+		})
+	}
+}
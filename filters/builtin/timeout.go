@@ -0,0 +1,106 @@
+package builtin
+
+import (
+	"time"
+
+	"github.com/zalando/skipper/filters"
+)
+
+type timeoutSpec struct {
+	name string
+	key  string
+}
+
+type timeoutFilter struct {
+	key     string
+	timeout time.Duration
+}
+
+// NewBackendTimeout returns a filter specification that sets the total
+// timeout of the backend request of the route, including receiving the
+// response body. When the timeout is exceeded before the response header
+// was received, the proxy responds with 504 Gateway Timeout. The
+// argument is either a duration string, e.g. "2s", or a number of
+// milliseconds.
+//
+// Example:
+//
+//	reports: Path("/reports") -> backendTimeout("30s") -> "https://reports.example.org";
+func NewBackendTimeout() filters.Spec {
+	return &timeoutSpec{name: BackendTimeoutName, key: filters.BackendTimeoutKey}
+}
+
+// NewBackendResponseHeaderTimeout returns a filter specification that
+// sets the timeout of waiting for the response header of the backend of
+// the route. When exceeded, the proxy responds with 504 Gateway Timeout.
+// The timeout can be only shorter than the global response header
+// timeout of the proxy.
+//
+// Example:
+//
+//	search: Path("/search") -> backendResponseHeaderTimeout("500ms") -> "https://search.example.org";
+func NewBackendResponseHeaderTimeout() filters.Spec {
+	return &timeoutSpec{name: BackendResponseHeaderTimeoutName, key: filters.BackendResponseHeaderTimeoutKey}
+}
+
+// NewReadTimeout returns a filter specification that sets the timeout of
+// reading the request body from the client, while it is forwarded to the
+// backend. When exceeded, the proxy responds with 408 Request Timeout.
+// The timeout can be only shorter than the global read timeout of the
+// server.
+//
+// Example:
+//
+//	upload: Path("/upload") -> readTimeout("10s") -> "https://upload.example.org";
+func NewReadTimeout() filters.Spec {
+	return &timeoutSpec{name: ReadTimeoutName, key: filters.ReadTimeoutKey}
+}
+
+// NewBackendIdleConnTimeout returns a filter specification that sets how
+// long the idle backend connections of the route are kept open for reuse.
+// It overrides the idle timeout of the backend connections set globally.
+// Connections are still closed by the periodic closing of the idle
+// connections, when enabled.
+//
+// Example:
+//
+//	poll: Path("/poll") -> backendIdleConnTimeout("5m") -> "https://poll.example.org";
+func NewBackendIdleConnTimeout() filters.Spec {
+	return &timeoutSpec{name: BackendIdleConnTimeoutName, key: filters.BackendIdleConnTimeoutKey}
+}
+
+func (s *timeoutSpec) Name() string { return s.name }
+
+func (s *timeoutSpec) CreateFilter(args []interface{}) (filters.Filter, error) {
+	if len(args) != 1 {
+		return nil, filters.ErrInvalidFilterParameters
+	}
+
+	var d time.Duration
+	switch v := args[0].(type) {
+	case string:
+		var err error
+		d, err = time.ParseDuration(v)
+		if err != nil {
+			return nil, filters.ErrInvalidFilterParameters
+		}
+	case float64:
+		d = time.Duration(v) * time.Millisecond
+	case int:
+		d = time.Duration(v) * time.Millisecond
+	default:
+		return nil, filters.ErrInvalidFilterParameters
+	}
+
+	if d <= 0 {
+		return nil, filters.ErrInvalidFilterParameters
+	}
+
+	return &timeoutFilter{key: s.key, timeout: d}, nil
+}
+
+func (f *timeoutFilter) Request(ctx filters.FilterContext) {
+	ctx.StateBag()[f.key] = f.timeout
+}
+
+func (f *timeoutFilter) Response(ctx filters.FilterContext) {}
@@ -0,0 +1,82 @@
+package builtin
+
+import (
+	"net/http"
+	"testing"
+	"time"
+
+	"github.com/zalando/skipper/filters"
+	"github.com/zalando/skipper/filters/filtertest"
+)
+
+func TestTimeoutFilters(t *testing.T) {
+	for _, spec := range []struct {
+		spec filters.Spec
+		name string
+		key  string
+	}{
+		{NewBackendTimeout(), BackendTimeoutName, filters.BackendTimeoutKey},
+		{NewBackendResponseHeaderTimeout(), BackendResponseHeaderTimeoutName, filters.BackendResponseHeaderTimeoutKey},
+		{NewReadTimeout(), ReadTimeoutName, filters.ReadTimeoutKey},
+		{NewBackendIdleConnTimeout(), BackendIdleConnTimeoutName, filters.BackendIdleConnTimeoutKey},
+	} {
+		if spec.spec.Name() != spec.name {
+			t.Errorf("expected name %s, got %s", spec.name, spec.spec.Name())
+		}
+
+		for _, tt := range []struct {
+			msg      string
+			args     []interface{}
+			err      bool
+			expected time.Duration
+		}{{
+			msg:  "no args",
+			args: nil,
+			err:  true,
+		}, {
+			msg:  "too many args",
+			args: []interface{}{"1s", "2s"},
+			err:  true,
+		}, {
+			msg:  "invalid duration",
+			args: []interface{}{"foo"},
+			err:  true,
+		}, {
+			msg:  "zero",
+			args: []interface{}{float64(0)},
+			err:  true,
+		}, {
+			msg:      "duration string",
+			args:     []interface{}{"100ms"},
+			expected: 100 * time.Millisecond,
+		}, {
+			msg:      "milliseconds",
+			args:     []interface{}{float64(250)},
+			expected: 250 * time.Millisecond,
+		}} {
+			t.Run(spec.name+"/"+tt.msg, func(t *testing.T) {
+				f, err := spec.spec.CreateFilter(tt.args)
+				if tt.err {
+					if err == nil {
+						t.Fatal("expected error")
+					}
+					return
+				}
+
+				if err != nil {
+					t.Fatal(err)
+				}
+
+				ctx := &filtertest.Context{
+					FRequest:  &http.Request{},
+					FStateBag: map[string]interface{}{},
+				}
+
+				f.Request(ctx)
+				if d, ok := ctx.FStateBag[spec.key].(time.Duration); !ok || d != tt.expected {
+					t.Errorf("expected %v, got %v", tt.expected, ctx.FStateBag[spec.key])
+				}
+			})
+		}
+	}
+}
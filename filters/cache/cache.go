@@ -0,0 +1,168 @@
+/*
+Package cache provides the cacheResponse filter, that serves the
+responses of the routes from an in-memory cache.
+
+For detailed documentation of the cache behavior, see
+https://godoc.org/github.com/zalando/skipper/cache.
+*/
+package cache
+
+import (
+	"bytes"
+	"io"
+	"io/ioutil"
+	"net/http"
+	"time"
+
+	log "github.com/sirupsen/logrus"
+	"github.com/zalando/skipper/cache"
+	"github.com/zalando/skipper/filters"
+	snet "github.com/zalando/skipper/net"
+)
+
+// Name of the filter, it can be referenced in eskip by this name.
+const Name = "cacheResponse"
+
+const stateBagKey = "filter:cacheresponse"
+
+type spec struct {
+	cache *cache.Cache
+}
+
+type filter struct {
+	cache      *cache.Cache
+	defaultTTL time.Duration
+}
+
+type lookup struct {
+	key    string
+	header http.Header
+}
+
+// NewCacheResponse creates a filter spec for the cacheResponse()
+// filter. The filter serves the GET requests from the cache, when a
+// fresh response is stored for them, and stores the cacheable responses
+// of the backend, following the Cache-Control directives.
+//
+// The optional argument sets the TTL of the responses without explicit
+// freshness information, as a duration string or a number of
+// milliseconds. Without it, only the responses with the max-age,
+// s-maxage directives or the Expires header are stored.
+//
+// Example:
+//
+//	products: Path("/products") -> cacheResponse("30s") -> "https://products.example.org";
+//
+// The responses are buffered in memory before passed on to the client.
+func NewCacheResponse(c *cache.Cache) filters.Spec {
+	return &spec{cache: c}
+}
+
+func (s *spec) Name() string { return Name }
+
+func (s *spec) CreateFilter(args []interface{}) (filters.Filter, error) {
+	f := &filter{cache: s.cache}
+	switch len(args) {
+	case 0:
+	case 1:
+		switch v := args[0].(type) {
+		case string:
+			d, err := time.ParseDuration(v)
+			if err != nil {
+				return nil, filters.ErrInvalidFilterParameters
+			}
+
+			f.defaultTTL = d
+		case float64:
+			f.defaultTTL = time.Duration(v) * time.Millisecond
+		case int:
+			f.defaultTTL = time.Duration(v) * time.Millisecond
+		default:
+			return nil, filters.ErrInvalidFilterParameters
+		}
+	default:
+		return nil, filters.ErrInvalidFilterParameters
+	}
+
+	if f.defaultTTL < 0 {
+		return nil, filters.ErrInvalidFilterParameters
+	}
+
+	return f, nil
+}
+
+// routeID returns the ID of the matched route, when the filter context
+// provides it.
+func routeID(ctx filters.FilterContext) string {
+	if r, ok := ctx.(interface{ RouteId() string }); ok {
+		return r.RouteId()
+	}
+
+	return ""
+}
+
+func (f *filter) Request(ctx filters.FilterContext) {
+	req := ctx.Request()
+	if _, ok := ctx.StateBag()[filters.ServerSentEventsKey]; ok || !cache.Storable(req) {
+		return
+	}
+
+	// the key and the header are captured before the subsequent
+	// filters could change them
+	l := &lookup{key: cache.Key(routeID(ctx), req), header: req.Header.Clone()}
+	if cache.Lookup(req) {
+		if e := f.cache.Get(l.key, l.header); e != nil {
+			ctx.Serve(e.Response(time.Now()))
+			return
+		}
+	}
+
+	ctx.StateBag()[stateBagKey] = l
+}
+
+func (f *filter) Response(ctx filters.FilterContext) {
+	l, ok := ctx.StateBag()[stateBagKey].(*lookup)
+	if !ok {
+		return
+	}
+
+	rsp := ctx.Response()
+	if snet.IsEventStream(rsp) {
+		return
+	}
+
+	now := time.Now()
+	ttl, ok := cache.Freshness(l.header, rsp, f.defaultTTL, now)
+	if !ok || rsp.ContentLength > f.cache.MaxEntrySize() {
+		return
+	}
+
+	var body []byte
+	if rsp.Body != nil {
+		var (
+			rest io.ReadCloser
+			err  error
+		)
+
+		body, rest, err = snet.ReadBody(rsp.Body, f.cache.MaxEntrySize())
+		if err != nil {
+			log.Errorf("Failed to read response body for caching: %v.", err)
+		}
+
+		if rest != nil {
+			// streaming the rest of the body to the client
+			rsp.Body = rest
+			return
+		}
+
+		rsp.Body = ioutil.NopCloser(bytes.NewReader(body))
+	}
+
+	f.cache.Put(l.key, l.header, &cache.Entry{
+		StatusCode: rsp.StatusCode,
+		Header:     rsp.Header.Clone(),
+		Body:       body,
+		Created:    now,
+		Expires:    now.Add(ttl),
+	})
+}
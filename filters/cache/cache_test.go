@@ -0,0 +1,272 @@
+package cache
+
+import (
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"sync/atomic"
+	"testing"
+
+	"github.com/zalando/skipper/cache"
+	"github.com/zalando/skipper/eskip"
+	"github.com/zalando/skipper/filters"
+	"github.com/zalando/skipper/metrics/metricstest"
+	"github.com/zalando/skipper/proxy/proxytest"
+)
+
+func TestCreateFilter(t *testing.T) {
+	for _, ti := range []struct {
+		msg  string
+		args []interface{}
+		err  bool
+	}{{
+		msg: "no args",
+	}, {
+		msg:  "duration string",
+		args: []interface{}{"30s"},
+	}, {
+		msg:  "milliseconds",
+		args: []interface{}{float64(3000)},
+	}, {
+		msg:  "invalid duration",
+		args: []interface{}{"foo"},
+		err:  true,
+	}, {
+		msg:  "negative duration",
+		args: []interface{}{"-1s"},
+		err:  true,
+	}, {
+		msg:  "too many args",
+		args: []interface{}{"30s", "1m"},
+		err:  true,
+	}} {
+		t.Run(ti.msg, func(t *testing.T) {
+			_, err := NewCacheResponse(cache.New(cache.Options{})).CreateFilter(ti.args)
+			if ti.err && err == nil {
+				t.Error("failed to fail")
+			} else if !ti.err && err != nil {
+				t.Error(err)
+			}
+		})
+	}
+}
+
+func TestCacheResponse(t *testing.T) {
+	for _, ti := range []struct {
+		msg            string
+		args           []interface{}
+		cacheControl   string
+		requestHeader  http.Header
+		expectedCalls  int32
+		expectedBodies []string
+	}{{
+		msg:            "not cacheable",
+		expectedCalls:  3,
+		expectedBodies: []string{"1", "2", "3"},
+	}, {
+		msg:            "max-age",
+		cacheControl:   "max-age=60",
+		expectedCalls:  1,
+		expectedBodies: []string{"1", "1", "1"},
+	}, {
+		msg:            "default ttl",
+		args:           []interface{}{"1m"},
+		expectedCalls:  1,
+		expectedBodies: []string{"1", "1", "1"},
+	}, {
+		msg:            "no-store response",
+		args:           []interface{}{"1m"},
+		cacheControl:   "no-store",
+		expectedCalls:  3,
+		expectedBodies: []string{"1", "2", "3"},
+	}, {
+		msg:            "no-cache request",
+		cacheControl:   "max-age=60",
+		requestHeader:  http.Header{"Cache-Control": []string{"no-cache"}},
+		expectedCalls:  3,
+		expectedBodies: []string{"1", "2", "3"},
+	}} {
+		t.Run(ti.msg, func(t *testing.T) {
+			var calls int32
+			backend := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				n := atomic.AddInt32(&calls, 1)
+				if ti.cacheControl != "" {
+					w.Header().Set("Cache-Control", ti.cacheControl)
+				}
+
+				fmt.Fprint(w, n)
+			}))
+			defer backend.Close()
+
+			m := &metricstest.MockMetrics{}
+			fr := make(filters.Registry)
+			fr.Register(NewCacheResponse(cache.New(cache.Options{Metrics: m})))
+			p := proxytest.New(fr, &eskip.Route{
+				Filters: []*eskip.Filter{{Name: Name, Args: ti.args}},
+				Backend: backend.URL,
+			})
+			defer p.Close()
+
+			for i, expected := range ti.expectedBodies {
+				req, err := http.NewRequest("GET", p.URL, nil)
+				if err != nil {
+					t.Fatal(err)
+				}
+
+				for k, v := range ti.requestHeader {
+					req.Header[k] = v
+				}
+
+				rsp, err := http.DefaultClient.Do(req)
+				if err != nil {
+					t.Fatal(err)
+				}
+
+				b, err := ioutil.ReadAll(rsp.Body)
+				rsp.Body.Close()
+				if err != nil {
+					t.Fatal(err)
+				}
+
+				if rsp.StatusCode != http.StatusOK {
+					t.Errorf("request %d: unexpected status: %d", i, rsp.StatusCode)
+				}
+
+				if string(b) != expected {
+					t.Errorf("request %d: unexpected body, expected: %s, got: %s", i, expected, string(b))
+				}
+			}
+
+			if c := atomic.LoadInt32(&calls); c != ti.expectedCalls {
+				t.Errorf("unexpected number of backend calls, expected: %d, got: %d", ti.expectedCalls, c)
+			}
+		})
+	}
+}
+
+func TestLargeResponseNotCached(t *testing.T) {
+	var calls int32
+	backend := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		atomic.AddInt32(&calls, 1)
+		w.Header().Set("Cache-Control", "max-age=60")
+		fmt.Fprint(w, "0123456789")
+	}))
+	defer backend.Close()
+
+	fr := make(filters.Registry)
+	fr.Register(NewCacheResponse(cache.New(cache.Options{MaxEntrySize: 4, Metrics: &metricstest.MockMetrics{}})))
+	p := proxytest.New(fr, &eskip.Route{
+		Filters: []*eskip.Filter{{Name: Name}},
+		Backend: backend.URL,
+	})
+	defer p.Close()
+
+	for i := 0; i < 2; i++ {
+		rsp, err := http.Get(p.URL)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		b, err := ioutil.ReadAll(rsp.Body)
+		rsp.Body.Close()
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		if string(b) != "0123456789" {
+			t.Errorf("unexpected body: %s", string(b))
+		}
+	}
+
+	if c := atomic.LoadInt32(&calls); c != 2 {
+		t.Errorf("unexpected number of backend calls, expected: 2, got: %d", c)
+	}
+}
+
+func TestEventStreamNotCached(t *testing.T) {
+	var calls int32
+	backend := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		atomic.AddInt32(&calls, 1)
+		w.Header().Set("Cache-Control", "max-age=60")
+		w.Header().Set("Content-Type", "text/event-stream")
+		fmt.Fprint(w, "data: 1\n\n")
+	}))
+	defer backend.Close()
+
+	fr := make(filters.Registry)
+	fr.Register(NewCacheResponse(cache.New(cache.Options{Metrics: &metricstest.MockMetrics{}})))
+	p := proxytest.New(fr, &eskip.Route{
+		Filters: []*eskip.Filter{{Name: Name}},
+		Backend: backend.URL,
+	})
+	defer p.Close()
+
+	for i := 0; i < 2; i++ {
+		rsp, err := http.Get(p.URL)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		ioutil.ReadAll(rsp.Body)
+		rsp.Body.Close()
+	}
+
+	if c := atomic.LoadInt32(&calls); c != 2 {
+		t.Errorf("unexpected number of backend calls, expected: 2, got: %d", c)
+	}
+}
+
+func TestCachePerRoute(t *testing.T) {
+	newBackend := func(body string) *httptest.Server {
+		return httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			w.Header().Set("Cache-Control", "max-age=60")
+			fmt.Fprint(w, body)
+		}))
+	}
+
+	b1 := newBackend("route1")
+	defer b1.Close()
+	b2 := newBackend("route2")
+	defer b2.Close()
+
+	fr := make(filters.Registry)
+	fr.Register(NewCacheResponse(cache.New(cache.Options{Metrics: &metricstest.MockMetrics{}})))
+	p := proxytest.New(fr, &eskip.Route{
+		Id:      "route1",
+		Filters: []*eskip.Filter{{Name: Name}},
+		Backend: b1.URL,
+	}, &eskip.Route{
+		Id:         "route2",
+		Predicates: []*eskip.Predicate{{Name: "Header", Args: []interface{}{"X-Route", "2"}}},
+		Filters:    []*eskip.Filter{{Name: Name}},
+		Backend:    b2.URL,
+	})
+	defer p.Close()
+
+	for _, expected := range []string{"route1", "route2", "route1", "route2"} {
+		req, err := http.NewRequest("GET", p.URL, nil)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		if expected == "route2" {
+			req.Header.Set("X-Route", "2")
+		}
+
+		rsp, err := http.DefaultClient.Do(req)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		b, err := ioutil.ReadAll(rsp.Body)
+		rsp.Body.Close()
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		if string(b) != expected {
+			t.Errorf("unexpected body, expected: %s, got: %s", expected, string(b))
+		}
+	}
+}
@@ -21,6 +21,15 @@ The JS cookie behaves exactly as the response cookie, but it doesn't
 set the HttpOnly directive, so these cookies will be
 accessible from JS code running in web browsers.
 
+The rewrite filters change the cookies passed between the clients and
+the backends, e.g. when fronting legacy applications under new domains.
+The dropRequestCookie filter removes a cookie from the requests, and the
+dropResponseCookie filter removes a cookie set by the backend. The
+rewriteResponseCookieDomain and rewriteResponseCookiePath filters rewrite
+the Domain and the Path attributes of the cookies set by the backend, and
+the enforceResponseCookieFlags filter adds the Secure, HttpOnly or
+SameSite attributes to them.
+
 Examples:
 
     requestCookie("test-session", "abc")
@@ -31,6 +40,16 @@ Examples:
 
     // response cookie without HttpOnly:
     jsCookie("test-session-info", "abc-debug", 31536000, "change-only")
+
+    dropRequestCookie("legacy-tracking")
+
+    dropResponseCookie("legacy-tracking")
+
+    rewriteResponseCookieDomain("legacy.internal", "www.example.org")
+
+    rewriteResponseCookiePath("/", "/legacy")
+
+    enforceResponseCookieFlags("Secure", "HttpOnly", "SameSite=Lax")
 */
 package cookie
 
@@ -0,0 +1,249 @@
+package cookie
+
+import (
+	"net/http"
+	"strings"
+
+	"github.com/zalando/skipper/filters"
+)
+
+const (
+	DropRequestCookieFilterName           = "dropRequestCookie"
+	DropResponseCookieFilterName          = "dropResponseCookie"
+	RewriteResponseCookieDomainFilterName = "rewriteResponseCookieDomain"
+	RewriteResponseCookiePathFilterName   = "rewriteResponseCookiePath"
+	EnforceResponseCookieFlagsFilterName  = "enforceResponseCookieFlags"
+)
+
+type rewriteType int
+
+const (
+	dropRequest rewriteType = iota
+	dropResponse
+	rewriteDomain
+	rewritePath
+	enforceFlags
+)
+
+type rewriteSpec struct {
+	typ        rewriteType
+	filterName string
+}
+
+type rewriteFilter struct {
+	typ      rewriteType
+	name     string
+	from, to string
+	secure   bool
+	httpOnly bool
+	sameSite http.SameSite
+}
+
+// Creates a filter spec for removing cookies from requests.
+// Name: dropRequestCookie
+func NewDropRequestCookie() filters.Spec {
+	return &rewriteSpec{dropRequest, DropRequestCookieFilterName}
+}
+
+// Creates a filter spec for removing the cookies set by the backend
+// responses.
+// Name: dropResponseCookie
+func NewDropResponseCookie() filters.Spec {
+	return &rewriteSpec{dropResponse, DropResponseCookieFilterName}
+}
+
+// Creates a filter spec for rewriting the domain of the cookies set by
+// the backend responses.
+// Name: rewriteResponseCookieDomain
+func NewRewriteResponseCookieDomain() filters.Spec {
+	return &rewriteSpec{rewriteDomain, RewriteResponseCookieDomainFilterName}
+}
+
+// Creates a filter spec for rewriting the path prefix of the cookies set
+// by the backend responses.
+// Name: rewriteResponseCookiePath
+func NewRewriteResponseCookiePath() filters.Spec {
+	return &rewriteSpec{rewritePath, RewriteResponseCookiePathFilterName}
+}
+
+// Creates a filter spec for enforcing the Secure, HttpOnly and SameSite
+// attributes of the cookies set by the backend responses.
+// Name: enforceResponseCookieFlags
+func NewEnforceResponseCookieFlags() filters.Spec {
+	return &rewriteSpec{enforceFlags, EnforceResponseCookieFlagsFilterName}
+}
+
+func (s *rewriteSpec) Name() string { return s.filterName }
+
+func stringArgs(args []interface{}) ([]string, bool) {
+	s := make([]string, len(args))
+	for i, a := range args {
+		var ok bool
+		if s[i], ok = a.(string); !ok {
+			return nil, false
+		}
+	}
+
+	return s, true
+}
+
+func (s *rewriteSpec) CreateFilter(args []interface{}) (filters.Filter, error) {
+	sargs, ok := stringArgs(args)
+	if !ok {
+		return nil, filters.ErrInvalidFilterParameters
+	}
+
+	f := &rewriteFilter{typ: s.typ}
+	switch s.typ {
+	case dropRequest, dropResponse:
+		if len(sargs) != 1 || sargs[0] == "" {
+			return nil, filters.ErrInvalidFilterParameters
+		}
+
+		f.name = sargs[0]
+	case rewriteDomain:
+		if len(sargs) != 2 || sargs[0] == "" {
+			return nil, filters.ErrInvalidFilterParameters
+		}
+
+		f.from = strings.ToLower(strings.TrimPrefix(sargs[0], "."))
+		f.to = strings.TrimPrefix(sargs[1], ".")
+	case rewritePath:
+		if len(sargs) != 2 || !strings.HasPrefix(sargs[0], "/") || !strings.HasPrefix(sargs[1], "/") {
+			return nil, filters.ErrInvalidFilterParameters
+		}
+
+		f.from, f.to = sargs[0], sargs[1]
+	case enforceFlags:
+		if len(sargs) == 0 {
+			return nil, filters.ErrInvalidFilterParameters
+		}
+
+		for _, flag := range sargs {
+			switch strings.ToLower(flag) {
+			case "secure":
+				f.secure = true
+			case "httponly":
+				f.httpOnly = true
+			case "samesite=strict":
+				f.sameSite = http.SameSiteStrictMode
+			case "samesite=lax":
+				f.sameSite = http.SameSiteLaxMode
+			case "samesite=none":
+				// browsers reject SameSite=None without Secure
+				f.sameSite = http.SameSiteNoneMode
+				f.secure = true
+			default:
+				return nil, filters.ErrInvalidFilterParameters
+			}
+		}
+	}
+
+	return f, nil
+}
+
+func (f *rewriteFilter) Request(ctx filters.FilterContext) {
+	if f.typ != dropRequest {
+		return
+	}
+
+	req := ctx.Request()
+	cookies := req.Cookies()
+	req.Header.Del("Cookie")
+	for _, c := range cookies {
+		if c.Name != f.name {
+			req.AddCookie(c)
+		}
+	}
+}
+
+// parseSetCookie parses a single Set-Cookie header value
+func parseSetCookie(value string) *http.Cookie {
+	cookies := (&http.Response{Header: http.Header{SetCookieHttpHeader: []string{value}}}).Cookies()
+	if len(cookies) != 1 {
+		return nil
+	}
+
+	return cookies[0]
+}
+
+// rewrite applies the filter to a response cookie, and tells whether it
+// was changed. Returning nil means that the cookie needs to be removed.
+func (f *rewriteFilter) rewrite(c *http.Cookie) (*http.Cookie, bool) {
+	switch f.typ {
+	case dropResponse:
+		if c.Name == f.name {
+			return nil, true
+		}
+	case rewriteDomain:
+		if strings.ToLower(strings.TrimPrefix(c.Domain, ".")) == f.from {
+			c.Domain = f.to
+			return c, true
+		}
+	case rewritePath:
+		from := strings.TrimSuffix(f.from, "/")
+		if c.Path == f.from || c.Path == from || strings.HasPrefix(c.Path, from+"/") {
+			c.Path = strings.TrimSuffix(f.to, "/") + c.Path[len(from):]
+			if c.Path == "" {
+				c.Path = "/"
+			}
+
+			return c, true
+		}
+	case enforceFlags:
+		var changed bool
+		if f.secure && !c.Secure {
+			c.Secure, changed = true, true
+		}
+
+		if f.httpOnly && !c.HttpOnly {
+			c.HttpOnly, changed = true, true
+		}
+
+		if f.sameSite != 0 && c.SameSite != f.sameSite {
+			c.SameSite, changed = f.sameSite, true
+		}
+
+		return c, changed
+	}
+
+	return c, false
+}
+
+func (f *rewriteFilter) Response(ctx filters.FilterContext) {
+	if f.typ == dropRequest {
+		return
+	}
+
+	h := ctx.Response().Header
+	values := h[SetCookieHttpHeader]
+	if len(values) == 0 {
+		return
+	}
+
+	var rewritten []string
+	for _, v := range values {
+		c := parseSetCookie(v)
+		if c == nil {
+			// keeping the values that cannot be parsed unchanged
+			rewritten = append(rewritten, v)
+			continue
+		}
+
+		c, changed := f.rewrite(c)
+		switch {
+		case c == nil:
+		case changed:
+			rewritten = append(rewritten, c.String())
+		default:
+			rewritten = append(rewritten, v)
+		}
+	}
+
+	if len(rewritten) == 0 {
+		h.Del(SetCookieHttpHeader)
+		return
+	}
+
+	h[SetCookieHttpHeader] = rewritten
+}
@@ -0,0 +1,181 @@
+package cookie
+
+import (
+	"net/http"
+	"reflect"
+	"testing"
+
+	"github.com/zalando/skipper/filters"
+	"github.com/zalando/skipper/filters/filtertest"
+)
+
+func TestCreateRewriteFilter(t *testing.T) {
+	for _, ti := range []struct {
+		msg  string
+		spec filters.Spec
+		args []interface{}
+		err  bool
+	}{{
+		msg:  "drop without name",
+		spec: NewDropRequestCookie(),
+		err:  true,
+	}, {
+		msg:  "drop with empty name",
+		spec: NewDropResponseCookie(),
+		args: []interface{}{""},
+		err:  true,
+	}, {
+		msg:  "drop",
+		spec: NewDropResponseCookie(),
+		args: []interface{}{"session"},
+	}, {
+		msg:  "domain without target",
+		spec: NewRewriteResponseCookieDomain(),
+		args: []interface{}{"legacy.internal"},
+		err:  true,
+	}, {
+		msg:  "domain not a string",
+		spec: NewRewriteResponseCookieDomain(),
+		args: []interface{}{"legacy.internal", 42.0},
+		err:  true,
+	}, {
+		msg:  "domain",
+		spec: NewRewriteResponseCookieDomain(),
+		args: []interface{}{"legacy.internal", "www.example.org"},
+	}, {
+		msg:  "relative path",
+		spec: NewRewriteResponseCookiePath(),
+		args: []interface{}{"legacy", "/app"},
+		err:  true,
+	}, {
+		msg:  "path",
+		spec: NewRewriteResponseCookiePath(),
+		args: []interface{}{"/", "/app"},
+	}, {
+		msg:  "no flags",
+		spec: NewEnforceResponseCookieFlags(),
+		err:  true,
+	}, {
+		msg:  "unknown flag",
+		spec: NewEnforceResponseCookieFlags(),
+		args: []interface{}{"Secure", "Partitioned"},
+		err:  true,
+	}, {
+		msg:  "flags",
+		spec: NewEnforceResponseCookieFlags(),
+		args: []interface{}{"secure", "HttpOnly", "SameSite=Strict"},
+	}} {
+		t.Run(ti.msg, func(t *testing.T) {
+			_, err := ti.spec.CreateFilter(ti.args)
+			if ti.err && err == nil {
+				t.Error("failed to fail")
+			} else if !ti.err && err != nil {
+				t.Error(err)
+			}
+		})
+	}
+}
+
+func TestDropRequestCookie(t *testing.T) {
+	f, err := NewDropRequestCookie().CreateFilter([]interface{}{"tracking"})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	req, err := http.NewRequest("GET", "https://www.example.org", nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	req.Header.Set("Cookie", "session=abc; tracking=123; theme=dark")
+	f.Request(&filtertest.Context{FRequest: req})
+	if c := req.Header.Get("Cookie"); c != "session=abc; theme=dark" {
+		t.Errorf("unexpected cookie header: %s", c)
+	}
+}
+
+func TestRewriteResponseCookies(t *testing.T) {
+	for _, ti := range []struct {
+		msg      string
+		spec     filters.Spec
+		args     []interface{}
+		cookies  []string
+		expected []string
+	}{{
+		msg:      "drop",
+		spec:     NewDropResponseCookie(),
+		args:     []interface{}{"tracking"},
+		cookies:  []string{"session=abc; Path=/", "tracking=123"},
+		expected: []string{"session=abc; Path=/"},
+	}, {
+		msg:     "drop the only cookie",
+		spec:    NewDropResponseCookie(),
+		args:    []interface{}{"tracking"},
+		cookies: []string{"tracking=123"},
+	}, {
+		msg:  "domain",
+		spec: NewRewriteResponseCookieDomain(),
+		args: []interface{}{"legacy.internal", "example.org"},
+		cookies: []string{
+			"session=abc; Domain=.Legacy.internal; Path=/",
+			"other=def; Domain=other.internal",
+		},
+		expected: []string{
+			"session=abc; Path=/; Domain=example.org",
+			"other=def; Domain=other.internal",
+		},
+	}, {
+		msg:      "domain removed",
+		spec:     NewRewriteResponseCookieDomain(),
+		args:     []interface{}{"legacy.internal", ""},
+		cookies:  []string{"session=abc; Domain=legacy.internal"},
+		expected: []string{"session=abc"},
+	}, {
+		msg:  "path",
+		spec: NewRewriteResponseCookiePath(),
+		args: []interface{}{"/legacy", "/app"},
+		cookies: []string{
+			"a=1; Path=/legacy",
+			"b=2; Path=/legacy/admin",
+			"c=3; Path=/legacy-old",
+		},
+		expected: []string{
+			"a=1; Path=/app",
+			"b=2; Path=/app/admin",
+			"c=3; Path=/legacy-old",
+		},
+	}, {
+		msg:      "path from root",
+		spec:     NewRewriteResponseCookiePath(),
+		args:     []interface{}{"/", "/app/"},
+		cookies:  []string{"a=1; Path=/", "b=2; Path=/admin"},
+		expected: []string{"a=1; Path=/app/", "b=2; Path=/app/admin"},
+	}, {
+		msg:  "flags",
+		spec: NewEnforceResponseCookieFlags(),
+		args: []interface{}{"HttpOnly", "SameSite=None"},
+		cookies: []string{
+			"a=1; Path=/",
+			"b=2; Path=/; HttpOnly; Secure; SameSite=None",
+			"invalid",
+		},
+		expected: []string{
+			"a=1; Path=/; HttpOnly; Secure; SameSite=None",
+			"b=2; Path=/; HttpOnly; Secure; SameSite=None",
+			"invalid",
+		},
+	}} {
+		t.Run(ti.msg, func(t *testing.T) {
+			f, err := ti.spec.CreateFilter(ti.args)
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			rsp := &http.Response{Header: http.Header{SetCookieHttpHeader: ti.cookies}}
+			f.Response(&filtertest.Context{FResponse: rsp})
+			if got := rsp.Header[SetCookieHttpHeader]; !reflect.DeepEqual(got, ti.expected) {
+				t.Errorf("unexpected cookies, expected: %v, got: %v", ti.expected, got)
+			}
+		})
+	}
+}
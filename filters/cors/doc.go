@@ -1,5 +1,6 @@
 /*
-Package cors implements the origin header for CORS.
+Package cors implements the origin header for CORS, and the cors filter
+handling the preflight requests.
 
 How It Works
 
@@ -12,5 +13,11 @@ Usage
 	corsOrigin()
 	corsOrigin("https://www.example.org")
 	corsOrigin("https://www.example.org", "http://localhost:9001")
+
+The cors filter responds to the preflight requests locally, and sets the
+CORS headers of the responses, configured with pairs of option names and
+values, see NewCors:
+
+	cors("origins", "https://*.example.org", "methods", "GET,POST", "headers", "Content-Type", "maxAge", 600)
 */
 package cors
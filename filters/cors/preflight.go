@@ -0,0 +1,303 @@
+package cors
+
+import (
+	"fmt"
+	"net/http"
+	"regexp"
+	"strconv"
+	"strings"
+
+	"github.com/zalando/skipper/filters"
+)
+
+const (
+	corsName = "cors"
+
+	allowMethodsHeader     = "Access-Control-Allow-Methods"
+	allowHeadersHeader     = "Access-Control-Allow-Headers"
+	allowCredentialsHeader = "Access-Control-Allow-Credentials"
+	exposeHeadersHeader    = "Access-Control-Expose-Headers"
+	maxAgeHeader           = "Access-Control-Max-Age"
+	requestMethodHeader    = "Access-Control-Request-Method"
+	requestHeadersHeader   = "Access-Control-Request-Headers"
+
+	defaultAllowedMethods = "GET,HEAD,POST"
+)
+
+type corsSpec struct{}
+
+type corsFilter struct {
+	anyOrigin      bool
+	origins        []string
+	originPrefixes [][2]string
+	originPatterns []*regexp.Regexp
+	methods        []string
+	anyHeader      bool
+	headers        []string
+	exposeHeaders  []string
+	credentials    bool
+	maxAge         int
+}
+
+// NewCors creates the cors filter, that handles the CORS preflight
+// requests locally, and sets the CORS headers of the responses to the
+// allowed origins. The filter is configured with pairs of option names and
+// values:
+//
+//	cors("origins", "https://*.example.org,https://app.example.com", "methods", "GET,POST,PUT", "headers", "Content-Type,Authorization", "credentials", "true", "maxAge", 600)
+//
+// The origins can contain a * wildcard for the subdomains, or * alone
+// to allow any origin, but not together with the credentials. The
+// originPattern option accepts a regular expression matching the whole
+// allowed origin, and it can be repeated. The
+// methods default to GET, HEAD and POST, and the headers option accepts
+// * to allow any request header. The exposeHeaders option sets the
+// response headers available to the scripts.
+func NewCors() filters.Spec {
+	return corsSpec{}
+}
+
+func (corsSpec) Name() string { return corsName }
+
+func splitList(s string) []string {
+	var l []string
+	for _, si := range strings.Split(s, ",") {
+		if si = strings.TrimSpace(si); si != "" {
+			l = append(l, si)
+		}
+	}
+
+	return l
+}
+
+func (f *corsFilter) addOrigins(s string) error {
+	for _, o := range splitList(s) {
+		switch i := strings.Index(o, "*"); {
+		case o == "*":
+			f.anyOrigin = true
+		case i < 0:
+			f.origins = append(f.origins, o)
+		case strings.Count(o, "*") == 1:
+			f.originPrefixes = append(f.originPrefixes, [2]string{o[:i], o[i+1:]})
+		default:
+			return fmt.Errorf("invalid origin: %s", o)
+		}
+	}
+
+	return nil
+}
+
+func (corsSpec) CreateFilter(args []interface{}) (filters.Filter, error) {
+	if len(args)%2 != 0 {
+		return nil, filters.ErrInvalidFilterParameters
+	}
+
+	f := &corsFilter{methods: splitList(defaultAllowedMethods)}
+	for i := 0; i < len(args); i += 2 {
+		key, ok := args[i].(string)
+		if !ok {
+			return nil, filters.ErrInvalidFilterParameters
+		}
+
+		if key == "maxAge" {
+			maxAge, ok := args[i+1].(float64)
+			if !ok || maxAge < 0 {
+				return nil, filters.ErrInvalidFilterParameters
+			}
+
+			f.maxAge = int(maxAge)
+			continue
+		}
+
+		value, ok := args[i+1].(string)
+		if !ok {
+			return nil, filters.ErrInvalidFilterParameters
+		}
+
+		switch key {
+		case "origins":
+			if err := f.addOrigins(value); err != nil {
+				return nil, err
+			}
+		case "originPattern":
+			// the pattern needs to match the whole origin, otherwise
+			// e.g. https://example.org.evil.com would be allowed
+			rx, err := regexp.Compile("^(?:" + value + ")$")
+			if err != nil {
+				return nil, err
+			}
+
+			f.originPatterns = append(f.originPatterns, rx)
+		case "methods":
+			f.methods = nil
+			for _, m := range splitList(value) {
+				f.methods = append(f.methods, strings.ToUpper(m))
+			}
+		case "headers":
+			for _, h := range splitList(value) {
+				if h == "*" {
+					f.anyHeader = true
+				} else {
+					f.headers = append(f.headers, http.CanonicalHeaderKey(h))
+				}
+			}
+		case "exposeHeaders":
+			for _, h := range splitList(value) {
+				f.exposeHeaders = append(f.exposeHeaders, http.CanonicalHeaderKey(h))
+			}
+		case "credentials":
+			credentials, err := strconv.ParseBool(value)
+			if err != nil {
+				return nil, filters.ErrInvalidFilterParameters
+			}
+
+			f.credentials = credentials
+		default:
+			return nil, fmt.Errorf("unknown cors option: %s", key)
+		}
+	}
+
+	if len(f.methods) == 0 {
+		return nil, filters.ErrInvalidFilterParameters
+	}
+
+	if f.anyOrigin && f.credentials {
+		// it would allow any site to make requests with the
+		// credentials of the user
+		return nil, fmt.Errorf("cors credentials are not allowed with any origin")
+	}
+
+	return f, nil
+}
+
+func contains(l []string, s string) bool {
+	for _, li := range l {
+		if li == s {
+			return true
+		}
+	}
+
+	return false
+}
+
+func (f *corsFilter) allowOrigin(origin string) bool {
+	if f.anyOrigin || contains(f.origins, origin) {
+		return true
+	}
+
+	for _, p := range f.originPrefixes {
+		if len(origin) > len(p[0])+len(p[1]) && strings.HasPrefix(origin, p[0]) && strings.HasSuffix(origin, p[1]) {
+			return true
+		}
+	}
+
+	for _, rx := range f.originPatterns {
+		if rx.MatchString(origin) {
+			return true
+		}
+	}
+
+	return false
+}
+
+func (f *corsFilter) allowHeaders(requested []string) bool {
+	if f.anyHeader {
+		return true
+	}
+
+	for _, h := range requested {
+		if !contains(f.headers, http.CanonicalHeaderKey(h)) {
+			return false
+		}
+	}
+
+	return true
+}
+
+func isPreflight(r *http.Request) bool {
+	return r.Method == "OPTIONS" && r.Header.Get("Origin") != "" && r.Header.Get(requestMethodHeader) != ""
+}
+
+// varyOrigin tells whether the response headers depend on the origin of
+// the request
+func (f *corsFilter) varyOrigin() bool {
+	return !f.anyOrigin
+}
+
+// setOrigin sets the allowed origin and the credentials header.
+func (f *corsFilter) setOrigin(h http.Header, origin string) {
+	if f.varyOrigin() {
+		h.Set(allowOriginHeader, origin)
+	} else {
+		h.Set(allowOriginHeader, "*")
+	}
+
+	if f.credentials {
+		h.Set(allowCredentialsHeader, "true")
+	}
+}
+
+// Request responds to the preflight requests, without forwarding them to
+// the backend. The preflight requests with an origin, method or headers
+// that are not allowed, are rejected with 403 Forbidden.
+func (f *corsFilter) Request(ctx filters.FilterContext) {
+	r := ctx.Request()
+	if !isPreflight(r) {
+		return
+	}
+
+	origin := r.Header.Get("Origin")
+	method := strings.ToUpper(r.Header.Get(requestMethodHeader))
+	requestedHeaders := splitList(r.Header.Get(requestHeadersHeader))
+	if !f.allowOrigin(origin) || !contains(f.methods, method) || !f.allowHeaders(requestedHeaders) {
+		ctx.Serve(&http.Response{StatusCode: http.StatusForbidden, Header: http.Header{}})
+		return
+	}
+
+	h := http.Header{}
+	f.setOrigin(h, origin)
+	h.Set(allowMethodsHeader, strings.Join(f.methods, ", "))
+	if len(requestedHeaders) > 0 {
+		if f.anyHeader {
+			h.Set(allowHeadersHeader, strings.Join(requestedHeaders, ", "))
+		} else {
+			h.Set(allowHeadersHeader, strings.Join(f.headers, ", "))
+		}
+	}
+
+	if f.maxAge > 0 {
+		h.Set(maxAgeHeader, strconv.Itoa(f.maxAge))
+	}
+
+	if f.varyOrigin() {
+		h.Add("Vary", "Origin")
+	}
+
+	h.Add("Vary", requestMethodHeader)
+	h.Add("Vary", requestHeadersHeader)
+	ctx.Serve(&http.Response{StatusCode: http.StatusNoContent, Header: h})
+}
+
+// Response sets the CORS headers of the actual requests from an allowed
+// origin.
+func (f *corsFilter) Response(ctx filters.FilterContext) {
+	r := ctx.Request()
+	origin := r.Header.Get("Origin")
+	if origin == "" || isPreflight(r) {
+		return
+	}
+
+	h := ctx.Response().Header
+	if f.varyOrigin() {
+		h.Add("Vary", "Origin")
+	}
+
+	if !f.allowOrigin(origin) {
+		return
+	}
+
+	f.setOrigin(h, origin)
+	if len(f.exposeHeaders) > 0 {
+		h.Set(exposeHeadersHeader, strings.Join(f.exposeHeaders, ", "))
+	}
+}
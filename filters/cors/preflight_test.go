@@ -0,0 +1,272 @@
+package cors
+
+import (
+	"net/http"
+	"testing"
+
+	"github.com/zalando/skipper/filters"
+	"github.com/zalando/skipper/filters/filtertest"
+)
+
+func TestCorsArgs(t *testing.T) {
+	for _, tt := range []struct {
+		name    string
+		args    []interface{}
+		wantErr bool
+	}{{
+		name: "no args",
+	}, {
+		name:    "odd number of args",
+		args:    []interface{}{"origins"},
+		wantErr: true,
+	}, {
+		name:    "unknown option",
+		args:    []interface{}{"foo", "bar"},
+		wantErr: true,
+	}, {
+		name:    "invalid pattern",
+		args:    []interface{}{"originPattern", "["},
+		wantErr: true,
+	}, {
+		name:    "any origin with credentials",
+		args:    []interface{}{"origins", "https://app.example.com,*", "credentials", "true"},
+		wantErr: true,
+	}, {
+		name:    "multiple wildcards",
+		args:    []interface{}{"origins", "https://*.*.example.org"},
+		wantErr: true,
+	}, {
+		name:    "invalid credentials",
+		args:    []interface{}{"credentials", "maybe"},
+		wantErr: true,
+	}, {
+		name:    "invalid max age",
+		args:    []interface{}{"maxAge", "600"},
+		wantErr: true,
+	}, {
+		name:    "no methods",
+		args:    []interface{}{"methods", ""},
+		wantErr: true,
+	}, {
+		name: "all options",
+		args: []interface{}{
+			"origins", "https://*.example.org, https://app.example.com",
+			"originPattern", "^https://[a-z]+[.]example[.]com$",
+			"methods", "get,post",
+			"headers", "Content-Type",
+			"exposeHeaders", "X-Request-Id",
+			"credentials", "true",
+			"maxAge", float64(600),
+		},
+	}} {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := NewCors().CreateFilter(tt.args)
+			if tt.wantErr && err == nil {
+				t.Error("failed to fail")
+			} else if !tt.wantErr && err != nil {
+				t.Error(err)
+			}
+		})
+	}
+}
+
+func TestCorsPreflight(t *testing.T) {
+	args := []interface{}{
+		"origins", "https://*.example.org,https://app.example.com",
+		"originPattern", "^https://[a-z]+[.]example[.]net$",
+		"methods", "GET,POST,PUT",
+		"headers", "Content-Type,Authorization",
+		"credentials", "true",
+		"maxAge", float64(600),
+	}
+
+	for _, tt := range []struct {
+		name        string
+		args        []interface{}
+		origin      string
+		method      string
+		headers     string
+		wantStatus  int
+		wantOrigin  string
+		wantHeaders string
+	}{{
+		name:       "exact origin",
+		args:       args,
+		origin:     "https://app.example.com",
+		method:     "PUT",
+		wantStatus: http.StatusNoContent,
+		wantOrigin: "https://app.example.com",
+	}, {
+		name:        "wildcard origin with headers",
+		args:        args,
+		origin:      "https://www.example.org",
+		method:      "POST",
+		headers:     "content-type, authorization",
+		wantStatus:  http.StatusNoContent,
+		wantOrigin:  "https://www.example.org",
+		wantHeaders: "Content-Type, Authorization",
+	}, {
+		name:       "pattern origin",
+		args:       args,
+		origin:     "https://shop.example.net",
+		method:     "GET",
+		wantStatus: http.StatusNoContent,
+		wantOrigin: "https://shop.example.net",
+	}, {
+		name:       "origin not allowed",
+		args:       args,
+		origin:     "https://example.org",
+		method:     "GET",
+		wantStatus: http.StatusForbidden,
+	}, {
+		name:       "method not allowed",
+		args:       args,
+		origin:     "https://app.example.com",
+		method:     "DELETE",
+		wantStatus: http.StatusForbidden,
+	}, {
+		name:       "header not allowed",
+		args:       args,
+		origin:     "https://app.example.com",
+		method:     "GET",
+		headers:    "X-Custom",
+		wantStatus: http.StatusForbidden,
+	}, {
+		name:        "any origin and header",
+		args:        []interface{}{"origins", "*", "headers", "*"},
+		origin:      "https://www.example.org",
+		method:      "GET",
+		headers:     "X-Custom",
+		wantStatus:  http.StatusNoContent,
+		wantOrigin:  "*",
+		wantHeaders: "X-Custom",
+	}} {
+		t.Run(tt.name, func(t *testing.T) {
+			f, err := NewCors().CreateFilter(tt.args)
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			req, err := http.NewRequest("OPTIONS", "https://api.example.org/orders", nil)
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			req.Header.Set("Origin", tt.origin)
+			req.Header.Set(requestMethodHeader, tt.method)
+			if tt.headers != "" {
+				req.Header.Set(requestHeadersHeader, tt.headers)
+			}
+
+			ctx := &filtertest.Context{FRequest: req}
+			f.Request(ctx)
+			if !ctx.FServed {
+				t.Fatal("preflight request not served")
+			}
+
+			rsp := ctx.FResponse
+			if rsp.StatusCode != tt.wantStatus {
+				t.Fatalf("unexpected status code: %d, want: %d", rsp.StatusCode, tt.wantStatus)
+			}
+
+			if got := rsp.Header.Get(allowOriginHeader); got != tt.wantOrigin {
+				t.Errorf("unexpected allowed origin: %q, want: %q", got, tt.wantOrigin)
+			}
+
+			if got := rsp.Header.Get(allowHeadersHeader); got != tt.wantHeaders {
+				t.Errorf("unexpected allowed headers: %q, want: %q", got, tt.wantHeaders)
+			}
+
+			if tt.wantStatus != http.StatusNoContent || tt.args[0] != "origins" || tt.args[1] == "*" {
+				return
+			}
+
+			for k, v := range map[string]string{
+				allowMethodsHeader:     "GET, POST, PUT",
+				allowCredentialsHeader: "true",
+				maxAgeHeader:           "600",
+			} {
+				if got := rsp.Header.Get(k); got != v {
+					t.Errorf("unexpected %s: %q, want: %q", k, got, v)
+				}
+			}
+		})
+	}
+}
+
+func corsResponse(t *testing.T, f filters.Filter, method, origin string) *http.Response {
+	req, err := http.NewRequest(method, "https://api.example.org/orders", nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if origin != "" {
+		req.Header.Set("Origin", origin)
+	}
+
+	ctx := &filtertest.Context{FRequest: req, FResponse: &http.Response{Header: http.Header{}}}
+	f.Request(ctx)
+	if ctx.FServed {
+		t.Fatal("request served by the filter")
+	}
+
+	f.Response(ctx)
+	return ctx.FResponse
+}
+
+func TestCorsResponse(t *testing.T) {
+	f, err := NewCors().CreateFilter([]interface{}{
+		"origins", "https://app.example.com",
+		"exposeHeaders", "x-request-id",
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	rsp := corsResponse(t, f, "GET", "https://app.example.com")
+	if got := rsp.Header.Get(allowOriginHeader); got != "https://app.example.com" {
+		t.Errorf("unexpected allowed origin: %q", got)
+	}
+
+	if got := rsp.Header.Get(exposeHeadersHeader); got != "X-Request-Id" {
+		t.Errorf("unexpected exposed headers: %q", got)
+	}
+
+	if got := rsp.Header.Get("Vary"); got != "Origin" {
+		t.Errorf("unexpected vary header: %q", got)
+	}
+
+	rsp = corsResponse(t, f, "GET", "https://www.example.org")
+	if got := rsp.Header.Get(allowOriginHeader); got != "" {
+		t.Errorf("origin allowed: %q", got)
+	}
+
+	if got := rsp.Header.Get("Vary"); got != "Origin" {
+		t.Errorf("unexpected vary header: %q", got)
+	}
+
+	// OPTIONS requests without the preflight headers are forwarded
+	rsp = corsResponse(t, f, "OPTIONS", "")
+	if got := rsp.Header.Get(allowOriginHeader); got != "" {
+		t.Errorf("headers set without origin: %q", got)
+	}
+}
+
+func TestCorsOriginPatternMatchesWholeOrigin(t *testing.T) {
+	f, err := NewCors().CreateFilter([]interface{}{"originPattern", "https://[a-z]+[.]example[.]org"})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	rsp := corsResponse(t, f, "GET", "https://app.example.org")
+	if got := rsp.Header.Get(allowOriginHeader); got != "https://app.example.org" {
+		t.Errorf("unexpected allowed origin: %q", got)
+	}
+
+	for _, origin := range []string{"https://app.example.org.example.com", "http://evil.com/https://app.example.org"} {
+		rsp = corsResponse(t, f, "GET", origin)
+		if got := rsp.Header.Get(allowOriginHeader); got != "" {
+			t.Errorf("origin allowed: %q", got)
+		}
+	}
+}
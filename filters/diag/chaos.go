@@ -0,0 +1,124 @@
+package diag
+
+import (
+	"math/rand"
+	"net/http"
+
+	log "github.com/sirupsen/logrus"
+	"github.com/zalando/skipper/filters"
+)
+
+const (
+	FaultName           = "fault"
+	AbortConnectionName = "abortConnection"
+)
+
+type fault struct {
+	status      int
+	probability float64
+}
+
+type abortConnection struct {
+	probability float64
+}
+
+// NewFault creates a filter specification whose filter instances can be
+// used to respond to a part of the requests with an error status, without
+// forwarding them to the backend. It expects the status code, and
+// optionally the probability of the fault, between 0 and 1, which defaults
+// to 1. Eskip example:
+//
+// 	* -> fault(503, 0.05) -> "https://www.example.org";
+func NewFault() filters.Spec { return &fault{} }
+
+// NewAbortConnection creates a filter specification whose filter instances
+// can be used to close the client connection of a part of the requests,
+// without sending a response. It expects the probability of the abort,
+// between 0 and 1. Eskip example:
+//
+// 	* -> abortConnection(0.01) -> "https://www.example.org";
+//
+// When the connection can't be taken over, e.g. with HTTP/2, the request
+// is answered with 502 Bad Gateway instead.
+func NewAbortConnection() filters.Spec { return &abortConnection{} }
+
+func parseProbability(v interface{}) (float64, error) {
+	p, ok := v.(float64)
+	if !ok || p < 0 || p > 1 {
+		return 0, filters.ErrInvalidFilterParameters
+	}
+
+	return p, nil
+}
+
+// happens tells whether an event with the given probability happens
+func happens(probability float64) bool {
+	return probability >= 1 || rand.Float64() < probability
+}
+
+func (f *fault) Name() string { return FaultName }
+
+func (f *fault) CreateFilter(args []interface{}) (filters.Filter, error) {
+	if len(args) == 0 || len(args) > 2 {
+		return nil, filters.ErrInvalidFilterParameters
+	}
+
+	status, ok := args[0].(float64)
+	if !ok || status < 100 || status > 599 {
+		return nil, filters.ErrInvalidFilterParameters
+	}
+
+	probability := 1.0
+	if len(args) == 2 {
+		var err error
+		if probability, err = parseProbability(args[1]); err != nil {
+			return nil, err
+		}
+	}
+
+	return &fault{status: int(status), probability: probability}, nil
+}
+
+func (f *fault) Request(ctx filters.FilterContext) {
+	if happens(f.probability) {
+		ctx.Serve(&http.Response{StatusCode: f.status})
+	}
+}
+
+func (f *fault) Response(ctx filters.FilterContext) {}
+
+func (a *abortConnection) Name() string { return AbortConnectionName }
+
+func (a *abortConnection) CreateFilter(args []interface{}) (filters.Filter, error) {
+	if len(args) != 1 {
+		return nil, filters.ErrInvalidFilterParameters
+	}
+
+	probability, err := parseProbability(args[0])
+	if err != nil {
+		return nil, err
+	}
+
+	return &abortConnection{probability: probability}, nil
+}
+
+func (a *abortConnection) Request(ctx filters.FilterContext) {
+	if !happens(a.probability) {
+		return
+	}
+
+	if h, ok := ctx.ResponseWriter().(http.Hijacker); ok {
+		conn, _, err := h.Hijack()
+		if err == nil {
+			conn.Close()
+			ctx.MarkServed()
+			return
+		}
+
+		log.Errorf("Failed to take over the connection to abort it: %v.", err)
+	}
+
+	ctx.Serve(&http.Response{StatusCode: http.StatusBadGateway})
+}
+
+func (a *abortConnection) Response(ctx filters.FilterContext) {}
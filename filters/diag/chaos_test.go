@@ -0,0 +1,112 @@
+package diag
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/zalando/skipper/eskip"
+	"github.com/zalando/skipper/filters"
+	"github.com/zalando/skipper/filters/filtertest"
+	"github.com/zalando/skipper/proxy/proxytest"
+)
+
+func TestChaosArgs(t *testing.T) {
+	for _, tt := range []struct {
+		name    string
+		spec    filters.Spec
+		args    []interface{}
+		wantErr bool
+	}{
+		{name: "fault without args", spec: NewFault(), wantErr: true},
+		{name: "fault with invalid status", spec: NewFault(), args: []interface{}{"503"}, wantErr: true},
+		{name: "fault with status out of range", spec: NewFault(), args: []interface{}{600.0}, wantErr: true},
+		{name: "fault with invalid probability", spec: NewFault(), args: []interface{}{503.0, 1.5}, wantErr: true},
+		{name: "fault", spec: NewFault(), args: []interface{}{503.0}},
+		{name: "fault with probability", spec: NewFault(), args: []interface{}{503.0, 0.05}},
+		{name: "abort without args", spec: NewAbortConnection(), wantErr: true},
+		{name: "abort with negative probability", spec: NewAbortConnection(), args: []interface{}{-0.1}, wantErr: true},
+		{name: "abort", spec: NewAbortConnection(), args: []interface{}{0.01}},
+		{name: "latency with invalid probability", spec: NewLatency(), args: []interface{}{"100ms", "0.1"}, wantErr: true},
+		{name: "latency with probability", spec: NewLatency(), args: []interface{}{"100ms", 0.1}},
+		{name: "bandwidth with probability", spec: NewBandwidth(), args: []interface{}{30.0, 0.1}, wantErr: true},
+	} {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := tt.spec.CreateFilter(tt.args)
+			if tt.wantErr && err == nil {
+				t.Error("failed to fail")
+			} else if !tt.wantErr && err != nil {
+				t.Error(err)
+			}
+		})
+	}
+}
+
+func TestFault(t *testing.T) {
+	for _, tt := range []struct {
+		probability float64
+		min, max    int
+	}{
+		{probability: 0, min: 0, max: 0},
+		{probability: 1, min: 1000, max: 1000},
+		{probability: 0.1, min: 50, max: 150},
+	} {
+		f, err := NewFault().CreateFilter([]interface{}{503.0, tt.probability})
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		faults := 0
+		for i := 0; i < 1000; i++ {
+			ctx := &filtertest.Context{FRequest: &http.Request{}}
+			f.Request(ctx)
+			if ctx.FServed {
+				if ctx.FResponse.StatusCode != http.StatusServiceUnavailable {
+					t.Fatalf("unexpected status code: %d", ctx.FResponse.StatusCode)
+				}
+
+				faults++
+			}
+		}
+
+		if faults < tt.min || faults > tt.max {
+			t.Errorf("unexpected number of faults with probability %v: %d", tt.probability, faults)
+		}
+	}
+}
+
+func TestAbortConnection(t *testing.T) {
+	backend := httptest.NewServer(http.HandlerFunc(func(http.ResponseWriter, *http.Request) {}))
+	defer backend.Close()
+
+	fr := make(filters.Registry)
+	fr.Register(NewAbortConnection())
+	p := proxytest.New(fr, &eskip.Route{
+		Id:      "abort",
+		Path:    "/abort",
+		Filters: []*eskip.Filter{{Name: AbortConnectionName, Args: []interface{}{1.0}}},
+		Backend: backend.URL,
+	}, &eskip.Route{
+		Id:      "pass",
+		Path:    "/pass",
+		Filters: []*eskip.Filter{{Name: AbortConnectionName, Args: []interface{}{0.0}}},
+		Backend: backend.URL,
+	})
+	defer p.Close()
+
+	rsp, err := http.Get(p.URL + "/pass")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	rsp.Body.Close()
+	if rsp.StatusCode != http.StatusOK {
+		t.Errorf("unexpected status code: %d", rsp.StatusCode)
+	}
+
+	rsp, err = http.Get(p.URL + "/abort")
+	if err == nil {
+		rsp.Body.Close()
+		t.Errorf("connection not aborted, status code: %d", rsp.StatusCode)
+	}
+}
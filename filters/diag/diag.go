@@ -4,6 +4,10 @@ Package diag provides a set of network throttling filters for diagnostic purpose
 The filters enable adding artificial latency, limiting bandwidth or chunking responses with custom chunk size
 and delay. This throttling can be applied to the proxy responses or to the outgoing backend requests. An
 additional filter, randomContent, can be used to generate response with random text of specified length.
+
+For chaos experiments, the latency can be added to only a part of the responses, and the fault and
+abortConnection filters respond with an error status, or close the client connection, with a given
+probability.
 */
 package diag
 
@@ -11,6 +15,7 @@ import (
 	"io"
 	"math/rand"
 	"net/http"
+	"strings"
 	"time"
 
 	log "github.com/sirupsen/logrus"
@@ -46,15 +51,39 @@ type random struct {
 }
 
 type throttle struct {
-	typ       throttleType
-	chunkSize int
-	delay     time.Duration
+	typ         throttleType
+	chunkSize   int
+	delay       time.Duration
+	probability float64
 }
 
 var randomChars = []byte("ABCDEFGHIJKLMNOPQRSTUVWXYZabcdefghijklmnopqrstuvwxyz0123456789")
 
-func kbps2bpms(kbps float64) float64 {
-	return kbps * 1024 / 1000
+// the throttled bodies are written at least in this interval, when
+// the bandwidth allows larger chunks than the default
+const bandwidthInterval = 10 * time.Millisecond
+
+// parseBandwidth returns the bandwidth in bytes per second. It accepts a
+// number in kbyte/sec, or a string with a unit, e.g. "1MB/s". The units are
+// binary, 1kB means 1024 bytes, the same as with the numbers.
+func parseBandwidth(v interface{}) (float64, error) {
+	switch vt := v.(type) {
+	case float64:
+		if vt <= 0 {
+			return 0, filters.ErrInvalidFilterParameters
+		}
+
+		return vt * 1024, nil
+	case string:
+		n, err := filters.ParseSize(strings.TrimSuffix(strings.TrimSpace(vt), "/s"))
+		if err != nil || n <= 0 {
+			return 0, filters.ErrInvalidFilterParameters
+		}
+
+		return n, nil
+	default:
+		return 0, filters.ErrInvalidFilterParameters
+	}
 }
 
 // NewRandom creates a filter specification whose filter instances can be used
@@ -69,17 +98,24 @@ func NewRandom() filters.Spec { return &random{} }
 // NewLatency creates a filter specification whose filter instances can be used
 // to add additional latency to responses. It expects the latency in milliseconds
 // as an argument. It always adds this value in addition to the natural latency,
-// and does not do any adjustments. Eskip example:
+// and does not do any adjustments. The optional second argument sets the
+// probability of adding the latency to a response, between 0 and 1, to inject
+// latency only into a part of the responses. Eskip example:
 //
 // 	* -> latency(120) -> "https://www.example.org";
+// 	* -> latency("100ms", 0.1) -> "https://www.example.org";
 //
 func NewLatency() filters.Spec { return &throttle{typ: latency} }
 
 // NewBandwidth creates a filter specification whose filter instances can be used
 // to maximize the bandwidth of the responses. It expects the bandwidth in
-// kbyte/sec as an argument.
+// kbyte/sec as an argument, or as a string with a unit, B, kB, MB or GB per
+// second, where the units are binary. The bandwidth is limited for each
+// response separately, and not per connection, so the responses sent on
+// the same keep-alive connection don't share the limit. Eskip examples:
 //
 // 	* -> bandwidth(30) -> "https://www.example.org";
+// 	* -> bandwidth("1MB/s") -> "https://www.example.org";
 //
 func NewBandwidth() filters.Spec { return &throttle{typ: bandwidth} }
 
@@ -208,13 +244,19 @@ func parseBandwidthArgs(args []interface{}) (int, time.Duration, error) {
 		return 0, 0, filters.ErrInvalidFilterParameters
 	}
 
-	kbps, ok := args[0].(float64)
-	if !ok || kbps <= 0 {
-		return 0, 0, filters.ErrInvalidFilterParameters
+	bps, err := parseBandwidth(args[0])
+	if err != nil {
+		return 0, 0, err
+	}
+
+	// with high bandwidths, the chunks are larger than the default, to
+	// avoid sleeping too frequently
+	chunkSize := defaultChunkSize
+	if perInterval := int(bps * bandwidthInterval.Seconds()); perInterval > chunkSize {
+		chunkSize = perInterval
 	}
 
-	bpms := kbps2bpms(kbps)
-	return defaultChunkSize, time.Duration(float64(defaultChunkSize)/bpms) * time.Millisecond, nil
+	return chunkSize, time.Duration(float64(chunkSize) / bps * float64(time.Second)), nil
 }
 
 func parseChunksArgs(args []interface{}) (int, time.Duration, error) {
@@ -238,8 +280,17 @@ func (t *throttle) CreateFilter(args []interface{}) (filters.Filter, error) {
 		err       error
 	)
 
+	probability := 1.0
 	switch t.typ {
 	case latency, backendLatency:
+		if len(args) == 2 {
+			if probability, err = parseProbability(args[1]); err != nil {
+				return nil, err
+			}
+
+			args = args[:1]
+		}
+
 		chunkSize, delay, err = parseLatencyArgs(args)
 	case bandwidth, backendBandwidth:
 		chunkSize, delay, err = parseBandwidthArgs(args)
@@ -253,7 +304,7 @@ func (t *throttle) CreateFilter(args []interface{}) (filters.Filter, error) {
 		return nil, err
 	}
 
-	return &throttle{t.typ, chunkSize, delay}, nil
+	return &throttle{t.typ, chunkSize, delay, probability}, nil
 }
 
 func (t *throttle) goThrottle(in io.ReadCloser, close bool) io.ReadCloser {
@@ -338,6 +389,10 @@ func (t *throttle) Request(ctx filters.FilterContext) {
 		return
 	}
 
+	if !happens(t.probability) {
+		return
+	}
+
 	req := ctx.Request()
 	req.Body = t.goThrottle(req.Body, false)
 }
@@ -348,6 +403,10 @@ func (t *throttle) Response(ctx filters.FilterContext) {
 		return
 	}
 
+	if !happens(t.probability) {
+		return
+	}
+
 	rsp := ctx.Response()
 	rsp.Body = t.goThrottle(rsp.Body, true)
 }
@@ -101,7 +101,7 @@ func checkMessage(expect messageExp, start time.Time, body io.Reader) error {
 	totalRead += len(b)
 
 	if expect.kbps > 0 && !checkWithTolerance(start,
-		time.Duration(float64(totalRead)/kbps2bpms(expect.kbps))*time.Millisecond) {
+		time.Duration(float64(totalRead)/(expect.kbps*1024/1000))*time.Millisecond) {
 		return fmt.Errorf("expected bandwidth failed, %v, %v", start, time.Now())
 	}
 
@@ -314,6 +314,26 @@ func TestThrottleArgs(t *testing.T) {
 		NewBandwidth,
 		[]interface{}{float64(1)},
 		false,
+	}, {
+		"bandwidth, unknown unit",
+		NewBandwidth,
+		[]interface{}{"1XB/s"},
+		true,
+	}, {
+		"bandwidth, no value",
+		NewBandwidth,
+		[]interface{}{"MB/s"},
+		true,
+	}, {
+		"bandwidth, negative value with unit",
+		NewBandwidth,
+		[]interface{}{"-1MB/s"},
+		true,
+	}, {
+		"bandwidth, with unit",
+		NewBandwidth,
+		[]interface{}{"1MB/s"},
+		false,
 	}, {
 		"backend bandwidth, zero args",
 		NewBackendBandwidth,
@@ -516,6 +536,10 @@ func TestThrottle(t *testing.T) {
 		msg:          "bandwidth",
 		filters:      []*eskip.Filter{{Name: BandwidthName, Args: []interface{}{float64(12)}}},
 		clientExpect: messageExp{kbps: 12},
+	}, {
+		msg:          "bandwidth with unit",
+		filters:      []*eskip.Filter{{Name: BandwidthName, Args: []interface{}{"12kB/s"}}},
+		clientExpect: messageExp{kbps: 12},
 	}, {
 		msg:     "very high bandwidth",
 		filters: []*eskip.Filter{{Name: BandwidthName, Args: []interface{}{float64(12000000000)}}},
@@ -653,3 +677,35 @@ func TestThrottle(t *testing.T) {
 		}()
 	}
 }
+
+func TestParseBandwidth(t *testing.T) {
+	for _, tt := range []struct {
+		arg  interface{}
+		want float64
+	}{
+		{arg: float64(30), want: 30 * 1024},
+		{arg: "512B/s", want: 512},
+		{arg: "1.5kB/s", want: 1536},
+		{arg: "1MB/s", want: 1 << 20},
+		{arg: "2 MiB/s", want: 2 << 20},
+		{arg: "1GB", want: 1 << 30},
+	} {
+		got, err := parseBandwidth(tt.arg)
+		if err != nil {
+			t.Errorf("failed to parse %v: %v", tt.arg, err)
+		} else if got != tt.want {
+			t.Errorf("unexpected bandwidth for %v: %v, want: %v", tt.arg, got, tt.want)
+		}
+	}
+}
+
+func TestHighBandwidthChunks(t *testing.T) {
+	chunkSize, delay, err := parseBandwidthArgs([]interface{}{"1MB/s"})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if chunkSize <= defaultChunkSize || delay < bandwidthInterval-time.Millisecond || delay > bandwidthInterval {
+		t.Errorf("unexpected chunks: %d bytes in %v", chunkSize, delay)
+	}
+}
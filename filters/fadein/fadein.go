@@ -0,0 +1,144 @@
+/*
+Package fadein provides the filters configuring the gradual warm-up of the
+newly discovered endpoints of the load balanced routes.
+
+The fadeIn filter sets the duration, and optionally the exponent, of the
+fade-in. During this time, the share of the traffic sent to a new endpoint
+grows from zero to its full share. With the default exponent 1, the
+traffic grows linearly, with higher exponents it grows slower at the
+beginning:
+
+	r: * -> fadeIn("3m", 1.5) -> <roundRobin, "http://10.2.0.1:8080", "http://10.2.0.2:8080">;
+
+The endpoints appearing in a route update are considered new from the
+time they were detected. The endpoints present at the startup are not
+faded in, unless their creation time is known. The endpointCreated filter
+sets the creation time of an endpoint, e.g. from the data source of the
+routes:
+
+	r: * -> fadeIn("3m") -> endpointCreated("http://10.2.0.1:8080", "2020-08-05T11:32:19Z") -> <"http://10.2.0.1:8080", "http://10.2.0.2:8080">;
+
+The filters don't do anything in the requests or the responses. They are
+processed by the load balancer, when the routes are created.
+*/
+package fadein
+
+import (
+	"net/url"
+	"time"
+
+	"github.com/zalando/skipper/filters"
+)
+
+const (
+	FadeInName          = "fadeIn"
+	EndpointCreatedName = "endpointCreated"
+
+	// DefaultExponent is the default exponent of the fade-in curve,
+	// resulting in a linear fade-in.
+	DefaultExponent = 1
+)
+
+type (
+	fadeInSpec          struct{}
+	endpointCreatedSpec struct{}
+
+	// FadeIn configures the fade-in of the new endpoints of the route.
+	// It is defined in the filters package, so that the load balancer
+	// doesn't depend on the filter implementations.
+	FadeIn = filters.FadeIn
+
+	// EndpointCreated sets the creation time of an endpoint of the route.
+	EndpointCreated = filters.EndpointCreated
+)
+
+// NewFadeIn creates the filter spec of the fadeIn filter.
+func NewFadeIn() filters.Spec { return fadeInSpec{} }
+
+func (fadeInSpec) Name() string { return FadeInName }
+
+func (fadeInSpec) CreateFilter(args []interface{}) (filters.Filter, error) {
+	if len(args) == 0 || len(args) > 2 {
+		return nil, filters.ErrInvalidFilterParameters
+	}
+
+	var f FadeIn
+	switch v := args[0].(type) {
+	case string:
+		d, err := time.ParseDuration(v)
+		if err != nil {
+			return nil, filters.ErrInvalidFilterParameters
+		}
+
+		f.Duration = d
+	case float64:
+		f.Duration = time.Duration(v) * time.Millisecond
+	default:
+		return nil, filters.ErrInvalidFilterParameters
+	}
+
+	if f.Duration <= 0 {
+		return nil, filters.ErrInvalidFilterParameters
+	}
+
+	f.Exponent = DefaultExponent
+	if len(args) == 2 {
+		exponent, ok := args[1].(float64)
+		if !ok || exponent <= 0 {
+			return nil, filters.ErrInvalidFilterParameters
+		}
+
+		f.Exponent = exponent
+	}
+
+	return f, nil
+}
+
+// NewEndpointCreated creates the filter spec of the endpointCreated filter.
+func NewEndpointCreated() filters.Spec { return endpointCreatedSpec{} }
+
+func (endpointCreatedSpec) Name() string { return EndpointCreatedName }
+
+// NormalizeEndpoint returns the scheme and the host of the endpoint URL,
+// the way the load balancer identifies the endpoints.
+func NormalizeEndpoint(e string) (string, error) {
+	u, err := url.ParseRequestURI(e)
+	if err != nil {
+		return "", err
+	}
+
+	return u.Scheme + "://" + u.Host, nil
+}
+
+func (endpointCreatedSpec) CreateFilter(args []interface{}) (filters.Filter, error) {
+	if len(args) != 2 {
+		return nil, filters.ErrInvalidFilterParameters
+	}
+
+	e, ok := args[0].(string)
+	if !ok {
+		return nil, filters.ErrInvalidFilterParameters
+	}
+
+	endpoint, err := NormalizeEndpoint(e)
+	if err != nil {
+		return nil, filters.ErrInvalidFilterParameters
+	}
+
+	var created time.Time
+	switch v := args[1].(type) {
+	case time.Time:
+		created = v
+	case string:
+		created, err = time.Parse(time.RFC3339, v)
+		if err != nil {
+			return nil, filters.ErrInvalidFilterParameters
+		}
+	case float64:
+		created = time.Unix(int64(v), 0)
+	default:
+		return nil, filters.ErrInvalidFilterParameters
+	}
+
+	return EndpointCreated{Endpoint: endpoint, Created: created}, nil
+}
@@ -0,0 +1,118 @@
+package fadein
+
+import (
+	"testing"
+	"time"
+)
+
+func TestFadeInArgs(t *testing.T) {
+	for _, tt := range []struct {
+		name    string
+		args    []interface{}
+		want    FadeIn
+		wantErr bool
+	}{{
+		name:    "no args",
+		wantErr: true,
+	}, {
+		name:    "invalid duration",
+		args:    []interface{}{"foo"},
+		wantErr: true,
+	}, {
+		name:    "negative duration",
+		args:    []interface{}{"-1m"},
+		wantErr: true,
+	}, {
+		name:    "invalid exponent",
+		args:    []interface{}{"1m", "2"},
+		wantErr: true,
+	}, {
+		name:    "zero exponent",
+		args:    []interface{}{"1m", 0.0},
+		wantErr: true,
+	}, {
+		name:    "too many args",
+		args:    []interface{}{"1m", 2.0, 3.0},
+		wantErr: true,
+	}, {
+		name: "duration",
+		args: []interface{}{"3m"},
+		want: FadeIn{Duration: 3 * time.Minute, Exponent: 1},
+	}, {
+		name: "duration in milliseconds",
+		args: []interface{}{1500.0},
+		want: FadeIn{Duration: 1500 * time.Millisecond, Exponent: 1},
+	}, {
+		name: "duration and exponent",
+		args: []interface{}{"3m", 1.5},
+		want: FadeIn{Duration: 3 * time.Minute, Exponent: 1.5},
+	}} {
+		t.Run(tt.name, func(t *testing.T) {
+			f, err := NewFadeIn().CreateFilter(tt.args)
+			if tt.wantErr {
+				if err == nil {
+					t.Error("failed to fail")
+				}
+
+				return
+			}
+
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			if f.(FadeIn) != tt.want {
+				t.Errorf("unexpected filter: %v, want: %v", f, tt.want)
+			}
+		})
+	}
+}
+
+func TestEndpointCreatedArgs(t *testing.T) {
+	created := time.Date(2020, 8, 5, 11, 32, 19, 0, time.UTC)
+	for _, tt := range []struct {
+		name    string
+		args    []interface{}
+		want    EndpointCreated
+		wantErr bool
+	}{{
+		name:    "no args",
+		wantErr: true,
+	}, {
+		name:    "invalid endpoint",
+		args:    []interface{}{"10.0.0.1:8080", "2020-08-05T11:32:19Z"},
+		wantErr: true,
+	}, {
+		name:    "invalid time",
+		args:    []interface{}{"http://10.0.0.1:8080", "yesterday"},
+		wantErr: true,
+	}, {
+		name: "RFC3339 time",
+		args: []interface{}{"http://10.0.0.1:8080/", "2020-08-05T11:32:19Z"},
+		want: EndpointCreated{Endpoint: "http://10.0.0.1:8080", Created: created},
+	}, {
+		name: "unix time",
+		args: []interface{}{"http://10.0.0.1:8080", float64(created.Unix())},
+		want: EndpointCreated{Endpoint: "http://10.0.0.1:8080", Created: created},
+	}} {
+		t.Run(tt.name, func(t *testing.T) {
+			f, err := NewEndpointCreated().CreateFilter(tt.args)
+			if tt.wantErr {
+				if err == nil {
+					t.Error("failed to fail")
+				}
+
+				return
+			}
+
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			ec := f.(EndpointCreated)
+			if ec.Endpoint != tt.want.Endpoint || !ec.Created.Equal(tt.want.Created) {
+				t.Errorf("unexpected filter: %v, want: %v", ec, tt.want)
+			}
+		})
+	}
+}
@@ -3,7 +3,10 @@ package filters
 import (
 	"errors"
 	"net/http"
+	"strconv"
+	"strings"
 	"time"
+	"unicode"
 
 	"github.com/opentracing/opentracing-go"
 )
@@ -20,6 +23,67 @@ const (
 
 	// BackendIsProxyKey is the key used in the state bag to notify proxy that the backend is also a proxy.
 	BackendIsProxyKey = "backend:isproxy"
+
+	// EgressProxyKey is the key used in the state bag to pass the URL (string) of
+	// the HTTP proxy through which the proxy sends the backend request.
+	EgressProxyKey = "backend:egressproxy"
+
+	// ResponseFlushIntervalKey is the key used in the state bag to override the interval at
+	// which the proxy flushes the response body to the client.
+	ResponseFlushIntervalKey = "response:flushinterval"
+
+	// BackendClientCertificateKey is the key used in the state bag to pass the client
+	// certificate (*tls.Certificate) that the proxy presents to the backend.
+	BackendClientCertificateKey = "backend:clientcertificate"
+
+	// BackendMaxIdleConnsPerHostKey is the key used in the state bag to set the maximum
+	// number (int) of idle connections kept per backend host for the route.
+	BackendMaxIdleConnsPerHostKey = "backend:maxidleconnsperhost"
+
+	// BackendDisableKeepAlivesKey is the key used in the state bag to disable (bool)
+	// reusing the backend connections of the route.
+	BackendDisableKeepAlivesKey = "backend:disablekeepalives"
+
+	// BackendIdleConnTimeoutKey is the key used in the state bag to set how long
+	// (time.Duration) an idle backend connection of the route is kept open.
+	BackendIdleConnTimeoutKey = "backend:idleconntimeout"
+
+	// BackendProxyProtocolKey is the key used in the state bag to send the PROXY
+	// protocol header of the given version (int) on the backend connections of the route.
+	BackendProxyProtocolKey = "backend:proxyprotocol"
+
+	// BackendTimeoutKey is the key used in the state bag to set the total timeout
+	// (time.Duration) of the backend request, including reading the response body.
+	BackendTimeoutKey = "backend:timeout"
+
+	// BackendResponseHeaderTimeoutKey is the key used in the state bag to set the
+	// timeout (time.Duration) of waiting for the response header of the backend.
+	BackendResponseHeaderTimeoutKey = "backend:responseheadertimeout"
+
+	// ReadTimeoutKey is the key used in the state bag to set the timeout
+	// (time.Duration) of reading the request body from the client.
+	ReadTimeoutKey = "request:readtimeout"
+
+	// MaxRequestBodyKey is the key used in the state bag to set the maximum size
+	// (int64) of the request body in bytes, enforced while the body is forwarded.
+	MaxRequestBodyKey = "request:maxbody"
+
+	// ServerSentEventsKey is the key used in the state bag to mark the response as a
+	// stream of Server-Sent Events. The value is the idle timeout (time.Duration) of
+	// the stream, zero means the default of the proxy.
+	ServerSentEventsKey = "response:serversentevents"
+
+	// BackendErrorResponseKey is the key used in the state bag to make the proxy turn
+	// the errors of the backend requests, e.g. when the connection is refused, into
+	// responses with the corresponding status code, so that the response filters of
+	// the route are applied to them.
+	BackendErrorResponseKey = "backend:errorresponse"
+
+	// HeaderRegexpCapturePrefix is the prefix of the keys used in the state bag to
+	// store the values (string) of the named capture groups of the HeaderRegexp
+	// conditions of the matched route, e.g. capture.version for the group
+	// (?P<version>...). They can be referenced in templates as ${state.capture.version}.
+	HeaderRegexpCapturePrefix = "capture."
 )
 
 // Context object providing state and information that is unique to a request.
@@ -149,6 +213,78 @@ type Registry map[string]Spec
 // Error used in case of invalid filter parameters.
 var ErrInvalidFilterParameters = errors.New("invalid filter parameters")
 
+// RequestBodyError can be returned by the request bodies wrapped by filters,
+// when the body is rejected while it is forwarded to the backend. The proxy
+// aborts the backend request, and responds with the status code of the error.
+type RequestBodyError struct {
+	StatusCode int
+	Err        error
+}
+
+func (e *RequestBodyError) Error() string { return e.Err.Error() }
+
+func (e *RequestBodyError) Unwrap() error { return e.Err }
+
+// FadeIn is the filter created by the fadeIn filter of the fadein
+// package. It configures the fade-in of the new endpoints of the route.
+// The filter doesn't do anything in the requests or the responses, the
+// load balancer applies it, when the routes are created.
+type FadeIn struct {
+	Duration time.Duration
+	Exponent float64
+}
+
+func (FadeIn) Request(FilterContext)  {}
+func (FadeIn) Response(FilterContext) {}
+
+// EndpointCreated is the filter created by the endpointCreated filter of
+// the fadein package. It sets the creation time of an endpoint of the
+// route, and, like FadeIn, it is applied by the load balancer.
+type EndpointCreated struct {
+	// Endpoint is the scheme and the host of the endpoint, in the
+	// same format as the load balancer endpoints of the routes.
+	Endpoint string
+	Created  time.Time
+}
+
+func (EndpointCreated) Request(FilterContext)  {}
+func (EndpointCreated) Response(FilterContext) {}
+
+// the units of the size arguments are binary, 1kB means 1024 bytes
+var sizeUnits = map[string]float64{
+	"B":   1,
+	"kB":  1 << 10,
+	"KB":  1 << 10,
+	"KiB": 1 << 10,
+	"MB":  1 << 20,
+	"MiB": 1 << 20,
+	"GB":  1 << 30,
+	"GiB": 1 << 30,
+}
+
+// ParseSize parses a filter argument of a size with a unit, e.g. "1.5MB",
+// and returns it in bytes. The units are binary, 1kB means 1024 bytes. It
+// returns ErrInvalidFilterParameters, when the size cannot be parsed.
+func ParseSize(s string) (float64, error) {
+	s = strings.TrimSpace(s)
+	i := strings.IndexFunc(s, unicode.IsLetter)
+	if i <= 0 {
+		return 0, ErrInvalidFilterParameters
+	}
+
+	unit, ok := sizeUnits[s[i:]]
+	if !ok {
+		return 0, ErrInvalidFilterParameters
+	}
+
+	n, err := strconv.ParseFloat(strings.TrimSpace(s[:i]), 64)
+	if err != nil {
+		return 0, ErrInvalidFilterParameters
+	}
+
+	return n * unit, nil
+}
+
 // Registers a filter specification.
 func (r Registry) Register(s Spec) {
 	r[s.Name()] = s
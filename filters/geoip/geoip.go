@@ -0,0 +1,97 @@
+/*
+Package geoip provides the setGeoHeaders filter, that passes the country
+and the autonomous system of the client to the backend in request
+headers, looked up in MaxMind databases, like the free GeoLite2-Country
+and GeoLite2-ASN databases.
+
+The databases are set with the -geoip-country-database and the
+-geoip-asn-database flags. The client address is resolved the same way as
+by the ClientIP predicate.
+
+The filter sets the following request headers, when the value is known:
+
+	X-Geo-Country    the ISO 3166-1 alpha-2 code of the country
+	X-Geo-Asn        the number of the autonomous system
+	X-Geo-Asn-Org    the organization of the autonomous system
+
+The headers received from the client with the same names are removed, so
+that the backends can rely on them.
+
+Eskip example:
+
+	r: * -> setGeoHeaders() -> "https://www.example.org";
+*/
+package geoip
+
+import (
+	"errors"
+	"strconv"
+
+	"github.com/zalando/skipper/filters"
+	sgeoip "github.com/zalando/skipper/geoip"
+)
+
+const (
+	Name = "setGeoHeaders"
+
+	CountryHeader = "X-Geo-Country"
+	ASNHeader     = "X-Geo-Asn"
+	ASNOrgHeader  = "X-Geo-Asn-Org"
+)
+
+var errNoDatabase = errors.New("no GeoIP database configured")
+
+type (
+	spec struct {
+		geoip *sgeoip.GeoIP
+	}
+
+	filter struct {
+		geoip *sgeoip.GeoIP
+	}
+)
+
+// NewSetGeoHeaders creates the filter spec of the setGeoHeaders filter,
+// looking up the client addresses with the provided GeoIP instance.
+func NewSetGeoHeaders(g *sgeoip.GeoIP) filters.Spec {
+	return &spec{geoip: g}
+}
+
+func (*spec) Name() string { return Name }
+
+func (s *spec) CreateFilter(args []interface{}) (filters.Filter, error) {
+	if s.geoip == nil || !s.geoip.HasCountry() && !s.geoip.HasASN() {
+		return nil, errNoDatabase
+	}
+
+	if len(args) != 0 {
+		return nil, filters.ErrInvalidFilterParameters
+	}
+
+	return &filter{geoip: s.geoip}, nil
+}
+
+func (f *filter) Request(ctx filters.FilterContext) {
+	r := ctx.Request()
+	r.Header.Del(CountryHeader)
+	r.Header.Del(ASNHeader)
+	r.Header.Del(ASNOrgHeader)
+
+	ip := f.geoip.ClientIP(r)
+	if ip == nil {
+		return
+	}
+
+	if c := f.geoip.Country(ip); c != "" {
+		r.Header.Set(CountryHeader, c)
+	}
+
+	if asn, ok := f.geoip.ASN(ip); ok {
+		r.Header.Set(ASNHeader, strconv.FormatUint(asn.Number, 10))
+		if asn.Organization != "" {
+			r.Header.Set(ASNOrgHeader, asn.Organization)
+		}
+	}
+}
+
+func (*filter) Response(filters.FilterContext) {}
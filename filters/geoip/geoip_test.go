@@ -0,0 +1,91 @@
+package geoip
+
+import (
+	"io/ioutil"
+	"net/http"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/zalando/skipper/filters/filtertest"
+	sgeoip "github.com/zalando/skipper/geoip"
+	"github.com/zalando/skipper/geoip/geoiptest"
+)
+
+func TestSetGeoHeaders(t *testing.T) {
+	dir, err := ioutil.TempDir("", "geoip")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	defer os.RemoveAll(dir)
+
+	o := sgeoip.Options{
+		CountryDatabase: filepath.Join(dir, "country.mmdb"),
+		ASNDatabase:     filepath.Join(dir, "asn.mmdb"),
+	}
+
+	if err := geoiptest.WriteFile(o.CountryDatabase, "GeoLite2-Country", 6, map[string]interface{}{
+		"1.2.3.0/24": map[string]interface{}{"country": map[string]interface{}{"iso_code": "DE"}},
+		"5.6.7.0/24": map[string]interface{}{"country": map[string]interface{}{"iso_code": "US"}},
+	}); err != nil {
+		t.Fatal(err)
+	}
+
+	if err := geoiptest.WriteFile(o.ASNDatabase, "GeoLite2-ASN", 6, map[string]interface{}{
+		"1.2.3.0/24": map[string]interface{}{
+			"autonomous_system_number":       uint32(64512),
+			"autonomous_system_organization": "Example",
+		},
+	}); err != nil {
+		t.Fatal(err)
+	}
+
+	g, err := sgeoip.New(o)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	defer g.Close()
+
+	if _, err := NewSetGeoHeaders(g).CreateFilter([]interface{}{"foo"}); err == nil {
+		t.Error("failed to fail with arguments")
+	}
+
+	if _, err := NewSetGeoHeaders(nil).CreateFilter(nil); err == nil {
+		t.Error("failed to fail without database")
+	}
+
+	f, err := NewSetGeoHeaders(g).CreateFilter(nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	for _, ti := range []struct {
+		remoteAddr string
+		expected   http.Header
+	}{{
+		"1.2.3.4:1234",
+		http.Header{CountryHeader: []string{"DE"}, ASNHeader: []string{"64512"}, ASNOrgHeader: []string{"Example"}},
+	}, {
+		"5.6.7.8:1234",
+		http.Header{CountryHeader: []string{"US"}},
+	}, {
+		"9.9.9.9:1234",
+		http.Header{},
+	}} {
+		t.Run(ti.remoteAddr, func(t *testing.T) {
+			r := &http.Request{
+				RemoteAddr: ti.remoteAddr,
+				Header:     http.Header{CountryHeader: []string{"XX"}, ASNOrgHeader: []string{"spoofed"}},
+			}
+
+			f.Request(&filtertest.Context{FRequest: r})
+			for _, h := range []string{CountryHeader, ASNHeader, ASNOrgHeader} {
+				if r.Header.Get(h) != ti.expected.Get(h) {
+					t.Errorf("invalid %s header, expected: %s, got: %s", h, ti.expected.Get(h), r.Header.Get(h))
+				}
+			}
+		})
+	}
+}
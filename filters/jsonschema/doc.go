@@ -0,0 +1,38 @@
+/*
+Package jsonschema provides the validateJsonSchema filter, that validates
+the JSON request bodies against a JSON Schema, and rejects the invalid
+requests before they reach the backend.
+
+The filter expects the path of a file containing the schema:
+
+	Path("/orders") && Method("POST") -> validateJsonSchema("/etc/skipper/schemas/order.json") -> "https://orders.example.org";
+
+The schema file is loaded when the route is created, and a route with a
+missing or invalid schema file is rejected. The file is checked for
+changes at most every 10 seconds, and when it changed, the updated schema
+is used for the following requests. When the updated file cannot be
+loaded, the error is logged, and the previous schema remains in use.
+
+Requests without a body are passed on. Requests whose body is not JSON,
+based on the Content-Type header, are rejected with 415 Unsupported Media
+Type, and bodies larger than 2MiB with 413 Payload Too Large. When the
+body is not valid JSON, or it doesn't match the schema, the request is
+rejected with 400 Bad Request, and a response body listing the validation
+errors:
+
+	{
+	  "title": "Bad Request",
+	  "status": 400,
+	  "detail": "request body doesn't match the schema",
+	  "errors": [{"path": "$.items[0].quantity", "message": "expected integer, got string"}]
+	}
+
+The validator implements a subset of JSON Schema draft 7, with the
+following keywords: type, enum, const, properties, required,
+additionalProperties, minProperties, maxProperties, items, minItems,
+maxItems, uniqueItems, minLength, maxLength, pattern, minimum, maximum,
+exclusiveMinimum, exclusiveMaximum, multipleOf, allOf, anyOf, oneOf, not
+and $ref. The references must point into the same document, e.g.
+#/definitions/item. Other keywords, e.g. format, are ignored.
+*/
+package jsonschema
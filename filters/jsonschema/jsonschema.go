@@ -0,0 +1,188 @@
+package jsonschema
+
+import (
+	"bytes"
+	"encoding/json"
+	"io"
+	"io/ioutil"
+	"mime"
+	"net/http"
+	"strings"
+	"sync"
+	"time"
+
+	log "github.com/sirupsen/logrus"
+	"github.com/zalando/skipper/filewatch"
+	"github.com/zalando/skipper/filters"
+)
+
+const (
+	// Name of the filter, it can be referenced in eskip by this name.
+	Name = "validateJsonSchema"
+
+	defaultCheckInterval = 10 * time.Second
+	maxBodySize          = 2 << 20
+)
+
+type spec struct {
+	checkInterval time.Duration
+
+	mu    sync.Mutex
+	files map[string]*filewatch.File
+}
+
+type filter struct {
+	file *filewatch.File
+}
+
+type errorResponse struct {
+	Title  string            `json:"title"`
+	Status int               `json:"status"`
+	Detail string            `json:"detail"`
+	Errors []ValidationError `json:"errors,omitempty"`
+}
+
+// New creates a filter specification for the validateJsonSchema filter.
+func New() filters.Spec {
+	return newSpec(defaultCheckInterval)
+}
+
+func newSpec(checkInterval time.Duration) *spec {
+	return &spec{
+		checkInterval: checkInterval,
+		files:         make(map[string]*filewatch.File),
+	}
+}
+
+func (s *spec) Name() string { return Name }
+
+func (s *spec) CreateFilter(args []interface{}) (filters.Filter, error) {
+	if len(args) != 1 {
+		return nil, filters.ErrInvalidFilterParameters
+	}
+
+	path, ok := args[0].(string)
+	if !ok || path == "" {
+		return nil, filters.ErrInvalidFilterParameters
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	// the files are shared between the routes, so that they are checked
+	// only once per interval
+	f, ok := s.files[path]
+	if !ok {
+		f = filewatch.New(path, s.checkInterval, parseSchemaFile)
+		if _, err := getSchema(f); err != nil {
+			return nil, err
+		}
+
+		s.files[path] = f
+	} else if _, err := getSchema(f); err != nil {
+		return nil, err
+	}
+
+	return &filter{file: f}, nil
+}
+
+func parseSchemaFile(data []byte) (interface{}, error) {
+	return parseSchema(data)
+}
+
+// getSchema returns the current schema of the file, and reloads it when
+// it changed since the last check. When reloading fails, the previous
+// schema is used.
+func getSchema(f *filewatch.File) (*schema, error) {
+	s, err := f.Get()
+	if err != nil {
+		if s == nil {
+			return nil, err
+		}
+
+		log.Errorf("Failed to reload JSON schema from %s: %v", f.Path(), err)
+	}
+
+	return s.(*schema), nil
+}
+
+func isJSON(contentType string) bool {
+	mediaType, _, err := mime.ParseMediaType(contentType)
+	if err != nil {
+		return false
+	}
+
+	return mediaType == "application/json" || strings.HasSuffix(mediaType, "+json")
+}
+
+func serveError(ctx filters.FilterContext, status int, detail string, errs []ValidationError) {
+	b, _ := json.Marshal(errorResponse{
+		Title:  http.StatusText(status),
+		Status: status,
+		Detail: detail,
+		Errors: errs,
+	})
+
+	ctx.Serve(&http.Response{
+		StatusCode:    status,
+		Header:        http.Header{"Content-Type": []string{"application/problem+json"}},
+		ContentLength: int64(len(b)),
+		Body:          ioutil.NopCloser(bytes.NewReader(b)),
+	})
+}
+
+func (f *filter) Request(ctx filters.FilterContext) {
+	req := ctx.Request()
+	if req.Body == nil || req.Body == http.NoBody || req.ContentLength == 0 {
+		return
+	}
+
+	if !isJSON(req.Header.Get("Content-Type")) {
+		serveError(ctx, http.StatusUnsupportedMediaType, "request body must be JSON", nil)
+		return
+	}
+
+	body, err := ioutil.ReadAll(io.LimitReader(req.Body, maxBodySize+1))
+	req.Body.Close()
+	if err != nil {
+		log.Errorf("Failed to read request body: %v", err)
+		serveError(ctx, http.StatusBadRequest, "failed to read request body", nil)
+		return
+	}
+
+	if len(body) > maxBodySize {
+		serveError(ctx, http.StatusRequestEntityTooLarge, "request body too large", nil)
+		return
+	}
+
+	req.Body = ioutil.NopCloser(bytes.NewReader(body))
+	req.ContentLength = int64(len(body))
+	req.GetBody = func() (io.ReadCloser, error) {
+		return ioutil.NopCloser(bytes.NewReader(body)), nil
+	}
+
+	if len(body) == 0 {
+		return
+	}
+
+	var doc interface{}
+	dec := json.NewDecoder(bytes.NewReader(body))
+	dec.UseNumber()
+	if err := dec.Decode(&doc); err != nil || dec.More() {
+		serveError(ctx, http.StatusBadRequest, "request body is not valid JSON", nil)
+		return
+	}
+
+	s, err := getSchema(f.file)
+	if err != nil {
+		log.Errorf("Failed to load JSON schema: %v", err)
+		serveError(ctx, http.StatusInternalServerError, "failed to load the schema", nil)
+		return
+	}
+
+	if errs := s.validate(doc); len(errs) > 0 {
+		serveError(ctx, http.StatusBadRequest, "request body doesn't match the schema", errs)
+	}
+}
+
+func (f *filter) Response(filters.FilterContext) {}
@@ -0,0 +1,232 @@
+package jsonschema
+
+import (
+	"bytes"
+	"encoding/json"
+	"io/ioutil"
+	"net/http"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/zalando/skipper/filters/filtertest"
+)
+
+const testFilterSchema = `{
+	"type": "object",
+	"required": ["name"],
+	"properties": {"name": {"type": "string"}}
+}`
+
+func writeSchema(t *testing.T, path, schema string, modTime time.Time) {
+	if err := ioutil.WriteFile(path, []byte(schema), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	if err := os.Chtimes(path, modTime, modTime); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func tempSchema(t *testing.T, schema string) (string, func()) {
+	dir, err := ioutil.TempDir("", "jsonschema")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	path := filepath.Join(dir, "schema.json")
+	writeSchema(t, path, schema, time.Now())
+	return path, func() { os.RemoveAll(dir) }
+}
+
+func TestCreateFilter(t *testing.T) {
+	path, cleanup := tempSchema(t, testFilterSchema)
+	defer cleanup()
+
+	invalid, cleanupInvalid := tempSchema(t, `{"type": `)
+	defer cleanupInvalid()
+
+	for _, tt := range []struct {
+		name string
+		args []interface{}
+		fail bool
+	}{{
+		name: "no args",
+		fail: true,
+	}, {
+		name: "not a string",
+		args: []interface{}{42},
+		fail: true,
+	}, {
+		name: "too many args",
+		args: []interface{}{path, path},
+		fail: true,
+	}, {
+		name: "missing file",
+		args: []interface{}{path + ".missing"},
+		fail: true,
+	}, {
+		name: "invalid schema",
+		args: []interface{}{invalid},
+		fail: true,
+	}, {
+		name: "valid",
+		args: []interface{}{path},
+	}} {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := New().CreateFilter(tt.args)
+			if tt.fail && err == nil {
+				t.Error("failed to fail")
+			} else if !tt.fail && err != nil {
+				t.Error(err)
+			}
+		})
+	}
+}
+
+func TestValidateRequest(t *testing.T) {
+	path, cleanup := tempSchema(t, testFilterSchema)
+	defer cleanup()
+
+	f, err := New().CreateFilter([]interface{}{path})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	for _, tt := range []struct {
+		name           string
+		contentType    string
+		body           string
+		expectedStatus int
+		expectedErrors []ValidationError
+	}{{
+		name: "no body",
+	}, {
+		name:        "valid",
+		contentType: "application/json; charset=utf-8",
+		body:        `{"name": "foo"}`,
+	}, {
+		name:        "valid with suffix",
+		contentType: "application/vnd.order+json",
+		body:        `{"name": "foo"}`,
+	}, {
+		name:           "not JSON",
+		contentType:    "text/plain",
+		body:           `{"name": "foo"}`,
+		expectedStatus: http.StatusUnsupportedMediaType,
+	}, {
+		name:           "invalid JSON",
+		contentType:    "application/json",
+		body:           `{"name": `,
+		expectedStatus: http.StatusBadRequest,
+	}, {
+		name:           "too large",
+		contentType:    "application/json",
+		body:           `"` + string(bytes.Repeat([]byte("x"), maxBodySize)) + `"`,
+		expectedStatus: http.StatusRequestEntityTooLarge,
+	}, {
+		name:           "invalid",
+		contentType:    "application/json",
+		body:           `{"name": 42}`,
+		expectedStatus: http.StatusBadRequest,
+		expectedErrors: []ValidationError{{Path: "$.name", Message: "expected string, got integer"}},
+	}} {
+		t.Run(tt.name, func(t *testing.T) {
+			req, err := http.NewRequest("POST", "https://www.example.org", bytes.NewBufferString(tt.body))
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			if tt.body == "" {
+				req.Body = http.NoBody
+			}
+
+			req.Header.Set("Content-Type", tt.contentType)
+			ctx := &filtertest.Context{FRequest: req}
+			f.Request(ctx)
+
+			if tt.expectedStatus == 0 {
+				if ctx.FServed {
+					t.Fatalf("request rejected: %d", ctx.FResponse.StatusCode)
+				}
+
+				b, err := ioutil.ReadAll(ctx.FRequest.Body)
+				if err != nil {
+					t.Fatal(err)
+				}
+
+				if string(b) != tt.body {
+					t.Errorf("body not preserved, expected: %s, got: %s", tt.body, b)
+				}
+
+				return
+			}
+
+			if !ctx.FServed {
+				t.Fatal("request not rejected")
+			}
+
+			rsp := ctx.FResponse
+			if rsp.StatusCode != tt.expectedStatus {
+				t.Errorf("unexpected status, expected: %d, got: %d", tt.expectedStatus, rsp.StatusCode)
+			}
+
+			if ct := rsp.Header.Get("Content-Type"); ct != "application/problem+json" {
+				t.Errorf("unexpected content type: %s", ct)
+			}
+
+			var body errorResponse
+			if err := json.NewDecoder(rsp.Body).Decode(&body); err != nil {
+				t.Fatal(err)
+			}
+
+			if body.Status != tt.expectedStatus || len(body.Errors) != len(tt.expectedErrors) {
+				t.Fatalf("unexpected response body: %v", body)
+			}
+
+			for i := range tt.expectedErrors {
+				if body.Errors[i] != tt.expectedErrors[i] {
+					t.Errorf("unexpected error, expected: %v, got: %v", tt.expectedErrors[i], body.Errors[i])
+				}
+			}
+		})
+	}
+}
+
+func TestReloadSchema(t *testing.T) {
+	path, cleanup := tempSchema(t, testFilterSchema)
+	defer cleanup()
+
+	f, err := newSpec(0).CreateFilter([]interface{}{path})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	valid := func(body string) bool {
+		req, err := http.NewRequest("POST", "https://www.example.org", bytes.NewBufferString(body))
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		req.Header.Set("Content-Type", "application/json")
+		ctx := &filtertest.Context{FRequest: req}
+		f.Request(ctx)
+		return !ctx.FServed
+	}
+
+	if !valid(`{"name": "foo"}`) || valid(`{"id": 1}`) {
+		t.Fatal("unexpected validation with the initial schema")
+	}
+
+	writeSchema(t, path, `{"required": ["id"]}`, time.Now().Add(time.Minute))
+	if valid(`{"name": "foo"}`) || !valid(`{"id": 1}`) {
+		t.Fatal("schema not reloaded")
+	}
+
+	// the previous schema is kept when the update is invalid
+	writeSchema(t, path, `{"required": `, time.Now().Add(2*time.Minute))
+	if valid(`{"name": "foo"}`) || !valid(`{"id": 1}`) {
+		t.Fatal("invalid schema applied")
+	}
+}
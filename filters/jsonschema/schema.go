@@ -0,0 +1,517 @@
+package jsonschema
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"math"
+	"reflect"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+	"unicode/utf8"
+)
+
+// maximum depth of resolving references, to detect circular references
+// without actual content
+const maxRefDepth = 64
+
+var errInvalidSchema = errors.New("invalid JSON schema")
+
+// ValidationError describes a value that doesn't match the schema. The
+// path points to the value in a JSONPath like syntax, e.g. $.items[0].id.
+type ValidationError struct {
+	Path    string `json:"path"`
+	Message string `json:"message"`
+}
+
+// schema validates JSON documents against a subset of JSON Schema, see
+// the package documentation for the supported keywords.
+type schema struct {
+	root     interface{}
+	patterns map[string]*regexp.Regexp
+}
+
+func parseSchema(data []byte) (*schema, error) {
+	var root interface{}
+	if err := json.Unmarshal(data, &root); err != nil {
+		return nil, err
+	}
+
+	s := &schema{root: root, patterns: make(map[string]*regexp.Regexp)}
+	if err := s.compile(root); err != nil {
+		return nil, err
+	}
+
+	return s, nil
+}
+
+// compile verifies the structure of the schema, and compiles the regular
+// expressions
+func (s *schema) compile(node interface{}) error {
+	switch n := node.(type) {
+	case bool:
+		return nil
+	case map[string]interface{}:
+		for key, value := range n {
+			switch key {
+			case "pattern":
+				p, ok := value.(string)
+				if !ok {
+					return errInvalidSchema
+				}
+
+				rx, err := regexp.Compile(p)
+				if err != nil {
+					return fmt.Errorf("%v: %v", errInvalidSchema, err)
+				}
+
+				s.patterns[p] = rx
+			case "$ref":
+				ref, ok := value.(string)
+				if !ok {
+					return errInvalidSchema
+				}
+
+				if _, err := s.resolve(ref); err != nil {
+					return err
+				}
+			case "additionalProperties", "not":
+				if err := s.compile(value); err != nil {
+					return err
+				}
+			case "items":
+				if a, ok := value.([]interface{}); ok {
+					for _, ai := range a {
+						if err := s.compile(ai); err != nil {
+							return err
+						}
+					}
+				} else if err := s.compile(value); err != nil {
+					return err
+				}
+			case "properties", "definitions", "$defs":
+				m, ok := value.(map[string]interface{})
+				if !ok {
+					return errInvalidSchema
+				}
+
+				for _, mi := range m {
+					if err := s.compile(mi); err != nil {
+						return err
+					}
+				}
+			case "allOf", "anyOf", "oneOf":
+				a, ok := value.([]interface{})
+				if !ok || len(a) == 0 {
+					return errInvalidSchema
+				}
+
+				for _, ai := range a {
+					if err := s.compile(ai); err != nil {
+						return err
+					}
+				}
+			}
+		}
+
+		return nil
+	default:
+		return errInvalidSchema
+	}
+}
+
+// resolve finds the schema referenced by a local JSON pointer, e.g.
+// #/definitions/user
+func (s *schema) resolve(ref string) (interface{}, error) {
+	if ref == "#" {
+		return s.root, nil
+	}
+
+	if !strings.HasPrefix(ref, "#/") {
+		return nil, fmt.Errorf("%v: only local references are supported: %s", errInvalidSchema, ref)
+	}
+
+	node := s.root
+	for _, token := range strings.Split(ref[2:], "/") {
+		token = strings.Replace(strings.Replace(token, "~1", "/", -1), "~0", "~", -1)
+		switch n := node.(type) {
+		case map[string]interface{}:
+			var ok bool
+			if node, ok = n[token]; !ok {
+				return nil, fmt.Errorf("%v: reference not found: %s", errInvalidSchema, ref)
+			}
+		case []interface{}:
+			i, err := strconv.Atoi(token)
+			if err != nil || i < 0 || i >= len(n) {
+				return nil, fmt.Errorf("%v: reference not found: %s", errInvalidSchema, ref)
+			}
+
+			node = n[i]
+		default:
+			return nil, fmt.Errorf("%v: reference not found: %s", errInvalidSchema, ref)
+		}
+	}
+
+	return node, nil
+}
+
+// validate returns the list of the validation errors of the document,
+// which was decoded with json.Decoder.UseNumber.
+func (s *schema) validate(doc interface{}) []ValidationError {
+	var errs []ValidationError
+	s.validateNode(s.root, doc, "$", 0, &errs)
+	return errs
+}
+
+func typeOf(v interface{}) string {
+	switch vv := v.(type) {
+	case nil:
+		return "null"
+	case bool:
+		return "boolean"
+	case map[string]interface{}:
+		return "object"
+	case []interface{}:
+		return "array"
+	case string:
+		return "string"
+	case json.Number:
+		if _, err := vv.Int64(); err == nil {
+			return "integer"
+		}
+
+		if f, err := vv.Float64(); err == nil && f == math.Trunc(f) {
+			return "integer"
+		}
+
+		return "number"
+	default:
+		return "unknown"
+	}
+}
+
+func hasType(v interface{}, t string) bool {
+	vt := typeOf(v)
+	return vt == t || t == "number" && vt == "integer"
+}
+
+// normalize converts the numbers to float64, so that the values decoded
+// from the document and the schema can be compared
+func normalize(v interface{}) interface{} {
+	switch vv := v.(type) {
+	case json.Number:
+		f, _ := vv.Float64()
+		return f
+	case map[string]interface{}:
+		m := make(map[string]interface{}, len(vv))
+		for k, mi := range vv {
+			m[k] = normalize(mi)
+		}
+
+		return m
+	case []interface{}:
+		a := make([]interface{}, len(vv))
+		for i, ai := range vv {
+			a[i] = normalize(ai)
+		}
+
+		return a
+	default:
+		return v
+	}
+}
+
+func number(v interface{}) (float64, bool) {
+	switch vv := v.(type) {
+	case float64:
+		return vv, true
+	case json.Number:
+		f, err := vv.Float64()
+		return f, err == nil
+	default:
+		return 0, false
+	}
+}
+
+func (s *schema) validateNode(node, v interface{}, path string, depth int, errs *[]ValidationError) {
+	fail := func(format string, args ...interface{}) {
+		*errs = append(*errs, ValidationError{Path: path, Message: fmt.Sprintf(format, args...)})
+	}
+
+	switch n := node.(type) {
+	case bool:
+		if !n {
+			fail("value not allowed")
+		}
+
+		return
+	case map[string]interface{}:
+		if ref, ok := n["$ref"].(string); ok {
+			if depth >= maxRefDepth {
+				fail("too many nested references")
+				return
+			}
+
+			target, err := s.resolve(ref)
+			if err != nil {
+				fail("%v", err)
+				return
+			}
+
+			s.validateNode(target, v, path, depth+1, errs)
+		}
+
+		s.validateKeywords(n, v, path, depth, fail, errs)
+	}
+}
+
+func (s *schema) validateKeywords(n map[string]interface{}, v interface{}, path string, depth int, fail func(string, ...interface{}), errs *[]ValidationError) {
+	if t, ok := n["type"]; ok {
+		var types []string
+		switch tt := t.(type) {
+		case string:
+			types = []string{tt}
+		case []interface{}:
+			for _, ti := range tt {
+				if ts, ok := ti.(string); ok {
+					types = append(types, ts)
+				}
+			}
+		}
+
+		var match bool
+		for _, ti := range types {
+			if hasType(v, ti) {
+				match = true
+				break
+			}
+		}
+
+		if !match {
+			fail("expected %s, got %s", strings.Join(types, " or "), typeOf(v))
+			return
+		}
+	}
+
+	if e, ok := n["enum"].([]interface{}); ok {
+		nv := normalize(v)
+		var match bool
+		for _, ei := range e {
+			if reflect.DeepEqual(nv, ei) {
+				match = true
+				break
+			}
+		}
+
+		if !match {
+			fail("value is not one of the allowed values")
+		}
+	}
+
+	if c, ok := n["const"]; ok && !reflect.DeepEqual(normalize(v), c) {
+		fail("value is not the allowed constant")
+	}
+
+	switch vv := v.(type) {
+	case string:
+		s.validateString(n, vv, fail)
+	case json.Number:
+		validateNumber(n, vv, fail)
+	case map[string]interface{}:
+		s.validateObject(n, vv, path, depth, fail, errs)
+	case []interface{}:
+		s.validateArray(n, vv, path, depth, fail, errs)
+	}
+
+	if a, ok := n["allOf"].([]interface{}); ok {
+		for _, ai := range a {
+			s.validateNode(ai, v, path, depth, errs)
+		}
+	}
+
+	if a, ok := n["anyOf"].([]interface{}); ok {
+		var match bool
+		for _, ai := range a {
+			if s.matches(ai, v, path, depth) {
+				match = true
+				break
+			}
+		}
+
+		if !match {
+			fail("value doesn't match any of the schemas")
+		}
+	}
+
+	if a, ok := n["oneOf"].([]interface{}); ok {
+		var count int
+		for _, ai := range a {
+			if s.matches(ai, v, path, depth) {
+				count++
+			}
+		}
+
+		if count != 1 {
+			fail("value matches %d of the schemas, instead of exactly one", count)
+		}
+	}
+
+	if not, ok := n["not"]; ok && s.matches(not, v, path, depth) {
+		fail("value matches a disallowed schema")
+	}
+}
+
+func (s *schema) matches(node, v interface{}, path string, depth int) bool {
+	var errs []ValidationError
+	s.validateNode(node, v, path, depth, &errs)
+	return len(errs) == 0
+}
+
+func (s *schema) validateString(n map[string]interface{}, v string, fail func(string, ...interface{})) {
+	l := utf8.RuneCountInString(v)
+	if min, ok := number(n["minLength"]); ok && float64(l) < min {
+		fail("string shorter than %v", min)
+	}
+
+	if max, ok := number(n["maxLength"]); ok && float64(l) > max {
+		fail("string longer than %v", max)
+	}
+
+	if p, ok := n["pattern"].(string); ok && !s.patterns[p].MatchString(v) {
+		fail("string doesn't match the pattern: %s", p)
+	}
+}
+
+func validateNumber(n map[string]interface{}, v json.Number, fail func(string, ...interface{})) {
+	f, err := v.Float64()
+	if err != nil {
+		fail("invalid number")
+		return
+	}
+
+	if min, ok := number(n["minimum"]); ok {
+		if exclusive, _ := n["exclusiveMinimum"].(bool); exclusive && f <= min {
+			fail("value must be greater than %v", min)
+		} else if f < min {
+			fail("value must be greater than or equal to %v", min)
+		}
+	}
+
+	if min, ok := number(n["exclusiveMinimum"]); ok && f <= min {
+		fail("value must be greater than %v", min)
+	}
+
+	if max, ok := number(n["maximum"]); ok {
+		if exclusive, _ := n["exclusiveMaximum"].(bool); exclusive && f >= max {
+			fail("value must be less than %v", max)
+		} else if f > max {
+			fail("value must be less than or equal to %v", max)
+		}
+	}
+
+	if max, ok := number(n["exclusiveMaximum"]); ok && f >= max {
+		fail("value must be less than %v", max)
+	}
+
+	if m, ok := number(n["multipleOf"]); ok && m > 0 {
+		if q := f / m; math.Abs(q-math.Round(q)) > 1e-9 {
+			fail("value must be a multiple of %v", m)
+		}
+	}
+}
+
+func (s *schema) validateObject(n map[string]interface{}, v map[string]interface{}, path string, depth int, fail func(string, ...interface{}), errs *[]ValidationError) {
+	if required, ok := n["required"].([]interface{}); ok {
+		for _, r := range required {
+			if name, ok := r.(string); ok {
+				if _, ok := v[name]; !ok {
+					fail("missing required property: %s", name)
+				}
+			}
+		}
+	}
+
+	if min, ok := number(n["minProperties"]); ok && float64(len(v)) < min {
+		fail("object has fewer than %v properties", min)
+	}
+
+	if max, ok := number(n["maxProperties"]); ok && float64(len(v)) > max {
+		fail("object has more than %v properties", max)
+	}
+
+	properties, _ := n["properties"].(map[string]interface{})
+	additional, hasAdditional := n["additionalProperties"]
+	for _, name := range sortedKeys(v) {
+		propertyPath := path + "." + name
+		if p, ok := properties[name]; ok {
+			s.validateNode(p, v[name], propertyPath, depth, errs)
+			continue
+		}
+
+		if !hasAdditional {
+			continue
+		}
+
+		if allowed, ok := additional.(bool); ok && !allowed {
+			fail("additional property not allowed: %s", name)
+			continue
+		}
+
+		s.validateNode(additional, v[name], propertyPath, depth, errs)
+	}
+}
+
+func (s *schema) validateArray(n map[string]interface{}, v []interface{}, path string, depth int, fail func(string, ...interface{}), errs *[]ValidationError) {
+	if min, ok := number(n["minItems"]); ok && float64(len(v)) < min {
+		fail("array has fewer than %v items", min)
+	}
+
+	if max, ok := number(n["maxItems"]); ok && float64(len(v)) > max {
+		fail("array has more than %v items", max)
+	}
+
+	if unique, _ := n["uniqueItems"].(bool); unique {
+		for i := range v {
+			for j := i + 1; j < len(v); j++ {
+				if reflect.DeepEqual(normalize(v[i]), normalize(v[j])) {
+					fail("array items are not unique")
+					i = len(v)
+					break
+				}
+			}
+		}
+	}
+
+	items, ok := n["items"]
+	if !ok {
+		return
+	}
+
+	if tuple, ok := items.([]interface{}); ok {
+		for i, ti := range tuple {
+			if i < len(v) {
+				s.validateNode(ti, v[i], fmt.Sprintf("%s[%d]", path, i), depth, errs)
+			}
+		}
+
+		return
+	}
+
+	for i, vi := range v {
+		s.validateNode(items, vi, fmt.Sprintf("%s[%d]", path, i), depth, errs)
+	}
+}
+
+// sortedKeys returns the keys of an object in a stable order, to report
+// the validation errors deterministically
+func sortedKeys(m map[string]interface{}) []string {
+	keys := make([]string, 0, len(m))
+	for k := range m {
+		keys = append(keys, k)
+	}
+
+	sort.Strings(keys)
+	return keys
+}
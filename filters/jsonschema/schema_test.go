@@ -0,0 +1,220 @@
+package jsonschema
+
+import (
+	"bytes"
+	"encoding/json"
+	"reflect"
+	"testing"
+)
+
+func decode(t *testing.T, s string) interface{} {
+	var doc interface{}
+	dec := json.NewDecoder(bytes.NewBufferString(s))
+	dec.UseNumber()
+	if err := dec.Decode(&doc); err != nil {
+		t.Fatal(err)
+	}
+
+	return doc
+}
+
+func TestParseSchema(t *testing.T) {
+	for _, tt := range []struct {
+		name   string
+		schema string
+		fail   bool
+	}{{
+		name:   "empty",
+		schema: `{}`,
+	}, {
+		name:   "boolean",
+		schema: `true`,
+	}, {
+		name:   "invalid JSON",
+		schema: `{"type":`,
+		fail:   true,
+	}, {
+		name:   "not an object",
+		schema: `"string"`,
+		fail:   true,
+	}, {
+		name:   "invalid pattern",
+		schema: `{"properties": {"a": {"pattern": "("}}}`,
+		fail:   true,
+	}, {
+		name:   "reference not found",
+		schema: `{"items": {"$ref": "#/definitions/foo"}}`,
+		fail:   true,
+	}, {
+		name:   "remote reference",
+		schema: `{"$ref": "https://schemas.example.org/foo.json"}`,
+		fail:   true,
+	}, {
+		name:   "empty anyOf",
+		schema: `{"anyOf": []}`,
+		fail:   true,
+	}} {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := parseSchema([]byte(tt.schema))
+			if tt.fail && err == nil {
+				t.Error("failed to fail")
+			} else if !tt.fail && err != nil {
+				t.Error(err)
+			}
+		})
+	}
+}
+
+const testSchema = `{
+	"type": "object",
+	"required": ["id", "items"],
+	"additionalProperties": false,
+	"properties": {
+		"id": {"type": "string", "pattern": "^[a-z0-9-]+$", "maxLength": 12},
+		"status": {"enum": ["new", "paid"]},
+		"note": {"type": ["string", "null"]},
+		"version": {"const": 1},
+		"items": {
+			"type": "array",
+			"minItems": 1,
+			"uniqueItems": true,
+			"items": {"$ref": "#/definitions/item"}
+		},
+		"discount": {
+			"oneOf": [
+				{"type": "integer", "minimum": 0, "exclusiveMaximum": 100},
+				{"type": "string", "minLength": 2}
+			]
+		},
+		"tags": {"not": {"type": "array", "maxItems": 0}}
+	},
+	"definitions": {
+		"item": {
+			"type": "object",
+			"required": ["sku", "quantity"],
+			"properties": {
+				"sku": {"type": "string"},
+				"quantity": {"type": "integer", "minimum": 1, "multipleOf": 1},
+				"price": {"type": "number", "exclusiveMinimum": 0}
+			},
+			"additionalProperties": {"type": "string"}
+		}
+	}
+}`
+
+func TestValidate(t *testing.T) {
+	s, err := parseSchema([]byte(testSchema))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	for _, tt := range []struct {
+		name     string
+		doc      string
+		expected []ValidationError
+	}{{
+		name: "valid",
+		doc: `{
+			"id": "order-1",
+			"status": "paid",
+			"note": null,
+			"version": 1,
+			"items": [{"sku": "a", "quantity": 2, "price": 9.99, "color": "red"}],
+			"discount": 10,
+			"tags": ["x"]
+		}`,
+	}, {
+		name: "wrong type",
+		doc:  `[]`,
+		expected: []ValidationError{
+			{Path: "$", Message: "expected object, got array"},
+		},
+	}, {
+		name: "missing required and additional property",
+		doc:  `{"id": "order-1", "foo": 1}`,
+		expected: []ValidationError{
+			{Path: "$", Message: "missing required property: items"},
+			{Path: "$", Message: "additional property not allowed: foo"},
+		},
+	}, {
+		name: "string constraints",
+		doc:  `{"id": "Order_1234567890", "items": [{"sku": "a", "quantity": 1}]}`,
+		expected: []ValidationError{
+			{Path: "$.id", Message: "string longer than 12"},
+			{Path: "$.id", Message: "string doesn't match the pattern: ^[a-z0-9-]+$"},
+		},
+	}, {
+		name: "enum, const and type list",
+		doc:  `{"id": "a", "status": "lost", "note": 1, "version": 2, "items": [{"sku": "a", "quantity": 1}]}`,
+		expected: []ValidationError{
+			{Path: "$.note", Message: "expected string or null, got integer"},
+			{Path: "$.status", Message: "value is not one of the allowed values"},
+			{Path: "$.version", Message: "value is not the allowed constant"},
+		},
+	}, {
+		name: "array items by reference",
+		doc:  `{"id": "a", "items": [{"sku": 1, "quantity": 0.5, "price": 0, "color": 3}, {"quantity": 1}]}`,
+		expected: []ValidationError{
+			{Path: "$.items[0].color", Message: "expected string, got integer"},
+			{Path: "$.items[0].price", Message: "value must be greater than 0"},
+			{Path: "$.items[0].quantity", Message: "expected integer, got number"},
+			{Path: "$.items[0].sku", Message: "expected string, got integer"},
+			{Path: "$.items[1]", Message: "missing required property: sku"},
+		},
+	}, {
+		name: "array constraints",
+		doc:  `{"id": "a", "items": [], "tags": []}`,
+		expected: []ValidationError{
+			{Path: "$.items", Message: "array has fewer than 1 items"},
+			{Path: "$.tags", Message: "value matches a disallowed schema"},
+		},
+	}, {
+		name: "unique items",
+		doc:  `{"id": "a", "items": [{"sku": "a", "quantity": 1}, {"sku": "a", "quantity": 1.0}]}`,
+		expected: []ValidationError{
+			{Path: "$.items", Message: "array items are not unique"},
+		},
+	}, {
+		name: "one of",
+		doc:  `{"id": "a", "items": [{"sku": "a", "quantity": 1}], "discount": 100}`,
+		expected: []ValidationError{
+			{Path: "$.discount", Message: "value matches 0 of the schemas, instead of exactly one"},
+		},
+	}} {
+		t.Run(tt.name, func(t *testing.T) {
+			errs := s.validate(decode(t, tt.doc))
+			if !reflect.DeepEqual(errs, tt.expected) {
+				t.Errorf("unexpected errors, expected: %v, got: %v", tt.expected, errs)
+			}
+		})
+	}
+}
+
+func TestCircularReference(t *testing.T) {
+	s, err := parseSchema([]byte(`{
+		"definitions": {"node": {
+			"type": "object",
+			"properties": {"children": {"type": "array", "items": {"$ref": "#/definitions/node"}}}
+		}},
+		"$ref": "#/definitions/node"
+	}`))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	doc := decode(t, `{"children": [{"children": [{"children": 1}]}]}`)
+
+	errs := s.validate(doc)
+	if len(errs) != 1 || errs[0].Path != "$.children[0].children[0].children" {
+		t.Errorf("unexpected errors: %v", errs)
+	}
+
+	s, err = parseSchema([]byte(`{"definitions": {"a": {"$ref": "#/definitions/a"}}, "$ref": "#/definitions/a"}`))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if errs := s.validate(doc); len(errs) != 1 || errs[0].Message != "too many nested references" {
+		t.Errorf("unexpected errors: %v", errs)
+	}
+}
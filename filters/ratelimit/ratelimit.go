@@ -12,6 +12,7 @@ import (
 
 	log "github.com/sirupsen/logrus"
 	"github.com/zalando/skipper/filters"
+	"github.com/zalando/skipper/filters/template"
 	"github.com/zalando/skipper/ratelimit"
 )
 
@@ -110,6 +111,18 @@ func NewClusterRateLimit() filters.Spec {
 //    -> clusterClientRatelimit("groupC", 20, "1h", "Authorization")
 //    -> "https://foo.backend.net";
 //
+// The optional third parameter can be a template, too, to count the
+// requests by arbitrary attributes of the request, like a JWT claim, a
+// path parameter, or the client address at a given depth of the
+// X-Forwarded-For header, behind a known number of proxies:
+//
+//    api: Path("/api/:tenant/*")
+//    -> clusterClientRatelimit("groupD", 100, "1m", "${tenant}-${jwt.sub}")
+//    -> "https://foo.backend.net";
+//
+// See eskip.Template for the available placeholders. The requests whose
+// template can't be fully resolved are not rate limited.
+//
 func NewClusterClientRateLimit() filters.Spec {
 	return &spec{typ: ratelimit.ClusterClientRatelimit, filterName: ratelimit.ClusterClientRatelimitName}
 }
@@ -218,7 +231,9 @@ func clusterClientRatelimitFilter(args []interface{}) (filters.Filter, error) {
 		if err != nil {
 			return nil, err
 		}
-		if strings.Contains(lookuperString, ",") {
+		if isTemplate(lookuperString) {
+			s.Lookuper = ratelimit.NewTemplateLookuper(lookuperString)
+		} else if strings.Contains(lookuperString, ",") {
 			var lookupers []ratelimit.Lookuper
 			for _, ls := range strings.Split(lookuperString, ",") {
 				lookupers = append(lookupers, getLookuper(ls))
@@ -234,6 +249,12 @@ func clusterClientRatelimitFilter(args []interface{}) (filters.Filter, error) {
 	return &filter{settings: s}, nil
 }
 
+// isTemplate tells whether the client is defined by a template, e.g.
+// "${jwt.sub}", instead of header names
+func isTemplate(s string) bool {
+	return template.New(s).HasPlaceholders()
+}
+
 func getLookuper(s string) ratelimit.Lookuper {
 	headerName := http.CanonicalHeaderKey(s)
 	if headerName == "X-Forwarded-For" {
@@ -264,7 +285,9 @@ func clientRatelimitFilter(args []interface{}) (filters.Filter, error) {
 		if err != nil {
 			return nil, err
 		}
-		if strings.Contains(lookuperString, ",") {
+		if isTemplate(lookuperString) {
+			lookuper = ratelimit.NewTemplateLookuper(lookuperString)
+		} else if strings.Contains(lookuperString, ",") {
 			var lookupers []ratelimit.Lookuper
 			for _, ls := range strings.Split(lookuperString, ",") {
 				lookupers = append(lookupers, getLookuper(ls))
@@ -256,6 +256,24 @@ func TestRateLimit(t *testing.T) {
 		"Authorization",
 	))
 
+	t.Run("ratelimit clusterClient template", test(
+		NewClusterClientRateLimit,
+		[]ratelimit.Settings{
+			{
+				Type:          ratelimit.ClusterClientRatelimit,
+				MaxHits:       3,
+				TimeWindow:    1 * time.Second,
+				CleanInterval: 10 * time.Second,
+				Lookuper:      ratelimit.NewTemplateLookuper("${request.source.2},${jwt.sub}"),
+				Group:         "mygroup",
+			},
+		},
+		"mygroup",
+		3,
+		"1s",
+		"${request.source.2},${jwt.sub}",
+	))
+
 	t.Run("ratelimit disable", test(
 		NewDisableRatelimit,
 		[]ratelimit.Settings{{Type: ratelimit.DisableRatelimit}},
@@ -18,6 +18,15 @@
 // scheduler group and lifo will get a per route unique scheduler
 // group.
 //
+// The lifoPriority filter sets the priority class of a route using the lifo
+// or lifoGroup filter. When the queue is saturated, the requests of the low
+// and normal priority routes are rejected earlier, to keep the capacity for
+// the high priority ones.
+//
+// The fifo filter limits the concurrency of a route with a bounded first
+// in first out queue, to protect backends with a known capacity limit.
+// On overflow, it responds with 503 and a Retry-After header.
+//
 // Bounded schedulers were tested in Kubernetes with 3 proxy instances
 // with 500m CPU and 500Mi memory resources. The load test was done
 // with 500 requests per second to backends with 25 seconds latency
@@ -0,0 +1,152 @@
+package scheduler
+
+import (
+	"math"
+	"net/http"
+	"strconv"
+	"time"
+
+	log "github.com/sirupsen/logrus"
+	"github.com/zalando/skipper/filters"
+	"github.com/zalando/skipper/scheduler"
+)
+
+type (
+	fifoSpec struct{}
+
+	fifoFilter struct {
+		config scheduler.Config
+		queue  *scheduler.FifoQueue
+	}
+)
+
+const FIFOName = "fifo"
+
+func NewFIFO() filters.Spec {
+	return &fifoSpec{}
+}
+
+func (*fifoSpec) Name() string { return FIFOName }
+
+// CreateFilter creates a fifoFilter, that limits the number of
+// concurrent requests of the route, and queues the requests exceeding
+// the limit in a bounded first in first out queue. The first parameter
+// is MaxConcurrency the second MaxQueueSize and the third Timeout.
+//
+// All parameters are optional and defaults to MaxConcurrency 100,
+// MaxQueueSize 100, Timeout 10s.
+//
+// The total maximum number of requests has to be computed by adding
+// MaxConcurrency and MaxQueueSize: total max = MaxConcurrency + MaxQueueSize
+//
+// Min values are 1 for MaxConcurrency, 0 for MaxQueueSize, and 1ms for
+// Timeout. All configration that is below will be set to the defaults.
+func (*fifoSpec) CreateFilter(args []interface{}) (filters.Filter, error) {
+	if len(args) > 3 {
+		return nil, filters.ErrInvalidFilterParameters
+	}
+
+	f := &fifoFilter{
+		config: scheduler.Config{
+			MaxConcurrency: defaultMaxConcurreny,
+			MaxQueueSize:   defaultMaxQueueSize,
+			Timeout:        defaultTimeout,
+		},
+	}
+
+	if len(args) > 0 {
+		c, err := intArg(args[0])
+		if err != nil {
+			return nil, err
+		}
+		if c >= 1 {
+			f.config.MaxConcurrency = c
+		}
+	}
+
+	if len(args) > 1 {
+		c, err := intArg(args[1])
+		if err != nil {
+			return nil, err
+		}
+		if c >= 0 {
+			f.config.MaxQueueSize = c
+		}
+	}
+
+	if len(args) > 2 {
+		d, err := durationArg(args[2])
+		if err != nil {
+			return nil, err
+		}
+		if d >= 1*time.Millisecond {
+			f.config.Timeout = d
+		}
+	}
+
+	return f, nil
+}
+
+// Config returns the scheduler configuration for the given filter
+func (f *fifoFilter) Config() scheduler.Config {
+	return f.config
+}
+
+// SetQueue binds the queue to the current filter context
+func (f *fifoFilter) SetQueue(q *scheduler.FifoQueue) {
+	f.queue = q
+}
+
+// GetQueue is only used in tests.
+func (f *fifoFilter) GetQueue() *scheduler.FifoQueue {
+	return f.queue
+}
+
+// Request is the filter.Filter interface implementation. Request will
+// wait for a free slot in the queue, and respond to the caller with
+// 503 and a Retry-After header, when the queue is full or the request
+// could not be scheduled within the timeout.
+func (f *fifoFilter) Request(ctx filters.FilterContext) {
+	q := f.GetQueue()
+	if q == nil {
+		log.Warningf("Unexpected scheduler.FifoQueue is nil for key %s", scheduler.FIFOKey)
+		return
+	}
+
+	done, err := q.Wait()
+	if err != nil {
+		switch err {
+		case scheduler.ErrQueueFull, scheduler.ErrQueueTimeout:
+			log.Debugf("Failed to get an entry on to the queue to process: %v for host %s", err, ctx.Request().Host)
+			ctx.Serve(&http.Response{
+				StatusCode: http.StatusServiceUnavailable,
+				Header:     http.Header{"Retry-After": []string{retryAfter(f.config.Timeout)}},
+			})
+		default:
+			log.Errorf("Failed to schedule the request for route based FIFO: %v for host %s", err, ctx.Request().Host)
+			ctx.Serve(&http.Response{StatusCode: http.StatusServiceUnavailable})
+		}
+
+		return
+	}
+
+	pending, _ := ctx.StateBag()[scheduler.FIFOKey].([]func())
+	ctx.StateBag()[scheduler.FIFOKey] = append(pending, done)
+}
+
+// Response is the filter.Filter interface implementation. Response
+// will decrease the number of inflight requests.
+func (f *fifoFilter) Response(ctx filters.FilterContext) {
+	response(scheduler.FIFOKey, ctx)
+}
+
+// retryAfter returns the queue timeout in whole seconds, as an estimate
+// of when the route may accept requests again.
+func retryAfter(timeout time.Duration) string {
+	s := int(math.Ceil(timeout.Seconds()))
+	if s < 1 {
+		s = 1
+	}
+
+	return strconv.Itoa(s)
+}
@@ -0,0 +1,138 @@
+package scheduler
+
+import (
+	"net/http"
+	"testing"
+	"time"
+
+	"github.com/zalando/skipper/eskip"
+	"github.com/zalando/skipper/filters/filtertest"
+	"github.com/zalando/skipper/routing"
+	"github.com/zalando/skipper/scheduler"
+)
+
+func TestNewFIFO(t *testing.T) {
+	for _, tt := range []struct {
+		name       string
+		args       []interface{}
+		wantErr    bool
+		wantConfig scheduler.Config
+	}{{
+		name: "defaults",
+		wantConfig: scheduler.Config{
+			MaxConcurrency: defaultMaxConcurreny,
+			MaxQueueSize:   defaultMaxQueueSize,
+			Timeout:        defaultTimeout,
+		},
+	}, {
+		name: "valid configuration",
+		args: []interface{}{10, 15, "5s"},
+		wantConfig: scheduler.Config{
+			MaxConcurrency: 10,
+			MaxQueueSize:   15,
+			Timeout:        5 * time.Second,
+		},
+	}, {
+		name: "no queue",
+		args: []interface{}{10.0, 0.0},
+		wantConfig: scheduler.Config{
+			MaxConcurrency: 10,
+			MaxQueueSize:   0,
+			Timeout:        defaultTimeout,
+		},
+	}, {
+		name: "invalid values, applies defaults",
+		args: []interface{}{-1, -15},
+		wantConfig: scheduler.Config{
+			MaxConcurrency: defaultMaxConcurreny,
+			MaxQueueSize:   defaultMaxQueueSize,
+			Timeout:        defaultTimeout,
+		},
+	}, {
+		name:    "invalid duration",
+		args:    []interface{}{10, 15, "4a"},
+		wantErr: true,
+	}, {
+		name:    "invalid int type",
+		args:    []interface{}{"foo"},
+		wantErr: true,
+	}, {
+		name:    "too many args",
+		args:    []interface{}{10, 15, "5s", "foo"},
+		wantErr: true,
+	}} {
+		t.Run(tt.name, func(t *testing.T) {
+			f, err := NewFIFO().CreateFilter(tt.args)
+			if tt.wantErr {
+				if err == nil {
+					t.Error("failed to fail")
+				}
+
+				return
+			}
+
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			if c := f.(*fifoFilter).Config(); c != tt.wantConfig {
+				t.Errorf("Failed to get Config, got: %v, want: %v", c, tt.wantConfig)
+			}
+		})
+	}
+}
+
+func TestFIFOOverflow(t *testing.T) {
+	r, err := eskip.Parse(`r: * -> fifo(1, 1, "50ms") -> <shunt>`)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	reg := scheduler.NewRegistry()
+	defer reg.Close()
+
+	route := &routing.Route{Route: *r[0]}
+	f, err := NewFIFO().CreateFilter(r[0].Filters[0].Args)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	route.Filters = []*routing.RouteFilter{{Filter: f, Name: FIFOName}}
+	reg.Do([]*routing.Route{route})
+	if f.(*fifoFilter).GetQueue() == nil {
+		t.Fatal("queue not set")
+	}
+
+	req, err := http.NewRequest("GET", "https://www.example.org", nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// the first request occupies the only slot
+	active := &filtertest.Context{FRequest: req, FStateBag: make(map[string]interface{})}
+	f.Request(active)
+	if active.FServed {
+		t.Fatal("first request rejected")
+	}
+
+	// the second request waits in the queue until the timeout
+	queued := &filtertest.Context{FRequest: req, FStateBag: make(map[string]interface{})}
+	f.Request(queued)
+	if !queued.FServed || queued.FResponse.StatusCode != http.StatusServiceUnavailable {
+		t.Fatal("queued request not rejected after the timeout")
+	}
+
+	if ra := queued.FResponse.Header.Get("Retry-After"); ra != "1" {
+		t.Errorf("unexpected Retry-After: %s", ra)
+	}
+
+	// releasing the slot allows the next request
+	f.Response(active)
+	next := &filtertest.Context{FRequest: req, FStateBag: make(map[string]interface{})}
+	f.Request(next)
+	if next.FServed {
+		t.Error("request rejected after the slot was released")
+	}
+
+	f.Response(next)
+}
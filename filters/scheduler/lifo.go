@@ -15,8 +15,9 @@ type (
 	lifoGroupSpec struct{}
 
 	lifoFilter struct {
-		config scheduler.Config
-		queue  *scheduler.Queue
+		config   scheduler.Config
+		queue    *scheduler.Queue
+		priority scheduler.Priority
 	}
 
 	lifoGroupFilter struct {
@@ -24,6 +25,7 @@ type (
 		hasConfig bool
 		config    scheduler.Config
 		queue     *scheduler.Queue
+		priority  scheduler.Priority
 	}
 )
 
@@ -231,14 +233,20 @@ func (l *lifoFilter) GetQueue() *scheduler.Queue {
 	return l.queue
 }
 
+// SetPriority sets the priority class of the route
+func (l *lifoFilter) SetPriority(p scheduler.Priority) {
+	l.priority = p
+}
+
 // Request is the filter.Filter interface implementation. Request will
 // increase the number of inflight requests and respond to the caller,
 // if the bounded queue returns an error. Status code by Error:
 //
 // - 503 if jobqueue.ErrQueueFull
+// - 503 if scheduler.ErrPriorityShed
 // - 502 if jobqueue.ErrTimeout
 func (l *lifoFilter) Request(ctx filters.FilterContext) {
-	request(l.GetQueue(), scheduler.LIFOKey, ctx)
+	request(l.GetQueue(), l.priority, scheduler.LIFOKey, ctx)
 }
 
 // Response is the filter.Filter interface implementation. Response
@@ -270,14 +278,20 @@ func (l *lifoGroupFilter) GetQueue() *scheduler.Queue {
 	return l.queue
 }
 
+// SetPriority sets the priority class of the route
+func (l *lifoGroupFilter) SetPriority(p scheduler.Priority) {
+	l.priority = p
+}
+
 // Request is the filter.Filter interface implementation. Request will
 // increase the number of inflight requests and respond to the caller,
 // if the bounded queue returns an error. Status code by Error:
 //
 // - 503 if jobqueue.ErrStackFull
+// - 503 if scheduler.ErrPriorityShed
 // - 502 if jobqueue.ErrTimeout
 func (l *lifoGroupFilter) Request(ctx filters.FilterContext) {
-	request(l.GetQueue(), scheduler.LIFOKey, ctx)
+	request(l.GetQueue(), l.priority, scheduler.LIFOKey, ctx)
 }
 
 // Response is the filter.Filter interface implementation. Response
@@ -286,13 +300,13 @@ func (l *lifoGroupFilter) Response(ctx filters.FilterContext) {
 	response(scheduler.LIFOKey, ctx)
 }
 
-func request(q *scheduler.Queue, key string, ctx filters.FilterContext) {
+func request(q *scheduler.Queue, p scheduler.Priority, key string, ctx filters.FilterContext) {
 	if q == nil {
 		log.Warningf("Unexpected scheduler.Queue is nil for key %s", key)
 		return
 	}
 
-	done, err := q.Wait()
+	done, err := q.WaitPriority(p)
 	if err != nil {
 		// TODO: replace the log with metrics
 		switch err {
@@ -302,6 +316,12 @@ func request(q *scheduler.Queue, key string, ctx filters.FilterContext) {
 				StatusCode: http.StatusServiceUnavailable,
 				Status:     "Queue Full - https://opensource.zalando.com/skipper/operation/operation/#scheduler",
 			})
+		case scheduler.ErrPriorityShed:
+			log.Debugf("Request shed by priority class %s for host %s", p, ctx.Request().Host)
+			ctx.Serve(&http.Response{
+				StatusCode: http.StatusServiceUnavailable,
+				Status:     "Queue Full - https://opensource.zalando.com/skipper/operation/operation/#scheduler",
+			})
 		case jobqueue.ErrTimeout:
 			log.Errorf("Failed to get an entry on to the queue to process Timeout: %v for host %s", err, ctx.Request().Host)
 			ctx.Serve(&http.Response{
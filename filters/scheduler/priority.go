@@ -0,0 +1,55 @@
+package scheduler
+
+import (
+	"github.com/zalando/skipper/filters"
+	"github.com/zalando/skipper/scheduler"
+)
+
+const LIFOPriorityName = "lifoPriority"
+
+type (
+	lifoPrioritySpec struct{}
+
+	lifoPriorityFilter struct {
+		priority scheduler.Priority
+	}
+)
+
+// NewLIFOPriority creates the filter spec of the lifoPriority filter, that
+// sets the priority class of the route for the lifo or lifoGroup filter.
+func NewLIFOPriority() filters.Spec {
+	return &lifoPrioritySpec{}
+}
+
+func (*lifoPrioritySpec) Name() string { return LIFOPriorityName }
+
+// CreateFilter creates a lifoPriorityFilter. It expects the name of the
+// priority class: high, normal or low. When the queue of the route is
+// filled above the limit of the class, the requests of the lower classes
+// are rejected with 503, while the requests of the higher classes are
+// still queued. The routes without this filter are in the high class.
+func (*lifoPrioritySpec) CreateFilter(args []interface{}) (filters.Filter, error) {
+	if len(args) != 1 {
+		return nil, filters.ErrInvalidFilterParameters
+	}
+
+	s, ok := args[0].(string)
+	if !ok {
+		return nil, filters.ErrInvalidFilterParameters
+	}
+
+	p, err := scheduler.ParsePriority(s)
+	if err != nil {
+		return nil, filters.ErrInvalidFilterParameters
+	}
+
+	return &lifoPriorityFilter{priority: p}, nil
+}
+
+// Priority returns the priority class of the route.
+func (l *lifoPriorityFilter) Priority() scheduler.Priority {
+	return l.priority
+}
+
+func (*lifoPriorityFilter) Request(filters.FilterContext)  {}
+func (*lifoPriorityFilter) Response(filters.FilterContext) {}
@@ -0,0 +1,25 @@
+/*
+Package sed provides the sed filter, that rewrites the response body by
+replacing the matches of a regular expression, e.g. to rewrite the
+absolute URLs or the hostnames emitted by legacy backends.
+
+The filter expects a regular expression in the syntax of the Go regexp
+package, and a replacement, that can reference the submatches of the
+expression, e.g. $1 or ${name}, the same way as regexp.Expand:
+
+	* -> sed("https?://legacy.internal/", "https://www.example.org/") -> "https://legacy.internal";
+
+The body is rewritten in a streaming way. The filter reads the body until
+the next match of the expression, and it keeps in memory only the content
+that was read since the previous match. The optional third argument sets
+the maximum size of this buffer in bytes, which defaults to 2MiB. When
+no match was found in the buffer, its content is passed on unchanged, and
+the matching starts again with the following content. This means, that
+matches crossing the buffer limit are not replaced, and that the
+expressions using anchors, like ^ or $, or matching long sequences of
+arbitrary content, like .*, may not behave as expected.
+
+Responses with an encoded body, e.g. gzip, are not rewritten, and the
+Content-Length header is removed from the rewritten responses.
+*/
+package sed
@@ -0,0 +1,145 @@
+package sed
+
+import (
+	"bytes"
+	"io"
+	"regexp"
+	"unicode/utf8"
+)
+
+const readSize = 8192
+
+// editor replaces the matches of the pattern in the content read from
+// the underlying reader. It keeps in the buffer only the content read
+// since the last match, up to the max buffer size.
+type editor struct {
+	body          io.ReadCloser
+	pattern       *regexp.Regexp
+	replacement   []byte
+	maxBufferSize int
+
+	// content read from the body, not processed yet
+	buffer []byte
+
+	// position of the rune reader in the buffer
+	pos int
+
+	// processed content, not returned yet
+	ready bytes.Buffer
+
+	readErr error
+}
+
+func newEditor(body io.ReadCloser, pattern *regexp.Regexp, replacement []byte, maxBufferSize int) *editor {
+	return &editor{
+		body:          body,
+		pattern:       pattern,
+		replacement:   replacement,
+		maxBufferSize: maxBufferSize,
+	}
+}
+
+// ReadRune feeds the regular expression from the buffer, reading more
+// content from the body when necessary. It returns io.EOF when the body
+// was consumed, or when the buffer is full.
+func (e *editor) ReadRune() (rune, int, error) {
+	for {
+		rest := e.buffer[e.pos:]
+		if utf8.FullRune(rest) || len(rest) > 0 && e.readErr != nil {
+			r, size := utf8.DecodeRune(rest)
+			e.pos += size
+			return r, size, nil
+		}
+
+		if e.readErr != nil || len(e.buffer) >= e.maxBufferSize {
+			return 0, 0, io.EOF
+		}
+
+		e.fill()
+	}
+}
+
+func (e *editor) fill() {
+	n := readSize
+	if l := e.maxBufferSize - len(e.buffer); l < n {
+		n = l
+	}
+
+	// reading at least a complete rune, even beyond the limit
+	if n < utf8.UTFMax {
+		n = utf8.UTFMax
+	}
+
+	l := len(e.buffer)
+	if cap(e.buffer)-l < n {
+		b := make([]byte, l, l+n)
+		copy(b, e.buffer)
+		e.buffer = b
+	}
+
+	m, err := e.body.Read(e.buffer[l : l+n])
+	e.buffer = e.buffer[:l+m]
+	if err != nil {
+		e.readErr = err
+	}
+}
+
+// consume drops the first n bytes of the buffer
+func (e *editor) consume(n int) {
+	e.buffer = e.buffer[:copy(e.buffer, e.buffer[n:])]
+	e.pos = 0
+}
+
+// next processes the content up to and including the next match
+func (e *editor) next() {
+	e.pos = 0
+	match := e.pattern.FindReaderSubmatchIndex(e)
+	if match == nil {
+		// no match until the end of the body, or within the limit of
+		// the buffer, passing on everything read so far
+		e.ready.Write(e.buffer)
+		e.consume(len(e.buffer))
+		return
+	}
+
+	e.ready.Write(e.buffer[:match[0]])
+	e.ready.Write(e.pattern.Expand(nil, e.replacement, e.buffer, match))
+	end := match[1]
+	if match[0] == match[1] {
+		// empty match, the next rune is passed on, to make progress
+		if end < len(e.buffer) {
+			_, size := utf8.DecodeRune(e.buffer[end:])
+			e.ready.Write(e.buffer[end : end+size])
+			end += size
+		} else if e.readErr == nil {
+			e.fill()
+			if len(e.buffer) > end {
+				_, size := utf8.DecodeRune(e.buffer[end:])
+				e.ready.Write(e.buffer[end : end+size])
+				end += size
+			}
+		}
+	}
+
+	e.consume(end)
+}
+
+func (e *editor) done() bool {
+	return e.readErr != nil && len(e.buffer) == 0
+}
+
+func (e *editor) Read(p []byte) (int, error) {
+	for e.ready.Len() == 0 && !e.done() {
+		e.next()
+	}
+
+	if e.ready.Len() > 0 {
+		return e.ready.Read(p)
+	}
+
+	return 0, e.readErr
+}
+
+func (e *editor) Close() error {
+	return e.body.Close()
+}
@@ -0,0 +1,88 @@
+package sed
+
+import (
+	"regexp"
+
+	"github.com/zalando/skipper/filters"
+)
+
+const (
+	// Name of the filter, it can be referenced in eskip by this name.
+	Name = "sed"
+
+	defaultMaxBufferSize = 2 << 20
+)
+
+type spec struct{}
+
+type filter struct {
+	pattern       *regexp.Regexp
+	replacement   []byte
+	maxBufferSize int
+}
+
+// New creates a filter specification for the sed filter.
+func New() filters.Spec { return spec{} }
+
+func (spec) Name() string { return Name }
+
+func (spec) CreateFilter(args []interface{}) (filters.Filter, error) {
+	if len(args) < 2 || len(args) > 3 {
+		return nil, filters.ErrInvalidFilterParameters
+	}
+
+	expression, ok := args[0].(string)
+	if !ok || expression == "" {
+		return nil, filters.ErrInvalidFilterParameters
+	}
+
+	replacement, ok := args[1].(string)
+	if !ok {
+		return nil, filters.ErrInvalidFilterParameters
+	}
+
+	pattern, err := regexp.Compile(expression)
+	if err != nil {
+		return nil, filters.ErrInvalidFilterParameters
+	}
+
+	f := &filter{
+		pattern:       pattern,
+		replacement:   []byte(replacement),
+		maxBufferSize: defaultMaxBufferSize,
+	}
+
+	if len(args) == 3 {
+		switch v := args[2].(type) {
+		case float64:
+			f.maxBufferSize = int(v)
+		case int:
+			f.maxBufferSize = v
+		default:
+			return nil, filters.ErrInvalidFilterParameters
+		}
+
+		if f.maxBufferSize <= 0 {
+			return nil, filters.ErrInvalidFilterParameters
+		}
+	}
+
+	return f, nil
+}
+
+func (f *filter) Request(filters.FilterContext) {}
+
+func (f *filter) Response(ctx filters.FilterContext) {
+	rsp := ctx.Response()
+	if rsp.Body == nil {
+		return
+	}
+
+	if ce := rsp.Header.Get("Content-Encoding"); ce != "" && ce != "identity" {
+		return
+	}
+
+	rsp.Header.Del("Content-Length")
+	rsp.ContentLength = -1
+	rsp.Body = newEditor(rsp.Body, f.pattern, f.replacement, f.maxBufferSize)
+}
@@ -0,0 +1,209 @@
+package sed
+
+import (
+	"bytes"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"regexp"
+	"strings"
+	"testing"
+	"testing/iotest"
+	"unicode/utf8"
+
+	"github.com/zalando/skipper/eskip"
+	"github.com/zalando/skipper/filters"
+	"github.com/zalando/skipper/proxy/proxytest"
+)
+
+func TestCreateFilter(t *testing.T) {
+	for _, tt := range []struct {
+		msg  string
+		args []interface{}
+		err  bool
+	}{{
+		msg: "no args",
+		err: true,
+	}, {
+		msg:  "missing replacement",
+		args: []interface{}{"foo"},
+		err:  true,
+	}, {
+		msg:  "invalid expression",
+		args: []interface{}{"foo(", "bar"},
+		err:  true,
+	}, {
+		msg:  "empty expression",
+		args: []interface{}{"", "bar"},
+		err:  true,
+	}, {
+		msg:  "invalid buffer size",
+		args: []interface{}{"foo", "bar", float64(0)},
+		err:  true,
+	}, {
+		msg:  "too many args",
+		args: []interface{}{"foo", "bar", float64(1), float64(2)},
+		err:  true,
+	}, {
+		msg:  "pattern and replacement",
+		args: []interface{}{"foo", "bar"},
+	}, {
+		msg:  "pattern, replacement and buffer size",
+		args: []interface{}{"foo", "bar", float64(1024)},
+	}} {
+		t.Run(tt.msg, func(t *testing.T) {
+			_, err := New().CreateFilter(tt.args)
+			if tt.err && err == nil {
+				t.Error("failed to fail")
+			} else if !tt.err && err != nil {
+				t.Error(err)
+			}
+		})
+	}
+}
+
+func TestEditor(t *testing.T) {
+	for _, tt := range []struct {
+		msg           string
+		pattern       string
+		replacement   string
+		input         string
+		maxBufferSize int
+	}{{
+		msg:         "no match",
+		pattern:     "foo",
+		replacement: "bar",
+		input:       "Hello, world!",
+	}, {
+		msg:         "single match",
+		pattern:     "world",
+		replacement: "skipper",
+		input:       "Hello, world!",
+	}, {
+		msg:         "multiple matches",
+		pattern:     "http://legacy\\.internal",
+		replacement: "https://www.example.org",
+		input:       `<a href="http://legacy.internal/foo">foo</a><a href="http://legacy.internal/bar">bar</a>`,
+	}, {
+		msg:         "submatches",
+		pattern:     "http://([a-z]+)\\.internal",
+		replacement: "https://$1.example.org",
+		input:       `<a href="http://foo.internal/">foo</a><a href="http://bar.internal/">bar</a>`,
+	}, {
+		msg:         "greedy match",
+		pattern:     "a+",
+		replacement: "b",
+		input:       "xaaaaaaaaaaaaaaaaaaaaaaaaay",
+	}, {
+		msg:         "match at the end",
+		pattern:     "end",
+		replacement: "END",
+		input:       "the end",
+	}, {
+		msg:         "multibyte runes",
+		pattern:     "ö+",
+		replacement: "o",
+		input:       "Schööön, schön",
+	}, {
+		msg:           "matches within the buffer limit",
+		pattern:       "foo",
+		replacement:   "bar",
+		input:         strings.Repeat("foo baz ", 1000),
+		maxBufferSize: 16,
+	}, {
+		msg:         "empty input",
+		pattern:     "foo",
+		replacement: "bar",
+	}} {
+		t.Run(tt.msg, func(t *testing.T) {
+			if tt.maxBufferSize == 0 {
+				tt.maxBufferSize = defaultMaxBufferSize
+			}
+
+			p := regexp.MustCompile(tt.pattern)
+			expected := p.ReplaceAllString(tt.input, tt.replacement)
+
+			// reading one byte at a time splits the matches and the runes
+			body := ioutil.NopCloser(iotest.OneByteReader(strings.NewReader(tt.input)))
+			e := newEditor(body, p, []byte(tt.replacement), tt.maxBufferSize)
+			b, err := ioutil.ReadAll(e)
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			if string(b) != expected {
+				t.Errorf("unexpected result, expected: %s, got: %s", expected, string(b))
+			}
+		})
+	}
+}
+
+func TestEditorBufferLimit(t *testing.T) {
+	// the match crosses the buffer limit, and it's passed on unchanged,
+	// while the content is streamed within bounded memory
+	input := strings.Repeat("x", 10) + "foo" + strings.Repeat("x", 100)
+	e := newEditor(ioutil.NopCloser(strings.NewReader(input)), regexp.MustCompile("foo"), []byte("bar"), 11)
+	b, err := ioutil.ReadAll(e)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if string(b) != input {
+		t.Errorf("unexpected result: %s", string(b))
+	}
+
+	if cap(e.buffer) > 11+utf8.UTFMax {
+		t.Errorf("buffer exceeded the limit: %d", cap(e.buffer))
+	}
+}
+
+func TestSed(t *testing.T) {
+	backend := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "text/html")
+		if r.URL.Path == "/encoded" {
+			w.Header().Set("Content-Encoding", "gzip")
+		}
+
+		fmt.Fprint(w, `<a href="http://legacy.internal/foo">foo</a>`)
+	}))
+	defer backend.Close()
+
+	fr := make(filters.Registry)
+	fr.Register(New())
+	p := proxytest.New(fr, &eskip.Route{
+		Filters: []*eskip.Filter{{Name: Name, Args: []interface{}{"http://legacy\\.internal/", "https://www.example.org/"}}},
+		Backend: backend.URL,
+	})
+	defer p.Close()
+
+	client := &http.Client{Transport: &http.Transport{DisableCompression: true}}
+	for _, tt := range []struct {
+		path     string
+		expected string
+	}{
+		{"/", `<a href="https://www.example.org/foo">foo</a>`},
+		{"/encoded", `<a href="http://legacy.internal/foo">foo</a>`},
+	} {
+		req, err := http.NewRequest("GET", p.URL+tt.path, nil)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		req.Header.Set("Accept-Encoding", "gzip")
+		rsp, err := client.Do(req)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		b, err := ioutil.ReadAll(rsp.Body)
+		rsp.Body.Close()
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		if !bytes.Equal(b, []byte(tt.expected)) {
+			t.Errorf("%s: unexpected body, expected: %s, got: %s", tt.path, tt.expected, string(b))
+		}
+	}
+}
@@ -0,0 +1,252 @@
+/*
+Package shedder provides the admissionControl filter, that sheds load when
+the backend of a route fails, following the adaptive client side
+throttling described in the Site Reliability Engineering book:
+
+https://landing.google.com/sre/sre-book/chapters/handling-overload/#eq2101
+
+The filter counts the requests, and the requests accepted by the backend,
+meaning the responses with a status code below 500, in a sliding time
+window. When the backend starts failing, the requests are rejected locally
+with 503 Service Unavailable, with the probability:
+
+	max(0, (requests - k * accepts) / (requests + 1))
+
+limited by the maximum reject probability. The rejected requests are
+counted as requests, too, so that the rejection rate adapts to the success
+rate of the backend, and when it recovers, the rejections stop.
+
+The state is shared by the filters with the same group name, and it is
+kept when the routes are updated. The filter accepts the group name, and
+optionally the mode, the size of the time window, the multiplier k, and
+the maximum reject probability:
+
+	r: * -> admissionControl("orders", "active", "10s", 2, 0.95) -> "https://orders.example.org";
+
+In the inactive mode, the filter doesn't reject any requests, but reports
+the would-be rejected requests in the metrics, to evaluate the settings.
+*/
+package shedder
+
+import (
+	"math/rand"
+	"net/http"
+	"sync"
+	"time"
+
+	"github.com/zalando/skipper/filters"
+)
+
+const (
+	AdmissionControlName = "admissionControl"
+
+	ModeActive   = "active"
+	ModeInactive = "inactive"
+
+	DefaultWindow               = 10 * time.Second
+	DefaultK                    = 2
+	DefaultMaxRejectProbability = 0.95
+
+	// number of buckets of the sliding window
+	windowBuckets = 10
+)
+
+type (
+	admissionControlSpec struct {
+		mu     sync.Mutex
+		groups map[string]*admissionGroup
+	}
+
+	admissionControlFilter struct {
+		group                *admissionGroup
+		active               bool
+		k                    float64
+		maxRejectProbability float64
+		rand                 func() float64
+	}
+
+	bucket struct {
+		start    time.Time
+		requests int64
+		accepts  int64
+	}
+
+	// admissionGroup counts the requests and the accepts of the filters
+	// of the same group in a sliding window
+	admissionGroup struct {
+		name string
+
+		mu         sync.Mutex
+		window     time.Duration
+		bucketSize time.Duration
+		buckets    [windowBuckets]bucket
+	}
+)
+
+// NewAdmissionControl creates the filter spec of the admissionControl
+// filter.
+func NewAdmissionControl() filters.Spec {
+	return &admissionControlSpec{groups: make(map[string]*admissionGroup)}
+}
+
+func (*admissionControlSpec) Name() string { return AdmissionControlName }
+
+// group returns the shared state of the group. When the window of the
+// group changes, the counters are reset.
+func (s *admissionControlSpec) group(name string, window time.Duration) *admissionGroup {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	g, ok := s.groups[name]
+	if !ok {
+		g = &admissionGroup{name: name}
+		s.groups[name] = g
+	}
+
+	g.mu.Lock()
+	defer g.mu.Unlock()
+	if g.window != window {
+		g.window = window
+		g.bucketSize = window / windowBuckets
+		g.buckets = [windowBuckets]bucket{}
+	}
+
+	return g
+}
+
+func (s *admissionControlSpec) CreateFilter(args []interface{}) (filters.Filter, error) {
+	if len(args) == 0 || len(args) > 5 {
+		return nil, filters.ErrInvalidFilterParameters
+	}
+
+	name, ok := args[0].(string)
+	if !ok || name == "" {
+		return nil, filters.ErrInvalidFilterParameters
+	}
+
+	f := &admissionControlFilter{
+		active:               true,
+		k:                    DefaultK,
+		maxRejectProbability: DefaultMaxRejectProbability,
+		rand:                 rand.Float64,
+	}
+
+	if len(args) > 1 {
+		switch args[1] {
+		case ModeActive:
+		case ModeInactive:
+			f.active = false
+		default:
+			return nil, filters.ErrInvalidFilterParameters
+		}
+	}
+
+	window := DefaultWindow
+	if len(args) > 2 {
+		s, ok := args[2].(string)
+		if !ok {
+			return nil, filters.ErrInvalidFilterParameters
+		}
+
+		var err error
+		if window, err = time.ParseDuration(s); err != nil || window < windowBuckets*time.Millisecond {
+			return nil, filters.ErrInvalidFilterParameters
+		}
+	}
+
+	if len(args) > 3 {
+		if f.k, ok = args[3].(float64); !ok || f.k < 1 {
+			return nil, filters.ErrInvalidFilterParameters
+		}
+	}
+
+	if len(args) > 4 {
+		if f.maxRejectProbability, ok = args[4].(float64); !ok || f.maxRejectProbability <= 0 || f.maxRejectProbability > 1 {
+			return nil, filters.ErrInvalidFilterParameters
+		}
+	}
+
+	f.group = s.group(name, window)
+	return f, nil
+}
+
+// current returns the bucket of the current time, and resets it, when it
+// belongs to an earlier round of the window
+func (g *admissionGroup) current(now time.Time) *bucket {
+	start := now.Truncate(g.bucketSize)
+	b := &g.buckets[int(start.UnixNano()/int64(g.bucketSize))%windowBuckets]
+	if !b.start.Equal(start) {
+		*b = bucket{start: start}
+	}
+
+	return b
+}
+
+// count returns the requests and the accepts of the window
+func (g *admissionGroup) count(now time.Time) (requests, accepts int64) {
+	for _, b := range g.buckets {
+		if now.Sub(b.start) < g.window {
+			requests += b.requests
+			accepts += b.accepts
+		}
+	}
+
+	return
+}
+
+// request counts a request, and returns the reject probability based on
+// the counters before it
+func (g *admissionGroup) request(now time.Time, k float64) float64 {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+
+	requests, accepts := g.count(now)
+	g.current(now).requests++
+	p := (float64(requests) - k*float64(accepts)) / float64(requests+1)
+	if p < 0 {
+		return 0
+	}
+
+	return p
+}
+
+func (g *admissionGroup) accept(now time.Time) {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+	g.current(now).accepts++
+}
+
+// Request rejects the request with the current reject probability. The
+// requests and the rejected requests of the group are counted in the
+// custom metrics of the filter, to monitor the shed rate.
+func (f *admissionControlFilter) Request(ctx filters.FilterContext) {
+	m := ctx.Metrics()
+	if m != nil {
+		m.IncCounter(f.group.name + ".requests")
+	}
+
+	p := f.group.request(time.Now(), f.k)
+	if p > f.maxRejectProbability {
+		p = f.maxRejectProbability
+	}
+
+	if p == 0 || f.rand() >= p {
+		return
+	}
+
+	if m != nil {
+		m.IncCounter(f.group.name + ".rejected")
+	}
+
+	if f.active {
+		ctx.Serve(&http.Response{StatusCode: http.StatusServiceUnavailable})
+	}
+}
+
+// Response counts the responses of the backend with a status code below
+// 500 as accepted.
+func (f *admissionControlFilter) Response(ctx filters.FilterContext) {
+	if ctx.Response().StatusCode < http.StatusInternalServerError {
+		f.group.accept(time.Now())
+	}
+}
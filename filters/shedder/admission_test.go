@@ -0,0 +1,139 @@
+package shedder
+
+import (
+	"net/http"
+	"testing"
+	"time"
+
+	"github.com/zalando/skipper/filters/filtertest"
+	"github.com/zalando/skipper/metrics/metricstest"
+)
+
+func TestAdmissionControlArgs(t *testing.T) {
+	for _, tt := range []struct {
+		name    string
+		args    []interface{}
+		wantErr bool
+	}{
+		{name: "no args", wantErr: true},
+		{name: "empty group", args: []interface{}{""}, wantErr: true},
+		{name: "invalid mode", args: []interface{}{"orders", "foo"}, wantErr: true},
+		{name: "invalid window", args: []interface{}{"orders", "active", "foo"}, wantErr: true},
+		{name: "too small window", args: []interface{}{"orders", "active", "1ms"}, wantErr: true},
+		{name: "invalid k", args: []interface{}{"orders", "active", "10s", 0.5}, wantErr: true},
+		{name: "invalid max reject probability", args: []interface{}{"orders", "active", "10s", 2.0, 1.5}, wantErr: true},
+		{name: "too many args", args: []interface{}{"orders", "active", "10s", 2.0, 0.9, 1.0}, wantErr: true},
+		{name: "group", args: []interface{}{"orders"}},
+		{name: "all args", args: []interface{}{"orders", "inactive", "1m", 1.5, 0.5}},
+	} {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := NewAdmissionControl().CreateFilter(tt.args)
+			if tt.wantErr && err == nil {
+				t.Error("failed to fail")
+			} else if !tt.wantErr && err != nil {
+				t.Error(err)
+			}
+		})
+	}
+}
+
+func TestAdmissionGroupWindow(t *testing.T) {
+	g := NewAdmissionControl().(*admissionControlSpec).group("test", time.Second)
+	now := time.Now()
+	for i := 0; i < 10; i++ {
+		g.request(now, DefaultK)
+	}
+
+	if p := g.request(now, DefaultK); p != 10.0/11 {
+		t.Errorf("unexpected reject probability without accepts: %v", p)
+	}
+
+	for i := 0; i < 11; i++ {
+		g.accept(now)
+	}
+
+	if p := g.request(now, DefaultK); p != 0 {
+		t.Errorf("unexpected reject probability with accepts: %v", p)
+	}
+
+	if r, a := g.count(now.Add(2 * time.Second)); r != 0 || a != 0 {
+		t.Errorf("counters not expired: %d, %d", r, a)
+	}
+}
+
+func admissionRequests(t *testing.T, f *admissionControlFilter, n, status int) (rejected int) {
+	for i := 0; i < n; i++ {
+		ctx := &filtertest.Context{FRequest: &http.Request{}, FMetrics: &metricstest.MockMetrics{}}
+		f.Request(ctx)
+		if !ctx.FServed {
+			ctx.FResponse = &http.Response{StatusCode: status}
+		}
+
+		f.Response(ctx)
+		if ctx.FServed {
+			if ctx.FResponse.StatusCode != http.StatusServiceUnavailable {
+				t.Fatalf("unexpected status code: %d", ctx.FResponse.StatusCode)
+			}
+
+			rejected++
+		}
+	}
+
+	return
+}
+
+func TestAdmissionControl(t *testing.T) {
+	spec := NewAdmissionControl()
+	ff, err := spec.CreateFilter([]interface{}{"orders", "active", "1m"})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	f := ff.(*admissionControlFilter)
+	if rejected := admissionRequests(t, f, 100, http.StatusOK); rejected != 0 {
+		t.Errorf("requests rejected while the backend succeeds: %d", rejected)
+	}
+
+	// the backend fails: with 100 accepts, the rejects start after 200
+	// requests, and most of the following ones are rejected
+	admissionRequests(t, f, 100, http.StatusInternalServerError)
+	if rejected := admissionRequests(t, f, 1000, http.StatusInternalServerError); rejected < 600 || rejected > 960 {
+		t.Errorf("unexpected number of rejected requests: %d", rejected)
+	}
+
+	// the filters of the same group share the state across route updates
+	ff, err = spec.CreateFilter([]interface{}{"orders", "active", "1m"})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if rejected := admissionRequests(t, ff.(*admissionControlFilter), 100, http.StatusOK); rejected == 0 {
+		t.Error("state of the group not shared")
+	}
+}
+
+func TestAdmissionControlInactive(t *testing.T) {
+	ff, err := NewAdmissionControl().CreateFilter([]interface{}{"orders", "inactive"})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	f := ff.(*admissionControlFilter)
+	m := &metricstest.MockMetrics{}
+	for i := 0; i < 100; i++ {
+		ctx := &filtertest.Context{FRequest: &http.Request{}, FMetrics: m}
+		f.Request(ctx)
+		if ctx.FServed {
+			t.Fatal("request rejected in inactive mode")
+		}
+
+		ctx.FResponse = &http.Response{StatusCode: http.StatusServiceUnavailable}
+		f.Response(ctx)
+	}
+
+	m.WithCounters(func(counters map[string]int64) {
+		if counters["orders.requests"] != 100 || counters["orders.rejected"] == 0 {
+			t.Errorf("unexpected counters: %v", counters)
+		}
+	})
+}
@@ -21,5 +21,11 @@ It is possible to change the path of the tee request, in a similar way to the mo
 	Path("/api/v1") -> tee("https://api.example.org", "^/v1", "/v2" ) -> "http://api.example.org"
 
 In the above example, one can test how a new version of an API would behave on incoming requests.
+
+The mirrorTraffic filter sends a copy of only a sampled fraction of the requests, and isolates the
+shadow backend from the main request, by buffering the request body and dropping the copies when the
+shadow backend cannot keep up:
+
+	* -> mirrorTraffic("https://shadow.example.org", 0.1) -> "https://www.example.org"
 */
 package tee
@@ -0,0 +1,215 @@
+package tee
+
+import (
+	"bytes"
+	"io"
+	"io/ioutil"
+	"math/rand"
+	"net/http"
+	"net/url"
+	"time"
+
+	log "github.com/sirupsen/logrus"
+	"github.com/zalando/skipper/filters"
+	snet "github.com/zalando/skipper/net"
+)
+
+// MirrorName is the name of the mirrorTraffic filter.
+const MirrorName = "mirrorTraffic"
+
+const (
+	defaultMirrorMaxBodySize    = 1 << 20
+	defaultMirrorMaxConcurrency = 64
+)
+
+// MirrorOptions for the mirrorTraffic filter.
+type MirrorOptions struct {
+
+	// Timeout of the requests sent to the shadow backend.
+	Timeout time.Duration
+
+	// MaxBodySize is the maximum size of the request body that is
+	// buffered in memory to be sent to the shadow backend. Requests
+	// with larger bodies are not mirrored.
+	MaxBodySize int64
+
+	// MaxConcurrency is the maximum number of requests in flight to the
+	// shadow backend, per route. When reached, the requests are not
+	// mirrored until some of the pending ones complete.
+	MaxConcurrency int
+}
+
+type mirrorSpec struct {
+	options MirrorOptions
+}
+
+type mirror struct {
+	tee
+	fraction    float64
+	maxBodySize int64
+	pending     chan struct{}
+	random      func() float64
+}
+
+// NewMirrorTraffic returns a filter spec, whose instances send a copy
+// of a sampled fraction of the requests to a shadow backend.
+//
+// Unlike tee, the mirrored requests are isolated from the main request:
+// the body, if any, is buffered in memory instead of streamed in
+// lockstep with the main backend, the requests are dropped when the
+// shadow backend cannot keep up, and redirects are not followed. The
+// responses of the shadow backend are discarded.
+//
+// Parameters: the shadow backend url, and optionally the fraction of the
+// requests to mirror, between 0 (exclusive) and 1 (inclusive). The
+// fraction defaults to 1.
+//
+// Example:
+//
+//	* -> mirrorTraffic("https://shadow.example.org", 0.1) -> "https://www.example.org";
+func NewMirrorTraffic() filters.Spec {
+	return MirrorWithOptions(MirrorOptions{})
+}
+
+// MirrorWithOptions returns a mirrorTraffic filter spec with the given
+// options.
+func MirrorWithOptions(o MirrorOptions) filters.Spec {
+	if o.Timeout <= 0 {
+		o.Timeout = defaultTeeTimeout
+	}
+
+	if o.MaxBodySize <= 0 {
+		o.MaxBodySize = defaultMirrorMaxBodySize
+	}
+
+	if o.MaxConcurrency <= 0 {
+		o.MaxConcurrency = defaultMirrorMaxConcurrency
+	}
+
+	return &mirrorSpec{options: o}
+}
+
+func (spec *mirrorSpec) Name() string { return MirrorName }
+
+func (spec *mirrorSpec) CreateFilter(config []interface{}) (filters.Filter, error) {
+	if len(config) < 1 || len(config) > 2 {
+		return nil, filters.ErrInvalidFilterParameters
+	}
+
+	backend, ok := config[0].(string)
+	if !ok {
+		return nil, filters.ErrInvalidFilterParameters
+	}
+
+	u, err := url.Parse(backend)
+	if err != nil {
+		return nil, err
+	}
+
+	if u.Host == "" {
+		return nil, filters.ErrInvalidFilterParameters
+	}
+
+	fraction := 1.0
+	if len(config) == 2 {
+		fraction, ok = config[1].(float64)
+		if !ok || fraction <= 0 || fraction > 1 {
+			return nil, filters.ErrInvalidFilterParameters
+		}
+	}
+
+	return &mirror{
+		tee: tee{
+			client: &http.Client{
+				Timeout: spec.options.Timeout,
+				CheckRedirect: func(req *http.Request, via []*http.Request) error {
+					return http.ErrUseLastResponse
+				},
+			},
+			typ:    asBackend,
+			host:   u.Host,
+			scheme: u.Scheme,
+		},
+		fraction:    fraction,
+		maxBodySize: spec.options.MaxBodySize,
+		pending:     make(chan struct{}, spec.options.MaxConcurrency),
+		random:      rand.Float64,
+	}, nil
+}
+
+func (m *mirror) Request(ctx filters.FilterContext) {
+	if m.fraction < 1 && m.random() >= m.fraction {
+		return
+	}
+
+	select {
+	case m.pending <- struct{}{}:
+	default:
+		ctx.Metrics().IncCounter("dropped")
+		return
+	}
+
+	req := ctx.Request()
+	if !m.bufferBody(req) {
+		<-m.pending
+		ctx.Metrics().IncCounter("dropped")
+		return
+	}
+
+	clone, body, err := cloneRequest(&m.tee, req)
+	if err != nil {
+		<-m.pending
+		log.Warnf("mirrorTraffic: error while cloning the request: %v", err)
+		return
+	}
+
+	req.Body = body
+	go func() {
+		defer func() {
+			<-m.pending
+			if m.shadowRequestDone != nil {
+				m.shadowRequestDone()
+			}
+		}()
+
+		rsp, err := m.client.Do(clone)
+		if err != nil {
+			log.Debugf("mirrorTraffic: error while sending the request: %v", err)
+			return
+		}
+
+		io.Copy(ioutil.Discard, rsp.Body)
+		rsp.Body.Close()
+	}()
+}
+
+// bufferBody makes the request body replayable, so that the shadow
+// request doesn't need to be read in lockstep with the main one. It
+// returns false when the body is larger than the maximum size or it
+// cannot be read. In this case, the main request receives the original
+// body unchanged.
+func (m *mirror) bufferBody(req *http.Request) bool {
+	if req.Body == nil || req.ContentLength == 0 || req.GetBody != nil {
+		return true
+	}
+
+	if req.ContentLength > m.maxBodySize {
+		return false
+	}
+
+	buf, rest, _ := snet.ReadBody(req.Body, m.maxBodySize)
+	if rest != nil {
+		// the error, if any, is returned to the proxy again
+		req.Body = rest
+		return false
+	}
+
+	req.Body = ioutil.NopCloser(bytes.NewReader(buf))
+	req.GetBody = func() (io.ReadCloser, error) {
+		return ioutil.NopCloser(bytes.NewReader(buf)), nil
+	}
+
+	return true
+}
+
+func (m *mirror) Response(filters.FilterContext) {}
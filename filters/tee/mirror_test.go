@@ -0,0 +1,201 @@
+package tee
+
+import (
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/zalando/skipper/eskip"
+	"github.com/zalando/skipper/filters"
+	"github.com/zalando/skipper/filters/filtertest"
+	"github.com/zalando/skipper/metrics/metricstest"
+	"github.com/zalando/skipper/proxy/proxytest"
+)
+
+func TestMirrorArgs(t *testing.T) {
+	for _, ti := range []struct {
+		msg  string
+		args []interface{}
+		err  bool
+	}{{
+		msg: "no args",
+		err: true,
+	}, {
+		msg:  "backend only",
+		args: []interface{}{"https://shadow.example.org"},
+	}, {
+		msg:  "backend and fraction",
+		args: []interface{}{"https://shadow.example.org", 0.1},
+	}, {
+		msg:  "invalid backend",
+		args: []interface{}{42.0},
+		err:  true,
+	}, {
+		msg:  "backend without host",
+		args: []interface{}{"/shadow"},
+		err:  true,
+	}, {
+		msg:  "invalid fraction",
+		args: []interface{}{"https://shadow.example.org", "0.1"},
+		err:  true,
+	}, {
+		msg:  "zero fraction",
+		args: []interface{}{"https://shadow.example.org", 0.0},
+		err:  true,
+	}, {
+		msg:  "fraction greater than 1",
+		args: []interface{}{"https://shadow.example.org", 1.5},
+		err:  true,
+	}, {
+		msg:  "too many args",
+		args: []interface{}{"https://shadow.example.org", 0.1, "foo"},
+		err:  true,
+	}} {
+		t.Run(ti.msg, func(t *testing.T) {
+			_, err := NewMirrorTraffic().CreateFilter(ti.args)
+			if ti.err && err == nil {
+				t.Error("failed to fail")
+			} else if !ti.err && err != nil {
+				t.Error(err)
+			}
+		})
+	}
+}
+
+func TestMirrorEndToEndBody(t *testing.T) {
+	shadowHandler := newTestHandler(t, "shadow")
+	shadowServer := httptest.NewServer(shadowHandler)
+	defer shadowServer.Close()
+
+	originalHandler := newTestHandler(t, "original")
+	originalServer := httptest.NewServer(originalHandler)
+	defer originalServer.Close()
+
+	route, _ := eskip.Parse(fmt.Sprintf(`* -> mirrorTraffic("%s", 1) -> "%s"`, shadowServer.URL, originalServer.URL))
+	registry := make(filters.Registry)
+	registry.Register(NewMirrorTraffic())
+	p := proxytest.New(registry, route...)
+	defer p.Close()
+
+	req, err := http.NewRequest("POST", p.URL, strings.NewReader("TESTEST"))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	rsp, err := http.DefaultClient.Do(req)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	rsp.Body.Close()
+	<-shadowHandler.served
+	if shadowHandler.body != "TESTEST" || originalHandler.body != "TESTEST" {
+		t.Errorf("bodies are not equal: %s, %s", shadowHandler.body, originalHandler.body)
+	}
+}
+
+func TestMirrorSampling(t *testing.T) {
+	for _, ti := range []struct {
+		msg      string
+		random   float64
+		mirrored bool
+	}{{
+		msg:      "sampled",
+		random:   0.05,
+		mirrored: true,
+	}, {
+		msg:    "not sampled",
+		random: 0.5,
+	}} {
+		t.Run(ti.msg, func(t *testing.T) {
+			served := make(chan struct{}, 1)
+			shadow := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				served <- struct{}{}
+			}))
+			defer shadow.Close()
+
+			f, err := NewMirrorTraffic().CreateFilter([]interface{}{shadow.URL, 0.1})
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			m := f.(*mirror)
+			m.random = func() float64 { return ti.random }
+
+			req, err := http.NewRequest("GET", "http://www.example.org", nil)
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			m.Request(&filtertest.Context{FRequest: req, FMetrics: &metricstest.MockMetrics{}})
+
+			select {
+			case <-served:
+				if !ti.mirrored {
+					t.Error("request mirrored unexpectedly")
+				}
+			case <-time.After(300 * time.Millisecond):
+				if ti.mirrored {
+					t.Error("request not mirrored")
+				}
+			}
+		})
+	}
+}
+
+func TestMirrorSlowShadowIsolated(t *testing.T) {
+	release := make(chan struct{})
+	received := make(chan struct{}, 2)
+	shadow := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		received <- struct{}{}
+		<-release
+	}))
+	defer shadow.Close()
+	defer close(release)
+
+	f, err := MirrorWithOptions(MirrorOptions{MaxConcurrency: 1}).CreateFilter([]interface{}{shadow.URL})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	metrics := &metricstest.MockMetrics{}
+	for i := 0; i < 2; i++ {
+		req, err := http.NewRequest("POST", "http://www.example.org", strings.NewReader("TESTEST"))
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		// hiding the replayable body
+		req.GetBody = nil
+		f.Request(&filtertest.Context{FRequest: req, FMetrics: metrics})
+
+		// the main body can be read while the shadow backend is blocked
+		b, err := ioutil.ReadAll(req.Body)
+		if err != nil || string(b) != "TESTEST" {
+			t.Errorf("failed to read the main body: %s, %v", b, err)
+		}
+	}
+
+	<-received
+	select {
+	case <-received:
+		t.Error("request mirrored over the concurrency limit")
+	case <-time.After(100 * time.Millisecond):
+	}
+
+	metrics.WithCounters(func(c map[string]int64) {
+		if c["dropped"] != 1 {
+			t.Errorf("unexpected number of dropped requests: %d", c["dropped"])
+		}
+	})
+}
+
+func TestMirrorName(t *testing.T) {
+	if n := NewMirrorTraffic().Name(); n != MirrorName {
+		t.Errorf("expected name %v, got %v", MirrorName, n)
+	}
+}
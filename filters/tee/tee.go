@@ -195,9 +195,20 @@ func cloneRequest(t *tee, req *http.Request) (*http.Request, io.ReadCloser, erro
 
 	// see proxy.go:231
 	if req.ContentLength != 0 {
-		pr, pw := io.Pipe()
-		teeBody = pr
-		mainBody = &teeTie{mainBody, pw}
+		if req.GetBody != nil {
+			// the body was buffered, e.g. by the bufferRequestBody
+			// filter, so the shadow request can read it independently
+			b, err := req.GetBody()
+			if err != nil {
+				return nil, nil, err
+			}
+
+			teeBody = b
+		} else {
+			pr, pw := io.Pipe()
+			teeBody = pr
+			mainBody = &teeTie{mainBody, pw}
+		}
 	}
 
 	clone, err := http.NewRequest(req.Method, u.String(), teeBody)
@@ -171,6 +171,37 @@ func TestTeeEndToEndBody(t *testing.T) {
 	}
 }
 
+func TestTeeBufferedBody(t *testing.T) {
+	f, _ := testTeeSpec.CreateFilter(teeArgsAsBackend)
+	rep, _ := f.(*tee)
+
+	// requests created with a strings.Reader body can replay the body
+	req, err := http.NewRequest("POST", "http://www.example.org", strings.NewReader("TESTEST"))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	teeRequest, mainBody, err := cloneRequest(rep, req)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// the shadow body can be read without reading the main body first
+	teeBody, err := ioutil.ReadAll(teeRequest.Body)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	body, err := ioutil.ReadAll(mainBody)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if string(teeBody) != "TESTEST" || string(body) != "TESTEST" {
+		t.Errorf("unexpected bodies: %s, %s", teeBody, body)
+	}
+}
+
 func TestTeeFollowOrNot(t *testing.T) {
 	for _, follow := range []bool{
 		true,
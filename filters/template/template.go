@@ -0,0 +1,228 @@
+/*
+Package template provides the templates of the filter arguments, whose
+placeholders are resolved from the attributes of the request, e.g. the
+headers, the path parameters or the claims of the JWT.
+
+The placeholders have a prefix, and the text that doesn't match any of them
+is left unchanged, so the arguments of the existing routes containing ${...}
+are not affected:
+
+	${param.name}             path parameter, e.g. from Path("/users/:name")
+	${request.header.X-Name}  header
+	${request.query.name}     query parameter
+	${request.cookie.name}    cookie
+	${request.source}         client address, based on X-Forwarded-For
+	${request.sourceFromLast} client address, based on the last entry of X-Forwarded-For
+	${request.source.N}       client address at the depth N of X-Forwarded-For, see net.RemoteHostAtDepth
+	${request.remoteAddr}     address of the connection
+	${request.method}, ${request.host}, ${request.path}
+	${response.status}, ${response.statusText}
+	${route.id}               ID of the matched route
+	${state.key}              value in the state bag
+	${jwt.claim}              claim of the bearer JWT, without verifying it
+*/
+package template
+
+import (
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"net"
+	"net/http"
+	"regexp"
+	"strconv"
+	"strings"
+
+	snet "github.com/zalando/skipper/net"
+)
+
+var placeholderRegexp = regexp.MustCompile(`\$\{((?:param|request|response|route|state|jwt)\.[\w.-]+)\}`)
+
+// Context provides the attributes of the request for evaluating the
+// templates. It is implemented by the filter context. When the context
+// implements the RouteId() string method, the ID of the matched route
+// can be referenced as ${route.id}, and when it implements the Response()
+// *http.Response method, the status of the response as ${response.status}.
+type Context interface {
+	PathParam(string) string
+	Request() *http.Request
+	StateBag() map[string]interface{}
+}
+
+// Template represents a string template with placeholders resolved from
+// the request.
+type Template struct {
+	template     string
+	placeholders []string
+}
+
+// New parses a template string and returns a reusable *Template object.
+func New(template string) *Template {
+	matches := placeholderRegexp.FindAllStringSubmatch(template, -1)
+	placeholders := make([]string, len(matches))
+	for index, placeholder := range matches {
+		placeholders[index] = placeholder[1]
+	}
+
+	return &Template{template: template, placeholders: placeholders}
+}
+
+// HasPlaceholders tells whether the template contains any placeholders.
+// The strings without placeholders can be used as they are.
+func (t *Template) HasPlaceholders() bool {
+	return len(t.placeholders) > 0
+}
+
+// Apply evaluates the template using the attributes of the request. It
+// returns false, when any of the placeholders could not be resolved, in
+// which case they are replaced with an empty string.
+func (t *Template) Apply(ctx Context) (string, bool) {
+	return t.ApplyEscaped(ctx, nil)
+}
+
+// ApplyEscaped evaluates the template like Apply, and escapes the
+// resolved values with the escape function, e.g. when the result is HTML
+// or JSON.
+func (t *Template) ApplyEscaped(ctx Context, escape func(string) string) (string, bool) {
+	if escape == nil {
+		escape = func(s string) string { return s }
+	}
+
+	resolved := true
+	result := t.template
+	var claims map[string]interface{}
+	for _, placeholder := range t.placeholders {
+		var (
+			v  string
+			ok bool
+		)
+
+		if strings.HasPrefix(placeholder, "jwt.") {
+			if claims == nil {
+				claims = jwtClaims(ctx.Request())
+			}
+
+			v, ok = formatValue(claims[strings.TrimPrefix(placeholder, "jwt.")])
+		} else {
+			v, ok = templateValue(ctx, placeholder)
+		}
+
+		resolved = resolved && ok
+		result = strings.Replace(result, "${"+placeholder+"}", escape(v), -1)
+	}
+
+	return result, resolved
+}
+
+func formatValue(v interface{}) (string, bool) {
+	switch vv := v.(type) {
+	case nil:
+		return "", false
+	case string:
+		return vv, vv != ""
+	case json.Number:
+		return vv.String(), true
+	case fmt.Stringer:
+		return vv.String(), true
+	case bool, int, int64, float64:
+		return fmt.Sprint(vv), true
+	default:
+		return "", false
+	}
+}
+
+func formatIP(ip net.IP) (string, bool) {
+	if ip == nil {
+		return "", false
+	}
+
+	return ip.String(), true
+}
+
+func templateValue(ctx Context, placeholder string) (string, bool) {
+	r := ctx.Request()
+	switch {
+	case strings.HasPrefix(placeholder, "param."):
+		return formatValue(ctx.PathParam(strings.TrimPrefix(placeholder, "param.")))
+	case strings.HasPrefix(placeholder, "request.header."):
+		return formatValue(r.Header.Get(strings.TrimPrefix(placeholder, "request.header.")))
+	case strings.HasPrefix(placeholder, "request.query."):
+		return formatValue(r.URL.Query().Get(strings.TrimPrefix(placeholder, "request.query.")))
+	case strings.HasPrefix(placeholder, "request.cookie."):
+		c, err := r.Cookie(strings.TrimPrefix(placeholder, "request.cookie."))
+		if err != nil {
+			return "", false
+		}
+
+		return formatValue(c.Value)
+	case strings.HasPrefix(placeholder, "request.source."):
+		depth, err := strconv.Atoi(strings.TrimPrefix(placeholder, "request.source."))
+		if err != nil || depth < 0 {
+			return "", false
+		}
+
+		return formatIP(snet.RemoteHostAtDepth(r, depth))
+	case strings.HasPrefix(placeholder, "state."):
+		return formatValue(ctx.StateBag()[strings.TrimPrefix(placeholder, "state.")])
+	}
+
+	switch placeholder {
+	case "request.source":
+		return formatIP(snet.RemoteHost(r))
+	case "request.sourceFromLast":
+		return formatIP(snet.RemoteHostFromLast(r))
+	case "request.remoteAddr":
+		return formatValue(r.RemoteAddr)
+	case "request.method":
+		return formatValue(r.Method)
+	case "request.host":
+		return formatValue(r.Host)
+	case "request.path":
+		return formatValue(r.URL.Path)
+	case "response.status", "response.statusText":
+		rc, ok := ctx.(interface{ Response() *http.Response })
+		if !ok || rc.Response() == nil {
+			return "", false
+		}
+
+		if placeholder == "response.statusText" {
+			return formatValue(http.StatusText(rc.Response().StatusCode))
+		}
+
+		return formatValue(rc.Response().StatusCode)
+	case "route.id":
+		if rc, ok := ctx.(interface{ RouteId() string }); ok {
+			return formatValue(rc.RouteId())
+		}
+
+		return "", false
+	default:
+		return "", false
+	}
+}
+
+// jwtClaims decodes the claims of the bearer token, when it is a JWT. The
+// signature is not verified, the token is expected to be validated by an
+// authentication filter.
+func jwtClaims(r *http.Request) map[string]interface{} {
+	claims := make(map[string]interface{})
+	token := r.Header.Get("Authorization")
+	if !strings.HasPrefix(token, "Bearer ") {
+		return claims
+	}
+
+	parts := strings.Split(strings.TrimPrefix(token, "Bearer "), ".")
+	if len(parts) != 3 {
+		return claims
+	}
+
+	payload, err := base64.RawURLEncoding.DecodeString(strings.TrimRight(parts[1], "="))
+	if err != nil {
+		return claims
+	}
+
+	d := json.NewDecoder(strings.NewReader(string(payload)))
+	d.UseNumber()
+	d.Decode(&claims)
+	return claims
+}
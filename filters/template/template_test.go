@@ -0,0 +1,97 @@
+package template
+
+import (
+	"encoding/base64"
+	"html"
+	"net/http"
+	"testing"
+)
+
+type testContext struct {
+	pathParams map[string]string
+	request    *http.Request
+	response   *http.Response
+	stateBag   map[string]interface{}
+}
+
+func (c *testContext) PathParam(name string) string     { return c.pathParams[name] }
+func (c *testContext) Request() *http.Request           { return c.request }
+func (c *testContext) Response() *http.Response         { return c.response }
+func (c *testContext) RouteId() string                  { return "orders" }
+func (c *testContext) StateBag() map[string]interface{} { return c.stateBag }
+
+func TestApply(t *testing.T) {
+	req, err := http.NewRequest("POST", "https://www.example.org/orders/42?tenant=acme", nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	payload := base64.RawURLEncoding.EncodeToString([]byte(`{"sub":"alice","exp":1600000000}`))
+	req.Header.Set("Authorization", "Bearer header."+payload+".signature")
+	req.Header.Set("X-Forwarded-For", "192.168.0.1, 10.0.0.1")
+	req.Header.Set("X-Flow-Id", "abc")
+	req.AddCookie(&http.Cookie{Name: "session", Value: "xyz"})
+	req.RemoteAddr = "10.0.0.2:1234"
+
+	ctx := &testContext{
+		pathParams: map[string]string{"id": "42"},
+		request:    req,
+		response:   &http.Response{StatusCode: http.StatusBadGateway},
+		stateBag:   map[string]interface{}{"tier": "gold", "count": 3},
+	}
+
+	for _, ti := range []struct {
+		template string
+		expected string
+		resolved bool
+	}{
+		{"${param.id}", "42", true},
+		{"${request.header.X-Flow-Id}", "abc", true},
+		{"${request.query.tenant}", "acme", true},
+		{"${request.cookie.session}", "xyz", true},
+		{"${request.source}", "192.168.0.1", true},
+		{"${request.sourceFromLast}", "10.0.0.1", true},
+		{"${request.source.1}", "10.0.0.1", true},
+		{"${request.remoteAddr}", "10.0.0.2:1234", true},
+		{"${request.method} ${request.host}${request.path}", "POST www.example.org/orders/42", true},
+		{"${response.status} ${response.statusText}", "502 Bad Gateway", true},
+		{"${route.id}", "orders", true},
+		{"${state.tier}-${state.count}", "gold-3", true},
+		{"${jwt.sub}:${jwt.exp}", "alice:1600000000", true},
+		{"user ${jwt.missing}", "user ", false},
+		{"${request.cookie.missing}", "", false},
+		{"${param.missing}", "", false},
+		{"${request.unknown}", "", false},
+	} {
+		result, resolved := New(ti.template).Apply(ctx)
+		if result != ti.expected || resolved != ti.resolved {
+			t.Errorf("unexpected result for %s, expected: %s, %v, got: %s, %v", ti.template, ti.expected, ti.resolved, result, resolved)
+		}
+	}
+}
+
+func TestNoPlaceholders(t *testing.T) {
+	for _, s := range []string{"", "foo", "${id}", "${foo.bar}", "$request.host", "${request.host"} {
+		tpl := New(s)
+		if tpl.HasPlaceholders() {
+			t.Errorf("unexpected placeholders in %s", s)
+		}
+
+		if result, resolved := tpl.Apply(&testContext{}); result != s || !resolved {
+			t.Errorf("unexpected result for %s: %s, %v", s, result, resolved)
+		}
+	}
+}
+
+func TestApplyEscaped(t *testing.T) {
+	req, err := http.NewRequest("GET", "https://www.example.org", nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	req.Header.Set("X-Name", "<script>")
+	result, _ := New("<p>${request.header.X-Name}</p>").ApplyEscaped(&testContext{request: req}, html.EscapeString)
+	if result != "<p>&lt;script&gt;</p>" {
+		t.Errorf("unexpected result: %s", result)
+	}
+}
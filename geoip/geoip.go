@@ -0,0 +1,213 @@
+/*
+Package geoip implements the lookup of the country and the autonomous
+system of the client addresses, using MaxMind databases, like the free
+GeoLite2-Country and GeoLite2-ASN databases.
+
+The databases are loaded into memory, and they are reloaded when the
+files change, e.g. after a scheduled update, without restarting skipper.
+
+The package is used by the Country predicate and the setGeoHeaders
+filter.
+*/
+package geoip
+
+import (
+	"net"
+	"net/http"
+	"sync"
+	"time"
+
+	log "github.com/sirupsen/logrus"
+	"github.com/zalando/skipper/filewatch"
+	snet "github.com/zalando/skipper/net"
+)
+
+const defaultReloadInterval = time.Minute
+
+// Options contains the settings of the GeoIP lookups.
+type Options struct {
+	// CountryDatabase is the path of a database containing the country
+	// of the networks, e.g. GeoLite2-Country.mmdb or GeoLite2-City.mmdb.
+	CountryDatabase string
+
+	// ASNDatabase is the path of a database containing the autonomous
+	// system of the networks, e.g. GeoLite2-ASN.mmdb.
+	ASNDatabase string
+
+	// ReloadInterval sets how often the database files are checked for
+	// changes. Defaults to 1 minute.
+	ReloadInterval time.Duration
+
+	// TrustedProxies contains the networks of the proxies in front of
+	// skipper. The client address is resolved by skipping them in the
+	// X-Forwarded-For header, the same way as by the ClientIP
+	// predicate.
+	TrustedProxies []*net.IPNet
+
+	// ForwardedDepth, when greater than zero, sets the depth of the
+	// client address in the X-Forwarded-For header, and it takes
+	// precedence over TrustedProxies.
+	ForwardedDepth int
+}
+
+// ASN contains the autonomous system of a network.
+type ASN struct {
+	Number       uint64
+	Organization string
+}
+
+// DB is a database file, that is reloaded when it changes.
+type DB struct {
+	file *filewatch.File
+}
+
+// GeoIP looks up the country and the autonomous system of the client
+// addresses.
+type GeoIP struct {
+	options Options
+	country *DB
+	asn     *DB
+	quit    chan struct{}
+	once    sync.Once
+}
+
+// OpenDB loads a database file.
+func OpenDB(path string) (*DB, error) {
+	db := &DB{file: filewatch.New(path, 0, parseDB)}
+	if _, err := db.file.Check(); err != nil {
+		return nil, err
+	}
+
+	return db, nil
+}
+
+func parseDB(b []byte) (interface{}, error) {
+	return NewReader(b)
+}
+
+// reload loads the database again, when the file changed since the last
+// load. On failure, the previous version stays in use.
+func (db *DB) reload() {
+	reloaded, err := db.file.Check()
+	if err != nil {
+		log.Errorf("Failed to reload GeoIP database %s: %v", db.file.Path(), err)
+		return
+	}
+
+	if reloaded {
+		log.Infof("GeoIP database reloaded: %s", db.file.Path())
+	}
+}
+
+// Lookup returns the record of the network containing the IP address,
+// or nil, when the address is not found.
+func (db *DB) Lookup(ip net.IP) (interface{}, error) {
+	return db.file.Value().(*Reader).Lookup(ip)
+}
+
+// New loads the configured databases, and starts checking them for
+// changes in the background. The returned instance needs to be closed
+// to stop checking.
+func New(o Options) (*GeoIP, error) {
+	if o.ReloadInterval <= 0 {
+		o.ReloadInterval = defaultReloadInterval
+	}
+
+	g := &GeoIP{options: o, quit: make(chan struct{})}
+
+	var err error
+	if o.CountryDatabase != "" {
+		if g.country, err = OpenDB(o.CountryDatabase); err != nil {
+			return nil, err
+		}
+	}
+
+	if o.ASNDatabase != "" {
+		if g.asn, err = OpenDB(o.ASNDatabase); err != nil {
+			return nil, err
+		}
+	}
+
+	go g.reload()
+	return g, nil
+}
+
+func (g *GeoIP) reload() {
+	ticker := time.NewTicker(g.options.ReloadInterval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ticker.C:
+			for _, db := range []*DB{g.country, g.asn} {
+				if db != nil {
+					db.reload()
+				}
+			}
+		case <-g.quit:
+			return
+		}
+	}
+}
+
+// ClientIP returns the address of the client of the request.
+func (g *GeoIP) ClientIP(r *http.Request) net.IP {
+	if g.options.ForwardedDepth > 0 {
+		return snet.RemoteHostAtDepth(r, g.options.ForwardedDepth)
+	}
+
+	return snet.RemoteHostTrusted(r, g.options.TrustedProxies)
+}
+
+// HasCountry tells whether a country database is configured.
+func (g *GeoIP) HasCountry() bool { return g.country != nil }
+
+// HasASN tells whether an ASN database is configured.
+func (g *GeoIP) HasASN() bool { return g.asn != nil }
+
+func lookup(db *DB, ip net.IP) map[string]interface{} {
+	if db == nil || ip == nil {
+		return nil
+	}
+
+	v, err := db.Lookup(ip)
+	if err != nil {
+		log.Errorf("Failed to look up %v in GeoIP database %s: %v", ip, db.file.Path(), err)
+		return nil
+	}
+
+	m, _ := v.(map[string]interface{})
+	return m
+}
+
+// Country returns the ISO 3166-1 alpha-2 code of the country of the IP
+// address, or an empty string, when it is not known. When the country
+// of the network is missing, the registered country is used.
+func (g *GeoIP) Country(ip net.IP) string {
+	m := lookup(g.country, ip)
+	for _, key := range []string{"country", "registered_country"} {
+		c, _ := m[key].(map[string]interface{})
+		if code, _ := c["iso_code"].(string); code != "" {
+			return code
+		}
+	}
+
+	return ""
+}
+
+// ASN returns the autonomous system of the IP address, or false, when it
+// is not known.
+func (g *GeoIP) ASN(ip net.IP) (ASN, bool) {
+	m := lookup(g.asn, ip)
+	n, ok := m["autonomous_system_number"].(uint64)
+	if !ok {
+		return ASN{}, false
+	}
+
+	org, _ := m["autonomous_system_organization"].(string)
+	return ASN{Number: n, Organization: org}, true
+}
+
+// Close stops checking the databases for changes.
+func (g *GeoIP) Close() {
+	g.once.Do(func() { close(g.quit) })
+}
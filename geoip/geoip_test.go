@@ -0,0 +1,164 @@
+package geoip
+
+import (
+	"io/ioutil"
+	"net"
+	"net/http"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/zalando/skipper/geoip/geoiptest"
+	snet "github.com/zalando/skipper/net"
+)
+
+func country(code string) map[string]interface{} {
+	return map[string]interface{}{"country": map[string]interface{}{"iso_code": code}}
+}
+
+func testDatabases(t *testing.T) (string, Options) {
+	dir, err := ioutil.TempDir("", "geoip")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	o := Options{
+		CountryDatabase: filepath.Join(dir, "country.mmdb"),
+		ASNDatabase:     filepath.Join(dir, "asn.mmdb"),
+	}
+
+	if err := geoiptest.WriteFile(o.CountryDatabase, "GeoLite2-Country", 6, map[string]interface{}{
+		"1.2.3.0/24": country("DE"),
+		"5.6.7.0/24": map[string]interface{}{"registered_country": map[string]interface{}{"iso_code": "AT"}},
+	}); err != nil {
+		t.Fatal(err)
+	}
+
+	if err := geoiptest.WriteFile(o.ASNDatabase, "GeoLite2-ASN", 6, map[string]interface{}{
+		"1.2.3.0/24": map[string]interface{}{
+			"autonomous_system_number":       uint32(64512),
+			"autonomous_system_organization": "Example",
+		},
+	}); err != nil {
+		t.Fatal(err)
+	}
+
+	return dir, o
+}
+
+func TestLookup(t *testing.T) {
+	dir, o := testDatabases(t)
+	defer os.RemoveAll(dir)
+
+	g, err := New(o)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	defer g.Close()
+
+	if !g.HasCountry() || !g.HasASN() {
+		t.Fatal("databases not loaded")
+	}
+
+	for _, test := range []struct {
+		ip      string
+		country string
+		asn     ASN
+		hasASN  bool
+	}{
+		{"1.2.3.4", "DE", ASN{Number: 64512, Organization: "Example"}, true},
+		{"5.6.7.8", "AT", ASN{}, false},
+		{"9.9.9.9", "", ASN{}, false},
+	} {
+		ip := net.ParseIP(test.ip)
+		if c := g.Country(ip); c != test.country {
+			t.Errorf("%s: invalid country, expected: %s, got: %s", test.ip, test.country, c)
+		}
+
+		if asn, ok := g.ASN(ip); ok != test.hasASN || asn != test.asn {
+			t.Errorf("%s: invalid ASN, expected: %v, got: %v", test.ip, test.asn, asn)
+		}
+	}
+
+	if c := g.Country(nil); c != "" {
+		t.Errorf("unexpected country for nil IP: %s", c)
+	}
+}
+
+func TestMissingDatabase(t *testing.T) {
+	if _, err := New(Options{CountryDatabase: "/no/such/file.mmdb"}); err == nil {
+		t.Error("failed to fail")
+	}
+}
+
+func TestReload(t *testing.T) {
+	dir, o := testDatabases(t)
+	defer os.RemoveAll(dir)
+
+	o.ReloadInterval = 10 * time.Millisecond
+	g, err := New(o)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	defer g.Close()
+
+	if err := ioutil.WriteFile(o.CountryDatabase, []byte("invalid"), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	// wait for a few failed reloads
+	time.Sleep(50 * time.Millisecond)
+	if c := g.Country(net.ParseIP("1.2.3.4")); c != "DE" {
+		t.Errorf("failed to keep the previous database, got: %s", c)
+	}
+
+	if err := geoiptest.WriteFile(o.CountryDatabase, "GeoLite2-Country", 6, map[string]interface{}{
+		"1.2.3.0/24": country("FR"),
+	}); err != nil {
+		t.Fatal(err)
+	}
+
+	// make sure that the change is detected even when the modification
+	// time has a low resolution
+	if err := os.Chtimes(o.CountryDatabase, time.Now(), time.Now().Add(time.Hour)); err != nil {
+		t.Fatal(err)
+	}
+
+	timeout := time.After(3 * time.Second)
+	for g.Country(net.ParseIP("1.2.3.4")) != "FR" {
+		select {
+		case <-timeout:
+			t.Fatal("failed to reload the database")
+		case <-time.After(10 * time.Millisecond):
+		}
+	}
+}
+
+func TestClientIP(t *testing.T) {
+	trusted, err := snet.ParseCIDRs([]string{"10.0.0.0/8"})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	r := &http.Request{
+		RemoteAddr: "10.0.0.1:1234",
+		Header:     http.Header{"X-Forwarded-For": []string{"9.9.9.9, 1.2.3.4"}},
+	}
+
+	for _, test := range []struct {
+		options  Options
+		expected string
+	}{
+		{Options{}, "10.0.0.1"},
+		{Options{TrustedProxies: trusted}, "1.2.3.4"},
+		{Options{TrustedProxies: trusted, ForwardedDepth: 2}, "9.9.9.9"},
+	} {
+		g := &GeoIP{options: test.options}
+		if ip := g.ClientIP(r); !ip.Equal(net.ParseIP(test.expected)) {
+			t.Errorf("invalid client IP, expected: %s, got: %v", test.expected, ip)
+		}
+	}
+}
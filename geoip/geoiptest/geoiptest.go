@@ -0,0 +1,241 @@
+/*
+Package geoiptest provides a minimal writer of databases in the MaxMind DB
+format, to test the GeoIP lookups without the real databases.
+*/
+package geoiptest
+
+import (
+	"bytes"
+	"encoding/binary"
+	"fmt"
+	"io/ioutil"
+	"net"
+	"sort"
+)
+
+// Pointer can be used as a value of the records, to refer to a previously
+// written value at the given offset of the data section.
+type Pointer uint32
+
+type writer struct {
+	nodes [][2]int
+	data  bytes.Buffer
+}
+
+const (
+	emptyRecord = -1
+	recordSize  = 24
+)
+
+// Database creates a database with the given networks in CIDR notation
+// and their records. When ipVersion is 6, the IPv4 networks are stored in
+// the ::/96 subtree, the same way as in the GeoLite2 databases. The
+// records can contain maps with string keys, slices, strings, uint16,
+// uint32, uint64, int32, float64, bool values and pointers.
+func Database(databaseType string, ipVersion int, networks map[string]interface{}) ([]byte, error) {
+	w := &writer{nodes: [][2]int{{emptyRecord, emptyRecord}}}
+
+	cidrs := make([]string, 0, len(networks))
+	for c := range networks {
+		cidrs = append(cidrs, c)
+	}
+
+	sort.Strings(cidrs)
+	for _, c := range cidrs {
+		offset := w.data.Len()
+		if err := w.encode(&w.data, networks[c]); err != nil {
+			return nil, err
+		}
+
+		if err := w.insert(c, ipVersion, offset); err != nil {
+			return nil, err
+		}
+	}
+
+	var b bytes.Buffer
+	nodeCount := len(w.nodes)
+	for _, n := range w.nodes {
+		for _, r := range n {
+			switch {
+			case r == emptyRecord:
+				r = nodeCount
+			case r < emptyRecord:
+				// data records are stored as -(offset + 2)
+				r = nodeCount + 16 - r - 2
+			}
+
+			b.Write([]byte{byte(r >> 16), byte(r >> 8), byte(r)})
+		}
+	}
+
+	b.Write(make([]byte, 16))
+	b.Write(w.data.Bytes())
+	b.WriteString("\xab\xcd\xefMaxMind.com")
+	err := w.encode(&b, map[string]interface{}{
+		"binary_format_major_version": uint16(2),
+		"binary_format_minor_version": uint16(0),
+		"database_type":               databaseType,
+		"ip_version":                  uint16(ipVersion),
+		"node_count":                  uint32(nodeCount),
+		"record_size":                 uint16(recordSize),
+	})
+
+	return b.Bytes(), err
+}
+
+// WriteFile creates a database with Database, and writes it to a file.
+func WriteFile(path, databaseType string, ipVersion int, networks map[string]interface{}) error {
+	b, err := Database(databaseType, ipVersion, networks)
+	if err != nil {
+		return err
+	}
+
+	return ioutil.WriteFile(path, b, 0644)
+}
+
+func (w *writer) insert(cidr string, ipVersion, offset int) error {
+	_, n, err := net.ParseCIDR(cidr)
+	if err != nil {
+		return err
+	}
+
+	ones, _ := n.Mask.Size()
+	ip := n.IP
+	if ip4 := ip.To4(); ip4 != nil && ipVersion == 6 {
+		ip = make(net.IP, net.IPv6len)
+		copy(ip[12:], ip4)
+		ones += 96
+	} else if ip4 == nil && ipVersion == 4 {
+		return fmt.Errorf("IPv6 network in IPv4 database: %s", cidr)
+	}
+
+	node := 0
+	for i := 0; i < ones; i++ {
+		bit := ip[i/8] >> (7 - uint(i%8)) & 1
+		if i == ones-1 {
+			if w.nodes[node][bit] != emptyRecord {
+				return fmt.Errorf("overlapping network: %s", cidr)
+			}
+
+			w.nodes[node][bit] = -offset - 2
+			break
+		}
+
+		next := w.nodes[node][bit]
+		if next < 0 {
+			if next != emptyRecord {
+				return fmt.Errorf("overlapping network: %s", cidr)
+			}
+
+			next = len(w.nodes)
+			w.nodes = append(w.nodes, [2]int{emptyRecord, emptyRecord})
+			w.nodes[node][bit] = next
+		}
+
+		node = next
+	}
+
+	return nil
+}
+
+func writeControl(b *bytes.Buffer, typ int, size int) {
+	var ctrl, ext []byte
+	if typ > 7 {
+		ctrl = []byte{0}
+		ext = []byte{byte(typ - 7)}
+	} else {
+		ctrl = []byte{byte(typ << 5)}
+	}
+
+	var sizeBytes []byte
+	switch {
+	case size < 29:
+		ctrl[0] |= byte(size)
+	case size < 285:
+		ctrl[0] |= 29
+		sizeBytes = []byte{byte(size - 29)}
+	case size < 65821:
+		ctrl[0] |= 30
+		s := size - 285
+		sizeBytes = []byte{byte(s >> 8), byte(s)}
+	default:
+		ctrl[0] |= 31
+		s := size - 65821
+		sizeBytes = []byte{byte(s >> 16), byte(s >> 8), byte(s)}
+	}
+
+	b.Write(ctrl)
+	b.Write(ext)
+	b.Write(sizeBytes)
+}
+
+func writeUint(b *bytes.Buffer, typ int, v uint64, maxSize int) {
+	var buf [8]byte
+	binary.BigEndian.PutUint64(buf[:], v)
+	i := 8 - maxSize
+	for i < 8 && buf[i] == 0 {
+		i++
+	}
+
+	writeControl(b, typ, 8-i)
+	b.Write(buf[i:])
+}
+
+func (w *writer) encode(b *bytes.Buffer, v interface{}) error {
+	switch vt := v.(type) {
+	case Pointer:
+		// only the pointers with 11 bits are supported
+		if vt >= 1<<11 {
+			return fmt.Errorf("pointer too large: %d", vt)
+		}
+
+		b.Write([]byte{1<<5 | byte(vt>>8), byte(vt)})
+	case string:
+		writeControl(b, 2, len(vt))
+		b.WriteString(vt)
+	case float64:
+		writeControl(b, 3, 8)
+		binary.Write(b, binary.BigEndian, vt)
+	case uint16:
+		writeUint(b, 5, uint64(vt), 2)
+	case uint32:
+		writeUint(b, 6, uint64(vt), 4)
+	case int32:
+		writeControl(b, 8, 4)
+		binary.Write(b, binary.BigEndian, vt)
+	case uint64:
+		writeUint(b, 9, vt, 8)
+	case bool:
+		s := 0
+		if vt {
+			s = 1
+		}
+
+		writeControl(b, 14, s)
+	case []interface{}:
+		writeControl(b, 11, len(vt))
+		for _, vi := range vt {
+			if err := w.encode(b, vi); err != nil {
+				return err
+			}
+		}
+	case map[string]interface{}:
+		writeControl(b, 7, len(vt))
+		keys := make([]string, 0, len(vt))
+		for k := range vt {
+			keys = append(keys, k)
+		}
+
+		sort.Strings(keys)
+		for _, k := range keys {
+			w.encode(b, k)
+			if err := w.encode(b, vt[k]); err != nil {
+				return err
+			}
+		}
+	default:
+		return fmt.Errorf("unsupported type: %T", v)
+	}
+
+	return nil
+}
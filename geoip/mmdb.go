@@ -0,0 +1,368 @@
+package geoip
+
+import (
+	"bytes"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"math"
+	"math/big"
+	"net"
+)
+
+// metadataStartMarker separates the search tree and the data section
+// from the metadata at the end of the database file.
+var metadataStartMarker = []byte("\xab\xcd\xefMaxMind.com")
+
+const (
+	dataSectionSeparatorSize = 16
+	maxPointerDepth          = 32
+)
+
+const (
+	typeExtended = iota
+	typePointer
+	typeString
+	typeDouble
+	typeBytes
+	typeUint16
+	typeUint32
+	typeMap
+	typeInt32
+	typeUint64
+	typeUint128
+	typeArray
+	typeContainer
+	typeEndMarker
+	typeBool
+	typeFloat
+)
+
+var (
+	errInvalidDatabase = errors.New("invalid MaxMind database")
+	errInvalidData     = errors.New("invalid data in MaxMind database")
+)
+
+// Reader reads the records of a database in the MaxMind DB format, like
+// the GeoLite2 databases. It supports the version 2 of the format, with
+// 24, 28 and 32 bit records, and IPv4 and IPv6 search trees.
+//
+// The format is described at https://maxmind.github.io/MaxMind-DB/
+type Reader struct {
+	buffer       []byte
+	nodeCount    uint
+	recordSize   uint
+	ipVersion    uint
+	databaseType string
+	treeSize     uint
+	dataSection  []byte
+	ipv4Start    uint
+	ipv4Depth    int
+}
+
+type decoder struct {
+	buffer []byte
+}
+
+// NewReader creates a reader for the database contained by the buffer.
+func NewReader(buffer []byte) (*Reader, error) {
+	i := bytes.LastIndex(buffer, metadataStartMarker)
+	if i < 0 {
+		return nil, fmt.Errorf("%v: metadata not found", errInvalidDatabase)
+	}
+
+	d := decoder{buffer: buffer[i+len(metadataStartMarker):]}
+	mv, _, err := d.decode(0, 0)
+	if err != nil {
+		return nil, err
+	}
+
+	m, ok := mv.(map[string]interface{})
+	if !ok {
+		return nil, fmt.Errorf("%v: invalid metadata", errInvalidDatabase)
+	}
+
+	if v, _ := m["binary_format_major_version"].(uint64); v != 2 {
+		return nil, fmt.Errorf("%v: unsupported format version: %d", errInvalidDatabase, v)
+	}
+
+	nodeCount, _ := m["node_count"].(uint64)
+	recordSize, _ := m["record_size"].(uint64)
+	ipVersion, _ := m["ip_version"].(uint64)
+	databaseType, _ := m["database_type"].(string)
+
+	if recordSize != 24 && recordSize != 28 && recordSize != 32 {
+		return nil, fmt.Errorf("%v: unsupported record size: %d", errInvalidDatabase, recordSize)
+	}
+
+	if ipVersion != 4 && ipVersion != 6 {
+		return nil, fmt.Errorf("%v: unsupported IP version: %d", errInvalidDatabase, ipVersion)
+	}
+
+	treeSize := nodeCount * recordSize / 4
+	if treeSize+dataSectionSeparatorSize > uint64(i) {
+		return nil, fmt.Errorf("%v: invalid node count", errInvalidDatabase)
+	}
+
+	r := &Reader{
+		buffer:       buffer,
+		nodeCount:    uint(nodeCount),
+		recordSize:   uint(recordSize),
+		ipVersion:    uint(ipVersion),
+		databaseType: databaseType,
+		treeSize:     uint(treeSize),
+		dataSection:  buffer[treeSize+dataSectionSeparatorSize : i],
+	}
+
+	// the IPv4 addresses are stored in the ::/96 subtree of the IPv6
+	// databases
+	if r.ipVersion == 6 {
+		for ; r.ipv4Depth < 96 && r.ipv4Start < r.nodeCount; r.ipv4Depth++ {
+			r.ipv4Start = r.record(r.ipv4Start, 0)
+		}
+	}
+
+	return r, nil
+}
+
+// DatabaseType returns the type of the database from its metadata, e.g.
+// GeoLite2-Country.
+func (r *Reader) DatabaseType() string {
+	return r.databaseType
+}
+
+// record returns the left (0) or the right (1) record of a node
+func (r *Reader) record(node uint, bit byte) uint {
+	switch r.recordSize {
+	case 24:
+		o := node*6 + uint(bit)*3
+		b := r.buffer[o : o+3]
+		return uint(b[0])<<16 | uint(b[1])<<8 | uint(b[2])
+	case 28:
+		b := r.buffer[node*7 : node*7+7]
+		if bit == 0 {
+			return uint(b[3]&0xf0)<<20 | uint(b[0])<<16 | uint(b[1])<<8 | uint(b[2])
+		}
+
+		return uint(b[3]&0x0f)<<24 | uint(b[4])<<16 | uint(b[5])<<8 | uint(b[6])
+	default:
+		o := node*8 + uint(bit)*4
+		return uint(binary.BigEndian.Uint32(r.buffer[o : o+4]))
+	}
+}
+
+// Lookup returns the record of the network containing the IP address,
+// or nil, when the address is not found in the database.
+func (r *Reader) Lookup(ip net.IP) (interface{}, error) {
+	var (
+		node  uint
+		depth int
+	)
+
+	if ip4 := ip.To4(); ip4 != nil {
+		ip = ip4
+		node = r.ipv4Start
+		depth = r.ipv4Depth
+	} else if ip = ip.To16(); ip == nil || r.ipVersion == 4 {
+		return nil, nil
+	}
+
+	for i := 0; i < len(ip)*8 && node < r.nodeCount; i++ {
+		node = r.record(node, ip[i/8]>>(7-uint(i%8))&1)
+		depth++
+	}
+
+	switch {
+	case node == r.nodeCount:
+		return nil, nil
+	case node < r.nodeCount:
+		return nil, fmt.Errorf("%v: search tree too deep at depth %d", errInvalidDatabase, depth)
+	}
+
+	offset := node - r.nodeCount - dataSectionSeparatorSize
+	d := decoder{buffer: r.dataSection}
+	v, _, err := d.decode(offset, 0)
+	return v, err
+}
+
+func (d decoder) bytes(offset, size uint) ([]byte, error) {
+	if offset+size > uint(len(d.buffer)) || offset+size < offset {
+		return nil, errInvalidData
+	}
+
+	return d.buffer[offset : offset+size], nil
+}
+
+func (d decoder) uint(offset, size uint) (uint64, error) {
+	b, err := d.bytes(offset, size)
+	if err != nil {
+		return 0, err
+	}
+
+	if size > 8 {
+		return 0, errInvalidData
+	}
+
+	var v uint64
+	for _, bi := range b {
+		v = v<<8 | uint64(bi)
+	}
+
+	return v, nil
+}
+
+// control decodes the type and the size of a field, and returns the
+// offset of its payload
+func (d decoder) control(offset uint) (typ int, size uint, next uint, err error) {
+	b, err := d.bytes(offset, 1)
+	if err != nil {
+		return
+	}
+
+	offset++
+	typ = int(b[0] >> 5)
+	if typ == typePointer {
+		// the size bits of the pointers are decoded separately
+		return typ, uint(b[0] & 0x1f), offset, nil
+	}
+
+	if typ == typeExtended {
+		var ext []byte
+		if ext, err = d.bytes(offset, 1); err != nil {
+			return
+		}
+
+		typ = int(ext[0]) + 7
+		offset++
+	}
+
+	size = uint(b[0] & 0x1f)
+	var v uint64
+	switch size {
+	case 29:
+		v, err = d.uint(offset, 1)
+		size, offset = 29+uint(v), offset+1
+	case 30:
+		v, err = d.uint(offset, 2)
+		size, offset = 285+uint(v), offset+2
+	case 31:
+		v, err = d.uint(offset, 3)
+		size, offset = 65821+uint(v), offset+3
+	}
+
+	return typ, size, offset, err
+}
+
+// pointer decodes the data section offset that a pointer field points to
+func (d decoder) pointer(sizeBits, offset uint) (uint, uint, error) {
+	ss := (sizeBits >> 3) & 0x3
+	vvv := uint64(sizeBits & 0x7)
+	v, err := d.uint(offset, ss+1)
+	if err != nil {
+		return 0, 0, err
+	}
+
+	switch ss {
+	case 0:
+		v |= vvv << 8
+	case 1:
+		v = (v | vvv<<16) + 2048
+	case 2:
+		v = (v | vvv<<24) + 526336
+	}
+
+	return uint(v), offset + ss + 1, nil
+}
+
+// decode decodes the field at the offset, and returns its value and the
+// offset of the next field
+func (d decoder) decode(offset uint, depth int) (interface{}, uint, error) {
+	typ, size, offset, err := d.control(offset)
+	if err != nil {
+		return nil, 0, err
+	}
+
+	if typ == typePointer {
+		if depth >= maxPointerDepth {
+			return nil, 0, errInvalidData
+		}
+
+		target, next, err := d.pointer(size, offset)
+		if err != nil {
+			return nil, 0, err
+		}
+
+		v, _, err := d.decode(target, depth+1)
+		return v, next, err
+	}
+
+	switch typ {
+	case typeString:
+		b, err := d.bytes(offset, size)
+		return string(b), offset + size, err
+	case typeDouble:
+		if size != 8 {
+			return nil, 0, errInvalidData
+		}
+
+		v, err := d.uint(offset, size)
+		return math.Float64frombits(v), offset + size, err
+	case typeFloat:
+		if size != 4 {
+			return nil, 0, errInvalidData
+		}
+
+		v, err := d.uint(offset, size)
+		return math.Float32frombits(uint32(v)), offset + size, err
+	case typeBytes:
+		b, err := d.bytes(offset, size)
+		return append([]byte(nil), b...), offset + size, err
+	case typeUint16, typeUint32, typeUint64:
+		v, err := d.uint(offset, size)
+		return v, offset + size, err
+	case typeInt32:
+		if size > 4 {
+			return nil, 0, errInvalidData
+		}
+
+		v, err := d.uint(offset, size)
+		return int64(int32(uint32(v))), offset + size, err
+	case typeUint128:
+		b, err := d.bytes(offset, size)
+		return new(big.Int).SetBytes(b), offset + size, err
+	case typeBool:
+		return size != 0, offset, nil
+	case typeMap:
+		m := make(map[string]interface{}, size)
+		for i := uint(0); i < size; i++ {
+			var k, v interface{}
+			if k, offset, err = d.decode(offset, depth); err != nil {
+				return nil, 0, err
+			}
+
+			ks, ok := k.(string)
+			if !ok {
+				return nil, 0, errInvalidData
+			}
+
+			if v, offset, err = d.decode(offset, depth); err != nil {
+				return nil, 0, err
+			}
+
+			m[ks] = v
+		}
+
+		return m, offset, nil
+	case typeArray:
+		a := make([]interface{}, size)
+		for i := range a {
+			if a[i], offset, err = d.decode(offset, depth); err != nil {
+				return nil, 0, err
+			}
+		}
+
+		return a, offset, nil
+	default:
+		return nil, 0, fmt.Errorf("%v: unsupported type: %d", errInvalidData, typ)
+	}
+}
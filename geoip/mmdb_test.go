@@ -0,0 +1,151 @@
+package geoip
+
+import (
+	"math/big"
+	"net"
+	"reflect"
+	"testing"
+
+	"github.com/zalando/skipper/geoip/geoiptest"
+)
+
+func TestReader(t *testing.T) {
+	record := map[string]interface{}{
+		"country": map[string]interface{}{
+			"iso_code":   "DE",
+			"geoname_id": uint32(2921044),
+		},
+		"location": map[string]interface{}{
+			"latitude":  51.5,
+			"time_zone": "Europe/Berlin",
+		},
+		"is_anycast": true,
+		"int":        int32(-42),
+		"big":        uint64(1 << 40),
+		"list":       []interface{}{"foo", uint16(42)},
+	}
+
+	expected := map[string]interface{}{
+		"country": map[string]interface{}{
+			"iso_code":   "DE",
+			"geoname_id": uint64(2921044),
+		},
+		"location": map[string]interface{}{
+			"latitude":  51.5,
+			"time_zone": "Europe/Berlin",
+		},
+		"is_anycast": true,
+		"int":        int64(-42),
+		"big":        uint64(1 << 40),
+		"list":       []interface{}{"foo", uint64(42)},
+	}
+
+	for _, ipVersion := range []int{4, 6} {
+		networks := map[string]interface{}{
+			"1.2.3.0/24":     record,
+			"5.6.0.0/16":     geoiptest.Pointer(0),
+			"10.0.0.0/8":     map[string]interface{}{"long": string(make([]byte, 300))},
+			"192.168.0.1/32": map[string]interface{}{"empty": map[string]interface{}{}},
+		}
+
+		if ipVersion == 6 {
+			networks["2001:db8::/32"] = record
+		}
+
+		b, err := geoiptest.Database("Test", ipVersion, networks)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		r, err := NewReader(b)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		if r.DatabaseType() != "Test" {
+			t.Errorf("invalid database type: %s", r.DatabaseType())
+		}
+
+		for _, test := range []struct {
+			ip       string
+			expected interface{}
+		}{
+			{"1.2.3.4", expected},
+			{"1.2.4.4", nil},
+			{"5.6.7.8", expected},
+			{"10.1.2.3", map[string]interface{}{"long": string(make([]byte, 300))}},
+			{"192.168.0.1", map[string]interface{}{"empty": map[string]interface{}{}}},
+			{"192.168.0.2", nil},
+			{"::ffff:1.2.3.4", expected},
+			{"2001:db8::1", map[int]interface{}{4: nil, 6: expected}[ipVersion]},
+			{"2001:db9::1", nil},
+		} {
+			v, err := r.Lookup(net.ParseIP(test.ip))
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			if !reflect.DeepEqual(v, test.expected) {
+				t.Errorf("IPv%d database, %s: expected %v, got %v", ipVersion, test.ip, test.expected, v)
+			}
+		}
+	}
+}
+
+func TestReaderUint128(t *testing.T) {
+	d := decoder{buffer: []byte{3, 3, 1, 2, 3}}
+	v, next, err := d.decode(0, 0)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if v.(*big.Int).Int64() != 0x010203 || next != 5 {
+		t.Errorf("invalid value: %v, %d", v, next)
+	}
+}
+
+func TestInvalidDatabase(t *testing.T) {
+	valid, err := geoiptest.Database("Test", 6, map[string]interface{}{"1.2.3.0/24": "foo"})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	for _, test := range []struct {
+		msg string
+		db  []byte
+	}{{
+		msg: "empty",
+	}, {
+		msg: "no metadata",
+		db:  valid[:len(valid)/2],
+	}, {
+		msg: "truncated metadata",
+		db:  valid[:len(valid)-3],
+	}, {
+		msg: "not a map",
+		db:  []byte("\xab\xcd\xefMaxMind.com\x43foo"),
+	}} {
+		t.Run(test.msg, func(t *testing.T) {
+			if _, err := NewReader(test.db); err == nil {
+				t.Error("failed to fail")
+			}
+		})
+	}
+}
+
+func TestRecordSizes(t *testing.T) {
+	for _, test := range []struct {
+		recordSize  uint
+		node        []byte
+		left, right uint
+	}{
+		{24, []byte{0x01, 0x02, 0x03, 0x04, 0x05, 0x06}, 0x010203, 0x040506},
+		{28, []byte{0x01, 0x02, 0x03, 0xab, 0x04, 0x05, 0x06}, 0xa010203, 0xb040506},
+		{32, []byte{0x01, 0x02, 0x03, 0x04, 0x05, 0x06, 0x07, 0x08}, 0x01020304, 0x05060708},
+	} {
+		r := &Reader{buffer: test.node, recordSize: test.recordSize}
+		if left, right := r.record(0, 0), r.record(0, 1); left != test.left || right != test.right {
+			t.Errorf("record size %d: expected %x, %x, got %x, %x", test.recordSize, test.left, test.right, left, right)
+		}
+	}
+}
@@ -0,0 +1,141 @@
+package skipper
+
+import (
+	"crypto/tls"
+	"fmt"
+	"net"
+	"net/http"
+	"sync/atomic"
+
+	log "github.com/sirupsen/logrus"
+)
+
+// altSvcMaxAge is the time in seconds that the clients can remember
+// the advertised HTTP/3 listener
+const altSvcMaxAge = 86400
+
+// HTTP3Server serves HTTP/3 over QUIC. Skipper doesn't implement QUIC
+// itself, the server needs to be provided, e.g. by wrapping the
+// http3.Server of github.com/quic-go/quic-go:
+//
+//	type quicServer struct{ s *http3.Server }
+//
+//	func (q *quicServer) Serve(conn net.PacketConn, tlsConfig *tls.Config, h http.Handler) error {
+//		q.s = &http3.Server{TLSConfig: tlsConfig, Handler: h}
+//		return q.s.Serve(conn)
+//	}
+//
+//	func (q *quicServer) Close() error { return q.s.Close() }
+type HTTP3Server interface {
+
+	// Serve serves the requests with the handler on the UDP
+	// connection, that is already listening. It blocks until the
+	// server is closed.
+	Serve(conn net.PacketConn, tlsConfig *tls.Config, handler http.Handler) error
+
+	// Close stops the server.
+	Close() error
+}
+
+type http3Listener struct {
+	server  HTTP3Server
+	conn    net.PacketConn
+	altSvc  string
+	serving int32
+}
+
+// listenHTTP3 starts the HTTP/3 listener, when enabled, and returns it,
+// or nil, when not enabled. The TLS configuration is the one of the TLS
+// listener, or the one loaded from the certificate and key files. It
+// fails, when the UDP address cannot be listened on.
+func listenHTTP3(o *Options, tlsConfig *tls.Config, handler http.Handler) (*http3Listener, error) {
+	if !o.EnableHTTP3 {
+		return nil, nil
+	}
+
+	if o.HTTP3Server == nil {
+		log.Warn("HTTP/3 is enabled without HTTP3Server, the HTTP/3 listener is not started")
+		return nil, nil
+	}
+
+	if tlsConfig == nil {
+		kp, err := tls.LoadX509KeyPair(o.CertPathTLS, o.KeyPathTLS)
+		if err != nil {
+			return nil, err
+		}
+
+		tlsConfig = &tls.Config{Certificates: []tls.Certificate{kp}}
+	}
+
+	address := o.HTTP3Address
+	if address == "" {
+		address = o.Address
+	}
+
+	if address == "" {
+		address = ":https"
+	}
+
+	_, port, err := net.SplitHostPort(address)
+	if err != nil {
+		return nil, err
+	}
+
+	if port == "https" {
+		port = "443"
+	}
+
+	conn, err := net.ListenPacket("udp", address)
+	if err != nil {
+		return nil, err
+	}
+
+	l := &http3Listener{
+		server:  o.HTTP3Server,
+		conn:    conn,
+		altSvc:  fmt.Sprintf(`h3=":%s"; ma=%d`, port, altSvcMaxAge),
+		serving: 1,
+	}
+
+	log.Infof("HTTP/3 listener on %v", address)
+	go func() {
+		err := l.server.Serve(conn, tlsConfig.Clone(), handler)
+		atomic.StoreInt32(&l.serving, 0)
+		if err != nil && err != http.ErrServerClosed {
+			log.Errorf("Failed to serve HTTP/3: %v", err)
+		}
+	}()
+
+	return l, nil
+}
+
+// advertise wraps the handler of the TLS listener, and sets the Alt-Svc
+// header on the responses, so that the clients supporting HTTP/3 can
+// switch to it. The header is not set anymore, once the HTTP/3 listener
+// stopped serving.
+func (l *http3Listener) advertise(h http.Handler) http.Handler {
+	if l == nil {
+		return h
+	}
+
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if atomic.LoadInt32(&l.serving) == 1 {
+			w.Header().Set("Alt-Svc", l.altSvc)
+		}
+
+		h.ServeHTTP(w, r)
+	})
+}
+
+func (l *http3Listener) close() {
+	if l == nil {
+		return
+	}
+
+	if err := l.server.Close(); err != nil {
+		log.Errorf("Failed to close the HTTP/3 listener: %v", err)
+	}
+
+	// the connection is not owned by the server
+	l.conn.Close()
+}
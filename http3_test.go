@@ -0,0 +1,214 @@
+package skipper
+
+import (
+	"crypto/tls"
+	"errors"
+	"io/ioutil"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"syscall"
+	"testing"
+	"time"
+
+	"github.com/zalando/skipper/dataclients/routestring"
+	"github.com/zalando/skipper/filters/builtin"
+	"github.com/zalando/skipper/proxy"
+	"github.com/zalando/skipper/routing"
+)
+
+type testHTTP3Server struct {
+	addr      chan string
+	tlsConfig *tls.Config
+	handler   http.Handler
+	quit      chan struct{}
+	err       error
+}
+
+func (s *testHTTP3Server) Serve(conn net.PacketConn, tlsConfig *tls.Config, h http.Handler) error {
+	s.tlsConfig, s.handler = tlsConfig, h
+	s.addr <- conn.LocalAddr().String()
+	if s.err != nil {
+		return s.err
+	}
+
+	<-s.quit
+	return http.ErrServerClosed
+}
+
+func (s *testHTTP3Server) Close() error {
+	close(s.quit)
+	return nil
+}
+
+func findUDPAddress() (string, error) {
+	c, err := net.ListenPacket("udp", "127.0.0.1:0")
+	if err != nil {
+		return "", err
+	}
+
+	defer c.Close()
+	return c.LocalAddr().String(), nil
+}
+
+func TestHTTP3Listener(t *testing.T) {
+	a, err := findAddress()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	a3, err := findUDPAddress()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	_, port, _ := net.SplitHostPort(a3)
+
+	h3 := &testHTTP3Server{addr: make(chan string, 1), quit: make(chan struct{})}
+	o := Options{
+		Address:      a,
+		CertPathTLS:  "fixtures/test.crt",
+		KeyPathTLS:   "fixtures/test.key",
+		EnableHTTP3:  true,
+		HTTP3Server:  h3,
+		HTTP3Address: a3,
+	}
+
+	dc, err := routestring.New(`* -> inlineContent("hello") -> <shunt>`)
+	if err != nil {
+		t.Fatalf("Failed to create dataclient: %v", err)
+	}
+
+	rt := routing.New(routing.Options{
+		FilterRegistry: builtin.MakeRegistry(),
+		DataClients:    []routing.DataClient{dc},
+	})
+	defer rt.Close()
+
+	proxy := proxy.New(rt, proxy.OptionsNone)
+	defer proxy.Close()
+
+	sigs := make(chan os.Signal, 1)
+	idleConnsCH := make(chan struct{})
+	done := make(chan error, 1)
+	go func() {
+		done <- listenAndServeQuit(proxy, &o, sigs, idleConnsCH, nil)
+	}()
+
+	select {
+	case addr := <-h3.addr:
+		if addr != a3 {
+			t.Errorf("Failed to listen on the HTTP/3 address, got: %s", addr)
+		}
+	case <-time.After(time.Second):
+		t.Fatal("Failed to start the HTTP/3 listener")
+	}
+
+	if len(h3.tlsConfig.Certificates) != 1 {
+		t.Error("Failed to share the certificates with the HTTP/3 listener")
+	}
+
+	r, err := waitConnGet("https://" + a)
+	if err != nil {
+		t.Fatalf("Cannot connect to the TLS listener: %v", err)
+	}
+
+	r.Body.Close()
+	if v := r.Header.Get("Alt-Svc"); v != `h3=":`+port+`"; ma=86400` {
+		t.Errorf("Failed to advertise HTTP/3, got Alt-Svc: %q", v)
+	}
+
+	w := httptest.NewRecorder()
+	h3.handler.ServeHTTP(w, httptest.NewRequest("GET", "https://www.example.org/", nil))
+	if b, _ := ioutil.ReadAll(w.Body); string(b) != "hello" {
+		t.Errorf("Failed to serve the HTTP/3 request, got: %q", b)
+	}
+
+	if w.Header().Get("Alt-Svc") != "" {
+		t.Error("Failed to serve HTTP/3 without Alt-Svc")
+	}
+
+	sigs <- syscall.SIGTERM
+	select {
+	case err := <-done:
+		if err != nil {
+			t.Errorf("Failed to listen and serve: %v", err)
+		}
+	case <-time.After(time.Second):
+		t.Fatal("Failed to shut down")
+	}
+
+	select {
+	case <-h3.quit:
+	default:
+		t.Error("Failed to close the HTTP/3 listener")
+	}
+}
+
+func TestHTTP3ListenerRequiresServer(t *testing.T) {
+	l, err := listenHTTP3(&Options{EnableHTTP3: true}, nil, nil)
+	if err != nil || l != nil {
+		t.Errorf("Failed to ignore HTTP/3 without server: %v, %v", l, err)
+	}
+}
+
+func TestHTTP3ListenerFailsToListen(t *testing.T) {
+	c, err := net.ListenPacket("udp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	defer c.Close()
+	h3 := &testHTTP3Server{addr: make(chan string, 1), quit: make(chan struct{})}
+	o := &Options{
+		CertPathTLS:  "fixtures/test.crt",
+		KeyPathTLS:   "fixtures/test.key",
+		EnableHTTP3:  true,
+		HTTP3Server:  h3,
+		HTTP3Address: c.LocalAddr().String(),
+	}
+
+	if l, err := listenHTTP3(o, nil, http.NotFoundHandler()); err == nil || l != nil {
+		t.Errorf("Failed to fail listening on a used address: %v, %v", l, err)
+	}
+}
+
+func TestHTTP3ListenerStopsAdvertising(t *testing.T) {
+	a3, err := findUDPAddress()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	h3 := &testHTTP3Server{addr: make(chan string, 1), err: errors.New("test error")}
+	o := &Options{
+		CertPathTLS:  "fixtures/test.crt",
+		KeyPathTLS:   "fixtures/test.key",
+		EnableHTTP3:  true,
+		HTTP3Server:  h3,
+		HTTP3Address: a3,
+	}
+
+	l, err := listenHTTP3(o, nil, http.NotFoundHandler())
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	defer l.conn.Close()
+	<-h3.addr
+	h := l.advertise(http.NotFoundHandler())
+	deadline := time.Now().Add(time.Second)
+	for {
+		w := httptest.NewRecorder()
+		h.ServeHTTP(w, httptest.NewRequest("GET", "https://www.example.org/", nil))
+		if w.Header().Get("Alt-Svc") == "" {
+			return
+		}
+
+		if time.Now().After(deadline) {
+			t.Fatal("Failed to stop advertising the failed HTTP/3 listener")
+		}
+
+		time.Sleep(time.Millisecond)
+	}
+}
@@ -3,6 +3,7 @@ package loadbalancer
 import (
 	"errors"
 	"hash/fnv"
+	"math"
 	"math/rand"
 	"net/url"
 	"sync"
@@ -10,6 +11,7 @@ import (
 
 	log "github.com/sirupsen/logrus"
 	"github.com/zalando/skipper/eskip"
+	"github.com/zalando/skipper/filters"
 	"github.com/zalando/skipper/net"
 	"github.com/zalando/skipper/routing"
 )
@@ -53,12 +55,53 @@ type roundRobin struct {
 	index int
 }
 
+// fadeIn returns the share of its traffic an endpoint receives, growing
+// from 0 to 1 during the fade-in
+func fadeIn(now time.Time, duration time.Duration, exponent float64, detected time.Time) float64 {
+	rel := now.Sub(detected)
+	if rel >= duration {
+		return 1
+	}
+
+	if rel <= 0 {
+		return 0
+	}
+
+	return math.Pow(float64(rel)/float64(duration), exponent)
+}
+
+// withFadeIn returns the chosen endpoint, or when it is fading in, and
+// the dice value is above its current share, the next endpoint that is
+// not fading in. When all the endpoints are fading in, the chosen
+// one is returned.
+func withFadeIn(ctx *routing.LBContext, choice int, dice float64) int {
+	r := ctx.Route
+	if r.LBFadeInDuration <= 0 {
+		return choice
+	}
+
+	now := time.Now()
+	f := fadeIn(now, r.LBFadeInDuration, r.LBFadeInExponent, r.LBEndpoints[choice].Detected)
+	if f >= 1 || dice < f {
+		return choice
+	}
+
+	for i := 1; i < len(r.LBEndpoints); i++ {
+		next := (choice + i) % len(r.LBEndpoints)
+		if fadeIn(now, r.LBFadeInDuration, r.LBFadeInExponent, r.LBEndpoints[next].Detected) >= 1 {
+			return next
+		}
+	}
+
+	return choice
+}
+
 // Apply implements routing.LBAlgorithm with a roundrobin algorithm.
 func (r *roundRobin) Apply(ctx *routing.LBContext) routing.LBEndpoint {
 	r.mx.Lock()
 	defer r.mx.Unlock()
 	r.index = (r.index + 1) % len(ctx.Route.LBEndpoints)
-	return ctx.Route.LBEndpoints[r.index]
+	return ctx.Route.LBEndpoints[withFadeIn(ctx, r.index, rand.Float64())]
 }
 
 type random struct {
@@ -72,7 +115,7 @@ func newRandom(endpoints []string) routing.LBAlgorithm {
 
 // Apply implements routing.LBAlgorithm with a stateless random algorithm.
 func (r *random) Apply(ctx *routing.LBContext) routing.LBEndpoint {
-	return ctx.Route.LBEndpoints[r.rand.Intn(len(ctx.Route.LBEndpoints))]
+	return ctx.Route.LBEndpoints[withFadeIn(ctx, r.rand.Intn(len(ctx.Route.LBEndpoints)), rand.Float64())]
 }
 
 type consistentHash struct{}
@@ -96,18 +139,27 @@ func (*consistentHash) Apply(ctx *routing.LBContext) routing.LBEndpoint {
 	if choice < 0 {
 		choice = len(ctx.Route.LBEndpoints) + choice
 	}
-	return ctx.Route.LBEndpoints[choice]
+
+	// the hash is used for the fade-in, too, so that the same clients
+	// are sent to the new endpoints
+	return ctx.Route.LBEndpoints[withFadeIn(ctx, choice, float64(sum)/math.MaxUint32)]
 }
 
 type (
-	algorithmProvider  struct{}
+	algorithmProvider struct {
+		// the time when the endpoints were detected, the endpoints of
+		// the first routing update are not considered new
+		detected    map[string]time.Time
+		initialized bool
+	}
+
 	initializeAgorithm func(endpoints []string) routing.LBAlgorithm
 )
 
 // NewAlgorithmProvider creates a routing.PostProcessor used to initialize
 // the algorithm of load balancing routes.
 func NewAlgorithmProvider() routing.PostProcessor {
-	return &algorithmProvider{}
+	return &algorithmProvider{detected: make(map[string]time.Time)}
 }
 
 // AlgorithmFromString parses the string representation of the algorithm definition.
@@ -171,8 +223,46 @@ func setAlgorithm(r *routing.Route) error {
 	return nil
 }
 
+// setFadeIn applies the fadeIn and endpointCreated filters of the route,
+// and sets the detection time of the endpoints
+func (p *algorithmProvider) setFadeIn(r *routing.Route, now time.Time, detected map[string]time.Time) {
+	created := make(map[string]time.Time)
+	for _, f := range r.Filters {
+		switch fi := f.Filter.(type) {
+		case filters.FadeIn:
+			r.LBFadeInDuration = fi.Duration
+			r.LBFadeInExponent = fi.Exponent
+		case filters.EndpointCreated:
+			created[fi.Endpoint] = fi.Created
+		}
+	}
+
+	for i := range r.LBEndpoints {
+		e := &r.LBEndpoints[i]
+		key := e.Scheme + "://" + e.Host
+		if c, ok := created[key]; ok {
+			e.Detected = c
+		} else if d, ok := detected[key]; ok {
+			e.Detected = d
+		} else if d, ok := p.detected[key]; ok {
+			e.Detected = d
+		} else if p.initialized {
+			e.Detected = now
+		}
+
+		detected[key] = e.Detected
+	}
+}
+
 // Do implements routing.PostProcessor
 func (p *algorithmProvider) Do(r []*routing.Route) []*routing.Route {
+	now := time.Now()
+	detected := make(map[string]time.Time)
+	defer func() {
+		p.detected = detected
+		p.initialized = true
+	}()
+
 	rr := make([]*routing.Route, 0, len(r))
 	for _, ri := range r {
 		if ri.Route.BackendType != eskip.LBBackend {
@@ -195,6 +285,8 @@ func (p *algorithmProvider) Do(r []*routing.Route) []*routing.Route {
 			continue
 		}
 
+		p.setFadeIn(ri, now, detected)
+
 		rr = append(rr, ri)
 	}
 
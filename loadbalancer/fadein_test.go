@@ -0,0 +1,135 @@
+package loadbalancer
+
+import (
+	"fmt"
+	"net/http"
+	"testing"
+	"time"
+
+	"github.com/zalando/skipper/eskip"
+	"github.com/zalando/skipper/filters/fadein"
+	"github.com/zalando/skipper/routing"
+)
+
+func fadeInRoute(algorithm string, endpoints ...string) *routing.Route {
+	return &routing.Route{
+		Route: eskip.Route{
+			BackendType: eskip.LBBackend,
+			LBAlgorithm: algorithm,
+			LBEndpoints: endpoints,
+		},
+		Filters: []*routing.RouteFilter{{
+			Filter: fadein.FadeIn{Duration: time.Minute, Exponent: 1},
+			Name:   fadein.FadeInName,
+		}},
+	}
+}
+
+func TestFadeInCurve(t *testing.T) {
+	now := time.Now()
+	for _, tt := range []struct {
+		age      time.Duration
+		exponent float64
+		want     float64
+	}{
+		{age: -time.Second, exponent: 1, want: 0},
+		{age: 0, exponent: 1, want: 0},
+		{age: 30 * time.Second, exponent: 1, want: 0.5},
+		{age: 30 * time.Second, exponent: 2, want: 0.25},
+		{age: time.Minute, exponent: 2, want: 1},
+		{age: time.Hour, exponent: 1, want: 1},
+	} {
+		if got := fadeIn(now, time.Minute, tt.exponent, now.Add(-tt.age)); got != tt.want {
+			t.Errorf("unexpected fade-in for age %v and exponent %v: %v, want: %v", tt.age, tt.exponent, got, tt.want)
+		}
+	}
+}
+
+func TestFadeInDetection(t *testing.T) {
+	p := NewAlgorithmProvider()
+
+	// the endpoints of the first update are not new
+	rr := p.Do([]*routing.Route{fadeInRoute("", "http://10.0.0.1:8080", "http://10.0.0.2:8080")})
+	if len(rr) != 1 || rr[0].LBFadeInDuration != time.Minute || rr[0].LBFadeInExponent != 1 {
+		t.Fatal("fade-in not set")
+	}
+
+	for _, e := range rr[0].LBEndpoints {
+		if !e.Detected.IsZero() {
+			t.Errorf("initial endpoint detected as new: %s", e.Host)
+		}
+	}
+
+	before := time.Now()
+	r := fadeInRoute("", "http://10.0.0.1:8080", "http://10.0.0.2:8080", "http://10.0.0.3:8080", "http://10.0.0.4:8080")
+	created := time.Now().Add(-time.Hour)
+	r.Filters = append(r.Filters, &routing.RouteFilter{
+		Filter: fadein.EndpointCreated{Endpoint: "http://10.0.0.4:8080", Created: created},
+		Name:   fadein.EndpointCreatedName,
+	})
+
+	rr = p.Do([]*routing.Route{r})
+	e := rr[0].LBEndpoints
+	if !e[0].Detected.IsZero() || !e[1].Detected.IsZero() {
+		t.Error("known endpoints detected as new")
+	}
+
+	if e[2].Detected.Before(before) {
+		t.Error("new endpoint not detected")
+	}
+
+	if !e[3].Detected.Equal(created) {
+		t.Error("creation time of the endpoint not used")
+	}
+
+	// the detection time is kept in the following updates
+	detected := e[2].Detected
+	rr = p.Do([]*routing.Route{fadeInRoute("", "http://10.0.0.3:8080")})
+	if !rr[0].LBEndpoints[0].Detected.Equal(detected) {
+		t.Error("detection time of the endpoint not kept")
+	}
+}
+
+func TestFadeInDistribution(t *testing.T) {
+	for _, algorithm := range []string{"roundRobin", "random", "consistentHash"} {
+		t.Run(algorithm, func(t *testing.T) {
+			p := NewAlgorithmProvider()
+			p.Do([]*routing.Route{fadeInRoute(algorithm, "http://10.0.0.1:8080", "http://10.0.0.2:8080")})
+			rr := p.Do([]*routing.Route{fadeInRoute(algorithm, "http://10.0.0.1:8080", "http://10.0.0.2:8080", "http://10.0.0.3:8080")})
+			r := rr[0]
+
+			// the new endpoint was detected 15 seconds ago, and receives
+			// a quarter of its full share
+			r.LBEndpoints[2].Detected = time.Now().Add(-15 * time.Second)
+
+			const n = 30000
+			counts := make(map[string]int)
+			for i := 0; i < n; i++ {
+				req := &http.Request{RemoteAddr: fmt.Sprintf("10.1.%d.%d:1234", i/250, i%250)}
+				e := r.LBAlgorithm.Apply(routing.NewLBContext(req, r))
+				counts[e.Host]++
+			}
+
+			share := float64(counts["10.0.0.3:8080"]) / n
+			if share < 0.04 || share > 0.14 {
+				t.Errorf("unexpected share of the new endpoint: %v, counts: %v", share, counts)
+			}
+		})
+	}
+}
+
+func TestFadeInAllEndpointsNew(t *testing.T) {
+	p := NewAlgorithmProvider()
+	p.Do(nil)
+	rr := p.Do([]*routing.Route{fadeInRoute("roundRobin", "http://10.0.0.1:8080", "http://10.0.0.2:8080")})
+	r := rr[0]
+
+	counts := make(map[string]int)
+	for i := 0; i < 100; i++ {
+		counts[r.LBAlgorithm.Apply(routing.NewLBContext(&http.Request{}, r)).Host]++
+	}
+
+	if counts["10.0.0.1:8080"] != 50 || counts["10.0.0.2:8080"] != 50 {
+		t.Errorf("traffic not distributed evenly between new endpoints: %v", counts)
+	}
+}
@@ -88,6 +88,10 @@ func (a *All) MeasureBackend5xx(t time.Time) {
 	a.codaHale.MeasureBackend5xx(t)
 
 }
+func (a *All) MeasureGRPCResponse(grpcStatus string, routeId string, start time.Time) {
+	a.prometheus.MeasureGRPCResponse(grpcStatus, routeId, start)
+	a.codaHale.MeasureGRPCResponse(grpcStatus, routeId, start)
+}
 func (a *All) IncErrorsStreaming(routeId string) {
 	a.prometheus.IncErrorsStreaming(routeId)
 	a.codaHale.IncErrorsStreaming(routeId)
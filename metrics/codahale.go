@@ -27,6 +27,7 @@ const (
 	KeyResponseCombined           = "all.response.%d.%s.skipper"
 	KeyServeRoute                 = "serveroute.%s.%s.%d"
 	KeyServeHost                  = "servehost.%s.%s.%d"
+	KeyGRPCResponse               = "grpcresponse.%s.skipper.%s"
 	Key5xxsBackend                = "all.backend.5xx"
 
 	KeyErrorsBackend   = "errors.backend.%s"
@@ -193,6 +194,12 @@ func (c *CodaHale) MeasureServe(routeId, host, method string, code int, start ti
 	}
 }
 
+func (c *CodaHale) MeasureGRPCResponse(grpcStatus string, routeId string, start time.Time) {
+	if c.options.EnableGRPCStatusMetrics {
+		c.measureSince(fmt.Sprintf(KeyGRPCResponse, grpcStatus, routeId), start)
+	}
+}
+
 func (c *CodaHale) getCounter(key string) metrics.Counter {
 	return c.reg.GetOrRegister(key, c.createCounter).(metrics.Counter)
 }
@@ -68,6 +68,7 @@ type Metrics interface {
 	MeasureAllFiltersResponse(routeId string, start time.Time)
 	MeasureResponse(code int, method string, routeId string, start time.Time)
 	MeasureServe(routeId, host, method string, code int, start time.Time)
+	MeasureGRPCResponse(grpcStatus string, routeId string, start time.Time)
 	IncRoutingFailures()
 	IncErrorsBackend(routeId string)
 	MeasureBackend5xx(t time.Time)
@@ -119,6 +120,11 @@ type Options struct {
 	// it is enabled by default.
 	EnableRouteResponseMetrics bool
 
+	// EnableGRPCStatusMetrics enables collecting response time
+	// metrics of gRPC responses per each route, grouped by the
+	// grpc-status of the response.
+	EnableGRPCStatusMetrics bool
+
 	// EnableRouteBackendErrorsCounters enables counters for backend
 	// errors per each route. Without the DisableCompatibilityDefaults,
 	// it is enabled by default.
@@ -144,6 +144,10 @@ func (*MockMetrics) MeasureServe(routeId, host, method string, code int, start t
 	panic("implement me")
 }
 
+func (*MockMetrics) MeasureGRPCResponse(grpcStatus string, routeId string, start time.Time) {
+	panic("implement me")
+}
+
 func (*MockMetrics) IncRoutingFailures() {
 	panic("implement me")
 }
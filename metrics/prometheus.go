@@ -18,6 +18,7 @@ const (
 	promStreamingSubsystem = "streaming"
 	promResponseSubsystem  = "response"
 	promServeSubsystem     = "serve"
+	promGRPCSubsystem      = "grpc"
 	promCustomSubsystem    = "custom"
 )
 
@@ -27,6 +28,7 @@ type Prometheus struct {
 	routeLookupM               *prometheus.HistogramVec
 	routeErrorsM               *prometheus.CounterVec
 	responseM                  *prometheus.HistogramVec
+	grpcResponseM              *prometheus.HistogramVec
 	filterRequestM             *prometheus.HistogramVec
 	filterAllRequestM          *prometheus.HistogramVec
 	filterAllCombinedRequestM  *prometheus.HistogramVec
@@ -81,6 +83,14 @@ func NewPrometheus(opts Options) *Prometheus {
 		Buckets:   opts.HistogramBuckets,
 	}, []string{"code", "method", "route"})
 
+	grpcResponse := prometheus.NewHistogramVec(prometheus.HistogramOpts{
+		Namespace: namespace,
+		Subsystem: promGRPCSubsystem,
+		Name:      "response_duration_seconds",
+		Help:      "Duration in seconds of a gRPC response.",
+		Buckets:   opts.HistogramBuckets,
+	}, []string{"grpc_status", "route"})
+
 	filterRequest := prometheus.NewHistogramVec(prometheus.HistogramOpts{
 		Namespace: namespace,
 		Subsystem: promFilterSubsystem,
@@ -205,6 +215,7 @@ func NewPrometheus(opts Options) *Prometheus {
 		routeLookupM:               routeLookup,
 		routeErrorsM:               routeErrors,
 		responseM:                  response,
+		grpcResponseM:              grpcResponse,
 		filterRequestM:             filterRequest,
 		filterAllRequestM:          filterAllRequest,
 		filterAllCombinedRequestM:  filterAllCombinedRequest,
@@ -239,6 +250,7 @@ func (p *Prometheus) sinceS(start time.Time) float64 {
 func (p *Prometheus) registerMetrics() {
 	p.registry.MustRegister(p.routeLookupM)
 	p.registry.MustRegister(p.responseM)
+	p.registry.MustRegister(p.grpcResponseM)
 	p.registry.MustRegister(p.routeErrorsM)
 	p.registry.MustRegister(p.filterRequestM)
 	p.registry.MustRegister(p.filterAllRequestM)
@@ -389,6 +401,13 @@ func (p *Prometheus) MeasureServe(routeID, host, method string, code int, start
 	}
 }
 
+// MeasureGRPCResponse satisfies Metrics interface.
+func (p *Prometheus) MeasureGRPCResponse(grpcStatus string, routeID string, start time.Time) {
+	if p.opts.EnableGRPCStatusMetrics {
+		p.grpcResponseM.WithLabelValues(grpcStatus, routeID).Observe(p.sinceS(start))
+	}
+}
+
 // IncRoutingFailures satisfies Metrics interface.
 func (p *Prometheus) IncRoutingFailures() {
 	p.routeErrorsM.WithLabelValues().Inc()
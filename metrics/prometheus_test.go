@@ -513,6 +513,25 @@ func TestPrometheusMetrics(t *testing.T) {
 			},
 			expCode: http.StatusOK,
 		},
+		{
+			name: "Measuring gRPC responses, should measure responses latency by grpc status.",
+			opts: metrics.Options{
+				EnableGRPCStatusMetrics: true,
+			},
+			addMetrics: func(pm *metrics.Prometheus) {
+				pm.MeasureGRPCResponse("0", "route1", time.Now().Add(-15*time.Millisecond))
+				pm.MeasureGRPCResponse("14", "route1", time.Now().Add(-3*time.Millisecond))
+			},
+			expMetrics: []string{
+				`skipper_grpc_response_duration_seconds_bucket{grpc_status="0",route="route1",le="0.01"} 0`,
+				`skipper_grpc_response_duration_seconds_bucket{grpc_status="0",route="route1",le="0.025"} 1`,
+				`skipper_grpc_response_duration_seconds_count{grpc_status="0",route="route1"} 1`,
+				`skipper_grpc_response_duration_seconds_bucket{grpc_status="14",route="route1",le="0.005"} 1`,
+				`skipper_grpc_response_duration_seconds_sum{grpc_status="14",route="route1"} 0.003`,
+				`skipper_grpc_response_duration_seconds_count{grpc_status="14",route="route1"} 1`,
+			},
+			expCode: http.StatusOK,
+		},
 		{
 			name: "Measuring all serves by the hosts splitted by route, only should measure served latency by route.",
 			opts: metrics.Options{
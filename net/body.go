@@ -0,0 +1,72 @@
+package net
+
+import (
+	"bytes"
+	"io"
+	"io/ioutil"
+)
+
+type multiReadCloser struct {
+	io.Reader
+	closer io.Closer
+}
+
+func (m *multiReadCloser) Close() error { return m.closer.Close() }
+
+// ReadBody reads a request or response body up to the limit. When the body
+// is not longer than the limit, it is closed, and its content is returned
+// with a nil remainder.
+//
+// When the body is longer than the limit, or reading it failed, the
+// returned remainder replays the bytes read so far, followed by the rest
+// of the body, so that the body can be forwarded unchanged. A read error
+// is returned, too.
+func ReadBody(body io.ReadCloser, limit int64) ([]byte, io.ReadCloser, error) {
+	// reading one more byte than the limit tells if the body is larger
+	b, err := ioutil.ReadAll(io.LimitReader(body, limit+1))
+	if err != nil || int64(len(b)) > limit {
+		return b, &multiReadCloser{
+			Reader: io.MultiReader(bytes.NewReader(b), body),
+			closer: body,
+		}, err
+	}
+
+	body.Close()
+	return b, nil, nil
+}
+
+type limitBody struct {
+	body      io.ReadCloser
+	remaining int64
+	err       error
+}
+
+// LimitBody returns a body that fails with the passed in error, once more
+// than limit bytes were read from it. Unlike io.LimitReader, it doesn't
+// truncate the longer bodies silently.
+func LimitBody(body io.ReadCloser, limit int64, err error) io.ReadCloser {
+	return &limitBody{body: body, remaining: limit, err: err}
+}
+
+func (b *limitBody) Read(p []byte) (int, error) {
+	if b.remaining < 0 {
+		return 0, b.err
+	}
+
+	// reading one byte more than the limit tells whether it was exceeded
+	if int64(len(p)) > b.remaining+1 {
+		p = p[:b.remaining+1]
+	}
+
+	n, err := b.body.Read(p)
+	b.remaining -= int64(n)
+	if b.remaining < 0 {
+		return 0, b.err
+	}
+
+	return n, err
+}
+
+func (b *limitBody) Close() error {
+	return b.body.Close()
+}
@@ -0,0 +1,131 @@
+package net
+
+import (
+	"errors"
+	"io/ioutil"
+	"strings"
+	"testing"
+	"testing/iotest"
+)
+
+type testBody struct {
+	*strings.Reader
+	closed bool
+}
+
+func (b *testBody) Close() error {
+	b.closed = true
+	return nil
+}
+
+func TestReadBody(t *testing.T) {
+	for _, ti := range []struct {
+		msg      string
+		body     string
+		limit    int64
+		complete bool
+	}{{
+		msg:      "empty",
+		limit:    3,
+		complete: true,
+	}, {
+		msg:      "within the limit",
+		body:     "foo",
+		limit:    4,
+		complete: true,
+	}, {
+		msg:      "equal to the limit",
+		body:     "foo",
+		limit:    3,
+		complete: true,
+	}, {
+		msg:   "exceeding the limit",
+		body:  "foobar",
+		limit: 3,
+	}} {
+		t.Run(ti.msg, func(t *testing.T) {
+			body := &testBody{Reader: strings.NewReader(ti.body)}
+			b, rest, err := ReadBody(body, ti.limit)
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			if ti.complete {
+				if rest != nil || string(b) != ti.body || !body.closed {
+					t.Errorf("body not read completely: %q, %v, closed: %t", b, rest, body.closed)
+				}
+
+				return
+			}
+
+			if rest == nil {
+				t.Fatal("body larger than the limit read completely")
+			}
+
+			all, err := ioutil.ReadAll(rest)
+			if err != nil || string(all) != ti.body {
+				t.Errorf("body not replayed: %q, %v", all, err)
+			}
+
+			rest.Close()
+			if !body.closed {
+				t.Error("body not closed")
+			}
+		})
+	}
+}
+
+func TestReadBodyFailed(t *testing.T) {
+	errTest := errors.New("test error")
+	body := ioutil.NopCloser(iotest.TimeoutReader(strings.NewReader("foobar")))
+	_, rest, err := ReadBody(body, 12)
+	if err == nil || rest == nil {
+		t.Fatalf("failed to fail: %v, %v", err, rest)
+	}
+
+	body = ioutil.NopCloser(iotest.ErrReader(errTest))
+	if _, rest, err = ReadBody(body, 12); err != errTest || rest == nil {
+		t.Errorf("failed to fail: %v, %v", err, rest)
+	}
+}
+
+func TestLimitBody(t *testing.T) {
+	errTest := errors.New("too large")
+	for _, ti := range []struct {
+		msg      string
+		body     string
+		limit    int64
+		expected error
+	}{{
+		msg:   "within the limit",
+		body:  "foo",
+		limit: 4,
+	}, {
+		msg:   "equal to the limit",
+		body:  "foobar",
+		limit: 6,
+	}, {
+		msg:      "exceeding the limit",
+		body:     strings.Repeat("foo", 20000),
+		limit:    50000,
+		expected: errTest,
+	}} {
+		t.Run(ti.msg, func(t *testing.T) {
+			body := &testBody{Reader: strings.NewReader(ti.body)}
+			l := LimitBody(body, ti.limit, errTest)
+			b, err := ioutil.ReadAll(l)
+			if err != ti.expected {
+				t.Fatalf("expected error: %v, got: %v", ti.expected, err)
+			}
+
+			if err == nil && string(b) != ti.body {
+				t.Errorf("unexpected body: %s", b)
+			}
+
+			l.Close()
+			if !body.closed {
+				t.Error("body not closed")
+			}
+		})
+	}
+}
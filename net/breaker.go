@@ -0,0 +1,33 @@
+package net
+
+import (
+	"errors"
+	"net/http"
+
+	"github.com/zalando/skipper/circuit"
+)
+
+// ErrCircuitOpen is returned for requests to a host, which circuit
+// breaker is open, see Options.CircuitBreakers.
+var ErrCircuitOpen = errors.New("circuit breaker open")
+
+func newBreakerRegistry(o Options) *circuit.Registry {
+	if len(o.CircuitBreakers) == 0 {
+		return nil
+	}
+	return circuit.NewRegistry(o.CircuitBreakers...)
+}
+
+// checkBreaker returns the callback to report the outcome of the
+// request and false, if the breaker of the request host is open.
+func (t *Transport) checkBreaker(req *http.Request) (func(bool), bool) {
+	if t.breakers == nil {
+		return nil, true
+	}
+
+	b := t.breakers.Get(circuit.BreakerSettings{Host: req.URL.Host})
+	if b == nil {
+		return nil, true
+	}
+	return b.Allow()
+}
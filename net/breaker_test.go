@@ -0,0 +1,92 @@
+package net
+
+import (
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/zalando/skipper/circuit"
+)
+
+func TestCircuitBreakers(t *testing.T) {
+	var failingRequests int32
+	failing := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		atomic.AddInt32(&failingRequests, 1)
+		w.WriteHeader(http.StatusInternalServerError)
+	}))
+	defer failing.Close()
+
+	healthy := startTestServer(func(*http.Request) {})
+	defer healthy.Close()
+
+	m := newTestMetricsCollector()
+	cli := NewClient(Options{
+		MetricsCollector: m,
+		CircuitBreakers: []circuit.BreakerSettings{{
+			Type:     circuit.ConsecutiveFailures,
+			Failures: 2,
+			Timeout:  time.Minute,
+		}},
+	})
+	defer cli.Close()
+
+	for i := 0; i < 2; i++ {
+		rsp, err := cli.Get(failing.URL)
+		if err != nil {
+			t.Fatalf("Failed to do request: %v", err)
+		}
+		rsp.Body.Close()
+	}
+
+	_, err := cli.Get(failing.URL)
+	if !errors.Is(err, ErrCircuitOpen) {
+		t.Fatalf("Failed to get open circuit: %v", err)
+	}
+	if ClassifyError(err) != ErrorClassCircuitOpen || m.errorCount(ErrorClassCircuitOpen) != 1 {
+		t.Errorf("Failed to classify open circuit: %v", err)
+	}
+	if n := atomic.LoadInt32(&failingRequests); n != 2 {
+		t.Errorf("Failed to not send requests with open circuit: %d", n)
+	}
+
+	rsp, err := cli.Get(healthy.URL)
+	if err != nil {
+		t.Fatalf("Failed to do request to other host: %v", err)
+	}
+	rsp.Body.Close()
+}
+
+func TestCircuitBreakersHostSettings(t *testing.T) {
+	s := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusServiceUnavailable)
+	}))
+	defer s.Close()
+
+	rt := NewTransport(Options{
+		CircuitBreakers: []circuit.BreakerSettings{{
+			Type:     circuit.ConsecutiveFailures,
+			Failures: 100,
+		}, {
+			Host:     s.Listener.Addr().String(),
+			Type:     circuit.FailureRate,
+			Window:   2,
+			Failures: 1,
+			Timeout:  time.Minute,
+		}},
+	})
+	defer rt.Close()
+
+	req, _ := http.NewRequest("GET", s.URL, nil)
+	rsp, err := rt.RoundTrip(req)
+	if err != nil {
+		t.Fatalf("Failed to do request: %v", err)
+	}
+	rsp.Body.Close()
+
+	if _, err := rt.RoundTrip(req); err != ErrCircuitOpen {
+		t.Errorf("Failed to open the circuit by the host settings: %v", err)
+	}
+}
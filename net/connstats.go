@@ -0,0 +1,112 @@
+package net
+
+import (
+	"context"
+	"net"
+	"net/http"
+	"net/http/httptrace"
+	"sync"
+	"sync/atomic"
+)
+
+// TransportStats is a snapshot of the connection pool of a Transport,
+// see Transport.Stats. It includes the connection pools of
+// Options.PerHostOptions.
+type TransportStats struct {
+	// OpenConns is the number of open connections.
+	OpenConns int64
+	// ActiveRequests is the number of requests, that were sent and
+	// which response body is not closed, yet.
+	ActiveRequests int64
+	// IdleConns is the number of open connections not used by an
+	// active request. It is exact for HTTP/1.1 and an estimate for
+	// HTTP/2, that multiplexes requests over a connection.
+	IdleConns int64
+	// Dials is the total number of successful dials.
+	Dials int64
+	// DialErrors is the total number of failed dials.
+	DialErrors int64
+	// Requests is the total number of requests, that got a
+	// connection.
+	Requests int64
+	// ReusedConns is the total number of requests, that got a
+	// connection from the pool.
+	ReusedConns int64
+	// ReuseRate is ReusedConns divided by Requests.
+	ReuseRate float64
+}
+
+type connStats struct {
+	openConns      int64
+	activeRequests int64
+	dials          int64
+	dialErrors     int64
+	requests       int64
+	reusedConns    int64
+}
+
+// Stats returns a snapshot of the connection pool statistics, e.g. to
+// monitor the connection pool health of internal clients.
+func (t *Transport) Stats() TransportStats {
+	s := TransportStats{
+		OpenConns:      atomic.LoadInt64(&t.stats.openConns),
+		ActiveRequests: atomic.LoadInt64(&t.stats.activeRequests),
+		Dials:          atomic.LoadInt64(&t.stats.dials),
+		DialErrors:     atomic.LoadInt64(&t.stats.dialErrors),
+		Requests:       atomic.LoadInt64(&t.stats.requests),
+		ReusedConns:    atomic.LoadInt64(&t.stats.reusedConns),
+	}
+	if s.OpenConns > s.ActiveRequests {
+		s.IdleConns = s.OpenConns - s.ActiveRequests
+	}
+	if s.Requests > 0 {
+		s.ReuseRate = float64(s.ReusedConns) / float64(s.Requests)
+	}
+	return s
+}
+
+func (cs *connStats) dialContext(dial dialFunc) dialFunc {
+	return func(ctx context.Context, network, address string) (net.Conn, error) {
+		c, err := dial(ctx, network, address)
+		if err != nil {
+			atomic.AddInt64(&cs.dialErrors, 1)
+			return nil, err
+		}
+
+		atomic.AddInt64(&cs.dials, 1)
+		atomic.AddInt64(&cs.openConns, 1)
+		return &statsConn{Conn: c, stats: cs}, nil
+	}
+}
+
+// startRequest counts the request as active and the connection it
+// gets. The returned func marks the request as done.
+func (cs *connStats) startRequest(req *http.Request) (*http.Request, func()) {
+	atomic.AddInt64(&cs.activeRequests, 1)
+	trace := &httptrace.ClientTrace{
+		GotConn: func(info httptrace.GotConnInfo) {
+			atomic.AddInt64(&cs.requests, 1)
+			if info.Reused {
+				atomic.AddInt64(&cs.reusedConns, 1)
+			}
+		},
+	}
+
+	var once sync.Once
+	done := func() {
+		once.Do(func() { atomic.AddInt64(&cs.activeRequests, -1) })
+	}
+	return req.WithContext(httptrace.WithClientTrace(req.Context(), trace)), done
+}
+
+// statsConn counts the connection as closed once.
+type statsConn struct {
+	net.Conn
+	stats *connStats
+	once  sync.Once
+}
+
+func (c *statsConn) Close() error {
+	c.once.Do(func() { atomic.AddInt64(&c.stats.openConns, -1) })
+	return c.Conn.Close()
+}
@@ -0,0 +1,68 @@
+package net
+
+import (
+	"io/ioutil"
+	"net/http"
+	"testing"
+)
+
+func TestTransportStats(t *testing.T) {
+	s := startTestServer(func(*http.Request) {})
+	defer s.Close()
+
+	cli := NewClient(Options{})
+	defer cli.Close()
+
+	rsp, err := cli.Get(s.URL)
+	if err != nil {
+		t.Fatalf("Failed to do request: %v", err)
+	}
+
+	stats := cli.Stats().Transport
+	if stats.OpenConns != 1 || stats.ActiveRequests != 1 || stats.IdleConns != 0 || stats.Dials != 1 {
+		t.Errorf("Failed to get stats of an active request: %+v", stats)
+	}
+
+	ioutil.ReadAll(rsp.Body)
+	rsp.Body.Close()
+
+	rsp, err = cli.Get(s.URL)
+	if err != nil {
+		t.Fatalf("Failed to do request: %v", err)
+	}
+	ioutil.ReadAll(rsp.Body)
+	rsp.Body.Close()
+
+	stats = cli.Stats().Transport
+	want := TransportStats{
+		OpenConns:   1,
+		IdleConns:   1,
+		Dials:       1,
+		Requests:    2,
+		ReusedConns: 1,
+		ReuseRate:   0.5,
+	}
+	if stats != want {
+		t.Errorf("Failed to get stats: got %+v, want %+v", stats, want)
+	}
+
+	cli.CloseIdleConnections()
+	if stats := cli.Stats().Transport; stats.OpenConns != 0 || stats.IdleConns != 0 {
+		t.Errorf("Failed to count closed connections: %+v", stats)
+	}
+}
+
+func TestTransportStatsDialError(t *testing.T) {
+	rt := NewTransport(Options{})
+	defer rt.Close()
+
+	req, _ := http.NewRequest("GET", "http://127.0.0.1:1/", nil)
+	if _, err := rt.RoundTrip(req); err == nil {
+		t.Fatal("Failed to get an error")
+	}
+
+	stats := rt.Stats()
+	if stats.DialErrors != 1 || stats.Dials != 0 || stats.ActiveRequests != 0 || stats.OpenConns != 0 {
+		t.Errorf("Failed to count dial error: %+v", stats)
+	}
+}
@@ -0,0 +1,105 @@
+package net
+
+import (
+	"context"
+	"net/http"
+
+	"github.com/opentracing/opentracing-go"
+	"github.com/zalando/skipper/filters/flowid"
+)
+
+const (
+	defaultCorrelationIDLength = 16
+	correlationIDBaggageKey    = "correlation_id"
+)
+
+var correlationIDGenerator, _ = flowid.NewStandardGenerator(defaultCorrelationIDLength)
+
+type correlationKey struct{}
+
+// Correlation ties together all outbound requests sent with the same
+// context. It is propagated by the Transport with every request:
+//
+// - the ID is sent in the Options.CorrelationIDHeader, default
+// X-Flow-Id, and as span baggage item "correlation_id",
+//
+// - every baggage item is set as span baggage item with the same key
+// and, if the key is mapped in Options.CorrelationBaggageHeaders, sent
+// in the mapped header.
+//
+// Headers already set on the request are not overwritten.
+type Correlation struct {
+	ID      string
+	Baggage map[string]string
+}
+
+// WithCorrelation returns a copy of ctx carrying the given
+// Correlation.
+func WithCorrelation(ctx context.Context, c Correlation) context.Context {
+	return context.WithValue(ctx, correlationKey{}, c)
+}
+
+// CorrelationFromContext returns the Correlation stored in ctx and if
+// it was found.
+func CorrelationFromContext(ctx context.Context) (Correlation, bool) {
+	c, ok := ctx.Value(correlationKey{}).(Correlation)
+	return c, ok
+}
+
+// SeedCorrelation establishes a Correlation with a generated ID and
+// the given baggage on ctx, typically at the start of handling an
+// incoming request. If ctx already carries a Correlation, it is kept
+// and the given baggage is added to a copy of its baggage.
+func SeedCorrelation(ctx context.Context, baggage map[string]string) (context.Context, Correlation) {
+	c, ok := CorrelationFromContext(ctx)
+	if !ok || c.ID == "" {
+		c.ID = correlationIDGenerator.MustGenerate()
+	}
+
+	b := make(map[string]string, len(c.Baggage)+len(baggage))
+	for k, v := range c.Baggage {
+		b[k] = v
+	}
+	for k, v := range baggage {
+		b[k] = v
+	}
+	c.Baggage = b
+
+	return WithCorrelation(ctx, c), c
+}
+
+// setCorrelationHeaders sets the headers of the Correlation found in
+// the request context.
+func (t *Transport) setCorrelationHeaders(req *http.Request) {
+	c, ok := CorrelationFromContext(req.Context())
+	if !ok {
+		return
+	}
+
+	if c.ID != "" && req.Header.Get(t.correlationIDHeader) == "" {
+		req.Header.Set(t.correlationIDHeader, c.ID)
+	}
+
+	for k, h := range t.correlationBaggageHeaders {
+		if v, ok := c.Baggage[k]; ok && req.Header.Get(h) == "" {
+			req.Header.Set(h, v)
+		}
+	}
+}
+
+// setCorrelationBaggage sets the Correlation found in the request
+// context as span baggage, before the span is injected into the
+// request.
+func setCorrelationBaggage(req *http.Request, span opentracing.Span) {
+	c, ok := CorrelationFromContext(req.Context())
+	if !ok {
+		return
+	}
+
+	if c.ID != "" {
+		span.SetBaggageItem(correlationIDBaggageKey, c.ID)
+	}
+	for k, v := range c.Baggage {
+		span.SetBaggageItem(k, v)
+	}
+}
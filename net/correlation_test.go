@@ -0,0 +1,94 @@
+package net
+
+import (
+	"context"
+	"net/http"
+	"testing"
+
+	"github.com/zalando/skipper/tracing/tracingtest"
+)
+
+func TestSeedCorrelation(t *testing.T) {
+	ctx, c := SeedCorrelation(context.Background(), map[string]string{"tenant": "foo"})
+	if c.ID == "" || c.Baggage["tenant"] != "foo" {
+		t.Fatalf("Failed to seed correlation: %v", c)
+	}
+
+	ctx, c2 := SeedCorrelation(ctx, map[string]string{"user": "bar"})
+	if c2.ID != c.ID {
+		t.Errorf("Failed to keep the correlation ID: got %s, want %s", c2.ID, c.ID)
+	}
+	if c2.Baggage["tenant"] != "foo" || c2.Baggage["user"] != "bar" {
+		t.Errorf("Failed to merge baggage: %v", c2.Baggage)
+	}
+	if _, ok := c.Baggage["user"]; ok {
+		t.Error("Failed to not modify the baggage of the parent correlation")
+	}
+
+	if got, ok := CorrelationFromContext(ctx); !ok || got.ID != c.ID {
+		t.Errorf("Failed to get correlation from context: %v", got)
+	}
+}
+
+func TestCorrelationPropagation(t *testing.T) {
+	var ids []string
+	s := startTestServer(func(r *http.Request) {
+		ids = append(ids, r.Header.Get("X-Flow-Id"))
+		if got := r.Header.Get("X-Tenant"); got != "foo" {
+			t.Errorf("Failed to propagate baggage header: %q", got)
+		}
+		if got := r.Header.Get("X-User"); got != "" {
+			t.Errorf("Failed to not propagate unmapped baggage as header: %q", got)
+		}
+	})
+	defer s.Close()
+
+	tracer := &tracingtest.Tracer{}
+	cli := NewClient(Options{
+		Tracer:                    tracer,
+		OpentracingSpanName:       "myspan",
+		CorrelationBaggageHeaders: map[string]string{"tenant": "X-Tenant"},
+	})
+	defer cli.Close()
+
+	ctx, c := SeedCorrelation(context.Background(), map[string]string{"tenant": "foo", "user": "bar"})
+	for i := 0; i < 2; i++ {
+		req, _ := http.NewRequest("GET", s.URL, nil)
+		rsp, err := cli.Do(req.WithContext(ctx))
+		if err != nil {
+			t.Fatalf("Failed to do request: %v", err)
+		}
+		rsp.Body.Close()
+	}
+
+	if len(ids) != 2 || ids[0] != c.ID || ids[1] != c.ID {
+		t.Errorf("Failed to propagate the correlation ID %s: %v", c.ID, ids)
+	}
+
+	for _, span := range tracer.FindAllSpans("myspan") {
+		if span.BaggageItem(correlationIDBaggageKey) != c.ID || span.BaggageItem("user") != "bar" {
+			t.Errorf("Failed to set span baggage")
+		}
+	}
+}
+
+func TestCorrelationKeepsExplicitHeader(t *testing.T) {
+	s := startTestServer(func(r *http.Request) {
+		if got := r.Header.Get("X-Flow-Id"); got != "explicit" {
+			t.Errorf("Failed to keep the explicit header: %q", got)
+		}
+	})
+	defer s.Close()
+
+	cli := NewClient(Options{})
+	defer cli.Close()
+
+	ctx, _ := SeedCorrelation(context.Background(), nil)
+	req, _ := http.NewRequest("GET", s.URL, nil)
+	req.Header.Set("X-Flow-Id", "explicit")
+	rsp, err := cli.Do(req.WithContext(ctx))
+	if err != nil {
+		t.Fatalf("Failed to do request: %v", err)
+	}
+	rsp.Body.Close()
+}
@@ -0,0 +1,154 @@
+package net
+
+import (
+	"context"
+	"net"
+	"time"
+)
+
+// IPFamily selects the address family of the connections, see
+// Options.IPFamily.
+type IPFamily int
+
+const (
+	// IPFamilyAny dials IPv4 and IPv6 addresses with the Happy
+	// Eyeballs algorithm of the net.Dialer, see
+	// Options.FallbackDelay.
+	IPFamilyAny IPFamily = iota
+
+	// IPFamilyIPv4 dials only IPv4 addresses.
+	IPFamilyIPv4
+
+	// IPFamilyIPv6 dials only IPv6 addresses.
+	IPFamilyIPv6
+
+	// IPFamilyPreferIPv4 dials the IPv4 addresses first and the IPv6
+	// addresses only, if that fails.
+	IPFamilyPreferIPv4
+
+	// IPFamilyPreferIPv6 dials the IPv6 addresses first and the IPv4
+	// addresses only, if that fails.
+	IPFamilyPreferIPv6
+)
+
+// withIPFamily restricts or orders the address families dialed by
+// TCP networks.
+func withIPFamily(dial dialFunc, family IPFamily) dialFunc {
+	var first, second string
+	switch family {
+	case IPFamilyIPv4:
+		first = "4"
+	case IPFamilyIPv6:
+		first = "6"
+	case IPFamilyPreferIPv4:
+		first, second = "4", "6"
+	case IPFamilyPreferIPv6:
+		first, second = "6", "4"
+	default:
+		return dial
+	}
+
+	return func(ctx context.Context, network, address string) (net.Conn, error) {
+		if network != "tcp" {
+			return dial(ctx, network, address)
+		}
+
+		conn, err := dial(ctx, network+first, address)
+		if err == nil || second == "" || ctx.Err() != nil {
+			return conn, err
+		}
+		return dial(ctx, network+second, address)
+	}
+}
+
+// Resolver resolves a hostname to its IP addresses, e.g. to integrate
+// a service discovery. *net.Resolver implements it.
+type Resolver interface {
+	LookupHost(ctx context.Context, host string) ([]string, error)
+}
+
+// hostResolver resolves hostnames by Options.StaticHosts first and
+// then by Options.Resolver.
+type hostResolver struct {
+	static   map[string][]string
+	resolver Resolver
+}
+
+func newHostResolver(o Options) *hostResolver {
+	if len(o.StaticHosts) == 0 && o.Resolver == nil {
+		return nil
+	}
+	return &hostResolver{static: o.StaticHosts, resolver: o.Resolver}
+}
+
+func (r *hostResolver) lookup(ctx context.Context, host string) ([]string, bool, error) {
+	if ips, ok := r.static[host]; ok {
+		return ips, true, nil
+	}
+	if r.resolver == nil {
+		return nil, false, nil
+	}
+
+	ips, err := r.resolver.LookupHost(ctx, host)
+	if err != nil {
+		return nil, true, err
+	}
+	if len(ips) == 0 {
+		return nil, true, &net.DNSError{Err: "no such host", Name: host, IsNotFound: true}
+	}
+	return ips, true, nil
+}
+
+// dialContext resolves the host of the address and dials the
+// resolved IPs in order, until one succeeds. Addresses, that are not
+// resolved, are passed to dial unchanged.
+func (r *hostResolver) dialContext(dial dialFunc) dialFunc {
+	return func(ctx context.Context, network, address string) (net.Conn, error) {
+		host, port, err := net.SplitHostPort(address)
+		if err != nil || net.ParseIP(host) != nil {
+			return dial(ctx, network, address)
+		}
+
+		ips, ok, err := r.lookup(ctx, host)
+		if !ok {
+			return dial(ctx, network, address)
+		}
+		if err != nil {
+			return nil, &net.OpError{Op: "dial", Net: network, Err: err}
+		}
+
+		for _, ip := range ips {
+			var conn net.Conn
+			conn, err = dial(ctx, network, net.JoinHostPort(ip, port))
+			if err == nil {
+				return conn, nil
+			}
+			if ctx.Err() != nil {
+				break
+			}
+		}
+		return nil, err
+	}
+}
+
+// withDialTimeout bounds a custom dial func by the timeout.
+func withDialTimeout(dial dialFunc, timeout time.Duration) dialFunc {
+	if timeout <= 0 {
+		return dial
+	}
+
+	return func(ctx context.Context, network, address string) (net.Conn, error) {
+		ctx, cancel := context.WithTimeout(ctx, timeout)
+		defer cancel()
+		return dial(ctx, network, address)
+	}
+}
+
+// dialUnix returns a dial func, that connects to the unix domain
+// socket at path independent of the address.
+func dialUnix(timeout time.Duration, path string) dialFunc {
+	dialer := &net.Dialer{Timeout: timeout}
+	return func(ctx context.Context, _, _ string) (net.Conn, error) {
+		return dialer.DialContext(ctx, "unix", path)
+	}
+}
@@ -0,0 +1,255 @@
+package net
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io/ioutil"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+type testResolver map[string][]string
+
+func (r testResolver) LookupHost(_ context.Context, host string) ([]string, error) {
+	if ips, ok := r[host]; ok {
+		return ips, nil
+	}
+	return nil, &net.DNSError{Err: "no such host", Name: host, IsNotFound: true}
+}
+
+func TestDialResolution(t *testing.T) {
+	s := startTestServer(func(*http.Request) {})
+	defer s.Close()
+
+	u, _ := url.Parse(s.URL)
+	port := u.Port()
+
+	for _, tt := range []struct {
+		name    string
+		options Options
+		host    string
+		wantErr bool
+	}{{
+		name:    "static host",
+		options: Options{StaticHosts: map[string][]string{"upstream.test": {"127.0.0.1"}}},
+		host:    "upstream.test",
+	}, {
+		name:    "static host tries all IPs",
+		options: Options{StaticHosts: map[string][]string{"upstream.test": {"127.0.0.2", "127.0.0.1"}}},
+		host:    "upstream.test",
+	}, {
+		name:    "resolver",
+		options: Options{Resolver: testResolver{"upstream.test": {"127.0.0.1"}}},
+		host:    "upstream.test",
+	}, {
+		name: "static hosts have precedence",
+		options: Options{
+			StaticHosts: map[string][]string{"upstream.test": {"127.0.0.1"}},
+			Resolver:    testResolver{"upstream.test": {"192.0.2.1"}},
+		},
+		host: "upstream.test",
+	}, {
+		name:    "resolver error",
+		options: Options{Resolver: testResolver{}},
+		host:    "upstream.test",
+		wantErr: true,
+	}, {
+		name:    "IPs are not resolved",
+		options: Options{Resolver: testResolver{}},
+		host:    "127.0.0.1",
+	}} {
+		t.Run(tt.name, func(t *testing.T) {
+			rt := NewTransport(tt.options)
+			defer rt.Close()
+
+			req, _ := http.NewRequest("GET", "http://"+net.JoinHostPort(tt.host, port)+"/", nil)
+			rsp, err := rt.RoundTrip(req)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("Failed to do request, error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if err != nil {
+				if ClassifyError(err) != ErrorClassDNS {
+					t.Errorf("Failed to classify resolver error: %v", err)
+				}
+				return
+			}
+			rsp.Body.Close()
+		})
+	}
+}
+
+func TestCustomDialContext(t *testing.T) {
+	s := startTestServer(func(*http.Request) {})
+	defer s.Close()
+
+	var dialed []string
+	rt := NewTransport(Options{
+		StaticHosts: map[string][]string{"upstream.test": {"127.0.0.1"}},
+		DialContext: func(ctx context.Context, network, address string) (net.Conn, error) {
+			dialed = append(dialed, address)
+			if _, ok := ctx.Deadline(); !ok {
+				return nil, errors.New("no dial timeout")
+			}
+			return (&net.Dialer{}).DialContext(ctx, network, address)
+		},
+		PerHostTimeouts: map[string]Timeouts{"upstream.test": {Dial: time.Second}},
+	})
+	defer rt.Close()
+
+	u, _ := url.Parse(s.URL)
+	req, _ := http.NewRequest("GET", "http://upstream.test:"+u.Port()+"/", nil)
+	rsp, err := rt.RoundTrip(req)
+	if err != nil {
+		t.Fatalf("Failed to do request: %v", err)
+	}
+	rsp.Body.Close()
+
+	if len(dialed) != 1 || dialed[0] != "127.0.0.1:"+u.Port() {
+		t.Errorf("Failed to dial with the custom func: %v", dialed)
+	}
+}
+
+func TestUnixSocket(t *testing.T) {
+	dir, err := ioutil.TempDir("", "skipper-net")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+
+	socket := filepath.Join(dir, "app.sock")
+	l, err := net.Listen("unix", socket)
+	if err != nil {
+		t.Fatalf("Failed to listen: %v", err)
+	}
+	s := &httptest.Server{
+		Listener: l,
+		Config: &http.Server{Handler: http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			w.Write([]byte(r.Host + r.URL.Path))
+		})},
+	}
+	s.Start()
+	defer s.Close()
+
+	tcp := startTestServer(func(*http.Request) {})
+	defer tcp.Close()
+
+	for _, tt := range []struct {
+		name    string
+		options Options
+		url     string
+		want    string
+	}{{
+		name:    "all connections",
+		options: Options{UnixSocketPath: socket},
+		url:     "http://sidecar/foo",
+		want:    "sidecar/foo",
+	}, {
+		name:    "per host",
+		options: Options{PerHostOptions: map[string]HostOptions{"sidecar": {UnixSocketPath: socket}}},
+		url:     "http://sidecar/bar",
+		want:    "sidecar/bar",
+	}, {
+		name:    "other hosts use TCP",
+		options: Options{PerHostOptions: map[string]HostOptions{"sidecar": {UnixSocketPath: socket}}},
+		url:     tcp.URL,
+	}} {
+		t.Run(tt.name, func(t *testing.T) {
+			cli := NewClient(tt.options)
+			defer cli.Close()
+
+			rsp, err := cli.Get(tt.url)
+			if err != nil {
+				t.Fatalf("Failed to do request: %v", err)
+			}
+			defer rsp.Body.Close()
+
+			b, _ := ioutil.ReadAll(rsp.Body)
+			if string(b) != tt.want {
+				t.Errorf("Failed to get response: got %q, want %q", b, tt.want)
+			}
+		})
+	}
+}
+
+func TestIPFamily(t *testing.T) {
+	for _, tt := range []struct {
+		family     IPFamily
+		network    string
+		failFirst  bool
+		wantDialed []string
+	}{{
+		family:     IPFamilyAny,
+		network:    "tcp",
+		wantDialed: []string{"tcp"},
+	}, {
+		family:     IPFamilyIPv4,
+		network:    "tcp",
+		wantDialed: []string{"tcp4"},
+	}, {
+		family:     IPFamilyIPv6,
+		network:    "tcp",
+		failFirst:  true,
+		wantDialed: []string{"tcp6"},
+	}, {
+		family:     IPFamilyPreferIPv6,
+		network:    "tcp",
+		wantDialed: []string{"tcp6"},
+	}, {
+		family:     IPFamilyPreferIPv6,
+		network:    "tcp",
+		failFirst:  true,
+		wantDialed: []string{"tcp6", "tcp4"},
+	}, {
+		family:     IPFamilyPreferIPv4,
+		network:    "tcp",
+		failFirst:  true,
+		wantDialed: []string{"tcp4", "tcp6"},
+	}, {
+		family:     IPFamilyIPv4,
+		network:    "unix",
+		wantDialed: []string{"unix"},
+	}} {
+		var dialed []string
+		dial := withIPFamily(func(_ context.Context, network, _ string) (net.Conn, error) {
+			dialed = append(dialed, network)
+			if tt.failFirst && len(dialed) == 1 {
+				return nil, errors.New("no address")
+			}
+			return nil, nil
+		}, tt.family)
+
+		dial(context.Background(), tt.network, "example.org:80")
+		if fmt.Sprint(dialed) != fmt.Sprint(tt.wantDialed) {
+			t.Errorf("Failed to dial family %d: got %v, want %v", tt.family, dialed, tt.wantDialed)
+		}
+	}
+}
+
+func TestIPFamilyTransport(t *testing.T) {
+	s := startTestServer(func(*http.Request) {})
+	defer s.Close()
+
+	for family, wantErr := range map[IPFamily]bool{
+		IPFamilyIPv4:       false,
+		IPFamilyIPv6:       true,
+		IPFamilyPreferIPv6: false,
+	} {
+		rt := NewTransport(Options{IPFamily: family})
+		req, _ := http.NewRequest("GET", s.URL, nil)
+		rsp, err := rt.RoundTrip(req)
+		if (err != nil) != wantErr {
+			t.Errorf("Failed to dial family %d, error = %v, wantErr %v", family, err, wantErr)
+		}
+		if err == nil {
+			rsp.Body.Close()
+		}
+		rt.Close()
+	}
+}
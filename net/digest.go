@@ -0,0 +1,124 @@
+package net
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"crypto/sha512"
+	"encoding/base64"
+	"errors"
+	"hash"
+	"io"
+	"net/http"
+	"strings"
+)
+
+var (
+	// ErrDigestMismatch is returned on reading the end of a response
+	// body, which digest does not match the digest announced in the
+	// Content-Digest or Digest response header.
+	ErrDigestMismatch = errors.New("response body digest mismatch")
+
+	// ErrDigestMissing is returned for responses without a supported
+	// Content-Digest or Digest header, if the digest is required.
+	ErrDigestMissing = errors.New("response digest missing")
+)
+
+type digestOptions struct {
+	verify  bool
+	require bool
+}
+
+type digestAlgorithm struct {
+	name    string
+	newHash func() hash.Hash
+}
+
+// supported algorithms, strongest first
+var digestAlgorithms = []digestAlgorithm{
+	{name: "sha-512", newHash: sha512.New},
+	{name: "sha-256", newHash: sha256.New},
+}
+
+// parseDigests parses the RFC 9530 Content-Digest header, like
+// "sha-256=:base64:", and falls back to the RFC 3230 Digest header,
+// like "SHA-256=base64". It returns the digests by lower case
+// algorithm name.
+func parseDigests(h http.Header) map[string][]byte {
+	if v := h.Get("Content-Digest"); v != "" {
+		return parseDigestList(v, true)
+	}
+	if v := h.Get("Digest"); v != "" {
+		return parseDigestList(v, false)
+	}
+	return nil
+}
+
+func parseDigestList(v string, structured bool) map[string][]byte {
+	digests := make(map[string][]byte)
+	for _, item := range strings.Split(v, ",") {
+		i := strings.IndexByte(item, '=')
+		if i < 0 {
+			continue
+		}
+
+		alg := strings.ToLower(strings.TrimSpace(item[:i]))
+		value := strings.TrimSpace(item[i+1:])
+		if structured {
+			if len(value) < 2 || value[0] != ':' || value[len(value)-1] != ':' {
+				continue
+			}
+			value = value[1 : len(value)-1]
+		}
+
+		d, err := base64.StdEncoding.DecodeString(value)
+		if err != nil {
+			continue
+		}
+		digests[alg] = d
+	}
+	return digests
+}
+
+// verifyDigest wraps the response body to verify the digest while
+// the body is read. It returns ErrDigestMissing, if a digest is
+// required, but not announced by the response.
+func (o digestOptions) verifyDigest(req *http.Request, rsp *http.Response) error {
+	if !o.verify || req.Method == http.MethodHead ||
+		rsp.StatusCode == http.StatusNoContent || rsp.StatusCode == http.StatusNotModified {
+		return nil
+	}
+
+	digests := parseDigests(rsp.Header)
+	for _, alg := range digestAlgorithms {
+		if d, ok := digests[alg.name]; ok {
+			rsp.Body = &digestBody{body: rsp.Body, hash: alg.newHash(), want: d}
+			return nil
+		}
+	}
+
+	if o.require {
+		return ErrDigestMissing
+	}
+	return nil
+}
+
+// digestBody hashes the body while it is read and compares the digest
+// at the end of the body without buffering it.
+type digestBody struct {
+	body io.ReadCloser
+	hash hash.Hash
+	want []byte
+}
+
+func (b *digestBody) Read(p []byte) (int, error) {
+	n, err := b.body.Read(p)
+	b.hash.Write(p[:n])
+	if err == io.EOF && !bytes.Equal(b.hash.Sum(nil), b.want) {
+		return n, ErrDigestMismatch
+	}
+	return n, err
+}
+
+func (b *digestBody) Close() error {
+	return b.body.Close()
+}
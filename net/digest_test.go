@@ -0,0 +1,137 @@
+package net
+
+import (
+	"bytes"
+	"compress/gzip"
+	"crypto/sha256"
+	"crypto/sha512"
+	"encoding/base64"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+func TestParseDigests(t *testing.T) {
+	sum := sha256.Sum256([]byte("foo"))
+	b64 := base64.StdEncoding.EncodeToString(sum[:])
+
+	for _, tt := range []struct {
+		name   string
+		header http.Header
+		want   bool
+	}{{
+		name:   "content digest",
+		header: http.Header{"Content-Digest": []string{"sha-256=:" + b64 + ":"}},
+		want:   true,
+	}, {
+		name:   "content digest with multiple algorithms",
+		header: http.Header{"Content-Digest": []string{"md5=:foo:, sha-256=:" + b64 + ":"}},
+		want:   true,
+	}, {
+		name:   "legacy digest",
+		header: http.Header{"Digest": []string{"SHA-256=" + b64}},
+		want:   true,
+	}, {
+		name:   "content digest without colons",
+		header: http.Header{"Content-Digest": []string{"sha-256=" + b64}},
+	}, {
+		name: "no digest",
+	}} {
+		t.Run(tt.name, func(t *testing.T) {
+			got, ok := parseDigests(tt.header)["sha-256"]
+			if ok != tt.want || (ok && !bytes.Equal(got, sum[:])) {
+				t.Errorf("Failed to parse digest: got %v %v, want %v", got, ok, tt.want)
+			}
+		})
+	}
+}
+
+func TestVerifyDigest(t *testing.T) {
+	body := []byte("hello world")
+	sum256 := sha256.Sum256(body)
+	sum512 := sha512.Sum512(body)
+	wrong := sha256.Sum256([]byte("tampered"))
+
+	var gzipped bytes.Buffer
+	zw := gzip.NewWriter(&gzipped)
+	zw.Write(body)
+	zw.Close()
+	sumGzip := sha256.Sum256(gzipped.Bytes())
+
+	b64 := base64.StdEncoding.EncodeToString
+
+	for _, tt := range []struct {
+		name        string
+		options     Options
+		header      http.Header
+		gzip        bool
+		wantErr     error
+		wantBodyErr error
+	}{{
+		name:    "verification disabled",
+		header:  http.Header{"Content-Digest": []string{"sha-256=:" + b64(wrong[:]) + ":"}},
+		options: Options{},
+	}, {
+		name:    "sha-256 match",
+		header:  http.Header{"Content-Digest": []string{"sha-256=:" + b64(sum256[:]) + ":"}},
+		options: Options{VerifyDigest: true},
+	}, {
+		name:    "sha-512 match",
+		header:  http.Header{"Digest": []string{"SHA-512=" + b64(sum512[:])}},
+		options: Options{VerifyDigest: true},
+	}, {
+		name:        "mismatch",
+		header:      http.Header{"Content-Digest": []string{"sha-256=:" + b64(wrong[:]) + ":"}},
+		options:     Options{VerifyDigest: true},
+		wantBodyErr: ErrDigestMismatch,
+	}, {
+		name:    "missing digest not required",
+		options: Options{VerifyDigest: true},
+	}, {
+		name:    "missing digest required",
+		options: Options{RequireDigest: true},
+		wantErr: ErrDigestMissing,
+	}, {
+		name:    "digest of the gzip encoded body",
+		header:  http.Header{"Content-Digest": []string{"sha-256=:" + b64(sumGzip[:]) + ":"}},
+		gzip:    true,
+		options: Options{VerifyDigest: true},
+	}} {
+		t.Run(tt.name, func(t *testing.T) {
+			s := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				for k, v := range tt.header {
+					w.Header()[k] = v
+				}
+				if tt.gzip {
+					w.Header().Set("Content-Encoding", "gzip")
+					w.Write(gzipped.Bytes())
+					return
+				}
+				w.Write(body)
+			}))
+			defer s.Close()
+
+			rt := NewTransport(tt.options)
+			defer rt.Close()
+
+			req, _ := http.NewRequest("GET", s.URL, nil)
+			rsp, err := rt.RoundTrip(req)
+			if err != tt.wantErr {
+				t.Fatalf("Failed to get error: got %v, want %v", err, tt.wantErr)
+			}
+			if err != nil {
+				return
+			}
+			defer rsp.Body.Close()
+
+			b, err := ioutil.ReadAll(rsp.Body)
+			if err != tt.wantBodyErr {
+				t.Fatalf("Failed to get body error: got %v, want %v", err, tt.wantBodyErr)
+			}
+			if err == nil && !bytes.Equal(b, body) {
+				t.Errorf("Failed to get body: %q", b)
+			}
+		})
+	}
+}
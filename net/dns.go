@@ -0,0 +1,73 @@
+package net
+
+import (
+	"context"
+	"errors"
+	"net"
+	"sync"
+)
+
+// isRetryableDNSError returns true for transient resolver failures
+// like timeouts or SERVFAIL, but not for unknown hosts.
+func isRetryableDNSError(err error) bool {
+	var dnsErr *net.DNSError
+	if !errors.As(err, &dnsErr) {
+		return false
+	}
+	return !dnsErr.IsNotFound && (dnsErr.IsTimeout || dnsErr.IsTemporary)
+}
+
+type dialFunc func(ctx context.Context, network, address string) (net.Conn, error)
+
+// dnsFallbackCache stores the last address a host was successfully
+// connected to. It is only used, if the resolver fails with a
+// transient error.
+type dnsFallbackCache struct {
+	mu    sync.RWMutex
+	hosts map[string]string
+}
+
+func newDNSFallbackCache() *dnsFallbackCache {
+	return &dnsFallbackCache{hosts: make(map[string]string)}
+}
+
+func (c *dnsFallbackCache) get(host string) (string, bool) {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+	ip, ok := c.hosts[host]
+	return ip, ok
+}
+
+func (c *dnsFallbackCache) set(host, ip string) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.hosts[host] = ip
+}
+
+func (c *dnsFallbackCache) dialContext(dial dialFunc) dialFunc {
+	return func(ctx context.Context, network, address string) (net.Conn, error) {
+		host, port, err := net.SplitHostPort(address)
+		if err != nil || net.ParseIP(host) != nil {
+			return dial(ctx, network, address)
+		}
+
+		conn, err := dial(ctx, network, address)
+		if err == nil {
+			if addr, ok := conn.RemoteAddr().(*net.TCPAddr); ok {
+				c.set(host, addr.IP.String())
+			}
+			return conn, nil
+		}
+
+		if !isRetryableDNSError(err) {
+			return nil, err
+		}
+
+		ip, ok := c.get(host)
+		if !ok {
+			return nil, err
+		}
+
+		return dial(ctx, network, net.JoinHostPort(ip, port))
+	}
+}
@@ -0,0 +1,164 @@
+package net
+
+import (
+	"context"
+	"net"
+	"net/http"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/zalando/skipper/tracing/tracingtest"
+)
+
+func TestIsRetryableDNSError(t *testing.T) {
+	for _, tt := range []struct {
+		name string
+		err  error
+		want bool
+	}{{
+		name: "timeout",
+		err:  &net.OpError{Op: "dial", Err: &net.DNSError{IsTimeout: true}},
+		want: true,
+	}, {
+		name: "servfail",
+		err:  &net.OpError{Op: "dial", Err: &net.DNSError{Err: "server misbehaving", IsTemporary: true}},
+		want: true,
+	}, {
+		name: "not found",
+		err:  &net.OpError{Op: "dial", Err: &net.DNSError{Err: "no such host", IsNotFound: true}},
+		want: false,
+	}, {
+		name: "connection refused",
+		err:  &net.OpError{Op: "dial"},
+		want: false,
+	}} {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := isRetryableDNSError(tt.err); got != tt.want {
+				t.Errorf("Failed to detect retryable DNS error: got %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+// failingDial simulates resolver failures for the host "upstream.test"
+// and resolves it to 127.0.0.1 otherwise.
+type failingDial struct {
+	mu       sync.Mutex
+	failures int
+	calls    int
+}
+
+func (fd *failingDial) dial(ctx context.Context, network, address string) (net.Conn, error) {
+	fd.mu.Lock()
+	fd.calls++
+	fail := fd.failures > 0
+	if fail {
+		fd.failures--
+	}
+	fd.mu.Unlock()
+
+	host, port, _ := net.SplitHostPort(address)
+	if host == "upstream.test" {
+		if fail {
+			return nil, &net.OpError{Op: "dial", Err: &net.DNSError{Name: host, IsTemporary: true}}
+		}
+		address = net.JoinHostPort("127.0.0.1", port)
+	}
+	return (&net.Dialer{}).DialContext(ctx, network, address)
+}
+
+func TestDNSFallbackCache(t *testing.T) {
+	l, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatalf("Failed to listen: %v", err)
+	}
+	defer l.Close()
+	_, port, _ := net.SplitHostPort(l.Addr().String())
+
+	fd := &failingDial{}
+	dial := newDNSFallbackCache().dialContext(fd.dial)
+
+	if conn, err := dial(context.Background(), "tcp", "upstream.test:"+port); err != nil {
+		t.Fatalf("Failed to dial: %v", err)
+	} else {
+		conn.Close()
+	}
+
+	fd.failures = 1
+	conn, err := dial(context.Background(), "tcp", "upstream.test:"+port)
+	if err != nil {
+		t.Fatalf("Failed to dial the cached address: %v", err)
+	}
+	conn.Close()
+
+	fd.failures = 1
+	if _, err := dial(context.Background(), "tcp", "other.test:"+port); err == nil {
+		t.Error("Failed to get an error for an uncached host")
+	}
+}
+
+func TestTransportDNSRetry(t *testing.T) {
+	s := startTestServer(func(*http.Request) {})
+	defer s.Close()
+	_, port, _ := net.SplitHostPort(s.Listener.Addr().String())
+
+	for _, tt := range []struct {
+		name     string
+		retries  int
+		failures int
+		method   string
+		wantErr  bool
+	}{{
+		name:     "no retries configured",
+		failures: 1,
+		method:   "GET",
+		wantErr:  true,
+	}, {
+		name:     "retried",
+		retries:  2,
+		failures: 2,
+		method:   "GET",
+	}, {
+		name:     "retries exceeded",
+		retries:  1,
+		failures: 2,
+		method:   "GET",
+		wantErr:  true,
+	}, {
+		name:     "not idempotent",
+		retries:  2,
+		failures: 1,
+		method:   "POST",
+		wantErr:  true,
+	}} {
+		t.Run(tt.name, func(t *testing.T) {
+			tracer := &tracingtest.Tracer{}
+			rt := NewTransport(Options{
+				DNSRetries:      tt.retries,
+				DNSRetryBackoff: time.Millisecond,
+				Tracer:          tracer,
+			})
+			defer rt.Close()
+			rt = WithSpanName(rt, "myspan")
+
+			fd := &failingDial{failures: tt.failures}
+			rt.tr.DialContext = fd.dial
+
+			req, _ := http.NewRequest(tt.method, "http://upstream.test:"+port+"/", nil)
+			rsp, err := rt.RoundTrip(req)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("Failed to do request, error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if err != nil {
+				return
+			}
+			rsp.Body.Close()
+
+			span, _ := tracer.FindSpan("myspan")
+			if span.Tags[retryReasonTag] != "dns" || span.Tags[retryCountTag] != tt.failures {
+				t.Errorf("Failed to tag the span: %v", span.Tags)
+			}
+		})
+	}
+}
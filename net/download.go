@@ -0,0 +1,206 @@
+package net
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"strconv"
+	"strings"
+	"sync"
+)
+
+const (
+	defaultDownloadChunkSize   = 8 << 20
+	defaultDownloadConcurrency = 4
+	downloadChunkRetries       = 3
+)
+
+// ErrDownloadSizeMismatch is returned by DownloadRanged, if the
+// number of downloaded bytes does not match the size announced by the
+// server.
+var ErrDownloadSizeMismatch = errors.New("downloaded size does not match the announced size")
+
+// DownloadRanged downloads the resource at url into w. If the server
+// supports range requests, announced by "Accept-Ranges: bytes", it
+// issues concurrent range requests of chunkSize bytes and writes each
+// chunk at its offset into w. Failed chunks are retried. If the
+// server does not support range requests or does not announce the
+// size of the resource, it falls back to a single request. In both
+// cases the number of written bytes is verified against the announced
+// size. chunkSize and concurrency default to 8MiB and 4 if not set. It
+// returns the number of bytes written.
+func (c *Client) DownloadRanged(ctx context.Context, url string, w io.WriterAt, chunkSize int64, concurrency int) (int64, error) {
+	if chunkSize <= 0 {
+		chunkSize = defaultDownloadChunkSize
+	}
+	if concurrency <= 0 {
+		concurrency = defaultDownloadConcurrency
+	}
+
+	req, err := http.NewRequest(http.MethodHead, url, nil)
+	if err != nil {
+		return 0, err
+	}
+
+	rsp, err := c.Do(req.WithContext(ctx))
+	if err != nil {
+		return 0, err
+	}
+	rsp.Body.Close()
+
+	if rsp.StatusCode != http.StatusOK || rsp.ContentLength < 0 ||
+		!strings.EqualFold(rsp.Header.Get("Accept-Ranges"), "bytes") {
+		return c.download(ctx, url, w)
+	}
+
+	return c.downloadRanges(ctx, url, w, rsp.ContentLength, chunkSize, concurrency)
+}
+
+func (c *Client) download(ctx context.Context, url string, w io.WriterAt) (int64, error) {
+	req, err := http.NewRequest(http.MethodGet, url, nil)
+	if err != nil {
+		return 0, err
+	}
+
+	rsp, err := c.Do(req.WithContext(ctx))
+	if err != nil {
+		return 0, err
+	}
+	defer rsp.Body.Close()
+
+	if rsp.StatusCode != http.StatusOK {
+		return 0, fmt.Errorf("failed to download, unexpected status code: %d", rsp.StatusCode)
+	}
+
+	n, err := io.Copy(&offsetWriter{w: w}, rsp.Body)
+	if err != nil {
+		return n, err
+	}
+
+	if rsp.ContentLength >= 0 && n != rsp.ContentLength {
+		return n, ErrDownloadSizeMismatch
+	}
+	return n, nil
+}
+
+func (c *Client) downloadRanges(ctx context.Context, url string, w io.WriterAt, size, chunkSize int64, concurrency int) (int64, error) {
+	ctx, cancel := context.WithCancel(ctx)
+	defer cancel()
+
+	offsets := make(chan int64)
+	go func() {
+		defer close(offsets)
+		for off := int64(0); off < size; off += chunkSize {
+			select {
+			case offsets <- off:
+			case <-ctx.Done():
+				return
+			}
+		}
+	}()
+
+	var (
+		mu       sync.Mutex
+		wg       sync.WaitGroup
+		firstErr error
+	)
+	for i := 0; i < concurrency; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for off := range offsets {
+				end := off + chunkSize - 1
+				if end >= size {
+					end = size - 1
+				}
+
+				var err error
+				for attempt := 0; attempt < downloadChunkRetries; attempt++ {
+					if err = c.downloadChunk(ctx, url, w, off, end, size); err == nil || ctx.Err() != nil {
+						break
+					}
+				}
+
+				if err != nil {
+					mu.Lock()
+					if firstErr == nil {
+						firstErr = err
+					}
+					mu.Unlock()
+					cancel()
+					return
+				}
+			}
+		}()
+	}
+	wg.Wait()
+
+	if firstErr != nil {
+		return 0, firstErr
+	}
+	if err := ctx.Err(); err != nil {
+		return 0, err
+	}
+	return size, nil
+}
+
+func (c *Client) downloadChunk(ctx context.Context, url string, w io.WriterAt, start, end, size int64) error {
+	req, err := http.NewRequest(http.MethodGet, url, nil)
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Range", fmt.Sprintf("bytes=%d-%d", start, end))
+
+	rsp, err := c.Do(req.WithContext(ctx))
+	if err != nil {
+		return err
+	}
+	defer rsp.Body.Close()
+
+	if rsp.StatusCode != http.StatusPartialContent {
+		return fmt.Errorf("failed to download range, unexpected status code: %d", rsp.StatusCode)
+	}
+
+	if total, ok := parseContentRangeSize(rsp.Header.Get("Content-Range")); !ok || total != size {
+		return ErrDownloadSizeMismatch
+	}
+
+	n, err := io.Copy(&offsetWriter{w: w, off: start}, io.LimitReader(rsp.Body, end-start+1))
+	if err != nil {
+		return err
+	}
+	if n != end-start+1 {
+		return ErrDownloadSizeMismatch
+	}
+	return nil
+}
+
+// parseContentRangeSize returns the complete length of a Content-Range
+// header value like "bytes 0-99/1000".
+func parseContentRangeSize(s string) (int64, bool) {
+	i := strings.LastIndexByte(s, '/')
+	if i < 0 || !strings.HasPrefix(s, "bytes ") {
+		return 0, false
+	}
+
+	size, err := strconv.ParseInt(s[i+1:], 10, 64)
+	if err != nil {
+		return 0, false
+	}
+	return size, true
+}
+
+// offsetWriter writes sequentially into an io.WriterAt starting at
+// off.
+type offsetWriter struct {
+	w   io.WriterAt
+	off int64
+}
+
+func (ow *offsetWriter) Write(p []byte) (int, error) {
+	n, err := ow.w.WriteAt(p, ow.off)
+	ow.off += int64(n)
+	return n, err
+}
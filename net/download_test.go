@@ -0,0 +1,142 @@
+package net
+
+import (
+	"bytes"
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+)
+
+type testWriterAt struct {
+	mu  sync.Mutex
+	buf []byte
+}
+
+func (w *testWriterAt) WriteAt(p []byte, off int64) (int, error) {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	if end := int(off) + len(p); end > len(w.buf) {
+		w.buf = append(w.buf, make([]byte, end-len(w.buf))...)
+	}
+	copy(w.buf[off:], p)
+	return len(p), nil
+}
+
+func TestDownloadRanged(t *testing.T) {
+	content := []byte(strings.Repeat("0123456789", 1000))
+
+	for _, tt := range []struct {
+		name       string
+		handler    func(*testing.T, *int) http.HandlerFunc
+		wantRanges bool
+	}{{
+		name: "server supports ranges",
+		handler: func(t *testing.T, ranges *int) http.HandlerFunc {
+			var mu sync.Mutex
+			return func(w http.ResponseWriter, r *http.Request) {
+				if r.Header.Get("Range") != "" {
+					mu.Lock()
+					*ranges++
+					mu.Unlock()
+				}
+				http.ServeContent(w, r, "", time.Time{}, bytes.NewReader(content))
+			}
+		},
+		wantRanges: true,
+	}, {
+		name: "server does not support ranges",
+		handler: func(t *testing.T, ranges *int) http.HandlerFunc {
+			return func(w http.ResponseWriter, r *http.Request) {
+				if r.Header.Get("Range") != "" {
+					*ranges++
+				}
+				w.Write(content)
+			}
+		},
+	}, {
+		name: "failed chunks are retried",
+		handler: func(t *testing.T, ranges *int) http.HandlerFunc {
+			var mu sync.Mutex
+			failed := make(map[string]bool)
+			return func(w http.ResponseWriter, r *http.Request) {
+				rng := r.Header.Get("Range")
+				if rng != "" {
+					mu.Lock()
+					*ranges++
+					fail := !failed[rng]
+					failed[rng] = true
+					mu.Unlock()
+
+					if fail {
+						w.WriteHeader(http.StatusServiceUnavailable)
+						return
+					}
+				}
+				http.ServeContent(w, r, "", time.Time{}, bytes.NewReader(content))
+			}
+		},
+		wantRanges: true,
+	}} {
+		t.Run(tt.name, func(t *testing.T) {
+			ranges := 0
+			s := httptest.NewServer(tt.handler(t, &ranges))
+			defer s.Close()
+
+			cli := NewClient(Options{})
+			defer cli.Close()
+
+			w := &testWriterAt{}
+			n, err := cli.DownloadRanged(context.Background(), s.URL, w, 1024, 3)
+			if err != nil {
+				t.Fatalf("Failed to download: %v", err)
+			}
+
+			if n != int64(len(content)) {
+				t.Errorf("Failed to get size: got %d, want %d", n, len(content))
+			}
+			if !bytes.Equal(w.buf, content) {
+				t.Error("Failed to get the same content")
+			}
+			if (ranges > 0) != tt.wantRanges {
+				t.Errorf("Failed to use range requests: %d, want: %v", ranges, tt.wantRanges)
+			}
+		})
+	}
+}
+
+func TestDownloadRangedSizeMismatch(t *testing.T) {
+	s := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Length", "100")
+		w.Write([]byte("short"))
+	}))
+	defer s.Close()
+
+	cli := NewClient(Options{})
+	defer cli.Close()
+
+	if _, err := cli.DownloadRanged(context.Background(), s.URL, &testWriterAt{}, 0, 0); err == nil {
+		t.Error("Failed to get an error for a truncated download")
+	}
+}
+
+func TestParseContentRangeSize(t *testing.T) {
+	for _, tt := range []struct {
+		value  string
+		want   int64
+		wantOK bool
+	}{
+		{value: "bytes 0-99/1000", want: 1000, wantOK: true},
+		{value: "bytes 0-99/*"},
+		{value: "0-99/1000"},
+		{value: ""},
+	} {
+		got, ok := parseContentRangeSize(tt.value)
+		if got != tt.want || ok != tt.wantOK {
+			t.Errorf("Failed to parse %q: got %d %v, want %d %v", tt.value, got, ok, tt.want, tt.wantOK)
+		}
+	}
+}
@@ -0,0 +1,129 @@
+package net
+
+import (
+	"context"
+	"crypto/tls"
+	"crypto/x509"
+	"errors"
+	"io"
+	"net"
+	"strings"
+)
+
+// ErrorClass is the classification of an error returned by the
+// Client or Transport, e.g. to count failures by their cause.
+type ErrorClass string
+
+const (
+	// ErrorClassNone is returned for nil errors.
+	ErrorClassNone ErrorClass = ""
+
+	// ErrorClassTimeout is a timeout or deadline exceeded.
+	ErrorClassTimeout ErrorClass = "timeout"
+
+	// ErrorClassCanceled is a canceled request context.
+	ErrorClassCanceled ErrorClass = "canceled"
+
+	// ErrorClassDNS is a failure to resolve the upstream host.
+	ErrorClassDNS ErrorClass = "dns"
+
+	// ErrorClassDial is a failure to connect to the upstream.
+	ErrorClassDial ErrorClass = "dial"
+
+	// ErrorClassTLS is a failed TLS handshake or certificate
+	// verification.
+	ErrorClassTLS ErrorClass = "tls"
+
+	// ErrorClassCircuitOpen is a request rejected by an open
+	// circuit breaker.
+	ErrorClassCircuitOpen ErrorClass = "circuit_open"
+
+	// ErrorClassRateLimited is a request rejected by the client side
+	// rate limit.
+	ErrorClassRateLimited ErrorClass = "rate_limited"
+
+	// ErrorClassTruncated is a response body, that ended before all
+	// announced data was received.
+	ErrorClassTruncated ErrorClass = "truncated"
+
+	// ErrorClassBodyTooLarge is a response body exceeding the
+	// configured maximum size.
+	ErrorClassBodyTooLarge ErrorClass = "body_too_large"
+
+	// ErrorClassDigestMismatch is a response body, that does not
+	// match its announced digest or misses a required digest.
+	ErrorClassDigestMismatch ErrorClass = "digest_mismatch"
+
+	// ErrorClassOther are all errors, that do not match another
+	// class.
+	ErrorClassOther ErrorClass = "other"
+)
+
+// ClassifyError returns the ErrorClass of an error returned by the
+// Client, the Transport or by reading a response body.
+func ClassifyError(err error) ErrorClass {
+	if err == nil {
+		return ErrorClassNone
+	}
+
+	if errors.Is(err, context.Canceled) {
+		return ErrorClassCanceled
+	}
+
+	if errors.Is(err, ErrCircuitOpen) {
+		return ErrorClassCircuitOpen
+	}
+
+	if errors.Is(err, ErrRateLimited) {
+		return ErrorClassRateLimited
+	}
+
+	if errors.Is(err, ErrResponseBodyTooLarge) {
+		return ErrorClassBodyTooLarge
+	}
+
+	if errors.Is(err, ErrDigestMismatch) || errors.Is(err, ErrDigestMissing) {
+		return ErrorClassDigestMismatch
+	}
+
+	if errors.Is(err, io.ErrUnexpectedEOF) {
+		return ErrorClassTruncated
+	}
+
+	if isTLSError(err) {
+		return ErrorClassTLS
+	}
+
+	var dnsErr *net.DNSError
+	if errors.As(err, &dnsErr) {
+		return ErrorClassDNS
+	}
+
+	var nerr net.Error
+	if errors.Is(err, context.DeadlineExceeded) || (errors.As(err, &nerr) && nerr.Timeout()) {
+		return ErrorClassTimeout
+	}
+
+	var opErr *net.OpError
+	if errors.As(err, &opErr) && opErr.Op == "dial" {
+		return ErrorClassDial
+	}
+
+	return ErrorClassOther
+}
+
+func isTLSError(err error) bool {
+	var (
+		unknownAuthority x509.UnknownAuthorityError
+		hostname         x509.HostnameError
+		invalid          x509.CertificateInvalidError
+		recordHeader     tls.RecordHeaderError
+	)
+	if errors.As(err, &unknownAuthority) || errors.As(err, &hostname) ||
+		errors.As(err, &invalid) || errors.As(err, &recordHeader) {
+		return true
+	}
+
+	// alerts sent by the peer are not exported by crypto/tls
+	return strings.Contains(err.Error(), "tls: ")
+}
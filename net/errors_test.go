@@ -0,0 +1,84 @@
+package net
+
+import (
+	"context"
+	"crypto/x509"
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"net/url"
+	"testing"
+)
+
+type testTimeoutError struct{}
+
+func (testTimeoutError) Error() string   { return "i/o timeout" }
+func (testTimeoutError) Timeout() bool   { return true }
+func (testTimeoutError) Temporary() bool { return true }
+
+func TestClassifyError(t *testing.T) {
+	for _, tt := range []struct {
+		name string
+		err  error
+		want ErrorClass
+	}{{
+		name: "nil",
+		err:  nil,
+		want: ErrorClassNone,
+	}, {
+		name: "deadline exceeded",
+		err:  &url.Error{Op: "Get", URL: "http://example.org", Err: context.DeadlineExceeded},
+		want: ErrorClassTimeout,
+	}, {
+		name: "net timeout",
+		err:  &net.OpError{Op: "read", Err: testTimeoutError{}},
+		want: ErrorClassTimeout,
+	}, {
+		name: "canceled",
+		err:  &url.Error{Op: "Get", URL: "http://example.org", Err: context.Canceled},
+		want: ErrorClassCanceled,
+	}, {
+		name: "dial",
+		err:  &net.OpError{Op: "dial", Err: errors.New("connection refused")},
+		want: ErrorClassDial,
+	}, {
+		name: "dns",
+		err:  &net.OpError{Op: "dial", Err: &net.DNSError{Err: "no such host", Name: "example.invalid"}},
+		want: ErrorClassDNS,
+	}, {
+		name: "certificate",
+		err:  &url.Error{Op: "Get", URL: "https://example.org", Err: x509.UnknownAuthorityError{}},
+		want: ErrorClassTLS,
+	}, {
+		name: "tls alert",
+		err:  &net.OpError{Op: "remote error", Err: errors.New("tls: bad certificate")},
+		want: ErrorClassTLS,
+	}, {
+		name: "truncated",
+		err:  io.ErrUnexpectedEOF,
+		want: ErrorClassTruncated,
+	}, {
+		name: "circuit open",
+		err:  &url.Error{Op: "Get", URL: "http://example.org", Err: ErrCircuitOpen},
+		want: ErrorClassCircuitOpen,
+	}, {
+		name: "rate limited",
+		err:  fmt.Errorf("get: %w", ErrRateLimited),
+		want: ErrorClassRateLimited,
+	}, {
+		name: "body too large",
+		err:  fmt.Errorf("read: %w", ErrResponseBodyTooLarge),
+		want: ErrorClassBodyTooLarge,
+	}, {
+		name: "other",
+		err:  errors.New("foo"),
+		want: ErrorClassOther,
+	}} {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := ClassifyError(tt.err); got != tt.want {
+				t.Errorf("Failed to classify error: got %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
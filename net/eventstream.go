@@ -0,0 +1,17 @@
+package net
+
+import (
+	"mime"
+	"net/http"
+)
+
+// EventStreamMIME is the content type of the streams of Server-Sent
+// Events, https://html.spec.whatwg.org/multipage/server-sent-events.html.
+const EventStreamMIME = "text/event-stream"
+
+// IsEventStream tells whether the response is a stream of Server-Sent
+// Events, based on its content type.
+func IsEventStream(rsp *http.Response) bool {
+	mt, _, err := mime.ParseMediaType(rsp.Header.Get("Content-Type"))
+	return err == nil && mt == EventStreamMIME
+}
@@ -0,0 +1,32 @@
+package net
+
+import (
+	"net/http"
+	"testing"
+)
+
+func TestIsEventStream(t *testing.T) {
+	for _, ti := range []struct {
+		contentType string
+		expected    bool
+	}{{
+		expected: false,
+	}, {
+		contentType: "text/plain",
+		expected:    false,
+	}, {
+		contentType: "text/event-stream",
+		expected:    true,
+	}, {
+		contentType: "Text/Event-Stream; charset=utf-8",
+		expected:    true,
+	}, {
+		contentType: "text/event-stream;;",
+		expected:    false,
+	}} {
+		rsp := &http.Response{Header: http.Header{"Content-Type": []string{ti.contentType}}}
+		if got := IsEventStream(rsp); got != ti.expected {
+			t.Errorf("unexpected result for %q, expected: %t, got: %t", ti.contentType, ti.expected, got)
+		}
+	}
+}
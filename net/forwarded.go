@@ -0,0 +1,212 @@
+package net
+
+import (
+	"fmt"
+	"net"
+	"net/http"
+	"strings"
+)
+
+// ForwardedHeaderMode defines how the proxy handles an X-Forwarded-*
+// header of the requests sent to the backends.
+type ForwardedHeaderMode int
+
+const (
+	// ForwardedKeep is the default mode. The header is passed to the
+	// backend as received from the client.
+	ForwardedKeep ForwardedHeaderMode = iota
+
+	// ForwardedAppend adds the value of the current hop. With
+	// X-Forwarded-For, the client address is appended to the list
+	// received from the previous proxies. With X-Forwarded-Proto and
+	// X-Forwarded-Host, the value received from the previous proxies is
+	// preserved, and it is set only when missing.
+	ForwardedAppend
+
+	// ForwardedOverwrite replaces the received value with the value of
+	// the current hop.
+	ForwardedOverwrite
+
+	// ForwardedDrop removes the header.
+	ForwardedDrop
+)
+
+const (
+	forwardedKeepString      = "keep"
+	forwardedAppendString    = "append"
+	forwardedOverwriteString = "overwrite"
+	forwardedDropString      = "drop"
+)
+
+// ParseForwardedHeaderMode parses the string representations of the
+// forwarded header modes: keep, append, overwrite or drop.
+func ParseForwardedHeaderMode(s string) (ForwardedHeaderMode, error) {
+	switch s {
+	case forwardedKeepString:
+		return ForwardedKeep, nil
+	case forwardedAppendString:
+		return ForwardedAppend, nil
+	case forwardedOverwriteString:
+		return ForwardedOverwrite, nil
+	case forwardedDropString:
+		return ForwardedDrop, nil
+	default:
+		return 0, fmt.Errorf("invalid forwarded header mode: %s", s)
+	}
+}
+
+func (m ForwardedHeaderMode) String() string {
+	switch m {
+	case ForwardedAppend:
+		return forwardedAppendString
+	case ForwardedOverwrite:
+		return forwardedOverwriteString
+	case ForwardedDrop:
+		return forwardedDropString
+	default:
+		return forwardedKeepString
+	}
+}
+
+// ForwardedHeaders defines how the proxy handles the X-Forwarded-For,
+// X-Forwarded-Proto and X-Forwarded-Host headers, and the Forwarded
+// header defined in https://tools.ietf.org/html/rfc7239.
+//
+// The zero value passes the headers to the backends unchanged.
+type ForwardedHeaders struct {
+
+	// For defines the handling of the X-Forwarded-For header.
+	For ForwardedHeaderMode
+
+	// Proto defines the handling of the X-Forwarded-Proto header.
+	Proto ForwardedHeaderMode
+
+	// Host defines the handling of the X-Forwarded-Host header.
+	Host ForwardedHeaderMode
+
+	// Forwarded enables setting the Forwarded header. The element of
+	// the current hop is appended to the value received from a trusted
+	// proxy.
+	Forwarded bool
+
+	// TrustedProxies, when set, contains the networks of the proxies in
+	// front, whose forwarded headers are accepted. When a request is
+	// received from a different address, the X-Forwarded-* and
+	// Forwarded headers are removed before applying the other settings.
+	TrustedProxies []*net.IPNet
+}
+
+// ParseCIDRs parses a list of networks in CIDR notation.
+func ParseCIDRs(cidrs []string) ([]*net.IPNet, error) {
+	var nets []*net.IPNet
+	for _, c := range cidrs {
+		_, n, err := net.ParseCIDR(strings.TrimSpace(c))
+		if err != nil {
+			return nil, err
+		}
+
+		nets = append(nets, n)
+	}
+
+	return nets, nil
+}
+
+func (h *ForwardedHeaders) trusted(remoteIP net.IP) bool {
+	if len(h.TrustedProxies) == 0 {
+		return true
+	}
+
+	if remoteIP == nil {
+		return false
+	}
+
+	for _, n := range h.TrustedProxies {
+		if n.Contains(remoteIP) {
+			return true
+		}
+	}
+
+	return false
+}
+
+func setForwardedHeader(out http.Header, key, value string, mode ForwardedHeaderMode) {
+	switch mode {
+	case ForwardedAppend:
+		if out.Get(key) == "" {
+			out.Set(key, value)
+		}
+	case ForwardedOverwrite:
+		out.Set(key, value)
+	case ForwardedDrop:
+		out.Del(key)
+	}
+}
+
+// forwardedNode formats the client address as a node of the Forwarded
+// header, with IPv6 addresses quoted and in brackets.
+func forwardedNode(ip net.IP) string {
+	if ip == nil {
+		return "unknown"
+	}
+
+	if ip.To4() == nil {
+		return fmt.Sprintf(`"[%s]"`, ip)
+	}
+
+	return ip.String()
+}
+
+// Set applies the forwarded header settings to the header of the
+// outgoing request, based on the incoming request.
+func (h *ForwardedHeaders) Set(in *http.Request, out http.Header) {
+	remoteIP := parse(in.RemoteAddr)
+	if !h.trusted(remoteIP) {
+		out.Del("X-Forwarded-For")
+		out.Del("X-Forwarded-Proto")
+		out.Del("X-Forwarded-Host")
+		out.Del("Forwarded")
+	}
+
+	proto := "http"
+	if in.TLS != nil {
+		proto = "https"
+	}
+
+	var client string
+	if remoteIP != nil {
+		client = remoteIP.String()
+	}
+
+	switch h.For {
+	case ForwardedAppend:
+		if client == "" {
+			break
+		}
+
+		if prior := strings.Join(out["X-Forwarded-For"], ", "); prior != "" {
+			out.Set("X-Forwarded-For", prior+", "+client)
+		} else {
+			out.Set("X-Forwarded-For", client)
+		}
+	default:
+		if client != "" || h.For == ForwardedDrop {
+			setForwardedHeader(out, "X-Forwarded-For", client, h.For)
+		}
+	}
+
+	setForwardedHeader(out, "X-Forwarded-Proto", proto, h.Proto)
+	setForwardedHeader(out, "X-Forwarded-Host", in.Host, h.Host)
+
+	if h.Forwarded {
+		element := fmt.Sprintf("for=%s;proto=%s", forwardedNode(remoteIP), proto)
+		if in.Host != "" {
+			element += fmt.Sprintf(`;host="%s"`, in.Host)
+		}
+
+		if prior := strings.Join(out["Forwarded"], ", "); prior != "" {
+			element = prior + ", " + element
+		}
+
+		out.Set("Forwarded", element)
+	}
+}
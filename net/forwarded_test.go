@@ -0,0 +1,133 @@
+package net
+
+import (
+	"crypto/tls"
+	"net/http"
+	"reflect"
+	"testing"
+)
+
+func TestParseForwardedHeaderMode(t *testing.T) {
+	for _, s := range []string{"keep", "append", "overwrite", "drop"} {
+		m, err := ParseForwardedHeaderMode(s)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		if m.String() != s {
+			t.Errorf("failed to parse mode, expected: %s, got: %s", s, m)
+		}
+	}
+
+	if _, err := ParseForwardedHeaderMode("foo"); err == nil {
+		t.Error("failed to fail")
+	}
+}
+
+func TestForwardedHeaders(t *testing.T) {
+	trusted, err := ParseCIDRs([]string{"10.0.0.0/8"})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	for _, tt := range []struct {
+		name       string
+		headers    ForwardedHeaders
+		remoteAddr string
+		tls        bool
+		in         http.Header
+		expected   http.Header
+	}{{
+		name:       "keep by default",
+		remoteAddr: "10.0.0.1:1234",
+		in:         http.Header{"X-Forwarded-For": []string{"1.2.3.4"}},
+		expected:   http.Header{"X-Forwarded-For": []string{"1.2.3.4"}},
+	}, {
+		name:       "append for",
+		headers:    ForwardedHeaders{For: ForwardedAppend},
+		remoteAddr: "10.0.0.1:1234",
+		in:         http.Header{"X-Forwarded-For": []string{"1.2.3.4", "5.6.7.8"}},
+		expected:   http.Header{"X-Forwarded-For": []string{"1.2.3.4, 5.6.7.8, 10.0.0.1"}},
+	}, {
+		name:       "append for without prior",
+		headers:    ForwardedHeaders{For: ForwardedAppend},
+		remoteAddr: "10.0.0.1:1234",
+		in:         http.Header{},
+		expected:   http.Header{"X-Forwarded-For": []string{"10.0.0.1"}},
+	}, {
+		name:       "overwrite",
+		headers:    ForwardedHeaders{For: ForwardedOverwrite, Proto: ForwardedOverwrite, Host: ForwardedOverwrite},
+		remoteAddr: "10.0.0.1:1234",
+		tls:        true,
+		in: http.Header{
+			"X-Forwarded-For":   []string{"1.2.3.4"},
+			"X-Forwarded-Proto": []string{"http"},
+			"X-Forwarded-Host":  []string{"other.example.org"},
+		},
+		expected: http.Header{
+			"X-Forwarded-For":   []string{"10.0.0.1"},
+			"X-Forwarded-Proto": []string{"https"},
+			"X-Forwarded-Host":  []string{"www.example.org"},
+		},
+	}, {
+		name:       "append keeps proto and host",
+		headers:    ForwardedHeaders{Proto: ForwardedAppend, Host: ForwardedAppend},
+		remoteAddr: "10.0.0.1:1234",
+		in:         http.Header{"X-Forwarded-Proto": []string{"https"}},
+		expected: http.Header{
+			"X-Forwarded-Proto": []string{"https"},
+			"X-Forwarded-Host":  []string{"www.example.org"},
+		},
+	}, {
+		name:       "drop",
+		headers:    ForwardedHeaders{For: ForwardedDrop, Proto: ForwardedDrop, Host: ForwardedDrop},
+		remoteAddr: "10.0.0.1:1234",
+		in: http.Header{
+			"X-Forwarded-For":   []string{"1.2.3.4"},
+			"X-Forwarded-Proto": []string{"http"},
+			"X-Forwarded-Host":  []string{"other.example.org"},
+		},
+		expected: http.Header{},
+	}, {
+		name:       "untrusted proxy",
+		headers:    ForwardedHeaders{For: ForwardedAppend, TrustedProxies: trusted},
+		remoteAddr: "192.168.0.1:1234",
+		in: http.Header{
+			"X-Forwarded-For":   []string{"1.2.3.4"},
+			"X-Forwarded-Proto": []string{"http"},
+			"Forwarded":         []string{"for=1.2.3.4"},
+		},
+		expected: http.Header{"X-Forwarded-For": []string{"192.168.0.1"}},
+	}, {
+		name:       "trusted proxy",
+		headers:    ForwardedHeaders{For: ForwardedAppend, TrustedProxies: trusted},
+		remoteAddr: "10.0.0.1:1234",
+		in:         http.Header{"X-Forwarded-For": []string{"1.2.3.4"}},
+		expected:   http.Header{"X-Forwarded-For": []string{"1.2.3.4, 10.0.0.1"}},
+	}, {
+		name:       "forwarded",
+		headers:    ForwardedHeaders{Forwarded: true},
+		remoteAddr: "10.0.0.1:1234",
+		tls:        true,
+		in:         http.Header{},
+		expected:   http.Header{"Forwarded": []string{`for=10.0.0.1;proto=https;host="www.example.org"`}},
+	}, {
+		name:       "forwarded appended, ipv6",
+		headers:    ForwardedHeaders{Forwarded: true},
+		remoteAddr: "[2001:db8::1]:1234",
+		in:         http.Header{"Forwarded": []string{"for=1.2.3.4"}},
+		expected:   http.Header{"Forwarded": []string{`for=1.2.3.4, for="[2001:db8::1]";proto=http;host="www.example.org"`}},
+	}} {
+		t.Run(tt.name, func(t *testing.T) {
+			r := &http.Request{RemoteAddr: tt.remoteAddr, Host: "www.example.org", Header: make(http.Header)}
+			if tt.tls {
+				r.TLS = &tls.ConnectionState{}
+			}
+
+			tt.headers.Set(r, tt.in)
+			if !reflect.DeepEqual(tt.in, tt.expected) {
+				t.Errorf("unexpected headers, expected: %v, got: %v", tt.expected, tt.in)
+			}
+		})
+	}
+}
@@ -0,0 +1,94 @@
+package net
+
+import (
+	"bufio"
+	"compress/gzip"
+	"io"
+	"net/http"
+	"strings"
+
+	log "github.com/sirupsen/logrus"
+)
+
+// requestGzip sets the Accept-Encoding header, such that the wrapped
+// http.Transport does not decode the response and we can do it with
+// fallback support or after verifying the digest of the encoded
+// body. It returns false, if the request would not be transparently
+// decoded by the http.Transport.
+func (t *Transport) requestGzip(req *http.Request) (*http.Request, bool) {
+	if (!t.gzipDecodeFallback && !t.digest.verify) || t.tr.DisableCompression ||
+		req.Method == http.MethodHead || req.Header.Get("Accept-Encoding") != "" ||
+		req.Header.Get("Range") != "" {
+		return req, false
+	}
+
+	r := new(http.Request)
+	*r = *req
+	r.Header = req.Header.Clone()
+	r.Header.Set("Accept-Encoding", "gzip")
+	return r, true
+}
+
+// decodeGzip decodes gzip encoded response bodies. If the body is not
+// gzip encoded, although the upstream said so, and the fallback is
+// enabled, it returns the raw body and keeps the Content-Encoding
+// header.
+func (t *Transport) decodeGzip(rsp *http.Response) *http.Response {
+	if !strings.EqualFold(rsp.Header.Get("Content-Encoding"), "gzip") || rsp.Body == nil {
+		return rsp
+	}
+
+	br := bufio.NewReader(rsp.Body)
+	magic, err := br.Peek(2)
+	if err != nil && len(magic) == 0 {
+		rsp.Body = &bufferedBody{Reader: br, Closer: rsp.Body}
+		return rsp
+	}
+
+	if t.gzipDecodeFallback && (len(magic) < 2 || magic[0] != 0x1f || magic[1] != 0x8b) {
+		if t.gzipDecodeFallbackLog {
+			log.Warnf("Failed to decode gzip response from %s, falling back to the raw body", t.redactURL(rsp.Request.URL))
+		}
+
+		rsp.Body = &bufferedBody{Reader: br, Closer: rsp.Body}
+		return rsp
+	}
+
+	rsp.Body = &gzipBody{br: br, body: rsp.Body}
+	rsp.Header.Del("Content-Encoding")
+	rsp.Header.Del("Content-Length")
+	rsp.ContentLength = -1
+	rsp.Uncompressed = true
+	return rsp
+}
+
+type bufferedBody struct {
+	io.Reader
+	io.Closer
+}
+
+// gzipBody lazily creates the gzip reader on first Read, the same
+// way as the http.Transport does.
+type gzipBody struct {
+	br   *bufio.Reader
+	body io.ReadCloser
+	zr   *gzip.Reader
+	err  error
+}
+
+func (b *gzipBody) Read(p []byte) (int, error) {
+	if b.zr == nil {
+		if b.err == nil {
+			b.zr, b.err = gzip.NewReader(b.br)
+		}
+		if b.err != nil {
+			return 0, b.err
+		}
+	}
+
+	return b.zr.Read(p)
+}
+
+func (b *gzipBody) Close() error {
+	return b.body.Close()
+}
@@ -0,0 +1,88 @@
+package net
+
+import (
+	"bytes"
+	"compress/gzip"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+func TestGzipDecodeFallback(t *testing.T) {
+	var gzipped bytes.Buffer
+	zw := gzip.NewWriter(&gzipped)
+	zw.Write([]byte("compressed content"))
+	zw.Close()
+
+	for _, tt := range []struct {
+		name         string
+		options      Options
+		body         []byte
+		wantBody     string
+		wantEncoding string
+		wantErr      bool
+	}{{
+		name:     "strict transparent decoding of a valid body",
+		body:     gzipped.Bytes(),
+		wantBody: "compressed content",
+	}, {
+		name:    "strict transparent decoding of a mislabeled body",
+		body:    []byte("plain content"),
+		wantErr: true,
+	}, {
+		name:     "fallback decoding of a valid body",
+		options:  Options{GzipDecodeFallback: true},
+		body:     gzipped.Bytes(),
+		wantBody: "compressed content",
+	}, {
+		name:         "fallback decoding of a mislabeled body",
+		options:      Options{GzipDecodeFallback: true, GzipDecodeFallbackLog: true},
+		body:         []byte("plain content"),
+		wantBody:     "plain content",
+		wantEncoding: "gzip",
+	}, {
+		name:         "fallback decoding of an empty body",
+		options:      Options{GzipDecodeFallback: true},
+		body:         nil,
+		wantBody:     "",
+		wantEncoding: "gzip",
+	}} {
+		t.Run(tt.name, func(t *testing.T) {
+			s := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				w.Header().Set("Content-Encoding", "gzip")
+				w.Write(tt.body)
+			}))
+			defer s.Close()
+
+			rt := NewTransport(tt.options)
+			defer rt.Close()
+
+			req, _ := http.NewRequest("GET", s.URL, nil)
+			rsp, err := rt.RoundTrip(req)
+			if err != nil {
+				t.Fatalf("Failed to do request: %v", err)
+			}
+			defer rsp.Body.Close()
+
+			if req.Header.Get("Accept-Encoding") != "" {
+				t.Errorf("Failed to keep the request header unchanged: %v", req.Header)
+			}
+
+			b, err := ioutil.ReadAll(rsp.Body)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("Failed to read body, error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if tt.wantErr {
+				return
+			}
+
+			if string(b) != tt.wantBody {
+				t.Errorf("Failed to get body: got %q, want %q", b, tt.wantBody)
+			}
+			if got := rsp.Header.Get("Content-Encoding"); got != tt.wantEncoding {
+				t.Errorf("Failed to get Content-Encoding: got %q, want %q", got, tt.wantEncoding)
+			}
+		})
+	}
+}
@@ -0,0 +1,137 @@
+package net
+
+import (
+	"context"
+	"net/http"
+	"sync"
+	"time"
+
+	"github.com/opentracing/opentracing-go"
+	log "github.com/sirupsen/logrus"
+)
+
+const defaultHTTP3FallbackDuration = 5 * time.Minute
+
+// http3Transport sends HTTPS requests with the HTTP/3 round tripper
+// and falls back to the HTTP/2 and HTTP/1.1 Transport, if it fails.
+// Hosts, that failed, are not tried with HTTP/3 again for the
+// fallback duration.
+type http3Transport struct {
+	rt               http.RoundTripper
+	fallbackDuration time.Duration
+
+	mu     sync.Mutex
+	broken map[string]time.Time
+}
+
+type http3SpanKey struct{}
+
+// TraceHTTP3Handshake logs the start of the QUIC handshake of an HTTP/3
+// connection to the span of the request, and returns the function
+// logging its end. QUIC round trippers don't report the handshake to
+// the httptrace.ClientTrace of the request, so the HTTP3RoundTripper
+// should call it when dialing, with the context passed to the dial
+// function, e.g.:
+//
+//	Dial: func(ctx context.Context, addr string, tlsCfg *tls.Config, cfg *quic.Config) (quic.EarlyConnection, error) {
+//		done := net.TraceHTTP3Handshake(ctx)
+//		c, err := quic.DialAddrEarlyContext(ctx, addr, tlsCfg, cfg)
+//		done(err)
+//		return c, err
+//	}
+//
+// It is a noop for the requests not sent by a tracing Transport.
+func TraceHTTP3Handshake(ctx context.Context) func(error) {
+	span, ok := ctx.Value(http3SpanKey{}).(opentracing.Span)
+	if !ok {
+		return func(error) {}
+	}
+
+	span.LogKV("QUIC_handshake", "start")
+	return func(err error) {
+		if err != nil {
+			span.LogKV("QUIC_handshake", "end", "error", err.Error())
+			return
+		}
+
+		span.LogKV("QUIC_handshake", "end")
+	}
+}
+
+func newHTTP3Transport(o Options) *http3Transport {
+	if !o.EnableHTTP3 {
+		return nil
+	}
+	if o.HTTP3RoundTripper == nil {
+		log.Warn("HTTP/3 is enabled without HTTP3RoundTripper, using HTTP/2 and HTTP/1.1 only")
+		return nil
+	}
+
+	d := o.HTTP3FallbackDuration
+	if d <= 0 {
+		d = defaultHTTP3FallbackDuration
+	}
+	return &http3Transport{
+		rt:               o.HTTP3RoundTripper,
+		fallbackDuration: d,
+		broken:           make(map[string]time.Time),
+	}
+}
+
+func (h *http3Transport) usable(req *http.Request) bool {
+	if h == nil || req.URL.Scheme != "https" {
+		return false
+	}
+
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	until, ok := h.broken[req.URL.Host]
+	if ok && time.Now().After(until) {
+		delete(h.broken, req.URL.Host)
+		return true
+	}
+	return !ok
+}
+
+func (h *http3Transport) markBroken(host string) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	h.broken[host] = time.Now().Add(h.fallbackDuration)
+}
+
+// roundTripHTTP3 tries to send the request with HTTP/3 first, if
+// enabled, and falls back to roundTripRetry.
+func (t *Transport) roundTripHTTP3(req *http.Request, span opentracing.Span, ro RequestOptions) (*http.Response, int, error) {
+	if !t.http3.usable(req) {
+		return t.roundTripRetry(req, span, ro)
+	}
+
+	h3req := req
+	if span != nil {
+		span.LogKV("HTTP3", "start")
+		h3req = req.WithContext(context.WithValue(req.Context(), http3SpanKey{}, span))
+	}
+	rsp, err := t.http3.rt.RoundTrip(h3req)
+	if err == nil {
+		if span != nil {
+			span.LogKV("HTTP3", "end")
+		}
+		return rsp, 0, nil
+	}
+
+	hasBody := req.Body != nil && req.Body != http.NoBody
+	if req.Context().Err() != nil || hasBody && req.GetBody == nil {
+		return nil, 0, err
+	}
+
+	fallbackReq, rerr := rewindRequest(req)
+	if rerr != nil {
+		return nil, 0, err
+	}
+
+	t.http3.markBroken(req.URL.Host)
+	if span != nil {
+		span.LogKV("HTTP3", "fallback", "error", err.Error())
+	}
+	return t.roundTripRetry(fallbackReq, span, ro)
+}
@@ -0,0 +1,193 @@
+package net
+
+import (
+	"context"
+	"crypto/tls"
+	"errors"
+	"io"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/opentracing/opentracing-go/mocktracer"
+	"github.com/zalando/skipper/tracing/tracingtest"
+)
+
+func TestHTTP3(t *testing.T) {
+	s := httptest.NewTLSServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte(r.Proto))
+	}))
+	defer s.Close()
+	plain := httptest.NewServer(s.Config.Handler)
+	defer plain.Close()
+
+	for _, tt := range []struct {
+		name      string
+		h3Err     error
+		url       string
+		body      io.Reader
+		wantCalls int
+		wantProto []string
+		wantErr   bool
+	}{{
+		name:      "HTTP/3",
+		url:       s.URL,
+		wantCalls: 2,
+		wantProto: []string{"HTTP/3.0", "HTTP/3.0"},
+	}, {
+		name:      "fallback",
+		h3Err:     errors.New("no QUIC"),
+		url:       s.URL,
+		wantCalls: 1,
+		wantProto: []string{"HTTP/1.1", "HTTP/1.1"},
+	}, {
+		name:      "plaintext requests",
+		url:       plain.URL,
+		wantCalls: 0,
+		wantProto: []string{"HTTP/1.1", "HTTP/1.1"},
+	}, {
+		name:      "no fallback for bodies not sent again",
+		h3Err:     errors.New("no QUIC"),
+		url:       s.URL,
+		body:      ioutil.NopCloser(strings.NewReader("foo")),
+		wantCalls: 2,
+		wantErr:   true,
+	}} {
+		t.Run(tt.name, func(t *testing.T) {
+			calls := 0
+			h3 := roundTripperFunc(func(req *http.Request) (*http.Response, error) {
+				calls++
+				if tt.h3Err != nil {
+					return nil, tt.h3Err
+				}
+				return &http.Response{
+					StatusCode: http.StatusOK,
+					Proto:      "HTTP/3.0",
+					Body:       ioutil.NopCloser(strings.NewReader("HTTP/3.0")),
+					Request:    req,
+				}, nil
+			})
+
+			rt := NewTransport(Options{
+				Tracer:            &tracingtest.Tracer{},
+				TLSClientConfig:   &tls.Config{InsecureSkipVerify: true},
+				EnableHTTP3:       true,
+				HTTP3RoundTripper: h3,
+			})
+			defer rt.Close()
+			rt = WithSpanName(rt, "myspan")
+
+			var protos []string
+			for i := 0; i < 2; i++ {
+				req, _ := http.NewRequest("POST", tt.url, tt.body)
+				rsp, err := rt.RoundTrip(req)
+				if err != nil {
+					if !tt.wantErr {
+						t.Fatalf("Failed to do request: %v", err)
+					}
+					continue
+				}
+
+				b, _ := ioutil.ReadAll(rsp.Body)
+				rsp.Body.Close()
+				protos = append(protos, string(b))
+			}
+
+			if calls != tt.wantCalls {
+				t.Errorf("Failed to get HTTP/3 calls: got %d, want %d", calls, tt.wantCalls)
+			}
+			if !tt.wantErr && strings.Join(protos, ",") != strings.Join(tt.wantProto, ",") {
+				t.Errorf("Failed to get protocols: got %v, want %v", protos, tt.wantProto)
+			}
+		})
+	}
+}
+
+func TestHTTP3FallbackExpires(t *testing.T) {
+	h := newHTTP3Transport(Options{
+		EnableHTTP3:           true,
+		HTTP3RoundTripper:     roundTripperFunc(nil),
+		HTTP3FallbackDuration: 10 * time.Millisecond,
+	})
+
+	req, _ := http.NewRequest("GET", "https://example.org/", nil)
+	h.markBroken(req.URL.Host)
+	if h.usable(req) {
+		t.Error("Failed to fall back")
+	}
+
+	time.Sleep(20 * time.Millisecond)
+	if !h.usable(req) {
+		t.Error("Failed to use HTTP/3 again")
+	}
+}
+
+func TestHTTP3HandshakeTrace(t *testing.T) {
+	for _, tt := range []struct {
+		name    string
+		dialErr error
+		want    []string
+	}{{
+		name: "handshake",
+		want: []string{"HTTP3:start", "QUIC_handshake:start", "QUIC_handshake:end", "HTTP3:end"},
+	}, {
+		name:    "failed handshake",
+		dialErr: errors.New("handshake timeout"),
+		want:    []string{"HTTP3:start", "QUIC_handshake:start", "QUIC_handshake:end", "error:handshake timeout", "HTTP3:fallback", "error:handshake timeout"},
+	}} {
+		t.Run(tt.name, func(t *testing.T) {
+			h3 := roundTripperFunc(func(req *http.Request) (*http.Response, error) {
+				TraceHTTP3Handshake(req.Context())(tt.dialErr)
+				if tt.dialErr != nil {
+					return nil, tt.dialErr
+				}
+
+				return &http.Response{StatusCode: http.StatusOK, Body: http.NoBody, Request: req}, nil
+			})
+
+			tracer := mocktracer.New()
+			rt := NewTransport(Options{
+				Tracer:            tracer,
+				EnableHTTP3:       true,
+				HTTP3RoundTripper: h3,
+			})
+			defer rt.Close()
+			rt = WithSpanName(rt, "myspan")
+
+			// the fallback fails, nothing is listening
+			req, _ := http.NewRequest("GET", "https://127.0.0.1:1/", nil)
+			if rsp, err := rt.RoundTrip(req); err == nil {
+				rsp.Body.Close()
+			}
+
+			spans := tracer.FinishedSpans()
+			if len(spans) != 1 {
+				t.Fatalf("Failed to get the span: %d", len(spans))
+			}
+
+			var got []string
+			for _, l := range spans[0].Logs() {
+				for _, f := range l.Fields {
+					switch f.Key {
+					case "HTTP3", "QUIC_handshake", "error":
+						if f.Key != "error" || strings.HasPrefix(f.ValueString, "handshake") {
+							got = append(got, f.Key+":"+f.ValueString)
+						}
+					}
+				}
+			}
+
+			if strings.Join(got, ",") != strings.Join(tt.want, ",") {
+				t.Errorf("Failed to get the span logs: got %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestHTTP3HandshakeTraceNoSpan(t *testing.T) {
+	// must not panic
+	TraceHTTP3Handshake(context.Background())(nil)
+}
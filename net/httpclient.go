@@ -1,19 +1,209 @@
 package net
 
 import (
+	"context"
 	"crypto/tls"
+	"errors"
+	"io"
+	"net"
 	"net/http"
 	"net/http/httptrace"
+	"net/url"
+	"strings"
+	"sync"
+	"syscall"
 	"time"
 
 	"github.com/opentracing/opentracing-go"
 	"github.com/opentracing/opentracing-go/ext"
+	"github.com/zalando/skipper/circuit"
+	"github.com/zalando/skipper/filters/flowid"
+	"github.com/zalando/skipper/secrets"
+	"go.opentelemetry.io/otel/trace"
+	"golang.org/x/oauth2"
+	"golang.org/x/oauth2/clientcredentials"
 )
 
 const (
 	defaultIdleConnTimeout = 30 * time.Second
+	defaultDNSRetryBackoff = 50 * time.Millisecond
 )
 
+const (
+	retryReasonTag = "http.retry_reason"
+	retryCountTag  = "http.retry_count"
+	timeoutTag     = "http.timeout"
+)
+
+// Client adds additional features like bearer token injection and
+// opentracing to the wrapped http.Client with the same interface as
+// http.Client from the stdlib.
+type Client struct {
+	once                    sync.Once
+	client                  http.Client
+	tr                      *Transport
+	ndjsonDecodeErrorPolicy DecodeErrorPolicy
+
+	forceHTTPS *forceHTTPS
+	sr         secrets.SecretsReader
+	ts         oauth2.TokenSource
+	limiter    *priorityLimiter
+	retry      *retryPolicy
+
+	requestTimeout time.Duration
+
+	mu          sync.Mutex
+	shutdown    bool
+	inflight    sync.WaitGroup
+	tokenExpiry time.Time
+}
+
+// NewClient creates a wrapped http.Client and uses Transport to
+// support opentracing. On teardown you have to use Close() to not
+// leak a goroutine.
+func NewClient(o Options) *Client {
+	tr := NewTransport(o)
+	if o.OpentracingSpanName != "" {
+		tr = WithSpanName(tr, o.OpentracingSpanName)
+	}
+	if o.OpentracingComponentTag != "" {
+		tr = WithComponentTag(tr, o.OpentracingComponentTag)
+	}
+
+	var rt http.RoundTripper = tr
+	for i := len(o.Middleware) - 1; i >= 0; i-- {
+		rt = o.Middleware[i](rt)
+	}
+
+	return &Client{
+		client: http.Client{
+			Transport:     rt,
+			CheckRedirect: newCheckRedirect(o),
+		},
+		tr:                      tr,
+		ndjsonDecodeErrorPolicy: o.NDJSONDecodeErrorPolicy,
+		forceHTTPS:              newForceHTTPS(o),
+		sr:                      o.SecretsReader,
+		ts:                      newTokenSource(o),
+		limiter:                 newPriorityLimiter(o.MaxConcurrentRequests, o.PriorityAging),
+		retry:                   newRetryPolicy(o),
+		requestTimeout:          o.RequestTimeout,
+	}
+}
+
+// Close stops the background goroutine of the wrapped Transport. It
+// does not wait for in-flight requests, see Shutdown. It is safe to
+// call Close multiple times.
+func (c *Client) Close() {
+	c.once.Do(func() {
+		c.tr.Close()
+	})
+}
+
+// Head is a wrapper for http.Client.Head
+func (c *Client) Head(url string) (*http.Response, error) {
+	req, err := http.NewRequest(http.MethodHead, url, nil)
+	if err != nil {
+		return nil, err
+	}
+	return c.Do(req)
+}
+
+// Get is a wrapper for http.Client.Get
+func (c *Client) Get(url string) (*http.Response, error) {
+	req, err := http.NewRequest(http.MethodGet, url, nil)
+	if err != nil {
+		return nil, err
+	}
+	return c.Do(req)
+}
+
+// Post is a wrapper for http.Client.Post
+func (c *Client) Post(url, contentType string, body io.Reader) (*http.Response, error) {
+	req, err := http.NewRequest(http.MethodPost, url, body)
+	if err != nil {
+		return nil, err
+	}
+	req.Header.Set("Content-Type", contentType)
+	return c.Do(req)
+}
+
+// PostForm is a wrapper for http.Client.PostForm
+func (c *Client) PostForm(url string, data url.Values) (*http.Response, error) {
+	return c.Post(url, "application/x-www-form-urlencoded", strings.NewReader(data.Encode()))
+}
+
+// Do is a wrapper for http.Client.Do. After Shutdown was called, it
+// returns ErrClientShutdown. Plaintext requests to hosts, that
+// require HTTPS, are upgraded or rejected, see Options.ForceHTTPS. If
+// Options.TokenSource or Options.ClientCredentials is set, the current
+// access token is injected, otherwise if Options.SecretsReader is set,
+// the secret found for the request URL is injected as bearer token. If
+// Options.MaxConcurrentRequests is exceeded, the request is queued by
+// its RequestOptions.Priority. If Options.MaxRetries is set, failed
+// requests are retried, see Options.RetryStatusCodes and
+// Options.RetryMethods. Requests without a context deadline get the
+// Options.RequestTimeout. Timeouts are returned as *TimeoutError
+// wrapped in a *url.Error.
+func (c *Client) Do(req *http.Request) (*http.Response, error) {
+	req, err := c.forceHTTPS.apply(req)
+	if err != nil {
+		return nil, err
+	}
+
+	if c.ts != nil {
+		if err := c.injectToken(req); err != nil {
+			return nil, err
+		}
+	} else if sr := c.secretsReader(req.URL); sr != nil {
+		if b, ok := sr.GetSecret(req.URL.String()); ok {
+			req.Header.Set("Authorization", "Bearer "+string(b))
+
+			expiry, _ := secretExpiry(sr, req.URL.String(), b)
+			c.mu.Lock()
+			c.tokenExpiry = expiry
+			c.mu.Unlock()
+		}
+	}
+
+	c.mu.Lock()
+	if c.shutdown {
+		c.mu.Unlock()
+		return nil, ErrClientShutdown
+	}
+	c.inflight.Add(1)
+	c.mu.Unlock()
+
+	req, cancel := c.withRequestTimeout(req)
+	ro, _ := RequestOptionsFromContext(req.Context())
+	if err := c.limiter.acquire(req.Context(), ro.Priority); err != nil {
+		cancel()
+		c.inflight.Done()
+		return nil, err
+	}
+
+	done := func() {
+		c.limiter.release()
+		c.inflight.Done()
+		cancel()
+	}
+
+	rsp, err := c.doRetry(req)
+	if err != nil {
+		done()
+		return nil, wrapTimeoutError(err)
+	}
+
+	rsp.Body = &inflightBody{ReadCloser: rsp.Body, done: done}
+	return rsp, nil
+}
+
+// CloseIdleConnections closes idle connections of the wrapped
+// Transport.
+func (c *Client) CloseIdleConnections() {
+	c.tr.closeIdleConnections()
+}
+
 // Options are mostly passed to the http.Transport of the same
 // name. Options.Timeout can be used as default for all timeouts, that
 // are not set. You can pass an opentracing.Tracer
@@ -43,10 +233,46 @@ type Options struct {
 	// Timeout sets all Timeouts, that are set to 0 to the given
 	// value. Basically it's the default timeout value.
 	Timeout time.Duration
+	// RequestTimeout is the total timeout of a request sent by the
+	// Client including queueing, retries and reading the response
+	// body. It is only applied to requests, which context has no
+	// deadline. Default: 0, no timeout.
+	RequestTimeout time.Duration
 	// TLSHandshakeTimeout see
 	// https://golang.org/pkg/net/http/#Transport.TLSHandshakeTimeout,
 	// if not set or set to 0, its using Options.Timeout.
 	TLSHandshakeTimeout time.Duration
+	// TLSClientConfig see
+	// https://golang.org/pkg/net/http/#Transport.TLSClientConfig,
+	// e.g. to set client certificates, custom root CAs or the minimum
+	// TLS version. The config is cloned, later changes have no
+	// effect. Default: nil, the default configuration of crypto/tls.
+	TLSClientConfig *tls.Config
+	// Proxy see https://golang.org/pkg/net/http/#Transport.Proxy,
+	// it returns the URL of the egress proxy for a request.
+	Proxy func(*http.Request) (*url.URL, error)
+	// ProxyFromEnvironment uses the proxies configured by the
+	// HTTP_PROXY, HTTPS_PROXY and NO_PROXY environment variables,
+	// see https://golang.org/pkg/net/http/#ProxyFromEnvironment. It
+	// is ignored, if Proxy is set.
+	ProxyFromEnvironment bool
+	// EnableHTTP3 sends HTTPS requests with the HTTP3RoundTripper
+	// first. If it fails, the request is sent again with HTTP/2 or
+	// HTTP/1.1 and the host is not tried with HTTP/3 again for the
+	// HTTP3FallbackDuration. Requests with a body, that can not be
+	// sent again, do not fall back. The HTTP/3 attempt, its result
+	// and the fallback are logged to the span.
+	EnableHTTP3 bool
+	// HTTP3RoundTripper is the QUIC capable round tripper used, if
+	// EnableHTTP3 is set, e.g. the http3.RoundTripper of
+	// github.com/lucas-clemente/quic-go. Its connections are not
+	// managed by the Transport. EnableHTTP3 is ignored, if not set.
+	// To log the QUIC handshake to the span, like the TLS handshake
+	// of the other connections, it should call TraceHTTP3Handshake.
+	HTTP3RoundTripper http.RoundTripper
+	// HTTP3FallbackDuration is the time a host, that failed with
+	// HTTP/3, is only requested with HTTP/2 or HTTP/1.1. Default: 5m.
+	HTTP3FallbackDuration time.Duration
 	// IdleConnTimeout see
 	// https://golang.org/pkg/net/http/#Transport.IdleConnTimeout,
 	// if not set or set to 0, its using Options.Timeout.
@@ -59,19 +285,266 @@ type Options struct {
 	// https://golang.org/pkg/net/http/#Transport.ExpectContinueTimeout,
 	// if not set or set to 0, its using Options.Timeout.
 	ExpectContinueTimeout time.Duration
+	// TCPUserTimeout sets the TCP_USER_TIMEOUT socket option on all
+	// connections, which bounds how long transmitted data may stay
+	// unacknowledged before the connection is closed. This detects
+	// dead peers faster than TCP keep-alive probes. It is only
+	// supported on Linux and ignored on other platforms. Default: 0,
+	// use the system default.
+	TCPUserTimeout time.Duration
+	// DialContext is used to establish the TCP connections instead
+	// of a net.Dialer, see
+	// https://golang.org/pkg/net/http/#Transport.DialContext.
+	// TCPUserTimeout is not applied to the connections.
+	DialContext func(ctx context.Context, network, addr string) (net.Conn, error)
+	// UnixSocketPath makes the Transport dial all connections to the
+	// unix domain socket at the given path instead of the request
+	// host, e.g. to talk to a sidecar. The request URL is used
+	// unchanged, e.g. http://sidecar/path. DialContext, StaticHosts,
+	// Resolver and TCPUserTimeout are ignored for unix
+	// sockets. Default: "", connect via TCP.
+	UnixSocketPath string
+	// IPFamily restricts or orders the address families of the
+	// connections, e.g. for IPv6 only or broken dual stack
+	// environments. Default: IPFamilyAny.
+	IPFamily IPFamily
+	// FallbackDelay see
+	// https://golang.org/pkg/net/#Dialer.FallbackDelay, the time to
+	// wait for an IPv6 connection before dialing IPv4 in parallel. A
+	// negative value disables the parallel dial. It is ignored, if
+	// DialContext is set. Default: 0, 300ms.
+	FallbackDelay time.Duration
+	// StaticHosts maps hostnames to the IPs, that are used instead of
+	// resolving the hostnames. The IPs are dialed in order, until a
+	// connection is established.
+	StaticHosts map[string][]string
+	// Resolver resolves the hostnames, that are not in StaticHosts,
+	// e.g. to integrate a service discovery. Default: nil, the
+	// hostnames are resolved by the dialer.
+	Resolver Resolver
+	// DNSRetries is the number of retries of idempotent requests,
+	// that failed because of a transient DNS resolution failure,
+	// e.g. a resolver timeout or SERVFAIL. If set, the last address a
+	// host was connected to is also cached and used instead of
+	// retrying, while the resolver fails. Default: 0, no retries.
+	DNSRetries int
+	// DNSRetryBackoff is the backoff before the first DNS retry, it
+	// is doubled for every following one. Default: 50ms.
+	DNSRetryBackoff time.Duration
+	// MaxResponseBodySize limits the number of bytes, that can be
+	// read from a response body. Reading more returns
+	// ErrResponseBodyTooLarge. RequestOptions.MaxResponseBodySize
+	// has precedence. Default: 0, unlimited.
+	MaxResponseBodySize int64
+	// CircuitBreakers configure client side circuit breakers by
+	// request host, see the circuit package. Settings without Host
+	// are the defaults for all hosts, settings with Host override
+	// them for the host with port. Requests to a host with an open
+	// breaker fail with ErrCircuitOpen without being sent. Errors
+	// and 5xx responses are reported as failures. Default: nil, no
+	// circuit breakers.
+	CircuitBreakers []circuit.BreakerSettings
+	// RequestsPerSecond limits the rate of requests by request host
+	// with a token bucket. Requests exceeding the rate wait for their
+	// turn. If the wait would exceed the deadline of the request
+	// context, the request fails with ErrRateLimited. Default: 0,
+	// unlimited.
+	RequestsPerSecond float64
+	// Burst is the number of requests to a host, that can be sent
+	// at once exceeding RequestsPerSecond. Default: 1.
+	Burst int
+	// PerHostTimeouts override the timeouts by request host. A host
+	// can be configured with or without port, the one with port takes
+	// precedence. Every host has its own connection pool. For hosts,
+	// that are not listed, the global timeouts of Options are
+	// used. Independent of the configured timeouts, an earlier
+	// deadline of the request context cancels the request.
+	PerHostTimeouts map[string]Timeouts
+	// PerHostOptions override the options by request host, e.g. the
+	// timeouts, connection limits, TLS configuration or bearer
+	// tokens, so that a single Client can talk to backends with
+	// different requirements. The hosts are matched like in
+	// PerHostTimeouts. If a host is configured in both, its
+	// PerHostOptions take precedence.
+	PerHostOptions map[string]HostOptions
+	// GzipDecodeFallback returns the raw response body with the
+	// original Content-Encoding header, if the transparent gzip
+	// decoding fails, because the upstream sent a body that is not
+	// gzip encoded. By default the decoding error is returned on
+	// reading the body.
+	GzipDecodeFallback bool
+	// GzipDecodeFallbackLog logs a warning on every fallback to the
+	// raw response body.
+	GzipDecodeFallbackLog bool
+	// NDJSONDecodeErrorPolicy defines how Client.StreamNDJSON
+	// handles lines, that can not be decoded. Default:
+	// DecodeErrorAbort.
+	NDJSONDecodeErrorPolicy DecodeErrorPolicy
+	// RedactQueryParams are the names of query parameters, which
+	// values are replaced by a placeholder, before the URL is used in
+	// span tags and logs. The request is sent with the real
+	// values. It is ignored, if URLRedactor is set.
+	RedactQueryParams []string
+	// URLRedactor returns the string representation of the request
+	// URL, that is used in span tags and logs.
+	URLRedactor func(*url.URL) string
+	// ForceHTTPS makes the Client apply the ForceHTTPSPolicy to all
+	// plaintext requests.
+	ForceHTTPS bool
+	// ForceHTTPSHosts are the hostnames, without port, to which the
+	// Client applies the ForceHTTPSPolicy for plaintext requests.
+	ForceHTTPSHosts []string
+	// ForceHTTPSPolicy defines if plaintext requests to hosts, that
+	// require HTTPS, are upgraded or rejected. Default:
+	// ForceHTTPSUpgrade.
+	ForceHTTPSPolicy ForceHTTPSPolicy
+	// MaxRetries is the number of retries of a request by the
+	// Client, that failed with a transient error, like a connection
+	// failure or a timeout, or with one of the RetryStatusCodes. Only
+	// requests with one of the RetryMethods and a body, that can be
+	// sent again, are retried. Default: 0, no retries.
+	MaxRetries int
+	// RetryBackoff is the backoff before the first retry, it is
+	// doubled for every following retry and randomized by a jitter
+	// between half and the full value. Default: 100ms.
+	RetryBackoff time.Duration
+	// RetryMaxBackoff limits the backoff between retries. Default:
+	// 5s.
+	RetryMaxBackoff time.Duration
+	// RetryBudget limits the total time of a request including all
+	// attempts and waits. A retry, that would exceed the budget, is
+	// not made and the last result is returned. Default: 0,
+	// unlimited.
+	RetryBudget time.Duration
+	// OnRetry is called for every failed attempt, that is retried,
+	// before waiting for the retry, e.g. to log or meter retries.
+	OnRetry func(RetryAttempt)
+	// RetryStatusCodes are the response status codes, that are
+	// retried. Default: 502, 503 and 504. Independent of the status
+	// codes, 429 and 503 responses with a Retry-After header are
+	// retried after the announced delay instead of the backoff, if
+	// it does not exceed RetryMaxBackoff.
+	RetryStatusCodes []int
+	// RetryMethods are the request methods, that are retried.
+	// Default: the idempotent methods GET, HEAD, OPTIONS, TRACE, PUT
+	// and DELETE.
+	RetryMethods []string
+	// MaxConcurrentRequests limits the number of concurrent requests
+	// of the Client. A request is active until its response body is
+	// closed. Requests exceeding the limit are queued by their
+	// RequestOptions.Priority. Default: 0, unlimited.
+	MaxConcurrentRequests int
+	// PriorityAging increases the priority of a queued request by one
+	// for every PriorityAging it waits, which prevents the starvation
+	// of low priority requests. Default: 0, no aging.
+	PriorityAging time.Duration
+	// RedirectHeaderPolicy defines which request headers are
+	// preserved, when the Client follows a redirect. The zero value
+	// preserves all headers on redirects to the same host and drops
+	// all sensitive headers on redirects to a different host.
+	RedirectHeaderPolicy RedirectHeaderPolicy
+	// MaxRedirects is the maximum number of redirects the Client
+	// follows, before it returns an error. A negative value disables
+	// following redirects, the Client returns the redirect response
+	// instead. Default: 10.
+	MaxRedirects int
+	// CheckRedirect is called by the Client before following a
+	// redirect, after the RedirectHeaderPolicy was applied, see
+	// https://golang.org/pkg/net/http/#Client.CheckRedirect. It is
+	// not called, if MaxRedirects is exceeded.
+	CheckRedirect func(req *http.Request, via []*http.Request) error
+	// SecretsReader is used by the Client to get the bearer token for
+	// a request by the request URL, e.g. secrets.CommandReader.
+	SecretsReader secrets.SecretsReader
+	// TokenSource is used by the Client to get the access token,
+	// that is injected into the Authorization header of every
+	// request. The token is cached until it expires. It takes
+	// precedence over ClientCredentials and SecretsReader.
+	TokenSource oauth2.TokenSource
+	// ClientCredentials configures the OAuth2 client credentials
+	// grant to get the access token, that is injected into the
+	// Authorization header of every request. The token is fetched
+	// from the TokenURL with the default http.Client and refreshed,
+	// when it expires. It takes precedence over SecretsReader.
+	ClientCredentials *clientcredentials.Config
+	// VerifyDigest verifies the response body against the digest
+	// announced in the Content-Digest (RFC 9530) or Digest (RFC 3230)
+	// response header, while the body is read. Supported algorithms
+	// are sha-256 and sha-512. On a mismatch reading the end of the
+	// body returns ErrDigestMismatch. The digest is verified over the
+	// body as sent, before a gzip encoded body is decoded.
+	VerifyDigest bool
+	// RequireDigest enables VerifyDigest and makes RoundTrip return
+	// ErrDigestMissing for responses without a supported digest.
+	RequireDigest bool
+	// CorrelationIDHeader is the header to propagate the ID of the
+	// Correlation found in the request context. Default: X-Flow-Id.
+	CorrelationIDHeader string
+	// CorrelationBaggageHeaders maps keys of the Correlation baggage
+	// to the headers to propagate them. Baggage not listed here is
+	// only propagated as span baggage.
+	CorrelationBaggageHeaders map[string]string
+	// Middleware wrap the Transport of the Client, e.g. to log,
+	// modify or sign requests and responses. The first Middleware is
+	// the outermost one, so it sees the request first and the
+	// response last. They are called for every attempt including
+	// retries and redirects, before the tracing, bearer token and
+	// metrics of the Transport are applied.
+	Middleware []func(http.RoundTripper) http.RoundTripper
+	// MetricsCollector receives the metrics of the sent requests,
+	// defaults to NoopMetricsCollector.
+	MetricsCollector MetricsCollector
+	// Metrics receives the request durations, connection timings,
+	// status codes and retries of the sent requests, e.g. the
+	// metrics.Metrics of the proxy. Default: nil, no metrics.
+	Metrics Metrics
+	// MetricsPrefix is the prefix of all keys passed to
+	// Metrics. Default: "client.".
+	MetricsPrefix string
 	// Tracer instance, can be nil to not enable tracing
 	Tracer opentracing.Tracer
+	// OtelTracerProvider enables OpenTelemetry tracing. Every request
+	// gets a client span with the semantic HTTP attributes, which is
+	// propagated in the W3C traceparent header. It can be used in
+	// addition to the opentracing Tracer. Default: nil, no
+	// OpenTelemetry spans.
+	OtelTracerProvider trace.TracerProvider
+	// OpentracingComponentTag sets component tag for all requests
+	// sent by the Client.
+	OpentracingComponentTag string
+	// OpentracingSpanName sets span name for all requests sent by
+	// the Client.
+	OpentracingSpanName string
 }
 
 // Transport wraps an http.Transport and adds support for tracing and
 // bearerToken injection.
 type Transport struct {
-	quit          chan struct{}
-	tr            *http.Transport
-	tracer        opentracing.Tracer
-	spanName      string
-	componentName string
-	bearerToken   string
+	quit                  chan struct{}
+	tr                    *http.Transport
+	tracer                opentracing.Tracer
+	otelTracer            trace.Tracer
+	spanName              string
+	componentName         string
+	bearerToken           string
+	gzipDecodeFallback    bool
+	gzipDecodeFallbackLog bool
+	metrics               MetricsCollector
+	measures              Metrics
+	metricsPrefix         string
+	urlRedactor           func(*url.URL) string
+	dnsRetries            int
+	dnsRetryBackoff       time.Duration
+	digest                digestOptions
+	hostTransports        map[string]hostTransport
+	stats                 *connStats
+	maxResponseBodySize   int64
+	http3                 *http3Transport
+	breakers              *circuit.Registry
+	rateLimiter           *hostRateLimiter
+
+	correlationIDHeader       string
+	correlationBaggageHeaders map[string]string
 }
 
 // NewTransport creates a wrapped http.Transport, with regular DNS
@@ -84,6 +557,31 @@ func NewTransport(options Options) *Transport {
 		options.Tracer = &opentracing.NoopTracer{}
 	}
 
+	var otelTracer trace.Tracer
+	if options.OtelTracerProvider != nil {
+		otelTracer = options.OtelTracerProvider.Tracer(otelInstrumentationName)
+	}
+
+	if options.URLRedactor == nil && len(options.RedactQueryParams) > 0 {
+		options.URLRedactor = newQueryRedactor(options.RedactQueryParams)
+	}
+
+	if options.MetricsCollector == nil {
+		options.MetricsCollector = NoopMetricsCollector{}
+	}
+
+	if options.MetricsPrefix == "" {
+		options.MetricsPrefix = defaultMetricsPrefix
+	}
+
+	if options.CorrelationIDHeader == "" {
+		options.CorrelationIDHeader = flowid.HeaderName
+	}
+
+	if options.Proxy == nil && options.ProxyFromEnvironment {
+		options.Proxy = http.ProxyFromEnvironment
+	}
+
 	// set timeout defaults
 	if options.TLSHandshakeTimeout == 0 {
 		options.TLSHandshakeTimeout = options.Timeout
@@ -102,7 +600,49 @@ func NewTransport(options Options) *Transport {
 		options.ExpectContinueTimeout = options.Timeout
 	}
 
+	if options.DNSRetryBackoff <= 0 {
+		options.DNSRetryBackoff = defaultDNSRetryBackoff
+	}
+
+	var dnsCache *dnsFallbackCache
+	if options.DNSRetries > 0 {
+		dnsCache = newDNSFallbackCache()
+	}
+
+	stats := &connStats{}
+	resolver := newHostResolver(options)
+	newDial := func(timeout time.Duration, unixSocketPath string) dialFunc {
+		if unixSocketPath != "" {
+			return stats.dialContext(dialUnix(timeout, unixSocketPath))
+		}
+
+		var dial dialFunc
+		if options.DialContext != nil {
+			dial = withDialTimeout(options.DialContext, timeout)
+		} else {
+			dialer := &net.Dialer{
+				Timeout:       timeout,
+				FallbackDelay: options.FallbackDelay,
+			}
+			if options.TCPUserTimeout > 0 {
+				dialer.Control = setTCPUserTimeout(options.TCPUserTimeout)
+			}
+			dial = dialer.DialContext
+		}
+		dial = withIPFamily(dial, options.IPFamily)
+
+		if resolver != nil {
+			dial = resolver.dialContext(dial)
+		}
+		if dnsCache != nil {
+			dial = dnsCache.dialContext(dial)
+		}
+		return stats.dialContext(dial)
+	}
+
 	htransport := &http.Transport{
+		Proxy:                  options.Proxy,
+		DialContext:            newDial(0, options.UnixSocketPath),
 		DisableKeepAlives:      options.DisableKeepAlives,
 		DisableCompression:     options.DisableCompression,
 		ForceAttemptHTTP2:      options.ForceAttemptHTTP2,
@@ -114,21 +654,45 @@ func NewTransport(options Options) *Transport {
 		MaxResponseHeaderBytes: options.MaxResponseHeaderBytes,
 		ResponseHeaderTimeout:  options.ResponseHeaderTimeout,
 		TLSHandshakeTimeout:    options.TLSHandshakeTimeout,
+		TLSClientConfig:        options.TLSClientConfig.Clone(),
 		IdleConnTimeout:        options.IdleConnTimeout,
 		ExpectContinueTimeout:  options.ExpectContinueTimeout,
 	}
 
 	t := &Transport{
-		quit:   make(chan struct{}),
-		tr:     htransport,
-		tracer: options.Tracer,
+		quit:                  make(chan struct{}),
+		tr:                    htransport,
+		tracer:                options.Tracer,
+		otelTracer:            otelTracer,
+		gzipDecodeFallback:    options.GzipDecodeFallback,
+		gzipDecodeFallbackLog: options.GzipDecodeFallbackLog,
+		metrics:               options.MetricsCollector,
+		measures:              options.Metrics,
+		metricsPrefix:         options.MetricsPrefix,
+		urlRedactor:           options.URLRedactor,
+		dnsRetries:            options.DNSRetries,
+		dnsRetryBackoff:       options.DNSRetryBackoff,
+		digest: digestOptions{
+			verify:  options.VerifyDigest || options.RequireDigest,
+			require: options.RequireDigest,
+		},
+		hostTransports: newHostTransports(htransport, options, newDial),
+		stats:          stats,
+
+		maxResponseBodySize: options.MaxResponseBodySize,
+		http3:               newHTTP3Transport(options),
+		breakers:            newBreakerRegistry(options),
+		rateLimiter:         newHostRateLimiter(options),
+
+		correlationIDHeader:       options.CorrelationIDHeader,
+		correlationBaggageHeaders: options.CorrelationBaggageHeaders,
 	}
 
 	go func() {
 		for {
 			select {
 			case <-time.After(options.IdleConnTimeout):
-				htransport.CloseIdleConnections()
+				t.closeIdleConnections()
 			case <-t.quit:
 				return
 			}
@@ -155,7 +719,8 @@ func WithComponentTag(t *Transport, componentName string) *Transport {
 }
 
 // WithBearerToken adds an Authorization header with "Bearer " prefix
-// and add the given bearerToken as value to all requests. To regular
+// and add the given bearerToken as value to all requests, except to
+// redirects to a different host. To regular
 // update your token you need to call this method and use the returned
 // Transport.
 func WithBearerToken(t *Transport, bearerToken string) *Transport {
@@ -175,29 +740,221 @@ func (t *Transport) Close() {
 
 // RoundTrip the request with tracing, bearer token injection and add client
 // tracing: DNS, TCP/IP, TLS handshake, connection pool access. Client
-// traces are added as logs into the created span.
+// traces are added as logs into the created span. RequestOptions
+// found in the request context override the Transport configuration.
 func (t *Transport) RoundTrip(req *http.Request) (*http.Response, error) {
+	if err := t.rateLimiter.wait(req); err != nil {
+		t.incError(req.URL.Host, err)
+		return nil, err
+	}
+
+	breakerDone, ok := t.checkBreaker(req)
+	if !ok {
+		t.incError(req.URL.Host, ErrCircuitOpen)
+		return nil, ErrCircuitOpen
+	}
+
+	ro, _ := RequestOptionsFromContext(req.Context())
+	if ht, ok := t.hostTransport(req); ok && ro.Timeout <= 0 {
+		ro.Timeout = ht.total
+	}
+	if ro.MaxResponseBodySize <= 0 {
+		ro.MaxResponseBodySize = t.maxResponseBodySize
+	}
+	req, cancel := ro.withTimeout(req)
+
 	var span opentracing.Span
 	if t.spanName != "" {
 		req, span = t.injectSpan(req)
 		defer span.Finish()
 		req = injectClientTrace(req, span)
 		span.LogKV("http_do", "start")
+		if ro.Timeout > 0 {
+			span.SetTag(timeoutTag, ro.Timeout.String())
+		}
+	}
+	var otelSpan trace.Span
+	if t.otelTracer != nil {
+		req, otelSpan = t.startOtelSpan(req)
 	}
-	if t.bearerToken != "" {
+	t.setCorrelationHeaders(req)
+	if t.bearerToken != "" && !ro.SkipBearerToken && !isCrossHostRedirect(req) {
 		req.Header.Set("Authorization", "Bearer "+t.bearerToken)
 	}
-	rsp, err := t.tr.RoundTrip(req)
+	req, decodeGzip := t.requestGzip(req)
+	req, rm := t.startRequestMetrics(req)
+	req, requestDone := t.stats.startRequest(req)
+
+	rsp, retries, err := t.roundTripHTTP3(req, span, ro)
+	rm.finish(rsp, retries)
+	if err == nil {
+		if err = t.digest.verifyDigest(req, rsp); err != nil {
+			rsp.Body.Close()
+			rsp = nil
+		}
+	}
+	if breakerDone != nil {
+		breakerDone(err == nil && rsp.StatusCode < http.StatusInternalServerError)
+	}
+
+	if err != nil {
+		cancel()
+		requestDone()
+		t.incError(req.URL.Host, err)
+	} else {
+		rsp.Body = &inflightBody{ReadCloser: rsp.Body, done: requestDone}
+		if decodeGzip {
+			rsp = t.decodeGzip(rsp)
+		}
+		ro.wrapBody(rsp, cancel)
+		t.wrapMetricsBody(req.URL.Host, rsp)
+	}
 	if span != nil {
 		span.LogKV("http_do", "stop")
+		span.SetTag(retryCountTag, retries)
 		if rsp != nil {
 			ext.HTTPStatusCode.Set(span, uint16(rsp.StatusCode))
 		}
 	}
+	if otelSpan != nil {
+		finishOtelSpan(otelSpan, rsp, retries, err)
+	}
 
 	return rsp, err
 }
 
+// roundTripRetry retries idempotent requests, that failed because of
+// a transient DNS resolution failure. It returns the number of all
+// retries made.
+func (t *Transport) roundTripRetry(req *http.Request, span opentracing.Span, ro RequestOptions) (*http.Response, int, error) {
+	retries := 0
+	backoff := t.dnsRetryBackoff
+	for dnsRetries := 0; ; dnsRetries++ {
+		var rsp *http.Response
+		var err error
+		if ro.DisableStaleConnRetry {
+			rsp, err = t.transport(req).RoundTrip(req)
+		} else {
+			var n int
+			rsp, n, err = t.roundTripStaleConn(req, span)
+			retries += n
+		}
+
+		if err == nil || dnsRetries >= t.dnsRetries || !isRetryableDNSError(err) || !isReplayable(req) {
+			return rsp, retries, err
+		}
+
+		retryReq, rerr := rewindRequest(req)
+		if rerr != nil {
+			return rsp, retries, err
+		}
+
+		if span != nil {
+			span.SetTag(retryReasonTag, "dns")
+			span.LogKV("dns_retry", "start")
+		}
+
+		select {
+		case <-time.After(backoff):
+		case <-req.Context().Done():
+			return rsp, retries, err
+		}
+
+		backoff *= 2
+		retries++
+		req = retryReq
+	}
+}
+
+// roundTripStaleConn retries the request once, if it failed on a
+// reused keep-alive connection that was already closed by the
+// peer. This is independent of any other retry and only done for
+// idempotent requests, that we can replay. It returns the number of
+// retries made.
+func (t *Transport) roundTripStaleConn(req *http.Request, span opentracing.Span) (*http.Response, int, error) {
+	reused := false
+	tr := t.transport(req)
+	rsp, err := tr.RoundTrip(withConnReuseTrace(req, &reused))
+	if err == nil || !reused || !isStaleConnError(err) || !isReplayable(req) {
+		return rsp, 0, err
+	}
+
+	retryReq, rerr := rewindRequest(req)
+	if rerr != nil {
+		return rsp, 0, err
+	}
+
+	if span != nil {
+		span.SetTag(retryReasonTag, "stale_connection")
+		span.LogKV("stale_conn_retry", "start")
+	}
+
+	// the other idle connections to the same peer are likely stale,
+	// too. The transport can't close the connections of a single
+	// host, so all of its idle connections are closed, to make sure
+	// that we dial a fresh one
+	tr.CloseIdleConnections()
+	rsp, err = tr.RoundTrip(retryReq)
+	return rsp, 1, err
+}
+
+func withConnReuseTrace(req *http.Request, reused *bool) *http.Request {
+	trace := &httptrace.ClientTrace{
+		GotConn: func(info httptrace.GotConnInfo) {
+			*reused = info.Reused
+		},
+	}
+	return req.WithContext(httptrace.WithClientTrace(req.Context(), trace))
+}
+
+// isStaleConnError detects the errors returned by the http.Transport
+// in case the peer closed the connection, while it was idle in the
+// connection pool.
+func isStaleConnError(err error) bool {
+	if errors.Is(err, io.EOF) || errors.Is(err, io.ErrUnexpectedEOF) ||
+		errors.Is(err, syscall.ECONNRESET) || errors.Is(err, syscall.EPIPE) {
+		return true
+	}
+
+	// net/http does not export these errors
+	s := err.Error()
+	return strings.Contains(s, "server closed idle connection") ||
+		strings.Contains(s, "connection reset by peer") ||
+		strings.Contains(s, "broken pipe")
+}
+
+func isIdempotent(req *http.Request) bool {
+	switch req.Method {
+	case "", http.MethodGet, http.MethodHead, http.MethodOptions, http.MethodTrace, http.MethodPut, http.MethodDelete:
+		return true
+	}
+	_, hasKey := req.Header["Idempotency-Key"]
+	return hasKey
+}
+
+// isReplayable returns true for idempotent requests, that have no body
+// or a body, that can be read again.
+func isReplayable(req *http.Request) bool {
+	if !isIdempotent(req) {
+		return false
+	}
+	return req.Body == nil || req.Body == http.NoBody || req.GetBody != nil
+}
+
+func rewindRequest(req *http.Request) (*http.Request, error) {
+	r := req.Clone(req.Context())
+	if req.Body == nil || req.Body == http.NoBody {
+		return r, nil
+	}
+
+	body, err := req.GetBody()
+	if err != nil {
+		return nil, err
+	}
+	r.Body = body
+	return r, nil
+}
+
 func (t *Transport) injectSpan(req *http.Request) (*http.Request, opentracing.Span) {
 	parentSpan := opentracing.SpanFromContext(req.Context())
 	var span opentracing.Span
@@ -210,9 +967,10 @@ func (t *Transport) injectSpan(req *http.Request) (*http.Request, opentracing.Sp
 
 	// add Tags
 	ext.Component.Set(span, t.componentName)
-	ext.HTTPUrl.Set(span, req.URL.String())
+	ext.HTTPUrl.Set(span, t.redactURL(req.URL))
 	ext.HTTPMethod.Set(span, req.Method)
 	ext.SpanKind.Set(span, "client")
+	setCorrelationBaggage(req, span)
 
 	_ = t.tracer.Inject(span.Context(), opentracing.HTTPHeaders, opentracing.HTTPHeadersCarrier(req.Header))
 
@@ -1,11 +1,23 @@
 package net
 
 import (
+	"bufio"
+	"crypto/tls"
+	"crypto/x509"
+	"errors"
+	"io"
+	"io/ioutil"
+	"net"
 	"net/http"
 	"net/http/httptest"
+	"net/url"
+	"os"
+	"strings"
+	"syscall"
 	"testing"
 
 	"github.com/zalando/skipper/tracing/tracers/basic"
+	"github.com/zalando/skipper/tracing/tracingtest"
 )
 
 func TestTransport(t *testing.T) {
@@ -103,3 +115,304 @@ func startTestServer(check requestCheck) *httptest.Server {
 		w.WriteHeader(http.StatusOK)
 	}))
 }
+
+func TestTransportRetriesStaleConnection(t *testing.T) {
+	l, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatalf("Failed to listen: %v", err)
+	}
+	defer l.Close()
+
+	go func() {
+		for i := 0; ; i++ {
+			conn, err := l.Accept()
+			if err != nil {
+				return
+			}
+
+			go func(first bool, conn net.Conn) {
+				defer conn.Close()
+				br := bufio.NewReader(conn)
+				for {
+					if _, err := http.ReadRequest(br); err != nil {
+						return
+					}
+
+					if !first {
+						conn.Write([]byte("HTTP/1.1 200 OK\r\nContent-Length: 2\r\n\r\nok"))
+						continue
+					}
+
+					// serve the first request and reset the
+					// connection on the next one
+					conn.Write([]byte("HTTP/1.1 200 OK\r\nContent-Length: 2\r\n\r\nok"))
+					first = false
+					if _, err := http.ReadRequest(br); err != nil {
+						return
+					}
+					conn.(*net.TCPConn).SetLinger(0)
+					return
+				}
+			}(i == 0, conn)
+		}
+	}()
+
+	rt := NewTransport(Options{})
+	defer rt.Close()
+
+	for i := 0; i < 2; i++ {
+		req, _ := http.NewRequest("GET", "http://"+l.Addr().String()+"/", nil)
+		rsp, err := rt.RoundTrip(req)
+		if err != nil {
+			t.Fatalf("Failed to do request %d: %v", i, err)
+		}
+		ioutil.ReadAll(rsp.Body)
+		rsp.Body.Close()
+		if rsp.StatusCode != http.StatusOK {
+			t.Fatalf("Failed to get status OK for request %d: %d", i, rsp.StatusCode)
+		}
+	}
+}
+
+func TestIsReplayable(t *testing.T) {
+	for _, tt := range []struct {
+		name string
+		req  func() *http.Request
+		want bool
+	}{{
+		name: "GET without body",
+		req:  func() *http.Request { r, _ := http.NewRequest("GET", "http://example.org", nil); return r },
+		want: true,
+	}, {
+		name: "PUT with rewindable body",
+		req: func() *http.Request {
+			r, _ := http.NewRequest("PUT", "http://example.org", strings.NewReader("foo"))
+			return r
+		},
+		want: true,
+	}, {
+		name: "POST is not idempotent",
+		req:  func() *http.Request { r, _ := http.NewRequest("POST", "http://example.org", nil); return r },
+		want: false,
+	}, {
+		name: "POST with idempotency key",
+		req: func() *http.Request {
+			r, _ := http.NewRequest("POST", "http://example.org", nil)
+			r.Header.Set("Idempotency-Key", "foo")
+			return r
+		},
+		want: true,
+	}, {
+		name: "GET with body that cannot be read again",
+		req: func() *http.Request {
+			r, _ := http.NewRequest("GET", "http://example.org", ioutil.NopCloser(strings.NewReader("foo")))
+			return r
+		},
+		want: false,
+	}} {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := isReplayable(tt.req()); got != tt.want {
+				t.Errorf("Failed to get replayable: got %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestIsStaleConnError(t *testing.T) {
+	for _, tt := range []struct {
+		err  error
+		want bool
+	}{
+		{err: io.EOF, want: true},
+		{err: &net.OpError{Op: "read", Err: os.NewSyscallError("read", syscall.ECONNRESET)}, want: true},
+		{err: errors.New("http: server closed idle connection"), want: true},
+		{err: errors.New("dial tcp: lookup example.invalid: no such host"), want: false},
+	} {
+		if got := isStaleConnError(tt.err); got != tt.want {
+			t.Errorf("Failed to classify %v: got %v, want %v", tt.err, got, tt.want)
+		}
+	}
+}
+
+func TestTransportRetryCountTag(t *testing.T) {
+	tracer := &tracingtest.Tracer{}
+
+	s := startTestServer(func(*http.Request) {})
+	defer s.Close()
+
+	rt := NewTransport(Options{Tracer: tracer})
+	defer rt.Close()
+	rt = WithSpanName(rt, "myspan")
+
+	req, _ := http.NewRequest("GET", s.URL, nil)
+	rsp, err := rt.RoundTrip(req)
+	if err != nil {
+		t.Fatalf("Failed to do request: %v", err)
+	}
+	rsp.Body.Close()
+
+	span, ok := tracer.FindSpan("myspan")
+	if !ok {
+		t.Fatal("Failed to find span")
+	}
+	if got, ok := span.Tags[retryCountTag]; !ok || got != 0 {
+		t.Errorf("Failed to get retry count tag: got %v", got)
+	}
+	if _, ok := span.Tags[retryReasonTag]; ok {
+		t.Error("Failed to not have a retry reason tag")
+	}
+}
+
+func TestTransportTLSClientConfig(t *testing.T) {
+	s := httptest.NewTLSServer(http.HandlerFunc(func(http.ResponseWriter, *http.Request) {}))
+	defer s.Close()
+
+	pool := x509.NewCertPool()
+	pool.AddCert(s.Certificate())
+
+	for _, tt := range []struct {
+		name    string
+		config  *tls.Config
+		wantErr bool
+	}{{
+		name:    "default config rejects unknown CA",
+		wantErr: true,
+	}, {
+		name:   "custom root CAs",
+		config: &tls.Config{RootCAs: pool},
+	}, {
+		name:   "insecure skip verify",
+		config: &tls.Config{InsecureSkipVerify: true},
+	}, {
+		name:    "maximum version not supported by server",
+		config:  &tls.Config{RootCAs: pool, MaxVersion: tls.VersionTLS11},
+		wantErr: true,
+	}} {
+		t.Run(tt.name, func(t *testing.T) {
+			rt := NewTransport(Options{TLSClientConfig: tt.config})
+			defer rt.Close()
+
+			req, _ := http.NewRequest("GET", s.URL, nil)
+			rsp, err := rt.RoundTrip(req)
+			if tt.wantErr {
+				if err == nil {
+					rsp.Body.Close()
+					t.Error("Failed to get an error")
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("Failed to do request: %v", err)
+			}
+			rsp.Body.Close()
+		})
+	}
+}
+
+func TestTransportProxy(t *testing.T) {
+	var proxied string
+	proxy := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		proxied = r.URL.String()
+	}))
+	defer proxy.Close()
+
+	proxyURL, _ := url.Parse(proxy.URL)
+	rt := NewTransport(Options{Proxy: http.ProxyURL(proxyURL)})
+	defer rt.Close()
+
+	req, _ := http.NewRequest("GET", "http://upstream.test/foo?bar=baz", nil)
+	rsp, err := rt.RoundTrip(req)
+	if err != nil {
+		t.Fatalf("Failed to do request: %v", err)
+	}
+	rsp.Body.Close()
+
+	if proxied != "http://upstream.test/foo?bar=baz" {
+		t.Errorf("Failed to send the request via the proxy: got %q", proxied)
+	}
+}
+
+func TestTransportProxyFromEnvironment(t *testing.T) {
+	rt := NewTransport(Options{ProxyFromEnvironment: true})
+	defer rt.Close()
+	if rt.tr.Proxy == nil {
+		t.Error("Failed to use the proxy from environment")
+	}
+
+	rt = NewTransport(Options{})
+	defer rt.Close()
+	if rt.tr.Proxy != nil {
+		t.Error("Failed to not use a proxy by default")
+	}
+}
+
+func TestClient(t *testing.T) {
+	tracer := &tracingtest.Tracer{TraceContent: "foo"}
+
+	s := startTestServer(func(r *http.Request) {
+		if r.Header.Get("X-Trace-Header") == "" {
+			t.Errorf("Failed to get trace header: %v", r.Header)
+		}
+	})
+	defer s.Close()
+
+	cli := NewClient(Options{
+		Tracer:                  tracer,
+		OpentracingSpanName:     "clientspan",
+		OpentracingComponentTag: "mycomponent",
+	})
+	defer cli.Close()
+
+	rsp, err := cli.Get(s.URL)
+	if err != nil {
+		t.Fatalf("Failed to do request: %v", err)
+	}
+	rsp.Body.Close()
+	if rsp.StatusCode != http.StatusOK {
+		t.Errorf("Failed to get status OK: %d", rsp.StatusCode)
+	}
+
+	span, ok := tracer.FindSpan("clientspan")
+	if !ok {
+		t.Fatal("Failed to find span")
+	}
+	if span.Tags["component"] != "mycomponent" {
+		t.Errorf("Failed to get component tag: %v", span.Tags)
+	}
+
+	// Close is safe to be called multiple times
+	cli.Close()
+}
+
+type testSecretsReader map[string]string
+
+func (sr testSecretsReader) GetSecret(s string) ([]byte, bool) {
+	v, ok := sr[s]
+	return []byte(v), ok
+}
+
+func TestClientSecretsReader(t *testing.T) {
+	s := startTestServer(func(r *http.Request) {
+		want := ""
+		if r.URL.Path == "/secure" {
+			want = "Bearer my-token"
+		}
+		if got := r.Header.Get("Authorization"); got != want {
+			t.Errorf("Failed to get authorization for %s: got %q, want %q", r.URL.Path, got, want)
+		}
+	})
+	defer s.Close()
+
+	cli := NewClient(Options{
+		SecretsReader: testSecretsReader{s.URL + "/secure": "my-token"},
+	})
+	defer cli.Close()
+
+	for _, p := range []string{"/secure", "/public"} {
+		rsp, err := cli.Get(s.URL + p)
+		if err != nil {
+			t.Fatalf("Failed to do request: %v", err)
+		}
+		rsp.Body.Close()
+	}
+}
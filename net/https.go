@@ -0,0 +1,92 @@
+package net
+
+import (
+	"fmt"
+	"net/http"
+	"strings"
+
+	log "github.com/sirupsen/logrus"
+)
+
+// ForceHTTPSPolicy defines how the Client handles plaintext requests
+// to hosts, that require HTTPS.
+type ForceHTTPSPolicy int
+
+const (
+	// ForceHTTPSUpgrade rewrites the request URL from http:// to
+	// https://.
+	ForceHTTPSUpgrade ForceHTTPSPolicy = iota
+
+	// ForceHTTPSReject rejects the request with an
+	// InsecureRequestError.
+	ForceHTTPSReject
+)
+
+// InsecureRequestError is returned by the Client for plaintext
+// requests to hosts, that require HTTPS, if the ForceHTTPSReject
+// policy is configured.
+type InsecureRequestError struct {
+	Host string
+}
+
+func (err *InsecureRequestError) Error() string {
+	return fmt.Sprintf("plaintext request to %s rejected, HTTPS is required", err.Host)
+}
+
+type forceHTTPS struct {
+	all    bool
+	hosts  map[string]struct{}
+	policy ForceHTTPSPolicy
+}
+
+func newForceHTTPS(o Options) *forceHTTPS {
+	if !o.ForceHTTPS && len(o.ForceHTTPSHosts) == 0 {
+		return nil
+	}
+
+	hosts := make(map[string]struct{}, len(o.ForceHTTPSHosts))
+	for _, h := range o.ForceHTTPSHosts {
+		hosts[strings.ToLower(h)] = struct{}{}
+	}
+
+	return &forceHTTPS{
+		all:    o.ForceHTTPS,
+		hosts:  hosts,
+		policy: o.ForceHTTPSPolicy,
+	}
+}
+
+// apply returns the request to be sent or an error, if the request
+// has to be rejected.
+func (f *forceHTTPS) apply(req *http.Request) (*http.Request, error) {
+	if f == nil || req.URL.Scheme != "http" {
+		return req, nil
+	}
+
+	host := strings.ToLower(req.URL.Hostname())
+	if _, ok := f.hosts[host]; !ok && !f.all {
+		return req, nil
+	}
+
+	if f.policy == ForceHTTPSReject {
+		return nil, &InsecureRequestError{Host: host}
+	}
+
+	r := new(http.Request)
+	*r = *req
+	u := *req.URL
+	u.Scheme = "https"
+	if u.Port() == "80" {
+		u.Host = strings.TrimSuffix(u.Host, ":80")
+	}
+	r.URL = &u
+
+	// the Host of the request defaults to the one of the URL, which
+	// may have changed, but an explicitly set Host is kept
+	if req.Host == req.URL.Host {
+		r.Host = ""
+	}
+
+	log.Warnf("Upgraded plaintext request to %s to HTTPS", host)
+	return r, nil
+}
@@ -0,0 +1,98 @@
+package net
+
+import (
+	"net/http"
+	"testing"
+)
+
+func TestForceHTTPS(t *testing.T) {
+	for _, tt := range []struct {
+		name     string
+		options  Options
+		url      string
+		host     string
+		wantURL  string
+		wantHost string
+		wantErr  bool
+	}{{
+		name:    "not configured",
+		url:     "http://example.org/foo",
+		wantURL: "http://example.org/foo",
+	}, {
+		name:    "host not matching",
+		options: Options{ForceHTTPSHosts: []string{"secure.example.org"}},
+		url:     "http://example.org/foo",
+		wantURL: "http://example.org/foo",
+	}, {
+		name:    "upgrade matching host",
+		options: Options{ForceHTTPSHosts: []string{"Example.org"}},
+		url:     "http://example.org:80/foo?a=b",
+		wantURL: "https://example.org/foo?a=b",
+	}, {
+		name:    "upgrade keeps custom port",
+		options: Options{ForceHTTPSHosts: []string{"example.org"}},
+		url:     "http://example.org:8080/foo",
+		wantURL: "https://example.org:8080/foo",
+	}, {
+		name:     "upgrade keeps explicit Host",
+		options:  Options{ForceHTTPSHosts: []string{"example.org"}},
+		url:      "http://example.org:80/foo",
+		host:     "www.example.org",
+		wantURL:  "https://example.org/foo",
+		wantHost: "www.example.org",
+	}, {
+		name:    "upgrade all hosts",
+		options: Options{ForceHTTPS: true},
+		url:     "http://example.org/foo",
+		wantURL: "https://example.org/foo",
+	}, {
+		name:    "reject matching host",
+		options: Options{ForceHTTPSHosts: []string{"example.org"}, ForceHTTPSPolicy: ForceHTTPSReject},
+		url:     "http://example.org/foo",
+		wantErr: true,
+	}, {
+		name:    "https is not rejected",
+		options: Options{ForceHTTPSHosts: []string{"example.org"}, ForceHTTPSPolicy: ForceHTTPSReject},
+		url:     "https://example.org/foo",
+		wantURL: "https://example.org/foo",
+	}} {
+		t.Run(tt.name, func(t *testing.T) {
+			req, _ := http.NewRequest("GET", tt.url, nil)
+			if tt.host != "" {
+				req.Host = tt.host
+			}
+
+			got, err := newForceHTTPS(tt.options).apply(req)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("Failed to apply, error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if err != nil {
+				if _, ok := err.(*InsecureRequestError); !ok {
+					t.Errorf("Failed to get an InsecureRequestError: %T", err)
+				}
+				return
+			}
+
+			if got.URL.String() != tt.wantURL {
+				t.Errorf("Failed to get URL: got %s, want %s", got.URL, tt.wantURL)
+			}
+			if got != req && got.Host != tt.wantHost {
+				t.Errorf("Failed to get Host: got %q, want %q", got.Host, tt.wantHost)
+			}
+			if req.URL.String() != tt.url {
+				t.Errorf("Failed to keep the original request unchanged: %s", req.URL)
+			}
+		})
+	}
+}
+
+func TestClientForceHTTPSReject(t *testing.T) {
+	cli := NewClient(Options{ForceHTTPS: true, ForceHTTPSPolicy: ForceHTTPSReject})
+	defer cli.Close()
+
+	if _, err := cli.Get("http://127.0.0.1:1/"); err == nil {
+		t.Error("Failed to reject plaintext request")
+	} else if _, ok := err.(*InsecureRequestError); !ok {
+		t.Errorf("Failed to get an InsecureRequestError: %v", err)
+	}
+}
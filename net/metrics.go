@@ -0,0 +1,177 @@
+package net
+
+import (
+	"crypto/tls"
+	"fmt"
+	"io"
+	"net/http"
+	"net/http/httptrace"
+	"sync"
+	"time"
+)
+
+const defaultMetricsPrefix = "client."
+
+// Metrics receives the request metrics of the Transport. It is a
+// subset of the skipper metrics.Metrics interface, so that the
+// metrics of internal clients can be exported with the same backend
+// as the proxy metrics.
+//
+// The keys are prefixed with Options.MetricsPrefix:
+//
+//	request.<host>              request duration until the response headers
+//	response.<host>.<code>      number of responses by status code
+//	dns.<host>                  DNS lookup duration
+//	connect.<host>              TCP connect duration
+//	tls.<host>                  TLS handshake duration
+//	retry.<host>                number of retries
+type Metrics interface {
+	MeasureSince(key string, start time.Time)
+	IncCounter(key string)
+	IncCounterBy(key string, value int64)
+}
+
+// MetricsCollector receives the metrics of the requests sent by the
+// Transport.
+type MetricsCollector interface {
+	// IncError counts a failed request to the given host by the
+	// class of the error, see ClassifyError.
+	IncError(host string, class ErrorClass)
+}
+
+// NoopMetricsCollector discards all metrics. It is used, if
+// Options.MetricsCollector is not set.
+type NoopMetricsCollector struct{}
+
+// IncError does nothing.
+func (NoopMetricsCollector) IncError(string, ErrorClass) {}
+
+func (t *Transport) incError(host string, err error) {
+	if err == nil || err == io.EOF {
+		return
+	}
+	t.metrics.IncError(host, ClassifyError(err))
+}
+
+// metricsBody counts the first error, that is returned by reading the
+// response body.
+type metricsBody struct {
+	io.ReadCloser
+	t        *Transport
+	host     string
+	reported bool
+}
+
+func (t *Transport) wrapMetricsBody(host string, rsp *http.Response) {
+	if _, ok := t.metrics.(NoopMetricsCollector); ok || rsp.Body == nil || rsp.Body == http.NoBody {
+		return
+	}
+	rsp.Body = &metricsBody{ReadCloser: rsp.Body, t: t, host: host}
+}
+
+func (b *metricsBody) Read(p []byte) (int, error) {
+	n, err := b.ReadCloser.Read(p)
+	if err != nil && err != io.EOF && !b.reported {
+		b.reported = true
+		b.t.incError(b.host, err)
+	}
+	return n, err
+}
+
+// requestMetrics measures a request, nil if Options.Metrics is not
+// set.
+type requestMetrics struct {
+	m      Metrics
+	prefix string
+	host   string
+	start  time.Time
+
+	mu           sync.Mutex
+	dnsStart     time.Time
+	connectStart time.Time
+	tlsStart     time.Time
+}
+
+func (t *Transport) startRequestMetrics(req *http.Request) (*http.Request, *requestMetrics) {
+	if t.measures == nil {
+		return req, nil
+	}
+
+	rm := &requestMetrics{
+		m:      t.measures,
+		prefix: t.metricsPrefix,
+		host:   req.URL.Host,
+		start:  time.Now(),
+	}
+
+	trace := &httptrace.ClientTrace{
+		DNSStart: func(httptrace.DNSStartInfo) {
+			rm.mu.Lock()
+			rm.dnsStart = time.Now()
+			rm.mu.Unlock()
+		},
+		DNSDone: func(httptrace.DNSDoneInfo) {
+			rm.measureSince("dns", &rm.dnsStart)
+		},
+		ConnectStart: func(string, string) {
+			rm.mu.Lock()
+			if rm.connectStart.IsZero() {
+				rm.connectStart = time.Now()
+			}
+			rm.mu.Unlock()
+		},
+		ConnectDone: func(_, _ string, err error) {
+			if err == nil {
+				rm.measureSince("connect", &rm.connectStart)
+			}
+		},
+		TLSHandshakeStart: func() {
+			rm.mu.Lock()
+			rm.tlsStart = time.Now()
+			rm.mu.Unlock()
+		},
+		TLSHandshakeDone: func(_ tls.ConnectionState, err error) {
+			if err == nil {
+				rm.measureSince("tls", &rm.tlsStart)
+			}
+		},
+	}
+	return req.WithContext(httptrace.WithClientTrace(req.Context(), trace)), rm
+}
+
+// measureSince measures the duration since the start time, once.
+func (rm *requestMetrics) measureSince(name string, start *time.Time) {
+	rm.mu.Lock()
+	s := *start
+	*start = time.Time{}
+	rm.mu.Unlock()
+
+	if !s.IsZero() {
+		rm.m.MeasureSince(rm.key(name), s)
+	}
+}
+
+func (rm *requestMetrics) key(name string) string {
+	return rm.prefix + name + "." + rm.host
+}
+
+func (rm *requestMetrics) finish(rsp *http.Response, retries int) {
+	if rm == nil {
+		return
+	}
+
+	rm.m.MeasureSince(rm.key("request"), rm.start)
+	if rsp != nil {
+		rm.m.IncCounter(fmt.Sprintf("%s.%d", rm.key("response"), rsp.StatusCode))
+	}
+	if retries > 0 {
+		rm.m.IncCounterBy(rm.key("retry"), int64(retries))
+	}
+}
+
+// incRetry counts a retry of the Client.
+func (t *Transport) incRetry(host string) {
+	if t.measures != nil {
+		t.measures.IncCounter(t.metricsPrefix + "retry." + host)
+	}
+}
@@ -0,0 +1,144 @@
+package net
+
+import (
+	"context"
+	"crypto/tls"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/zalando/skipper/metrics/metricstest"
+)
+
+type testMetricsCollector struct {
+	mu     sync.Mutex
+	errors map[string]int
+}
+
+func newTestMetricsCollector() *testMetricsCollector {
+	return &testMetricsCollector{errors: make(map[string]int)}
+}
+
+func (c *testMetricsCollector) IncError(host string, class ErrorClass) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.errors[string(class)]++
+}
+
+func (c *testMetricsCollector) errorCount(class ErrorClass) int {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.errors[string(class)]
+}
+
+func TestMetricsCollectorDialError(t *testing.T) {
+	m := newTestMetricsCollector()
+	rt := NewTransport(Options{MetricsCollector: m})
+	defer rt.Close()
+
+	req, _ := http.NewRequest("GET", "http://127.0.0.1:1/", nil)
+	if _, err := rt.RoundTrip(req); err == nil {
+		t.Fatal("Failed to get a dial error")
+	}
+	if m.errorCount(ErrorClassDial) != 1 {
+		t.Errorf("Failed to count dial error: %v", m.errors)
+	}
+}
+
+func TestMetricsCollectorBodyError(t *testing.T) {
+	s := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte("foo"))
+	}))
+	defer s.Close()
+
+	m := newTestMetricsCollector()
+	rt := NewTransport(Options{MetricsCollector: m})
+	defer rt.Close()
+
+	req, _ := http.NewRequest("GET", s.URL, nil)
+	req = req.WithContext(WithRequestOptions(context.Background(), RequestOptions{MaxResponseBodySize: 1}))
+	rsp, err := rt.RoundTrip(req)
+	if err != nil {
+		t.Fatalf("Failed to do request: %v", err)
+	}
+	defer rsp.Body.Close()
+
+	ioutil.ReadAll(rsp.Body)
+	if m.errorCount(ErrorClassBodyTooLarge) != 1 {
+		t.Errorf("Failed to count body error: %v", m.errors)
+	}
+}
+
+func TestMetrics(t *testing.T) {
+	var requests int32
+	s := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if atomic.AddInt32(&requests, 1) == 1 {
+			w.WriteHeader(http.StatusServiceUnavailable)
+		}
+	}))
+	defer s.Close()
+
+	m := &metricstest.MockMetrics{}
+	cli := NewClient(Options{Metrics: m, MaxRetries: 1, RetryBackoff: time.Millisecond})
+	defer cli.Close()
+
+	rsp, err := cli.Get(s.URL)
+	if err != nil {
+		t.Fatalf("Failed to do request: %v", err)
+	}
+	rsp.Body.Close()
+
+	host := s.Listener.Addr().String()
+	m.WithCounters(func(counters map[string]int64) {
+		for key, want := range map[string]int64{
+			"client.response." + host + ".503": 1,
+			"client.response." + host + ".200": 1,
+			"client.retry." + host:             1,
+		} {
+			if counters[key] != want {
+				t.Errorf("Failed to get counter %s: got %d, want %d", key, counters[key], want)
+			}
+		}
+	})
+	m.WithMeasures(func(measures map[string][]time.Duration) {
+		for key, want := range map[string]int{
+			"client.request." + host: 2,
+			"client.connect." + host: 1,
+		} {
+			if len(measures[key]) != want {
+				t.Errorf("Failed to get measures %s: got %d, want %d", key, len(measures[key]), want)
+			}
+		}
+	})
+}
+
+func TestMetricsPrefixTLS(t *testing.T) {
+	s := httptest.NewTLSServer(http.HandlerFunc(func(http.ResponseWriter, *http.Request) {}))
+	defer s.Close()
+
+	m := &metricstest.MockMetrics{}
+	rt := NewTransport(Options{
+		Metrics:         m,
+		MetricsPrefix:   "webhook.",
+		TLSClientConfig: &tls.Config{InsecureSkipVerify: true},
+	})
+	defer rt.Close()
+
+	req, _ := http.NewRequest("GET", s.URL, nil)
+	rsp, err := rt.RoundTrip(req)
+	if err != nil {
+		t.Fatalf("Failed to do request: %v", err)
+	}
+	rsp.Body.Close()
+
+	key := "webhook.tls." + s.Listener.Addr().String()
+	m.WithMeasures(func(measures map[string][]time.Duration) {
+		if len(measures[key]) != 1 {
+			t.Errorf("Failed to measure the TLS handshake: %v", measures)
+		}
+	})
+}
@@ -0,0 +1,55 @@
+package net
+
+import (
+	"fmt"
+	"net/http"
+	"testing"
+)
+
+type roundTripperFunc func(*http.Request) (*http.Response, error)
+
+func (f roundTripperFunc) RoundTrip(req *http.Request) (*http.Response, error) {
+	return f(req)
+}
+
+func TestMiddleware(t *testing.T) {
+	var got string
+	s := startTestServer(func(r *http.Request) {
+		got = fmt.Sprint(r.Header["X-Middleware"])
+	})
+	defer s.Close()
+
+	var calls []string
+	middleware := func(name string) func(http.RoundTripper) http.RoundTripper {
+		return func(next http.RoundTripper) http.RoundTripper {
+			return roundTripperFunc(func(req *http.Request) (*http.Response, error) {
+				calls = append(calls, name+" request")
+				req.Header.Add("X-Middleware", name)
+				rsp, err := next.RoundTrip(req)
+				calls = append(calls, name+" response")
+				return rsp, err
+			})
+		}
+	}
+
+	cli := NewClient(Options{
+		Middleware: []func(http.RoundTripper) http.RoundTripper{
+			middleware("outer"),
+			middleware("inner"),
+		},
+	})
+	defer cli.Close()
+
+	rsp, err := cli.Get(s.URL)
+	if err != nil {
+		t.Fatalf("Failed to do request: %v", err)
+	}
+	rsp.Body.Close()
+
+	if want := "[outer inner]"; got != want {
+		t.Errorf("Failed to get headers: got %s, want %s", got, want)
+	}
+	if want := "[outer request inner request inner response outer response]"; fmt.Sprint(calls) != want {
+		t.Errorf("Failed to call middleware in order: got %v, want %s", calls, want)
+	}
+}
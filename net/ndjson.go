@@ -0,0 +1,108 @@
+package net
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+)
+
+// DecodeErrorPolicy defines what happens, if an element of a stream
+// can not be decoded.
+type DecodeErrorPolicy int
+
+const (
+	// DecodeErrorAbort stops the stream and returns the decode
+	// error.
+	DecodeErrorAbort DecodeErrorPolicy = iota
+
+	// DecodeErrorSkip drops the element, that could not be decoded,
+	// and continues with the next one.
+	DecodeErrorSkip
+)
+
+// NDJSONStream decodes a newline delimited JSON response body one
+// object at a time, while the response is streamed.
+type NDJSONStream struct {
+	rsp    *http.Response
+	br     *bufio.Reader
+	policy DecodeErrorPolicy
+	err    error
+}
+
+// StreamNDJSON sends the request and returns a stream to decode the
+// newline delimited JSON (http://ndjson.org) response body. The
+// response body is not buffered, objects are decoded as they
+// arrive. Canceling the context of the request stops the stream. The
+// caller has to Close() the returned stream. Responses with a
+// non 2xx status code return an error.
+func (c *Client) StreamNDJSON(req *http.Request) (*NDJSONStream, error) {
+	rsp, err := c.Do(req)
+	if err != nil {
+		return nil, err
+	}
+
+	if rsp.StatusCode < 200 || rsp.StatusCode >= 300 {
+		rsp.Body.Close()
+		return nil, fmt.Errorf("failed to stream ndjson, unexpected status code: %d", rsp.StatusCode)
+	}
+
+	return &NDJSONStream{
+		rsp:    rsp,
+		br:     bufio.NewReader(rsp.Body),
+		policy: c.ndjsonDecodeErrorPolicy,
+	}, nil
+}
+
+// Next decodes the next object of the stream into v. It returns io.EOF
+// at the end of the stream. After an error was returned, all
+// subsequent calls return the same error.
+func (s *NDJSONStream) Next(v interface{}) error {
+	for s.err == nil {
+		if err := s.rsp.Request.Context().Err(); err != nil {
+			s.err = err
+			break
+		}
+
+		line, err := s.br.ReadBytes('\n')
+		line = bytes.TrimSpace(line)
+		if len(line) == 0 {
+			if err != nil {
+				s.err = err
+			}
+			continue
+		}
+
+		if derr := json.Unmarshal(line, v); derr != nil {
+			if s.policy == DecodeErrorSkip {
+				if err != nil {
+					s.err = err
+				}
+				continue
+			}
+
+			s.err = derr
+			break
+		}
+
+		if err != nil && err != io.EOF {
+			s.err = err
+		}
+		return nil
+	}
+
+	return s.err
+}
+
+// Response returns the response of the stream, e.g. to check the
+// headers.
+func (s *NDJSONStream) Response() *http.Response {
+	return s.rsp
+}
+
+// Close closes the response body of the stream.
+func (s *NDJSONStream) Close() error {
+	return s.rsp.Body.Close()
+}
@@ -0,0 +1,145 @@
+package net
+
+import (
+	"context"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"testing"
+	"time"
+)
+
+type testEvent struct {
+	ID int `json:"id"`
+}
+
+func TestStreamNDJSON(t *testing.T) {
+	for _, tt := range []struct {
+		name    string
+		policy  DecodeErrorPolicy
+		body    string
+		want    []int
+		wantErr bool
+	}{{
+		name: "all valid",
+		body: "{\"id\":1}\n{\"id\":2}\n\n{\"id\":3}",
+		want: []int{1, 2, 3},
+	}, {
+		name:    "abort on decode error",
+		body:    "{\"id\":1}\nfoo\n{\"id\":3}\n",
+		want:    []int{1},
+		wantErr: true,
+	}, {
+		name:   "skip decode error",
+		policy: DecodeErrorSkip,
+		body:   "{\"id\":1}\nfoo\n{\"id\":3}\n",
+		want:   []int{1, 3},
+	}} {
+		t.Run(tt.name, func(t *testing.T) {
+			s := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				w.Header().Set("Content-Type", "application/x-ndjson")
+				w.Write([]byte(tt.body))
+			}))
+			defer s.Close()
+
+			cli := NewClient(Options{NDJSONDecodeErrorPolicy: tt.policy})
+			defer cli.Close()
+
+			req, _ := http.NewRequest("GET", s.URL, nil)
+			stream, err := cli.StreamNDJSON(req)
+			if err != nil {
+				t.Fatalf("Failed to stream: %v", err)
+			}
+			defer stream.Close()
+
+			var got []int
+			for {
+				var e testEvent
+				err = stream.Next(&e)
+				if err != nil {
+					break
+				}
+				got = append(got, e.ID)
+			}
+
+			if (err != io.EOF) != tt.wantErr {
+				t.Errorf("Failed to get the final error, got: %v, wantErr: %v", err, tt.wantErr)
+			}
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("Failed to decode stream: got %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestStreamNDJSONIncremental(t *testing.T) {
+	next := make(chan struct{})
+	s := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		for i := 0; ; i++ {
+			w.Write([]byte("{\"id\":1}\n"))
+			w.(http.Flusher).Flush()
+			select {
+			case <-next:
+			case <-r.Context().Done():
+				return
+			}
+		}
+	}))
+	defer s.Close()
+
+	cli := NewClient(Options{})
+	defer cli.Close()
+
+	ctx, cancel := context.WithCancel(context.Background())
+	req, _ := http.NewRequest("GET", s.URL, nil)
+	stream, err := cli.StreamNDJSON(req.WithContext(ctx))
+	if err != nil {
+		t.Fatalf("Failed to stream: %v", err)
+	}
+	defer stream.Close()
+
+	for i := 0; i < 3; i++ {
+		var e testEvent
+		if err := stream.Next(&e); err != nil {
+			t.Fatalf("Failed to decode object %d: %v", i, err)
+		}
+		next <- struct{}{}
+	}
+
+	cancel()
+	done := make(chan error)
+	go func() {
+		var e testEvent
+		for {
+			if err := stream.Next(&e); err != nil {
+				done <- err
+				return
+			}
+		}
+	}()
+
+	select {
+	case err := <-done:
+		if err == nil || err == io.EOF {
+			t.Errorf("Failed to get cancellation error: %v", err)
+		}
+	case <-time.After(time.Second):
+		t.Error("Failed to stop the stream on cancellation")
+	}
+}
+
+func TestStreamNDJSONStatusCode(t *testing.T) {
+	s := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusNotFound)
+	}))
+	defer s.Close()
+
+	cli := NewClient(Options{})
+	defer cli.Close()
+
+	req, _ := http.NewRequest("GET", s.URL, nil)
+	if _, err := cli.StreamNDJSON(req); err == nil {
+		t.Error("Failed to get an error for status code 404")
+	}
+}
@@ -61,3 +61,69 @@ func RemoteHostFromLast(r *http.Request) net.IP {
 
 	return parse(r.RemoteAddr)
 }
+
+// RemoteHostAtDepth returns the address of the client at the given depth
+// of the proxies in front of skipper. Depth 0 is the address of the
+// connection, depth 1 is the last entry of the 'X-Forwarded-For' header,
+// added by the closest proxy, depth 2 the entry before it, and so on.
+// When the header has fewer entries than the depth, the first entry is
+// used. Unlike the first entry, the entries up to the depth of the
+// trusted proxies cannot be spoofed by the clients.
+//
+// Example, depth 2 returns client-ip-address:
+//
+//     X-Forwarded-For: spoofed-address, client-ip-address, proxy-ip-address
+func RemoteHostAtDepth(r *http.Request, depth int) net.IP {
+	ffs := r.Header.Get("X-Forwarded-For")
+	if depth <= 0 || ffs == "" {
+		return parse(r.RemoteAddr)
+	}
+
+	ffa := strings.Split(ffs, ",")
+	i := len(ffa) - depth
+	if i < 0 {
+		i = 0
+	}
+
+	return parse(strings.TrimSpace(ffa[i]))
+}
+
+// RemoteHostTrusted returns the address of the client behind the chain of
+// the trusted proxies. Starting with the address of the connection, it
+// steps back through the entries of the 'X-Forwarded-For' header, from
+// the last one, as long as the current address belongs to one of the
+// trusted networks. The first untrusted address is the client. When all
+// the addresses are trusted, the first entry of the header is used. When
+// an entry in the chain is not a valid address, it returns nil.
+//
+// Example, with the trusted network 10.0.0.0/8, returns 1.2.3.4:
+//
+//     X-Forwarded-For: spoofed-address, 1.2.3.4, 10.0.0.2
+func RemoteHostTrusted(r *http.Request, trusted []*net.IPNet) net.IP {
+	ip := parse(r.RemoteAddr)
+	ffs := r.Header.Get("X-Forwarded-For")
+	if ffs == "" {
+		return ip
+	}
+
+	ffa := strings.Split(ffs, ",")
+	for i := len(ffa) - 1; i >= 0 && isTrusted(ip, trusted); i-- {
+		ip = parse(strings.TrimSpace(ffa[i]))
+	}
+
+	return ip
+}
+
+func isTrusted(ip net.IP, trusted []*net.IPNet) bool {
+	if ip == nil {
+		return false
+	}
+
+	for _, n := range trusted {
+		if n.Contains(ip) {
+			return true
+		}
+	}
+
+	return false
+}
@@ -77,6 +77,67 @@ func TestRemoteHostFromLast(t *testing.T) {
 	}
 }
 
+func TestRemoteHostAtDepth(t *testing.T) {
+	for _, tt := range []struct {
+		name   string
+		depth  int
+		want   net.IP
+		fwdHdr []string
+	}{
+		{"no header", 1, net.IPv4(127, 0, 0, 1), []string{}},
+		{"depth 0", 0, net.IPv4(127, 0, 0, 1), []string{"1.2.3.4", "8.7.6.5"}},
+		{"depth 1", 1, net.IPv4(8, 7, 6, 5), []string{"172.16.0.1", "1.2.3.4", "8.7.6.5"}},
+		{"depth 2", 2, net.IPv4(1, 2, 3, 4), []string{"172.16.0.1", "1.2.3.4", "8.7.6.5"}},
+		{"depth over entries", 5, net.IPv4(172, 16, 0, 1), []string{"172.16.0.1", "1.2.3.4", "8.7.6.5"}},
+		{"invalid entry", 1, nil, []string{"1.2.3.4", "invalid"}},
+	} {
+		t.Run(tt.name, func(t *testing.T) {
+			r := &http.Request{RemoteAddr: "127.0.0.1:8080", Header: make(http.Header)}
+			if len(tt.fwdHdr) > 0 {
+				r.Header.Set("X-Forwarded-For", strings.Join(tt.fwdHdr, ", "))
+			}
+
+			got := RemoteHostAtDepth(r, tt.depth)
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("Unexpected IP address '%v'. Wanted '%v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestRemoteHostTrusted(t *testing.T) {
+	trusted, err := ParseCIDRs([]string{"10.0.0.0/8", "127.0.0.1/32"})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	for _, tt := range []struct {
+		name       string
+		remoteAddr string
+		want       net.IP
+		fwdHdr     []string
+	}{
+		{"no header", "127.0.0.1:8080", net.IPv4(127, 0, 0, 1), []string{}},
+		{"untrusted remote", "192.168.0.1:8080", net.IPv4(192, 168, 0, 1), []string{"1.2.3.4"}},
+		{"trusted remote", "127.0.0.1:8080", net.IPv4(1, 2, 3, 4), []string{"1.2.3.4"}},
+		{"trusted chain", "127.0.0.1:8080", net.IPv4(1, 2, 3, 4), []string{"8.7.6.5", "1.2.3.4", "10.0.0.2", "10.0.0.1"}},
+		{"all trusted", "127.0.0.1:8080", net.IPv4(10, 0, 0, 3), []string{"10.0.0.3", "10.0.0.2"}},
+		{"invalid entry", "127.0.0.1:8080", nil, []string{"1.2.3.4", "invalid", "10.0.0.1"}},
+	} {
+		t.Run(tt.name, func(t *testing.T) {
+			r := &http.Request{RemoteAddr: tt.remoteAddr, Header: make(http.Header)}
+			if len(tt.fwdHdr) > 0 {
+				r.Header.Set("X-Forwarded-For", strings.Join(tt.fwdHdr, ", "))
+			}
+
+			got := RemoteHostTrusted(r, trusted)
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("Unexpected IP address '%v'. Wanted '%v", got, tt.want)
+			}
+		})
+	}
+}
+
 func BenchmarkRemoteHostFromLast(b *testing.B) {
 	r := &http.Request{RemoteAddr: "1.2.3.4"}
 	b.ResetTimer()
@@ -0,0 +1,55 @@
+package net
+
+import (
+	"net/http"
+
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/propagation"
+	"go.opentelemetry.io/otel/semconv"
+	"go.opentelemetry.io/otel/trace"
+)
+
+const otelInstrumentationName = "github.com/zalando/skipper/net"
+
+// otelPropagator propagates the span context in the W3C traceparent
+// and tracestate headers.
+var otelPropagator = propagation.TraceContext{}
+
+// startOtelSpan starts a client span as child of the span found in
+// the request context and injects it into the request headers.
+func (t *Transport) startOtelSpan(req *http.Request) (*http.Request, trace.Span) {
+	name := t.spanName
+	if name == "" {
+		name = "HTTP " + req.Method
+	}
+
+	attrs := semconv.HTTPClientAttributesFromHTTPRequest(req)
+	attrs = append(attrs, semconv.HTTPURLKey.String(t.redactURL(req.URL)))
+	if t.componentName != "" {
+		attrs = append(attrs, attribute.String("component", t.componentName))
+	}
+
+	ctx, span := t.otelTracer.Start(
+		req.Context(),
+		name,
+		trace.WithSpanKind(trace.SpanKindClient),
+		trace.WithAttributes(attrs...),
+	)
+	req = req.WithContext(ctx)
+	otelPropagator.Inject(ctx, propagation.HeaderCarrier(req.Header))
+	return req, span
+}
+
+// finishOtelSpan records the result of the request and ends the span.
+func finishOtelSpan(span trace.Span, rsp *http.Response, retries int, err error) {
+	span.SetAttributes(attribute.Int(retryCountTag, retries))
+	if err != nil {
+		span.RecordError(err)
+		span.SetStatus(codes.Error, err.Error())
+	} else {
+		span.SetAttributes(semconv.HTTPAttributesFromHTTPStatusCode(rsp.StatusCode)...)
+		span.SetStatus(semconv.SpanStatusFromHTTPStatusCode(rsp.StatusCode))
+	}
+	span.End()
+}
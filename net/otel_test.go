@@ -0,0 +1,95 @@
+package net
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/oteltest"
+)
+
+func TestOtelTracing(t *testing.T) {
+	var traceparent string
+	s := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		traceparent = r.Header.Get("traceparent")
+		w.WriteHeader(http.StatusServiceUnavailable)
+	}))
+	defer s.Close()
+
+	sr := new(oteltest.SpanRecorder)
+	tp := oteltest.NewTracerProvider(oteltest.WithSpanRecorder(sr))
+
+	parentCtx, parent := tp.Tracer("test").Start(context.Background(), "parent")
+	defer parent.End()
+
+	rt := NewTransport(Options{
+		OtelTracerProvider: tp,
+		RedactQueryParams:  []string{"token"},
+	})
+	defer rt.Close()
+
+	req, _ := http.NewRequest("GET", s.URL+"/foo?token=secret", nil)
+	rsp, err := rt.RoundTrip(req.WithContext(parentCtx))
+	if err != nil {
+		t.Fatalf("Failed to do request: %v", err)
+	}
+	rsp.Body.Close()
+
+	spans := sr.Completed()
+	if len(spans) != 1 {
+		t.Fatalf("Failed to get one span: %d", len(spans))
+	}
+
+	span := spans[0]
+	if span.Name() != "HTTP GET" {
+		t.Errorf("Failed to get span name: %q", span.Name())
+	}
+	if span.ParentSpanID() != parent.SpanContext().SpanID() {
+		t.Error("Failed to start span as child of the context span")
+	}
+	if span.StatusCode() != codes.Error {
+		t.Errorf("Failed to get span status: %v", span.StatusCode())
+	}
+
+	attrs := span.Attributes()
+	if got := attrs[attribute.Key("http.status_code")].AsInt64(); got != http.StatusServiceUnavailable {
+		t.Errorf("Failed to get status code attribute: %d", got)
+	}
+	if got := attrs[attribute.Key("http.url")].AsString(); got != s.URL+"/foo?token=REDACTED" {
+		t.Errorf("Failed to get redacted URL attribute: %q", got)
+	}
+
+	sc := span.SpanContext()
+	want := "00-" + sc.TraceID().String() + "-" + sc.SpanID().String() + "-00"
+	if traceparent != want {
+		t.Errorf("Failed to propagate traceparent: got %q, want %q", traceparent, want)
+	}
+}
+
+func TestOtelTracingError(t *testing.T) {
+	sr := new(oteltest.SpanRecorder)
+	rt := NewTransport(Options{
+		OtelTracerProvider: oteltest.NewTracerProvider(oteltest.WithSpanRecorder(sr)),
+	})
+	defer rt.Close()
+	rt = WithSpanName(rt, "myspan")
+
+	req, _ := http.NewRequest("GET", "http://127.0.0.1:1/", nil)
+	if _, err := rt.RoundTrip(req); err == nil {
+		t.Fatal("Failed to get an error")
+	}
+
+	spans := sr.Completed()
+	if len(spans) != 1 {
+		t.Fatalf("Failed to get one span: %d", len(spans))
+	}
+	if spans[0].Name() != "myspan" {
+		t.Errorf("Failed to get span name: %q", spans[0].Name())
+	}
+	if spans[0].StatusCode() != codes.Error || len(spans[0].Events()) != 1 {
+		t.Error("Failed to record the error")
+	}
+}
@@ -0,0 +1,126 @@
+package net
+
+import (
+	"context"
+	"sync"
+	"time"
+)
+
+type priorityWaiter struct {
+	priority int
+	enqueued time.Time
+	ready    chan struct{}
+}
+
+// priorityLimiter bounds the number of concurrent requests. Requests
+// exceeding the limit are queued and served by priority, where a
+// higher value has a higher priority. To prevent starvation, the
+// effective priority of a queued request is increased by one for
+// every aging interval it waits.
+type priorityLimiter struct {
+	mu      sync.Mutex
+	max     int
+	active  int
+	aging   time.Duration
+	waiters []*priorityWaiter
+}
+
+func newPriorityLimiter(max int, aging time.Duration) *priorityLimiter {
+	if max <= 0 {
+		return nil
+	}
+	return &priorityLimiter{max: max, aging: aging}
+}
+
+func (l *priorityLimiter) acquire(ctx context.Context, priority int) error {
+	if l == nil {
+		return nil
+	}
+
+	l.mu.Lock()
+	if l.active < l.max && len(l.waiters) == 0 {
+		l.active++
+		l.mu.Unlock()
+		return nil
+	}
+
+	w := &priorityWaiter{priority: priority, enqueued: time.Now(), ready: make(chan struct{})}
+	l.waiters = append(l.waiters, w)
+	l.mu.Unlock()
+
+	select {
+	case <-w.ready:
+		return nil
+	case <-ctx.Done():
+		l.mu.Lock()
+		for i, wi := range l.waiters {
+			if wi == w {
+				l.waiters = append(l.waiters[:i], l.waiters[i+1:]...)
+				l.mu.Unlock()
+				return ctx.Err()
+			}
+		}
+		l.mu.Unlock()
+
+		// the slot was granted concurrently, pass it on
+		l.release()
+		return ctx.Err()
+	}
+}
+
+func (l *priorityLimiter) release() {
+	if l == nil {
+		return
+	}
+
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	if len(l.waiters) == 0 {
+		l.active--
+		return
+	}
+
+	now := time.Now()
+	next := 0
+	for i := 1; i < len(l.waiters); i++ {
+		if l.before(l.waiters[i], l.waiters[next], now) {
+			next = i
+		}
+	}
+
+	w := l.waiters[next]
+	l.waiters = append(l.waiters[:next], l.waiters[next+1:]...)
+	close(w.ready)
+}
+
+// before returns true, if a has to be served before b.
+func (l *priorityLimiter) before(a, b *priorityWaiter, now time.Time) bool {
+	pa, pb := l.effectivePriority(a, now), l.effectivePriority(b, now)
+	if pa != pb {
+		return pa > pb
+	}
+	return a.enqueued.Before(b.enqueued)
+}
+
+func (l *priorityLimiter) effectivePriority(w *priorityWaiter, now time.Time) int {
+	if l.aging <= 0 {
+		return w.priority
+	}
+	return w.priority + int(now.Sub(w.enqueued)/l.aging)
+}
+
+// queueDepths returns the number of queued requests by priority.
+func (l *priorityLimiter) queueDepths() map[int]int {
+	if l == nil {
+		return nil
+	}
+
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	d := make(map[int]int)
+	for _, w := range l.waiters {
+		d[w.priority]++
+	}
+	return d
+}
@@ -0,0 +1,129 @@
+package net
+
+import (
+	"context"
+	"net/http"
+	"testing"
+	"time"
+)
+
+func waitForQueueDepth(t *testing.T, l *priorityLimiter, n int) {
+	for i := 0; i < 100; i++ {
+		depth := 0
+		for _, d := range l.queueDepths() {
+			depth += d
+		}
+		if depth == n {
+			return
+		}
+		time.Sleep(time.Millisecond)
+	}
+	t.Fatalf("Failed to get queue depth %d", n)
+}
+
+func enqueue(t *testing.T, l *priorityLimiter, priority int, served chan<- int) {
+	go func() {
+		if err := l.acquire(context.Background(), priority); err != nil {
+			t.Errorf("Failed to acquire: %v", err)
+		}
+		served <- priority
+	}()
+}
+
+func TestPriorityLimiterOrder(t *testing.T) {
+	l := newPriorityLimiter(1, 0)
+	if err := l.acquire(context.Background(), 0); err != nil {
+		t.Fatalf("Failed to acquire: %v", err)
+	}
+
+	served := make(chan int)
+	enqueue(t, l, 0, served)
+	waitForQueueDepth(t, l, 1)
+	enqueue(t, l, 2, served)
+	waitForQueueDepth(t, l, 2)
+	enqueue(t, l, 1, served)
+	waitForQueueDepth(t, l, 3)
+
+	for _, want := range []int{2, 1, 0} {
+		l.release()
+		if got := <-served; got != want {
+			t.Errorf("Failed to serve by priority: got %d, want %d", got, want)
+		}
+	}
+}
+
+func TestPriorityLimiterAging(t *testing.T) {
+	l := newPriorityLimiter(1, 5*time.Millisecond)
+	l.acquire(context.Background(), 0)
+
+	served := make(chan int)
+	enqueue(t, l, 0, served)
+	waitForQueueDepth(t, l, 1)
+	time.Sleep(50 * time.Millisecond)
+	enqueue(t, l, 2, served)
+	waitForQueueDepth(t, l, 2)
+
+	l.release()
+	if got := <-served; got != 0 {
+		t.Errorf("Failed to serve the aged request first: got %d", got)
+	}
+	l.release()
+	<-served
+}
+
+func TestPriorityLimiterCancel(t *testing.T) {
+	l := newPriorityLimiter(1, 0)
+	l.acquire(context.Background(), 0)
+
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Millisecond)
+	defer cancel()
+	if err := l.acquire(ctx, 0); err != context.DeadlineExceeded {
+		t.Errorf("Failed to get deadline exceeded: %v", err)
+	}
+	if len(l.queueDepths()) != 0 {
+		t.Errorf("Failed to remove canceled request from queue: %v", l.queueDepths())
+	}
+
+	l.release()
+	if err := l.acquire(context.Background(), 0); err != nil {
+		t.Errorf("Failed to acquire after release: %v", err)
+	}
+}
+
+func TestClientMaxConcurrentRequests(t *testing.T) {
+	s := startTestServer(func(*http.Request) {})
+	defer s.Close()
+
+	cli := NewClient(Options{MaxConcurrentRequests: 1})
+	defer cli.Close()
+
+	rsp, err := cli.Get(s.URL)
+	if err != nil {
+		t.Fatalf("Failed to do request: %v", err)
+	}
+
+	done := make(chan struct{})
+	go func() {
+		req, _ := http.NewRequest("GET", s.URL, nil)
+		req = req.WithContext(WithRequestOptions(context.Background(), RequestOptions{Priority: 3}))
+		rsp, err := cli.Do(req)
+		if err != nil {
+			t.Errorf("Failed to do queued request: %v", err)
+		} else {
+			rsp.Body.Close()
+		}
+		close(done)
+	}()
+
+	waitForQueueDepth(t, cli.limiter, 1)
+	if d := cli.Stats().QueueDepths; d[3] != 1 {
+		t.Errorf("Failed to get queue depth by priority: %v", d)
+	}
+
+	rsp.Body.Close()
+	select {
+	case <-done:
+	case <-time.After(time.Second):
+		t.Fatal("Failed to serve the queued request")
+	}
+}
@@ -0,0 +1,109 @@
+package net
+
+import (
+	"errors"
+	"net/http"
+	"sync"
+	"time"
+)
+
+// ErrRateLimited is returned for requests, that can not be sent
+// within the deadline of the request context, because the rate
+// limit of the host is exceeded, see Options.RequestsPerSecond.
+var ErrRateLimited = errors.New("client rate limit exceeded")
+
+// hostRateLimiter has a token bucket for every request host.
+type hostRateLimiter struct {
+	rate  float64
+	burst float64
+
+	mu      sync.Mutex
+	buckets map[string]*tokenBucket
+}
+
+type tokenBucket struct {
+	tokens float64
+	last   time.Time
+}
+
+func newHostRateLimiter(o Options) *hostRateLimiter {
+	if o.RequestsPerSecond <= 0 {
+		return nil
+	}
+
+	burst := o.Burst
+	if burst <= 0 {
+		burst = 1
+	}
+	return &hostRateLimiter{
+		rate:    o.RequestsPerSecond,
+		burst:   float64(burst),
+		buckets: make(map[string]*tokenBucket),
+	}
+}
+
+// reserve takes a token from the bucket of the host and returns how
+// long to wait until it is available.
+func (l *hostRateLimiter) reserve(host string, now time.Time) time.Duration {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	b, ok := l.buckets[host]
+	if !ok {
+		b = &tokenBucket{tokens: l.burst, last: now}
+		l.buckets[host] = b
+	}
+
+	b.tokens += now.Sub(b.last).Seconds() * l.rate
+	if b.tokens > l.burst {
+		b.tokens = l.burst
+	}
+	b.last = now
+
+	b.tokens--
+	if b.tokens >= 0 {
+		return 0
+	}
+	return time.Duration(-b.tokens / l.rate * float64(time.Second))
+}
+
+// cancel returns a reserved token, that was not used.
+func (l *hostRateLimiter) cancel(host string) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	if b, ok := l.buckets[host]; ok {
+		b.tokens++
+	}
+}
+
+// wait blocks until the request may be sent. It fails with
+// ErrRateLimited, if the wait would exceed the deadline of the request
+// context.
+func (l *hostRateLimiter) wait(req *http.Request) error {
+	if l == nil {
+		return nil
+	}
+
+	host := req.URL.Host
+	now := time.Now()
+	d := l.reserve(host, now)
+	if d == 0 {
+		return nil
+	}
+
+	ctx := req.Context()
+	if deadline, ok := ctx.Deadline(); ok && now.Add(d).After(deadline) {
+		l.cancel(host)
+		return ErrRateLimited
+	}
+
+	timer := time.NewTimer(d)
+	defer timer.Stop()
+	select {
+	case <-timer.C:
+		return nil
+	case <-ctx.Done():
+		l.cancel(host)
+		return ctx.Err()
+	}
+}
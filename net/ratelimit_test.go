@@ -0,0 +1,78 @@
+package net
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"testing"
+	"time"
+)
+
+func TestRateLimit(t *testing.T) {
+	s := startTestServer(func(*http.Request) {})
+	defer s.Close()
+	other := startTestServer(func(*http.Request) {})
+	defer other.Close()
+
+	rt := NewTransport(Options{RequestsPerSecond: 20, Burst: 2})
+	defer rt.Close()
+
+	roundTrip := func(url string, timeout time.Duration) error {
+		ctx := context.Background()
+		if timeout > 0 {
+			var cancel context.CancelFunc
+			ctx, cancel = context.WithTimeout(ctx, timeout)
+			defer cancel()
+		}
+
+		req, _ := http.NewRequest("GET", url, nil)
+		rsp, err := rt.RoundTrip(req.WithContext(ctx))
+		if err != nil {
+			return err
+		}
+		return rsp.Body.Close()
+	}
+
+	start := time.Now()
+	for i := 0; i < 2; i++ {
+		if err := roundTrip(s.URL, 0); err != nil {
+			t.Fatalf("Failed to do request: %v", err)
+		}
+	}
+	if d := time.Since(start); d > 40*time.Millisecond {
+		t.Errorf("Failed to allow the burst: %v", d)
+	}
+
+	if err := roundTrip(s.URL, 10*time.Millisecond); !errors.Is(err, ErrRateLimited) || ClassifyError(err) != ErrorClassRateLimited {
+		t.Errorf("Failed to reject request exceeding the deadline: %v", err)
+	}
+
+	if err := roundTrip(other.URL, 0); err != nil {
+		t.Errorf("Failed to not limit other hosts: %v", err)
+	}
+
+	start = time.Now()
+	if err := roundTrip(s.URL, 0); err != nil {
+		t.Fatalf("Failed to do request: %v", err)
+	}
+	if d := time.Since(start); d < 20*time.Millisecond {
+		t.Errorf("Failed to wait for the rate limit: %v", d)
+	}
+}
+
+func TestRateLimitReserve(t *testing.T) {
+	l := newHostRateLimiter(Options{RequestsPerSecond: 10})
+	now := time.Now()
+
+	if d := l.reserve("foo", now); d != 0 {
+		t.Errorf("Failed to allow first request: %v", d)
+	}
+	if d := l.reserve("foo", now); d != 100*time.Millisecond {
+		t.Errorf("Failed to wait for the next token: %v", d)
+	}
+
+	l.cancel("foo")
+	if d := l.reserve("foo", now.Add(100*time.Millisecond)); d != 0 {
+		t.Errorf("Failed to refill the bucket: %v", d)
+	}
+}
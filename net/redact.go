@@ -0,0 +1,49 @@
+package net
+
+import (
+	"net/url"
+	"strings"
+)
+
+const redactedPlaceholder = "REDACTED"
+
+// newQueryRedactor returns a func to be used for Options.URLRedactor,
+// that replaces the values of the given query parameters by a
+// placeholder. The order of the query parameters is preserved.
+func newQueryRedactor(params []string) func(*url.URL) string {
+	redact := make(map[string]struct{}, len(params))
+	for _, p := range params {
+		redact[p] = struct{}{}
+	}
+
+	return func(u *url.URL) string {
+		if u.RawQuery == "" {
+			return u.String()
+		}
+
+		parts := strings.Split(u.RawQuery, "&")
+		for i, p := range parts {
+			k := p
+			if j := strings.IndexByte(p, '='); j >= 0 {
+				k = p[:j]
+			}
+
+			if name, err := url.QueryUnescape(k); err == nil {
+				if _, ok := redact[name]; ok {
+					parts[i] = k + "=" + redactedPlaceholder
+				}
+			}
+		}
+
+		uu := *u
+		uu.RawQuery = strings.Join(parts, "&")
+		return uu.String()
+	}
+}
+
+func (t *Transport) redactURL(u *url.URL) string {
+	if t.urlRedactor == nil {
+		return u.String()
+	}
+	return t.urlRedactor(u)
+}
@@ -0,0 +1,62 @@
+package net
+
+import (
+	"net/http"
+	"net/url"
+	"testing"
+
+	"github.com/zalando/skipper/tracing/tracingtest"
+)
+
+func TestQueryRedactor(t *testing.T) {
+	redact := newQueryRedactor([]string{"token", "e mail"})
+	for _, tt := range []struct {
+		url  string
+		want string
+	}{
+		{url: "http://example.org/foo", want: "http://example.org/foo"},
+		{url: "http://example.org/foo?a=b", want: "http://example.org/foo?a=b"},
+		{url: "http://example.org/foo?token=secret&a=b", want: "http://example.org/foo?token=REDACTED&a=b"},
+		{url: "http://example.org/foo?a=b&token=secret&token=other", want: "http://example.org/foo?a=b&token=REDACTED&token=REDACTED"},
+		{url: "http://example.org/foo?e+mail=me%40example.org", want: "http://example.org/foo?e+mail=REDACTED"},
+		{url: "http://example.org/foo?token", want: "http://example.org/foo?token=REDACTED"},
+	} {
+		u, _ := url.Parse(tt.url)
+		if got := redact(u); got != tt.want {
+			t.Errorf("Failed to redact %s: got %s, want %s", tt.url, got, tt.want)
+		}
+	}
+}
+
+func TestTransportRedactsSpanURL(t *testing.T) {
+	tracer := &tracingtest.Tracer{}
+
+	s := startTestServer(func(r *http.Request) {
+		if got := r.URL.Query().Get("token"); got != "secret" {
+			t.Errorf("Failed to send the real query value: %s", got)
+		}
+	})
+	defer s.Close()
+
+	rt := NewTransport(Options{
+		Tracer:            tracer,
+		RedactQueryParams: []string{"token"},
+	})
+	defer rt.Close()
+	rt = WithSpanName(rt, "myspan")
+
+	req, _ := http.NewRequest("GET", s.URL+"/foo?token=secret", nil)
+	rsp, err := rt.RoundTrip(req)
+	if err != nil {
+		t.Fatalf("Failed to do request: %v", err)
+	}
+	rsp.Body.Close()
+
+	span, ok := tracer.FindSpan("myspan")
+	if !ok {
+		t.Fatal("Failed to find span")
+	}
+	if got, want := span.Tags["http.url"], s.URL+"/foo?token=REDACTED"; got != want {
+		t.Errorf("Failed to redact span URL: got %v, want %v", got, want)
+	}
+}
@@ -0,0 +1,108 @@
+package net
+
+import (
+	"fmt"
+	"net/http"
+	"net/textproto"
+)
+
+const defaultMaxRedirects = 10
+
+// redirectSafeHeaders are preserved on all redirects.
+var redirectSafeHeaders = []string{
+	"Accept",
+	"Accept-Language",
+	"Content-Type",
+	"User-Agent",
+}
+
+// RedirectHeaderPolicy defines which request headers are preserved,
+// when the Client follows a redirect. The headers Accept,
+// Accept-Language, Content-Type and User-Agent are always preserved.
+type RedirectHeaderPolicy struct {
+	// SameHost are the headers preserved on redirects to the same
+	// host and port. Default: nil, all headers are preserved.
+	SameHost []string
+	// CrossHost are the headers preserved on redirects to a
+	// different host or port. Default: nil, only the always
+	// preserved headers, which drops sensitive headers like
+	// Authorization, Cookie or custom tenant headers.
+	CrossHost []string
+}
+
+func newHeaderSet(headers []string) map[string]struct{} {
+	if headers == nil {
+		return nil
+	}
+
+	s := make(map[string]struct{}, len(headers)+len(redirectSafeHeaders))
+	for _, h := range redirectSafeHeaders {
+		s[textproto.CanonicalMIMEHeaderKey(h)] = struct{}{}
+	}
+	for _, h := range headers {
+		s[textproto.CanonicalMIMEHeaderKey(h)] = struct{}{}
+	}
+	return s
+}
+
+// newCheckRedirect returns the CheckRedirect func of the http.Client,
+// that limits the number of redirects to Options.MaxRedirects,
+// applies the RedirectHeaderPolicy and calls Options.CheckRedirect.
+func newCheckRedirect(o Options) func(*http.Request, []*http.Request) error {
+	p := o.RedirectHeaderPolicy
+	sameHost := newHeaderSet(p.SameHost)
+	crossHost := newHeaderSet(p.CrossHost)
+	if crossHost == nil {
+		crossHost = newHeaderSet([]string{})
+	}
+
+	maxRedirects := o.MaxRedirects
+	if maxRedirects == 0 {
+		maxRedirects = defaultMaxRedirects
+	}
+
+	return func(req *http.Request, via []*http.Request) error {
+		if maxRedirects < 0 {
+			return http.ErrUseLastResponse
+		}
+		if len(via) >= maxRedirects {
+			return fmt.Errorf("stopped after %d redirects", maxRedirects)
+		}
+
+		allowed := crossHost
+		if !isCrossHostRedirect(req) {
+			allowed = sameHost
+		}
+
+		filterHeaders(req.Header, allowed)
+
+		if o.CheckRedirect != nil {
+			return o.CheckRedirect(req, via)
+		}
+		return nil
+	}
+}
+
+// isCrossHostRedirect returns true, if the request follows a redirect
+// and has a different host and port than the original request.
+func isCrossHostRedirect(req *http.Request) bool {
+	orig := req
+	for orig.Response != nil && orig.Response.Request != nil {
+		orig = orig.Response.Request
+	}
+	return orig.URL.Host != req.URL.Host
+}
+
+// filterHeaders removes all headers, that are not in allowed. A nil
+// allowed set keeps all headers.
+func filterHeaders(h http.Header, allowed map[string]struct{}) {
+	if allowed == nil {
+		return
+	}
+
+	for k := range h {
+		if _, ok := allowed[k]; !ok {
+			delete(h, k)
+		}
+	}
+}
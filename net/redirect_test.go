@@ -0,0 +1,197 @@
+package net
+
+import (
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"testing"
+)
+
+func TestRedirectHeaderPolicy(t *testing.T) {
+	got := make(chan http.Header, 1)
+	target := func(w http.ResponseWriter, r *http.Request) {
+		got <- r.Header
+	}
+
+	cross := httptest.NewServer(http.HandlerFunc(target))
+	defer cross.Close()
+
+	mux := http.NewServeMux()
+	mux.HandleFunc("/target", target)
+	mux.HandleFunc("/same", func(w http.ResponseWriter, r *http.Request) {
+		http.Redirect(w, r, "/target", http.StatusFound)
+	})
+	mux.HandleFunc("/cross", func(w http.ResponseWriter, r *http.Request) {
+		http.Redirect(w, r, cross.URL+"/target", http.StatusFound)
+	})
+	origin := httptest.NewServer(mux)
+	defer origin.Close()
+
+	for _, tt := range []struct {
+		name   string
+		policy RedirectHeaderPolicy
+		path   string
+		want   []string
+		drop   []string
+	}{{
+		name: "default same host keeps all headers",
+		path: "/same",
+		want: []string{"Authorization", "X-Tenant", "X-Other", "User-Agent"},
+	}, {
+		name: "default cross host drops sensitive headers",
+		path: "/cross",
+		want: []string{"User-Agent", "Accept"},
+		drop: []string{"Authorization", "X-Tenant", "X-Other"},
+	}, {
+		name:   "same host allowlist",
+		policy: RedirectHeaderPolicy{SameHost: []string{"x-tenant"}},
+		path:   "/same",
+		want:   []string{"X-Tenant", "User-Agent"},
+		drop:   []string{"Authorization", "X-Other"},
+	}, {
+		name:   "cross host allowlist",
+		policy: RedirectHeaderPolicy{CrossHost: []string{"X-Tenant"}},
+		path:   "/cross",
+		want:   []string{"X-Tenant", "User-Agent"},
+		drop:   []string{"Authorization", "X-Other"},
+	}} {
+		t.Run(tt.name, func(t *testing.T) {
+			cli := NewClient(Options{RedirectHeaderPolicy: tt.policy})
+			defer cli.Close()
+
+			req, _ := http.NewRequest("GET", origin.URL+tt.path, nil)
+			req.Header.Set("Authorization", "Bearer my-token")
+			req.Header.Set("X-Tenant", "tenant")
+			req.Header.Set("X-Other", "other")
+			req.Header.Set("Accept", "application/json")
+
+			rsp, err := cli.Do(req)
+			if err != nil {
+				t.Fatalf("Failed to do request: %v", err)
+			}
+			rsp.Body.Close()
+
+			h := <-got
+			for _, k := range tt.want {
+				if h.Get(k) == "" {
+					t.Errorf("Failed to preserve header %s: %v", k, h)
+				}
+			}
+			for _, k := range tt.drop {
+				if h.Get(k) != "" {
+					t.Errorf("Failed to drop header %s: %v", k, h)
+				}
+			}
+		})
+	}
+}
+
+func TestRedirectLimits(t *testing.T) {
+	mux := http.NewServeMux()
+	mux.HandleFunc("/1", func(w http.ResponseWriter, r *http.Request) {
+		http.Redirect(w, r, "/2", http.StatusFound)
+	})
+	mux.HandleFunc("/2", func(w http.ResponseWriter, r *http.Request) {
+		http.Redirect(w, r, "/3", http.StatusFound)
+	})
+	mux.HandleFunc("/3", func(w http.ResponseWriter, r *http.Request) {})
+	s := httptest.NewServer(mux)
+	defer s.Close()
+
+	errCheck := errors.New("check")
+	for _, tt := range []struct {
+		name       string
+		options    Options
+		wantErr    error
+		wantStatus int
+	}{{
+		name:       "default",
+		wantStatus: http.StatusOK,
+	}, {
+		name:       "disabled",
+		options:    Options{MaxRedirects: -1},
+		wantStatus: http.StatusFound,
+	}, {
+		name:    "exceeded",
+		options: Options{MaxRedirects: 1},
+		wantErr: errors.New("stopped after 1 redirects"),
+	}, {
+		name: "check redirect",
+		options: Options{CheckRedirect: func(req *http.Request, via []*http.Request) error {
+			if req.URL.Path == "/3" {
+				return errCheck
+			}
+			return nil
+		}},
+		wantErr: errCheck,
+	}} {
+		t.Run(tt.name, func(t *testing.T) {
+			cli := NewClient(tt.options)
+			defer cli.Close()
+
+			rsp, err := cli.Get(s.URL + "/1")
+			if tt.wantErr != nil {
+				if err == nil {
+					rsp.Body.Close()
+					t.Fatal("Failed to get an error")
+				}
+				if uerr, ok := err.(*url.Error); !ok || uerr.Err.Error() != tt.wantErr.Error() {
+					t.Errorf("Failed to get error: got %v, want %v", err, tt.wantErr)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("Failed to do request: %v", err)
+			}
+			rsp.Body.Close()
+
+			if rsp.StatusCode != tt.wantStatus {
+				t.Errorf("Failed to get status: got %d, want %d", rsp.StatusCode, tt.wantStatus)
+			}
+		})
+	}
+}
+
+func TestRedirectStripsTransportBearerToken(t *testing.T) {
+	got := make(chan string, 2)
+	target := func(w http.ResponseWriter, r *http.Request) {
+		got <- r.Header.Get("Authorization")
+	}
+
+	cross := httptest.NewServer(http.HandlerFunc(target))
+	defer cross.Close()
+
+	mux := http.NewServeMux()
+	mux.HandleFunc("/target", target)
+	mux.HandleFunc("/same", func(w http.ResponseWriter, r *http.Request) {
+		http.Redirect(w, r, "/target", http.StatusFound)
+	})
+	mux.HandleFunc("/cross", func(w http.ResponseWriter, r *http.Request) {
+		http.Redirect(w, r, cross.URL+"/target", http.StatusFound)
+	})
+	origin := httptest.NewServer(mux)
+	defer origin.Close()
+
+	rt := NewTransport(Options{})
+	defer rt.Close()
+	cli := &http.Client{
+		Transport:     WithBearerToken(rt, "my-token"),
+		CheckRedirect: newCheckRedirect(Options{}),
+	}
+
+	for path, want := range map[string]string{
+		"/same":  "Bearer my-token",
+		"/cross": "",
+	} {
+		rsp, err := cli.Get(origin.URL + path)
+		if err != nil {
+			t.Fatalf("Failed to do request: %v", err)
+		}
+		rsp.Body.Close()
+
+		if auth := <-got; auth != want {
+			t.Errorf("Failed to get authorization on %s: got %q, want %q", path, auth, want)
+		}
+	}
+}
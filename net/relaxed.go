@@ -0,0 +1,89 @@
+package net
+
+import (
+	"context"
+	"net/http"
+	"time"
+)
+
+const (
+	defaultRelaxedTimeoutFactor      = 2
+	defaultRelaxedTimeoutMaxAttempts = 3
+)
+
+// RelaxedTimeouts configure Client.DoRelaxed.
+type RelaxedTimeouts struct {
+	// Initial is the timeout of the first attempt. It is required.
+	Initial time.Duration
+	// Factor is multiplied with the timeout of an attempt to get the
+	// timeout of the next attempt. Default: 2.
+	Factor float64
+	// Max limits the timeout of a single attempt. Default: 0, no
+	// limit.
+	Max time.Duration
+	// Budget limits the total time of all attempts. The timeout of
+	// the last attempt is shortened to fit into the budget. Default:
+	// 0, only limited by the request context.
+	Budget time.Duration
+	// MaxAttempts is the maximum number of attempts. Default: 3.
+	MaxAttempts int
+}
+
+// DoRelaxed sends the request with a tight timeout first and retries
+// it on timeouts with progressively relaxed timeouts, see
+// RelaxedTimeouts. This optimizes for the common fast case of an
+// occasionally slow endpoint. The timeout of an attempt covers the
+// whole request including reading the response body, see
+// RequestOptions.Timeout, and is tagged on the span of the
+// attempt. Only idempotent requests, that can be sent again, are
+// retried. Other RequestOptions in the request context are preserved.
+func (c *Client) DoRelaxed(req *http.Request, rt RelaxedTimeouts) (*http.Response, error) {
+	if rt.Factor < 1 {
+		rt.Factor = defaultRelaxedTimeoutFactor
+	}
+	if rt.MaxAttempts <= 0 {
+		rt.MaxAttempts = defaultRelaxedTimeoutMaxAttempts
+	}
+
+	ctx := req.Context()
+	if rt.Budget > 0 {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, rt.Budget)
+		defer cancel()
+	}
+
+	deadline, hasDeadline := ctx.Deadline()
+	ro, _ := RequestOptionsFromContext(ctx)
+	timeout := rt.Initial
+	for attempt := 1; ; attempt++ {
+		if rt.Max > 0 && timeout > rt.Max {
+			timeout = rt.Max
+		}
+
+		ro.Timeout = timeout
+		if hasDeadline {
+			if remaining := time.Until(deadline); remaining < ro.Timeout {
+				ro.Timeout = remaining
+			}
+		}
+
+		attemptReq := req
+		if attempt > 1 {
+			var err error
+			if attemptReq, err = rewindRequest(req); err != nil {
+				return nil, err
+			}
+		}
+
+		// the budget context is not passed to the request, because
+		// it would be canceled on return, while the response body
+		// is read
+		rsp, err := c.Do(attemptReq.WithContext(WithRequestOptions(req.Context(), ro)))
+		if err == nil || attempt >= rt.MaxAttempts || ctx.Err() != nil ||
+			ClassifyError(err) != ErrorClassTimeout || !isReplayable(req) {
+			return rsp, err
+		}
+
+		timeout = time.Duration(float64(timeout) * rt.Factor)
+	}
+}
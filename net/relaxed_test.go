@@ -0,0 +1,93 @@
+package net
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/zalando/skipper/tracing/tracingtest"
+)
+
+func TestDoRelaxed(t *testing.T) {
+	var requests int32
+	s := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		atomic.AddInt32(&requests, 1)
+		time.Sleep(60 * time.Millisecond)
+	}))
+	defer s.Close()
+
+	for _, tt := range []struct {
+		name         string
+		method       string
+		rt           RelaxedTimeouts
+		wantErr      bool
+		wantRequests int32
+		wantTimeouts []interface{}
+	}{{
+		name:         "succeeds with relaxed timeout",
+		method:       "GET",
+		rt:           RelaxedTimeouts{Initial: 20 * time.Millisecond},
+		wantRequests: 3,
+		wantTimeouts: []interface{}{"20ms", "40ms", "80ms"},
+	}, {
+		name:         "max attempts exceeded",
+		method:       "GET",
+		rt:           RelaxedTimeouts{Initial: 10 * time.Millisecond, MaxAttempts: 2},
+		wantErr:      true,
+		wantRequests: 2,
+		wantTimeouts: []interface{}{"10ms", "20ms"},
+	}, {
+		name:         "max timeout",
+		method:       "GET",
+		rt:           RelaxedTimeouts{Initial: 10 * time.Millisecond, Factor: 10, Max: 30 * time.Millisecond},
+		wantErr:      true,
+		wantRequests: 3,
+		wantTimeouts: []interface{}{"10ms", "30ms", "30ms"},
+	}, {
+		name:         "budget exceeded",
+		method:       "GET",
+		rt:           RelaxedTimeouts{Initial: 20 * time.Millisecond, Budget: 50 * time.Millisecond, MaxAttempts: 10},
+		wantErr:      true,
+		wantRequests: 2,
+	}, {
+		name:         "not idempotent",
+		method:       "POST",
+		rt:           RelaxedTimeouts{Initial: 20 * time.Millisecond},
+		wantErr:      true,
+		wantRequests: 1,
+		wantTimeouts: []interface{}{"20ms"},
+	}} {
+		t.Run(tt.name, func(t *testing.T) {
+			atomic.StoreInt32(&requests, 0)
+			tracer := &tracingtest.Tracer{}
+			cli := NewClient(Options{Tracer: tracer, OpentracingSpanName: "myspan"})
+			defer cli.Close()
+
+			req, _ := http.NewRequest(tt.method, s.URL, nil)
+			rsp, err := cli.DoRelaxed(req, tt.rt)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("Failed to do request, error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if err == nil {
+				rsp.Body.Close()
+			}
+
+			if got := atomic.LoadInt32(&requests); got != tt.wantRequests {
+				t.Errorf("Failed to get number of requests: got %d, want %d", got, tt.wantRequests)
+			}
+
+			if tt.wantTimeouts != nil {
+				var got []interface{}
+				for _, span := range tracer.FindAllSpans("myspan") {
+					got = append(got, span.Tags[timeoutTag])
+				}
+				if !reflect.DeepEqual(got, tt.wantTimeouts) {
+					t.Errorf("Failed to tag attempt timeouts: got %v, want %v", got, tt.wantTimeouts)
+				}
+			}
+		})
+	}
+}
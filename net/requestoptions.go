@@ -0,0 +1,123 @@
+package net
+
+import (
+	"context"
+	"errors"
+	"io"
+	"net/http"
+	"time"
+)
+
+// ErrResponseBodyTooLarge is returned on reading a response body, that
+// exceeds the configured maximum size.
+var ErrResponseBodyTooLarge = errors.New("response body too large")
+
+type requestOptionsKey struct{}
+
+// RequestOptions are per request overrides of the Transport
+// configuration. They are passed via the request context, see
+// WithRequestOptions. A field set to its zero value does not change
+// the behavior configured by Options, a non zero value has always
+// precedence over Options.
+type RequestOptions struct {
+	// Timeout is the total timeout of the request including reading
+	// the response body. If the request context has an earlier
+	// deadline, the earlier one is used. Default: 0, no timeout
+	// additional to the request context.
+	Timeout time.Duration
+	// MaxResponseBodySize limits the number of bytes, that can be
+	// read from the response body. Reading more returns
+	// ErrResponseBodyTooLarge. Default: 0, unlimited.
+	MaxResponseBodySize int64
+	// SkipBearerToken disables the injection of the bearer token
+	// configured by WithBearerToken. Default: false.
+	SkipBearerToken bool
+	// DisableStaleConnRetry disables the retry of idempotent
+	// requests, that failed on a stale keep-alive
+	// connection. Default: false.
+	DisableStaleConnRetry bool
+	// Priority of the request, if the Client queues requests, see
+	// Options.MaxConcurrentRequests. Requests with a higher value are
+	// served first. Default: 0.
+	Priority int
+}
+
+// WithRequestOptions returns a copy of ctx carrying the given
+// RequestOptions. Requests using the returned context are sent by
+// Transport.RoundTrip with the given overrides.
+func WithRequestOptions(ctx context.Context, o RequestOptions) context.Context {
+	return context.WithValue(ctx, requestOptionsKey{}, o)
+}
+
+// RequestOptionsFromContext returns the RequestOptions stored in ctx
+// and if they were found.
+func RequestOptionsFromContext(ctx context.Context) (RequestOptions, bool) {
+	o, ok := ctx.Value(requestOptionsKey{}).(RequestOptions)
+	return o, ok
+}
+
+// withTimeout sets the RequestOptions.Timeout on the request
+// context. The returned cancel func has to be called, if the round
+// trip fails, otherwise it is called on closing the response body.
+func (o RequestOptions) withTimeout(req *http.Request) (*http.Request, context.CancelFunc) {
+	if o.Timeout <= 0 {
+		return req, func() {}
+	}
+
+	ctx, cancel := context.WithTimeout(req.Context(), o.Timeout)
+	return req.WithContext(ctx), cancel
+}
+
+func (o RequestOptions) wrapBody(rsp *http.Response, cancel context.CancelFunc) {
+	if rsp.Body == nil {
+		cancel()
+		return
+	}
+
+	if o.MaxResponseBodySize > 0 {
+		rsp.Body = &limitedBody{body: rsp.Body, n: o.MaxResponseBodySize}
+	}
+	if o.Timeout > 0 {
+		rsp.Body = &cancelBody{ReadCloser: rsp.Body, cancel: cancel}
+	}
+}
+
+type cancelBody struct {
+	io.ReadCloser
+	cancel context.CancelFunc
+}
+
+func (b *cancelBody) Close() error {
+	err := b.ReadCloser.Close()
+	b.cancel()
+	return err
+}
+
+// limitedBody returns ErrResponseBodyTooLarge instead of io.EOF, if
+// the underlying body has more than n bytes.
+type limitedBody struct {
+	body io.ReadCloser
+	n    int64
+}
+
+func (b *limitedBody) Read(p []byte) (int, error) {
+	if b.n <= 0 {
+		var probe [1]byte
+		n, err := b.body.Read(probe[:])
+		if n > 0 {
+			return 0, ErrResponseBodyTooLarge
+		}
+		return 0, err
+	}
+
+	if int64(len(p)) > b.n {
+		p = p[:b.n]
+	}
+	n, err := b.body.Read(p)
+	b.n -= int64(n)
+	return n, err
+}
+
+func (b *limitedBody) Close() error {
+	return b.body.Close()
+}
@@ -0,0 +1,142 @@
+package net
+
+import (
+	"context"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestRequestOptions(t *testing.T) {
+	s := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path == "/slow" {
+			time.Sleep(100 * time.Millisecond)
+		}
+		w.Header().Set("X-Authorization", r.Header.Get("Authorization"))
+		w.Write([]byte(strings.Repeat("a", 100)))
+	}))
+	defer s.Close()
+
+	rt := NewTransport(Options{})
+	defer rt.Close()
+	rt = WithBearerToken(rt, "my-token")
+
+	for _, tt := range []struct {
+		name        string
+		path        string
+		options     *RequestOptions
+		wantErr     bool
+		wantBodyErr error
+		wantAuth    string
+	}{{
+		name:     "no request options",
+		path:     "/",
+		wantAuth: "Bearer my-token",
+	}, {
+		name:     "zero request options do not change the behavior",
+		path:     "/",
+		options:  &RequestOptions{},
+		wantAuth: "Bearer my-token",
+	}, {
+		name:    "timeout",
+		path:    "/slow",
+		options: &RequestOptions{Timeout: 10 * time.Millisecond},
+		wantErr: true,
+	}, {
+		name:     "timeout not reached",
+		path:     "/",
+		options:  &RequestOptions{Timeout: time.Second},
+		wantAuth: "Bearer my-token",
+	}, {
+		name:        "response body too large",
+		path:        "/",
+		options:     &RequestOptions{MaxResponseBodySize: 10},
+		wantBodyErr: ErrResponseBodyTooLarge,
+		wantAuth:    "Bearer my-token",
+	}, {
+		name:     "response body within limit",
+		path:     "/",
+		options:  &RequestOptions{MaxResponseBodySize: 100},
+		wantAuth: "Bearer my-token",
+	}, {
+		name:    "skip bearer token",
+		path:    "/",
+		options: &RequestOptions{SkipBearerToken: true},
+	}} {
+		t.Run(tt.name, func(t *testing.T) {
+			req, _ := http.NewRequest("GET", s.URL+tt.path, nil)
+			if tt.options != nil {
+				req = req.WithContext(WithRequestOptions(context.Background(), *tt.options))
+			}
+
+			rsp, err := rt.RoundTrip(req)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("Failed to do request, error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if tt.wantErr {
+				return
+			}
+			defer rsp.Body.Close()
+
+			if _, err := ioutil.ReadAll(rsp.Body); err != tt.wantBodyErr {
+				t.Errorf("Failed to read body, error = %v, want %v", err, tt.wantBodyErr)
+			}
+			if got := rsp.Header.Get("X-Authorization"); got != tt.wantAuth {
+				t.Errorf("Failed to get authorization: got %q, want %q", got, tt.wantAuth)
+			}
+		})
+	}
+}
+
+func TestMaxResponseBodySize(t *testing.T) {
+	s := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte(strings.Repeat("a", 100)))
+	}))
+	defer s.Close()
+
+	cli := NewClient(Options{MaxResponseBodySize: 10})
+	defer cli.Close()
+
+	for _, tt := range []struct {
+		name    string
+		options *RequestOptions
+		wantErr error
+	}{{
+		name:    "limit of the client",
+		wantErr: ErrResponseBodyTooLarge,
+	}, {
+		name:    "request options have precedence",
+		options: &RequestOptions{MaxResponseBodySize: 100},
+	}} {
+		t.Run(tt.name, func(t *testing.T) {
+			req, _ := http.NewRequest("GET", s.URL, nil)
+			if tt.options != nil {
+				req = req.WithContext(WithRequestOptions(context.Background(), *tt.options))
+			}
+
+			rsp, err := cli.Do(req)
+			if err != nil {
+				t.Fatalf("Failed to do request: %v", err)
+			}
+			defer rsp.Body.Close()
+
+			if _, err := ioutil.ReadAll(rsp.Body); err != tt.wantErr {
+				t.Errorf("Failed to read body, error = %v, want %v", err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestRequestOptionsFromContext(t *testing.T) {
+	if _, ok := RequestOptionsFromContext(context.Background()); ok {
+		t.Error("Failed to not find request options")
+	}
+
+	ctx := WithRequestOptions(context.Background(), RequestOptions{Timeout: time.Second})
+	if o, ok := RequestOptionsFromContext(ctx); !ok || o.Timeout != time.Second {
+		t.Errorf("Failed to get request options: %v %v", o, ok)
+	}
+}
@@ -0,0 +1,235 @@
+package net
+
+import (
+	"io"
+	"io/ioutil"
+	"math/rand"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+)
+
+const (
+	defaultRetryBackoff    = 100 * time.Millisecond
+	defaultRetryMaxBackoff = 5 * time.Second
+	maxRetryDrainBytes     = 4 << 10
+)
+
+var (
+	defaultRetryStatusCodes = []int{
+		http.StatusBadGateway,
+		http.StatusServiceUnavailable,
+		http.StatusGatewayTimeout,
+	}
+
+	defaultRetryMethods = []string{
+		http.MethodGet,
+		http.MethodHead,
+		http.MethodOptions,
+		http.MethodTrace,
+		http.MethodPut,
+		http.MethodDelete,
+	}
+)
+
+// RetryAttempt describes a failed attempt of a request, that the
+// Client retries, see Options.OnRetry.
+type RetryAttempt struct {
+	// Request is the original request.
+	Request *http.Request
+	// Attempt is the number of the failed attempt, starting at 1.
+	Attempt int
+	// Response is the response of the failed attempt, nil if Err is
+	// set. Its body is already closed.
+	Response *http.Response
+	// Err is the error of the failed attempt.
+	Err error
+	// Wait is the time the Client waits before the retry.
+	Wait time.Duration
+}
+
+type retryPolicy struct {
+	maxRetries  int
+	backoff     time.Duration
+	maxBackoff  time.Duration
+	budget      time.Duration
+	statusCodes map[int]struct{}
+	methods     map[string]struct{}
+	onRetry     func(RetryAttempt)
+}
+
+func newRetryPolicy(o Options) *retryPolicy {
+	if o.MaxRetries <= 0 {
+		return nil
+	}
+
+	if o.RetryBackoff <= 0 {
+		o.RetryBackoff = defaultRetryBackoff
+	}
+	if o.RetryMaxBackoff <= 0 {
+		o.RetryMaxBackoff = defaultRetryMaxBackoff
+	}
+	if o.RetryStatusCodes == nil {
+		o.RetryStatusCodes = defaultRetryStatusCodes
+	}
+	if o.RetryMethods == nil {
+		o.RetryMethods = defaultRetryMethods
+	}
+
+	p := &retryPolicy{
+		maxRetries:  o.MaxRetries,
+		backoff:     o.RetryBackoff,
+		maxBackoff:  o.RetryMaxBackoff,
+		budget:      o.RetryBudget,
+		onRetry:     o.OnRetry,
+		statusCodes: make(map[int]struct{}, len(o.RetryStatusCodes)),
+		methods:     make(map[string]struct{}, len(o.RetryMethods)),
+	}
+	for _, code := range o.RetryStatusCodes {
+		p.statusCodes[code] = struct{}{}
+	}
+	for _, m := range o.RetryMethods {
+		p.methods[strings.ToUpper(m)] = struct{}{}
+	}
+	return p
+}
+
+// retryable returns true, if the request method allows a retry and
+// the request body can be sent again.
+func (p *retryPolicy) retryable(req *http.Request) bool {
+	method := req.Method
+	if method == "" {
+		method = http.MethodGet
+	}
+	if _, ok := p.methods[method]; !ok {
+		return false
+	}
+	return req.Body == nil || req.Body == http.NoBody || req.GetBody != nil
+}
+
+// retryableResult returns true for transient errors, i.e. dial, DNS,
+// timeout and truncated response errors, for the configured
+// status codes and 429 or 503 responses with a Retry-After header.
+func (p *retryPolicy) retryableResult(rsp *http.Response, err error) bool {
+	if err != nil {
+		switch ClassifyError(err) {
+		case ErrorClassDial, ErrorClassDNS, ErrorClassTimeout, ErrorClassTruncated:
+			return true
+		default:
+			return false
+		}
+	}
+
+	if _, ok := p.statusCodes[rsp.StatusCode]; ok {
+		return true
+	}
+	_, ok := retryAfter(rsp)
+	return ok
+}
+
+// retryAfter returns the delay announced by the Retry-After header of
+// 429 and 503 responses, either in seconds or as HTTP date.
+func retryAfter(rsp *http.Response) (time.Duration, bool) {
+	if rsp == nil || rsp.StatusCode != http.StatusTooManyRequests && rsp.StatusCode != http.StatusServiceUnavailable {
+		return 0, false
+	}
+
+	h := rsp.Header.Get("Retry-After")
+	if h == "" {
+		return 0, false
+	}
+	if s, err := strconv.Atoi(h); err == nil {
+		if s < 0 {
+			return 0, false
+		}
+		return time.Duration(s) * time.Second, true
+	}
+	if t, err := http.ParseTime(h); err == nil {
+		d := time.Until(t)
+		if d < 0 {
+			d = 0
+		}
+		return d, true
+	}
+	return 0, false
+}
+
+// waitFor returns the wait before the given retry, which is the
+// Retry-After of the response or the backoff. It returns false, if
+// the Retry-After exceeds the maximum backoff.
+func (p *retryPolicy) waitFor(retry int, rsp *http.Response) (time.Duration, bool) {
+	if d, ok := retryAfter(rsp); ok {
+		return d, d <= p.maxBackoff
+	}
+	return p.backoffFor(retry), true
+}
+
+// backoffFor returns the exponential backoff with jitter before the
+// given retry, starting at 1. The jitter spreads the backoff randomly
+// between half and the full exponential value.
+func (p *retryPolicy) backoffFor(retry int) time.Duration {
+	b := p.backoff
+	for i := 1; i < retry && b < p.maxBackoff; i++ {
+		b *= 2
+	}
+	if b > p.maxBackoff {
+		b = p.maxBackoff
+	}
+
+	half := int64(b / 2)
+	return time.Duration(half + rand.Int63n(half+1))
+}
+
+// doRetry sends the request and retries it according to the retry
+// policy. The response of the last attempt is returned.
+func (c *Client) doRetry(req *http.Request) (*http.Response, error) {
+	p := c.retry
+	if p == nil || !p.retryable(req) {
+		return c.client.Do(req)
+	}
+
+	start := time.Now()
+	for retry := 1; ; retry++ {
+		attemptReq := req
+		if retry > 1 {
+			var err error
+			if attemptReq, err = rewindRequest(req); err != nil {
+				return nil, err
+			}
+		}
+
+		rsp, err := c.client.Do(attemptReq)
+		if retry > p.maxRetries || !p.retryableResult(rsp, err) {
+			return rsp, err
+		}
+
+		wait, ok := p.waitFor(retry, rsp)
+		if !ok || p.budget > 0 && time.Since(start)+wait > p.budget {
+			return rsp, err
+		}
+
+		if rsp != nil {
+			// drain a bit to allow the connection to be reused
+			io.CopyN(ioutil.Discard, rsp.Body, maxRetryDrainBytes)
+			rsp.Body.Close()
+		}
+
+		c.tr.incRetry(req.URL.Host)
+		if p.onRetry != nil {
+			p.onRetry(RetryAttempt{
+				Request:  req,
+				Attempt:  retry,
+				Response: rsp,
+				Err:      err,
+				Wait:     wait,
+			})
+		}
+
+		select {
+		case <-time.After(wait):
+		case <-req.Context().Done():
+			return nil, req.Context().Err()
+		}
+	}
+}
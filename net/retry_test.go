@@ -0,0 +1,288 @@
+package net
+
+import (
+	"context"
+	"errors"
+	"io/ioutil"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+func TestClientRetry(t *testing.T) {
+	for _, tt := range []struct {
+		name         string
+		options      Options
+		method       string
+		body         string
+		failures     int32
+		failStatus   int
+		wantStatus   int
+		wantRequests int32
+	}{{
+		name:         "no retries configured",
+		method:       "GET",
+		failures:     1,
+		failStatus:   http.StatusServiceUnavailable,
+		wantStatus:   http.StatusServiceUnavailable,
+		wantRequests: 1,
+	}, {
+		name:         "retried until success",
+		options:      Options{MaxRetries: 3},
+		method:       "GET",
+		failures:     2,
+		failStatus:   http.StatusBadGateway,
+		wantStatus:   http.StatusOK,
+		wantRequests: 3,
+	}, {
+		name:         "retries exhausted",
+		options:      Options{MaxRetries: 2},
+		method:       "GET",
+		failures:     5,
+		failStatus:   http.StatusGatewayTimeout,
+		wantStatus:   http.StatusGatewayTimeout,
+		wantRequests: 3,
+	}, {
+		name:         "status code not retried",
+		options:      Options{MaxRetries: 2},
+		method:       "GET",
+		failures:     1,
+		failStatus:   http.StatusInternalServerError,
+		wantStatus:   http.StatusInternalServerError,
+		wantRequests: 1,
+	}, {
+		name:         "custom status code",
+		options:      Options{MaxRetries: 2, RetryStatusCodes: []int{http.StatusInternalServerError}},
+		method:       "GET",
+		failures:     1,
+		failStatus:   http.StatusInternalServerError,
+		wantStatus:   http.StatusOK,
+		wantRequests: 2,
+	}, {
+		name:         "non idempotent method not retried",
+		options:      Options{MaxRetries: 2},
+		method:       "POST",
+		body:         "foo",
+		failures:     1,
+		failStatus:   http.StatusServiceUnavailable,
+		wantStatus:   http.StatusServiceUnavailable,
+		wantRequests: 1,
+	}, {
+		name:         "custom method with body",
+		options:      Options{MaxRetries: 2, RetryMethods: []string{"post"}},
+		method:       "POST",
+		body:         "foo",
+		failures:     1,
+		failStatus:   http.StatusServiceUnavailable,
+		wantStatus:   http.StatusOK,
+		wantRequests: 2,
+	}} {
+		t.Run(tt.name, func(t *testing.T) {
+			var requests int32
+			s := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				n := atomic.AddInt32(&requests, 1)
+				b, _ := ioutil.ReadAll(r.Body)
+				if string(b) != tt.body {
+					t.Errorf("Failed to get the body on request %d: %q", n, b)
+				}
+				if n <= tt.failures {
+					w.WriteHeader(tt.failStatus)
+				}
+			}))
+			defer s.Close()
+
+			tt.options.RetryBackoff = time.Millisecond
+			cli := NewClient(tt.options)
+			defer cli.Close()
+
+			req, _ := http.NewRequest(tt.method, s.URL, strings.NewReader(tt.body))
+			rsp, err := cli.Do(req)
+			if err != nil {
+				t.Fatalf("Failed to do request: %v", err)
+			}
+			rsp.Body.Close()
+
+			if rsp.StatusCode != tt.wantStatus {
+				t.Errorf("Failed to get status: got %d, want %d", rsp.StatusCode, tt.wantStatus)
+			}
+			if got := atomic.LoadInt32(&requests); got != tt.wantRequests {
+				t.Errorf("Failed to get number of requests: got %d, want %d", got, tt.wantRequests)
+			}
+		})
+	}
+}
+
+func TestClientRetryDialError(t *testing.T) {
+	cli := NewClient(Options{MaxRetries: 2, RetryBackoff: time.Millisecond})
+	defer cli.Close()
+
+	fd := &failingDial{failures: 10}
+	cli.tr.tr.DialContext = fd.dial
+
+	if _, err := cli.Get("http://upstream.test/"); err == nil {
+		t.Fatal("Failed to get an error")
+	}
+	if fd.calls != 3 {
+		t.Errorf("Failed to retry dial errors: got %d dials, want 3", fd.calls)
+	}
+}
+
+func TestClientRetryOtherError(t *testing.T) {
+	cli := NewClient(Options{MaxRetries: 2, RetryBackoff: time.Millisecond})
+	defer cli.Close()
+
+	var calls int32
+	cli.tr.tr.DialContext = func(context.Context, string, string) (net.Conn, error) {
+		atomic.AddInt32(&calls, 1)
+		return nil, errors.New("test error")
+	}
+
+	_, err := cli.Get("http://upstream.test/")
+	if c := ClassifyError(err); c != ErrorClassOther {
+		t.Fatalf("Failed to get an error of class other: %v, %v", c, err)
+	}
+	if calls != 1 {
+		t.Errorf("Failed to not retry permanent errors: got %d dials, want 1", calls)
+	}
+}
+
+func TestRetryBackoff(t *testing.T) {
+	p := newRetryPolicy(Options{MaxRetries: 10, RetryBackoff: 100 * time.Millisecond, RetryMaxBackoff: time.Second})
+	for retry, want := range map[int]time.Duration{
+		1: 100 * time.Millisecond,
+		2: 200 * time.Millisecond,
+		3: 400 * time.Millisecond,
+		5: time.Second,
+		9: time.Second,
+	} {
+		for i := 0; i < 10; i++ {
+			if got := p.backoffFor(retry); got < want/2 || got > want {
+				t.Errorf("Failed to get backoff for retry %d: got %v, want between %v and %v", retry, got, want/2, want)
+			}
+		}
+	}
+}
+
+func TestClientRetryAfter(t *testing.T) {
+	for _, tt := range []struct {
+		name         string
+		options      Options
+		status       int
+		retryAfter   string
+		wantStatus   int
+		wantRequests int32
+		wantWait     time.Duration
+	}{{
+		name:         "retry after seconds on 429",
+		options:      Options{MaxRetries: 1},
+		status:       http.StatusTooManyRequests,
+		retryAfter:   "1",
+		wantStatus:   http.StatusOK,
+		wantRequests: 2,
+		wantWait:     time.Second,
+	}, {
+		name:         "retry after date on 503",
+		options:      Options{MaxRetries: 1},
+		status:       http.StatusServiceUnavailable,
+		retryAfter:   time.Now().Add(-time.Minute).UTC().Format(http.TimeFormat),
+		wantStatus:   http.StatusOK,
+		wantRequests: 2,
+	}, {
+		name:         "429 without retry after is not retried",
+		options:      Options{MaxRetries: 1},
+		status:       http.StatusTooManyRequests,
+		wantStatus:   http.StatusTooManyRequests,
+		wantRequests: 1,
+	}, {
+		name:         "retry after exceeding max backoff",
+		options:      Options{MaxRetries: 1, RetryMaxBackoff: time.Second},
+		status:       http.StatusTooManyRequests,
+		retryAfter:   "2",
+		wantStatus:   http.StatusTooManyRequests,
+		wantRequests: 1,
+	}, {
+		name:         "retry after exceeding budget",
+		options:      Options{MaxRetries: 1, RetryBudget: 500 * time.Millisecond},
+		status:       http.StatusTooManyRequests,
+		retryAfter:   "1",
+		wantStatus:   http.StatusTooManyRequests,
+		wantRequests: 1,
+	}} {
+		t.Run(tt.name, func(t *testing.T) {
+			var requests int32
+			s := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				if atomic.AddInt32(&requests, 1) == 1 {
+					if tt.retryAfter != "" {
+						w.Header().Set("Retry-After", tt.retryAfter)
+					}
+					w.WriteHeader(tt.status)
+				}
+			}))
+			defer s.Close()
+
+			var attempts []RetryAttempt
+			tt.options.OnRetry = func(a RetryAttempt) {
+				attempts = append(attempts, a)
+			}
+			cli := NewClient(tt.options)
+			defer cli.Close()
+
+			rsp, err := cli.Get(s.URL)
+			if err != nil {
+				t.Fatalf("Failed to do request: %v", err)
+			}
+			rsp.Body.Close()
+
+			if rsp.StatusCode != tt.wantStatus {
+				t.Errorf("Failed to get status: got %d, want %d", rsp.StatusCode, tt.wantStatus)
+			}
+			if got := atomic.LoadInt32(&requests); got != tt.wantRequests {
+				t.Errorf("Failed to get number of requests: got %d, want %d", got, tt.wantRequests)
+			}
+			if len(attempts) != int(tt.wantRequests)-1 {
+				t.Fatalf("Failed to call OnRetry: %d", len(attempts))
+			}
+			if len(attempts) == 1 {
+				a := attempts[0]
+				if a.Attempt != 1 || a.Response.StatusCode != tt.status || a.Wait != tt.wantWait {
+					t.Errorf("Failed to get retry attempt: %+v", a)
+				}
+			}
+		})
+	}
+}
+
+func TestClientRetryBudget(t *testing.T) {
+	var requests int32
+	s := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		atomic.AddInt32(&requests, 1)
+		w.WriteHeader(http.StatusServiceUnavailable)
+	}))
+	defer s.Close()
+
+	cli := NewClient(Options{
+		MaxRetries:      100,
+		RetryBackoff:    20 * time.Millisecond,
+		RetryMaxBackoff: 20 * time.Millisecond,
+		RetryBudget:     100 * time.Millisecond,
+	})
+	defer cli.Close()
+
+	start := time.Now()
+	rsp, err := cli.Get(s.URL)
+	if err != nil {
+		t.Fatalf("Failed to do request: %v", err)
+	}
+	rsp.Body.Close()
+
+	if d := time.Since(start); d > 500*time.Millisecond {
+		t.Errorf("Failed to stop retrying within the budget: %v", d)
+	}
+	if got := atomic.LoadInt32(&requests); got < 2 || got > 20 {
+		t.Errorf("Failed to retry within the budget: %d requests", got)
+	}
+}
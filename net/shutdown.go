@@ -0,0 +1,60 @@
+package net
+
+import (
+	"context"
+	"errors"
+	"io"
+	"sync"
+)
+
+// ErrClientShutdown is returned by the Client for requests, that are
+// sent after Shutdown was called.
+var ErrClientShutdown = errors.New("client is shut down")
+
+// Shutdown gracefully shuts down the Client. It is designed to be
+// called from a signal handler, e.g. on SIGTERM. Shutdown first
+// rejects all new requests with ErrClientShutdown, then waits for the
+// in-flight requests to finish and finally tears down the
+// Transport. A request is in-flight until its response body is
+// closed. If ctx expires before all in-flight requests are drained,
+// the Transport is torn down anyway and the context error is
+// returned.
+//
+// In contrast to Shutdown, Close only stops the background goroutine
+// of the Transport and does neither reject new requests nor wait for
+// in-flight ones.
+func (c *Client) Shutdown(ctx context.Context) error {
+	c.mu.Lock()
+	c.shutdown = true
+	c.mu.Unlock()
+
+	drained := make(chan struct{})
+	go func() {
+		c.inflight.Wait()
+		close(drained)
+	}()
+
+	var err error
+	select {
+	case <-drained:
+	case <-ctx.Done():
+		err = ctx.Err()
+	}
+
+	c.CloseIdleConnections()
+	c.Close()
+	return err
+}
+
+// inflightBody marks the request as done on the first Close.
+type inflightBody struct {
+	io.ReadCloser
+	once sync.Once
+	done func()
+}
+
+func (b *inflightBody) Close() error {
+	err := b.ReadCloser.Close()
+	b.once.Do(b.done)
+	return err
+}
@@ -0,0 +1,68 @@
+package net
+
+import (
+	"context"
+	"net/http"
+	"testing"
+	"time"
+)
+
+func TestClientShutdown(t *testing.T) {
+	s := startTestServer(func(*http.Request) {})
+	defer s.Close()
+
+	cli := NewClient(Options{})
+
+	rsp, err := cli.Get(s.URL)
+	if err != nil {
+		t.Fatalf("Failed to do request: %v", err)
+	}
+
+	done := make(chan error)
+	go func() {
+		done <- cli.Shutdown(context.Background())
+	}()
+
+	select {
+	case <-done:
+		t.Fatal("Failed to wait for the in-flight request")
+	case <-time.After(50 * time.Millisecond):
+	}
+
+	// new requests are rejected while draining
+	if _, err := cli.Get(s.URL); err == nil {
+		t.Error("Failed to reject a request after shutdown")
+	}
+
+	rsp.Body.Close()
+	select {
+	case err := <-done:
+		if err != nil {
+			t.Errorf("Failed to shutdown: %v", err)
+		}
+	case <-time.After(time.Second):
+		t.Fatal("Failed to shutdown after the in-flight request finished")
+	}
+
+	// Close after Shutdown is safe
+	cli.Close()
+}
+
+func TestClientShutdownTimeout(t *testing.T) {
+	s := startTestServer(func(*http.Request) {})
+	defer s.Close()
+
+	cli := NewClient(Options{})
+
+	rsp, err := cli.Get(s.URL)
+	if err != nil {
+		t.Fatalf("Failed to do request: %v", err)
+	}
+	defer rsp.Body.Close()
+
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Millisecond)
+	defer cancel()
+	if err := cli.Shutdown(ctx); err != context.DeadlineExceeded {
+		t.Errorf("Failed to get deadline exceeded: %v", err)
+	}
+}
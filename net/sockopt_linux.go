@@ -0,0 +1,26 @@
+// +build linux
+
+package net
+
+import (
+	"syscall"
+	"time"
+)
+
+// TCP_USER_TIMEOUT is not defined by the syscall package for all
+// architectures, but has the same value for all of them, see
+// include/uapi/linux/tcp.h
+const tcpUserTimeout = 0x12
+
+func setTCPUserTimeout(d time.Duration) func(network, address string, c syscall.RawConn) error {
+	return func(network, address string, c syscall.RawConn) error {
+		var serr error
+		err := c.Control(func(fd uintptr) {
+			serr = syscall.SetsockoptInt(int(fd), syscall.IPPROTO_TCP, tcpUserTimeout, int(d/time.Millisecond))
+		})
+		if err != nil {
+			return err
+		}
+		return serr
+	}
+}
@@ -0,0 +1,58 @@
+// +build linux
+
+package net
+
+import (
+	"net"
+	"net/http"
+	"syscall"
+	"testing"
+	"time"
+)
+
+func TestSetTCPUserTimeout(t *testing.T) {
+	l, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatalf("Failed to listen: %v", err)
+	}
+	defer l.Close()
+
+	d := &net.Dialer{Control: setTCPUserTimeout(1500 * time.Millisecond)}
+	conn, err := d.Dial("tcp", l.Addr().String())
+	if err != nil {
+		t.Fatalf("Failed to dial: %v", err)
+	}
+	defer conn.Close()
+
+	rc, err := conn.(*net.TCPConn).SyscallConn()
+	if err != nil {
+		t.Fatalf("Failed to get raw conn: %v", err)
+	}
+
+	var got int
+	var gerr error
+	rc.Control(func(fd uintptr) {
+		got, gerr = syscall.GetsockoptInt(int(fd), syscall.IPPROTO_TCP, tcpUserTimeout)
+	})
+	if gerr != nil {
+		t.Fatalf("Failed to get socket option: %v", gerr)
+	}
+	if got != 1500 {
+		t.Errorf("Failed to set TCP_USER_TIMEOUT: got %d, want %d", got, 1500)
+	}
+}
+
+func TestTransportTCPUserTimeout(t *testing.T) {
+	s := startTestServer(func(*http.Request) {})
+	defer s.Close()
+
+	rt := NewTransport(Options{TCPUserTimeout: time.Second})
+	defer rt.Close()
+
+	req, _ := http.NewRequest("GET", s.URL, nil)
+	rsp, err := rt.RoundTrip(req)
+	if err != nil {
+		t.Fatalf("Failed to do request: %v", err)
+	}
+	rsp.Body.Close()
+}
@@ -0,0 +1,13 @@
+// +build !linux
+
+package net
+
+import (
+	"syscall"
+	"time"
+)
+
+// TCP_USER_TIMEOUT is only supported on Linux.
+func setTCPUserTimeout(time.Duration) func(network, address string, c syscall.RawConn) error {
+	return nil
+}
@@ -0,0 +1,108 @@
+package net
+
+import (
+	"bytes"
+	"encoding/base64"
+	"encoding/json"
+	"net/url"
+	"time"
+
+	"github.com/zalando/skipper/secrets"
+)
+
+// ClientStats is a snapshot of the state of a Client, see
+// Client.Stats.
+type ClientStats struct {
+	// BearerTokenExpiry is the expiry of the bearer token, that was
+	// injected last by the Client. It is zero, if the expiry is
+	// unknown or no token was injected, yet.
+	BearerTokenExpiry time.Time
+	// BearerTokenRemaining is the remaining validity of the bearer
+	// token, that was injected last. It is negative, if the token is
+	// expired and zero if the expiry is unknown.
+	BearerTokenRemaining time.Duration
+	// QueueDepths are the number of queued requests by priority,
+	// see Options.MaxConcurrentRequests.
+	QueueDepths map[int]int
+	// Transport are the connection pool statistics of the Client
+	// Transport.
+	Transport TransportStats
+}
+
+// Stats returns a snapshot of the Client state, e.g. to export it as
+// metrics and alert before bearer token refresh failures cause
+// authentication errors.
+func (c *Client) Stats() ClientStats {
+	c.mu.Lock()
+	expiry := c.tokenExpiry
+	c.mu.Unlock()
+
+	s := ClientStats{
+		QueueDepths: c.limiter.queueDepths(),
+		Transport:   c.tr.Stats(),
+	}
+	if !expiry.IsZero() {
+		s.BearerTokenExpiry = expiry
+		s.BearerTokenRemaining = time.Until(expiry)
+	}
+	return s
+}
+
+// TokenExpiry returns the remaining validity of the bearer token the
+// Client would inject for the given URL and if it is known. The expiry
+// is known, if the SecretsReader implements secrets.ExpiryReader or
+// if the token is a JWT with an exp claim.
+func (c *Client) TokenExpiry(rawURL string) (time.Duration, bool) {
+	u, err := url.Parse(rawURL)
+	if err != nil {
+		return 0, false
+	}
+
+	sr := c.secretsReader(u)
+	if sr == nil {
+		return 0, false
+	}
+
+	b, ok := sr.GetSecret(rawURL)
+	if !ok {
+		return 0, false
+	}
+
+	expiry, ok := secretExpiry(sr, rawURL, b)
+	if !ok {
+		return 0, false
+	}
+	return time.Until(expiry), true
+}
+
+func secretExpiry(sr secrets.SecretsReader, name string, secret []byte) (time.Time, bool) {
+	if er, ok := sr.(secrets.ExpiryReader); ok {
+		if expiry, ok := er.GetSecretExpiry(name); ok {
+			return expiry, true
+		}
+	}
+	return jwtExpiry(secret)
+}
+
+// jwtExpiry returns the exp claim of a JWT without verifying the
+// signature.
+func jwtExpiry(token []byte) (time.Time, bool) {
+	parts := bytes.Split(token, []byte("."))
+	if len(parts) != 3 {
+		return time.Time{}, false
+	}
+
+	payload, err := base64.RawURLEncoding.DecodeString(string(parts[1]))
+	if err != nil {
+		return time.Time{}, false
+	}
+
+	var claims struct {
+		Exp float64 `json:"exp"`
+	}
+	if err := json.Unmarshal(payload, &claims); err != nil || claims.Exp <= 0 {
+		return time.Time{}, false
+	}
+
+	return time.Unix(int64(claims.Exp), 0), true
+}
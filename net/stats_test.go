@@ -0,0 +1,114 @@
+package net
+
+import (
+	"encoding/base64"
+	"fmt"
+	"net/http"
+	"testing"
+	"time"
+)
+
+func testJWT(exp int64) string {
+	header := base64.RawURLEncoding.EncodeToString([]byte(`{"alg":"none"}`))
+	payload := base64.RawURLEncoding.EncodeToString([]byte(fmt.Sprintf(`{"sub":"foo","exp":%d}`, exp)))
+	return header + "." + payload + ".sig"
+}
+
+type testExpirySecretsReader struct {
+	token  string
+	expiry time.Time
+}
+
+func (sr testExpirySecretsReader) GetSecret(string) ([]byte, bool) {
+	return []byte(sr.token), true
+}
+
+func (sr testExpirySecretsReader) GetSecretExpiry(string) (time.Time, bool) {
+	return sr.expiry, true
+}
+
+func TestJWTExpiry(t *testing.T) {
+	exp := time.Now().Add(time.Hour).Unix()
+	for _, tt := range []struct {
+		name   string
+		token  string
+		want   time.Time
+		wantOK bool
+	}{{
+		name:   "jwt with exp",
+		token:  testJWT(exp),
+		want:   time.Unix(exp, 0),
+		wantOK: true,
+	}, {
+		name:  "opaque token",
+		token: "foo",
+	}, {
+		name:  "invalid payload",
+		token: "a.b.c",
+	}} {
+		t.Run(tt.name, func(t *testing.T) {
+			got, ok := jwtExpiry([]byte(tt.token))
+			if ok != tt.wantOK || !got.Equal(tt.want) {
+				t.Errorf("Failed to get expiry: got %v %v, want %v %v", got, ok, tt.want, tt.wantOK)
+			}
+		})
+	}
+}
+
+func TestClientTokenExpiry(t *testing.T) {
+	s := startTestServer(func(*http.Request) {})
+	defer s.Close()
+
+	for _, tt := range []struct {
+		name   string
+		sr     testSecretsReader
+		esr    *testExpirySecretsReader
+		want   time.Duration
+		wantOK bool
+	}{{
+		name:   "jwt",
+		sr:     testSecretsReader{s.URL: testJWT(time.Now().Add(time.Hour).Unix())},
+		want:   time.Hour,
+		wantOK: true,
+	}, {
+		name:   "expiry reader",
+		esr:    &testExpirySecretsReader{token: "foo", expiry: time.Now().Add(2 * time.Hour)},
+		want:   2 * time.Hour,
+		wantOK: true,
+	}, {
+		name: "unknown expiry",
+		sr:   testSecretsReader{s.URL: "foo"},
+	}} {
+		t.Run(tt.name, func(t *testing.T) {
+			o := Options{SecretsReader: tt.sr}
+			if tt.esr != nil {
+				o.SecretsReader = tt.esr
+			}
+			cli := NewClient(o)
+			defer cli.Close()
+
+			if st := cli.Stats(); !st.BearerTokenExpiry.IsZero() {
+				t.Errorf("Failed to have no expiry before the first request: %v", st)
+			}
+
+			got, ok := cli.TokenExpiry(s.URL)
+			if ok != tt.wantOK || got > tt.want || got < tt.want-time.Minute {
+				t.Errorf("Failed to get token expiry: got %v %v, want %v %v", got, ok, tt.want, tt.wantOK)
+			}
+
+			rsp, err := cli.Get(s.URL)
+			if err != nil {
+				t.Fatalf("Failed to do request: %v", err)
+			}
+			rsp.Body.Close()
+
+			st := cli.Stats()
+			if st.BearerTokenExpiry.IsZero() == tt.wantOK {
+				t.Errorf("Failed to get expiry in stats: %v", st)
+			}
+			if st.BearerTokenRemaining > tt.want || st.BearerTokenRemaining < tt.want-time.Minute {
+				t.Errorf("Failed to get remaining validity in stats: got %v, want %v", st.BearerTokenRemaining, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,97 @@
+package net
+
+import (
+	"context"
+	"errors"
+	"net"
+	"net/http"
+	"net/url"
+	"strings"
+)
+
+// TimeoutKind is the phase of a request, that timed out.
+type TimeoutKind string
+
+const (
+	// TimeoutConnect is a timeout establishing the connection.
+	TimeoutConnect TimeoutKind = "connect"
+
+	// TimeoutTLSHandshake is a timeout of the TLS handshake.
+	TimeoutTLSHandshake TimeoutKind = "tls_handshake"
+
+	// TimeoutResponseHeader is a timeout waiting for the response
+	// headers, see Options.ResponseHeaderTimeout.
+	TimeoutResponseHeader TimeoutKind = "response_header"
+
+	// TimeoutTotal is the deadline of the request context, e.g. set
+	// by Options.RequestTimeout or RequestOptions.Timeout.
+	TimeoutTotal TimeoutKind = "total"
+)
+
+// TimeoutError is returned by the Client for requests, that timed
+// out, wrapped in a *url.Error. It wraps the original error.
+type TimeoutError struct {
+	Kind TimeoutKind
+	Err  error
+}
+
+func (e *TimeoutError) Error() string {
+	return string(e.Kind) + " timeout: " + e.Err.Error()
+}
+
+// Unwrap returns the original error.
+func (e *TimeoutError) Unwrap() error { return e.Err }
+
+// Timeout returns true, it implements net.Error.
+func (e *TimeoutError) Timeout() bool { return true }
+
+// Temporary returns true, it implements net.Error.
+func (e *TimeoutError) Temporary() bool { return true }
+
+// withRequestTimeout sets the Options.RequestTimeout, if the request
+// context has no deadline.
+func (c *Client) withRequestTimeout(req *http.Request) (*http.Request, context.CancelFunc) {
+	if c.requestTimeout <= 0 {
+		return req, func() {}
+	}
+	if _, ok := req.Context().Deadline(); ok {
+		return req, func() {}
+	}
+
+	ctx, cancel := context.WithTimeout(req.Context(), c.requestTimeout)
+	return req.WithContext(ctx), cancel
+}
+
+// wrapTimeoutError wraps timeouts into a TimeoutError.
+func wrapTimeoutError(err error) error {
+	if ClassifyError(err) != ErrorClassTimeout {
+		return err
+	}
+
+	var terr *TimeoutError
+	if errors.As(err, &terr) {
+		return err
+	}
+
+	if uerr, ok := err.(*url.Error); ok {
+		return &url.Error{Op: uerr.Op, URL: uerr.URL, Err: newTimeoutError(uerr.Err)}
+	}
+	return newTimeoutError(err)
+}
+
+func newTimeoutError(err error) *TimeoutError {
+	// net/http does not export these errors
+	s := err.Error()
+	switch {
+	case strings.Contains(s, "timeout awaiting response headers"):
+		return &TimeoutError{Kind: TimeoutResponseHeader, Err: err}
+	case strings.Contains(s, "TLS handshake timeout"):
+		return &TimeoutError{Kind: TimeoutTLSHandshake, Err: err}
+	}
+
+	var opErr *net.OpError
+	if errors.As(err, &opErr) && opErr.Op == "dial" {
+		return &TimeoutError{Kind: TimeoutConnect, Err: err}
+	}
+	return &TimeoutError{Kind: TimeoutTotal, Err: err}
+}
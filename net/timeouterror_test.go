@@ -0,0 +1,108 @@
+package net
+
+import (
+	"context"
+	"errors"
+	"io/ioutil"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"testing"
+	"time"
+)
+
+func TestTimeoutError(t *testing.T) {
+	s := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/slow-header":
+			time.Sleep(100 * time.Millisecond)
+		case "/slow-body":
+			w.WriteHeader(http.StatusOK)
+			w.(http.Flusher).Flush()
+			time.Sleep(100 * time.Millisecond)
+		}
+	}))
+	defer s.Close()
+
+	blockingDial := func(ctx context.Context, network, address string) (net.Conn, error) {
+		<-ctx.Done()
+		return nil, &net.OpError{Op: "dial", Net: network, Err: ctx.Err()}
+	}
+
+	for _, tt := range []struct {
+		name     string
+		options  Options
+		path     string
+		ctx      time.Duration
+		wantKind TimeoutKind
+		bodyErr  bool
+	}{{
+		name:    "no timeout",
+		options: Options{RequestTimeout: time.Second},
+		path:    "/slow-header",
+	}, {
+		name:     "request timeout",
+		options:  Options{RequestTimeout: 10 * time.Millisecond},
+		path:     "/slow-header",
+		wantKind: TimeoutTotal,
+	}, {
+		name:    "request context deadline has precedence",
+		options: Options{RequestTimeout: 10 * time.Millisecond},
+		path:    "/slow-header",
+		ctx:     time.Second,
+	}, {
+		name:    "request timeout while reading the body",
+		options: Options{RequestTimeout: 50 * time.Millisecond},
+		path:    "/slow-body",
+		bodyErr: true,
+	}, {
+		name:     "response header timeout",
+		options:  Options{ResponseHeaderTimeout: 10 * time.Millisecond},
+		path:     "/slow-header",
+		wantKind: TimeoutResponseHeader,
+	}, {
+		name: "connect timeout",
+		options: Options{
+			DialContext:     blockingDial,
+			PerHostTimeouts: map[string]Timeouts{"127.0.0.1": {Dial: 10 * time.Millisecond}},
+		},
+		path:     "/",
+		wantKind: TimeoutConnect,
+	}} {
+		t.Run(tt.name, func(t *testing.T) {
+			cli := NewClient(tt.options)
+			defer cli.Close()
+
+			req, _ := http.NewRequest("GET", s.URL+tt.path, nil)
+			if tt.ctx > 0 {
+				ctx, cancel := context.WithTimeout(context.Background(), tt.ctx)
+				defer cancel()
+				req = req.WithContext(ctx)
+			}
+
+			rsp, err := cli.Do(req)
+			if tt.wantKind == "" {
+				if err != nil {
+					t.Fatalf("Failed to do request: %v", err)
+				}
+				defer rsp.Body.Close()
+				if _, err := ioutil.ReadAll(rsp.Body); (err != nil) != tt.bodyErr {
+					t.Errorf("Failed to read body, error = %v, bodyErr %v", err, tt.bodyErr)
+				}
+				return
+			}
+
+			var terr *TimeoutError
+			if _, ok := err.(*url.Error); !ok || !errors.As(err, &terr) {
+				t.Fatalf("Failed to get a timeout error: %v", err)
+			}
+			if terr.Kind != tt.wantKind {
+				t.Errorf("Failed to get timeout kind: got %s, want %s", terr.Kind, tt.wantKind)
+			}
+			if ClassifyError(err) != ErrorClassTimeout {
+				t.Errorf("Failed to classify timeout: %v", err)
+			}
+		})
+	}
+}
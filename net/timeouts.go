@@ -0,0 +1,152 @@
+package net
+
+import (
+	"crypto/tls"
+	"net/http"
+	"net/url"
+	"time"
+
+	"github.com/zalando/skipper/secrets"
+)
+
+// Timeouts override the timeouts of the Transport for a host, see
+// Options.PerHostTimeouts. A zero value uses the global value of
+// Options.
+type Timeouts struct {
+	// Dial is the timeout to establish the TCP connection. There is
+	// no global dial timeout.
+	Dial time.Duration
+	// TLSHandshake overrides Options.TLSHandshakeTimeout.
+	TLSHandshake time.Duration
+	// ResponseHeader overrides Options.ResponseHeaderTimeout.
+	ResponseHeader time.Duration
+	// Total is the timeout of the whole request including reading
+	// the response body. RequestOptions.Timeout has precedence.
+	Total time.Duration
+}
+
+// HostOptions override the Options for a host, see
+// Options.PerHostOptions. A zero value uses the global value of
+// Options.
+type HostOptions struct {
+	// Timeouts override the timeouts of the Transport.
+	Timeouts Timeouts
+	// MaxConnsPerHost overrides Options.MaxConnsPerHost.
+	MaxConnsPerHost int
+	// MaxIdleConnsPerHost overrides Options.MaxIdleConnsPerHost.
+	MaxIdleConnsPerHost int
+	// TLSClientConfig overrides Options.TLSClientConfig.
+	TLSClientConfig *tls.Config
+	// UnixSocketPath overrides Options.UnixSocketPath, the
+	// connections to the host are dialed to the unix domain socket
+	// at the given path.
+	UnixSocketPath string
+	// SecretsReader overrides Options.SecretsReader to get the
+	// bearer token, that the Client injects. It is not used, if the
+	// Client has a TokenSource or ClientCredentials.
+	SecretsReader secrets.SecretsReader
+}
+
+type hostTransport struct {
+	tr    *http.Transport
+	total time.Duration
+	sr    secrets.SecretsReader
+}
+
+// perHostOptions merges Options.PerHostTimeouts into
+// Options.PerHostOptions.
+func perHostOptions(o Options) map[string]HostOptions {
+	if len(o.PerHostTimeouts) == 0 {
+		return o.PerHostOptions
+	}
+
+	hos := make(map[string]HostOptions, len(o.PerHostTimeouts)+len(o.PerHostOptions))
+	for host, to := range o.PerHostTimeouts {
+		hos[host] = HostOptions{Timeouts: to}
+	}
+	for host, ho := range o.PerHostOptions {
+		hos[host] = ho
+	}
+	return hos
+}
+
+// newHostTransports creates a clone of the http.Transport for every
+// host with overridden options. The clones have their own connection
+// pools.
+func newHostTransports(base *http.Transport, o Options, dial func(time.Duration, string) dialFunc) map[string]hostTransport {
+	hos := perHostOptions(o)
+	if len(hos) == 0 {
+		return nil
+	}
+
+	hts := make(map[string]hostTransport, len(hos))
+	for host, ho := range hos {
+		to := ho.Timeouts
+		tr := base.Clone()
+		if to.Dial > 0 || ho.UnixSocketPath != "" {
+			socket := ho.UnixSocketPath
+			if socket == "" {
+				socket = o.UnixSocketPath
+			}
+			tr.DialContext = dial(to.Dial, socket)
+		}
+		if to.TLSHandshake > 0 {
+			tr.TLSHandshakeTimeout = to.TLSHandshake
+		}
+		if to.ResponseHeader > 0 {
+			tr.ResponseHeaderTimeout = to.ResponseHeader
+		}
+		if ho.MaxConnsPerHost > 0 {
+			tr.MaxConnsPerHost = ho.MaxConnsPerHost
+		}
+		if ho.MaxIdleConnsPerHost > 0 {
+			tr.MaxIdleConnsPerHost = ho.MaxIdleConnsPerHost
+		}
+		if ho.TLSClientConfig != nil {
+			tr.TLSClientConfig = ho.TLSClientConfig.Clone()
+		}
+		hts[host] = hostTransport{tr: tr, total: to.Total, sr: ho.SecretsReader}
+	}
+	return hts
+}
+
+// hostTransport returns the transport overriding the options for the
+// request host. The host is matched with port first and then without.
+func (t *Transport) hostTransport(req *http.Request) (hostTransport, bool) {
+	return t.hostTransportFor(req.URL)
+}
+
+func (t *Transport) hostTransportFor(u *url.URL) (hostTransport, bool) {
+	if len(t.hostTransports) == 0 {
+		return hostTransport{}, false
+	}
+
+	if ht, ok := t.hostTransports[u.Host]; ok {
+		return ht, true
+	}
+	ht, ok := t.hostTransports[u.Hostname()]
+	return ht, ok
+}
+
+// transport returns the http.Transport to send the request with.
+func (t *Transport) transport(req *http.Request) *http.Transport {
+	if ht, ok := t.hostTransport(req); ok {
+		return ht.tr
+	}
+	return t.tr
+}
+
+func (t *Transport) closeIdleConnections() {
+	t.tr.CloseIdleConnections()
+	for _, ht := range t.hostTransports {
+		ht.tr.CloseIdleConnections()
+	}
+}
+
+// secretsReader returns the SecretsReader for the host of the URL.
+func (c *Client) secretsReader(u *url.URL) secrets.SecretsReader {
+	if ht, ok := c.tr.hostTransportFor(u); ok && ht.sr != nil {
+		return ht.sr
+	}
+	return c.sr
+}
@@ -0,0 +1,136 @@
+package net
+
+import (
+	"context"
+	"crypto/tls"
+	"crypto/x509"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"testing"
+	"time"
+)
+
+func TestPerHostTimeouts(t *testing.T) {
+	handler := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/slow-header":
+			time.Sleep(100 * time.Millisecond)
+		case "/slow-body":
+			w.WriteHeader(http.StatusOK)
+			w.(http.Flusher).Flush()
+			time.Sleep(100 * time.Millisecond)
+		}
+		w.Write([]byte("OK"))
+	})
+
+	slow := httptest.NewServer(handler)
+	defer slow.Close()
+	other := httptest.NewServer(handler)
+	defer other.Close()
+
+	rt := NewTransport(Options{
+		PerHostTimeouts: map[string]Timeouts{
+			slow.Listener.Addr().String(): {ResponseHeader: 10 * time.Millisecond, Total: 50 * time.Millisecond},
+			"127.0.0.1":                   {Dial: time.Second},
+		},
+	})
+	defer rt.Close()
+
+	for _, tt := range []struct {
+		name        string
+		url         string
+		options     *RequestOptions
+		wantErr     bool
+		wantBodyErr bool
+	}{{
+		name:    "response header timeout of the host",
+		url:     slow.URL + "/slow-header",
+		wantErr: true,
+	}, {
+		name:        "total timeout of the host",
+		url:         slow.URL + "/slow-body",
+		wantBodyErr: true,
+	}, {
+		name:    "request options have precedence",
+		url:     slow.URL + "/slow-body",
+		options: &RequestOptions{Timeout: time.Second},
+	}, {
+		name: "host without overridden response timeouts",
+		url:  other.URL + "/slow-header",
+	}} {
+		t.Run(tt.name, func(t *testing.T) {
+			req, _ := http.NewRequest("GET", tt.url, nil)
+			if tt.options != nil {
+				req = req.WithContext(WithRequestOptions(context.Background(), *tt.options))
+			}
+
+			rsp, err := rt.RoundTrip(req)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("Failed to do request, error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if err != nil {
+				return
+			}
+			defer rsp.Body.Close()
+
+			_, err = ioutil.ReadAll(rsp.Body)
+			if (err != nil) != tt.wantBodyErr {
+				t.Errorf("Failed to read body, error = %v, wantBodyErr %v", err, tt.wantBodyErr)
+			}
+		})
+	}
+}
+
+func TestPerHostOptions(t *testing.T) {
+	var auth []string
+	handler := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		auth = append(auth, r.Header.Get("Authorization"))
+	})
+
+	secure := httptest.NewTLSServer(handler)
+	defer secure.Close()
+	plain := httptest.NewServer(handler)
+	defer plain.Close()
+
+	pool := x509.NewCertPool()
+	pool.AddCert(secure.Certificate())
+
+	secureHost := secure.Listener.Addr().String()
+	cli := NewClient(Options{
+		SecretsReader: testSecretsReader{secure.URL: "global-token", plain.URL: "global-token"},
+		PerHostTimeouts: map[string]Timeouts{
+			secureHost: {Total: time.Nanosecond},
+		},
+		PerHostOptions: map[string]HostOptions{
+			secureHost: {
+				MaxConnsPerHost: 1,
+				TLSClientConfig: &tls.Config{RootCAs: pool},
+				SecretsReader:   testSecretsReader{secure.URL: "host-token"},
+			},
+		},
+	})
+	defer cli.Close()
+
+	for _, u := range []string{secure.URL, plain.URL} {
+		rsp, err := cli.Get(u)
+		if err != nil {
+			t.Fatalf("Failed to do request to %s: %v", u, err)
+		}
+		rsp.Body.Close()
+	}
+
+	if want := []string{"Bearer host-token", "Bearer global-token"}; fmt.Sprint(auth) != fmt.Sprint(want) {
+		t.Errorf("Failed to inject tokens: got %v, want %v", auth, want)
+	}
+
+	ht, ok := cli.tr.hostTransportFor(&url.URL{Host: secureHost})
+	if !ok || ht.tr.MaxConnsPerHost != 1 || ht.total != 0 {
+		t.Error("Failed to override the host options")
+	}
+	if _, ok := cli.TokenExpiry(secure.URL); ok {
+		t.Error("Failed to not know the expiry of an opaque token")
+	}
+}
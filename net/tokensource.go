@@ -0,0 +1,35 @@
+package net
+
+import (
+	"context"
+	"net/http"
+
+	"golang.org/x/oauth2"
+)
+
+func newTokenSource(o Options) oauth2.TokenSource {
+	switch {
+	case o.TokenSource != nil:
+		return oauth2.ReuseTokenSource(nil, o.TokenSource)
+	case o.ClientCredentials != nil:
+		return o.ClientCredentials.TokenSource(context.Background())
+	default:
+		return nil
+	}
+}
+
+// injectToken sets the Authorization header of the request to the
+// current token of the TokenSource, which fetches a new one, if the
+// cached token is expired.
+func (c *Client) injectToken(req *http.Request) error {
+	tok, err := c.ts.Token()
+	if err != nil {
+		return err
+	}
+
+	tok.SetAuthHeader(req)
+	c.mu.Lock()
+	c.tokenExpiry = tok.Expiry
+	c.mu.Unlock()
+	return nil
+}
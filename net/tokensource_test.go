@@ -0,0 +1,132 @@
+package net
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"sync/atomic"
+	"testing"
+
+	"golang.org/x/oauth2"
+	"golang.org/x/oauth2/clientcredentials"
+)
+
+func TestClientCredentials(t *testing.T) {
+	for _, tt := range []struct {
+		name        string
+		expiresIn   int
+		wantFetches int32
+		wantAuth    []string
+	}{{
+		name:        "token is cached",
+		expiresIn:   3600,
+		wantFetches: 1,
+		wantAuth:    []string{"Bearer token-1", "Bearer token-1"},
+	}, {
+		name:        "expired token is refreshed",
+		expiresIn:   1,
+		wantFetches: 2,
+		wantAuth:    []string{"Bearer token-1", "Bearer token-2"},
+	}} {
+		t.Run(tt.name, func(t *testing.T) {
+			var fetches int32
+			tokenServer := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				if user, pass, _ := r.BasicAuth(); user != "client" || pass != "secret" {
+					t.Errorf("Failed to get client credentials: %q %q", user, pass)
+				}
+				r.ParseForm()
+				if r.Form.Get("grant_type") != "client_credentials" || r.Form.Get("scope") != "read write" {
+					t.Errorf("Failed to get grant: %v", r.Form)
+				}
+
+				n := atomic.AddInt32(&fetches, 1)
+				w.Header().Set("Content-Type", "application/json")
+				fmt.Fprintf(w, `{"access_token":"token-%d","token_type":"bearer","expires_in":%d}`, n, tt.expiresIn)
+			}))
+			defer tokenServer.Close()
+
+			var auth []string
+			s := startTestServer(func(r *http.Request) {
+				auth = append(auth, r.Header.Get("Authorization"))
+			})
+			defer s.Close()
+
+			cli := NewClient(Options{
+				ClientCredentials: &clientcredentials.Config{
+					ClientID:     "client",
+					ClientSecret: "secret",
+					TokenURL:     tokenServer.URL,
+					Scopes:       []string{"read", "write"},
+				},
+			})
+			defer cli.Close()
+
+			for i := 0; i < 2; i++ {
+				rsp, err := cli.Get(s.URL)
+				if err != nil {
+					t.Fatalf("Failed to do request: %v", err)
+				}
+				rsp.Body.Close()
+			}
+
+			if got := atomic.LoadInt32(&fetches); got != tt.wantFetches {
+				t.Errorf("Failed to get number of token fetches: got %d, want %d", got, tt.wantFetches)
+			}
+			if fmt.Sprint(auth) != fmt.Sprint(tt.wantAuth) {
+				t.Errorf("Failed to inject tokens: got %v, want %v", auth, tt.wantAuth)
+			}
+			if cli.Stats().BearerTokenExpiry.IsZero() {
+				t.Error("Failed to get token expiry")
+			}
+		})
+	}
+}
+
+type errorTokenSource struct{}
+
+func (errorTokenSource) Token() (*oauth2.Token, error) {
+	return nil, errors.New("no token")
+}
+
+func TestTokenSourceError(t *testing.T) {
+	var requests int32
+	s := startTestServer(func(*http.Request) {
+		atomic.AddInt32(&requests, 1)
+	})
+	defer s.Close()
+
+	cli := NewClient(Options{TokenSource: errorTokenSource{}})
+	defer cli.Close()
+
+	if _, err := cli.Get(s.URL); err == nil {
+		t.Error("Failed to get an error")
+	}
+	if requests != 0 {
+		t.Error("Failed to not send the request without token")
+	}
+}
+
+func TestTokenSourcePrecedence(t *testing.T) {
+	var auth string
+	s := startTestServer(func(r *http.Request) {
+		auth = r.Header.Get("Authorization")
+	})
+	defer s.Close()
+
+	cli := NewClient(Options{
+		TokenSource:   oauth2.StaticTokenSource(&oauth2.Token{AccessToken: "foo"}),
+		SecretsReader: testSecretsReader{s.URL: "bar"},
+	})
+	defer cli.Close()
+
+	rsp, err := cli.Get(s.URL)
+	if err != nil {
+		t.Fatalf("Failed to do request: %v", err)
+	}
+	rsp.Body.Close()
+
+	if auth != "Bearer foo" {
+		t.Errorf("Failed to inject the token: %q", auth)
+	}
+}
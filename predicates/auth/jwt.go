@@ -13,6 +13,9 @@ Examples:
     // all key value pairs have to match
     example2: * && JWTPayloadAllKV("iss", "https://accounts.google.com", "email", "skipper-router@googlegroups.com")
 	-> "http://example.org/";
+    // a single claim has to match, also when it is a list, like the audience
+    example3: JWTClaim("aud", "my-api")
+	-> "http://example.org/";
 */
 package auth
 
@@ -151,27 +154,38 @@ func (m regexMatcher) Match(jwtValue string) bool {
 	return m.regexp.MatchString(jwtValue)
 }
 
-func (p *predicate) Match(r *http.Request) bool {
+// jwtPayload returns the decoded, unverified payload of the Bearer JWT
+// token of the request
+func jwtPayload(r *http.Request) (map[string]interface{}, bool) {
 	ahead := r.Header.Get(authHeaderName)
 	if !strings.HasPrefix(ahead, authHeaderPrefix) {
-		return false
+		return nil, false
 	}
 
 	fields := strings.FieldsFunc(ahead, func(r rune) bool {
 		return r == []rune(".")[0]
 	})
 	if len(fields) != 3 {
-		return false
+		return nil, false
 	}
 
 	sDec, err := base64.RawURLEncoding.DecodeString(fields[1])
 	if err != nil {
-		return false
+		return nil, false
 	}
 
 	var payload map[string]interface{}
 	err = json.Unmarshal(sDec, &payload)
 	if err != nil {
+		return nil, false
+	}
+
+	return payload, true
+}
+
+func (p *predicate) Match(r *http.Request) bool {
+	payload, ok := jwtPayload(r)
+	if !ok {
 		return false
 	}
 
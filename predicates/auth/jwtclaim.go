@@ -0,0 +1,147 @@
+package auth
+
+import (
+	"net/http"
+	"regexp"
+	"strconv"
+	"strings"
+
+	"github.com/zalando/skipper/predicates"
+	"github.com/zalando/skipper/routing"
+)
+
+const (
+	matchJWTClaimName       = "JWTClaim"
+	matchJWTClaimRegexpName = "JWTClaimRegexp"
+)
+
+type (
+	claimSpec struct {
+		name      string
+		matchMode matchMode
+	}
+	claimPredicate struct {
+		claim   string
+		matcher valueMatcher
+	}
+)
+
+// NewJWTClaim creates a predicate spec for the JWTClaim predicate. It
+// matches, when the claim of the JWT token, passed as the first argument,
+// has the value passed as the second argument. When the claim is a list,
+// like the audience often is, it matches when any of the items has the
+// value. Numbers and booleans are compared by their JSON representation.
+// Nested claims can be referenced with a dot separated path, when the
+// payload doesn't contain a claim with the same name.
+//
+// The token is not validated, the predicate is meant to select a route,
+// that validates the token with a filter.
+func NewJWTClaim() routing.PredicateSpec {
+	return &claimSpec{name: matchJWTClaimName, matchMode: matchModeExact}
+}
+
+// NewJWTClaimRegexp creates a predicate spec for the JWTClaimRegexp
+// predicate. It works the same way as JWTClaim, but the second argument
+// is a regular expression.
+func NewJWTClaimRegexp() routing.PredicateSpec {
+	return &claimSpec{name: matchJWTClaimRegexpName, matchMode: matchModeRegexp}
+}
+
+func (s *claimSpec) Name() string {
+	return s.name
+}
+
+func (s *claimSpec) Create(args []interface{}) (routing.Predicate, error) {
+	if len(args) != 2 {
+		return nil, predicates.ErrInvalidPredicateParameters
+	}
+
+	claim, claimOk := args[0].(string)
+	value, valueOk := args[1].(string)
+	if !claimOk || !valueOk || claim == "" {
+		return nil, predicates.ErrInvalidPredicateParameters
+	}
+
+	p := &claimPredicate{claim: claim}
+	switch s.matchMode {
+	case matchModeRegexp:
+		re, err := regexp.Compile(value)
+		if err != nil {
+			return nil, predicates.ErrInvalidPredicateParameters
+		}
+		p.matcher = regexMatcher{regexp: re}
+	default:
+		p.matcher = exactMatcher{expected: value}
+	}
+
+	return p, nil
+}
+
+// claimValue looks up the claim by its name, or, when the payload has no
+// claim with the name, by a dot separated path of the nested claims
+func claimValue(payload map[string]interface{}, claim string) (interface{}, bool) {
+	if v, ok := payload[claim]; ok {
+		return v, true
+	}
+
+	path := strings.Split(claim, ".")
+	if len(path) == 1 {
+		return nil, false
+	}
+
+	var v interface{} = payload
+	for _, key := range path {
+		m, ok := v.(map[string]interface{})
+		if !ok {
+			return nil, false
+		}
+
+		if v, ok = m[key]; !ok {
+			return nil, false
+		}
+	}
+
+	return v, true
+}
+
+func formatClaimValue(v interface{}) (string, bool) {
+	switch vv := v.(type) {
+	case string:
+		return vv, true
+	case float64:
+		return strconv.FormatFloat(vv, 'f', -1, 64), true
+	case bool:
+		return strconv.FormatBool(vv), true
+	default:
+		return "", false
+	}
+}
+
+func (p *claimPredicate) matchValue(v interface{}) bool {
+	s, ok := formatClaimValue(v)
+	return ok && p.matcher.Match(s)
+}
+
+func (p *claimPredicate) Match(r *http.Request) bool {
+	payload, ok := jwtPayload(r)
+	if !ok {
+		return false
+	}
+
+	v, ok := claimValue(payload, p.claim)
+	if !ok {
+		return false
+	}
+
+	if items, ok := v.([]interface{}); ok {
+		for _, item := range items {
+			if p.matchValue(item) {
+				return true
+			}
+		}
+
+		return false
+	}
+
+	return p.matchValue(v)
+}
@@ -0,0 +1,171 @@
+package auth
+
+import (
+	"encoding/base64"
+	"net/http"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+	"github.com/zalando/skipper/routing"
+)
+
+func testToken(payload string) string {
+	return "eyJhbGciOiJSUzI1NiJ9." + base64.RawURLEncoding.EncodeToString([]byte(payload)) + ".c2lnbmF0dXJl"
+}
+
+func Test_claimSpec(t *testing.T) {
+	require.Equal(t, matchJWTClaimName, NewJWTClaim().Name())
+	require.Equal(t, matchJWTClaimRegexpName, NewJWTClaimRegexp().Name())
+}
+
+func Test_claimSpec_Create(t *testing.T) {
+	for _, tt := range []struct {
+		name    string
+		spec    routing.PredicateSpec
+		args    []interface{}
+		wantErr bool
+	}{{
+		name:    "no args",
+		spec:    NewJWTClaim(),
+		wantErr: true,
+	}, {
+		name:    "too many args",
+		spec:    NewJWTClaim(),
+		args:    []interface{}{"aud", "my-api", "foo"},
+		wantErr: true,
+	}, {
+		name:    "invalid type of args",
+		spec:    NewJWTClaim(),
+		args:    []interface{}{"aud", 3},
+		wantErr: true,
+	}, {
+		name:    "empty claim",
+		spec:    NewJWTClaim(),
+		args:    []interface{}{"", "my-api"},
+		wantErr: true,
+	}, {
+		name:    "invalid regexp",
+		spec:    NewJWTClaimRegexp(),
+		args:    []interface{}{"aud", "("},
+		wantErr: true,
+	}, {
+		name: "exact",
+		spec: NewJWTClaim(),
+		args: []interface{}{"aud", "my-api"},
+	}, {
+		name: "regexp",
+		spec: NewJWTClaimRegexp(),
+		args: []interface{}{"aud", "^my-"},
+	}} {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := tt.spec.Create(tt.args)
+			if (err != nil) != tt.wantErr {
+				t.Errorf("claimSpec.Create() error = %v, wantErr %v", err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func Test_claimPredicate_Match(t *testing.T) {
+	for _, tt := range []struct {
+		name    string
+		spec    routing.PredicateSpec
+		args    []interface{}
+		payload string
+		header  string
+		want    bool
+	}{{
+		name:    "string claim",
+		spec:    NewJWTClaim(),
+		args:    []interface{}{"aud", "my-api"},
+		payload: `{"aud": "my-api"}`,
+		want:    true,
+	}, {
+		name:    "string claim not matching",
+		spec:    NewJWTClaim(),
+		args:    []interface{}{"aud", "my-api"},
+		payload: `{"aud": "other-api"}`,
+	}, {
+		name:    "missing claim",
+		spec:    NewJWTClaim(),
+		args:    []interface{}{"aud", "my-api"},
+		payload: `{"sub": "my-api"}`,
+	}, {
+		name:    "list claim",
+		spec:    NewJWTClaim(),
+		args:    []interface{}{"aud", "my-api"},
+		payload: `{"aud": ["other-api", "my-api"]}`,
+		want:    true,
+	}, {
+		name:    "list claim not matching",
+		spec:    NewJWTClaim(),
+		args:    []interface{}{"aud", "my-api"},
+		payload: `{"aud": ["other-api"]}`,
+	}, {
+		name:    "number claim",
+		spec:    NewJWTClaim(),
+		args:    []interface{}{"tenant", "42"},
+		payload: `{"tenant": 42}`,
+		want:    true,
+	}, {
+		name:    "boolean claim",
+		spec:    NewJWTClaim(),
+		args:    []interface{}{"admin", "true"},
+		payload: `{"admin": true}`,
+		want:    true,
+	}, {
+		name:    "object claim",
+		spec:    NewJWTClaim(),
+		args:    []interface{}{"realm", "users"},
+		payload: `{"realm": {"name": "users"}}`,
+	}, {
+		name:    "nested claim",
+		spec:    NewJWTClaim(),
+		args:    []interface{}{"realm_access.roles", "admin"},
+		payload: `{"realm_access": {"roles": ["user", "admin"]}}`,
+		want:    true,
+	}, {
+		name:    "claim name with dots",
+		spec:    NewJWTClaim(),
+		args:    []interface{}{"https://example.org/tenant", "acme"},
+		payload: `{"https://example.org/tenant": "acme"}`,
+		want:    true,
+	}, {
+		name:    "regexp",
+		spec:    NewJWTClaimRegexp(),
+		args:    []interface{}{"iss", "^https://"},
+		payload: `{"iss": "https://issuer.example.org"}`,
+		want:    true,
+	}, {
+		name:    "regexp on list",
+		spec:    NewJWTClaimRegexp(),
+		args:    []interface{}{"aud", "^tenant-[0-9]+$"},
+		payload: `{"aud": ["my-api", "tenant-7"]}`,
+		want:    true,
+	}, {
+		name:    "invalid payload",
+		spec:    NewJWTClaim(),
+		args:    []interface{}{"aud", "my-api"},
+		payload: `not json`,
+	}, {
+		name:   "not a bearer token",
+		spec:   NewJWTClaim(),
+		args:   []interface{}{"aud", "my-api"},
+		header: "Basic Zm9vOmJhcg==",
+	}} {
+		t.Run(tt.name, func(t *testing.T) {
+			p, err := tt.spec.Create(tt.args)
+			require.NoError(t, err)
+
+			header := tt.header
+			if header == "" {
+				header = authHeaderPrefix + testToken(tt.payload)
+			}
+
+			r := &http.Request{Header: http.Header{authHeaderName: []string{header}}}
+			if got := p.Match(r); got != tt.want {
+				t.Errorf("claimPredicate.Match() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,69 @@
+/*
+Package contentlength implements a predicate to match the routes based on
+the size of the request body, declared in the Content-Length header.
+
+The ContentLengthBetween predicate matches, when the Content-Length of
+the request is known, and it is greater than or equal to the first
+argument, and less than the second argument. The arguments are numbers
+of bytes. The requests of unknown length, e.g. with chunked transfer
+encoding, don't match.
+
+Examples:
+
+	small: ContentLengthBetween(0, 65536) -> "https://api.example.org";
+	large: ContentLengthBetween(65536, 1073741824) -> maxRequestBody("1GB") -> "https://upload.example.org";
+*/
+package contentlength
+
+import (
+	"math"
+	"net/http"
+
+	"github.com/zalando/skipper/predicates"
+	"github.com/zalando/skipper/routing"
+)
+
+const Name = "ContentLengthBetween"
+
+type (
+	spec      struct{}
+	predicate struct {
+		min, max int64
+	}
+)
+
+// New creates the spec of the ContentLengthBetween predicate.
+func New() routing.PredicateSpec { return &spec{} }
+
+func (*spec) Name() string { return Name }
+
+func size(a interface{}) (int64, bool) {
+	f, ok := a.(float64)
+	if !ok || f < 0 || f != math.Trunc(f) || f >= math.MaxInt64 {
+		return 0, false
+	}
+
+	return int64(f), true
+}
+
+func (*spec) Create(args []interface{}) (routing.Predicate, error) {
+	if len(args) != 2 {
+		return nil, predicates.ErrInvalidPredicateParameters
+	}
+
+	min, ok := size(args[0])
+	if !ok {
+		return nil, predicates.ErrInvalidPredicateParameters
+	}
+
+	max, ok := size(args[1])
+	if !ok || min >= max {
+		return nil, predicates.ErrInvalidPredicateParameters
+	}
+
+	return &predicate{min: min, max: max}, nil
+}
+
+func (p *predicate) Match(r *http.Request) bool {
+	return r.ContentLength >= 0 && r.ContentLength >= p.min && r.ContentLength < p.max
+}
@@ -0,0 +1,80 @@
+package contentlength
+
+import (
+	"net/http"
+	"testing"
+)
+
+func TestCreate(t *testing.T) {
+	for _, tt := range []struct {
+		msg  string
+		args []interface{}
+		err  bool
+	}{{
+		msg: "no args",
+		err: true,
+	}, {
+		msg:  "one arg",
+		args: []interface{}{float64(0)},
+		err:  true,
+	}, {
+		msg:  "too many args",
+		args: []interface{}{float64(0), float64(1), float64(2)},
+		err:  true,
+	}, {
+		msg:  "string",
+		args: []interface{}{"0", float64(1)},
+		err:  true,
+	}, {
+		msg:  "negative",
+		args: []interface{}{float64(-1), float64(1)},
+		err:  true,
+	}, {
+		msg:  "fraction",
+		args: []interface{}{float64(0), float64(1.5)},
+		err:  true,
+	}, {
+		msg:  "empty range",
+		args: []interface{}{float64(1), float64(1)},
+		err:  true,
+	}, {
+		msg:  "reversed range",
+		args: []interface{}{float64(2), float64(1)},
+		err:  true,
+	}, {
+		msg:  "valid",
+		args: []interface{}{float64(0), float64(1024)},
+	}} {
+		t.Run(tt.msg, func(t *testing.T) {
+			_, err := New().Create(tt.args)
+			if tt.err && err == nil {
+				t.Error("expected error")
+			} else if !tt.err && err != nil {
+				t.Error(err)
+			}
+		})
+	}
+}
+
+func TestMatch(t *testing.T) {
+	p, err := New().Create([]interface{}{float64(10), float64(100)})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	for _, tt := range []struct {
+		contentLength int64
+		expected      bool
+	}{
+		{-1, false},
+		{0, false},
+		{9, false},
+		{10, true},
+		{99, true},
+		{100, false},
+	} {
+		if m := p.Match(&http.Request{ContentLength: tt.contentLength}); m != tt.expected {
+			t.Errorf("content length %d: expected %t, got %t", tt.contentLength, tt.expected, m)
+		}
+	}
+}
@@ -3,10 +3,23 @@ Package cron implements custom predicates to match routes
 only when they also match the system time matches the given
 cron-like expressions.
 
-Package includes a single predicate: Cron.
+Package includes two predicates: Cron and Schedule.
 
-For supported & unsupported features refer to the "cronmask" package
-documentation (https://github.com/sarslanhan/cronmask).
+Cron matches the cron-like expression. For supported & unsupported
+features refer to the "cronmask" package documentation
+(https://github.com/sarslanhan/cronmask).
+
+Schedule matches a weekly time window, given as the days of the week and
+the time of the day, e.g. "Mon-Fri 09:00-17:00".
+
+Both predicates accept an optional second argument with the name of the
+time zone of the expression, e.g. "Europe/Berlin". Without it, the time
+zone of the system is used.
+
+Examples:
+
+	maintenance: Schedule("Sat 01:00-05:00", "Europe/Berlin") -> inlineContent("maintenance") -> <shunt>;
+	office: Cron("* 7-18 * * 1-5", "Europe/Berlin") -> "https://office.example.org";
 */
 package cron
 
@@ -28,7 +41,7 @@ func (*spec) Name() string {
 }
 
 func (*spec) Create(args []interface{}) (routing.Predicate, error) {
-	if len(args) != 1 {
+	if len(args) == 0 || len(args) > 2 {
 		return nil, predicates.ErrInvalidPredicateParameters
 	}
 
@@ -42,19 +55,41 @@ func (*spec) Create(args []interface{}) (routing.Predicate, error) {
 		return nil, err
 	}
 
+	loc, err := location(args[1:])
+	if err != nil {
+		return nil, err
+	}
+
 	return &predicate{
-		mask:    mask,
-		getTime: time.Now,
+		mask:     mask,
+		location: loc,
+		getTime:  time.Now,
 	}, nil
 }
 
+// location returns the time zone from the optional argument, or the
+// local time zone when it is not set
+func location(args []interface{}) (*time.Location, error) {
+	if len(args) == 0 {
+		return time.Local, nil
+	}
+
+	name, ok := args[0].(string)
+	if !ok {
+		return nil, predicates.ErrInvalidPredicateParameters
+	}
+
+	return time.LoadLocation(name)
+}
+
 type predicate struct {
-	mask    *cronmask.CronMask
-	getTime clock
+	mask     *cronmask.CronMask
+	location *time.Location
+	getTime  clock
 }
 
 func (p *predicate) Match(r *http.Request) bool {
-	now := p.getTime()
+	now := p.getTime().In(p.location)
 
 	return p.mask.Match(now)
 }
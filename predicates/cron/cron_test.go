@@ -36,6 +36,16 @@ func TestCreate(t *testing.T) {
 			[]interface{}{"* * * * *"},
 			false,
 		},
+		{
+			"valid arguments with time zone",
+			[]interface{}{"* * * * *", "Europe/Berlin"},
+			false,
+		},
+		{
+			"time zone with mismatched type",
+			[]interface{}{"* * * * *", 1},
+			true,
+		},
 	}
 
 	for _, tc := range testCases {
@@ -82,3 +92,15 @@ func TestPredicateMatch(t *testing.T) {
 		}
 	}
 }
+
+func TestPredicateMatchTimeZone(t *testing.T) {
+	p, err := New().Create([]interface{}{"* 9 * * *", "Europe/Berlin"})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	p.(*predicate).getTime = func() time.Time { return time.Date(2020, 6, 1, 7, 30, 0, 0, time.UTC) }
+	if !p.Match(nil) {
+		t.Error("failed to match in the time zone")
+	}
+}
@@ -0,0 +1,199 @@
+package cron
+
+import (
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/zalando/skipper/predicates"
+	"github.com/zalando/skipper/routing"
+)
+
+const minutesPerDay = 24 * 60
+
+var weekdays = map[string]time.Weekday{
+	"sun": time.Sunday,
+	"mon": time.Monday,
+	"tue": time.Tuesday,
+	"wed": time.Wednesday,
+	"thu": time.Thursday,
+	"fri": time.Friday,
+	"sat": time.Saturday,
+}
+
+type scheduleSpec struct{}
+
+type schedulePredicate struct {
+	days     [7]bool
+	begin    int
+	end      int
+	location *time.Location
+	getTime  clock
+}
+
+// NewSchedule creates the spec of the Schedule predicate. It matches the
+// requests received in a weekly time window, given as an optional list of
+// days or day ranges of the week, and a time range of the day:
+//
+//	Schedule("Mon-Fri 09:00-17:00", "Europe/Berlin")
+//	Schedule("Sat,Sun 00:00-24:00")
+//	Schedule("22:00-06:00")
+//
+// The beginning of the time range is inclusive, the end is exclusive. When
+// the end is before the beginning, the window spans midnight, and the days
+// refer to the day when the window begins. Without days, the window
+// applies to every day.
+func NewSchedule() routing.PredicateSpec {
+	return &scheduleSpec{}
+}
+
+func (*scheduleSpec) Name() string {
+	return "Schedule"
+}
+
+func (*scheduleSpec) Create(args []interface{}) (routing.Predicate, error) {
+	if len(args) == 0 || len(args) > 2 {
+		return nil, predicates.ErrInvalidPredicateParameters
+	}
+
+	expr, ok := args[0].(string)
+	if !ok {
+		return nil, predicates.ErrInvalidPredicateParameters
+	}
+
+	p := &schedulePredicate{getTime: time.Now}
+	fields := strings.Fields(expr)
+	switch len(fields) {
+	case 1:
+		for i := range p.days {
+			p.days[i] = true
+		}
+	case 2:
+		if err := p.parseDays(fields[0]); err != nil {
+			return nil, err
+		}
+	default:
+		return nil, predicates.ErrInvalidPredicateParameters
+	}
+
+	if err := p.parseTimeRange(fields[len(fields)-1]); err != nil {
+		return nil, err
+	}
+
+	loc, err := location(args[1:])
+	if err != nil {
+		return nil, err
+	}
+
+	p.location = loc
+	return p, nil
+}
+
+func parseWeekday(s string) (time.Weekday, error) {
+	d, ok := weekdays[strings.ToLower(s)]
+	if !ok {
+		return 0, predicates.ErrInvalidPredicateParameters
+	}
+
+	return d, nil
+}
+
+// parseDays parses a comma separated list of days and day ranges, e.g.
+// Mon-Wed,Fri. The ranges may wrap around the end of the week, e.g.
+// Fri-Mon.
+func (p *schedulePredicate) parseDays(s string) error {
+	for _, di := range strings.Split(s, ",") {
+		r := strings.Split(di, "-")
+		if len(r) > 2 {
+			return predicates.ErrInvalidPredicateParameters
+		}
+
+		from, err := parseWeekday(r[0])
+		if err != nil {
+			return err
+		}
+
+		to := from
+		if len(r) == 2 {
+			if to, err = parseWeekday(r[1]); err != nil {
+				return err
+			}
+		}
+
+		for d := from; ; d = (d + 1) % 7 {
+			p.days[d] = true
+			if d == to {
+				break
+			}
+		}
+	}
+
+	return nil
+}
+
+// parseMinutes parses the time of the day in the HH:MM format, and returns
+// it as the minutes elapsed since midnight. 24:00 is accepted as the end
+// of the day.
+func parseMinutes(s string) (int, error) {
+	hm := strings.Split(s, ":")
+	if len(hm) != 2 || len(hm[0]) != 2 || len(hm[1]) != 2 {
+		return 0, predicates.ErrInvalidPredicateParameters
+	}
+
+	h, err := strconv.Atoi(hm[0])
+	if err != nil {
+		return 0, predicates.ErrInvalidPredicateParameters
+	}
+
+	m, err := strconv.Atoi(hm[1])
+	if err != nil {
+		return 0, predicates.ErrInvalidPredicateParameters
+	}
+
+	if h < 0 || m < 0 || m > 59 || h > 24 || h == 24 && m > 0 {
+		return 0, predicates.ErrInvalidPredicateParameters
+	}
+
+	return h*60 + m, nil
+}
+
+func (p *schedulePredicate) parseTimeRange(s string) error {
+	r := strings.Split(s, "-")
+	if len(r) != 2 {
+		return predicates.ErrInvalidPredicateParameters
+	}
+
+	var err error
+	if p.begin, err = parseMinutes(r[0]); err != nil {
+		return err
+	}
+
+	if p.end, err = parseMinutes(r[1]); err != nil {
+		return err
+	}
+
+	if p.begin == p.end || p.begin == minutesPerDay {
+		return predicates.ErrInvalidPredicateParameters
+	}
+
+	return nil
+}
+
+func (p *schedulePredicate) Match(r *http.Request) bool {
+	now := p.getTime().In(p.location)
+	minutes := now.Hour()*60 + now.Minute()
+	day := now.Weekday()
+
+	if p.begin < p.end {
+		return p.days[day] && minutes >= p.begin && minutes < p.end
+	}
+
+	// the window spans midnight, the part after midnight belongs to the
+	// window of the previous day
+	if minutes >= p.begin {
+		return p.days[day]
+	}
+
+	return minutes < p.end && p.days[(day+6)%7]
+}
@@ -0,0 +1,87 @@
+package cron
+
+import (
+	"testing"
+	"time"
+)
+
+func TestScheduleCreate(t *testing.T) {
+	for _, tc := range []struct {
+		msg     string
+		args    []interface{}
+		isError bool
+	}{
+		{"no arguments", nil, true},
+		{"too many arguments", []interface{}{"09:00-17:00", "UTC", "foo"}, true},
+		{"argument with mismatched type", []interface{}{1}, true},
+		{"time range only", []interface{}{"09:00-17:00"}, false},
+		{"days and time range", []interface{}{"Mon-Fri 09:00-17:00"}, false},
+		{"day list", []interface{}{"mon,Wed,fri-sun 09:00-17:00"}, false},
+		{"end of day", []interface{}{"Sat 00:00-24:00"}, false},
+		{"time zone", []interface{}{"Mon-Fri 09:00-17:00", "Europe/Berlin"}, false},
+		{"invalid time zone", []interface{}{"09:00-17:00", "Europe/Nowhere"}, true},
+		{"time zone with mismatched type", []interface{}{"09:00-17:00", 1}, true},
+		{"invalid day", []interface{}{"Monday 09:00-17:00"}, true},
+		{"invalid day range", []interface{}{"Mon-Wed-Fri 09:00-17:00"}, true},
+		{"invalid time", []interface{}{"9:00-17:00"}, true},
+		{"invalid hour", []interface{}{"09:00-25:00"}, true},
+		{"invalid minute", []interface{}{"09:60-17:00"}, true},
+		{"missing time range", []interface{}{"Mon-Fri"}, true},
+		{"empty time range", []interface{}{"09:00-09:00"}, true},
+		{"too many fields", []interface{}{"Mon 09:00-12:00 14:00-17:00"}, true},
+	} {
+		t.Run(tc.msg, func(t *testing.T) {
+			_, err := NewSchedule().Create(tc.args)
+			if err == nil && tc.isError {
+				t.Error("expected an error and got none")
+			} else if err != nil && !tc.isError {
+				t.Errorf("expected no error and got %v", err)
+			}
+		})
+	}
+}
+
+func TestScheduleMatch(t *testing.T) {
+	berlin, err := time.LoadLocation("Europe/Berlin")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// 2020-06-01 is a Monday
+	at := func(day, hour, minute int, loc *time.Location) clock {
+		return func() time.Time { return time.Date(2020, 6, day, hour, minute, 0, 0, loc) }
+	}
+
+	for _, tc := range []struct {
+		msg     string
+		args    []interface{}
+		clock   clock
+		matches bool
+	}{
+		{"in window", []interface{}{"Mon-Fri 09:00-17:00", "UTC"}, at(1, 9, 0, time.UTC), true},
+		{"before window", []interface{}{"Mon-Fri 09:00-17:00", "UTC"}, at(1, 8, 59, time.UTC), false},
+		{"end excluded", []interface{}{"Mon-Fri 09:00-17:00", "UTC"}, at(5, 17, 0, time.UTC), false},
+		{"other day", []interface{}{"Mon-Fri 09:00-17:00", "UTC"}, at(6, 12, 0, time.UTC), false},
+		{"every day", []interface{}{"09:00-17:00", "UTC"}, at(7, 12, 0, time.UTC), true},
+		{"wrapping day range", []interface{}{"Sat-Mon 09:00-17:00", "UTC"}, at(7, 12, 0, time.UTC), true},
+		{"end of day", []interface{}{"Sun 00:00-24:00", "UTC"}, at(7, 23, 59, time.UTC), true},
+		{"time zone", []interface{}{"Mon 09:00-17:00", "Europe/Berlin"}, at(1, 7, 30, time.UTC), true},
+		{"time zone outside", []interface{}{"Mon 09:00-17:00", "UTC"}, at(1, 9, 30, berlin), false},
+		{"over midnight before", []interface{}{"Fri 22:00-06:00", "UTC"}, at(5, 23, 0, time.UTC), true},
+		{"over midnight after", []interface{}{"Fri 22:00-06:00", "UTC"}, at(6, 5, 59, time.UTC), true},
+		{"over midnight other day", []interface{}{"Fri 22:00-06:00", "UTC"}, at(5, 5, 0, time.UTC), false},
+		{"over midnight end", []interface{}{"Fri 22:00-06:00", "UTC"}, at(6, 6, 0, time.UTC), false},
+	} {
+		t.Run(tc.msg, func(t *testing.T) {
+			p, err := NewSchedule().Create(tc.args)
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			p.(*schedulePredicate).getTime = tc.clock
+			if got := p.Match(nil); got != tc.matches {
+				t.Errorf("expected %t and got %t", tc.matches, got)
+			}
+		})
+	}
+}
@@ -0,0 +1,80 @@
+/*
+Package geoip implements a predicate to match the routes based on the
+country of the client, looked up in a MaxMind database, like the free
+GeoLite2-Country database.
+
+The country database is set with the -geoip-country-database flag. The
+client address is resolved the same way as by the ClientIP predicate,
+using the trusted proxies of the -forwarded-trusted-cidrs flag, or the
+depth of the -client-ip-forwarded-depth flag.
+
+The Country predicate matches, when the ISO 3166-1 alpha-2 code of the
+country of the client is one of the arguments. The requests from unknown
+countries don't match.
+
+Eskip example:
+
+	dach: Country("DE", "AT", "CH") -> "https://dach.example.org";
+*/
+package geoip
+
+import (
+	"errors"
+	"net/http"
+	"strings"
+
+	sgeoip "github.com/zalando/skipper/geoip"
+	"github.com/zalando/skipper/predicates"
+	"github.com/zalando/skipper/routing"
+)
+
+// The predicate can be referenced in eskip by the name "Country".
+const Name = "Country"
+
+var errNoCountryDatabase = errors.New("no GeoIP country database configured")
+
+type (
+	spec struct {
+		geoip *sgeoip.GeoIP
+	}
+
+	predicate struct {
+		geoip     *sgeoip.GeoIP
+		countries map[string]bool
+	}
+)
+
+// NewCountry creates the spec of the Country predicate, looking up the
+// countries with the provided GeoIP instance.
+func NewCountry(g *sgeoip.GeoIP) routing.PredicateSpec {
+	return &spec{geoip: g}
+}
+
+func (*spec) Name() string { return Name }
+
+func (s *spec) Create(args []interface{}) (routing.Predicate, error) {
+	if s.geoip == nil || !s.geoip.HasCountry() {
+		return nil, errNoCountryDatabase
+	}
+
+	if len(args) == 0 {
+		return nil, predicates.ErrInvalidPredicateParameters
+	}
+
+	p := &predicate{geoip: s.geoip, countries: make(map[string]bool)}
+	for _, a := range args {
+		c, ok := a.(string)
+		if !ok || len(c) != 2 {
+			return nil, predicates.ErrInvalidPredicateParameters
+		}
+
+		p.countries[strings.ToUpper(c)] = true
+	}
+
+	return p, nil
+}
+
+func (p *predicate) Match(r *http.Request) bool {
+	c := p.geoip.Country(p.geoip.ClientIP(r))
+	return c != "" && p.countries[c]
+}
@@ -0,0 +1,104 @@
+package geoip
+
+import (
+	"io/ioutil"
+	"net/http"
+	"os"
+	"path/filepath"
+	"testing"
+
+	sgeoip "github.com/zalando/skipper/geoip"
+	"github.com/zalando/skipper/geoip/geoiptest"
+)
+
+func testGeoIP(t *testing.T) (*sgeoip.GeoIP, func()) {
+	dir, err := ioutil.TempDir("", "geoip")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	db := filepath.Join(dir, "country.mmdb")
+	if err := geoiptest.WriteFile(db, "GeoLite2-Country", 6, map[string]interface{}{
+		"1.2.3.0/24": map[string]interface{}{"country": map[string]interface{}{"iso_code": "DE"}},
+		"5.6.7.0/24": map[string]interface{}{"country": map[string]interface{}{"iso_code": "US"}},
+	}); err != nil {
+		t.Fatal(err)
+	}
+
+	g, err := sgeoip.New(sgeoip.Options{CountryDatabase: db})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	return g, func() {
+		g.Close()
+		os.RemoveAll(dir)
+	}
+}
+
+func TestCreate(t *testing.T) {
+	g, cleanup := testGeoIP(t)
+	defer cleanup()
+
+	for _, ti := range []struct {
+		msg  string
+		args []interface{}
+		err  bool
+	}{{
+		"no args",
+		nil,
+		true,
+	}, {
+		"arg not string",
+		[]interface{}{1.0},
+		true,
+	}, {
+		"invalid country code",
+		[]interface{}{"DEU"},
+		true,
+	}, {
+		"valid country codes",
+		[]interface{}{"DE", "at"},
+		false,
+	}} {
+		t.Run(ti.msg, func(t *testing.T) {
+			_, err := NewCountry(g).Create(ti.args)
+			if ti.err && err == nil {
+				t.Error("failed to fail")
+			} else if !ti.err && err != nil {
+				t.Error(err)
+			}
+		})
+	}
+}
+
+func TestCreateWithoutDatabase(t *testing.T) {
+	if _, err := NewCountry(nil).Create([]interface{}{"DE"}); err == nil {
+		t.Error("failed to fail")
+	}
+}
+
+func TestMatch(t *testing.T) {
+	g, cleanup := testGeoIP(t)
+	defer cleanup()
+
+	p, err := NewCountry(g).Create([]interface{}{"de", "AT"})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	for _, ti := range []struct {
+		remoteAddr string
+		match      bool
+	}{
+		{"1.2.3.4:1234", true},
+		{"5.6.7.8:1234", false},
+		{"9.9.9.9:1234", false},
+		{"invalid", false},
+	} {
+		r := &http.Request{RemoteAddr: ti.remoteAddr, Header: make(http.Header)}
+		if m := p.Match(r); m != ti.match {
+			t.Errorf("%s: failed to match, expected: %t, got: %t", ti.remoteAddr, ti.match, m)
+		}
+	}
+}
@@ -0,0 +1,78 @@
+/*
+Package listener implements a predicate to scope routes to one of the
+additional listeners of skipper.
+
+When skipper is started with multiple listeners, e.g. a public and an
+internal one, the requests accepted by a named listener carry the name
+of the listener. The Listener predicate matches these requests, so that
+routes can be served only on a given listener, with their own policies.
+
+Routes without the Listener predicate match the requests from all the
+listeners.
+
+Eskip example:
+
+	admin: Listener("internal") && Path("/admin") -> "http://admin.example.org";
+*/
+package listener
+
+import (
+	"context"
+	"net/http"
+
+	"github.com/zalando/skipper/predicates"
+	"github.com/zalando/skipper/routing"
+)
+
+// The predicate can be referenced in eskip by the name "Listener".
+const Name = "Listener"
+
+type (
+	spec      struct{}
+	predicate string
+	key       struct{}
+)
+
+// NewContext returns a copy of ctx carrying the name of the listener that
+// accepted the request.
+func NewContext(ctx context.Context, name string) context.Context {
+	return context.WithValue(ctx, key{}, name)
+}
+
+// FromContext returns the name of the listener that accepted the request,
+// or an empty string when the request was accepted by the main listener.
+func FromContext(ctx context.Context) string {
+	name, _ := ctx.Value(key{}).(string)
+	return name
+}
+
+// Handler returns an http.Handler that marks every request with the name
+// of the listener before passing it to the next handler.
+func Handler(name string, next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		next.ServeHTTP(w, r.WithContext(NewContext(r.Context(), name)))
+	})
+}
+
+// New creates a predicate specification, whose instances match the requests
+// accepted by the listener with the name passed in as the only argument.
+func New() routing.PredicateSpec { return &spec{} }
+
+func (s *spec) Name() string { return Name }
+
+func (s *spec) Create(args []interface{}) (routing.Predicate, error) {
+	if len(args) != 1 {
+		return nil, predicates.ErrInvalidPredicateParameters
+	}
+
+	name, ok := args[0].(string)
+	if !ok || name == "" {
+		return nil, predicates.ErrInvalidPredicateParameters
+	}
+
+	return predicate(name), nil
+}
+
+func (p predicate) Match(r *http.Request) bool {
+	return FromContext(r.Context()) == string(p)
+}
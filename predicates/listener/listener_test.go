@@ -0,0 +1,94 @@
+package listener
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+func TestListenerArgs(t *testing.T) {
+	for _, ti := range []struct {
+		msg  string
+		args []interface{}
+		err  bool
+	}{{
+		"no args",
+		nil,
+		true,
+	}, {
+		"too many args",
+		[]interface{}{"internal", "public"},
+		true,
+	}, {
+		"invalid name",
+		[]interface{}{float64(1)},
+		true,
+	}, {
+		"empty name",
+		[]interface{}{""},
+		true,
+	}, {
+		"ok",
+		[]interface{}{"internal"},
+		false,
+	}} {
+		t.Run(ti.msg, func(t *testing.T) {
+			p, err := New().Create(ti.args)
+			if ti.err && err == nil {
+				t.Error("failed to fail")
+			} else if !ti.err && err != nil {
+				t.Error(err)
+			}
+
+			if err == nil && p == nil {
+				t.Error("failed to create predicate")
+			}
+		})
+	}
+}
+
+func TestListenerMatch(t *testing.T) {
+	for _, ti := range []struct {
+		msg      string
+		listener string
+		arg      string
+		match    bool
+	}{{
+		"main listener",
+		"",
+		"internal",
+		false,
+	}, {
+		"other listener",
+		"public",
+		"internal",
+		false,
+	}, {
+		"matching listener",
+		"internal",
+		"internal",
+		true,
+	}} {
+		t.Run(ti.msg, func(t *testing.T) {
+			p, err := New().Create([]interface{}{ti.arg})
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			var match bool
+			h := http.HandlerFunc(func(_ http.ResponseWriter, r *http.Request) {
+				match = p.Match(r)
+			})
+
+			var hh http.Handler = h
+			if ti.listener != "" {
+				hh = Handler(ti.listener, h)
+			}
+
+			hh.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest("GET", "/", nil))
+			if match != ti.match {
+				t.Errorf("failed to match, expected: %v, got: %v", ti.match, match)
+			}
+		})
+	}
+}
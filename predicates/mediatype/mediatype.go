@@ -0,0 +1,171 @@
+// Package mediatype implements predicates to match the Content-Type and the
+// Accept headers of the requests with media type semantics, to route the
+// requests to different backends based on content negotiation.
+//
+// The arguments of the predicates are media types, that may contain
+// wildcards, like text/* or */*, and structured syntax suffixes, like
+// application/*+json. The suffix patterns match the subtypes with the
+// suffix, e.g. application/vnd.api+json, and the subtype equal to the
+// suffix, e.g. application/json. The media type parameters, like the
+// charset, are ignored.
+//
+// The ContentType predicate matches, when the Content-Type header of the
+// request matches one of the arguments.
+//
+// The Accept predicate matches, when one of the media ranges of the Accept
+// header of the request matches one of the arguments. The media ranges with
+// the quality value of 0 are ignored. The wildcard media ranges of the
+// header, like */*, are not matched, so that the generic clients fall back
+// to the routes without this predicate.
+//
+// Examples:
+//
+//	json: ContentType("application/json", "application/*+json") -> "https://json.example.org";
+//	html: Accept("text/html") -> "https://www.example.org";
+package mediatype
+
+import (
+	"mime"
+	"net/http"
+	"strconv"
+	"strings"
+
+	"github.com/zalando/skipper/predicates"
+	"github.com/zalando/skipper/routing"
+)
+
+const (
+	ContentTypeName = "ContentType"
+	AcceptName      = "Accept"
+)
+
+type (
+	spec struct {
+		accept bool
+	}
+
+	mediaType struct {
+		typ, subtype string
+	}
+
+	predicate struct {
+		accept   bool
+		patterns []mediaType
+	}
+)
+
+// NewContentType creates the spec of the ContentType predicate.
+func NewContentType() routing.PredicateSpec { return &spec{} }
+
+// NewAccept creates the spec of the Accept predicate.
+func NewAccept() routing.PredicateSpec { return &spec{accept: true} }
+
+func (s *spec) Name() string {
+	if s.accept {
+		return AcceptName
+	}
+
+	return ContentTypeName
+}
+
+func parse(s string) (mediaType, map[string]string, bool) {
+	mt, params, err := mime.ParseMediaType(s)
+	if err != nil {
+		return mediaType{}, nil, false
+	}
+
+	ts := strings.Split(mt, "/")
+	if len(ts) != 2 || ts[0] == "" || ts[1] == "" {
+		return mediaType{}, nil, false
+	}
+
+	return mediaType{typ: ts[0], subtype: ts[1]}, params, true
+}
+
+func (s *spec) Create(args []interface{}) (routing.Predicate, error) {
+	if len(args) == 0 {
+		return nil, predicates.ErrInvalidPredicateParameters
+	}
+
+	p := &predicate{accept: s.accept}
+	for _, a := range args {
+		as, ok := a.(string)
+		if !ok {
+			return nil, predicates.ErrInvalidPredicateParameters
+		}
+
+		mt, _, ok := parse(as)
+		if !ok {
+			return nil, predicates.ErrInvalidPredicateParameters
+		}
+
+		p.patterns = append(p.patterns, mt)
+	}
+
+	return p, nil
+}
+
+func (mt mediaType) wildcard() bool {
+	return mt.typ == "*" || strings.HasPrefix(mt.subtype, "*")
+}
+
+// matches tells whether a media type without wildcards matches the pattern
+func (pattern mediaType) matches(mt mediaType) bool {
+	if pattern.typ != "*" && pattern.typ != mt.typ {
+		return false
+	}
+
+	switch {
+	case pattern.subtype == "*":
+		return true
+	case strings.HasPrefix(pattern.subtype, "*+"):
+		suffix := pattern.subtype[2:]
+		return mt.subtype == suffix || strings.HasSuffix(mt.subtype, "+"+suffix)
+	default:
+		return pattern.subtype == mt.subtype
+	}
+}
+
+func (p *predicate) matchAny(mt mediaType) bool {
+	for _, pattern := range p.patterns {
+		if pattern.matches(mt) {
+			return true
+		}
+	}
+
+	return false
+}
+
+// accepted tells whether a media range of the Accept header has a quality
+// value other than 0
+func accepted(params map[string]string) bool {
+	q, ok := params["q"]
+	if !ok {
+		return true
+	}
+
+	qv, err := strconv.ParseFloat(q, 64)
+	return err == nil && qv > 0
+}
+
+func (p *predicate) matchAccept(r *http.Request) bool {
+	for _, h := range r.Header["Accept"] {
+		for _, mr := range strings.Split(h, ",") {
+			mt, params, ok := parse(mr)
+			if ok && !mt.wildcard() && accepted(params) && p.matchAny(mt) {
+				return true
+			}
+		}
+	}
+
+	return false
+}
+
+func (p *predicate) Match(r *http.Request) bool {
+	if p.accept {
+		return p.matchAccept(r)
+	}
+
+	mt, _, ok := parse(r.Header.Get("Content-Type"))
+	return ok && !mt.wildcard() && p.matchAny(mt)
+}
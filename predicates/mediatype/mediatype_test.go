@@ -0,0 +1,203 @@
+package mediatype
+
+import (
+	"net/http"
+	"testing"
+
+	"github.com/zalando/skipper/routing"
+)
+
+func TestCreate(t *testing.T) {
+	for _, ti := range []struct {
+		msg  string
+		args []interface{}
+		err  bool
+	}{{
+		"no args",
+		nil,
+		true,
+	}, {
+		"arg not string",
+		[]interface{}{1.0},
+		true,
+	}, {
+		"invalid media type",
+		[]interface{}{"application"},
+		true,
+	}, {
+		"empty subtype",
+		[]interface{}{"application/"},
+		true,
+	}, {
+		"valid media types",
+		[]interface{}{"application/json", "text/*", "application/*+json", "*/*"},
+		false,
+	}} {
+		t.Run(ti.msg, func(t *testing.T) {
+			for _, s := range []routing.PredicateSpec{NewContentType(), NewAccept()} {
+				_, err := s.Create(ti.args)
+				if ti.err && err == nil {
+					t.Error(s.Name(), "failed to fail")
+				} else if !ti.err && err != nil {
+					t.Error(s.Name(), err)
+				}
+			}
+		})
+	}
+}
+
+func TestContentType(t *testing.T) {
+	for _, ti := range []struct {
+		msg         string
+		args        []interface{}
+		contentType string
+		match       bool
+	}{{
+		"exact",
+		[]interface{}{"application/json"},
+		"application/json",
+		true,
+	}, {
+		"parameters ignored",
+		[]interface{}{"application/json"},
+		"Application/JSON; charset=utf-8",
+		true,
+	}, {
+		"different",
+		[]interface{}{"application/json"},
+		"application/xml",
+		false,
+	}, {
+		"subtype wildcard",
+		[]interface{}{"text/*"},
+		"text/plain",
+		true,
+	}, {
+		"subtype wildcard different type",
+		[]interface{}{"text/*"},
+		"application/json",
+		false,
+	}, {
+		"any",
+		[]interface{}{"*/*"},
+		"image/png",
+		true,
+	}, {
+		"suffix",
+		[]interface{}{"application/*+json"},
+		"application/vnd.api+json",
+		true,
+	}, {
+		"suffix matches plain",
+		[]interface{}{"application/*+json"},
+		"application/json",
+		true,
+	}, {
+		"suffix different",
+		[]interface{}{"application/*+json"},
+		"application/vnd.api+xml",
+		false,
+	}, {
+		"one of multiple",
+		[]interface{}{"application/xml", "application/json"},
+		"application/json",
+		true,
+	}, {
+		"missing",
+		[]interface{}{"*/*"},
+		"",
+		false,
+	}, {
+		"invalid",
+		[]interface{}{"*/*"},
+		"json",
+		false,
+	}} {
+		t.Run(ti.msg, func(t *testing.T) {
+			p, err := NewContentType().Create(ti.args)
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			r := &http.Request{Header: make(http.Header)}
+			if ti.contentType != "" {
+				r.Header.Set("Content-Type", ti.contentType)
+			}
+
+			if m := p.Match(r); m != ti.match {
+				t.Error("failed to match", m, ti.match)
+			}
+		})
+	}
+}
+
+func TestAccept(t *testing.T) {
+	for _, ti := range []struct {
+		msg    string
+		args   []interface{}
+		accept []string
+		match  bool
+	}{{
+		"exact",
+		[]interface{}{"application/json"},
+		[]string{"application/json"},
+		true,
+	}, {
+		"one of the ranges",
+		[]interface{}{"application/json"},
+		[]string{"text/html, application/json;q=0.9"},
+		true,
+	}, {
+		"multiple headers",
+		[]interface{}{"application/json"},
+		[]string{"text/html", "application/json"},
+		true,
+	}, {
+		"not accepted",
+		[]interface{}{"application/json"},
+		[]string{"text/html, application/json;q=0"},
+		false,
+	}, {
+		"wildcard range ignored",
+		[]interface{}{"application/json"},
+		[]string{"text/html, */*;q=0.8"},
+		false,
+	}, {
+		"subtype wildcard range ignored",
+		[]interface{}{"application/json"},
+		[]string{"application/*"},
+		false,
+	}, {
+		"wildcard pattern",
+		[]interface{}{"text/*"},
+		[]string{"text/html,application/xhtml+xml"},
+		true,
+	}, {
+		"suffix pattern",
+		[]interface{}{"application/*+xml"},
+		[]string{"text/html,application/xhtml+xml"},
+		true,
+	}, {
+		"invalid quality",
+		[]interface{}{"application/json"},
+		[]string{"application/json;q=foo"},
+		false,
+	}, {
+		"missing",
+		[]interface{}{"*/*"},
+		nil,
+		false,
+	}} {
+		t.Run(ti.msg, func(t *testing.T) {
+			p, err := NewAccept().Create(ti.args)
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			r := &http.Request{Header: http.Header{"Accept": ti.accept}}
+			if m := p.Match(r); m != ti.match {
+				t.Error("failed to match", m, ti.match)
+			}
+		})
+	}
+}
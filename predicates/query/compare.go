@@ -0,0 +1,137 @@
+package query
+
+import (
+	"math"
+	"net/http"
+	"strconv"
+
+	"github.com/zalando/skipper/predicates"
+	"github.com/zalando/skipper/routing"
+)
+
+const (
+	intName = "QueryParamInt"
+	inName  = "QueryParamIn"
+)
+
+type (
+	intSpec struct{}
+	inSpec  struct{}
+
+	intPredicate struct {
+		paramName string
+		compare   func(int64) bool
+	}
+
+	inPredicate struct {
+		paramName string
+		values    map[string]bool
+	}
+)
+
+// NewInt creates the QueryParamInt predicate specification. It matches
+// when a value of the query param is an integer, and it satisfies the
+// comparison with the operator and the number in the arguments. The
+// supported operators are: ==, !=, <, <=, > and >=.
+//
+// Example:
+//
+//	v2: QueryParamInt("version", ">=", 2) -> "https://v2.example.org";
+func NewInt() routing.PredicateSpec { return &intSpec{} }
+
+// NewIn creates the QueryParamIn predicate specification. It matches when
+// a value of the query param is one of the values in the arguments.
+//
+// Example:
+//
+//	beta: QueryParamIn("channel", "beta", "canary") -> "https://beta.example.org";
+func NewIn() routing.PredicateSpec { return &inSpec{} }
+
+func (*intSpec) Name() string { return intName }
+
+func (*intSpec) Create(args []interface{}) (routing.Predicate, error) {
+	if len(args) != 3 {
+		return nil, predicates.ErrInvalidPredicateParameters
+	}
+
+	name, ok := args[0].(string)
+	if !ok {
+		return nil, predicates.ErrInvalidPredicateParameters
+	}
+
+	op, ok := args[1].(string)
+	if !ok {
+		return nil, predicates.ErrInvalidPredicateParameters
+	}
+
+	f, ok := args[2].(float64)
+	if !ok || f != math.Trunc(f) {
+		return nil, predicates.ErrInvalidPredicateParameters
+	}
+
+	n := int64(f)
+	p := &intPredicate{paramName: name}
+	switch op {
+	case "==":
+		p.compare = func(v int64) bool { return v == n }
+	case "!=":
+		p.compare = func(v int64) bool { return v != n }
+	case "<":
+		p.compare = func(v int64) bool { return v < n }
+	case "<=":
+		p.compare = func(v int64) bool { return v <= n }
+	case ">":
+		p.compare = func(v int64) bool { return v > n }
+	case ">=":
+		p.compare = func(v int64) bool { return v >= n }
+	default:
+		return nil, predicates.ErrInvalidPredicateParameters
+	}
+
+	return p, nil
+}
+
+func (p *intPredicate) Match(r *http.Request) bool {
+	for _, v := range r.URL.Query()[p.paramName] {
+		if i, err := strconv.ParseInt(v, 10, 64); err == nil && p.compare(i) {
+			return true
+		}
+	}
+
+	return false
+}
+
+func (*inSpec) Name() string { return inName }
+
+func (*inSpec) Create(args []interface{}) (routing.Predicate, error) {
+	if len(args) < 2 {
+		return nil, predicates.ErrInvalidPredicateParameters
+	}
+
+	name, ok := args[0].(string)
+	if !ok {
+		return nil, predicates.ErrInvalidPredicateParameters
+	}
+
+	p := &inPredicate{paramName: name, values: make(map[string]bool)}
+	for _, a := range args[1:] {
+		v, ok := a.(string)
+		if !ok {
+			return nil, predicates.ErrInvalidPredicateParameters
+		}
+
+		p.values[v] = true
+	}
+
+	return p, nil
+}
+
+func (p *inPredicate) Match(r *http.Request) bool {
+	for _, v := range r.URL.Query()[p.paramName] {
+		if p.values[v] {
+			return true
+		}
+	}
+
+	return false
+}
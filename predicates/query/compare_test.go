@@ -0,0 +1,172 @@
+package query
+
+import (
+	"net/http"
+	"net/url"
+	"testing"
+
+	"github.com/zalando/skipper/routing"
+)
+
+func TestCompareArgs(t *testing.T) {
+	for _, ti := range []struct {
+		msg  string
+		spec routing.PredicateSpec
+		args []interface{}
+		err  bool
+	}{{
+		"int too few args",
+		NewInt(),
+		[]interface{}{"version", ">="},
+		true,
+	}, {
+		"int name not string",
+		NewInt(),
+		[]interface{}{1.0, ">=", 2.0},
+		true,
+	}, {
+		"int invalid operator",
+		NewInt(),
+		[]interface{}{"version", "=>", 2.0},
+		true,
+	}, {
+		"int value not number",
+		NewInt(),
+		[]interface{}{"version", ">=", "2"},
+		true,
+	}, {
+		"int value not integer",
+		NewInt(),
+		[]interface{}{"version", ">=", 2.5},
+		true,
+	}, {
+		"int valid",
+		NewInt(),
+		[]interface{}{"version", ">=", 2.0},
+		false,
+	}, {
+		"in too few args",
+		NewIn(),
+		[]interface{}{"channel"},
+		true,
+	}, {
+		"in value not string",
+		NewIn(),
+		[]interface{}{"channel", "beta", 1.0},
+		true,
+	}, {
+		"in valid",
+		NewIn(),
+		[]interface{}{"channel", "beta", "canary"},
+		false,
+	}} {
+		t.Run(ti.msg, func(t *testing.T) {
+			_, err := ti.spec.Create(ti.args)
+			if ti.err && err == nil {
+				t.Error("failed to fail")
+			} else if !ti.err && err != nil {
+				t.Error(err)
+			}
+		})
+	}
+}
+
+func TestCompareMatch(t *testing.T) {
+	for _, ti := range []struct {
+		msg   string
+		spec  routing.PredicateSpec
+		args  []interface{}
+		query string
+		match bool
+	}{{
+		"equal",
+		NewInt(),
+		[]interface{}{"version", "==", 2.0},
+		"version=2",
+		true,
+	}, {
+		"not equal",
+		NewInt(),
+		[]interface{}{"version", "!=", 2.0},
+		"version=2",
+		false,
+	}, {
+		"less",
+		NewInt(),
+		[]interface{}{"version", "<", 2.0},
+		"version=1",
+		true,
+	}, {
+		"less or equal",
+		NewInt(),
+		[]interface{}{"version", "<=", 2.0},
+		"version=3",
+		false,
+	}, {
+		"greater",
+		NewInt(),
+		[]interface{}{"version", ">", 2.0},
+		"version=2",
+		false,
+	}, {
+		"greater or equal",
+		NewInt(),
+		[]interface{}{"version", ">=", 2.0},
+		"version=10",
+		true,
+	}, {
+		"negative",
+		NewInt(),
+		[]interface{}{"offset", "<", 0.0},
+		"offset=-1",
+		true,
+	}, {
+		"missing param",
+		NewInt(),
+		[]interface{}{"version", ">=", 2.0},
+		"foo=3",
+		false,
+	}, {
+		"not a number",
+		NewInt(),
+		[]interface{}{"version", ">=", 2.0},
+		"version=v3",
+		false,
+	}, {
+		"multiple values",
+		NewInt(),
+		[]interface{}{"version", ">=", 2.0},
+		"version=1&version=3",
+		true,
+	}, {
+		"in set",
+		NewIn(),
+		[]interface{}{"channel", "beta", "canary"},
+		"channel=canary",
+		true,
+	}, {
+		"not in set",
+		NewIn(),
+		[]interface{}{"channel", "beta", "canary"},
+		"channel=stable",
+		false,
+	}, {
+		"in set missing param",
+		NewIn(),
+		[]interface{}{"channel", "beta", "canary"},
+		"foo=beta",
+		false,
+	}} {
+		t.Run(ti.msg, func(t *testing.T) {
+			p, err := ti.spec.Create(ti.args)
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			req := &http.Request{URL: &url.URL{RawQuery: ti.query}}
+			if m := p.Match(req); m != ti.match {
+				t.Error("failed to match", m, ti.match)
+			}
+		})
+	}
+}
@@ -23,6 +23,14 @@ Examples:
     // matches http://example.org?bb=a&query=testing&query=example
     example1: QueryParam("query", "^example$") -> "http://example.org";
 
+    // compares the integer value of a query param
+    // matches http://example.org?version=3
+    example2: QueryParamInt("version", ">=", 2) -> "http://example.org";
+
+    // matches one of a set of values
+    // matches http://example.org?channel=canary
+    example3: QueryParamIn("channel", "beta", "canary") -> "http://example.org";
+
 */
 package query
 
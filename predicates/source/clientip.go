@@ -0,0 +1,105 @@
+package source
+
+import (
+	"net"
+	"net/http"
+	"strings"
+
+	snet "github.com/zalando/skipper/net"
+	"github.com/zalando/skipper/routing"
+)
+
+const NameClientIP = "ClientIP"
+
+// ClientIPOptions defines how the ClientIP predicate resolves the address
+// of the client.
+type ClientIPOptions struct {
+	// TrustedProxies contains the networks of the proxies in front of
+	// skipper. The entries of the X-Forwarded-For header are only
+	// accepted when they were added by a trusted proxy.
+	TrustedProxies []*net.IPNet
+
+	// ForwardedDepth, when greater than zero, sets the fixed number of
+	// the proxies in front of skipper, and the client address is taken
+	// from the X-Forwarded-For header at this depth, counted from the
+	// last entry. It takes precedence over TrustedProxies.
+	ForwardedDepth int
+}
+
+type clientIPSpec struct {
+	options ClientIPOptions
+}
+
+type clientIPPredicate struct {
+	options ClientIPOptions
+	nets    []net.IPNet
+}
+
+// NewClientIP creates the spec of the ClientIP predicate. Unlike Source
+// and SourceFromLast, the predicate doesn't accept the X-Forwarded-For
+// entries set by arbitrary clients, it resolves the client address
+// either by skipping the trusted proxies, or at a fixed depth. Without
+// trusted proxies and depth, the address of the connection is used.
+//
+// Example:
+//
+//	internal: ClientIP("10.0.0.0/8", "192.168.0.0/16") -> "http://internal.example.org";
+func NewClientIP(o ClientIPOptions) routing.PredicateSpec {
+	return &clientIPSpec{options: o}
+}
+
+func (*clientIPSpec) Name() string { return NameClientIP }
+
+func (s *clientIPSpec) Create(args []interface{}) (routing.Predicate, error) {
+	if len(args) == 0 {
+		return nil, InvalidArgsError
+	}
+
+	p := &clientIPPredicate{options: s.options}
+	for _, a := range args {
+		s, ok := a.(string)
+		if !ok {
+			return nil, InvalidArgsError
+		}
+
+		if !strings.Contains(s, "/") {
+			if strings.Contains(s, ":") {
+				s += "/128"
+			} else {
+				s += "/32"
+			}
+		}
+
+		_, n, err := net.ParseCIDR(s)
+		if err != nil {
+			return nil, InvalidArgsError
+		}
+
+		p.nets = append(p.nets, *n)
+	}
+
+	return p, nil
+}
+
+func (p *clientIPPredicate) clientIP(r *http.Request) net.IP {
+	if p.options.ForwardedDepth > 0 {
+		return snet.RemoteHostAtDepth(r, p.options.ForwardedDepth)
+	}
+
+	return snet.RemoteHostTrusted(r, p.options.TrustedProxies)
+}
+
+func (p *clientIPPredicate) Match(r *http.Request) bool {
+	ip := p.clientIP(r)
+	if ip == nil {
+		return false
+	}
+
+	for _, n := range p.nets {
+		if n.Contains(ip) {
+			return true
+		}
+	}
+
+	return false
+}
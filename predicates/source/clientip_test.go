@@ -0,0 +1,117 @@
+package source
+
+import (
+	"net/http"
+	"testing"
+
+	snet "github.com/zalando/skipper/net"
+)
+
+func TestClientIPCreate(t *testing.T) {
+	for _, ti := range []struct {
+		msg  string
+		args []interface{}
+		err  bool
+	}{{
+		"no args",
+		nil,
+		true,
+	}, {
+		"arg not string",
+		[]interface{}{1},
+		true,
+	}, {
+		"invalid network",
+		[]interface{}{"all the things"},
+		true,
+	}, {
+		"valid networks",
+		[]interface{}{"10.0.0.0/8", "1.2.3.4", "C0:FF::EE"},
+		false,
+	}} {
+		t.Run(ti.msg, func(t *testing.T) {
+			_, err := NewClientIP(ClientIPOptions{}).Create(ti.args)
+			if err == nil && ti.err || err != nil && !ti.err {
+				t.Error(ti.msg, "failure case", err, ti.err)
+			}
+		})
+	}
+}
+
+func TestClientIPMatching(t *testing.T) {
+	trusted, err := snet.ParseCIDRs([]string{"10.0.0.0/8"})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	for _, ti := range []struct {
+		msg     string
+		options ClientIPOptions
+		args    []interface{}
+		req     *http.Request
+		matches bool
+	}{{
+		"connection address",
+		ClientIPOptions{},
+		[]interface{}{"192.168.0.0/16"},
+		&http.Request{RemoteAddr: "192.168.0.1:1234"},
+		true,
+	}, {
+		"forwarded address ignored without trusted proxies",
+		ClientIPOptions{},
+		[]interface{}{"192.168.0.0/16"},
+		&http.Request{RemoteAddr: "8.8.8.8:1234", Header: http.Header{"X-Forwarded-For": []string{"192.168.0.1"}}},
+		false,
+	}, {
+		"forwarded address from trusted proxy",
+		ClientIPOptions{TrustedProxies: trusted},
+		[]interface{}{"192.168.0.0/16"},
+		&http.Request{RemoteAddr: "10.0.0.1:1234", Header: http.Header{"X-Forwarded-For": []string{"8.8.8.8, 192.168.0.1, 10.0.0.2"}}},
+		true,
+	}, {
+		"forwarded address from untrusted proxy",
+		ClientIPOptions{TrustedProxies: trusted},
+		[]interface{}{"192.168.0.0/16"},
+		&http.Request{RemoteAddr: "8.8.8.8:1234", Header: http.Header{"X-Forwarded-For": []string{"192.168.0.1"}}},
+		false,
+	}, {
+		"trusted proxy not matched as client",
+		ClientIPOptions{TrustedProxies: trusted},
+		[]interface{}{"10.0.0.0/8"},
+		&http.Request{RemoteAddr: "10.0.0.1:1234", Header: http.Header{"X-Forwarded-For": []string{"8.8.8.8"}}},
+		false,
+	}, {
+		"forwarded depth",
+		ClientIPOptions{ForwardedDepth: 2},
+		[]interface{}{"192.168.0.1"},
+		&http.Request{RemoteAddr: "8.8.4.4:1234", Header: http.Header{"X-Forwarded-For": []string{"8.8.8.8, 192.168.0.1, 1.2.3.4"}}},
+		true,
+	}, {
+		"forwarded depth takes precedence",
+		ClientIPOptions{TrustedProxies: trusted, ForwardedDepth: 1},
+		[]interface{}{"192.168.0.1"},
+		&http.Request{RemoteAddr: "10.0.0.1:1234", Header: http.Header{"X-Forwarded-For": []string{"192.168.0.1, 10.0.0.2"}}},
+		false,
+	}, {
+		"invalid forwarded address",
+		ClientIPOptions{TrustedProxies: trusted},
+		[]interface{}{"0.0.0.0/0"},
+		&http.Request{RemoteAddr: "10.0.0.1:1234", Header: http.Header{"X-Forwarded-For": []string{"invalid"}}},
+		false,
+	}} {
+		t.Run(ti.msg, func(t *testing.T) {
+			p, err := NewClientIP(ti.options).Create(ti.args)
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			if ti.req.Header == nil {
+				ti.req.Header = make(http.Header)
+			}
+
+			if p.Match(ti.req) != ti.matches {
+				t.Error(ti.msg, "failed to match as expected")
+			}
+		})
+	}
+}
@@ -23,6 +23,10 @@ There are two flavors of this predicate Source() and SourceFromLast().
 The difference is that Source() finds the remote host as first entry from
 the X-Forwarded-For header and SourceFromLast() as last entry.
 
+The ClientIP() predicate doesn't accept the X-Forwarded-For entries set by
+arbitrary clients. It resolves the client address by skipping the trusted
+proxies in front of skipper, or by taking the entry at a configured depth.
+
 Examples:
 
     // only match requests from 1.2.3.4
@@ -36,6 +40,9 @@ Examples:
 
     // same as example3, only match requests from 1.2.3.4 and the 2.2.2.0/24 network
     example4: SourceFromLast("1.2.3.4", "2.2.2.0/24") -> "http://example.org";
+
+    // only match requests whose client, behind the trusted proxies, is in the 10.0.0.0/8 network
+    example5: ClientIP("10.0.0.0/8") -> "http://internal.example.org";
 */
 package source
 
@@ -0,0 +1,156 @@
+/*
+Package tlscert implements predicates to match the routes based on the
+attributes of the TLS client certificate of the requests, so that the
+routes can be restricted to specific client identities on mutual TLS
+listeners.
+
+The predicates only consider the client certificates verified during the
+TLS handshake, which requires a server TLS configuration with ClientCAs,
+and ClientAuth set to tls.VerifyClientCertIfGiven or
+tls.RequireAndVerifyClientCert, e.g. by the -tls-client-ca flag or in the
+ProxyTLS option. The requests without a verified client certificate don't
+match.
+
+Package includes three predicates:
+
+ClientCertCN matches, when the subject common name of the certificate is
+equal to one of the arguments.
+
+ClientCertSAN matches, when one of the subject alternative names of the
+certificate, the DNS names, email addresses, IP addresses or URIs, is
+equal to one of the arguments.
+
+ClientCertIssuer matches, when the issuer common name of the certificate
+is equal to one of the arguments.
+
+Examples:
+
+	orders: ClientCertCN("orders-service") && Path("/orders") -> "https://orders.example.org";
+	partner: ClientCertSAN("spiffe://example.org/partner") -> "https://partner.example.org";
+	internal: ClientCertIssuer("Example Internal CA") -> "https://internal.example.org";
+*/
+package tlscert
+
+import (
+	"crypto/x509"
+	"net/http"
+
+	"github.com/zalando/skipper/predicates"
+	"github.com/zalando/skipper/routing"
+)
+
+const (
+	CNName     = "ClientCertCN"
+	SANName    = "ClientCertSAN"
+	IssuerName = "ClientCertIssuer"
+)
+
+type attribute int
+
+const (
+	cn attribute = iota
+	san
+	issuer
+)
+
+type (
+	spec struct {
+		attribute attribute
+	}
+
+	predicate struct {
+		attribute attribute
+		values    map[string]bool
+	}
+)
+
+// NewCN creates the spec of the ClientCertCN predicate.
+func NewCN() routing.PredicateSpec { return &spec{attribute: cn} }
+
+// NewSAN creates the spec of the ClientCertSAN predicate.
+func NewSAN() routing.PredicateSpec { return &spec{attribute: san} }
+
+// NewIssuer creates the spec of the ClientCertIssuer predicate.
+func NewIssuer() routing.PredicateSpec { return &spec{attribute: issuer} }
+
+func (s *spec) Name() string {
+	switch s.attribute {
+	case san:
+		return SANName
+	case issuer:
+		return IssuerName
+	default:
+		return CNName
+	}
+}
+
+func (s *spec) Create(args []interface{}) (routing.Predicate, error) {
+	if len(args) == 0 {
+		return nil, predicates.ErrInvalidPredicateParameters
+	}
+
+	p := &predicate{attribute: s.attribute, values: make(map[string]bool)}
+	for _, a := range args {
+		v, ok := a.(string)
+		if !ok || v == "" {
+			return nil, predicates.ErrInvalidPredicateParameters
+		}
+
+		p.values[v] = true
+	}
+
+	return p, nil
+}
+
+// clientCert returns the verified client certificate of the request
+func clientCert(r *http.Request) *x509.Certificate {
+	if r.TLS == nil || len(r.TLS.VerifiedChains) == 0 || len(r.TLS.VerifiedChains[0]) == 0 {
+		return nil
+	}
+
+	return r.TLS.VerifiedChains[0][0]
+}
+
+func (p *predicate) matchSAN(c *x509.Certificate) bool {
+	for _, n := range c.DNSNames {
+		if p.values[n] {
+			return true
+		}
+	}
+
+	for _, e := range c.EmailAddresses {
+		if p.values[e] {
+			return true
+		}
+	}
+
+	for _, ip := range c.IPAddresses {
+		if p.values[ip.String()] {
+			return true
+		}
+	}
+
+	for _, u := range c.URIs {
+		if p.values[u.String()] {
+			return true
+		}
+	}
+
+	return false
+}
+
+func (p *predicate) Match(r *http.Request) bool {
+	c := clientCert(r)
+	if c == nil {
+		return false
+	}
+
+	switch p.attribute {
+	case san:
+		return p.matchSAN(c)
+	case issuer:
+		return p.values[c.Issuer.CommonName]
+	default:
+		return p.values[c.Subject.CommonName]
+	}
+}
@@ -0,0 +1,158 @@
+package tlscert
+
+import (
+	"crypto/tls"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"net"
+	"net/http"
+	"net/url"
+	"testing"
+
+	"github.com/zalando/skipper/routing"
+)
+
+func TestCreate(t *testing.T) {
+	for _, ti := range []struct {
+		msg  string
+		args []interface{}
+		err  bool
+	}{{
+		"no args",
+		nil,
+		true,
+	}, {
+		"arg not string",
+		[]interface{}{1.0},
+		true,
+	}, {
+		"empty arg",
+		[]interface{}{""},
+		true,
+	}, {
+		"valid args",
+		[]interface{}{"foo", "bar"},
+		false,
+	}} {
+		t.Run(ti.msg, func(t *testing.T) {
+			for _, s := range []routing.PredicateSpec{NewCN(), NewSAN(), NewIssuer()} {
+				_, err := s.Create(ti.args)
+				if ti.err && err == nil {
+					t.Error(s.Name(), "failed to fail")
+				} else if !ti.err && err != nil {
+					t.Error(s.Name(), err)
+				}
+			}
+		})
+	}
+}
+
+func TestMatch(t *testing.T) {
+	spiffe, err := url.Parse("spiffe://example.org/orders")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	cert := &x509.Certificate{
+		Subject:        pkix.Name{CommonName: "orders-service"},
+		Issuer:         pkix.Name{CommonName: "Example Internal CA"},
+		DNSNames:       []string{"orders.example.org"},
+		EmailAddresses: []string{"orders@example.org"},
+		IPAddresses:    []net.IP{net.IPv4(10, 0, 0, 1)},
+		URIs:           []*url.URL{spiffe},
+	}
+
+	verified := &tls.ConnectionState{
+		PeerCertificates: []*x509.Certificate{cert},
+		VerifiedChains:   [][]*x509.Certificate{{cert}},
+	}
+
+	unverified := &tls.ConnectionState{
+		PeerCertificates: []*x509.Certificate{cert},
+	}
+
+	for _, ti := range []struct {
+		msg   string
+		spec  routing.PredicateSpec
+		args  []interface{}
+		tls   *tls.ConnectionState
+		match bool
+	}{{
+		"no TLS",
+		NewCN(),
+		[]interface{}{"orders-service"},
+		nil,
+		false,
+	}, {
+		"unverified certificate",
+		NewCN(),
+		[]interface{}{"orders-service"},
+		unverified,
+		false,
+	}, {
+		"common name",
+		NewCN(),
+		[]interface{}{"payments-service", "orders-service"},
+		verified,
+		true,
+	}, {
+		"different common name",
+		NewCN(),
+		[]interface{}{"payments-service"},
+		verified,
+		false,
+	}, {
+		"DNS name",
+		NewSAN(),
+		[]interface{}{"orders.example.org"},
+		verified,
+		true,
+	}, {
+		"email address",
+		NewSAN(),
+		[]interface{}{"orders@example.org"},
+		verified,
+		true,
+	}, {
+		"IP address",
+		NewSAN(),
+		[]interface{}{"10.0.0.1"},
+		verified,
+		true,
+	}, {
+		"URI",
+		NewSAN(),
+		[]interface{}{"spiffe://example.org/orders"},
+		verified,
+		true,
+	}, {
+		"different SAN",
+		NewSAN(),
+		[]interface{}{"orders-service"},
+		verified,
+		false,
+	}, {
+		"issuer",
+		NewIssuer(),
+		[]interface{}{"Example Internal CA"},
+		verified,
+		true,
+	}, {
+		"different issuer",
+		NewIssuer(),
+		[]interface{}{"orders-service"},
+		verified,
+		false,
+	}} {
+		t.Run(ti.msg, func(t *testing.T) {
+			p, err := ti.spec.Create(ti.args)
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			if m := p.Match(&http.Request{TLS: ti.tls}); m != ti.match {
+				t.Error("failed to match", m, ti.match)
+			}
+		})
+	}
+}
@@ -0,0 +1,87 @@
+package traffic
+
+import (
+	"net/http"
+	"strings"
+
+	"github.com/zalando/skipper/predicates"
+	"github.com/zalando/skipper/routing"
+)
+
+// The eskip name of the segment predicate.
+const SegmentPredicateName = "TrafficSegment"
+
+const (
+	segmentSourceHeaderPrefix = "header:"
+	segmentSourceCookiePrefix = "cookie:"
+)
+
+type segmentSpec struct{}
+
+type segmentPredicate struct {
+	min, max float64
+	header   string
+	cookie   string
+}
+
+// NewSegment creates a traffic control predicate specification, that
+// matches the requests whose header or cookie value hashes into the
+// segment between the first two arguments.
+func NewSegment() routing.PredicateSpec { return &segmentSpec{} }
+
+func (s *segmentSpec) Name() string { return SegmentPredicateName }
+
+func (s *segmentSpec) Create(args []interface{}) (routing.Predicate, error) {
+	if len(args) != 3 {
+		return nil, predicates.ErrInvalidPredicateParameters
+	}
+
+	min, ok := args[0].(float64)
+	if !ok || min < 0 || min >= 1 {
+		return nil, predicates.ErrInvalidPredicateParameters
+	}
+
+	max, ok := args[1].(float64)
+	if !ok || max <= min || max > 1 {
+		return nil, predicates.ErrInvalidPredicateParameters
+	}
+
+	source, ok := args[2].(string)
+	if !ok {
+		return nil, predicates.ErrInvalidPredicateParameters
+	}
+
+	p := &segmentPredicate{min: min, max: max}
+	switch {
+	case strings.HasPrefix(source, segmentSourceHeaderPrefix) && len(source) > len(segmentSourceHeaderPrefix):
+		p.header = source[len(segmentSourceHeaderPrefix):]
+	case strings.HasPrefix(source, segmentSourceCookiePrefix) && len(source) > len(segmentSourceCookiePrefix):
+		p.cookie = source[len(segmentSourceCookiePrefix):]
+	default:
+		return nil, predicates.ErrInvalidPredicateParameters
+	}
+
+	return p, nil
+}
+
+func (p *segmentPredicate) value(r *http.Request) string {
+	if p.header != "" {
+		return r.Header.Get(p.header)
+	}
+
+	if c, err := r.Cookie(p.cookie); err == nil {
+		return c.Value
+	}
+
+	return ""
+}
+
+func (p *segmentPredicate) Match(r *http.Request) bool {
+	v := p.value(r)
+	if v == "" {
+		return false
+	}
+
+	x := position("", v)
+	return p.min <= x && x < p.max
+}
@@ -0,0 +1,128 @@
+package traffic
+
+import (
+	"fmt"
+	"net/http"
+	"testing"
+)
+
+func TestCreateSegment(t *testing.T) {
+	for _, ti := range []struct {
+		msg   string
+		args  []interface{}
+		check segmentPredicate
+		err   bool
+	}{{
+		msg: "no args",
+		err: true,
+	}, {
+		msg:  "missing source",
+		args: []interface{}{0.0, .25},
+		err:  true,
+	}, {
+		msg:  "min not number",
+		args: []interface{}{"0", .25, "header:X-User-Id"},
+		err:  true,
+	}, {
+		msg:  "negative min",
+		args: []interface{}{-.1, .25, "header:X-User-Id"},
+		err:  true,
+	}, {
+		msg:  "max not greater than min",
+		args: []interface{}{.25, .25, "header:X-User-Id"},
+		err:  true,
+	}, {
+		msg:  "max greater than 1",
+		args: []interface{}{.25, 1.1, "header:X-User-Id"},
+		err:  true,
+	}, {
+		msg:  "invalid source",
+		args: []interface{}{0.0, .25, "query:user"},
+		err:  true,
+	}, {
+		msg:  "empty header",
+		args: []interface{}{0.0, .25, "header:"},
+		err:  true,
+	}, {
+		msg:   "header",
+		args:  []interface{}{0.0, .25, "header:X-User-Id"},
+		check: segmentPredicate{min: 0, max: .25, header: "X-User-Id"},
+	}, {
+		msg:   "cookie",
+		args:  []interface{}{.75, 1.0, "cookie:session"},
+		check: segmentPredicate{min: .75, max: 1, cookie: "session"},
+	}} {
+		t.Run(ti.msg, func(t *testing.T) {
+			pi, err := NewSegment().Create(ti.args)
+			if ti.err {
+				if err == nil {
+					t.Error("failed to fail")
+				}
+
+				return
+			}
+
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			if p := pi.(*segmentPredicate); *p != ti.check {
+				t.Errorf("unexpected predicate, expected: %v, got: %v", ti.check, *p)
+			}
+		})
+	}
+}
+
+func TestMatchSegment(t *testing.T) {
+	const N = 10000
+	segments := []*segmentPredicate{
+		{min: 0, max: .25, header: "X-User-Id"},
+		{min: .25, max: .5, header: "X-User-Id"},
+		{min: .5, max: 1, header: "X-User-Id"},
+	}
+
+	counts := make([]int, len(segments))
+	for i := 0; i < N; i++ {
+		r := &http.Request{Header: http.Header{"X-User-Id": []string{fmt.Sprintf("user-%d", i)}}}
+		matched := -1
+		for j, s := range segments {
+			if !s.Match(r) {
+				continue
+			}
+
+			if matched >= 0 {
+				t.Fatalf("overlapping segments for user-%d", i)
+			}
+
+			matched = j
+		}
+
+		if matched < 0 {
+			t.Fatalf("no segment for user-%d", i)
+		}
+
+		counts[matched]++
+	}
+
+	for i, expected := range []float64{.25, .25, .5} {
+		if counts[i] < int(N*(expected-.03)) || counts[i] > int(N*(expected+.03)) {
+			t.Errorf("unexpected number of matches in segment %d: %d", i, counts[i])
+		}
+	}
+
+	if segments[0].Match(&http.Request{Header: http.Header{}}) || segments[2].Match(&http.Request{Header: http.Header{}}) {
+		t.Error("unexpected match without the header")
+	}
+}
+
+func TestMatchSegmentCookie(t *testing.T) {
+	header := &segmentPredicate{min: 0, max: .5, header: "X-User-Id"}
+	cookie := &segmentPredicate{min: 0, max: .5, cookie: "user"}
+	for i := 0; i < 100; i++ {
+		id := fmt.Sprintf("user-%d", i)
+		r := &http.Request{Header: http.Header{"X-User-Id": []string{id}, "Cookie": []string{"user=" + id}}}
+		if header.Match(r) != cookie.Match(r) {
+			t.Fatalf("inconsistent cohorts of the header and the cookie for %s", id)
+		}
+	}
+}
@@ -66,10 +66,51 @@ independent traffic controlled route sets, which uses session stickiness:
         responseCookie("catalog-test", "default") ->
         "https://catalog";
 
+The TrafficHash predicate keeps the clients on the same route without
+a cookie. Instead of a random number, it compares the chance to the
+hash of a request header, e.g. a user or session ID, so the requests
+with the same header value always match the same route. The first
+argument is the chance, the second argument is the name of the header.
+The optional third argument is the traffic group, which is hashed
+together with the header value. Routes of the same route set having
+different traffic groups make independent decisions, similar to the
+Traffic predicate, while a client stays on the same route for as long
+as the chance doesn't change. Requests without the header match by a
+random chance.
+
+    // 10% of the users hit the canary, identified by X-User-Id
+    v2:
+        TrafficHash(.1, "X-User-Id") ->
+        "https://api-test-green";
+
+    v1:
+        "https://api-test-blue";
+
+The TrafficSegment predicate assigns the clients to consistent cohorts.
+It maps the value of a request header or cookie to a position in [0, 1),
+and it matches when the position is in the segment between the first,
+inclusive, and the second, exclusive, argument. The third argument is
+the source of the value, header:<name> or cookie:<name>. Since the
+position depends only on the value, a client belongs to the same cohort
+on every route using the same source, and routes with adjacent segments
+split the clients without overlap. Requests without the header or the
+cookie don't match.
+
+    // the first quarter of the sessions hit the new checkout
+    checkoutNew:
+        TrafficSegment(0, .25, "cookie:session") && Path("/checkout") ->
+        "https://checkout-new";
+
+    // the same cohort sees the new catalog
+    catalogNew:
+        TrafficSegment(0, .25, "cookie:session") && Path("/catalog") ->
+        "https://catalog-new";
+
 */
 package traffic
 
 import (
+	"hash/fnv"
 	"math/rand"
 	"net/http"
 
@@ -80,6 +121,9 @@ import (
 const (
 	// The eskip name of the predicate.
 	PredicateName = "Traffic"
+
+	// The eskip name of the header hash based predicate.
+	HashPredicateName = "TrafficHash"
 )
 
 type spec struct{}
@@ -139,3 +183,74 @@ func (p *predicate) Match(r *http.Request) bool {
 		return p.takeChance()
 	}
 }
+
+type hashSpec struct{}
+
+type hashPredicate struct {
+	chance       float64
+	header       string
+	trafficGroup string
+}
+
+// NewHash creates a traffic control predicate specification, that
+// matches based on the hash of a request header.
+func NewHash() routing.PredicateSpec { return &hashSpec{} }
+
+func (s *hashSpec) Name() string { return HashPredicateName }
+
+func (s *hashSpec) Create(args []interface{}) (routing.Predicate, error) {
+	if len(args) < 2 || len(args) > 3 {
+		return nil, predicates.ErrInvalidPredicateParameters
+	}
+
+	p := &hashPredicate{}
+
+	if c, ok := args[0].(float64); ok && 0.0 <= c && c < 1.0 {
+		p.chance = c
+	} else {
+		return nil, predicates.ErrInvalidPredicateParameters
+	}
+
+	if h, ok := args[1].(string); ok && h != "" {
+		p.header = h
+	} else {
+		return nil, predicates.ErrInvalidPredicateParameters
+	}
+
+	if len(args) == 3 {
+		if tg, ok := args[2].(string); ok {
+			p.trafficGroup = tg
+		} else {
+			return nil, predicates.ErrInvalidPredicateParameters
+		}
+	}
+
+	return p, nil
+}
+
+// position maps the value and the traffic group to [0, 1)
+func position(trafficGroup, value string) float64 {
+	h := fnv.New64a()
+	h.Write([]byte(trafficGroup))
+	h.Write([]byte{0})
+	h.Write([]byte(value))
+
+	// FNV mixes the last bytes poorly into the high bits, so the
+	// result is finalized like in MurmurHash3
+	x := h.Sum64()
+	x ^= x >> 33
+	x *= 0xff51afd7ed558ccd
+	x ^= x >> 33
+	x *= 0xc4ceb9fe1a85ec53
+	x ^= x >> 33
+	return float64(x>>11) / (1 << 53)
+}
+
+func (p *hashPredicate) Match(r *http.Request) bool {
+	v := r.Header.Get(p.header)
+	if v == "" {
+		return rand.Float64() < p.chance
+	}
+
+	return position(p.trafficGroup, v) < p.chance
+}
@@ -1,6 +1,7 @@
 package traffic
 
 import (
+	"fmt"
 	"net/http"
 	"testing"
 
@@ -225,3 +226,111 @@ func TestTrafficPredicateInRoutes(t *testing.T) {
 		})
 	}
 }
+
+func TestCreateHash(t *testing.T) {
+	for _, ti := range []struct {
+		msg   string
+		args  []interface{}
+		check hashPredicate
+		err   bool
+	}{{
+		msg: "no args",
+		err: true,
+	}, {
+		msg:  "only chance",
+		args: []interface{}{.3},
+		err:  true,
+	}, {
+		msg:  "too many args",
+		args: []interface{}{.3, "X-User-Id", "A", "B"},
+		err:  true,
+	}, {
+		msg:  "wrong chance",
+		args: []interface{}{1.3, "X-User-Id"},
+		err:  true,
+	}, {
+		msg:  "header not string",
+		args: []interface{}{.3, .2},
+		err:  true,
+	}, {
+		msg:  "empty header",
+		args: []interface{}{.3, ""},
+		err:  true,
+	}, {
+		msg:  "group not string",
+		args: []interface{}{.3, "X-User-Id", .2},
+		err:  true,
+	}, {
+		msg:   "chance and header",
+		args:  []interface{}{.3, "X-User-Id"},
+		check: hashPredicate{chance: .3, header: "X-User-Id"},
+	}, {
+		msg:   "chance, header and group",
+		args:  []interface{}{.3, "X-User-Id", "A"},
+		check: hashPredicate{chance: .3, header: "X-User-Id", trafficGroup: "A"},
+	}} {
+		t.Run(ti.msg, func(t *testing.T) {
+			pi, err := NewHash().Create(ti.args)
+			if ti.err {
+				if err == nil {
+					t.Error("failed to fail")
+				}
+
+				return
+			}
+
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			if p := pi.(*hashPredicate); *p != ti.check {
+				t.Errorf("unexpected predicate, expected: %v, got: %v", ti.check, *p)
+			}
+		})
+	}
+}
+
+func TestMatchHash(t *testing.T) {
+	const N = 10000
+	request := func(id string) *http.Request {
+		return &http.Request{Header: http.Header{"X-User-Id": []string{id}}}
+	}
+
+	p := &hashPredicate{chance: .3, header: "X-User-Id"}
+	other := &hashPredicate{chance: .3, header: "X-User-Id", trafficGroup: "B"}
+	matched, independent := 0, 0
+	for i := 0; i < N; i++ {
+		r := request(fmt.Sprintf("user-%d", i))
+		m := p.Match(r)
+		for j := 0; j < 3; j++ {
+			if p.Match(r) != m {
+				t.Fatalf("client switched route: user-%d", i)
+			}
+		}
+
+		if m {
+			matched++
+		}
+
+		if !m && other.Match(r) {
+			independent++
+		}
+	}
+
+	if matched < N*.27 || matched > N*.33 {
+		t.Errorf("unexpected number of matches: %d", matched)
+	}
+
+	// the other group matches 30% of the remaining 70%
+	if independent < N*.18 || independent > N*.24 {
+		t.Errorf("unexpected number of matches in the other group: %d", independent)
+	}
+
+	if (&hashPredicate{chance: 0, header: "X-User-Id"}).Match(request("user")) {
+		t.Error("unexpected match with zero chance")
+	}
+
+	if (&hashPredicate{chance: 0, header: "X-User-Id"}).Match(&http.Request{Header: http.Header{}}) {
+		t.Error("unexpected match without the header")
+	}
+}
@@ -0,0 +1,91 @@
+package proxy
+
+import (
+	"crypto/tls"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/zalando/skipper/filters"
+	"github.com/zalando/skipper/filters/builtin"
+)
+
+type clientCertSpec struct {
+	cert *tls.Certificate
+}
+
+func (s *clientCertSpec) Name() string { return "testClientCert" }
+
+func (s *clientCertSpec) CreateFilter([]interface{}) (filters.Filter, error) { return s, nil }
+
+func (s *clientCertSpec) Request(ctx filters.FilterContext) {
+	ctx.StateBag()[filters.BackendClientCertificateKey] = s.cert
+}
+
+func (s *clientCertSpec) Response(filters.FilterContext) {}
+
+func TestBackendClientCertificate(t *testing.T) {
+	cert, err := tls.LoadX509KeyPair("../fixtures/test.crt", "../fixtures/test.key")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	backend := httptest.NewUnstartedServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		fmt.Fprintf(w, "%d", len(r.TLS.PeerCertificates))
+	}))
+	backend.TLS = &tls.Config{ClientAuth: tls.RequireAnyClientCert}
+	backend.StartTLS()
+	defer backend.Close()
+
+	fr := builtin.MakeRegistry()
+	fr.Register(&clientCertSpec{cert: &cert})
+
+	doc := fmt.Sprintf(`
+		mtls: Path("/mtls") -> testClientCert() -> "%s";
+		plain: Path("/plain") -> "%s";
+	`, backend.URL, backend.URL)
+	tp, err := newTestProxyWithFilters(fr, doc, Insecure)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	defer tp.close()
+
+	ps := httptest.NewServer(tp.proxy)
+	defer ps.Close()
+
+	for _, tt := range []struct {
+		path         string
+		expectedCode int
+		expectedBody string
+	}{
+		{"/mtls", http.StatusOK, "1"},
+		{"/plain", http.StatusServiceUnavailable, ""},
+		{"/mtls", http.StatusOK, "1"},
+	} {
+		rsp, err := http.Get(ps.URL + tt.path)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		b, err := ioutil.ReadAll(rsp.Body)
+		rsp.Body.Close()
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		if rsp.StatusCode != tt.expectedCode {
+			t.Errorf("%s: expected status %d, got %d", tt.path, tt.expectedCode, rsp.StatusCode)
+		}
+
+		if tt.expectedCode == http.StatusOK && string(b) != tt.expectedBody {
+			t.Errorf("%s: unexpected body: %s", tt.path, b)
+		}
+	}
+
+	if n := len(tp.proxy.backendTransports.transports); n != 1 {
+		t.Errorf("expected one client certificate transport, got %d", n)
+	}
+}
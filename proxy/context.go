@@ -36,6 +36,8 @@ type context struct {
 	debugFilterPanics    []interface{}
 	outgoingDebugRequest *http.Request
 	loopCounter          int
+	failedEndpoints      map[string]bool
+	ratelimitHeader      http.Header
 	startServe           time.Time
 	metrics              *filterMetrics
 	tracer               opentracing.Tracer
@@ -153,6 +155,9 @@ func (c *context) applyRoute(route *routing.Route, params map[string]string, pre
 	}
 
 	c.pathParams = appendParams(c.pathParams, params)
+	for name, value := range route.HeaderRegexpCaptures(c.request.Header) {
+		c.stateBag[filters.HeaderRegexpCapturePrefix+name] = value
+	}
 }
 
 func (c *context) ensureDefaultResponse() {
@@ -192,6 +197,7 @@ func (c *context) MarkServed()                         { c.deprecatedServed = tr
 func (c *context) Served() bool                        { return c.deprecatedServed || c.servedWithResponse }
 func (c *context) PathParam(key string) string         { return c.pathParams[key] }
 func (c *context) StateBag() map[string]interface{}    { return c.stateBag }
+func (c *context) RouteId() string                     { return c.route.Id }
 func (c *context) BackendUrl() string                  { return c.route.Backend }
 func (c *context) OriginalRequest() *http.Request      { return c.originalRequest }
 func (c *context) OriginalResponse() *http.Response    { return c.originalResponse }
@@ -0,0 +1,93 @@
+package proxy
+
+import (
+	"errors"
+	"io"
+	"net"
+	"net/http"
+	"sync"
+	"time"
+
+	"github.com/zalando/skipper/filters"
+	snet "github.com/zalando/skipper/net"
+)
+
+var errServerSentEventsIdle = errors.New("server-sent events stream idle timeout")
+
+func isServerSentEvents(bag map[string]interface{}) bool {
+	_, ok := bag[filters.ServerSentEventsKey]
+	return ok
+}
+
+// serverSentEvents tells whether the response is a stream of Server-Sent
+// Events, either marked by a filter, or by the content type of the
+// response, and returns the idle timeout of the stream.
+func (p *Proxy) serverSentEvents(ctx *context) (time.Duration, bool) {
+	d, marked := ctx.StateBag()[filters.ServerSentEventsKey].(time.Duration)
+	if !marked && !snet.IsEventStream(ctx.response) {
+		return 0, false
+	}
+
+	if d <= 0 {
+		d = p.serverSentEventsIdle
+	}
+
+	return d, true
+}
+
+// disableConnTimeouts clears the read and write deadlines set by the
+// server on an HTTP/1 client connection, so that the stream is not
+// interrupted by the server timeouts. HTTP/2 connections are shared by
+// multiple streams, and they are left untouched.
+func disableConnTimeouts(r *http.Request) {
+	if r.ProtoMajor != 1 {
+		return
+	}
+
+	if conn, ok := r.Context().Value(connKey{}).(net.Conn); ok {
+		conn.SetDeadline(time.Time{})
+	}
+}
+
+// idleTimeoutBody closes the response body of the backend, when no data
+// was received within the idle timeout.
+type idleTimeoutBody struct {
+	body    io.ReadCloser
+	timeout time.Duration
+	timer   *time.Timer
+	mu      sync.Mutex
+	idle    bool
+}
+
+func newIdleTimeoutBody(body io.ReadCloser, timeout time.Duration) *idleTimeoutBody {
+	b := &idleTimeoutBody{body: body, timeout: timeout}
+	b.timer = time.AfterFunc(timeout, b.expire)
+	return b
+}
+
+func (b *idleTimeoutBody) expire() {
+	b.mu.Lock()
+	b.idle = true
+	b.mu.Unlock()
+	b.body.Close()
+}
+
+func (b *idleTimeoutBody) Read(p []byte) (int, error) {
+	n, err := b.body.Read(p)
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	if b.idle {
+		return 0, errServerSentEventsIdle
+	}
+
+	if n > 0 {
+		b.timer.Reset(b.timeout)
+	}
+
+	return n, err
+}
+
+func (b *idleTimeoutBody) Close() error {
+	b.timer.Stop()
+	return b.body.Close()
+}
@@ -0,0 +1,175 @@
+package proxy
+
+import (
+	"bufio"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+)
+
+// eventStreamBackend sends an event, and then waits until the client
+// goes away, or sends the next event after the delay
+func eventStreamBackend(delay time.Duration, contentType string) *httptest.Server {
+	return httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", contentType)
+		w.WriteHeader(http.StatusOK)
+		for i := 0; ; i++ {
+			if _, err := fmt.Fprintf(w, "data: %d\n\n", i); err != nil {
+				return
+			}
+
+			w.(http.Flusher).Flush()
+			select {
+			case <-time.After(delay):
+			case <-r.Context().Done():
+				return
+			}
+		}
+	}))
+}
+
+func readEvent(t *testing.T, r *bufio.Reader) string {
+	line, err := r.ReadString('\n')
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if _, err := r.ReadString('\n'); err != nil {
+		t.Fatal(err)
+	}
+
+	return strings.TrimSpace(line)
+}
+
+func TestServerSentEvents(t *testing.T) {
+	for _, ti := range []struct {
+		msg         string
+		filters     string
+		contentType string
+	}{{
+		msg:         "detected by the content type",
+		contentType: "text/event-stream; charset=utf-8",
+	}, {
+		msg:         "marked by the filter",
+		filters:     "serverSentEvents() ->",
+		contentType: "text/plain",
+	}} {
+		t.Run(ti.msg, func(t *testing.T) {
+			backend := eventStreamBackend(100*time.Millisecond, ti.contentType)
+			defer backend.Close()
+
+			// the flush interval and the backend timeout would both stall
+			// and interrupt the stream
+			tp, err := newTestProxyWithParams(
+				fmt.Sprintf(`* -> %s backendTimeout("50ms") -> "%s"`, ti.filters, backend.URL),
+				Params{ResponseFlushInterval: time.Hour},
+			)
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			defer tp.close()
+			ps := httptest.NewServer(tp.proxy)
+			defer ps.Close()
+
+			req, err := http.NewRequest("GET", ps.URL, nil)
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			rsp, err := http.DefaultClient.Do(req)
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			defer rsp.Body.Close()
+			r := bufio.NewReader(rsp.Body)
+			for i := 0; i < 3; i++ {
+				if e := readEvent(t, r); e != fmt.Sprintf("data: %d", i) {
+					t.Fatalf("unexpected event: %s", e)
+				}
+			}
+		})
+	}
+}
+
+func TestServerSentEventsNotMarkedByTheClient(t *testing.T) {
+	backend := eventStreamBackend(100*time.Millisecond, "text/plain")
+	defer backend.Close()
+
+	tp, err := newTestProxy(fmt.Sprintf(`* -> backendTimeout("50ms") -> "%s"`, backend.URL), FlagsNone)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	defer tp.close()
+	ps := httptest.NewServer(tp.proxy)
+	defer ps.Close()
+
+	req, err := http.NewRequest("GET", ps.URL, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	req.Header.Set("Accept", "text/event-stream")
+	rsp, err := (&http.Client{Timeout: time.Second}).Do(req)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	defer rsp.Body.Close()
+
+	// the backend timeout interrupts the stream after the first event
+	b, _ := ioutil.ReadAll(rsp.Body)
+	if n := strings.Count(string(b), "data:"); n != 1 {
+		t.Errorf("the backend timeout was disabled by the client, received events: %d", n)
+	}
+}
+
+func TestServerSentEventsIdleTimeout(t *testing.T) {
+	backend := eventStreamBackend(time.Hour, "text/event-stream")
+	defer backend.Close()
+
+	tp, err := newTestProxyWithParams(
+		fmt.Sprintf(`* -> "%s"`, backend.URL),
+		Params{ServerSentEventsIdleTimeout: 100 * time.Millisecond},
+	)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	defer tp.close()
+	ps := httptest.NewServer(tp.proxy)
+	defer ps.Close()
+
+	start := time.Now()
+	rsp, err := http.Get(ps.URL)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	defer rsp.Body.Close()
+	r := bufio.NewReader(rsp.Body)
+	if e := readEvent(t, r); e != "data: 0" {
+		t.Fatalf("unexpected event: %s", e)
+	}
+
+	done := make(chan struct{})
+	go func() {
+		r.ReadString('\n')
+		close(done)
+	}()
+
+	select {
+	case <-done:
+		if d := time.Since(start); d < 100*time.Millisecond {
+			t.Errorf("stream closed too early: %v", d)
+		}
+	case <-time.After(3 * time.Second):
+		t.Fatal("idle stream not closed")
+	}
+}
@@ -0,0 +1,165 @@
+package proxy
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+)
+
+type countingFlusher struct {
+	mu      sync.Mutex
+	header  http.Header
+	body    strings.Builder
+	flushes int
+}
+
+func (w *countingFlusher) Header() http.Header {
+	if w.header == nil {
+		w.header = make(http.Header)
+	}
+
+	return w.header
+}
+
+func (w *countingFlusher) WriteHeader(int) {}
+
+func (w *countingFlusher) Write(p []byte) (int, error) {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	return w.body.Write(p)
+}
+
+func (w *countingFlusher) Flush() {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	w.flushes++
+}
+
+func (w *countingFlusher) count() int {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	return w.flushes
+}
+
+func TestCopyStreamFlushInterval(t *testing.T) {
+	for _, tt := range []struct {
+		msg      string
+		interval time.Duration
+		check    func(int) bool
+	}{{
+		msg:      "flush on every read",
+		interval: 0,
+		check:    func(n int) bool { return n == 3 },
+	}, {
+		msg:      "negative interval flushes on every read",
+		interval: -1,
+		check:    func(n int) bool { return n == 3 },
+	}, {
+		msg:      "long interval flushes only at the end",
+		interval: time.Hour,
+		check:    func(n int) bool { return n == 1 },
+	}} {
+		t.Run(tt.msg, func(t *testing.T) {
+			pr, pw := io.Pipe()
+			go func() {
+				for _, s := range []string{"foo", "bar", "baz"} {
+					pw.Write([]byte(s))
+				}
+
+				pw.Close()
+			}()
+
+			w := &countingFlusher{}
+			if err := copyStream(w, pr, tt.interval, newProxyTracing(nil), nil); err != nil {
+				t.Fatal(err)
+			}
+
+			if w.body.String() != "foobarbaz" {
+				t.Errorf("unexpected body: %s", w.body.String())
+			}
+
+			if n := w.count(); !tt.check(n) {
+				t.Errorf("unexpected number of flushes: %d", n)
+			}
+		})
+	}
+}
+
+func TestCopyStreamFlushesPeriodically(t *testing.T) {
+	pr, pw := io.Pipe()
+	defer pw.Close()
+
+	w := &countingFlusher{}
+	done := make(chan error, 1)
+	go func() { done <- copyStream(w, pr, 10*time.Millisecond, newProxyTracing(nil), nil) }()
+
+	pw.Write([]byte("foo"))
+	pw.Write([]byte("bar"))
+
+	timeout := time.After(time.Second)
+	for w.count() == 0 {
+		select {
+		case <-timeout:
+			t.Fatal("response was not flushed")
+		case <-time.After(time.Millisecond):
+		}
+	}
+
+	pw.Close()
+	if err := <-done; err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestFlushIntervalFilterOverridesDefault(t *testing.T) {
+	next := make(chan struct{})
+	backend := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte("foo\n"))
+		w.(http.Flusher).Flush()
+		<-next
+		w.Write([]byte("bar\n"))
+	}))
+	defer backend.Close()
+
+	doc := fmt.Sprintf(`* -> flushInterval(0) -> "%s"`, backend.URL)
+	tp, err := newTestProxyWithParams(doc, Params{ResponseFlushInterval: time.Hour})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	defer tp.close()
+
+	ps := httptest.NewServer(tp.proxy)
+	defer ps.Close()
+
+	rsp, err := http.Get(ps.URL)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	defer rsp.Body.Close()
+
+	line := make(chan string, 1)
+	br := bufio.NewReader(rsp.Body)
+	go func() {
+		l, _ := br.ReadString('\n')
+		line <- l
+	}()
+
+	select {
+	case l := <-line:
+		if l != "foo\n" {
+			t.Errorf("unexpected line: %q", l)
+		}
+	case <-time.After(time.Second):
+		t.Error("first part of the response was not flushed")
+	}
+
+	close(next)
+}
@@ -0,0 +1,124 @@
+package proxy
+
+import (
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/zalando/skipper/metrics"
+	"golang.org/x/net/http2"
+	"golang.org/x/net/http2/h2c"
+)
+
+type grpcStatusMetrics struct {
+	metrics.Metrics
+	mu       sync.Mutex
+	statuses []string
+}
+
+func (m *grpcStatusMetrics) MeasureGRPCResponse(grpcStatus string, routeId string, start time.Time) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.statuses = append(m.statuses, routeId+":"+grpcStatus)
+}
+
+func TestAcceptsTrailers(t *testing.T) {
+	for _, tt := range []struct {
+		te       []string
+		expected bool
+	}{
+		{nil, false},
+		{[]string{"gzip"}, false},
+		{[]string{"trailers"}, true},
+		{[]string{"gzip, Trailers"}, true},
+		{[]string{"gzip", "trailers"}, true},
+	} {
+		h := http.Header{"Te": tt.te}
+		if got := acceptsTrailers(h); got != tt.expected {
+			t.Errorf("%v: expected %v, got %v", tt.te, tt.expected, got)
+		}
+	}
+}
+
+func TestGRPCPassthrough(t *testing.T) {
+	backend := httptest.NewServer(h2c.NewHandler(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.ProtoMajor != 2 {
+			t.Errorf("expected HTTP/2, got: %s", r.Proto)
+		}
+
+		if te := r.Header.Get("Te"); te != "trailers" {
+			t.Errorf("expected te: trailers, got: %q", te)
+		}
+
+		w.Header().Set("Content-Type", "application/grpc")
+		w.Header().Set("Trailer", "Grpc-Status")
+		w.WriteHeader(http.StatusOK)
+		w.Write([]byte("message"))
+		w.Header().Set("Grpc-Status", "5")
+		w.Header().Set(http.TrailerPrefix+"Grpc-Message", "not found")
+	}), &http2.Server{}))
+	defer backend.Close()
+
+	u, err := url.Parse(backend.URL)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	tp, err := newTestProxyWithParams(
+		fmt.Sprintf(`grpc: * -> "h2c://%s"`, u.Host),
+		Params{Flags: HopHeadersRemoval},
+	)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	defer tp.close()
+
+	m := &grpcStatusMetrics{Metrics: metrics.Void}
+	tp.proxy.metrics = m
+
+	ps := httptest.NewServer(tp.proxy)
+	defer ps.Close()
+
+	req, err := http.NewRequest("POST", ps.URL+"/helloworld.Greeter/SayHello", nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	req.Header.Set("Content-Type", "application/grpc")
+	req.Header.Set("Te", "trailers")
+	rsp, err := http.DefaultClient.Do(req)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	defer rsp.Body.Close()
+
+	b, err := ioutil.ReadAll(rsp.Body)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if string(b) != "message" {
+		t.Errorf("unexpected body: %s", b)
+	}
+
+	if s := rsp.Trailer.Get("Grpc-Status"); s != "5" {
+		t.Errorf("unexpected grpc-status trailer: %q", s)
+	}
+
+	if s := rsp.Trailer.Get("Grpc-Message"); s != "not found" {
+		t.Errorf("unexpected grpc-message trailer: %q", s)
+	}
+
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	if len(m.statuses) != 1 || m.statuses[0] != "grpc:5" {
+		t.Errorf("unexpected grpc status metrics: %v", m.statuses)
+	}
+}
@@ -0,0 +1,44 @@
+package proxy
+
+import (
+	stdlibcontext "context"
+	"crypto/tls"
+	"net"
+	"net/http"
+
+	"golang.org/x/net/http2"
+)
+
+// h2cScheme is the backend scheme used for routes whose backends speak
+// HTTP/2 over cleartext connections, e.g. gRPC services without TLS.
+const h2cScheme = "h2c"
+
+// h2cRoundTripper forwards requests with the h2c scheme to the backends
+// over HTTP/2 with prior knowledge, without TLS.
+type h2cRoundTripper struct {
+	transport *http2.Transport
+}
+
+func newH2CRoundTripper(dial func(stdlibcontext.Context, string, string) (net.Conn, error)) *h2cRoundTripper {
+	return &h2cRoundTripper{
+		transport: &http2.Transport{
+			AllowHTTP: true,
+			DialTLS: func(network, addr string, _ *tls.Config) (net.Conn, error) {
+				return dial(stdlibcontext.Background(), network, addr)
+			},
+		},
+	}
+}
+
+func (rt *h2cRoundTripper) RoundTrip(req *http.Request) (*http.Response, error) {
+	u := *req.URL
+	u.Scheme = "http"
+
+	r := req.WithContext(req.Context())
+	r.URL = &u
+	return rt.transport.RoundTrip(r)
+}
+
+func (rt *h2cRoundTripper) CloseIdleConnections() {
+	rt.transport.CloseIdleConnections()
+}
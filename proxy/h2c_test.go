@@ -0,0 +1,67 @@
+package proxy
+
+import (
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"testing"
+
+	"golang.org/x/net/http2"
+	"golang.org/x/net/http2/h2c"
+)
+
+func TestH2CBackend(t *testing.T) {
+	backend := httptest.NewServer(h2c.NewHandler(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		fmt.Fprintf(w, "%s %s", r.Proto, r.URL.Path)
+	}), &http2.Server{}))
+	defer backend.Close()
+
+	u, err := url.Parse(backend.URL)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	for _, tt := range []struct {
+		msg string
+		doc string
+	}{{
+		msg: "network backend",
+		doc: fmt.Sprintf(`* -> "h2c://%s"`, u.Host),
+	}, {
+		msg: "load balanced backend",
+		doc: fmt.Sprintf(`* -> <roundRobin, "h2c://%s">`, u.Host),
+	}, {
+		msg: "backend with per route transport settings",
+		doc: fmt.Sprintf(`* -> backendDisableKeepAlives() -> "h2c://%s"`, u.Host),
+	}} {
+		t.Run(tt.msg, func(t *testing.T) {
+			tp, err := newTestProxy(tt.doc, FlagsNone)
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			defer tp.close()
+
+			ps := httptest.NewServer(tp.proxy)
+			defer ps.Close()
+
+			rsp, err := http.Get(ps.URL + "/foo")
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			defer rsp.Body.Close()
+
+			b, err := ioutil.ReadAll(rsp.Body)
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			if rsp.StatusCode != http.StatusOK || string(b) != "HTTP/2.0 /foo" {
+				t.Errorf("unexpected response: %d %s", rsp.StatusCode, b)
+			}
+		})
+	}
+}
@@ -7,10 +7,12 @@ import (
 	"net/http/httptest"
 	"strings"
 	"testing"
+	"time"
 
 	log "github.com/sirupsen/logrus"
 	"github.com/zalando/skipper/eskip"
 	"github.com/zalando/skipper/filters/builtin"
+	"github.com/zalando/skipper/proxy"
 	"github.com/zalando/skipper/proxy/proxytest"
 )
 
@@ -193,6 +195,91 @@ func TestConnectionRefused(t *testing.T) {
 	}
 }
 
+func TestConnectionRefusedRetriesNextEndpoint(t *testing.T) {
+	working := testBackend("working", 200)
+	defer working.Close()
+
+	failing1 := newFailingBackend()
+	failing2 := newFailingBackend()
+	failing1.Close()
+	failing2.Close()
+
+	for _, ti := range []struct {
+		msg        string
+		maxRetries int
+		filters    string
+		body       string
+		expectOK   bool
+	}{{
+		msg:        "default retries once",
+		maxRetries: 0,
+		expectOK:   false,
+	}, {
+		msg:        "retries disabled",
+		maxRetries: -1,
+		expectOK:   false,
+	}, {
+		msg:        "retries all endpoints",
+		maxRetries: 2,
+		expectOK:   true,
+	}, {
+		msg:        "does not retry requests with a body",
+		maxRetries: 2,
+		body:       "foo",
+		expectOK:   false,
+	}, {
+		msg:        "retries requests with a buffered body",
+		maxRetries: 2,
+		filters:    "bufferRequestBody(1024) ->",
+		body:       "foo",
+		expectOK:   true,
+	}} {
+		t.Run(ti.msg, func(t *testing.T) {
+			routes, err := eskip.Parse(fmt.Sprintf(
+				`* -> %s <roundRobin, "%s", "%s", "%s">`,
+				ti.filters,
+				failing1.url,
+				failing2.url,
+				working.URL,
+			))
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			p := proxytest.WithParams(builtin.MakeRegistry(), proxy.Params{
+				CloseIdleConnsPeriod: -time.Second,
+				MaxLBRetries:         ti.maxRetries,
+			}, routes...)
+			defer p.Close()
+
+			var failed int
+			for i := 0; i < 30; i++ {
+				var rsp *http.Response
+				if ti.body == "" {
+					rsp, err = http.Get(p.URL)
+				} else {
+					rsp, err = http.Post(p.URL, "text/plain", strings.NewReader(ti.body))
+				}
+
+				if err != nil {
+					t.Fatal(err)
+				}
+
+				rsp.Body.Close()
+				if rsp.StatusCode != http.StatusOK {
+					failed++
+				}
+			}
+
+			if ti.expectOK && failed > 0 {
+				t.Errorf("expected all requests to succeed, %d failed", failed)
+			} else if !ti.expectOK && failed == 0 {
+				t.Error("expected failing requests")
+			}
+		})
+	}
+}
+
 func BenchmarkConnectionRefusedA(b *testing.B) {
 	idx := 2
 	p, cs := setup()
@@ -0,0 +1,25 @@
+package proxy
+
+import (
+	"errors"
+	"net/http"
+
+	"github.com/zalando/skipper/filters"
+	snet "github.com/zalando/skipper/net"
+)
+
+var errRequestBodyTooLarge = errors.New("request body too large")
+
+// withMaxBody limits the size of the body of the backend request, based on
+// the limit set by filters in the state bag. The bodies with a known
+// length are checked by the filters, and the transport doesn't send more
+// than the Content-Length, so only the bodies of unknown length are
+// limited here.
+func withMaxBody(req *http.Request, bag map[string]interface{}) {
+	limit, _ := bag[filters.MaxRequestBodyKey].(int64)
+	if limit <= 0 || req.Body == nil || req.Body == http.NoBody || req.ContentLength >= 0 {
+		return
+	}
+
+	req.Body = snet.LimitBody(req.Body, limit, errRequestBodyTooLarge)
+}
@@ -0,0 +1,106 @@
+package proxy
+
+import (
+	"fmt"
+	"io"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+func TestMaxRequestBody(t *testing.T) {
+	backend := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		b, err := ioutil.ReadAll(r.Body)
+		if err != nil {
+			return
+		}
+
+		w.Write(b)
+	}))
+	defer backend.Close()
+
+	tp, err := newTestProxy(fmt.Sprintf(`* -> maxRequestBody(8) -> "%s"`, backend.URL), FlagsNone)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	defer tp.close()
+
+	ps := httptest.NewServer(tp.proxy)
+	defer ps.Close()
+
+	// the io.MultiReader hides the length of the body, so that it is sent
+	// with chunked transfer encoding
+	for _, tt := range []struct {
+		msg      string
+		body     io.Reader
+		expected int
+	}{{
+		msg:      "known length within the limit",
+		body:     strings.NewReader("foobar"),
+		expected: http.StatusOK,
+	}, {
+		msg:      "known length exceeding the limit",
+		body:     strings.NewReader("foobarbaz"),
+		expected: http.StatusRequestEntityTooLarge,
+	}, {
+		msg:      "unknown length within the limit",
+		body:     io.MultiReader(strings.NewReader("foo"), strings.NewReader("barba")),
+		expected: http.StatusOK,
+	}, {
+		msg:      "unknown length exceeding the limit",
+		body:     io.MultiReader(strings.NewReader("foo"), strings.NewReader(strings.Repeat("bar", 10000))),
+		expected: http.StatusRequestEntityTooLarge,
+	}} {
+		t.Run(tt.msg, func(t *testing.T) {
+			rsp, err := http.Post(ps.URL, "text/plain", tt.body)
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			defer rsp.Body.Close()
+			if rsp.StatusCode != tt.expected {
+				t.Errorf("unexpected status code: %d", rsp.StatusCode)
+			}
+		})
+	}
+}
+
+func TestMaxBody(t *testing.T) {
+	for _, tt := range []struct {
+		msg      string
+		body     string
+		limit    int64
+		expected error
+	}{{
+		msg:   "within the limit",
+		body:  "foo",
+		limit: 4,
+	}, {
+		msg:   "equal to the limit",
+		body:  "foobar",
+		limit: 6,
+	}, {
+		msg:      "exceeding the limit",
+		body:     strings.Repeat("foo", 20000),
+		limit:    50000,
+		expected: errRequestBodyTooLarge,
+	}} {
+		t.Run(tt.msg, func(t *testing.T) {
+			req := httptest.NewRequest("POST", "/", ioutil.NopCloser(strings.NewReader(tt.body)))
+			req.ContentLength = -1
+			withMaxBody(req, map[string]interface{}{"request:maxbody": tt.limit})
+
+			b, err := ioutil.ReadAll(req.Body)
+			if err != tt.expected {
+				t.Fatalf("expected error: %v, got: %v", tt.expected, err)
+			}
+
+			if err == nil && string(b) != tt.body {
+				t.Errorf("unexpected body: %s", string(b))
+			}
+		})
+	}
+}
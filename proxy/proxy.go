@@ -7,6 +7,7 @@ import (
 	"errors"
 	"fmt"
 	"io"
+	"io/ioutil"
 	"net"
 	"net/http"
 	"net/http/httputil"
@@ -14,6 +15,8 @@ import (
 	"os"
 	"runtime"
 	"strconv"
+	"strings"
+	"sync"
 	"time"
 
 	ot "github.com/opentracing/opentracing-go"
@@ -28,6 +31,7 @@ import (
 	"github.com/zalando/skipper/loadbalancer"
 	"github.com/zalando/skipper/logging"
 	"github.com/zalando/skipper/metrics"
+	snet "github.com/zalando/skipper/net"
 	"github.com/zalando/skipper/ratelimit"
 	"github.com/zalando/skipper/rfc"
 	"github.com/zalando/skipper/routing"
@@ -44,6 +48,9 @@ const (
 	// Number of loops allowed by default.
 	DefaultMaxLoopbacks = 9
 
+	// Number of retries of load balanced backends allowed by default.
+	DefaultMaxLBRetries = 1
+
 	// The default value set for http.Transport.MaxIdleConnsPerHost.
 	DefaultIdleConnsPerHost = 64
 
@@ -151,6 +158,11 @@ type Params struct {
 	// and the response messages during web socket upgrades.
 	ExperimentalUpgradeAudit bool
 
+	// WebSocketIdleTimeout sets the maximum time a proxied websocket
+	// connection can be idle in both directions, before it is closed.
+	// Requires ExperimentalUpgrade. Defaults to no timeout.
+	WebSocketIdleTimeout time.Duration
+
 	// When set, no access log is printed.
 	AccessLogDisabled bool
 
@@ -161,12 +173,24 @@ type Params struct {
 	// for a request.
 	DefaultHTTPStatus int
 
+	// ForwardedHeaders defines how the X-Forwarded-* and the Forwarded
+	// headers are set on the requests sent to the backends. The zero
+	// value passes the headers unchanged.
+	ForwardedHeaders snet.ForwardedHeaders
+
 	// MaxLoopbacks sets the maximum number of allowed loops. If 0
 	// the default (9) is applied. To disable looping, set it to
 	// -1. Note, that disabling looping by this option, may result
 	// wrong routing depending on the current configuration.
 	MaxLoopbacks int
 
+	// MaxLBRetries sets the maximum number of times a request is
+	// retried against the next endpoint of a load balanced route,
+	// when dialing the backend failed. If 0 the default (1) is
+	// applied. To disable retries, set it to -1. Requests with a body
+	// are only retried when the body can be replayed.
+	MaxLBRetries int
+
 	// Same as net/http.Transport.MaxIdleConnsPerHost, but the default
 	// is 64. This value supports scenarios with relatively few remote
 	// hosts. When the routing table contains different hosts in the
@@ -180,6 +204,19 @@ type Params struct {
 	// DisableHTTPKeepalives forces backend to always create a new connection
 	DisableHTTPKeepalives bool
 
+	// EnableHTTP2Backends enables negotiating HTTP/2 with TLS
+	// backends, e.g. for proxying gRPC. Backends with the h2c scheme
+	// always use HTTP/2.
+	EnableHTTP2Backends bool
+
+	// EgressProxy, when set, is the URL of an HTTP proxy, through which
+	// the backend requests are sent. Requests to TLS backends are
+	// tunneled with CONNECT, and the credentials of the URL are sent in
+	// the Proxy-Authorization header. The egressProxy filter and the
+	// backendIsProxy filter override it per route. Upgraded connections
+	// are dialed directly.
+	EgressProxy *url.URL
+
 	// CircuitBreakers provides a registry that skipper can use to
 	// find the matching circuit breaker for backend requests. If not
 	// set, no circuit breakers are used.
@@ -190,6 +227,18 @@ type Params struct {
 	// set, no ratelimits are used.
 	RateLimiters *ratelimit.Registry
 
+	// RatelimitResponseHeaders enables the RateLimit-Limit,
+	// RateLimit-Remaining and RateLimit-Reset headers in the responses
+	// to the requests allowed by the route rate limits, when the rate
+	// limit can tell the remaining quota. The rejected requests get
+	// these headers in either case.
+	RatelimitResponseHeaders bool
+
+	// RatelimitLegacyHeaders enables the X-RateLimit-Limit,
+	// X-RateLimit-Remaining and X-RateLimit-Reset headers in addition
+	// to the RateLimit headers.
+	RatelimitLegacyHeaders bool
+
 	// LoadBalancer to report unhealthy or dead backends to
 	LoadBalancer *loadbalancer.LB
 
@@ -201,6 +250,18 @@ type Params struct {
 	// The Flush interval for copying upgraded connections
 	FlushInterval time.Duration
 
+	// ResponseFlushInterval sets the interval at which the response
+	// body is flushed to the client. When zero or negative, the
+	// response is flushed after every read from the backend. It can be
+	// overridden per route with the flushInterval filter.
+	ResponseFlushInterval time.Duration
+
+	// ServerSentEventsIdleTimeout sets the maximum time a stream of
+	// Server-Sent Events can be idle, i.e. without receiving data from
+	// the backend, before it is closed. It can be overridden per route
+	// with the serverSentEvents filter. Defaults to no timeout.
+	ServerSentEventsIdleTimeout time.Duration
+
 	// Timeout sets the TCP client connection timeout for proxy http connections to the backend
 	Timeout time.Duration
 
@@ -298,26 +359,37 @@ type PriorityRoute interface {
 // Proxy instances implement Skipper proxying functionality. For
 // initializing, see the WithParams the constructor and Params.
 type Proxy struct {
+	// accessed atomically, kept first for 64-bit alignment
+	webSocketConnections int64
+
 	experimentalUpgrade      bool
 	experimentalUpgradeAudit bool
 	accessLogDisabled        bool
 	maxLoops                 int
+	maxLBRetries             int
 	defaultHTTPStatus        int
 	routing                  *routing.Routing
 	roundTripper             *http.Transport
+	backendTransports        *backendTransports
 	priorityRoutes           []PriorityRoute
 	flags                    Flags
 	metrics                  metrics.Metrics
 	quit                     chan struct{}
 	flushInterval            time.Duration
+	responseFlushInterval    time.Duration
+	serverSentEventsIdle     time.Duration
 	breakers                 *circuit.Registry
 	limiters                 *ratelimit.Registry
+	ratelimitHeaders         bool
+	ratelimitLegacyHeaders   bool
 	log                      logging.Logger
 	tracing                  *proxyTracing
 	lb                       *loadbalancer.LB
 	upgradeAuditLogOut       io.Writer
 	upgradeAuditLogErr       io.Writer
 	auditLogHook             chan struct{}
+	webSocketIdleTimeout     time.Duration
+	forwardedHeaders         snet.ForwardedHeaders
 }
 
 // proxyError is used to wrap errors during proxying and to indicate
@@ -392,9 +464,71 @@ func cloneHeaderExcluding(h http.Header, excludeList map[string]bool) http.Heade
 	return hh
 }
 
+// delays flushing of a response writer, so that a flush happens at most
+// once per interval
+type intervalFlusher struct {
+	flushedResponseWriter
+	mu       sync.Mutex
+	interval time.Duration
+	timer    *time.Timer
+	pending  bool
+}
+
+func (f *intervalFlusher) Write(p []byte) (int, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	return f.flushedResponseWriter.Write(p)
+}
+
+func (f *intervalFlusher) Flush() {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	if f.pending {
+		return
+	}
+
+	f.pending = true
+	if f.timer == nil {
+		f.timer = time.AfterFunc(f.interval, f.delayedFlush)
+	} else {
+		f.timer.Reset(f.interval)
+	}
+}
+
+func (f *intervalFlusher) delayedFlush() {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	if !f.pending {
+		return
+	}
+
+	f.flushedResponseWriter.Flush()
+	f.pending = false
+}
+
+func (f *intervalFlusher) stop() {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	if f.timer != nil {
+		f.timer.Stop()
+	}
+
+	if f.pending {
+		f.flushedResponseWriter.Flush()
+		f.pending = false
+	}
+}
+
 // copies a stream with flushing on every successful read operation
-// (similar to io.Copy but with flushing)
-func copyStream(to flushedResponseWriter, from io.Reader, tracing *proxyTracing, span ot.Span) error {
+// (similar to io.Copy but with flushing). When the interval is positive,
+// the flushes are delayed and happen at most once per interval.
+func copyStream(to flushedResponseWriter, from io.Reader, interval time.Duration, tracing *proxyTracing, span ot.Span) error {
+	if interval > 0 {
+		f := &intervalFlusher{flushedResponseWriter: to, interval: interval}
+		defer f.stop()
+		to = f
+	}
+
 	b := make([]byte, proxyBufferSize)
 
 	for {
@@ -458,22 +592,47 @@ func setRequestURLForDynamicBackend(u *url.URL, stateBag map[string]interface{})
 	}
 }
 
-func setRequestURLForLoadBalancedBackend(u *url.URL, rt *routing.Route, lbctx *routing.LBContext) {
+func setRequestURLForLoadBalancedBackend(u *url.URL, rt *routing.Route, lbctx *routing.LBContext, failedEndpoints map[string]bool) {
 	e := rt.LBAlgorithm.Apply(lbctx)
+	if failedEndpoints[e.Host] {
+		e = nextEndpoint(rt.LBEndpoints, e, failedEndpoints)
+	}
+
 	u.Scheme = e.Scheme
 	u.Host = e.Host
 }
 
+// returns the first endpoint following e that has not failed yet, or e
+// when all the endpoints failed
+func nextEndpoint(endpoints []routing.LBEndpoint, e routing.LBEndpoint, failed map[string]bool) routing.LBEndpoint {
+	start := 0
+	for i, ei := range endpoints {
+		if ei == e {
+			start = i + 1
+			break
+		}
+	}
+
+	for i := 0; i < len(endpoints); i++ {
+		ei := endpoints[(start+i)%len(endpoints)]
+		if !failed[ei.Host] {
+			return ei
+		}
+	}
+
+	return e
+}
+
 // creates an outgoing http request to be forwarded to the route endpoint
 // based on the augmented incoming request
-func mapRequest(r *http.Request, rt *routing.Route, host string, removeHopHeaders bool, stateBag map[string]interface{}) (*http.Request, error) {
+func mapRequest(r *http.Request, rt *routing.Route, host string, removeHopHeaders bool, stateBag map[string]interface{}, failedEndpoints map[string]bool) (*http.Request, error) {
 	u := r.URL
 	switch rt.BackendType {
 	case eskip.DynamicBackend:
 		setRequestURLFromRequest(u, r)
 		setRequestURLForDynamicBackend(u, stateBag)
 	case eskip.LBBackend:
-		setRequestURLForLoadBalancedBackend(u, rt, routing.NewLBContext(r, rt))
+		setRequestURLForLoadBalancedBackend(u, rt, routing.NewLBContext(r, rt), failedEndpoints)
 	default:
 		u.Scheme = rt.Scheme
 		u.Host = rt.Host
@@ -492,6 +651,12 @@ func mapRequest(r *http.Request, rt *routing.Route, host string, removeHopHeader
 
 	if removeHopHeaders {
 		rr.Header = cloneHeaderExcluding(r.Header, hopHeaders)
+
+		// Te: trailers is required by gRPC to signal that the client
+		// supports trailers, and it is allowed in HTTP/2 requests
+		if acceptsTrailers(r.Header) {
+			rr.Header.Set("Te", "trailers")
+		}
 	} else {
 		rr.Header = cloneHeader(r.Header)
 	}
@@ -506,6 +671,8 @@ func mapRequest(r *http.Request, rt *routing.Route, host string, removeHopHeader
 
 	if _, ok := stateBag[filters.BackendIsProxyKey]; ok {
 		forwardToProxy(r, rr)
+	} else if u, ok := stateBag[filters.EgressProxyKey].(string); ok {
+		rr.Header.Set(backendIsProxyHeader, u)
 	}
 
 	ctxspan := ot.SpanFromContext(r.Context())
@@ -516,6 +683,65 @@ func mapRequest(r *http.Request, rt *routing.Route, host string, removeHopHeader
 	return rr, nil
 }
 
+func acceptsTrailers(h http.Header) bool {
+	for _, v := range h["Te"] {
+		for _, t := range strings.Split(v, ",") {
+			if strings.EqualFold(strings.TrimSpace(t), "trailers") {
+				return true
+			}
+		}
+	}
+
+	return false
+}
+
+// announces the trailers known before the response body is copied, this
+// needs to happen before the header is written. The content length is
+// dropped, because trailers require chunked encoding with HTTP/1.1.
+func announceTrailers(w http.ResponseWriter, trailer http.Header) map[string]bool {
+	announced := make(map[string]bool)
+	if len(trailer) > 0 {
+		w.Header().Del("Content-Length")
+	}
+
+	for k := range trailer {
+		w.Header().Add("Trailer", k)
+		announced[k] = true
+	}
+
+	return announced
+}
+
+// copies the trailers after the response body was copied. Trailers that
+// were not announced are set with the http.TrailerPrefix.
+func copyTrailers(w http.ResponseWriter, trailer http.Header, announced map[string]bool) {
+	for k, v := range trailer {
+		if !announced[k] {
+			k = http.TrailerPrefix + k
+		}
+
+		w.Header()[k] = v
+	}
+}
+
+func isGRPC(rsp *http.Response) bool {
+	return strings.HasPrefix(rsp.Header.Get("Content-Type"), "application/grpc")
+}
+
+// returns the grpc-status of the response. When the backend responds
+// with an error only, the status is sent in the header.
+func grpcStatus(rsp *http.Response) string {
+	if s := rsp.Trailer.Get("Grpc-Status"); s != "" {
+		return s
+	}
+
+	if s := rsp.Header.Get("Grpc-Status"); s != "" {
+		return s
+	}
+
+	return "unknown"
+}
+
 func forwardToProxy(incoming, outgoing *http.Request) {
 	proxyURL := &url.URL{
 		Scheme: outgoing.URL.Scheme,
@@ -598,12 +824,14 @@ func WithParams(p Params) *Proxy {
 		p.ExpectContinueTimeout = DefaultExpectContinueTimeout
 	}
 
+	dialContext := newSkipperDialer(net.Dialer{
+		Timeout:   p.Timeout,
+		KeepAlive: p.KeepAlive,
+		DualStack: p.DualStack,
+	}).DialContext
+
 	tr := &http.Transport{
-		DialContext: newSkipperDialer(net.Dialer{
-			Timeout:   p.Timeout,
-			KeepAlive: p.KeepAlive,
-			DualStack: p.DualStack,
-		}).DialContext,
+		DialContext:           dialContext,
 		TLSHandshakeTimeout:   p.TLSHandshakeTimeout,
 		ResponseHeaderTimeout: p.ResponseHeaderTimeout,
 		ExpectContinueTimeout: p.ExpectContinueTimeout,
@@ -611,13 +839,18 @@ func WithParams(p Params) *Proxy {
 		MaxIdleConnsPerHost:   p.IdleConnectionsPerHost,
 		IdleConnTimeout:       p.CloseIdleConnsPeriod,
 		DisableKeepAlives:     p.DisableHTTPKeepalives,
-		Proxy:                 proxyFromHeader,
+		ForceAttemptHTTP2:     p.EnableHTTP2Backends,
+		Proxy:                 egressProxy(p.EgressProxy),
 	}
 
 	if p.ClientTLS != nil {
 		tr.TLSClientConfig = p.ClientTLS
 	}
 
+	h2c := newH2CRoundTripper(dialContext)
+	tr.RegisterProtocol(h2cScheme, h2c)
+	backendTransports := newBackendTransports(tr)
+
 	quit := make(chan struct{})
 	// We need this to reliably fade on DNS change, which is right
 	// now not fixed with IdleConnTimeout in the http.Transport.
@@ -628,6 +861,8 @@ func WithParams(p Params) *Proxy {
 				select {
 				case <-time.After(p.CloseIdleConnsPeriod):
 					tr.CloseIdleConnections()
+					h2c.CloseIdleConnections()
+					backendTransports.closeIdleConnections()
 				case <-quit:
 					return
 				}
@@ -656,6 +891,12 @@ func WithParams(p Params) *Proxy {
 		p.MaxLoopbacks = 0
 	}
 
+	if p.MaxLBRetries == 0 {
+		p.MaxLBRetries = DefaultMaxLBRetries
+	} else if p.MaxLBRetries < 0 {
+		p.MaxLBRetries = 0
+	}
+
 	defaultHTTPStatus := http.StatusNotFound
 
 	if p.DefaultHTTPStatus >= http.StatusContinue && p.DefaultHTTPStatus <= http.StatusNetworkAuthenticationRequired {
@@ -667,17 +908,25 @@ func WithParams(p Params) *Proxy {
 	return &Proxy{
 		routing:                  p.Routing,
 		roundTripper:             tr,
+		backendTransports:        backendTransports,
 		priorityRoutes:           p.PriorityRoutes,
 		flags:                    p.Flags,
 		metrics:                  m,
 		quit:                     quit,
 		flushInterval:            p.FlushInterval,
+		responseFlushInterval:    p.ResponseFlushInterval,
+		serverSentEventsIdle:     p.ServerSentEventsIdleTimeout,
 		experimentalUpgrade:      p.ExperimentalUpgrade,
+		webSocketIdleTimeout:     p.WebSocketIdleTimeout,
+		forwardedHeaders:         p.ForwardedHeaders,
 		experimentalUpgradeAudit: p.ExperimentalUpgradeAudit,
 		maxLoops:                 p.MaxLoopbacks,
+		maxLBRetries:             p.MaxLBRetries,
 		breakers:                 p.CircuitBreakers,
 		lb:                       p.LoadBalancer,
 		limiters:                 p.RateLimiters,
+		ratelimitHeaders:         p.RatelimitResponseHeaders,
+		ratelimitLegacyHeaders:   p.RatelimitLegacyHeaders,
 		log:                      &logging.DefaultLog{},
 		defaultHTTPStatus:        defaultHTTPStatus,
 		tracing:                  newProxyTracing(p.OpenTracing),
@@ -717,6 +966,20 @@ func proxyFromHeader(req *http.Request) (*url.URL, error) {
 	return nil, nil
 }
 
+// egressProxy returns the proxy function of the transport, which uses
+// the proxy set for the request by the filters, or the default egress
+// proxy.
+func egressProxy(defaultProxy *url.URL) func(*http.Request) (*url.URL, error) {
+	return func(req *http.Request) (*url.URL, error) {
+		u, err := proxyFromHeader(req)
+		if u != nil || err != nil {
+			return u, err
+		}
+
+		return defaultProxy, nil
+	}
+}
+
 // applies filters to a request
 func (p *Proxy) applyFiltersToRequest(f []*routing.RouteFilter, ctx *context) []*routing.RouteFilter {
 	if len(f) == 0 {
@@ -834,6 +1097,10 @@ func (p *Proxy) makeUpgradeRequest(ctx *context, req *http.Request) error {
 		auditLogOut:     p.upgradeAuditLogOut,
 		auditLogErr:     p.upgradeAuditLogErr,
 		auditLogHook:    p.auditLogHook,
+
+		metrics:              p.metrics,
+		webSocketIdleTimeout: p.webSocketIdleTimeout,
+		webSocketConnections: &p.webSocketConnections,
 	}
 
 	upgradeProxy.serveHTTP(ctx.responseWriter, req)
@@ -842,12 +1109,14 @@ func (p *Proxy) makeUpgradeRequest(ctx *context, req *http.Request) error {
 }
 
 func (p *Proxy) makeBackendRequest(ctx *context) (*http.Response, *proxyError) {
-	req, err := mapRequest(ctx.request, ctx.route, ctx.outgoingHost, p.flags.HopHeadersRemoval(), ctx.StateBag())
+	req, err := mapRequest(ctx.request, ctx.route, ctx.outgoingHost, p.flags.HopHeadersRemoval(), ctx.StateBag(), ctx.failedEndpoints)
 	if err != nil {
 		p.log.Errorf("could not map backend request, caused by: %v", err)
 		return nil, &proxyError{err: err}
 	}
 
+	p.forwardedHeaders.Set(ctx.request, req.Header)
+
 	if p.experimentalUpgrade && isUpgradeRequest(req) {
 		if err = p.makeUpgradeRequest(ctx, req); err != nil {
 			return nil, &proxyError{err: err}
@@ -858,6 +1127,10 @@ func (p *Proxy) makeBackendRequest(ctx *context) (*http.Response, *proxyError) {
 	}
 
 	bag := ctx.StateBag()
+	withMaxBody(req, bag)
+	withReadTimeout(req, ctx.request, bag)
+	req, timeouts := withBackendTimeouts(req, bag)
+
 	spanName, ok := bag[tracingfilter.OpenTracingProxySpanKey].(string)
 	if !ok {
 		spanName = "proxy"
@@ -880,7 +1153,12 @@ func (p *Proxy) makeBackendRequest(ctx *context) (*http.Response, *proxyError) {
 
 	p.metrics.IncCounter("outgoing." + req.Proto)
 	ctx.proxySpan.LogKV("http_roundtrip", StartEvent)
-	response, err := p.roundTripper.RoundTrip(req)
+	settings := transportSettingsFromBag(bag)
+	if settings.proxyProtocol > 0 {
+		req = withProxyProtocolAddrs(req, ctx.request)
+	}
+
+	response, err := p.backendTransports.get(settings).RoundTrip(req)
 	ctx.proxySpan.LogKV("http_roundtrip", EndEvent)
 	if err != nil {
 		p.tracing.setTag(ctx.proxySpan, ErrorTag, true)
@@ -888,6 +1166,44 @@ func (p *Proxy) makeBackendRequest(ctx *context) (*http.Response, *proxyError) {
 			"event", "error",
 			"message", err.Error())
 
+		timeouts.release()
+		if err == errReadTimeout {
+			p.log.Errorf("Failed to read the request body for %s: %v", ctx.route.Backend, err)
+			p.tracing.setTag(ctx.proxySpan, HTTPStatusCodeTag, uint16(http.StatusRequestTimeout))
+			return nil, &proxyError{
+				err:  err,
+				code: http.StatusRequestTimeout,
+			}
+		}
+
+		if errors.Is(err, errRequestBodyTooLarge) {
+			p.log.Errorf("Failed to forward the request body to %s: %v", ctx.route.Backend, err)
+			p.tracing.setTag(ctx.proxySpan, HTTPStatusCodeTag, uint16(http.StatusRequestEntityTooLarge))
+			return nil, &proxyError{
+				err:  err,
+				code: http.StatusRequestEntityTooLarge,
+			}
+		}
+
+		var berr *filters.RequestBodyError
+		if errors.As(err, &berr) {
+			p.log.Errorf("Failed to forward the request body to %s: %v", ctx.route.Backend, err)
+			p.tracing.setTag(ctx.proxySpan, HTTPStatusCodeTag, uint16(berr.StatusCode))
+			return nil, &proxyError{
+				err:  err,
+				code: berr.StatusCode,
+			}
+		}
+
+		if timeouts.exceeded() {
+			p.log.Errorf("Backend roundtrip to %s timed out: %v", ctx.route.Backend, err)
+			p.tracing.setTag(ctx.proxySpan, HTTPStatusCodeTag, uint16(http.StatusGatewayTimeout))
+			return nil, &proxyError{
+				err:  err,
+				code: http.StatusGatewayTimeout,
+			}
+		}
+
 		if perr, ok := err.(*proxyError); ok {
 			p.log.Errorf("Failed to do backend roundtrip to %s: %v", ctx.route.Backend, perr)
 			//p.lb.AddHealthcheck(ctx.route.Backend)
@@ -933,6 +1249,8 @@ func (p *Proxy) makeBackendRequest(ctx *context) (*http.Response, *proxyError) {
 		p.log.Errorf("Unexpected error from Go stdlib net/http package during roundtrip: %v", err)
 		return nil, &proxyError{err: err}
 	}
+
+	timeouts.received(response, isServerSentEvents(bag) || snet.IsEventStream(response))
 	p.tracing.setTag(ctx.proxySpan, HTTPStatusCodeTag, uint16(response.StatusCode))
 	return response, nil
 }
@@ -941,6 +1259,8 @@ func (p *Proxy) makeBackendRequest(ctx *context) (*http.Response, *proxyError) {
 // configuration. It returns the used ratelimit.Settings and 0 if
 // the request passed in the context should be allowed.
 // otherwise it returns the used ratelimit.Settings and the retry-after period.
+// When the ratelimit response headers are enabled, it stores the headers of
+// the lowest remaining quota in the context.
 func (p *Proxy) checkRatelimit(ctx *context) (ratelimit.Settings, int) {
 	if p.limiters == nil {
 		return ratelimit.Settings{}, 0
@@ -951,6 +1271,13 @@ func (p *Proxy) checkRatelimit(ctx *context) (ratelimit.Settings, int) {
 		return ratelimit.Settings{}, 0
 	}
 
+	var (
+		quotaFound bool
+		limit      int
+		remaining  int
+		reset      time.Duration
+	)
+
 	for _, setting := range settings {
 		rl := p.limiters.Get(setting)
 		if rl == nil {
@@ -963,7 +1290,13 @@ func (p *Proxy) checkRatelimit(ctx *context) (ratelimit.Settings, int) {
 			continue
 		}
 
-		s := setting.Lookuper.Lookup(ctx.Request())
+		var s string
+		if cl, ok := setting.Lookuper.(ratelimit.ContextLookuper); ok {
+			s = cl.LookupContext(ctx)
+		} else {
+			s = setting.Lookuper.Lookup(ctx.Request())
+		}
+
 		if s == "" {
 			p.log.Errorf("Lookuper found no data in request for setting: %s and request: %v", setting, ctx.Request())
 			continue
@@ -972,6 +1305,19 @@ func (p *Proxy) checkRatelimit(ctx *context) (ratelimit.Settings, int) {
 		if !rl.Allow(s) {
 			return setting, rl.RetryAfter(s)
 		}
+
+		if !p.ratelimitHeaders {
+			continue
+		}
+
+		if r, d, ok := rl.Quota(s); ok && (!quotaFound || r < remaining) {
+			quotaFound, limit, remaining, reset = true, setting.MaxHits, r, d
+		}
+	}
+
+	if quotaFound {
+		ctx.ratelimitHeader = make(http.Header)
+		ratelimit.SetHeaders(ctx.ratelimitHeader, limit, remaining, reset, p.ratelimitLegacyHeaders)
 	}
 
 	return ratelimit.Settings{}, 0
@@ -994,14 +1340,17 @@ func (p *Proxy) checkBreaker(c *context) (func(bool), bool) {
 	return done, ok
 }
 
-func newRatelimitError(settings ratelimit.Settings, retryAfter int) error {
+func (p *Proxy) newRatelimitError(settings ratelimit.Settings, retryAfter int) error {
+	h := http.Header{
+		ratelimit.Header:           []string{strconv.Itoa(settings.MaxHits * int(time.Hour/settings.TimeWindow))},
+		ratelimit.RetryAfterHeader: []string{strconv.Itoa(retryAfter)},
+	}
+
+	ratelimit.SetHeaders(h, settings.MaxHits, 0, time.Duration(retryAfter)*time.Second, p.ratelimitLegacyHeaders)
 	return &proxyError{
-		err:  errRatelimit,
-		code: http.StatusTooManyRequests,
-		additionalHeader: http.Header{
-			ratelimit.Header:           []string{strconv.Itoa(settings.MaxHits * int(time.Hour/settings.TimeWindow))},
-			ratelimit.RetryAfterHeader: []string{strconv.Itoa(retryAfter)},
-		},
+		err:              errRatelimit,
+		code:             http.StatusTooManyRequests,
+		additionalHeader: h,
 	}
 }
 
@@ -1014,7 +1363,7 @@ func (p *Proxy) do(ctx *context) error {
 
 	// proxy global setting
 	if settings, retryAfter := p.limiters.Check(ctx.request); retryAfter > 0 {
-		rerr := newRatelimitError(settings, retryAfter)
+		rerr := p.newRatelimitError(settings, retryAfter)
 		return rerr
 	}
 
@@ -1037,7 +1386,7 @@ func (p *Proxy) do(ctx *context) error {
 
 	// per route rate limit
 	if settings, retryAfter := p.checkRatelimit(ctx); retryAfter > 0 {
-		rerr := newRatelimitError(settings, retryAfter)
+		rerr := p.newRatelimitError(settings, retryAfter)
 		return rerr
 	}
 
@@ -1055,7 +1404,7 @@ func (p *Proxy) do(ctx *context) error {
 		ctx.setResponse(loopCTX.response, p.flags.PreserveOriginal())
 		ctx.proxySpan = loopCTX.proxySpan
 	} else if p.flags.Debug() {
-		debugReq, err := mapRequest(ctx.request, ctx.route, ctx.outgoingHost, p.flags.HopHeadersRemoval(), ctx.StateBag())
+		debugReq, err := mapRequest(ctx.request, ctx.route, ctx.outgoingHost, p.flags.HopHeadersRemoval(), ctx.StateBag(), nil)
 		if err != nil {
 			return &proxyError{err: err}
 		}
@@ -1079,26 +1428,17 @@ func (p *Proxy) do(ctx *context) error {
 
 			p.metrics.IncErrorsBackend(ctx.route.Id)
 
-			if retryable(ctx.Request()) && perr.DialError() && ctx.route.BackendType == eskip.LBBackend {
-				if ctx.proxySpan != nil {
-					ctx.proxySpan.Finish()
-					ctx.proxySpan = nil
-				}
-
-				tracing.LogKV("retry", ctx.route.Id, ctx.Request().Context())
-
-				perr = nil
-				var perr2 *proxyError
-				rsp, perr2 = p.makeBackendRequest(ctx)
-				if perr2 != nil {
-					p.log.Errorf("Failed to do retry backend request: %v", perr2)
-					if perr2.code >= http.StatusInternalServerError {
+			if p.maxLBRetries > 0 && retryable(ctx.Request()) && perr.DialError() && ctx.route.BackendType == eskip.LBBackend {
+				rsp, perr = p.retryLoadBalancedBackend(ctx, perr)
+				if perr != nil {
+					p.log.Errorf("Failed to do retry backend request: %v", perr)
+					if perr.code >= http.StatusInternalServerError {
 						p.metrics.MeasureBackend5xx(backendStart)
 					}
-					return perr2
+					return p.backendErrorResponse(ctx, perr, processedFilters)
 				}
 			} else {
-				return perr
+				return p.backendErrorResponse(ctx, perr, processedFilters)
 			}
 		}
 
@@ -1116,12 +1456,95 @@ func (p *Proxy) do(ctx *context) error {
 	}
 
 	addBranding(ctx.response.Header)
+	copyHeader(ctx.response.Header, ctx.ratelimitHeader)
+	p.applyFiltersToResponse(processedFilters, ctx)
+	return nil
+}
+
+// backendErrorResponse turns a failed backend request into a response with
+// the status code of the error, and applies the response filters to it, when
+// it was requested by a filter. Otherwise, it returns the original error.
+func (p *Proxy) backendErrorResponse(ctx *context, perr *proxyError, processedFilters []*routing.RouteFilter) error {
+	if on, _ := ctx.StateBag()[filters.BackendErrorResponseKey].(bool); !on || perr.handled {
+		return perr
+	}
+
+	code := http.StatusInternalServerError
+	if perr.code == -1 { // -1 == dial connection refused
+		code = http.StatusBadGateway
+	} else if perr.code != 0 {
+		code = perr.code
+	}
+
+	p.log.Errorf("error while proxying, route %s with backend %s, status code %d: %v", ctx.route.Id, ctx.route.Backend, code, perr)
+
+	rsp := &http.Response{
+		StatusCode: code,
+		Status:     fmt.Sprintf("%d %s", code, http.StatusText(code)),
+		Header:     make(http.Header),
+		Body:       ioutil.NopCloser(strings.NewReader(http.StatusText(code) + "\n")),
+		Request:    ctx.Request(),
+	}
+
+	rsp.Header.Set("Content-Type", "text/plain; charset=utf-8")
+	rsp.Header.Set("X-Content-Type-Options", "nosniff")
+	copyHeader(rsp.Header, perr.additionalHeader)
+
+	ctx.setResponse(rsp, p.flags.PreserveOriginal())
+	addBranding(ctx.response.Header)
+	copyHeader(ctx.response.Header, ctx.ratelimitHeader)
 	p.applyFiltersToResponse(processedFilters, ctx)
 	return nil
 }
 
+// retries the request against the next endpoints of a load balanced
+// route, as long as dialing the backend fails
+func (p *Proxy) retryLoadBalancedBackend(ctx *context, perr *proxyError) (*http.Response, *proxyError) {
+	var rsp *http.Response
+	for retries := 0; retries < p.maxLBRetries && perr != nil && perr.DialError(); retries++ {
+		if ctx.failedEndpoints == nil {
+			ctx.failedEndpoints = make(map[string]bool)
+		}
+
+		// the request URL holds the endpoint that failed
+		ctx.failedEndpoints[ctx.request.URL.Host] = true
+
+		if ctx.proxySpan != nil {
+			ctx.proxySpan.Finish()
+			ctx.proxySpan = nil
+		}
+
+		if err := rewindBody(ctx.request); err != nil {
+			p.log.Errorf("Failed to replay request body for retry: %v", err)
+			return nil, perr
+		}
+
+		tracing.LogKV("retry", ctx.route.Id, ctx.Request().Context())
+		p.metrics.IncCounter("lbretry." + ctx.route.Id)
+		rsp, perr = p.makeBackendRequest(ctx)
+	}
+
+	return rsp, perr
+}
+
+// a request can be retried when it has no body, or the body can be
+// replayed, e.g. because it was buffered
 func retryable(req *http.Request) bool {
-	return req != nil && (req.Body == nil || req.Body == http.NoBody)
+	return req != nil && (req.Body == nil || req.Body == http.NoBody || req.GetBody != nil)
+}
+
+func rewindBody(req *http.Request) error {
+	if req.Body == nil || req.Body == http.NoBody || req.GetBody == nil {
+		return nil
+	}
+
+	body, err := req.GetBody()
+	if err != nil {
+		return err
+	}
+
+	req.Body = body
+	return nil
 }
 
 func (p *Proxy) serveResponse(ctx *context) {
@@ -1150,15 +1573,40 @@ func (p *Proxy) serveResponse(ctx *context) {
 		p.tracing.setTag(ctx.proxySpan, ClientRequestStateTag, ClientRequestCanceled)
 	}
 
+	announced := announceTrailers(ctx.responseWriter, ctx.response.Trailer)
+	flushInterval := p.responseFlushIntervalFor(ctx)
+	if idleTimeout, ok := p.serverSentEvents(ctx); ok {
+		flushInterval = 0
+		disableConnTimeouts(ctx.request)
+		if idleTimeout > 0 {
+			ctx.response.Body = newIdleTimeoutBody(ctx.response.Body, idleTimeout)
+		}
+	}
+
 	ctx.responseWriter.WriteHeader(ctx.response.StatusCode)
 	ctx.responseWriter.Flush()
-	err := copyStream(ctx.responseWriter, ctx.response.Body, p.tracing, ctx.proxySpan)
-	if err != nil {
+	err := copyStream(ctx.responseWriter, ctx.response.Body, flushInterval, p.tracing, ctx.proxySpan)
+	if err == errServerSentEventsIdle {
+		p.metrics.IncCounter("serversentevents.idletimeout")
+		p.log.Debugf("server-sent events stream idle, route: %s", ctx.route.Id)
+	} else if err != nil {
 		p.metrics.IncErrorsStreaming(ctx.route.Id)
 		p.log.Error("error while copying the response stream", err)
 	} else {
+		copyTrailers(ctx.responseWriter, ctx.response.Trailer, announced)
 		p.metrics.MeasureResponse(ctx.response.StatusCode, ctx.request.Method, ctx.route.Id, start)
+		if isGRPC(ctx.response) {
+			p.metrics.MeasureGRPCResponse(grpcStatus(ctx.response), ctx.route.Id, start)
+		}
+	}
+}
+
+func (p *Proxy) responseFlushIntervalFor(ctx *context) time.Duration {
+	if d, ok := ctx.StateBag()[filters.ResponseFlushIntervalKey].(time.Duration); ok {
+		return d
 	}
+
+	return p.responseFlushInterval
 }
 
 func (p *Proxy) errorResponse(ctx *context, err error) {
@@ -1317,6 +1765,11 @@ func (p *Proxy) ServeHTTP(w http.ResponseWriter, r *http.Request) {
 		for _, done := range pendingLIFO {
 			done()
 		}
+
+		pendingFIFO, _ := ctx.StateBag()[scheduler.FIFOKey].([]func())
+		for _, done := range pendingFIFO {
+			done()
+		}
 	}
 
 	if err != nil {
@@ -27,6 +27,7 @@ import (
 	"github.com/zalando/skipper/loadbalancer"
 	"github.com/zalando/skipper/logging"
 	"github.com/zalando/skipper/logging/loggingtest"
+	snet "github.com/zalando/skipper/net"
 	"github.com/zalando/skipper/routing"
 	"github.com/zalando/skipper/routing/testdataclient"
 )
@@ -322,6 +323,12 @@ func TestRetryable(t *testing.T) {
 	if err != nil {
 		t.Fatalf("Failed to create request with body: %v", err)
 	}
+	// like incoming requests, the body cannot be replayed
+	reqWithBody.GetBody = nil
+	reqWithReplayableBody, err := http.NewRequest("GET", "http://www.zalando.de", bytes.NewBufferString("hello"))
+	if err != nil {
+		t.Fatalf("Failed to create request with replayable body: %v", err)
+	}
 
 	for _, tt := range []struct {
 		name string
@@ -347,6 +354,11 @@ func TestRetryable(t *testing.T) {
 			name: "test request with body",
 			req:  reqWithBody,
 			want: false,
+		},
+		{
+			name: "test request with replayable body",
+			req:  reqWithReplayableBody,
+			want: true,
 		}} {
 		t.Run(tt.name, func(t *testing.T) {
 			got := retryable(tt.req)
@@ -1568,6 +1580,36 @@ func TestRequestContentHeaders(t *testing.T) {
 	req(true)
 }
 
+func TestHeaderRegexpCaptures(t *testing.T) {
+	backend := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte(r.Header.Get("X-Api-Version")))
+	}))
+	defer backend.Close()
+
+	doc := fmt.Sprintf(`HeaderRegexp("Accept", "^application/vnd\\.example\\.v(?P<version>[0-9]+)\\+json$")
+		-> setRequestHeader("X-Api-Version", "${state.capture.version}")
+		-> "%s"`, backend.URL)
+	tp, err := newTestProxy(doc, FlagsNone)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	defer tp.close()
+
+	r := httptest.NewRequest("GET", "https://www.example.org", nil)
+	r.Header.Set("Accept", "application/vnd.example.v3+json")
+	w := httptest.NewRecorder()
+	tp.proxy.ServeHTTP(w, r)
+
+	if w.Code != http.StatusOK {
+		t.Fatalf("unexpected status code: %d", w.Code)
+	}
+
+	if w.Body.String() != "3" {
+		t.Errorf("unexpected version: %q", w.Body.String())
+	}
+}
+
 func TestSettingDefaultHTTPStatus(t *testing.T) {
 	params := Params{
 		DefaultHTTPStatus: http.StatusBadGateway,
@@ -2070,3 +2112,178 @@ func BenchmarkAccessLogEnablePrint(b *testing.B) {
 	benchmarkAccessLog(b, "enableAccessLog(1,200,3)", 200)
 }
 func BenchmarkAccessLogEnable(b *testing.B) { benchmarkAccessLog(b, "enableAccessLog(1,3)", 200) }
+
+func TestForwardedHeaders(t *testing.T) {
+	received := make(chan http.Header, 1)
+	backend := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		received <- r.Header
+	}))
+	defer backend.Close()
+
+	tp, err := newTestProxyWithParams(fmt.Sprintf(`* -> "%s"`, backend.URL), Params{
+		ForwardedHeaders: snet.ForwardedHeaders{
+			For:       snet.ForwardedAppend,
+			Proto:     snet.ForwardedOverwrite,
+			Forwarded: true,
+		},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	defer tp.close()
+	ps := httptest.NewServer(tp.proxy)
+	defer ps.Close()
+
+	req, err := http.NewRequest("GET", ps.URL, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	req.Header.Set("X-Forwarded-For", "1.2.3.4")
+	req.Header.Set("X-Forwarded-Proto", "https")
+	rsp, err := http.DefaultClient.Do(req)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	rsp.Body.Close()
+	h := <-received
+	if xff := h.Get("X-Forwarded-For"); xff != "1.2.3.4, 127.0.0.1" {
+		t.Errorf("unexpected X-Forwarded-For: %s", xff)
+	}
+
+	if xfp := h.Get("X-Forwarded-Proto"); xfp != "http" {
+		t.Errorf("unexpected X-Forwarded-Proto: %s", xfp)
+	}
+
+	if f := h.Get("Forwarded"); !strings.HasPrefix(f, "for=127.0.0.1;proto=http;host=") {
+		t.Errorf("unexpected Forwarded: %s", f)
+	}
+}
+
+// testEgressProxy forwards the requests, and tunnels the CONNECT requests,
+// recording the requested hosts
+func testEgressProxy(t *testing.T, requested chan<- string) *httptest.Server {
+	return httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		requested <- r.Method + " " + r.Host
+
+		if r.Method != "CONNECT" {
+			rsp, err := http.DefaultTransport.RoundTrip(r)
+			if err != nil {
+				w.WriteHeader(http.StatusBadGateway)
+				return
+			}
+
+			defer rsp.Body.Close()
+			w.WriteHeader(rsp.StatusCode)
+			io.Copy(w, rsp.Body)
+			return
+		}
+
+		backend, err := net.Dial("tcp", r.Host)
+		if err != nil {
+			w.WriteHeader(http.StatusBadGateway)
+			return
+		}
+
+		defer backend.Close()
+		w.WriteHeader(http.StatusOK)
+		client, rw, err := w.(http.Hijacker).Hijack()
+		if err != nil {
+			t.Error(err)
+			return
+		}
+
+		defer client.Close()
+		go io.Copy(backend, rw)
+		io.Copy(client, backend)
+	}))
+}
+
+func TestEgressProxy(t *testing.T) {
+	backend := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte("Hello"))
+	}))
+	defer backend.Close()
+
+	tlsBackend := httptest.NewTLSServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte("Hello"))
+	}))
+	defer tlsBackend.Close()
+
+	requested := make(chan string, 1)
+	egress := testEgressProxy(t, requested)
+	defer egress.Close()
+
+	egressURL, err := url.Parse(egress.URL)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	backendHost := strings.TrimPrefix(backend.URL, "http://")
+	tlsBackendHost := strings.TrimPrefix(tlsBackend.URL, "https://")
+	for _, tt := range []struct {
+		name     string
+		route    string
+		global   *url.URL
+		expected string
+	}{{
+		name:     "global",
+		route:    fmt.Sprintf(`* -> "%s"`, backend.URL),
+		global:   egressURL,
+		expected: "GET " + backendHost,
+	}, {
+		name:     "filter",
+		route:    fmt.Sprintf(`* -> egressProxy("%s") -> "%s"`, egress.URL, backend.URL),
+		expected: "GET " + backendHost,
+	}, {
+		name:     "filter overrides global",
+		route:    fmt.Sprintf(`* -> egressProxy("%s") -> "%s"`, egress.URL, backend.URL),
+		global:   &url.URL{Scheme: "http", Host: "invalid.egress.test:3128"},
+		expected: "GET " + backendHost,
+	}, {
+		name:     "tunnel to TLS backend",
+		route:    fmt.Sprintf(`* -> "%s"`, tlsBackend.URL),
+		global:   egressURL,
+		expected: "CONNECT " + tlsBackendHost,
+	}} {
+		t.Run(tt.name, func(t *testing.T) {
+			tp, err := newTestProxyWithParams(tt.route, Params{
+				EgressProxy: tt.global,
+				ClientTLS:   &tls.Config{InsecureSkipVerify: true},
+			})
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			defer tp.close()
+			ps := httptest.NewServer(tp.proxy)
+			defer ps.Close()
+
+			rsp, err := http.Get(ps.URL)
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			defer rsp.Body.Close()
+			b, err := ioutil.ReadAll(rsp.Body)
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			if rsp.StatusCode != http.StatusOK || string(b) != "Hello" {
+				t.Fatalf("unexpected response: %d, %s", rsp.StatusCode, string(b))
+			}
+
+			select {
+			case r := <-requested:
+				if r != tt.expected {
+					t.Errorf("unexpected request to the egress proxy, expected: %s, got: %s", tt.expected, r)
+				}
+			default:
+				t.Error("request not sent through the egress proxy")
+			}
+		})
+	}
+}
@@ -0,0 +1,59 @@
+package proxy
+
+import (
+	stdlibcontext "context"
+	"net"
+	"net/http"
+	"strconv"
+
+	"github.com/zalando/skipper/proxyprotocol"
+)
+
+type proxyProtocolAddrs struct {
+	src, dst net.Addr
+}
+
+type proxyProtocolKey struct{}
+
+// withProxyProtocolAddrs passes the client address of the incoming
+// request, and the local address that received it, to the dialer of the
+// backend connection, in the context of the outgoing request
+func withProxyProtocolAddrs(req, incoming *http.Request) *http.Request {
+	var a proxyProtocolAddrs
+	if host, port, err := net.SplitHostPort(incoming.RemoteAddr); err == nil {
+		if p, err := strconv.Atoi(port); err == nil {
+			a.src = &net.TCPAddr{IP: net.ParseIP(host), Port: p}
+		}
+	}
+
+	a.dst, _ = incoming.Context().Value(http.LocalAddrContextKey).(net.Addr)
+	return req.WithContext(stdlibcontext.WithValue(req.Context(), proxyProtocolKey{}, a))
+}
+
+// proxyProtocolDial wraps the dial function of the transport, and sends
+// the PROXY protocol header on the new connections
+func proxyProtocolDial(
+	dial func(stdlibcontext.Context, string, string) (net.Conn, error),
+	version int,
+) func(stdlibcontext.Context, string, string) (net.Conn, error) {
+	if dial == nil {
+		dial = (&net.Dialer{}).DialContext
+	}
+
+	return func(ctx stdlibcontext.Context, network, address string) (net.Conn, error) {
+		c, err := dial(ctx, network, address)
+		if err != nil {
+			return nil, err
+		}
+
+		// without the addresses, the header announces an unknown
+		// connection
+		a, _ := ctx.Value(proxyProtocolKey{}).(proxyProtocolAddrs)
+		if err := proxyprotocol.WriteHeader(c, version, a.src, a.dst); err != nil {
+			c.Close()
+			return nil, err
+		}
+
+		return c, nil
+	}
+}
@@ -0,0 +1,62 @@
+package proxy
+
+import (
+	"fmt"
+	"io/ioutil"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/zalando/skipper/proxyprotocol"
+)
+
+func TestBackendProxyProtocol(t *testing.T) {
+	for _, version := range []int{1, 2} {
+		t.Run(fmt.Sprintf("version %d", version), func(t *testing.T) {
+			l, err := net.Listen("tcp", "127.0.0.1:0")
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			backend := httptest.NewUnstartedServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				w.Write([]byte(r.RemoteAddr))
+			}))
+			backend.Listener = proxyprotocol.NewListener(l, proxyprotocol.Options{})
+			backend.Start()
+			defer backend.Close()
+
+			tp, err := newTestProxy(fmt.Sprintf(`* -> backendProxyProtocol(%d) -> "%s"`, version, backend.URL), FlagsNone)
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			defer tp.close()
+			ps := httptest.NewServer(tp.proxy)
+			defer ps.Close()
+
+			// the client address is known from the connection used by the
+			// client, and it's received by the backend, instead of the
+			// address of the proxy
+			c, err := net.Dial("tcp", ps.Listener.Addr().String())
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			defer c.Close()
+			if _, err := fmt.Fprintf(c, "GET / HTTP/1.1\r\nHost: www.example.org\r\nConnection: close\r\n\r\n"); err != nil {
+				t.Fatal(err)
+			}
+
+			b, err := ioutil.ReadAll(c)
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			expected := c.LocalAddr().String()
+			if len(b) < len(expected) || string(b[len(b)-len(expected):]) != expected {
+				t.Errorf("expected the backend to receive the client address %s, got response: %q", expected, b)
+			}
+		})
+	}
+}
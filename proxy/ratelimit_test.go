@@ -1,6 +1,7 @@
 package proxy_test
 
 import (
+	"fmt"
 	"io/ioutil"
 	"math/rand"
 	"net/http"
@@ -465,3 +466,64 @@ func TestRetryAfterHeader(t *testing.T) {
 		t.Fatalf("should calculate ratelimit header correctly: %d expected: %d", i, expected)
 	}
 }
+
+func TestRatelimitResponseHeaders(t *testing.T) {
+	backend := httptest.NewServer(http.HandlerFunc(func(http.ResponseWriter, *http.Request) {}))
+	defer backend.Close()
+
+	r, err := eskip.Parse(fmt.Sprintf(`* -> ratelimit(2, "1m") -> "%s"`, backend.URL))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	p := proxytest.WithParams(builtin.MakeRegistry(), proxy.Params{
+		CloseIdleConnsPeriod:     -time.Second,
+		RateLimiters:             ratelimit.NewRegistry(),
+		RatelimitResponseHeaders: true,
+		RatelimitLegacyHeaders:   true,
+	}, r...)
+	defer p.Close()
+
+	request := func() (int, http.Header) {
+		rsp, err := http.Get(p.URL)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		defer rsp.Body.Close()
+		return rsp.StatusCode, rsp.Header
+	}
+
+	checkHeader := func(h http.Header, name, expected string) {
+		if v := h.Get(name); v != expected {
+			t.Errorf("invalid header %s, expected: %s, got: %s", name, expected, v)
+		}
+	}
+
+	for i, remaining := range []string{"1", "0"} {
+		code, h := request()
+		if code != http.StatusOK {
+			t.Fatalf("unexpected status code: %d", code)
+		}
+
+		checkHeader(h, ratelimit.LimitHeader, "2")
+		checkHeader(h, ratelimit.RemainingHeader, remaining)
+		checkHeader(h, ratelimit.LegacyLimitHeader, "2")
+		checkHeader(h, ratelimit.LegacyRemainingHeader, remaining)
+		if reset, err := strconv.Atoi(h.Get(ratelimit.ResetHeader)); err != nil || reset < 0 || reset > 60 {
+			t.Errorf("invalid reset header in request %d: %s", i, h.Get(ratelimit.ResetHeader))
+		}
+	}
+
+	code, h := request()
+	if code != http.StatusTooManyRequests {
+		t.Fatalf("unexpected status code: %d", code)
+	}
+
+	checkHeader(h, ratelimit.LimitHeader, "2")
+	checkHeader(h, ratelimit.RemainingHeader, "0")
+	checkHeader(h, ratelimit.ResetHeader, h.Get(ratelimit.RetryAfterHeader))
+	if reset, err := strconv.ParseInt(h.Get(ratelimit.LegacyResetHeader), 10, 64); err != nil || reset < time.Now().Unix() {
+		t.Errorf("invalid legacy reset header: %s", h.Get(ratelimit.LegacyResetHeader))
+	}
+}
@@ -0,0 +1,237 @@
+package proxy
+
+import (
+	stdlibcontext "context"
+	"errors"
+	"io"
+	"net"
+	"net/http"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/zalando/skipper/filters"
+)
+
+var errReadTimeout = errors.New("timeout while reading the request body")
+
+type connKey struct{}
+
+// ConnContext stores the client connection in the context of the
+// incoming requests. It can be set as the ConnContext of the http.Server
+// serving the proxy, and it allows the read timeout set by filters to
+// interrupt a blocked read of the request body, instead of waiting for
+// the read timeout of the server.
+func ConnContext(ctx stdlibcontext.Context, c net.Conn) stdlibcontext.Context {
+	return stdlibcontext.WithValue(ctx, connKey{}, c)
+}
+
+// backendTimeouts applies the route specific timeouts of the backend
+// request, set by filters in the state bag. The total timeout covers the
+// whole backend request, including reading the response body, while the
+// response header timeout only covers waiting for the response header.
+// With streams of Server-Sent Events, the total timeout only covers
+// waiting for the response header, too.
+type backendTimeouts struct {
+	ctx            stdlibcontext.Context
+	cancel         func()
+	totalTimer     *time.Timer
+	headerTimer    *time.Timer
+	totalTimedOut  int32
+	headerTimedOut int32
+}
+
+type cancelBody struct {
+	io.ReadCloser
+	cancel func()
+}
+
+func withBackendTimeouts(req *http.Request, bag map[string]interface{}) (*http.Request, *backendTimeouts) {
+	total, _ := bag[filters.BackendTimeoutKey].(time.Duration)
+	header, _ := bag[filters.BackendResponseHeaderTimeoutKey].(time.Duration)
+	if total <= 0 && header <= 0 {
+		return req, nil
+	}
+
+	t := &backendTimeouts{}
+	t.ctx, t.cancel = stdlibcontext.WithCancel(req.Context())
+	if total > 0 {
+		t.totalTimer = time.AfterFunc(total, func() {
+			atomic.StoreInt32(&t.totalTimedOut, 1)
+			t.cancel()
+		})
+	}
+
+	if header > 0 {
+		t.headerTimer = time.AfterFunc(header, func() {
+			atomic.StoreInt32(&t.headerTimedOut, 1)
+			t.cancel()
+		})
+	}
+
+	return req.WithContext(t.ctx), t
+}
+
+// exceeded tells whether the backend request was canceled by one of the
+// timeouts.
+func (t *backendTimeouts) exceeded() bool {
+	if t == nil {
+		return false
+	}
+
+	return atomic.LoadInt32(&t.headerTimedOut) == 1 || atomic.LoadInt32(&t.totalTimedOut) == 1
+}
+
+// received stops the response header timeout, and keeps the total
+// timeout running until the response body is closed, unless the
+// response is a stream.
+func (t *backendTimeouts) received(rsp *http.Response, stream bool) {
+	if t == nil {
+		return
+	}
+
+	if t.headerTimer != nil {
+		t.headerTimer.Stop()
+	}
+
+	if stream && t.totalTimer != nil {
+		t.totalTimer.Stop()
+	}
+
+	rsp.Body = &cancelBody{ReadCloser: rsp.Body, cancel: t.release}
+}
+
+// release stops the timeouts when the backend request failed, or the
+// response body was closed.
+func (t *backendTimeouts) release() {
+	if t == nil {
+		return
+	}
+
+	if t.totalTimer != nil {
+		t.totalTimer.Stop()
+	}
+
+	if t.headerTimer != nil {
+		t.headerTimer.Stop()
+	}
+
+	t.cancel()
+}
+
+func (b *cancelBody) Close() error {
+	defer b.cancel()
+	return b.ReadCloser.Close()
+}
+
+type readChunk struct {
+	data []byte
+	err  error
+}
+
+// readTimeoutBody fails reading the request body, when it cannot be
+// completed within the timeout. The underlying body is read by a
+// separate goroutine, so that a blocked read doesn't prevent returning
+// the timeout error.
+type readTimeoutBody struct {
+	body      io.ReadCloser
+	conn      net.Conn
+	timer     *time.Timer
+	chunks    chan readChunk
+	done      chan struct{}
+	start     sync.Once
+	closeOnce sync.Once
+	buf       []byte
+	err       error
+}
+
+// withReadTimeout sets the read timeout on the body of the backend request,
+// based on the incoming request.
+func withReadTimeout(req, incoming *http.Request, bag map[string]interface{}) {
+	timeout, _ := bag[filters.ReadTimeoutKey].(time.Duration)
+	if timeout <= 0 || req.Body == nil || req.Body == http.NoBody || req.ContentLength == 0 {
+		return
+	}
+
+	// with HTTP/1, the server cannot complete the response while a read
+	// of the body is blocked, so the connection is interrupted on timeout.
+	// HTTP/2 streams don't have this limitation, and they share the
+	// connection, so it must not be interrupted.
+	var conn net.Conn
+	if incoming.ProtoMajor == 1 {
+		conn, _ = incoming.Context().Value(connKey{}).(net.Conn)
+	}
+
+	req.Body = &readTimeoutBody{
+		body:   req.Body,
+		conn:   conn,
+		timer:  time.NewTimer(timeout),
+		chunks: make(chan readChunk),
+		done:   make(chan struct{}),
+	}
+}
+
+func (b *readTimeoutBody) pump() {
+	defer b.body.Close()
+	for {
+		buf := make([]byte, 32*1024)
+		n, err := b.body.Read(buf)
+		select {
+		case b.chunks <- readChunk{data: buf[:n], err: err}:
+		case <-b.done:
+			return
+		}
+
+		if err != nil {
+			return
+		}
+	}
+}
+
+func (b *readTimeoutBody) Read(p []byte) (int, error) {
+	if len(b.buf) > 0 {
+		n := copy(p, b.buf)
+		b.buf = b.buf[n:]
+		return n, nil
+	}
+
+	if b.err != nil {
+		return 0, b.err
+	}
+
+	b.start.Do(func() { go b.pump() })
+	select {
+	case c := <-b.chunks:
+		n := copy(p, c.data)
+		b.buf = c.data[n:]
+		if c.err != nil {
+			b.timer.Stop()
+			b.err = c.err
+			if len(b.buf) == 0 {
+				return n, c.err
+			}
+		}
+
+		return n, nil
+	case <-b.timer.C:
+		b.err = errReadTimeout
+		if b.conn != nil {
+			b.conn.SetReadDeadline(time.Now())
+		}
+
+		return 0, b.err
+	}
+}
+
+func (b *readTimeoutBody) Close() error {
+	b.closeOnce.Do(func() {
+		b.timer.Stop()
+		close(b.done)
+
+		// when reading was started, the reading goroutine closes the
+		// body, otherwise it is closed here
+		b.start.Do(func() { b.body.Close() })
+	})
+
+	return nil
+}
@@ -0,0 +1,169 @@
+package proxy
+
+import (
+	"fmt"
+	"io"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestBackendTimeouts(t *testing.T) {
+	for _, tt := range []struct {
+		msg          string
+		filter       string
+		headerDelay  time.Duration
+		bodyDelay    time.Duration
+		expectedCode int
+		expectedBody string
+	}{{
+		msg:          "response header timeout exceeded",
+		filter:       `backendResponseHeaderTimeout("50ms")`,
+		headerDelay:  time.Second,
+		expectedCode: http.StatusGatewayTimeout,
+	}, {
+		msg:          "response header timeout does not cover the body",
+		filter:       `backendResponseHeaderTimeout("50ms")`,
+		bodyDelay:    200 * time.Millisecond,
+		expectedCode: http.StatusOK,
+		expectedBody: "foobar",
+	}, {
+		msg:          "total timeout exceeded before the response header",
+		filter:       `backendTimeout("50ms")`,
+		headerDelay:  time.Second,
+		expectedCode: http.StatusGatewayTimeout,
+	}, {
+		msg:          "total timeout exceeded while receiving the body",
+		filter:       `backendTimeout("100ms")`,
+		bodyDelay:    time.Second,
+		expectedCode: http.StatusOK,
+		expectedBody: "foo",
+	}, {
+		msg:          "within the timeouts",
+		filter:       `backendTimeout("1s") -> backendResponseHeaderTimeout("1s")`,
+		bodyDelay:    10 * time.Millisecond,
+		expectedCode: http.StatusOK,
+		expectedBody: "foobar",
+	}} {
+		t.Run(tt.msg, func(t *testing.T) {
+			backend := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				select {
+				case <-time.After(tt.headerDelay):
+				case <-r.Context().Done():
+					return
+				}
+
+				w.Write([]byte("foo"))
+				w.(http.Flusher).Flush()
+
+				select {
+				case <-time.After(tt.bodyDelay):
+				case <-r.Context().Done():
+					return
+				}
+
+				w.Write([]byte("bar"))
+			}))
+			defer backend.Close()
+
+			tp, err := newTestProxy(fmt.Sprintf(`* -> %s -> "%s"`, tt.filter, backend.URL), FlagsNone)
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			defer tp.close()
+
+			ps := httptest.NewServer(tp.proxy)
+			defer ps.Close()
+
+			rsp, err := http.Get(ps.URL)
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			defer rsp.Body.Close()
+			if rsp.StatusCode != tt.expectedCode {
+				t.Fatalf("unexpected status code, expected: %d, got: %d", tt.expectedCode, rsp.StatusCode)
+			}
+
+			if tt.expectedCode != http.StatusOK {
+				return
+			}
+
+			b, _ := ioutil.ReadAll(rsp.Body)
+			if string(b) != tt.expectedBody {
+				t.Errorf("unexpected body, expected: %q, got: %q", tt.expectedBody, string(b))
+			}
+		})
+	}
+}
+
+func TestReadTimeout(t *testing.T) {
+	backend := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if _, err := ioutil.ReadAll(r.Body); err != nil {
+			return
+		}
+
+		w.Write([]byte("done"))
+	}))
+	defer backend.Close()
+
+	tp, err := newTestProxy(fmt.Sprintf(`* -> readTimeout("100ms") -> "%s"`, backend.URL), FlagsNone)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	defer tp.close()
+
+	ps := httptest.NewUnstartedServer(tp.proxy)
+	ps.Config.ConnContext = ConnContext
+	ps.Start()
+	defer ps.Close()
+
+	t.Run("body within the timeout", func(t *testing.T) {
+		rsp, err := http.Post(ps.URL, "text/plain", strings.NewReader("foo"))
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		defer rsp.Body.Close()
+		if rsp.StatusCode != http.StatusOK {
+			t.Errorf("unexpected status code: %d", rsp.StatusCode)
+		}
+	})
+
+	t.Run("slow body", func(t *testing.T) {
+		pr, pw := io.Pipe()
+		defer pw.Close()
+		go pw.Write([]byte("foo"))
+
+		rsp, err := http.Post(ps.URL, "text/plain", pr)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		defer rsp.Body.Close()
+		if rsp.StatusCode != http.StatusRequestTimeout {
+			t.Errorf("unexpected status code: %d", rsp.StatusCode)
+		}
+	})
+}
+
+func TestReadTimeoutBody(t *testing.T) {
+	req := httptest.NewRequest("POST", "/", strings.NewReader(strings.Repeat("foo", 20000)))
+	withReadTimeout(req, req, map[string]interface{}{"request:readtimeout": time.Second})
+
+	b, err := ioutil.ReadAll(req.Body)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if len(b) != 60000 {
+		t.Errorf("unexpected body length: %d", len(b))
+	}
+
+	req.Body.Close()
+}
@@ -0,0 +1,155 @@
+package proxy
+
+import (
+	"container/list"
+	"crypto/tls"
+	"fmt"
+	"net"
+	"net/http"
+	"sync"
+	"time"
+
+	"github.com/zalando/skipper/filters"
+)
+
+// transportSettings contains the settings of the backend transport, that
+// can be set per route by filters. The zero value means the settings of
+// the base transport.
+type transportSettings struct {
+	cert                *tls.Certificate
+	maxIdleConnsPerHost int
+	disableKeepAlives   bool
+	idleConnTimeout     time.Duration
+	proxyProtocol       int
+}
+
+func transportSettingsFromBag(bag map[string]interface{}) transportSettings {
+	var s transportSettings
+	s.cert, _ = bag[filters.BackendClientCertificateKey].(*tls.Certificate)
+	s.maxIdleConnsPerHost, _ = bag[filters.BackendMaxIdleConnsPerHostKey].(int)
+	s.disableKeepAlives, _ = bag[filters.BackendDisableKeepAlivesKey].(bool)
+	s.idleConnTimeout, _ = bag[filters.BackendIdleConnTimeoutKey].(time.Duration)
+	s.proxyProtocol, _ = bag[filters.BackendProxyProtocolKey].(int)
+	if s.cert != nil && len(s.cert.Certificate) == 0 {
+		s.cert = nil
+	}
+
+	return s
+}
+
+func (s transportSettings) key() string {
+	var cert string
+	if s.cert != nil {
+		// the leaf certificate identifies the key pair
+		cert = string(s.cert.Certificate[0])
+	}
+
+	return fmt.Sprintf("%d/%t/%v/%d/%s", s.maxIdleConnsPerHost, s.disableKeepAlives, s.idleConnTimeout, s.proxyProtocol, cert)
+}
+
+// maxBackendTransports limits the number of the transports created for
+// the settings set by filters. The settings change e.g. when the client
+// certificates are rotated, so the least recently used transports are
+// evicted.
+const maxBackendTransports = 256
+
+type backendTransport struct {
+	key string
+	tr  *http.Transport
+	h2c *h2cRoundTripper
+}
+
+// backendTransports holds a separate transport for every combination of
+// the settings set by filters, because the client certificates are part
+// of the transport's TLS configuration, and the connection reuse settings
+// are part of the transport's connection pool
+type backendTransports struct {
+	mu         sync.Mutex
+	base       *http.Transport
+	transports map[string]*list.Element
+	lru        *list.List
+}
+
+func newBackendTransports(base *http.Transport) *backendTransports {
+	return &backendTransports{
+		base:       base,
+		transports: make(map[string]*list.Element),
+		lru:        list.New(),
+	}
+}
+
+func (t *backendTransports) get(s transportSettings) *http.Transport {
+	if s == (transportSettings{}) {
+		return t.base
+	}
+
+	key := s.key()
+
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	if e, ok := t.transports[key]; ok {
+		t.lru.MoveToFront(e)
+		return e.Value.(*backendTransport).tr
+	}
+
+	tr := t.base.Clone()
+	if s.cert != nil {
+		if tr.TLSClientConfig == nil {
+			tr.TLSClientConfig = &tls.Config{}
+		}
+
+		tr.TLSClientConfig.Certificates = []tls.Certificate{*s.cert}
+	}
+
+	if s.maxIdleConnsPerHost > 0 {
+		tr.MaxIdleConnsPerHost = s.maxIdleConnsPerHost
+	}
+
+	if s.disableKeepAlives {
+		tr.DisableKeepAlives = true
+	}
+
+	if s.idleConnTimeout > 0 {
+		tr.IdleConnTimeout = s.idleConnTimeout
+	}
+
+	if s.proxyProtocol > 0 {
+		// the header is sent once per connection, and it carries the
+		// address of a single client
+		tr.DisableKeepAlives = true
+		tr.DialContext = proxyProtocolDial(tr.DialContext, s.proxyProtocol)
+	}
+
+	// the registered protocols are not cloned
+	dial := tr.DialContext
+	if dial == nil {
+		dial = (&net.Dialer{}).DialContext
+	}
+
+	h2c := newH2CRoundTripper(dial)
+	tr.RegisterProtocol(h2cScheme, h2c)
+
+	t.transports[key] = t.lru.PushFront(&backendTransport{key: key, tr: tr, h2c: h2c})
+	if t.lru.Len() > maxBackendTransports {
+		e := t.lru.Back()
+		t.lru.Remove(e)
+		evicted := e.Value.(*backendTransport)
+		delete(t.transports, evicted.key)
+		evicted.closeIdleConnections()
+	}
+
+	return tr
+}
+
+func (bt *backendTransport) closeIdleConnections() {
+	bt.tr.CloseIdleConnections()
+	bt.h2c.CloseIdleConnections()
+}
+
+func (t *backendTransports) closeIdleConnections() {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	for _, e := range t.transports {
+		e.Value.(*backendTransport).closeIdleConnections()
+	}
+}
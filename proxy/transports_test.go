@@ -0,0 +1,99 @@
+package proxy
+
+import (
+	"fmt"
+	"io/ioutil"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+func TestBackendTransportSettings(t *testing.T) {
+	base := &http.Transport{MaxIdleConnsPerHost: 64, IdleConnTimeout: time.Minute}
+	trs := newBackendTransports(base)
+	if trs.get(transportSettings{}) != base {
+		t.Fatal("base transport not used without settings")
+	}
+
+	s := transportSettings{maxIdleConnsPerHost: 8, idleConnTimeout: time.Second}
+	tr := trs.get(s)
+	if tr == base || tr.MaxIdleConnsPerHost != 8 || tr.IdleConnTimeout != time.Second || tr.DisableKeepAlives {
+		t.Errorf("unexpected transport settings: %d, %v, %t", tr.MaxIdleConnsPerHost, tr.IdleConnTimeout, tr.DisableKeepAlives)
+	}
+
+	if trs.get(s) != tr {
+		t.Error("transport not reused for the same settings")
+	}
+
+	noKeepAlive := trs.get(transportSettings{disableKeepAlives: true})
+	if noKeepAlive == tr || !noKeepAlive.DisableKeepAlives || noKeepAlive.MaxIdleConnsPerHost != 64 {
+		t.Error("unexpected transport without keep-alives")
+	}
+}
+
+func TestBackendTransportsEvicted(t *testing.T) {
+	trs := newBackendTransports(&http.Transport{})
+	first := trs.get(transportSettings{maxIdleConnsPerHost: 1})
+	for i := 0; i < maxBackendTransports; i++ {
+		trs.get(transportSettings{maxIdleConnsPerHost: i + 2})
+	}
+
+	if len(trs.transports) != maxBackendTransports || trs.lru.Len() != maxBackendTransports {
+		t.Fatalf("unexpected number of transports: %d", len(trs.transports))
+	}
+
+	if trs.get(transportSettings{maxIdleConnsPerHost: 1}) == first {
+		t.Error("least recently used transport not evicted")
+	}
+}
+
+func TestBackendDisableKeepAlives(t *testing.T) {
+	var conns int32
+	backend := httptest.NewUnstartedServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {}))
+	backend.Config.ConnState = func(_ net.Conn, s http.ConnState) {
+		if s == http.StateNew {
+			atomic.AddInt32(&conns, 1)
+		}
+	}
+	backend.Start()
+	defer backend.Close()
+
+	doc := fmt.Sprintf(`
+		reuse: Path("/reuse") -> "%s";
+		noreuse: Path("/noreuse") -> backendDisableKeepAlives() -> "%s";
+	`, backend.URL, backend.URL)
+	tp, err := newTestProxy(doc, FlagsNone)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	defer tp.close()
+	ps := httptest.NewServer(tp.proxy)
+	defer ps.Close()
+
+	for _, tt := range []struct {
+		path          string
+		expectedConns int32
+	}{
+		{"/reuse", 1},
+		{"/noreuse", 3},
+	} {
+		atomic.StoreInt32(&conns, 0)
+		for i := 0; i < 3; i++ {
+			rsp, err := http.Get(ps.URL + tt.path)
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			ioutil.ReadAll(rsp.Body)
+			rsp.Body.Close()
+		}
+
+		if n := atomic.LoadInt32(&conns); n != tt.expectedConns {
+			t.Errorf("%s: unexpected number of backend connections, expected: %d, got: %d", tt.path, tt.expectedConns, n)
+		}
+	}
+}
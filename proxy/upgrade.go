@@ -12,8 +12,10 @@ import (
 	"net/url"
 	"strings"
 	"sync"
+	"time"
 
 	log "github.com/sirupsen/logrus"
+	"github.com/zalando/skipper/metrics"
 )
 
 // isUpgradeRequest returns true if and only if there is a "Connection"
@@ -47,6 +49,10 @@ type upgradeProxy struct {
 	auditLogOut     io.Writer
 	auditLogErr     io.Writer
 	auditLogHook    chan struct{}
+
+	metrics              metrics.Metrics
+	webSocketIdleTimeout time.Duration
+	webSocketConnections *int64
 }
 
 // TODO: add user here
@@ -106,7 +112,8 @@ func (p *upgradeProxy) serveHTTP(w http.ResponseWriter, req *http.Request) {
 		}
 	}
 
-	resp, err := http.ReadResponse(bufio.NewReader(backendConn), req)
+	backendBuf := bufio.NewReader(backendConn)
+	resp, err := http.ReadResponse(backendBuf, req)
 	if err != nil {
 		log.Errorf("Error reading response from backend: %s", err)
 		w.WriteHeader(http.StatusInternalServerError)
@@ -133,7 +140,17 @@ func (p *upgradeProxy) serveHTTP(w http.ResponseWriter, req *http.Request) {
 		return
 	}
 
-	requestHijackedConn, _, err := w.(http.Hijacker).Hijack()
+	webSocket := isWebSocketHandshake(req)
+	if webSocket {
+		if err := checkWebSocketProtocol(req, resp); err != nil {
+			log.Errorf("Error negotiating websocket subprotocol: %v", err)
+			w.WriteHeader(http.StatusBadGateway)
+			w.Write([]byte(http.StatusText(http.StatusBadGateway)))
+			return
+		}
+	}
+
+	requestHijackedConn, clientBuf, err := w.(http.Hijacker).Hijack()
 	if err != nil {
 		log.Errorf("Error hijacking request connection: %s", err)
 		w.WriteHeader(http.StatusInternalServerError)
@@ -150,19 +167,24 @@ func (p *upgradeProxy) serveHTTP(w http.ResponseWriter, req *http.Request) {
 		return
 	}
 
-	var wg sync.WaitGroup
-	wg.Add(2)
-
-	if p.useAuditLog {
-		copyAsync(&wg, backendConn, requestHijackedConn, p.auditLogOut)
+	if webSocket {
+		log.Debugf("Successfully upgraded to protocol %s by user request", getUpgradeRequest(req))
+		p.serveWebSocket(requestHijackedConn, clientBuf.Reader, backendConn, backendBuf)
 	} else {
-		copyAsync(&wg, backendConn, requestHijackedConn)
-	}
+		var wg sync.WaitGroup
+		wg.Add(2)
 
-	copyAsync(&wg, requestHijackedConn, backendConn)
-	log.Debugf("Successfully upgraded to protocol %s by user request", getUpgradeRequest(req))
-	// Wait for goroutine to finish, such that the established connection does not break.
-	wg.Wait()
+		if p.useAuditLog {
+			copyAsync(&wg, backendConn, requestHijackedConn, p.auditLogOut)
+		} else {
+			copyAsync(&wg, backendConn, requestHijackedConn)
+		}
+
+		copyAsync(&wg, requestHijackedConn, backendConn)
+		log.Debugf("Successfully upgraded to protocol %s by user request", getUpgradeRequest(req))
+		// Wait for goroutine to finish, such that the established connection does not break.
+		wg.Wait()
+	}
 
 	if p.useAuditLog {
 		select {
@@ -0,0 +1,397 @@
+package proxy
+
+import (
+	"bufio"
+	"crypto/rand"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"net/http"
+	"strings"
+	"sync/atomic"
+	"time"
+
+	log "github.com/sirupsen/logrus"
+	"github.com/zalando/skipper/metrics"
+)
+
+const (
+	webSocketOpClose = 0x8
+
+	webSocketCloseGoingAway = 1001
+	webSocketCloseNoStatus  = 1005
+	webSocketCloseAbnormal  = 1006
+
+	// maximum payload length of the control frames, including the close
+	// frames, https://tools.ietf.org/html/rfc6455#section-5.5
+	webSocketMaxControlPayload = 125
+
+	// time to wait for the peer to answer a close frame, before closing
+	// the connections
+	webSocketCloseTimeout = 5 * time.Second
+)
+
+var (
+	errWebSocketIdle            = errors.New("websocket idle timeout")
+	errWebSocketControlTooLarge = errors.New("websocket control frame too large")
+)
+
+// isWebSocketHandshake tells whether the upgrade request is a websocket
+// opening handshake, as defined in https://tools.ietf.org/html/rfc6455#section-4.1.
+// Only these connections are handled frame by frame, other upgraded
+// connections are copied as a byte stream.
+func isWebSocketHandshake(req *http.Request) bool {
+	return strings.ToLower(req.Header.Get("Upgrade")) == "websocket" &&
+		req.Header.Get("Sec-Websocket-Key") != "" &&
+		req.Header.Get("Sec-Websocket-Version") != ""
+}
+
+// webSocketProtocols returns the subprotocols listed in the
+// Sec-WebSocket-Protocol header fields.
+func webSocketProtocols(h http.Header) []string {
+	var p []string
+	for _, hi := range h[http.CanonicalHeaderKey("Sec-WebSocket-Protocol")] {
+		for _, pi := range strings.Split(hi, ",") {
+			if pi = strings.TrimSpace(pi); pi != "" {
+				p = append(p, pi)
+			}
+		}
+	}
+
+	return p
+}
+
+// checkWebSocketProtocol verifies that the subprotocol selected by the
+// backend is one of those offered by the client. A backend may select at
+// most one subprotocol, and only when the client offered any.
+func checkWebSocketProtocol(req *http.Request, rsp *http.Response) error {
+	selected := webSocketProtocols(rsp.Header)
+	if len(selected) == 0 {
+		return nil
+	}
+
+	if len(selected) > 1 {
+		return fmt.Errorf("multiple websocket subprotocols selected by the backend: %s", strings.Join(selected, ", "))
+	}
+
+	for _, p := range webSocketProtocols(req.Header) {
+		if p == selected[0] {
+			return nil
+		}
+	}
+
+	return fmt.Errorf("websocket subprotocol not offered by the client: %s", selected[0])
+}
+
+type webSocketFrameHeader struct {
+	raw    []byte
+	opcode byte
+	masked bool
+	mask   [4]byte
+	length int64
+}
+
+func readWebSocketFrameHeader(r io.Reader) (h webSocketFrameHeader, err error) {
+	var b [14]byte
+	if _, err = io.ReadFull(r, b[:2]); err != nil {
+		return
+	}
+
+	n := 2
+	h.opcode = b[0] & 0x0f
+	h.masked = b[1]&0x80 != 0
+	switch l := b[1] & 0x7f; l {
+	case 126:
+		if _, err = io.ReadFull(r, b[n:n+2]); err != nil {
+			return
+		}
+
+		h.length = int64(binary.BigEndian.Uint16(b[n:]))
+		n += 2
+	case 127:
+		if _, err = io.ReadFull(r, b[n:n+8]); err != nil {
+			return
+		}
+
+		h.length = int64(binary.BigEndian.Uint64(b[n:]))
+		n += 8
+	default:
+		h.length = int64(l)
+	}
+
+	if h.masked {
+		if _, err = io.ReadFull(r, b[n:n+4]); err != nil {
+			return
+		}
+
+		copy(h.mask[:], b[n:])
+		n += 4
+	}
+
+	h.raw = make([]byte, n)
+	copy(h.raw, b[:n])
+	return
+}
+
+// webSocketCloseFrame creates a close frame with the given status code.
+// Frames sent to the backend need to be masked.
+func webSocketCloseFrame(code int, reason string, masked bool) []byte {
+	payload := make([]byte, 2+len(reason))
+	binary.BigEndian.PutUint16(payload, uint16(code))
+	copy(payload[2:], reason)
+
+	f := []byte{0x80 | webSocketOpClose, byte(len(payload))}
+	if masked {
+		var mask [4]byte
+		rand.Read(mask[:])
+		f[1] |= 0x80
+		f = append(f, mask[:]...)
+		for i := range payload {
+			payload[i] ^= mask[i%4]
+		}
+	}
+
+	return append(f, payload...)
+}
+
+// webSocketSession tracks the activity of a proxied websocket connection
+// in both directions, to enforce the idle timeout.
+type webSocketSession struct {
+	idleTimeout  time.Duration
+	lastActivity int64
+}
+
+func (s *webSocketSession) touch() {
+	atomic.StoreInt64(&s.lastActivity, time.Now().UnixNano())
+}
+
+func (s *webSocketSession) idle() bool {
+	last := time.Unix(0, atomic.LoadInt64(&s.lastActivity))
+	return time.Since(last) >= s.idleTimeout
+}
+
+// webSocketReader reads one side of the connection. It reads first the
+// data that was already buffered during the handshake, and enforces the
+// idle timeout, which is reset by the activity in either direction.
+type webSocketReader struct {
+	session  *webSocketSession
+	conn     net.Conn
+	buffered io.Reader
+}
+
+func newWebSocketReader(s *webSocketSession, conn net.Conn, buf *bufio.Reader) *webSocketReader {
+	var buffered io.Reader
+	if n := buf.Buffered(); n > 0 {
+		buffered = io.LimitReader(buf, int64(n))
+	}
+
+	return &webSocketReader{session: s, conn: conn, buffered: buffered}
+}
+
+func (r *webSocketReader) Read(p []byte) (int, error) {
+	if r.buffered != nil {
+		n, err := r.buffered.Read(p)
+		if err == io.EOF {
+			r.buffered = nil
+			err = nil
+		}
+
+		if n > 0 || err != nil {
+			r.session.touch()
+			return n, err
+		}
+	}
+
+	for {
+		if r.session.idleTimeout > 0 {
+			r.conn.SetReadDeadline(time.Now().Add(r.session.idleTimeout))
+		}
+
+		n, err := r.conn.Read(p)
+		if n > 0 {
+			r.session.touch()
+			return n, nil
+		}
+
+		if nerr, ok := err.(net.Error); ok && nerr.Timeout() && r.session.idleTimeout > 0 {
+			if r.session.idle() {
+				return 0, errWebSocketIdle
+			}
+
+			// the other direction was active
+			continue
+		}
+
+		return n, err
+	}
+}
+
+// relayWebSocket copies the websocket frames from src to dst until a close frame
+// was forwarded, and returns its status code. When the connection ends
+// without a close frame, it returns an error.
+func relayWebSocket(dst io.Writer, src io.Reader) (int, error) {
+	for {
+		h, err := readWebSocketFrameHeader(src)
+		if err != nil {
+			return 0, err
+		}
+
+		if _, err := dst.Write(h.raw); err != nil {
+			return 0, err
+		}
+
+		if h.opcode != webSocketOpClose {
+			if _, err := io.CopyN(dst, src, h.length); err != nil {
+				return 0, err
+			}
+
+			continue
+		}
+
+		if h.length > webSocketMaxControlPayload {
+			return 0, errWebSocketControlTooLarge
+		}
+
+		payload := make([]byte, h.length)
+		if _, err := io.ReadFull(src, payload); err != nil {
+			return 0, err
+		}
+
+		if _, err := dst.Write(payload); err != nil {
+			return 0, err
+		}
+
+		code := webSocketCloseNoStatus
+		if len(payload) >= 2 {
+			if h.masked {
+				payload[0] ^= h.mask[0]
+				payload[1] ^= h.mask[1]
+			}
+
+			code = int(binary.BigEndian.Uint16(payload))
+		}
+
+		return code, nil
+	}
+}
+
+type webSocketRelayResult struct {
+	fromClient bool
+	code       int
+	err        error
+}
+
+// serveWebSocket proxies the frames of an upgraded websocket connection
+// in both directions. The close frames are forwarded with their status
+// code, and when one side of the connection is lost or idle, the other
+// side receives a close frame with the status Going Away.
+func (p *upgradeProxy) serveWebSocket(
+	client net.Conn,
+	clientBuf *bufio.Reader,
+	backend net.Conn,
+	backendBuf *bufio.Reader,
+) {
+	start := time.Now()
+	m := p.metrics
+	if m == nil {
+		m = metrics.Default
+	}
+
+	if p.webSocketConnections != nil {
+		m.UpdateGauge("websocket.connections", float64(atomic.AddInt64(p.webSocketConnections, 1)))
+		defer func() {
+			m.UpdateGauge("websocket.connections", float64(atomic.AddInt64(p.webSocketConnections, -1)))
+		}()
+	}
+
+	m.IncCounter("websocket.upgrades")
+	defer m.MeasureSince("websocket.duration", start)
+
+	s := &webSocketSession{idleTimeout: p.webSocketIdleTimeout}
+	s.touch()
+
+	var toClient io.Writer = client
+	if p.useAuditLog {
+		toClient = io.MultiWriter(client, p.auditLogOut)
+	}
+
+	results := make(chan webSocketRelayResult, 2)
+	go func() {
+		code, err := relayWebSocket(backend, newWebSocketReader(s, client, clientBuf))
+		results <- webSocketRelayResult{fromClient: true, code: code, err: err}
+	}()
+
+	go func() {
+		code, err := relayWebSocket(toClient, newWebSocketReader(s, backend, backendBuf))
+		results <- webSocketRelayResult{code: code, err: err}
+	}()
+
+	closeAll := func() {
+		client.Close()
+		backend.Close()
+	}
+
+	first := <-results
+	m.IncCounter(webSocketCloseMetric(first))
+	switch first.err {
+	case nil:
+	case errWebSocketIdle:
+		m.IncCounter("websocket.idletimeout")
+		log.Debugf("websocket connection idle for %v", s.idleTimeout)
+	default:
+		if first.err != io.EOF && !isClosedConnError(first.err) {
+			log.Debugf("error proxying websocket frames: %v", first.err)
+		}
+	}
+
+	switch {
+	case first.err == errWebSocketIdle:
+		// no frames are in flight in either direction, both sides are
+		// notified, and the connections are closed without waiting for
+		// the close handshake
+		backend.Write(webSocketCloseFrame(webSocketCloseGoingAway, "idle timeout", true))
+		client.Write(webSocketCloseFrame(webSocketCloseGoingAway, "idle timeout", false))
+		closeAll()
+	case first.err != nil:
+		// only the finished relay was writing to this side, so the close
+		// frame doesn't interfere with other frames
+		if first.fromClient {
+			backend.Write(webSocketCloseFrame(webSocketCloseGoingAway, "", true))
+		} else {
+			client.Write(webSocketCloseFrame(webSocketCloseGoingAway, "", false))
+		}
+	}
+
+	// waiting for the other side to answer the close frame
+	t := time.AfterFunc(webSocketCloseTimeout, closeAll)
+	second := <-results
+	t.Stop()
+	if second.err == nil {
+		m.IncCounter(webSocketCloseMetric(second))
+	}
+
+	closeAll()
+}
+
+// webSocketCloseMetric returns the counter key of the close status, by
+// the side that closed the connection. When a connection was lost without
+// a close frame, the status is reported as Abnormal Closure.
+func webSocketCloseMetric(r webSocketRelayResult) string {
+	side := "backend"
+	if r.fromClient {
+		side = "client"
+	}
+
+	code := r.code
+	if r.err != nil {
+		code = webSocketCloseAbnormal
+	}
+
+	return fmt.Sprintf("websocket.close.%s.%d", side, code)
+}
+
+func isClosedConnError(err error) bool {
+	return strings.Contains(err.Error(), "use of closed network connection")
+}
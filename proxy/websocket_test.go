@@ -0,0 +1,350 @@
+package proxy
+
+import (
+	"bufio"
+	"encoding/binary"
+	"fmt"
+	"io"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"testing"
+	"time"
+
+	"github.com/zalando/skipper/metrics"
+	"github.com/zalando/skipper/metrics/metricstest"
+)
+
+// webSocketTestMetrics records only the counters
+type webSocketTestMetrics struct {
+	metrics.Metrics
+	counters *metricstest.MockMetrics
+}
+
+func (m webSocketTestMetrics) IncCounter(key string) { m.counters.IncCounter(key) }
+
+func TestCheckWebSocketProtocol(t *testing.T) {
+	for _, ti := range []struct {
+		msg      string
+		offered  []string
+		selected []string
+		fail     bool
+	}{{
+		msg: "no subprotocol",
+	}, {
+		msg:     "none selected",
+		offered: []string{"chat, superchat"},
+	}, {
+		msg:      "offered selected",
+		offered:  []string{"chat, superchat"},
+		selected: []string{"superchat"},
+	}, {
+		msg:      "offered in multiple fields",
+		offered:  []string{"chat", "superchat"},
+		selected: []string{"superchat"},
+	}, {
+		msg:      "not offered selected",
+		offered:  []string{"chat"},
+		selected: []string{"superchat"},
+		fail:     true,
+	}, {
+		msg:      "selected without offer",
+		selected: []string{"chat"},
+		fail:     true,
+	}, {
+		msg:      "multiple selected",
+		offered:  []string{"chat, superchat"},
+		selected: []string{"chat, superchat"},
+		fail:     true,
+	}} {
+		t.Run(ti.msg, func(t *testing.T) {
+			req := &http.Request{Header: http.Header{"Sec-Websocket-Protocol": ti.offered}}
+			rsp := &http.Response{Header: http.Header{"Sec-Websocket-Protocol": ti.selected}}
+			err := checkWebSocketProtocol(req, rsp)
+			if ti.fail && err == nil {
+				t.Error("failed to fail")
+			} else if !ti.fail && err != nil {
+				t.Error(err)
+			}
+		})
+	}
+}
+
+// webSocketBackend accepts the upgrade with the given subprotocol, and
+// passes the connection to the handler function.
+func webSocketBackend(t *testing.T, protocol string, handle func(net.Conn, *bufio.Reader)) *httptest.Server {
+	return httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		conn, rw, err := w.(http.Hijacker).Hijack()
+		if err != nil {
+			t.Error(err)
+			return
+		}
+
+		defer conn.Close()
+		rsp := "HTTP/1.1 101 Switching Protocols\r\nUpgrade: websocket\r\nConnection: Upgrade\r\nSec-WebSocket-Accept: test\r\n"
+		if protocol != "" {
+			rsp += "Sec-WebSocket-Protocol: " + protocol + "\r\n"
+		}
+
+		if _, err := conn.Write([]byte(rsp + "\r\n")); err != nil {
+			t.Error(err)
+			return
+		}
+
+		handle(conn, rw.Reader)
+	}))
+}
+
+func dialWebSocket(t *testing.T, proxyURL, protocol string) (net.Conn, *bufio.Reader, *http.Response) {
+	u, err := url.Parse(proxyURL)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	conn, err := net.Dial("tcp", u.Host)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	req, err := http.NewRequest("GET", proxyURL+"/ws", nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	req.Header.Set("Connection", "Upgrade")
+	req.Header.Set("Upgrade", "websocket")
+	req.Header.Set("Sec-WebSocket-Key", "dGhlIHNhbXBsZSBub25jZQ==")
+	req.Header.Set("Sec-WebSocket-Version", "13")
+	if protocol != "" {
+		req.Header.Set("Sec-WebSocket-Protocol", protocol)
+	}
+
+	if err := req.Write(conn); err != nil {
+		t.Fatal(err)
+	}
+
+	r := bufio.NewReader(conn)
+	rsp, err := http.ReadResponse(r, req)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	return conn, r, rsp
+}
+
+// readCloseCode reads frames until a close frame, and returns its code.
+func readCloseCode(r io.Reader) (int, error) {
+	for {
+		h, err := readWebSocketFrameHeader(r)
+		if err != nil {
+			return 0, err
+		}
+
+		payload := make([]byte, h.length)
+		if _, err := io.ReadFull(r, payload); err != nil {
+			return 0, err
+		}
+
+		if h.opcode != webSocketOpClose {
+			continue
+		}
+
+		if h.masked {
+			for i := range payload {
+				payload[i] ^= h.mask[i%4]
+			}
+		}
+
+		if len(payload) < 2 {
+			return webSocketCloseNoStatus, nil
+		}
+
+		return int(binary.BigEndian.Uint16(payload)), nil
+	}
+}
+
+func newWebSocketTestProxy(t *testing.T, backendURL string, idleTimeout time.Duration) (*testProxy, *httptest.Server, *metricstest.MockMetrics) {
+	tp, err := newTestProxyWithParams(
+		fmt.Sprintf(`* -> "%s"`, backendURL),
+		Params{ExperimentalUpgrade: true, WebSocketIdleTimeout: idleTimeout},
+	)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	m := &metricstest.MockMetrics{}
+	tp.proxy.metrics = webSocketTestMetrics{Metrics: metrics.Void, counters: m}
+	return tp, httptest.NewServer(tp.proxy), m
+}
+
+func TestWebSocketSubprotocol(t *testing.T) {
+	for _, ti := range []struct {
+		msg            string
+		offered        string
+		selected       string
+		expectedStatus int
+	}{{
+		msg:            "negotiated",
+		offered:        "chat, superchat",
+		selected:       "superchat",
+		expectedStatus: http.StatusSwitchingProtocols,
+	}, {
+		msg:            "not offered",
+		offered:        "chat",
+		selected:       "superchat",
+		expectedStatus: http.StatusBadGateway,
+	}} {
+		t.Run(ti.msg, func(t *testing.T) {
+			backend := webSocketBackend(t, ti.selected, func(net.Conn, *bufio.Reader) {})
+			defer backend.Close()
+
+			tp, ps, _ := newWebSocketTestProxy(t, backend.URL, 0)
+			defer tp.close()
+			defer ps.Close()
+
+			conn, _, rsp := dialWebSocket(t, ps.URL, ti.offered)
+			defer conn.Close()
+			if rsp.StatusCode != ti.expectedStatus {
+				t.Fatalf("unexpected status, expected: %d, got: %d", ti.expectedStatus, rsp.StatusCode)
+			}
+
+			if ti.expectedStatus == http.StatusSwitchingProtocols {
+				if p := rsp.Header.Get("Sec-WebSocket-Protocol"); p != ti.selected {
+					t.Errorf("unexpected subprotocol, expected: %s, got: %s", ti.selected, p)
+				}
+			}
+		})
+	}
+}
+
+func TestWebSocketClosePropagation(t *testing.T) {
+	backendReceived := make(chan int, 1)
+	backend := webSocketBackend(t, "", func(conn net.Conn, r *bufio.Reader) {
+		if _, err := conn.Write(webSocketCloseFrame(4000, "bye", false)); err != nil {
+			t.Error(err)
+			return
+		}
+
+		code, err := readCloseCode(r)
+		if err != nil {
+			t.Error(err)
+			return
+		}
+
+		backendReceived <- code
+	})
+	defer backend.Close()
+
+	tp, ps, _ := newWebSocketTestProxy(t, backend.URL, 0)
+	defer tp.close()
+	defer ps.Close()
+
+	conn, r, rsp := dialWebSocket(t, ps.URL, "")
+	defer conn.Close()
+	if rsp.StatusCode != http.StatusSwitchingProtocols {
+		t.Fatalf("failed to upgrade: %d", rsp.StatusCode)
+	}
+
+	code, err := readCloseCode(r)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if code != 4000 {
+		t.Errorf("unexpected close code received by the client: %d", code)
+	}
+
+	if _, err := conn.Write(webSocketCloseFrame(4001, "", true)); err != nil {
+		t.Fatal(err)
+	}
+
+	select {
+	case code := <-backendReceived:
+		if code != 4001 {
+			t.Errorf("unexpected close code received by the backend: %d", code)
+		}
+	case <-time.After(3 * time.Second):
+		t.Fatal("close frame not received by the backend")
+	}
+}
+
+func TestWebSocketBackendLost(t *testing.T) {
+	backend := webSocketBackend(t, "", func(net.Conn, *bufio.Reader) {})
+	defer backend.Close()
+
+	tp, ps, _ := newWebSocketTestProxy(t, backend.URL, 0)
+	defer tp.close()
+	defer ps.Close()
+
+	conn, r, rsp := dialWebSocket(t, ps.URL, "")
+	defer conn.Close()
+	if rsp.StatusCode != http.StatusSwitchingProtocols {
+		t.Fatalf("failed to upgrade: %d", rsp.StatusCode)
+	}
+
+	code, err := readCloseCode(r)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if code != webSocketCloseGoingAway {
+		t.Errorf("unexpected close code: %d", code)
+	}
+}
+
+func TestWebSocketIdleTimeout(t *testing.T) {
+	backendReceived := make(chan int, 1)
+	backend := webSocketBackend(t, "", func(_ net.Conn, r *bufio.Reader) {
+		code, err := readCloseCode(r)
+		if err != nil {
+			t.Error(err)
+			return
+		}
+
+		backendReceived <- code
+	})
+	defer backend.Close()
+
+	tp, ps, m := newWebSocketTestProxy(t, backend.URL, 100*time.Millisecond)
+	defer tp.close()
+	defer ps.Close()
+
+	conn, r, rsp := dialWebSocket(t, ps.URL, "")
+	defer conn.Close()
+	if rsp.StatusCode != http.StatusSwitchingProtocols {
+		t.Fatalf("failed to upgrade: %d", rsp.StatusCode)
+	}
+
+	start := time.Now()
+	codes := make(chan int, 1)
+	go func() {
+		code, err := readCloseCode(r)
+		if err != nil {
+			t.Error(err)
+		}
+
+		codes <- code
+	}()
+
+	for _, c := range []chan int{backendReceived, codes} {
+		select {
+		case code := <-c:
+			if code != webSocketCloseGoingAway {
+				t.Errorf("unexpected close code: %d", code)
+			}
+		case <-time.After(3 * time.Second):
+			t.Fatal("connection not closed after the idle timeout")
+		}
+	}
+
+	if d := time.Since(start); d < 100*time.Millisecond {
+		t.Errorf("connection closed too early: %v", d)
+	}
+
+	m.WithCounters(func(c map[string]int64) {
+		if c["websocket.idletimeout"] != 1 || c["websocket.upgrades"] != 1 {
+			t.Errorf("unexpected counters: %v", c)
+		}
+	})
+}
@@ -0,0 +1,89 @@
+package proxyprotocol
+
+import (
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"io"
+	"net"
+)
+
+// ErrUnsupportedVersion is returned when writing a header of a version
+// other than 1 or 2.
+var ErrUnsupportedVersion = errors.New("unsupported PROXY protocol version")
+
+// tcpAddrs returns the TCP addresses and whether they are of the same
+// family, which is required to send them in the header
+func tcpAddrs(src, dst net.Addr) (*net.TCPAddr, *net.TCPAddr, bool) {
+	s, ok := src.(*net.TCPAddr)
+	if !ok {
+		return nil, nil, false
+	}
+
+	d, ok := dst.(*net.TCPAddr)
+	if !ok {
+		return nil, nil, false
+	}
+
+	return s, d, (s.IP.To4() == nil) == (d.IP.To4() == nil)
+}
+
+// WriteHeader writes the PROXY protocol header of the given version, 1
+// or 2, announcing a connection from the source to the destination
+// address. When the addresses are not TCP addresses of the same family,
+// the header doesn't carry them: in version 1, the UNKNOWN protocol is
+// sent, and in version 2, the LOCAL command.
+func WriteHeader(w io.Writer, version int, src, dst net.Addr) error {
+	var h []byte
+	switch version {
+	case 1:
+		h = headerV1(src, dst)
+	case 2:
+		h = headerV2(src, dst)
+	default:
+		return ErrUnsupportedVersion
+	}
+
+	_, err := w.Write(h)
+	return err
+}
+
+func headerV1(src, dst net.Addr) []byte {
+	s, d, ok := tcpAddrs(src, dst)
+	if !ok {
+		return []byte(v1Prefix + "UNKNOWN\r\n")
+	}
+
+	proto := "TCP4"
+	if s.IP.To4() == nil {
+		proto = "TCP6"
+	}
+
+	return []byte(fmt.Sprintf("%s%s %s %s %d %d\r\n", v1Prefix, proto, s.IP, d.IP, s.Port, d.Port))
+}
+
+func headerV2(src, dst net.Addr) []byte {
+	h := append([]byte{}, v2Signature...)
+	s, d, ok := tcpAddrs(src, dst)
+	if !ok {
+		return append(h, 0x20|v2CommandLocal, 0, 0, 0)
+	}
+
+	var payload []byte
+	family := byte(v2FamilyTCP4)
+	if sip, dip := s.IP.To4(), d.IP.To4(); sip != nil {
+		payload = append(append(payload, sip...), dip...)
+	} else {
+		family = v2FamilyTCP6
+		payload = append(append(payload, s.IP.To16()...), d.IP.To16()...)
+	}
+
+	ports := make([]byte, 4)
+	binary.BigEndian.PutUint16(ports[0:2], uint16(s.Port))
+	binary.BigEndian.PutUint16(ports[2:4], uint16(d.Port))
+	payload = append(payload, ports...)
+
+	h = append(h, 0x20|v2CommandProxy, family, 0, 0)
+	binary.BigEndian.PutUint16(h[14:16], uint16(len(payload)))
+	return append(h, payload...)
+}
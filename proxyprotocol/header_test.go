@@ -0,0 +1,96 @@
+package proxyprotocol
+
+import (
+	"bufio"
+	"bytes"
+	"net"
+	"testing"
+)
+
+func TestWriteHeader(t *testing.T) {
+	tcp4Src := &net.TCPAddr{IP: net.ParseIP("192.0.2.1"), Port: 56324}
+	tcp4Dst := &net.TCPAddr{IP: net.ParseIP("198.51.100.1"), Port: 443}
+	tcp6Src := &net.TCPAddr{IP: net.ParseIP("2001:db8::1"), Port: 56324}
+	tcp6Dst := &net.TCPAddr{IP: net.ParseIP("2001:db8::2"), Port: 443}
+
+	for _, tt := range []struct {
+		msg      string
+		version  int
+		src, dst net.Addr
+		v1       string
+		expected string
+	}{{
+		msg:      "v1 TCP4",
+		version:  1,
+		src:      tcp4Src,
+		dst:      tcp4Dst,
+		v1:       "PROXY TCP4 192.0.2.1 198.51.100.1 56324 443\r\n",
+		expected: "192.0.2.1:56324",
+	}, {
+		msg:      "v1 TCP6",
+		version:  1,
+		src:      tcp6Src,
+		dst:      tcp6Dst,
+		v1:       "PROXY TCP6 2001:db8::1 2001:db8::2 56324 443\r\n",
+		expected: "[2001:db8::1]:56324",
+	}, {
+		msg:     "v1 mixed families",
+		version: 1,
+		src:     tcp4Src,
+		dst:     tcp6Dst,
+		v1:      "PROXY UNKNOWN\r\n",
+	}, {
+		msg:      "v2 TCP4",
+		version:  2,
+		src:      tcp4Src,
+		dst:      tcp4Dst,
+		expected: "192.0.2.1:56324",
+	}, {
+		msg:      "v2 TCP6",
+		version:  2,
+		src:      tcp6Src,
+		dst:      tcp6Dst,
+		expected: "[2001:db8::1]:56324",
+	}, {
+		msg:     "v2 no TCP addresses",
+		version: 2,
+		src:     &net.UnixAddr{Name: "/tmp/sock", Net: "unix"},
+		dst:     tcp4Dst,
+	}} {
+		t.Run(tt.msg, func(t *testing.T) {
+			var b bytes.Buffer
+			if err := WriteHeader(&b, tt.version, tt.src, tt.dst); err != nil {
+				t.Fatal(err)
+			}
+
+			if tt.v1 != "" && b.String() != tt.v1 {
+				t.Errorf("expected header %q, got %q", tt.v1, b.String())
+			}
+
+			b.WriteString("hello")
+			r := bufio.NewReader(&b)
+			addr, err := readHeader(r)
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			if tt.expected == "" {
+				if addr != nil {
+					t.Errorf("unexpected address: %v", addr)
+				}
+			} else if addr == nil || addr.String() != tt.expected {
+				t.Errorf("expected address %s, got %v", tt.expected, addr)
+			}
+
+			if rest, _ := r.ReadString(0); rest != "hello" {
+				t.Errorf("unexpected data after the header: %q", rest)
+			}
+		})
+	}
+}
+
+func TestWriteHeaderUnsupportedVersion(t *testing.T) {
+	if err := WriteHeader(&bytes.Buffer{}, 3, nil, nil); err != ErrUnsupportedVersion {
+		t.Errorf("expected unsupported version error, got: %v", err)
+	}
+}
@@ -0,0 +1,321 @@
+/*
+Package proxyprotocol implements a net.Listener that accepts the PROXY
+protocol, version 1 and 2, as sent by TCP load balancers like the AWS
+Network Load Balancer or HAProxy, so that the address of the original
+client is preserved.
+
+The PROXY protocol header is read when the connection is first read from
+or when its remote address is requested, and not while accepting the
+connection. Connections without a header are accepted as they are. The
+header can be restricted to the trusted peers, e.g. the load balancers,
+so that other clients can not spoof their address.
+
+The package can also write the PROXY protocol header, to send the client
+address to backends.
+
+See: https://www.haproxy.org/download/2.0/doc/proxy-protocol.txt
+*/
+package proxyprotocol
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/binary"
+	"errors"
+	"io"
+	"net"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+const (
+	// maximum length of a version 1 header, including CRLF
+	maxV1HeaderLength = 107
+
+	v1Prefix = "PROXY "
+
+	v2HeaderLength = 16
+
+	v2CommandLocal = 0x0
+	v2CommandProxy = 0x1
+
+	v2FamilyTCP4 = 0x11
+	v2FamilyUDP4 = 0x12
+	v2FamilyTCP6 = 0x21
+	v2FamilyUDP6 = 0x22
+)
+
+var v2Signature = []byte("\r\n\r\n\x00\r\nQUIT\n")
+
+// ErrInvalidHeader is returned when reading from a connection that
+// started with an invalid PROXY protocol header.
+var ErrInvalidHeader = errors.New("invalid PROXY protocol header")
+
+// Options are used to initialize the listener.
+type Options struct {
+
+	// ReadHeaderTimeout limits the time to read the PROXY protocol
+	// header of a connection. When 0, no limit is applied.
+	ReadHeaderTimeout time.Duration
+
+	// TrustedNetworks restricts reading the PROXY protocol header to
+	// the connections of the peers in these networks. The connections
+	// of other peers are returned as they are, and a header sent by
+	// them is not interpreted. When empty, the header is read from
+	// the connections of any peer.
+	TrustedNetworks []*net.IPNet
+}
+
+type listener struct {
+	net.Listener
+	options Options
+}
+
+type conn struct {
+	net.Conn
+	reader     *bufio.Reader
+	timeout    time.Duration
+	once       sync.Once
+	remoteAddr net.Addr
+	err        error
+
+	mu           sync.Mutex
+	readDeadline time.Time
+}
+
+// NewListener wraps a listener, and returns connections that report the
+// client address received in the PROXY protocol header as their remote
+// address.
+func NewListener(l net.Listener, o Options) net.Listener {
+	return &listener{Listener: l, options: o}
+}
+
+func (l *listener) Accept() (net.Conn, error) {
+	c, err := l.Listener.Accept()
+	if err != nil {
+		return nil, err
+	}
+
+	if !l.trusted(c.RemoteAddr()) {
+		return c, nil
+	}
+
+	return &conn{
+		Conn:    c,
+		reader:  bufio.NewReader(c),
+		timeout: l.options.ReadHeaderTimeout,
+	}, nil
+}
+
+func (l *listener) trusted(a net.Addr) bool {
+	if len(l.options.TrustedNetworks) == 0 {
+		return true
+	}
+
+	ta, ok := a.(*net.TCPAddr)
+	if !ok {
+		return false
+	}
+
+	for _, n := range l.options.TrustedNetworks {
+		if n.Contains(ta.IP) {
+			return true
+		}
+	}
+
+	return false
+}
+
+func (c *conn) readHeader() {
+	c.once.Do(func() {
+		if c.timeout > 0 {
+			c.mu.Lock()
+			deadline := c.readDeadline
+			c.mu.Unlock()
+
+			headerDeadline := time.Now().Add(c.timeout)
+			if deadline.IsZero() || headerDeadline.Before(deadline) {
+				c.Conn.SetReadDeadline(headerDeadline)
+				defer func() {
+					c.mu.Lock()
+					defer c.mu.Unlock()
+					c.Conn.SetReadDeadline(c.readDeadline)
+				}()
+			}
+		}
+
+		c.remoteAddr, c.err = readHeader(c.reader)
+	})
+}
+
+func (c *conn) Read(b []byte) (int, error) {
+	c.readHeader()
+	if c.err != nil {
+		return 0, c.err
+	}
+
+	return c.reader.Read(b)
+}
+
+// RemoteAddr returns the client address received in the PROXY protocol
+// header, or the address of the peer, when there was no header.
+func (c *conn) RemoteAddr() net.Addr {
+	c.readHeader()
+	if c.remoteAddr != nil {
+		return c.remoteAddr
+	}
+
+	return c.Conn.RemoteAddr()
+}
+
+func (c *conn) SetDeadline(t time.Time) error {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.readDeadline = t
+	return c.Conn.SetDeadline(t)
+}
+
+func (c *conn) SetReadDeadline(t time.Time) error {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.readDeadline = t
+	return c.Conn.SetReadDeadline(t)
+}
+
+// reads the PROXY protocol header, if there is one. It returns a nil
+// address when there is no header, or when the header doesn't carry the
+// client address, e.g. for health checks of the load balancer.
+func readHeader(r *bufio.Reader) (net.Addr, error) {
+	b, err := r.Peek(1)
+	if err != nil {
+		// the error is returned again from the subsequent reads
+		return nil, nil
+	}
+
+	switch b[0] {
+	case v1Prefix[0]:
+		if b, err := r.Peek(len(v1Prefix)); err != nil || string(b) != v1Prefix {
+			return nil, nil
+		}
+
+		return readV1(r)
+	case v2Signature[0]:
+		if b, err := r.Peek(len(v2Signature)); err != nil || !bytes.Equal(b, v2Signature) {
+			return nil, nil
+		}
+
+		return readV2(r)
+	default:
+		return nil, nil
+	}
+}
+
+func readV1(r *bufio.Reader) (net.Addr, error) {
+	var line []byte
+	for {
+		b, err := r.ReadByte()
+		if err != nil {
+			return nil, ErrInvalidHeader
+		}
+
+		line = append(line, b)
+		if b == '\n' {
+			break
+		}
+
+		if len(line) >= maxV1HeaderLength {
+			return nil, ErrInvalidHeader
+		}
+	}
+
+	if !bytes.HasSuffix(line, []byte("\r\n")) {
+		return nil, ErrInvalidHeader
+	}
+
+	fields := strings.Split(string(line[:len(line)-2]), " ")
+	if len(fields) < 2 {
+		return nil, ErrInvalidHeader
+	}
+
+	switch fields[1] {
+	case "UNKNOWN":
+		return nil, nil
+	case "TCP4", "TCP6":
+	default:
+		return nil, ErrInvalidHeader
+	}
+
+	if len(fields) != 6 {
+		return nil, ErrInvalidHeader
+	}
+
+	ip := net.ParseIP(fields[2])
+	if ip == nil || net.ParseIP(fields[3]) == nil {
+		return nil, ErrInvalidHeader
+	}
+
+	if (fields[1] == "TCP4") != (ip.To4() != nil) {
+		return nil, ErrInvalidHeader
+	}
+
+	port, err := strconv.ParseUint(fields[4], 10, 16)
+	if err != nil {
+		return nil, ErrInvalidHeader
+	}
+
+	if _, err := strconv.ParseUint(fields[5], 10, 16); err != nil {
+		return nil, ErrInvalidHeader
+	}
+
+	return &net.TCPAddr{IP: ip, Port: int(port)}, nil
+}
+
+func readV2(r *bufio.Reader) (net.Addr, error) {
+	h := make([]byte, v2HeaderLength)
+	if _, err := io.ReadFull(r, h); err != nil {
+		return nil, ErrInvalidHeader
+	}
+
+	if h[12]>>4 != 2 {
+		return nil, ErrInvalidHeader
+	}
+
+	payload := make([]byte, binary.BigEndian.Uint16(h[14:16]))
+	if _, err := io.ReadFull(r, payload); err != nil {
+		return nil, ErrInvalidHeader
+	}
+
+	switch h[12] & 0xf {
+	case v2CommandLocal:
+		return nil, nil
+	case v2CommandProxy:
+	default:
+		return nil, ErrInvalidHeader
+	}
+
+	switch h[13] {
+	case v2FamilyTCP4, v2FamilyUDP4:
+		if len(payload) < 12 {
+			return nil, ErrInvalidHeader
+		}
+
+		return &net.TCPAddr{
+			IP:   net.IP(payload[0:4]),
+			Port: int(binary.BigEndian.Uint16(payload[8:10])),
+		}, nil
+	case v2FamilyTCP6, v2FamilyUDP6:
+		if len(payload) < 36 {
+			return nil, ErrInvalidHeader
+		}
+
+		return &net.TCPAddr{
+			IP:   net.IP(payload[0:16]),
+			Port: int(binary.BigEndian.Uint16(payload[32:34])),
+		}, nil
+	default:
+		// unix sockets and unspecified families are not forwarded
+		return nil, nil
+	}
+}
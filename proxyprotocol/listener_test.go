@@ -0,0 +1,258 @@
+package proxyprotocol
+
+import (
+	"encoding/binary"
+	"io/ioutil"
+	"net"
+	"testing"
+	"time"
+)
+
+func v2Header(command, family byte, payload []byte) []byte {
+	h := append([]byte{}, v2Signature...)
+	h = append(h, 0x20|command, family, 0, 0)
+	binary.BigEndian.PutUint16(h[14:16], uint16(len(payload)))
+	return append(h, payload...)
+}
+
+func v2TCP4Payload() []byte {
+	p := []byte{192, 0, 2, 1, 198, 51, 100, 1, 0, 0, 0, 0}
+	binary.BigEndian.PutUint16(p[8:10], 56324)
+	binary.BigEndian.PutUint16(p[10:12], 443)
+	return p
+}
+
+func v2TCP6Payload() []byte {
+	p := make([]byte, 36)
+	copy(p[0:16], net.ParseIP("2001:db8::1"))
+	copy(p[16:32], net.ParseIP("2001:db8::2"))
+	binary.BigEndian.PutUint16(p[32:34], 56324)
+	binary.BigEndian.PutUint16(p[34:36], 443)
+	return p
+}
+
+func TestListener(t *testing.T) {
+	for _, tt := range []struct {
+		msg        string
+		header     []byte
+		remoteAddr string
+		err        bool
+	}{{
+		msg:    "no header",
+		header: nil,
+	}, {
+		msg:        "v1 TCP4",
+		header:     []byte("PROXY TCP4 192.0.2.1 198.51.100.1 56324 443\r\n"),
+		remoteAddr: "192.0.2.1:56324",
+	}, {
+		msg:        "v1 TCP6",
+		header:     []byte("PROXY TCP6 2001:db8::1 2001:db8::2 56324 443\r\n"),
+		remoteAddr: "[2001:db8::1]:56324",
+	}, {
+		msg:    "v1 unknown",
+		header: []byte("PROXY UNKNOWN\r\n"),
+	}, {
+		msg:    "v1 invalid address",
+		header: []byte("PROXY TCP4 foo 198.51.100.1 56324 443\r\n"),
+		err:    true,
+	}, {
+		msg:    "v1 mismatching family",
+		header: []byte("PROXY TCP4 2001:db8::1 2001:db8::2 56324 443\r\n"),
+		err:    true,
+	}, {
+		msg:    "v1 invalid port",
+		header: []byte("PROXY TCP4 192.0.2.1 198.51.100.1 99999 443\r\n"),
+		err:    true,
+	}, {
+		msg:    "v1 missing CR",
+		header: []byte("PROXY TCP4 192.0.2.1 198.51.100.1 56324 443\n"),
+		err:    true,
+	}, {
+		msg:        "v2 TCP4",
+		header:     v2Header(v2CommandProxy, v2FamilyTCP4, v2TCP4Payload()),
+		remoteAddr: "192.0.2.1:56324",
+	}, {
+		msg:        "v2 TCP6",
+		header:     v2Header(v2CommandProxy, v2FamilyTCP6, v2TCP6Payload()),
+		remoteAddr: "[2001:db8::1]:56324",
+	}, {
+		msg:    "v2 local",
+		header: v2Header(v2CommandLocal, 0, nil),
+	}, {
+		msg:    "v2 short address",
+		header: v2Header(v2CommandProxy, v2FamilyTCP4, []byte{192, 0, 2, 1}),
+		err:    true,
+	}, {
+		msg:    "v2 invalid command",
+		header: v2Header(0x3, v2FamilyTCP4, v2TCP4Payload()),
+		err:    true,
+	}} {
+		t.Run(tt.msg, func(t *testing.T) {
+			raw, err := net.Listen("tcp", "127.0.0.1:0")
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			l := NewListener(raw, Options{ReadHeaderTimeout: time.Second})
+			defer l.Close()
+
+			client, err := net.Dial("tcp", l.Addr().String())
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			defer client.Close()
+			go func() {
+				client.Write(append(tt.header, []byte("hello")...))
+				client.(*net.TCPConn).CloseWrite()
+			}()
+
+			c, err := l.Accept()
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			defer c.Close()
+
+			remoteAddr := c.RemoteAddr().String()
+			b, err := ioutil.ReadAll(c)
+			if tt.err {
+				if err != ErrInvalidHeader {
+					t.Fatalf("expected invalid header error, got: %v", err)
+				}
+
+				return
+			}
+
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			if string(b) != "hello" {
+				t.Errorf("unexpected data: %q", b)
+			}
+
+			expectedAddr := tt.remoteAddr
+			if expectedAddr == "" {
+				expectedAddr = client.LocalAddr().String()
+			}
+
+			if remoteAddr != expectedAddr {
+				t.Errorf("expected remote address %s, got %s", expectedAddr, remoteAddr)
+			}
+		})
+	}
+}
+
+func TestReadHeaderTimeout(t *testing.T) {
+	raw, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	l := NewListener(raw, Options{ReadHeaderTimeout: 30 * time.Millisecond})
+	defer l.Close()
+
+	client, err := net.Dial("tcp", l.Addr().String())
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	defer client.Close()
+
+	// incomplete header
+	if _, err := client.Write([]byte("PROXY TCP4 ")); err != nil {
+		t.Fatal(err)
+	}
+
+	c, err := l.Accept()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	defer c.Close()
+
+	done := make(chan error, 1)
+	go func() {
+		_, err := c.Read(make([]byte, 8))
+		done <- err
+	}()
+
+	select {
+	case err := <-done:
+		if err == nil {
+			t.Error("expected error")
+		}
+	case <-time.After(time.Second):
+		t.Error("timeout reading the header")
+	}
+}
+
+func TestTrustedNetworks(t *testing.T) {
+	for _, tt := range []struct {
+		msg        string
+		trusted    string
+		remoteAddr string
+	}{{
+		msg:        "trusted peer",
+		trusted:    "127.0.0.0/8",
+		remoteAddr: "192.0.2.1:56324",
+	}, {
+		msg:     "untrusted peer",
+		trusted: "10.0.0.0/8",
+	}} {
+		t.Run(tt.msg, func(t *testing.T) {
+			_, n, err := net.ParseCIDR(tt.trusted)
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			raw, err := net.Listen("tcp", "127.0.0.1:0")
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			l := NewListener(raw, Options{TrustedNetworks: []*net.IPNet{n}})
+			defer l.Close()
+
+			client, err := net.Dial("tcp", l.Addr().String())
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			defer client.Close()
+			header := "PROXY TCP4 192.0.2.1 198.51.100.1 56324 443\r\n"
+			go func() {
+				client.Write([]byte(header + "hello"))
+				client.(*net.TCPConn).CloseWrite()
+			}()
+
+			c, err := l.Accept()
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			defer c.Close()
+
+			remoteAddr := c.RemoteAddr().String()
+			b, err := ioutil.ReadAll(c)
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			expectedAddr, expectedData := tt.remoteAddr, "hello"
+			if expectedAddr == "" {
+				// the header of untrusted peers is not interpreted
+				expectedAddr, expectedData = client.LocalAddr().String(), header+"hello"
+			}
+
+			if remoteAddr != expectedAddr {
+				t.Errorf("expected remote address %s, got %s", expectedAddr, remoteAddr)
+			}
+
+			if string(b) != expectedData {
+				t.Errorf("unexpected data: %q", b)
+			}
+		})
+	}
+}
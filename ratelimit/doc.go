@@ -122,6 +122,28 @@ request:
 
 Both are based on RFC 6585.
 
+In addition, the headers of the IETF draft for the rate limit headers
+(https://datatracker.ietf.org/doc/draft-ietf-httpapi-ratelimit-headers/)
+are set, with the allowed requests in the time window, the remaining
+requests, and the seconds until the quota resets:
+
+	RateLimit-Limit: 100
+	RateLimit-Remaining: 0
+	RateLimit-Reset: 3600
+
+With the -ratelimit-response-headers flag, these headers are set in the
+responses to the requests allowed by the route rate limits, too, so that
+the clients can slow down before they get rejected. This requires the rate
+limit to know the remaining quota, which is the case for the service rate
+limits and for the cluster rate limits based on Redis, but not for the
+client rate limits counted in the local instance or in the swarm. When
+multiple rate limits apply to a route, the headers show the lowest
+remaining quota.
+
+With the -ratelimit-legacy-headers flag, the X-RateLimit-Limit,
+X-RateLimit-Remaining and X-RateLimit-Reset headers are set, too, where the
+reset is the Unix time when the quota resets.
+
 Registry
 
 The active rate limiters are stored in a registry. They are created
@@ -3,11 +3,14 @@ package ratelimit
 import (
 	"bytes"
 	"fmt"
+	"math"
 	"net/http"
+	"strconv"
 	"time"
 
 	log "github.com/sirupsen/logrus"
 	circularbuffer "github.com/szuecs/rate-limit-buffer"
+	"github.com/zalando/skipper/filters/template"
 	"github.com/zalando/skipper/net"
 )
 
@@ -19,6 +22,28 @@ const (
 	// long a client should wait before making a new request
 	RetryAfterHeader = "Retry-After"
 
+	// LimitHeader is the name of the header of the IETF draft, which
+	// tells the number of requests allowed in the time window
+	LimitHeader = "RateLimit-Limit"
+
+	// RemainingHeader is the name of the header of the IETF draft, which
+	// tells the number of requests remaining in the time window
+	RemainingHeader = "RateLimit-Remaining"
+
+	// ResetHeader is the name of the header of the IETF draft, which
+	// tells the number of seconds until the quota resets
+	ResetHeader = "RateLimit-Reset"
+
+	// LegacyLimitHeader is the legacy variant of LimitHeader
+	LegacyLimitHeader = "X-RateLimit-Limit"
+
+	// LegacyRemainingHeader is the legacy variant of RemainingHeader
+	LegacyRemainingHeader = "X-RateLimit-Remaining"
+
+	// LegacyResetHeader is the legacy variant of ResetHeader, containing
+	// the Unix time when the quota resets
+	LegacyResetHeader = "X-RateLimit-Reset"
+
 	// ServiceRatelimitName is the name of the Ratelimit filter, which will be shown in log
 	ServiceRatelimitName = "ratelimit"
 
@@ -243,6 +268,63 @@ func (t TupleLookuper) String() string {
 	return "TupleLookuper"
 }
 
+// ContextLookuper is an optional extension of the Lookuper interface, for
+// the lookupers that need more than the request, e.g. the path parameters
+// of the route. When a Lookuper implements it, the proxy uses it instead
+// of Lookup.
+type ContextLookuper interface {
+	Lookuper
+	LookupContext(template.Context) string
+}
+
+// TemplateLookuper implements the Lookuper and the ContextLookuper
+// interfaces, and selects a bucket by the result of a template, e.g.
+// "${jwt.sub}" or "${request.source.2}-${request.header.X-Tenant}". See
+// the template package for the available placeholders. The requests,
+// whose template cannot be fully resolved, share a single bucket.
+type TemplateLookuper struct {
+	template string
+}
+
+// NewTemplateLookuper returns TemplateLookuper configured to lookup the
+// result of the template
+func NewTemplateLookuper(t string) TemplateLookuper {
+	return TemplateLookuper{template: t}
+}
+
+type requestContext struct {
+	request *http.Request
+}
+
+func (c requestContext) PathParam(string) string          { return "" }
+func (c requestContext) Request() *http.Request           { return c.request }
+func (c requestContext) StateBag() map[string]interface{} { return nil }
+
+// Lookup returns the result of the template evaluated with the
+// attributes of the request, without the path parameters.
+func (t TemplateLookuper) Lookup(req *http.Request) string {
+	return t.LookupContext(requestContext{request: req})
+}
+
+// LookupContext returns the result of the template evaluated with the
+// context of the request. When any of the placeholders could not be
+// resolved, it returns the template itself, the key of the shared bucket.
+//
+// The template is parsed on every lookup, to keep the lookuper comparable
+// without holding the parsed templates of the removed routes.
+func (t TemplateLookuper) LookupContext(ctx template.Context) string {
+	s, ok := template.New(t.template).Apply(ctx)
+	if !ok {
+		return t.template
+	}
+
+	return s
+}
+
+func (t TemplateLookuper) String() string {
+	return "TemplateLookuper"
+}
+
 // Settings configures the chosen rate limiter
 type Settings struct {
 	// Type of the chosen rate limiter
@@ -320,6 +402,50 @@ type limiter interface {
 	RetryAfter(string) int
 }
 
+// quotaLimiter is implemented by the limiters that can tell the remaining
+// quota of a bucket
+type quotaLimiter interface {
+	// quota returns the number of the remaining requests in the
+	// time window, and the duration until the oldest counted request
+	// leaves the window
+	quota(string) (int, time.Duration)
+}
+
+// resetAfter returns the duration until the oldest counted request
+// leaves the time window
+func resetAfter(oldest time.Time, window time.Duration) time.Duration {
+	if oldest.IsZero() {
+		return 0
+	}
+
+	d := time.Until(oldest.Add(window))
+	if d < 0 {
+		return 0
+	}
+
+	return d
+}
+
+// SetHeaders sets the rate limit headers of the IETF draft
+// https://datatracker.ietf.org/doc/draft-ietf-httpapi-ratelimit-headers/,
+// and when legacy is true, the X-RateLimit-* headers, too. The reset is
+// rounded up to seconds.
+func SetHeaders(h http.Header, limit, remaining int, reset time.Duration, legacy bool) {
+	if remaining < 0 {
+		remaining = 0
+	}
+
+	resetSeconds := int64(math.Ceil(reset.Seconds()))
+	h.Set(LimitHeader, strconv.Itoa(limit))
+	h.Set(RemainingHeader, strconv.Itoa(remaining))
+	h.Set(ResetHeader, strconv.FormatInt(resetSeconds, 10))
+	if legacy {
+		h.Set(LegacyLimitHeader, strconv.Itoa(limit))
+		h.Set(LegacyRemainingHeader, strconv.Itoa(remaining))
+		h.Set(LegacyResetHeader, strconv.FormatInt(time.Now().Unix()+resetSeconds, 10))
+	}
+}
+
 // Ratelimit is a proxy object that delegates to limiter
 // implemetations and stores settings for the ratelimiter
 type Ratelimit struct {
@@ -349,6 +475,27 @@ func (l *Ratelimit) RetryAfter(s string) int {
 	return l.impl.RetryAfter(s)
 }
 
+// Quota returns the number of the remaining requests in the time
+// window, and the duration until the oldest counted request leaves the
+// window. It returns false, when the ratelimit can't tell the remaining
+// quota, which is the case for the client ratelimits counted in the local
+// instance or in the swarm. It may need a roundtrip to Redis.
+func (l *Ratelimit) Quota(s string) (remaining int, reset time.Duration, ok bool) {
+	if l == nil {
+		return 0, 0, false
+	}
+
+	switch impl := l.impl.(type) {
+	case quotaLimiter:
+		remaining, reset = impl.quota(s)
+		return remaining, reset, true
+	case *circularbuffer.CircularBuffer:
+		return impl.Cap() - impl.Len(), resetAfter(impl.Oldest(s), l.settings.TimeWindow), true
+	default:
+		return 0, 0, false
+	}
+}
+
 func (l *Ratelimit) Delta(s string) time.Duration {
 	return l.impl.Delta(s)
 }
@@ -174,6 +174,58 @@ func TestHeaderLookuper(t *testing.T) {
 	})
 }
 
+type testTemplateContext struct {
+	req    *http.Request
+	params map[string]string
+}
+
+func (c testTemplateContext) PathParam(k string) string        { return c.params[k] }
+func (c testTemplateContext) Request() *http.Request           { return c.req }
+func (c testTemplateContext) StateBag() map[string]interface{} { return nil }
+
+func TestTemplateLookuper(t *testing.T) {
+	req, err := http.NewRequest("GET", "/foo", nil)
+	if err != nil {
+		t.Fatalf("Could not create request: %v", err)
+	}
+
+	req.RemoteAddr = "10.0.0.2:1234"
+	req.Header.Set("X-Forwarded-For", "192.168.0.1, 172.16.0.1, 10.0.0.1")
+	req.Header.Set("X-Tenant", "acme")
+
+	t.Run("request attributes", func(t *testing.T) {
+		l := NewTemplateLookuper("${request.header.X-Tenant}-${request.source.2}")
+		if s := l.Lookup(req); s != "acme-172.16.0.1" {
+			t.Errorf("Failed to lookup request: %s", s)
+		}
+	})
+
+	t.Run("unresolved", func(t *testing.T) {
+		l := NewTemplateLookuper("${request.header.X-Tenant}-${request.header.X-Missing}")
+		if s := l.Lookup(req); s != "${request.header.X-Tenant}-${request.header.X-Missing}" {
+			t.Errorf("Unresolved template not looked up as the shared bucket: %s", s)
+		}
+	})
+
+	t.Run("path params from the context", func(t *testing.T) {
+		var l Lookuper = NewTemplateLookuper("${param.tenant}")
+		cl, ok := l.(ContextLookuper)
+		if !ok {
+			t.Fatal("Template lookuper is not a context lookuper")
+		}
+
+		if s := cl.LookupContext(testTemplateContext{req: req, params: map[string]string{"tenant": "foo"}}); s != "foo" {
+			t.Errorf("Failed to lookup request: %s", s)
+		}
+	})
+
+	t.Run("comparable", func(t *testing.T) {
+		if NewTemplateLookuper("${jwt.sub}") != NewTemplateLookuper("${jwt.sub}") {
+			t.Error("Template lookupers with the same template differ")
+		}
+	})
+}
+
 func TestTupleLookuper(t *testing.T) {
 	req, err := http.NewRequest("GET", "/foo", nil)
 	if err != nil {
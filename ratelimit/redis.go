@@ -55,29 +55,36 @@ const (
 	redisMetricsPrefix         = "swarm.redis."
 )
 
-func newRing(ro *RedisOptions, quit <-chan struct{}) *ring {
-	var r *ring
-
+// NewRedisRing creates a redis.Ring client from the options, so that
+// other components can share the redis shards configured for the
+// ratelimits. The caller is responsible for closing it.
+func NewRedisRing(ro *RedisOptions) *redis.Ring {
 	ringOptions := &redis.RingOptions{
-		Addrs: map[string]string{},
+		Addrs:        map[string]string{},
+		ReadTimeout:  ro.ReadTimeout,
+		WriteTimeout: ro.WriteTimeout,
+		PoolTimeout:  ro.PoolTimeout,
+		MinIdleConns: ro.MinIdleConns,
+		PoolSize:     ro.MaxIdleConns,
 	}
 
-	if ro != nil {
-		for idx, addr := range ro.Addrs {
-			ringOptions.Addrs[fmt.Sprintf("redis%d", idx)] = addr
-		}
-		ringOptions.ReadTimeout = ro.ReadTimeout
-		ringOptions.WriteTimeout = ro.WriteTimeout
-		ringOptions.PoolTimeout = ro.PoolTimeout
-		ringOptions.MinIdleConns = ro.MinIdleConns
-		ringOptions.PoolSize = ro.MaxIdleConns
+	for idx, addr := range ro.Addrs {
+		ringOptions.Addrs[fmt.Sprintf("redis%d", idx)] = addr
+	}
 
+	return redis.NewRing(ringOptions)
+}
+
+func newRing(ro *RedisOptions, quit <-chan struct{}) *ring {
+	var r *ring
+
+	if ro != nil {
 		if ro.ConnMetricsInterval <= 0 {
 			ro.ConnMetricsInterval = defaultConnMetricsInterval
 		}
 
 		r = new(ring)
-		r.ring = redis.NewRing(ringOptions)
+		r.ring = NewRedisRing(ro)
 		r.metrics = metrics.Default
 
 		go func() {
@@ -191,6 +198,35 @@ func (c *clusterLimitRedis) allowCheckCard(key string, clearBefore int64) int64
 	return zcardResult.Val()
 }
 
+// quota returns the number of the remaining requests in the time window,
+// and the duration until the oldest counted request leaves the window.
+//
+// Performance considerations:
+//
+// It uses ZCOUNT and ZRANGEBYSCORE in one pipeline.
+func (c *clusterLimitRedis) quota(s string) (int, time.Duration) {
+	key := swarmPrefix + c.group + "." + s
+	now := time.Now()
+	from := fmt.Sprint(float64(now.Add(-c.window).UnixNano()))
+	to := fmt.Sprint(float64(now.UnixNano()))
+
+	pipe := c.ring.TxPipeline()
+	defer pipe.Close()
+	count := pipe.ZCount(key, from, to)
+	oldest := pipe.ZRangeByScoreWithScores(key, &redis.ZRangeBy{Min: from, Max: to, Offset: 0, Count: 1})
+	if _, err := pipe.Exec(); err != nil {
+		log.Errorf("Failed to get the redis quota for %s: %v", key, err)
+		return int(c.maxHits), 0
+	}
+
+	var reset time.Duration
+	if zs := oldest.Val(); len(zs) > 0 {
+		reset = resetAfter(time.Unix(0, int64(zs[0].Score)), c.window)
+	}
+
+	return int(c.maxHits - count.Val()), reset
+}
+
 // Close can not decide to teardown redis ring, because it is not the
 // owner of it.
 func (c *clusterLimitRedis) Close() {}
@@ -46,6 +46,9 @@ HeaderRegexp("Key", "^Value$").
 - HeaderRegexp: a header key and a regular expression, where the key
 must be present in the request and one of the associated values must
 match the expression.
+The values of the named capture groups of the
+expression are available via Route.HeaderRegexpCaptures, and the proxy
+stores them in the state bag of the request.
 
 
 Wildcards
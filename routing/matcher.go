@@ -134,6 +134,37 @@ func canonicalizeHeaderRegexps(hrx map[string][]*regexp.Regexp) map[string][]*re
 	return chrx
 }
 
+// selects the header regexp conditions with named capture groups
+func headerCaptures(hrx map[string][]*regexp.Regexp) map[string][]*regexp.Regexp {
+	var hc map[string][]*regexp.Regexp
+	for k, rxs := range hrx {
+		for _, rx := range rxs {
+			if !hasNamedGroups(rx) {
+				continue
+			}
+
+			if hc == nil {
+				hc = make(map[string][]*regexp.Regexp)
+			}
+
+			ck := http.CanonicalHeaderKey(k)
+			hc[ck] = append(hc[ck], rx)
+		}
+	}
+
+	return hc
+}
+
+func hasNamedGroups(rx *regexp.Regexp) bool {
+	for _, name := range rx.SubexpNames() {
+		if name != "" {
+			return true
+		}
+	}
+
+	return false
+}
+
 // extracts the expected wildcard param names and returns them in reverse order
 func extractWildcardParamNames(r *Route) []string {
 	path := r.path
@@ -233,6 +264,7 @@ func newLeaf(r *Route, rxs map[string]*regexp.Regexp) (*leafMatcher, error) {
 		allHeaderRxs[k] = headerRxs
 	}
 
+	r.headerCaptures = headerCaptures(allHeaderRxs)
 	return &leafMatcher{
 		wildcardParamNames:   extractWildcardParamNames(r),
 		hasFreeWildcardParam: hasFreeWildcardParam(r),
@@ -430,6 +462,38 @@ func matchHeaders(exact map[string]string, hrxs map[string][]*regexp.Regexp, h h
 	return true
 }
 
+// HeaderRegexpCaptures returns the values of the named capture groups of
+// the HeaderRegexp conditions of the route, matched against the request
+// headers. When a header has multiple values, the first matching value is
+// used, the same way as when matching the route.
+func (r *Route) HeaderRegexpCaptures(h http.Header) map[string]string {
+	if len(r.headerCaptures) == 0 {
+		return nil
+	}
+
+	captures := make(map[string]string)
+	for k, rxs := range r.headerCaptures {
+		for _, rx := range rxs {
+			for _, val := range h[k] {
+				m := rx.FindStringSubmatch(val)
+				if m == nil {
+					continue
+				}
+
+				for i, name := range rx.SubexpNames() {
+					if name != "" {
+						captures[name] = m[i]
+					}
+				}
+
+				break
+			}
+		}
+	}
+
+	return captures
+}
+
 // check if all defined custom predicates are matched
 func matchPredicates(cps []Predicate, req *http.Request) bool {
 	for _, cp := range cps {
@@ -860,6 +860,49 @@ func TestMakeLeaf(t *testing.T) {
 	}
 }
 
+func TestHeaderRegexpCaptures(t *testing.T) {
+	m, err := docToMatcher(`
+		versioned: HeaderRegexp("accept", "^application/vnd\\.example\\.v(?P<version>[0-9]+)\\+(?P<format>json|xml)$") &&
+			HeaderRegexp("X-Tenant", "^(?P<tenant>[a-z]+)-") &&
+			HeaderRegexp("X-Tenant", "^[a-z]+-[0-9]+$") -> "https://api.example.org";
+		plain: HeaderRegexp("X-Plain", "^(foo|bar)$") -> "https://plain.example.org"`)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	h := http.Header{
+		"Accept":   []string{"text/html", "application/vnd.example.v2+json"},
+		"X-Tenant": []string{"acme-42"},
+	}
+
+	r, _ := m.match(&http.Request{URL: &url.URL{Path: "/"}, Header: h})
+	if r == nil || r.Id != "versioned" {
+		t.Fatal("failed to match request")
+	}
+
+	captures := r.HeaderRegexpCaptures(h)
+	expected := map[string]string{"version": "2", "format": "json", "tenant": "acme"}
+	if len(captures) != len(expected) {
+		t.Fatalf("unexpected captures: %v", captures)
+	}
+
+	for k, v := range expected {
+		if captures[k] != v {
+			t.Errorf("unexpected capture %s: %q, expected: %q", k, captures[k], v)
+		}
+	}
+
+	h = http.Header{"X-Plain": []string{"foo"}}
+	r, _ = m.match(&http.Request{URL: &url.URL{Path: "/"}, Header: h})
+	if r == nil || r.Id != "plain" {
+		t.Fatal("failed to match request")
+	}
+
+	if captures := r.HeaderRegexpCaptures(h); captures != nil {
+		t.Errorf("unexpected captures: %v", captures)
+	}
+}
+
 func TestMakeMatcherEmpty(t *testing.T) {
 	m, errs := newMatcher(nil, MatchingOptionsNone)
 	if len(errs) != 0 || m == nil {
@@ -4,6 +4,7 @@ import (
 	"encoding/json"
 	"fmt"
 	"net/http"
+	"regexp"
 	"strconv"
 	"strings"
 	"sync/atomic"
@@ -142,6 +143,10 @@ type RouteFilter struct {
 // backends.
 type LBEndpoint struct {
 	Scheme, Host string
+
+	// Detected is the time when the endpoint was detected, or created,
+	// used for the fade-in of the new endpoints.
+	Detected time.Time
 }
 
 // LBAlgorithm implementations apply a load balancing algorithm
@@ -194,6 +199,18 @@ type Route struct {
 	// LBAlgorithm is the selected load balancing algorithm
 	// of a load balanced route.
 	LBAlgorithm LBAlgorithm
+
+	// LBFadeInDuration is the duration of the fade-in of the new
+	// endpoints of a load balanced route. The fade-in is disabled,
+	// when it is 0.
+	LBFadeInDuration time.Duration
+
+	// LBFadeInExponent sets the shape of the fade-in curve, 1 means
+	// linear fade-in.
+	LBFadeInExponent float64
+
+	// the HeaderRegexp conditions containing named capture groups
+	headerCaptures map[string][]*regexp.Regexp
 }
 
 // PostProcessor is an interface for custom post-processors applying changes
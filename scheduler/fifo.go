@@ -0,0 +1,199 @@
+package scheduler
+
+import (
+	"container/list"
+	"errors"
+	"sync"
+	"time"
+)
+
+const (
+	// FIFOKey is used during routing to pass the fifo values from the
+	// filters to the proxy.
+	FIFOKey = "fifo"
+)
+
+var (
+	// ErrQueueFull is returned by the FIFO queue when the maximum number
+	// of waiting requests was reached.
+	ErrQueueFull = errors.New("queue full")
+
+	// ErrQueueTimeout is returned by the FIFO queue when a request could
+	// not be scheduled within the configured timeout.
+	ErrQueueTimeout = errors.New("queue timeout")
+
+	// ErrQueueClosed is returned by the FIFO queue when it was closed,
+	// e.g. because the route was removed.
+	ErrQueueClosed = errors.New("queue closed")
+)
+
+type fifoWaiter struct {
+	ready chan struct{}
+	err   error
+}
+
+// FifoQueue objects implement a FIFO queue for handling requests, with a
+// maximum allowed concurrency and queue size. The requests exceeding the
+// concurrency wait in the order of their arrival, until either one of the
+// active requests is done or the timeout is reached. With the queue size
+// 0, the requests exceeding the concurrency are rejected right away. Currently, they can
+// be used from the fifo filter in the filters/scheduler package only.
+type FifoQueue struct {
+	mu                       sync.Mutex
+	config                   Config
+	active                   int
+	waiting                  *list.List
+	closed                   bool
+	activeRequestsMetricsKey string
+	queuedRequestsMetricsKey string
+}
+
+func newFifoQueue(c Config) *FifoQueue {
+	return &FifoQueue{
+		config:  c,
+		waiting: list.New(),
+	}
+}
+
+func (q *FifoQueue) maxConcurrency() int {
+	if q.config.MaxConcurrency <= 0 {
+		return 1
+	}
+
+	return q.config.MaxConcurrency
+}
+
+func (q *FifoQueue) maxQueueSize() int {
+	if q.config.MaxQueueSize < 0 {
+		return 0
+	}
+
+	return q.config.MaxQueueSize
+}
+
+// Wait blocks until a request can be processed or needs to be rejected.
+// When it can be processed, calling done indicates that it has finished.
+// It is mandatory to call done() when the request was processed. When the
+// request needs to be rejected, ErrQueueFull, ErrQueueTimeout or
+// ErrQueueClosed is returned.
+func (q *FifoQueue) Wait() (done func(), err error) {
+	q.mu.Lock()
+	if q.closed {
+		q.mu.Unlock()
+		return nil, ErrQueueClosed
+	}
+
+	if q.active < q.maxConcurrency() {
+		q.active++
+		q.mu.Unlock()
+		return q.doneFunc(), nil
+	}
+
+	if q.waiting.Len() >= q.maxQueueSize() {
+		q.mu.Unlock()
+		return nil, ErrQueueFull
+	}
+
+	w := &fifoWaiter{ready: make(chan struct{})}
+	e := q.waiting.PushBack(w)
+	timeout := q.config.Timeout
+	q.mu.Unlock()
+
+	var timer <-chan time.Time
+	if timeout > 0 {
+		t := time.NewTimer(timeout)
+		defer t.Stop()
+		timer = t.C
+	}
+
+	select {
+	case <-w.ready:
+	case <-timer:
+		q.mu.Lock()
+		select {
+		case <-w.ready:
+			// scheduled concurrently with the timeout
+		default:
+			q.waiting.Remove(e)
+			w.err = ErrQueueTimeout
+		}
+
+		q.mu.Unlock()
+	}
+
+	if w.err != nil {
+		return nil, w.err
+	}
+
+	return q.doneFunc(), nil
+}
+
+func (q *FifoQueue) doneFunc() func() {
+	var once sync.Once
+	return func() { once.Do(q.release) }
+}
+
+// release passes the slot of a finished request to the next waiting one,
+// or frees it when there are none.
+func (q *FifoQueue) release() {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+	if q.active > q.maxConcurrency() || q.waiting.Len() == 0 {
+		q.active--
+		return
+	}
+
+	w := q.waiting.Remove(q.waiting.Front()).(*fifoWaiter)
+	close(w.ready)
+}
+
+// Status returns the current status of a queue.
+func (q *FifoQueue) Status() QueueStatus {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+	return QueueStatus{
+		ActiveRequests: q.active,
+		QueuedRequests: q.waiting.Len(),
+		Closed:         q.closed,
+	}
+}
+
+// Config returns the configuration that the queue was created with.
+func (q *FifoQueue) Config() Config {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+	return q.config
+}
+
+func (q *FifoQueue) reconfigure(c Config) {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+	q.config = c
+
+	// when the concurrency was increased, the waiting requests can be
+	// scheduled right away
+	for q.active < q.maxConcurrency() && q.waiting.Len() > 0 {
+		w := q.waiting.Remove(q.waiting.Front()).(*fifoWaiter)
+		q.active++
+		close(w.ready)
+	}
+
+	// when the queue size was decreased, the overflowing requests are
+	// rejected
+	for q.waiting.Len() > q.maxQueueSize() {
+		w := q.waiting.Remove(q.waiting.Back()).(*fifoWaiter)
+		w.err = ErrQueueFull
+		close(w.ready)
+	}
+}
+
+func (q *FifoQueue) close() {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+	q.closed = true
+	for q.waiting.Len() > 0 {
+		w := q.waiting.Remove(q.waiting.Front()).(*fifoWaiter)
+		w.err = ErrQueueClosed
+		close(w.ready)
+	}
+}
@@ -0,0 +1,179 @@
+package scheduler
+
+import (
+	"testing"
+	"time"
+)
+
+func TestFifoQueueOrder(t *testing.T) {
+	q := newFifoQueue(Config{MaxConcurrency: 1, MaxQueueSize: 3, Timeout: time.Second})
+	done, err := q.Wait()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	order := make(chan int, 3)
+	for i := 0; i < 3; i++ {
+		go func(i int) {
+			d, err := q.Wait()
+			if err != nil {
+				t.Error(err)
+				return
+			}
+
+			order <- i
+			d()
+		}(i)
+
+		// making sure that the requests are queued in order
+		for q.Status().QueuedRequests != i+1 {
+			time.Sleep(time.Millisecond)
+		}
+	}
+
+	done()
+	for i := 0; i < 3; i++ {
+		if o := <-order; o != i {
+			t.Errorf("unexpected order, expected: %d, got: %d", i, o)
+		}
+	}
+
+	if s := q.Status(); s.ActiveRequests != 0 || s.QueuedRequests != 0 {
+		t.Errorf("unexpected status: %+v", s)
+	}
+}
+
+func TestFifoQueueFull(t *testing.T) {
+	q := newFifoQueue(Config{MaxConcurrency: 1, MaxQueueSize: 1, Timeout: time.Second})
+	done, err := q.Wait()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	defer done()
+	go q.Wait()
+	for q.Status().QueuedRequests != 1 {
+		time.Sleep(time.Millisecond)
+	}
+
+	if _, err := q.Wait(); err != ErrQueueFull {
+		t.Errorf("unexpected error, expected: %v, got: %v", ErrQueueFull, err)
+	}
+}
+
+func TestFifoQueueNoQueueing(t *testing.T) {
+	q := newFifoQueue(Config{MaxConcurrency: 1, MaxQueueSize: 0, Timeout: time.Second})
+	done, err := q.Wait()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if _, err := q.Wait(); err != ErrQueueFull {
+		t.Errorf("unexpected error, expected: %v, got: %v", ErrQueueFull, err)
+	}
+
+	done()
+	done, err = q.Wait()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	done()
+}
+
+func TestFifoQueueTimeout(t *testing.T) {
+	q := newFifoQueue(Config{MaxConcurrency: 1, MaxQueueSize: 1, Timeout: 10 * time.Millisecond})
+	done, err := q.Wait()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if _, err := q.Wait(); err != ErrQueueTimeout {
+		t.Errorf("unexpected error, expected: %v, got: %v", ErrQueueTimeout, err)
+	}
+
+	done()
+	if s := q.Status(); s.ActiveRequests != 0 || s.QueuedRequests != 0 {
+		t.Errorf("unexpected status: %+v", s)
+	}
+}
+
+func TestFifoQueueDoneIdempotent(t *testing.T) {
+	q := newFifoQueue(Config{MaxConcurrency: 2})
+	done, err := q.Wait()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	other, err := q.Wait()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	defer other()
+	done()
+	done()
+	if s := q.Status(); s.ActiveRequests != 1 {
+		t.Errorf("unexpected active requests, expected: 1, got: %d", s.ActiveRequests)
+	}
+}
+
+func TestFifoQueueReconfigure(t *testing.T) {
+	q := newFifoQueue(Config{MaxConcurrency: 1, MaxQueueSize: 2, Timeout: time.Second})
+	done, err := q.Wait()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	defer done()
+	scheduled := make(chan error, 2)
+	for i := 0; i < 2; i++ {
+		go func() {
+			d, err := q.Wait()
+			if err == nil {
+				defer d()
+			}
+
+			scheduled <- err
+		}()
+	}
+
+	for q.Status().QueuedRequests != 2 {
+		time.Sleep(time.Millisecond)
+	}
+
+	q.reconfigure(Config{MaxConcurrency: 3, MaxQueueSize: 2, Timeout: time.Second})
+	for i := 0; i < 2; i++ {
+		if err := <-scheduled; err != nil {
+			t.Error(err)
+		}
+	}
+}
+
+func TestFifoQueueClose(t *testing.T) {
+	q := newFifoQueue(Config{MaxConcurrency: 1, MaxQueueSize: 1, Timeout: time.Second})
+	done, err := q.Wait()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	defer done()
+	waitErr := make(chan error)
+	go func() {
+		_, err := q.Wait()
+		waitErr <- err
+	}()
+
+	for q.Status().QueuedRequests != 1 {
+		time.Sleep(time.Millisecond)
+	}
+
+	q.close()
+	if err := <-waitErr; err != ErrQueueClosed {
+		t.Errorf("unexpected error, expected: %v, got: %v", ErrQueueClosed, err)
+	}
+
+	if _, err := q.Wait(); err != ErrQueueClosed {
+		t.Errorf("unexpected error, expected: %v, got: %v", ErrQueueClosed, err)
+	}
+}
@@ -0,0 +1,145 @@
+package scheduler
+
+import (
+	"errors"
+	"fmt"
+	"sync/atomic"
+)
+
+// Priority is the priority class of the requests of a route using a LIFO
+// queue. Under saturation, the requests of the lower classes are shed
+// first.
+type Priority int
+
+const (
+	// PriorityHigh requests are only rejected when the queue is full. This
+	// is the default class of the routes without a priority.
+	PriorityHigh Priority = iota
+
+	// PriorityNormal requests are rejected when the queue is filled to
+	// three quarters.
+	PriorityNormal
+
+	// PriorityLow requests are rejected when the queue is filled to half.
+	PriorityLow
+
+	priorityClasses = iota
+)
+
+// ErrPriorityShed is returned by the LIFO queue, when a request was
+// rejected due to its priority class.
+var ErrPriorityShed = errors.New("request shed by priority")
+
+// PriorityFilter is the interface of the filters that set the priority
+// class of a route.
+type PriorityFilter interface {
+	Priority() Priority
+}
+
+// PrioritizedLIFOFilter is an extension of the LIFOFilter interface, for the
+// filters that can wait in the queue with a priority class.
+type PrioritizedLIFOFilter interface {
+	LIFOFilter
+
+	// SetPriority will be used by the registry to pass in the priority
+	// class of the route.
+	SetPriority(Priority)
+}
+
+type classStatus struct {
+	active int64
+	queued int64
+}
+
+// ParsePriority returns the priority class from its name: high, normal or
+// low.
+func ParsePriority(s string) (Priority, error) {
+	switch s {
+	case "high":
+		return PriorityHigh, nil
+	case "normal":
+		return PriorityNormal, nil
+	case "low":
+		return PriorityLow, nil
+	default:
+		return 0, fmt.Errorf("invalid priority class: %s", s)
+	}
+}
+
+func (p Priority) String() string {
+	switch p {
+	case PriorityNormal:
+		return "normal"
+	case PriorityLow:
+		return "low"
+	default:
+		return "high"
+	}
+}
+
+// shed tells whether a request of the class needs to be rejected, based on
+// the number of the already waiting requests
+func (p Priority) shed(queued int64, maxQueueSize int) bool {
+	switch p {
+	case PriorityNormal:
+		return maxQueueSize > 0 && 4*queued >= 3*int64(maxQueueSize)
+	case PriorityLow:
+		return maxQueueSize > 0 && 2*queued >= int64(maxQueueSize)
+	default:
+		return false
+	}
+}
+
+func (q *Queue) queued() int64 {
+	var n int64
+	for i := range q.classes {
+		n += atomic.LoadInt64(&q.classes[i].queued)
+	}
+
+	return n
+}
+
+// WaitPriority blocks until a request of the given priority class can be
+// processed or needs to be rejected. It works the same way as Wait, but it
+// rejects the normal and low priority requests with ErrPriorityShed, when
+// the queue is filled above the limit of their class.
+func (q *Queue) WaitPriority(p Priority) (done func(), err error) {
+	if p < 0 || p >= priorityClasses {
+		p = PriorityHigh
+	}
+
+	if p.shed(q.queued(), q.config.MaxQueueSize) {
+		if q.metrics != nil {
+			q.metrics.IncCounter(q.classMetricsKeys[p][2])
+		}
+
+		return nil, ErrPriorityShed
+	}
+
+	c := &q.classes[p]
+	atomic.AddInt64(&c.queued, 1)
+	jobDone, err := q.queue.Wait()
+	atomic.AddInt64(&c.queued, -1)
+	if err != nil {
+		return nil, err
+	}
+
+	atomic.AddInt64(&c.active, 1)
+	return func() {
+		atomic.AddInt64(&c.active, -1)
+		jobDone()
+	}, nil
+}
+
+// ClassStatus returns the current status of a priority class in the queue.
+func (q *Queue) ClassStatus(p Priority) QueueStatus {
+	if p < 0 || p >= priorityClasses {
+		return QueueStatus{}
+	}
+
+	c := &q.classes[p]
+	return QueueStatus{
+		ActiveRequests: int(atomic.LoadInt64(&c.active)),
+		QueuedRequests: int(atomic.LoadInt64(&c.queued)),
+	}
+}
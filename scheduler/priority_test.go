@@ -0,0 +1,76 @@
+package scheduler
+
+import (
+	"testing"
+	"time"
+
+	"github.com/zalando/skipper/metrics/metricstest"
+)
+
+func TestParsePriority(t *testing.T) {
+	for _, s := range []string{"high", "normal", "low"} {
+		p, err := ParsePriority(s)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		if p.String() != s {
+			t.Errorf("invalid priority, expected: %s, got: %s", s, p)
+		}
+	}
+
+	if _, err := ParsePriority("urgent"); err == nil {
+		t.Error("failed to fail")
+	}
+}
+
+func TestPriorityShedding(t *testing.T) {
+	m := &metricstest.MockMetrics{}
+	r := RegistryWith(Options{EnableRouteLIFOMetrics: true, Metrics: m})
+	defer r.Close()
+
+	q := r.newQueue("test", Config{MaxConcurrency: 1, MaxQueueSize: 4, Timeout: time.Second})
+	q.prioritize()
+
+	done, err := q.WaitPriority(PriorityLow)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	defer done()
+
+	waitQueued := func(p Priority, n int) {
+		for q.ClassStatus(p).QueuedRequests != n {
+			time.Sleep(time.Millisecond)
+		}
+	}
+
+	if s := q.ClassStatus(PriorityLow); s.ActiveRequests != 1 {
+		t.Errorf("unexpected status of the low class: %+v", s)
+	}
+
+	for i := 0; i < 2; i++ {
+		go q.WaitPriority(PriorityHigh)
+		waitQueued(PriorityHigh, i+1)
+	}
+
+	if _, err := q.WaitPriority(PriorityLow); err != ErrPriorityShed {
+		t.Errorf("unexpected error for low priority, expected: %v, got: %v", ErrPriorityShed, err)
+	}
+
+	go q.WaitPriority(PriorityNormal)
+	waitQueued(PriorityNormal, 1)
+
+	if _, err := q.WaitPriority(PriorityNormal); err != ErrPriorityShed {
+		t.Errorf("unexpected error for normal priority, expected: %v, got: %v", ErrPriorityShed, err)
+	}
+
+	go q.Wait()
+	waitQueued(PriorityHigh, 3)
+
+	m.WithCounters(func(c map[string]int64) {
+		if c["lifo.test.low.shed"] != 1 || c["lifo.test.normal.shed"] != 1 || c["lifo.test.high.shed"] != 0 {
+			t.Errorf("unexpected shed counters: %v", c)
+		}
+	})
+}
@@ -1,10 +1,11 @@
 // Package scheduler provides a registry to be used as a postprocessor for the routes
-// that use a LIFO filter.
+// that use a LIFO or a FIFO filter.
 package scheduler
 
 import (
 	"fmt"
 	"sync"
+	"sync/atomic"
 	"time"
 
 	"github.com/aryszka/jobqueue"
@@ -61,6 +62,13 @@ type Queue struct {
 	config                   Config
 	activeRequestsMetricsKey string
 	queuedRequestsMetricsKey string
+
+	// status and metrics of the priority classes, the keys contain the
+	// active, the queued and the shed metrics of each class
+	classes          [priorityClasses]classStatus
+	classMetricsKeys [priorityClasses][3]string
+	metrics          metrics.Metrics
+	prioritized      int32
 }
 
 // Options provides options for the registry.
@@ -73,6 +81,9 @@ type Options struct {
 	// EnableRouteLIFOMetrics enables collecting metrics about the LIFO queues.
 	EnableRouteLIFOMetrics bool
 
+	// EnableRouteFIFOMetrics enables collecting metrics about the FIFO queues.
+	EnableRouteFIFOMetrics bool
+
 	// Metrics must be provided to the registry in order to collect the LIFO metrics.
 	Metrics metrics.Metrics
 }
@@ -110,6 +121,22 @@ type LIFOFilter interface {
 	Config() Config
 }
 
+// FIFOFilter is the interface that needs to be implemented by the filters
+// that use a FIFO queue maintained by the registry.
+type FIFOFilter interface {
+
+	// SetQueue will be used by the registry to pass in the right queue to
+	// the filter.
+	SetQueue(*FifoQueue)
+
+	// GetQueue is currently used only by tests.
+	GetQueue() *FifoQueue
+
+	// Config will be called by the registry once during processing the
+	// routing to get the right queue settings from the filter.
+	Config() Config
+}
+
 // GroupedLIFOFilter is an extension of the LIFOFilter interface for filters
 // that use a shared queue.
 type GroupedLIFOFilter interface {
@@ -126,9 +153,10 @@ type GroupedLIFOFilter interface {
 // Wait blocks until a request can be processed or needs to be rejected.
 // When it can be processed, calling done indicates that it has finished.
 // It is mandatory to call done() the request was processed. When the
-// request needs to be rejected, an error will be returned.
+// request needs to be rejected, an error will be returned. The request
+// is handled in the high priority class.
 func (q *Queue) Wait() (done func(), err error) {
-	return q.queue.Wait()
+	return q.WaitPriority(PriorityHigh)
 }
 
 // Status returns the current status of a queue.
@@ -194,6 +222,30 @@ func (r *Registry) newQueue(name string, c Config) *Queue {
 
 		q.activeRequestsMetricsKey = fmt.Sprintf("lifo.%s.active", name)
 		q.queuedRequestsMetricsKey = fmt.Sprintf("lifo.%s.queued", name)
+		for p := Priority(0); p < priorityClasses; p++ {
+			q.classMetricsKeys[p] = [3]string{
+				fmt.Sprintf("lifo.%s.%s.active", name, p),
+				fmt.Sprintf("lifo.%s.%s.queued", name, p),
+				fmt.Sprintf("lifo.%s.%s.shed", name, p),
+			}
+		}
+
+		q.metrics = r.options.Metrics
+		r.measure()
+	}
+
+	return q
+}
+
+func (r *Registry) newFifoQueue(name string, c Config) *FifoQueue {
+	q := newFifoQueue(c)
+	if r.options.EnableRouteFIFOMetrics {
+		if name == "" {
+			name = "unknown"
+		}
+
+		q.activeRequestsMetricsKey = fmt.Sprintf("fifo.%s.active", name)
+		q.queuedRequestsMetricsKey = fmt.Sprintf("fifo.%s.queued", name)
 		r.measure()
 	}
 
@@ -207,14 +259,45 @@ func (r *Registry) Do(routes []*routing.Route) []*routing.Route {
 	rr := make([]*routing.Route, len(routes))
 	existingKeys := make(map[string]bool)
 	groups := make(map[string][]GroupedLIFOFilter)
+	prioritizedGroups := make(map[string]bool)
 
 	for i, ri := range routes {
 		rr[i] = ri
-		var lifoCount int
+		var lifoCount, fifoCount int
+		priority, prioritized := routePriority(ri)
 		for _, fi := range ri.Filters {
+			if ff, ok := fi.Filter.(FIFOFilter); ok {
+				fifoCount++
+				var q *FifoQueue
+				key := fmt.Sprintf("fifo::%s", ri.Id)
+				existingKeys[key] = true
+				c := ff.Config()
+				qi, ok := r.queues.Load(key)
+				if ok {
+					q = qi.(*FifoQueue)
+					if q.Config() != c {
+						q.reconfigure(c)
+					}
+				} else {
+					q = r.newFifoQueue(ri.Id, c)
+					r.queues.Store(key, q)
+				}
+
+				ff.SetQueue(q)
+				continue
+			}
+
 			if glf, ok := fi.Filter.(GroupedLIFOFilter); ok {
 				groupName := glf.Group()
 				groups[groupName] = append(groups[groupName], glf)
+				if pf, ok := glf.(PrioritizedLIFOFilter); ok {
+					pf.SetPriority(priority)
+				}
+
+				if prioritized {
+					prioritizedGroups[groupName] = true
+				}
+
 				continue
 			}
 
@@ -240,12 +323,24 @@ func (r *Registry) Do(routes []*routing.Route) []*routing.Route {
 				r.queues.Store(key, q)
 			}
 
+			if pf, ok := lf.(PrioritizedLIFOFilter); ok {
+				pf.SetPriority(priority)
+			}
+
+			if prioritized {
+				q.prioritize()
+			}
+
 			lf.SetQueue(q)
 		}
 
 		if lifoCount > 1 {
 			log.Warnf("Found multiple lifo filters in route: %s", ri.Id)
 		}
+
+		if fifoCount > 1 {
+			log.Warnf("Found multiple fifo filters in route: %s", ri.Id)
+		}
 	}
 
 	for name, group := range groups {
@@ -283,6 +378,10 @@ func (r *Registry) Do(routes []*routing.Route) []*routing.Route {
 			r.queues.Store(key, q)
 		}
 
+		if prioritizedGroups[name] {
+			q.prioritize()
+		}
+
 		for _, glf := range group {
 			glf.SetQueue(q)
 		}
@@ -290,7 +389,7 @@ func (r *Registry) Do(routes []*routing.Route) []*routing.Route {
 
 	r.queues.Range(func(key, qi interface{}) bool {
 		if !existingKeys[key.(string)] {
-			qi.(*Queue).close()
+			closeQueue(qi)
 			r.queues.Delete(key)
 		}
 
@@ -300,6 +399,23 @@ func (r *Registry) Do(routes []*routing.Route) []*routing.Route {
 	return rr
 }
 
+// routePriority returns the priority class set for the route, if any
+func routePriority(r *routing.Route) (Priority, bool) {
+	for _, fi := range r.Filters {
+		if pf, ok := fi.Filter.(PriorityFilter); ok {
+			return pf.Priority(), true
+		}
+	}
+
+	return PriorityHigh, false
+}
+
+// prioritize marks that the queue is used by routes with priority classes,
+// to collect the metrics of the classes
+func (q *Queue) prioritize() {
+	atomic.StoreInt32(&q.prioritized, 1)
+}
+
 func (r *Registry) measure() {
 	if r.options.Metrics == nil || r.measuring {
 		return
@@ -309,10 +425,34 @@ func (r *Registry) measure() {
 	go func() {
 		for {
 			r.queues.Range(func(_, value interface{}) bool {
-				q := value.(*Queue)
-				s := q.Status()
-				r.options.Metrics.UpdateGauge(q.activeRequestsMetricsKey, float64(s.ActiveRequests))
-				r.options.Metrics.UpdateGauge(q.queuedRequestsMetricsKey, float64(s.QueuedRequests))
+				var (
+					s                    QueueStatus
+					activeKey, queuedKey string
+				)
+
+				switch q := value.(type) {
+				case *Queue:
+					s = q.Status()
+					activeKey, queuedKey = q.activeRequestsMetricsKey, q.queuedRequestsMetricsKey
+				case *FifoQueue:
+					s = q.Status()
+					activeKey, queuedKey = q.activeRequestsMetricsKey, q.queuedRequestsMetricsKey
+				}
+
+				if activeKey == "" {
+					return true
+				}
+
+				r.options.Metrics.UpdateGauge(activeKey, float64(s.ActiveRequests))
+				r.options.Metrics.UpdateGauge(queuedKey, float64(s.QueuedRequests))
+				if q, ok := value.(*Queue); ok && atomic.LoadInt32(&q.prioritized) != 0 {
+					for p := Priority(0); p < priorityClasses; p++ {
+						cs := q.ClassStatus(p)
+						r.options.Metrics.UpdateGauge(q.classMetricsKeys[p][0], float64(cs.ActiveRequests))
+						r.options.Metrics.UpdateGauge(q.classMetricsKeys[p][1], float64(cs.QueuedRequests))
+					}
+				}
+
 				return true
 			})
 
@@ -329,9 +469,18 @@ func (r *Registry) measure() {
 // queues.
 func (r *Registry) Close() {
 	r.queues.Range(func(_, value interface{}) bool {
-		value.(*Queue).close()
+		closeQueue(value)
 		return true
 	})
 
 	close(r.quit)
 }
+
+func closeQueue(q interface{}) {
+	switch qt := q.(type) {
+	case *Queue:
+		qt.close()
+	case *FifoQueue:
+		qt.close()
+	}
+}
@@ -0,0 +1,224 @@
+package secrets
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"os/exec"
+	"sync"
+	"time"
+
+	log "github.com/sirupsen/logrus"
+)
+
+const (
+	defaultCommandTimeout       = 30 * time.Second
+	defaultCommandRefreshBefore = time.Minute
+	defaultCommandMinBackoff    = time.Second
+	defaultCommandMaxBackoff    = 5 * time.Minute
+)
+
+// ErrNoToken is returned, if the output of the credential command
+// does not contain a token.
+var ErrNoToken = errors.New("credential command returned no token")
+
+// CommandOptions configure a CommandReader.
+type CommandOptions struct {
+	// Command is the executable to run.
+	Command string
+	// Args are the arguments passed to Command.
+	Args []string
+	// Env are additional environment variables in the form
+	// "key=value" passed to Command in addition to the environment
+	// of the current process.
+	Env []string
+	// Timeout of a single execution of Command, defaults to 30s.
+	Timeout time.Duration
+	// RefreshBefore defines how long before the expiry of the token
+	// Command is run again, defaults to 1m.
+	RefreshBefore time.Duration
+	// RefreshInterval is used to run Command again, if the output has
+	// no expiry, defaults to 10m.
+	RefreshInterval time.Duration
+	// MinBackoff and MaxBackoff limit the exponential backoff
+	// between failed executions, default to 1s and 5m.
+	MinBackoff time.Duration
+	MaxBackoff time.Duration
+	// OnRefreshError is called with the error of every failed
+	// execution.
+	OnRefreshError func(error)
+}
+
+// CommandReader is a SecretsReader, that gets a bearer token by
+// executing a command, similar to the exec credential plugins of
+// kubectl. The command has to write JSON to stdout, either in the
+// form:
+//
+//     {"token": "...", "expiry": "2006-01-02T15:04:05Z"}
+//
+// or in the form of a Kubernetes ExecCredential:
+//
+//     {"status": {"token": "...", "expirationTimestamp": "2006-01-02T15:04:05Z"}}
+//
+// The expiry is optional. The token is cached and the command is run
+// again in the background before the token expires.
+type CommandReader struct {
+	options CommandOptions
+	quit    chan struct{}
+	once    sync.Once
+
+	mu     sync.RWMutex
+	token  []byte
+	expiry time.Time
+}
+
+type commandOutput struct {
+	Token  string    `json:"token"`
+	Expiry time.Time `json:"expiry"`
+	Status *struct {
+		Token               string    `json:"token"`
+		ExpirationTimestamp time.Time `json:"expirationTimestamp"`
+	} `json:"status"`
+}
+
+// NewCommandReader creates a CommandReader. It runs the command once
+// synchronously and starts a background refresher. On tear down make
+// sure to Close() it.
+func NewCommandReader(o CommandOptions) *CommandReader {
+	if o.Timeout <= 0 {
+		o.Timeout = defaultCommandTimeout
+	}
+	if o.RefreshBefore <= 0 {
+		o.RefreshBefore = defaultCommandRefreshBefore
+	}
+	if o.RefreshInterval <= 0 {
+		o.RefreshInterval = defaultCredentialsUpdateInterval
+	}
+	if o.MinBackoff <= 0 {
+		o.MinBackoff = defaultCommandMinBackoff
+	}
+	if o.MaxBackoff < o.MinBackoff {
+		o.MaxBackoff = defaultCommandMaxBackoff
+		if o.MaxBackoff < o.MinBackoff {
+			o.MaxBackoff = o.MinBackoff
+		}
+	}
+
+	cr := &CommandReader{
+		options: o,
+		quit:    make(chan struct{}),
+	}
+
+	err := cr.refresh()
+	go cr.runRefresher(err)
+	return cr
+}
+
+// GetSecret returns the cached token, if it is not expired. The name
+// is ignored.
+func (cr *CommandReader) GetSecret(string) ([]byte, bool) {
+	cr.mu.RLock()
+	defer cr.mu.RUnlock()
+	if len(cr.token) == 0 || (!cr.expiry.IsZero() && !time.Now().Before(cr.expiry)) {
+		return nil, false
+	}
+	return cr.token, true
+}
+
+// GetSecretExpiry returns the expiry of the cached token, if the
+// command returned one. The name is ignored.
+func (cr *CommandReader) GetSecretExpiry(string) (time.Time, bool) {
+	cr.mu.RLock()
+	defer cr.mu.RUnlock()
+	if len(cr.token) == 0 || cr.expiry.IsZero() {
+		return time.Time{}, false
+	}
+	return cr.expiry, true
+}
+
+// Close stops the background refresher.
+func (cr *CommandReader) Close() {
+	cr.once.Do(func() {
+		close(cr.quit)
+	})
+}
+
+func (cr *CommandReader) refresh() error {
+	ctx, cancel := context.WithTimeout(context.Background(), cr.options.Timeout)
+	defer cancel()
+
+	cmd := exec.CommandContext(ctx, cr.options.Command, cr.options.Args...)
+	cmd.Env = append(os.Environ(), cr.options.Env...)
+	out, err := cmd.Output()
+	if err != nil {
+		return fmt.Errorf("failed to run credential command %s: %w", cr.options.Command, err)
+	}
+
+	var co commandOutput
+	if err := json.Unmarshal(out, &co); err != nil {
+		return fmt.Errorf("failed to parse output of credential command %s: %w", cr.options.Command, err)
+	}
+
+	token, expiry := co.Token, co.Expiry
+	if co.Status != nil {
+		token, expiry = co.Status.Token, co.Status.ExpirationTimestamp
+	}
+	if token == "" {
+		return ErrNoToken
+	}
+
+	cr.mu.Lock()
+	cr.token = []byte(token)
+	cr.expiry = expiry
+	cr.mu.Unlock()
+	return nil
+}
+
+// nextRefresh returns the duration until the command has to be run
+// again after a successful run.
+func (cr *CommandReader) nextRefresh() time.Duration {
+	cr.mu.RLock()
+	expiry := cr.expiry
+	cr.mu.RUnlock()
+
+	if expiry.IsZero() {
+		return cr.options.RefreshInterval
+	}
+
+	d := time.Until(expiry) - cr.options.RefreshBefore
+	if d < cr.options.MinBackoff {
+		d = cr.options.MinBackoff
+	}
+	return d
+}
+
+func (cr *CommandReader) runRefresher(err error) {
+	backoff := cr.options.MinBackoff
+	for {
+		var wait time.Duration
+		if err != nil {
+			log.Errorf("Failed to refresh token: %v", err)
+			if cr.options.OnRefreshError != nil {
+				cr.options.OnRefreshError(err)
+			}
+
+			wait = backoff
+			backoff *= 2
+			if backoff > cr.options.MaxBackoff {
+				backoff = cr.options.MaxBackoff
+			}
+		} else {
+			wait = cr.nextRefresh()
+			backoff = cr.options.MinBackoff
+		}
+
+		select {
+		case <-time.After(wait):
+			err = cr.refresh()
+		case <-cr.quit:
+			return
+		}
+	}
+}
@@ -0,0 +1,140 @@
+package secrets
+
+import (
+	"errors"
+	"fmt"
+	"sync"
+	"testing"
+	"time"
+)
+
+func TestCommandReader(t *testing.T) {
+	expiry := time.Now().Add(time.Hour).UTC().Format(time.RFC3339)
+	expired := time.Now().Add(-time.Hour).UTC().Format(time.RFC3339)
+
+	for _, tt := range []struct {
+		name    string
+		script  string
+		want    string
+		wantOk  bool
+		wantErr bool
+	}{{
+		name:   "token with expiry",
+		script: fmt.Sprintf(`echo '{"token": "foo", "expiry": "%s"}'`, expiry),
+		want:   "foo",
+		wantOk: true,
+	}, {
+		name:   "token without expiry",
+		script: `echo '{"token": "foo"}'`,
+		want:   "foo",
+		wantOk: true,
+	}, {
+		name:   "exec credential",
+		script: fmt.Sprintf(`echo '{"kind": "ExecCredential", "status": {"token": "bar", "expirationTimestamp": "%s"}}'`, expiry),
+		want:   "bar",
+		wantOk: true,
+	}, {
+		name:   "expired token",
+		script: fmt.Sprintf(`echo '{"token": "foo", "expiry": "%s"}'`, expired),
+		wantOk: false,
+	}, {
+		name:    "no token",
+		script:  `echo '{}'`,
+		wantErr: true,
+	}, {
+		name:    "invalid output",
+		script:  `echo foo`,
+		wantErr: true,
+	}, {
+		name:    "command fails",
+		script:  `exit 1`,
+		wantErr: true,
+	}} {
+		t.Run(tt.name, func(t *testing.T) {
+			errs := make(chan error, 1)
+			cr := NewCommandReader(CommandOptions{
+				Command:    "sh",
+				Args:       []string{"-c", tt.script},
+				MinBackoff: time.Hour,
+				OnRefreshError: func(err error) {
+					select {
+					case errs <- err:
+					default:
+					}
+				},
+			})
+			defer cr.Close()
+
+			got, ok := cr.GetSecret("ignored")
+			if ok != tt.wantOk || string(got) != tt.want {
+				t.Errorf("Failed to get secret: got %q %v, want %q %v", got, ok, tt.want, tt.wantOk)
+			}
+
+			select {
+			case err := <-errs:
+				if !tt.wantErr {
+					t.Errorf("Failed to not get an error: %v", err)
+				}
+			case <-time.After(50 * time.Millisecond):
+				if tt.wantErr {
+					t.Error("Failed to get an error")
+				}
+			}
+		})
+	}
+}
+
+func TestCommandReaderRefresh(t *testing.T) {
+	var mu sync.Mutex
+	var errs []error
+	cr := NewCommandReader(CommandOptions{
+		Command:    "sh",
+		Args:       []string{"-c", `test -f "$TOKEN_FILE" && cat "$TOKEN_FILE" || exit 1`},
+		Env:        []string{"TOKEN_FILE=/nonexistent/skipper-test-token"},
+		MinBackoff: 10 * time.Millisecond,
+		MaxBackoff: 20 * time.Millisecond,
+		OnRefreshError: func(err error) {
+			mu.Lock()
+			errs = append(errs, err)
+			mu.Unlock()
+		},
+	})
+	defer cr.Close()
+
+	time.Sleep(100 * time.Millisecond)
+
+	mu.Lock()
+	defer mu.Unlock()
+	if len(errs) < 2 {
+		t.Fatalf("Failed to retry failed command, errors: %v", errs)
+	}
+	var exitErr interface{ ExitCode() int }
+	if !errors.As(errs[0], &exitErr) {
+		t.Errorf("Failed to get the command error: %v", errs[0])
+	}
+}
+
+func TestCommandReaderExpiry(t *testing.T) {
+	expiry := time.Now().Add(time.Hour).UTC().Truncate(time.Second)
+
+	cr := NewCommandReader(CommandOptions{
+		Command: "sh",
+		Args:    []string{"-c", fmt.Sprintf(`echo '{"token": "foo", "expiry": "%s"}'`, expiry.Format(time.RFC3339))},
+	})
+	defer cr.Close()
+
+	got, ok := cr.GetSecretExpiry("ignored")
+	if !ok || !got.Equal(expiry) {
+		t.Errorf("Failed to get expiry: got %v %v, want %v", got, ok, expiry)
+	}
+
+	cr = NewCommandReader(CommandOptions{
+		Command: "sh",
+		Args:    []string{"-c", `echo '{"token": "foo"}'`},
+	})
+	defer cr.Close()
+
+	if _, ok := cr.GetSecretExpiry("ignored"); ok {
+		t.Error("Failed to not get an expiry")
+	}
+}
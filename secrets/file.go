@@ -28,6 +28,14 @@ type SecretsReader interface {
 	GetSecret(string) ([]byte, bool)
 }
 
+// ExpiryReader is implemented by SecretsReaders, that know when their
+// secrets expire.
+type ExpiryReader interface {
+	// GetSecretExpiry returns the expiry of the secret found by name
+	// and if the expiry is known
+	GetSecretExpiry(string) (time.Time, bool)
+}
+
 // SecretsProvider is a SecretsReader and can add secret sources that
 // contain a secret. It will automatically update secrets if the source
 // changed.
@@ -0,0 +1,113 @@
+package secrets
+
+import (
+	"net/url"
+	"sort"
+	"strings"
+	"time"
+)
+
+// HostLookuper is a SecretsReader, that looks up the secret for a URL
+// by its hostname. The hosts are mapped to secret names, that are read
+// from the wrapped SecretsReader. A host can be configured as:
+//
+//	api.example.org          the exact hostname
+//	*.internal.example.org   all subdomains of internal.example.org
+//	.example.org             example.org and all its subdomains
+//
+// An exact hostname has precedence, otherwise the wildcard or suffix
+// entry with the longest matching suffix is used.
+type HostLookuper struct {
+	sr       SecretsReader
+	exact    map[string]string
+	suffixes []hostSuffix
+}
+
+type hostSuffix struct {
+	suffix     string
+	secret     string
+	subdomains bool
+}
+
+// NewHostLookuper creates a HostLookuper reading the secrets from sr
+// and hosts mapping hostnames to secret names.
+func NewHostLookuper(sr SecretsReader, hosts map[string]string) *HostLookuper {
+	hl := &HostLookuper{sr: sr, exact: make(map[string]string)}
+	for h, secret := range hosts {
+		h = strings.ToLower(h)
+		switch {
+		case strings.HasPrefix(h, "*."):
+			hl.suffixes = append(hl.suffixes, hostSuffix{suffix: h[1:], secret: secret, subdomains: true})
+		case strings.HasPrefix(h, "."):
+			hl.suffixes = append(hl.suffixes, hostSuffix{suffix: h, secret: secret})
+		default:
+			hl.exact[h] = secret
+		}
+	}
+
+	sort.Slice(hl.suffixes, func(i, j int) bool {
+		if len(hl.suffixes[i].suffix) != len(hl.suffixes[j].suffix) {
+			return len(hl.suffixes[i].suffix) > len(hl.suffixes[j].suffix)
+		}
+		// the suffix entry includes the domain itself
+		return !hl.suffixes[i].subdomains
+	})
+	return hl
+}
+
+// GetSecret returns the secret for the hostname of the given URL or
+// hostname and if found or not.
+func (hl *HostLookuper) GetSecret(s string) ([]byte, bool) {
+	name, ok := hl.lookup(s)
+	if !ok {
+		return nil, false
+	}
+	return hl.sr.GetSecret(name)
+}
+
+// GetSecretExpiry returns the expiry of the secret for the hostname of
+// the given URL or hostname, if the wrapped SecretsReader implements
+// ExpiryReader.
+func (hl *HostLookuper) GetSecretExpiry(s string) (time.Time, bool) {
+	er, ok := hl.sr.(ExpiryReader)
+	if !ok {
+		return time.Time{}, false
+	}
+
+	name, ok := hl.lookup(s)
+	if !ok {
+		return time.Time{}, false
+	}
+	return er.GetSecretExpiry(name)
+}
+
+func (hl *HostLookuper) lookup(s string) (string, bool) {
+	host := hostname(s)
+	if host == "" {
+		return "", false
+	}
+
+	if secret, ok := hl.exact[host]; ok {
+		return secret, true
+	}
+
+	for _, hs := range hl.suffixes {
+		if strings.HasSuffix(host, hs.suffix) || !hs.subdomains && "."+host == hs.suffix {
+			return hs.secret, true
+		}
+	}
+	return "", false
+}
+
+// hostname returns the lower case hostname of a URL or the given
+// string, if it is not a URL.
+func hostname(s string) string {
+	if strings.Contains(s, "://") {
+		u, err := url.Parse(s)
+		if err != nil {
+			return ""
+		}
+		return strings.ToLower(u.Hostname())
+	}
+	return strings.ToLower(s)
+}
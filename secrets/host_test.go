@@ -0,0 +1,113 @@
+package secrets
+
+import (
+	"testing"
+	"time"
+)
+
+type mapReader map[string]string
+
+func (r mapReader) GetSecret(s string) ([]byte, bool) {
+	v, ok := r[s]
+	return []byte(v), ok
+}
+
+type expiryMapReader struct {
+	mapReader
+	expiry time.Time
+}
+
+func (r expiryMapReader) GetSecretExpiry(s string) (time.Time, bool) {
+	_, ok := r.mapReader[s]
+	return r.expiry, ok
+}
+
+func TestHostLookuper(t *testing.T) {
+	sr := mapReader{
+		"exact":    "exact-token",
+		"internal": "internal-token",
+		"team":     "team-token",
+		"domain":   "domain-token",
+	}
+	hl := NewHostLookuper(sr, map[string]string{
+		"api.example.org":             "exact",
+		"*.internal.example.org":      "internal",
+		"*.team.internal.example.org": "team",
+		".example.org":                "domain",
+	})
+
+	for _, tt := range []struct {
+		name   string
+		url    string
+		want   string
+		wantOk bool
+	}{{
+		name:   "exact host",
+		url:    "https://api.example.org/foo",
+		want:   "exact-token",
+		wantOk: true,
+	}, {
+		name:   "exact host with port and upper case",
+		url:    "https://API.example.org:8443/foo",
+		want:   "exact-token",
+		wantOk: true,
+	}, {
+		name:   "wildcard",
+		url:    "https://svc.internal.example.org/",
+		want:   "internal-token",
+		wantOk: true,
+	}, {
+		name:   "longest wildcard",
+		url:    "https://svc.team.internal.example.org/",
+		want:   "team-token",
+		wantOk: true,
+	}, {
+		name:   "wildcard does not match the domain itself",
+		url:    "https://internal.example.org/",
+		want:   "domain-token",
+		wantOk: true,
+	}, {
+		name:   "suffix matches the domain itself",
+		url:    "https://example.org/",
+		want:   "domain-token",
+		wantOk: true,
+	}, {
+		name:   "hostname",
+		url:    "other.example.org",
+		want:   "domain-token",
+		wantOk: true,
+	}, {
+		name: "suffix is matched by labels",
+		url:  "https://notexample.org/",
+	}, {
+		name: "unknown host",
+		url:  "https://example.com/",
+	}} {
+		t.Run(tt.name, func(t *testing.T) {
+			got, ok := hl.GetSecret(tt.url)
+			if ok != tt.wantOk || string(got) != tt.want {
+				t.Errorf("Failed to get secret: got %q %v, want %q %v", got, ok, tt.want, tt.wantOk)
+			}
+		})
+	}
+}
+
+func TestHostLookuperExpiry(t *testing.T) {
+	expiry := time.Now().Add(time.Hour)
+	hl := NewHostLookuper(
+		expiryMapReader{mapReader: mapReader{"internal": "token"}, expiry: expiry},
+		map[string]string{"*.internal.example.org": "internal"},
+	)
+
+	if got, ok := hl.GetSecretExpiry("https://svc.internal.example.org/"); !ok || !got.Equal(expiry) {
+		t.Errorf("Failed to get expiry: %v %v", got, ok)
+	}
+	if _, ok := hl.GetSecretExpiry("https://example.org/"); ok {
+		t.Error("Failed to not get expiry for unknown host")
+	}
+
+	hl = NewHostLookuper(mapReader{"internal": "token"}, map[string]string{"*.internal.example.org": "internal"})
+	if _, ok := hl.GetSecretExpiry("https://svc.internal.example.org/"); ok {
+		t.Error("Failed to not get expiry without ExpiryReader")
+	}
+}
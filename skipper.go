@@ -3,25 +3,31 @@ package skipper
 import (
 	"context"
 	"crypto/tls"
+	"crypto/x509"
 	"fmt"
 	"io"
 	"io/ioutil"
 	"net"
 	"net/http"
+	"net/url"
 	"os"
 	"os/signal"
 	"path"
 	"strconv"
 	"strings"
+	"sync"
+	"sync/atomic"
 	"syscall"
 	"time"
 
 	"github.com/zalando/skipper/predicates/cron"
+	"github.com/zalando/skipper/predicates/listener"
 	"github.com/zalando/skipper/predicates/primitive"
 
 	ot "github.com/opentracing/opentracing-go"
 	log "github.com/sirupsen/logrus"
 
+	"github.com/zalando/skipper/cache"
 	"github.com/zalando/skipper/circuit"
 	"github.com/zalando/skipper/dataclients/kubernetes"
 	"github.com/zalando/skipper/dataclients/routestring"
@@ -32,18 +38,27 @@ import (
 	"github.com/zalando/skipper/filters/apiusagemonitoring"
 	"github.com/zalando/skipper/filters/auth"
 	"github.com/zalando/skipper/filters/builtin"
+	cachefilter "github.com/zalando/skipper/filters/cache"
+	geofilter "github.com/zalando/skipper/filters/geoip"
 	logfilter "github.com/zalando/skipper/filters/log"
+	"github.com/zalando/skipper/geoip"
 	"github.com/zalando/skipper/innkeeper"
 	"github.com/zalando/skipper/loadbalancer"
 	"github.com/zalando/skipper/logging"
 	"github.com/zalando/skipper/metrics"
+	snet "github.com/zalando/skipper/net"
 	pauth "github.com/zalando/skipper/predicates/auth"
+	"github.com/zalando/skipper/predicates/contentlength"
 	"github.com/zalando/skipper/predicates/cookie"
+	pgeoip "github.com/zalando/skipper/predicates/geoip"
 	"github.com/zalando/skipper/predicates/interval"
+	"github.com/zalando/skipper/predicates/mediatype"
 	"github.com/zalando/skipper/predicates/query"
 	"github.com/zalando/skipper/predicates/source"
+	"github.com/zalando/skipper/predicates/tlscert"
 	"github.com/zalando/skipper/predicates/traffic"
 	"github.com/zalando/skipper/proxy"
+	"github.com/zalando/skipper/proxyprotocol"
 	"github.com/zalando/skipper/queuelistener"
 	"github.com/zalando/skipper/ratelimit"
 	"github.com/zalando/skipper/routing"
@@ -60,6 +75,23 @@ const (
 
 const DefaultPluginDir = "./plugins"
 
+// ListenerOptions defines an additional listener of the proxy.
+type ListenerOptions struct {
+
+	// Name of the listener, used by the Listener predicate to
+	// match the requests accepted by this listener.
+	Name string `yaml:"name"`
+
+	// Network address that the listener should listen on.
+	Address string `yaml:"address"`
+
+	// CertPathTLS and KeyPathTLS are the paths of the TLS certificate
+	// and key of the listener. When not set, the listener serves plain
+	// HTTP.
+	CertPathTLS string `yaml:"tls-cert"`
+	KeyPathTLS  string `yaml:"tls-key"`
+}
+
 type testOptions struct {
 	redisConnMetricsInterval time.Duration
 }
@@ -71,6 +103,13 @@ type Options struct {
 	// to 0.
 	WaitForHealthcheckInterval time.Duration
 
+	// ShutdownTimeout sets the maximum time that skipper waits for
+	// the in-flight requests to complete, after it stopped accepting
+	// new connections during shutdown. When the timeout expires, the
+	// remaining connections are closed. Defaults to 0, meaning no
+	// limit.
+	ShutdownTimeout time.Duration
+
 	// StatusChecks is an experimental feature. It defines a
 	// comma separated list of HTTP URLs to do GET requests to,
 	// that have to return 200 before skipper becomes ready
@@ -106,6 +145,38 @@ type Options struct {
 	// If defines the maximum number of pending connection waiting in the queue.
 	MaxTCPListenerQueue int
 
+	// EnableProxyProtocol enables accepting the PROXY protocol, version 1
+	// and 2, on the proxy listener, so that the address of the original
+	// client is preserved behind TCP load balancers. Connections without
+	// the PROXY protocol header are accepted as they are.
+	EnableProxyProtocol bool
+
+	// ProxyProtocolTrustedNetworks restricts accepting the PROXY
+	// protocol header to the peers in these networks, e.g. the load
+	// balancers, so that other clients can not spoof their address.
+	// When empty, the header is accepted from any peer.
+	ProxyProtocolTrustedNetworks []*net.IPNet
+
+	// EnableHTTP3 starts an experimental HTTP/3 listener next to the TLS
+	// listener of the proxy, with the same certificates, served by the
+	// HTTP3Server. The responses of the TLS listener advertise it with
+	// the Alt-Svc header. It requires the TLS settings of the proxy.
+	EnableHTTP3 bool
+
+	// HTTP3Server is the QUIC capable server used when EnableHTTP3 is
+	// set. EnableHTTP3 is ignored, if not set.
+	HTTP3Server HTTP3Server
+
+	// HTTP3Address is the UDP address of the HTTP/3 listener. Defaults
+	// to Address.
+	HTTP3Address string
+
+	// Listeners defines additional listeners of the proxy, next to the
+	// one listening on Address. Each listener can have its own TLS
+	// settings, and the routes can be scoped to a listener with the
+	// Listener predicate.
+	Listeners []ListenerOptions
+
 	// List of custom filter specifications.
 	CustomFilters []filters.Spec
 
@@ -185,6 +256,14 @@ type Options struct {
 	// KubernetesEastWestDomain sets the cluster internal domain used to create additional routes in skipper, defaults to skipper.cluster.local
 	KubernetesEastWestDomain string
 
+	// KubernetesEnableGatewayAPI enables the routes defined by the Gateway API resources: GatewayClass,
+	// Gateway and HTTPRoute
+	KubernetesEnableGatewayAPI bool
+
+	// KubernetesGatewayController sets the controller name of the GatewayClasses handled by skipper,
+	// defaults to zalando.org/skipper
+	KubernetesGatewayController string
+
 	// *DEPRECATED* API endpoint of the Innkeeper service, storing route definitions.
 	InnkeeperUrl string
 
@@ -294,6 +373,15 @@ type Options struct {
 	// a backend to always create a new connection.
 	DisableHTTPKeepalives bool
 
+	// EnableHTTP2Backends enables negotiating HTTP/2 with TLS
+	// backends, e.g. for proxying gRPC.
+	EnableHTTP2Backends bool
+
+	// EgressProxy, when set, is the URL of an HTTP proxy, through
+	// which the backend requests are sent. It can be overridden per
+	// route with the egressProxy filter.
+	EgressProxy *url.URL
+
 	// Flag indicating to ignore trailing slashes in paths during route
 	// lookup.
 	IgnoreTrailingSlash bool
@@ -367,6 +455,11 @@ type Options struct {
 	// it is enabled by default.
 	EnableRouteResponseMetrics bool
 
+	// EnableGRPCStatusMetrics enables collecting response time
+	// metrics of gRPC responses per each route, grouped by the
+	// grpc-status of the response.
+	EnableGRPCStatusMetrics bool
+
 	// EnableRouteBackendErrorsCounters enables counters for backend
 	// errors per each route. Without the DisableMetricsCompatibilityDefaults,
 	// it is enabled by default.
@@ -447,6 +540,12 @@ type Options struct {
 	// multiple keys, the order must match the one given in CertPathTLS
 	KeyPathTLS string
 
+	// Path of the CA certificate(s) verifying the TLS client certificates,
+	// multiple may be given comma separated. When set, the clients may
+	// present a certificate, that can be matched by the ClientCert
+	// predicates. It applies to ProxyTLS, too.
+	ClientCAPathTLS string
+
 	// TLS Settings for Proxy Server
 	ProxyTLS *tls.Config
 
@@ -456,6 +555,15 @@ type Options struct {
 	// Flush interval for upgraded Proxy connections
 	BackendFlushInterval time.Duration
 
+	// Flush interval for response bodies. When 0, the response is
+	// flushed after every read from the backend.
+	ResponseFlushInterval time.Duration
+
+	// ServerSentEventsIdleTimeout sets the maximum time a stream of
+	// Server-Sent Events can be idle, before it is closed. 0 means no
+	// timeout.
+	ServerSentEventsIdleTimeout time.Duration
+
 	// Experimental feature to handle protocol Upgrades for Websockets, SPDY, etc.
 	ExperimentalUpgrade bool
 
@@ -463,10 +571,19 @@ type Options struct {
 	// and the response messages during web socket upgrades.
 	ExperimentalUpgradeAudit bool
 
+	// WebSocketIdleTimeout sets the maximum time an upgraded websocket
+	// connection can be idle in both directions, before it is closed.
+	// Defaults to no timeout.
+	WebSocketIdleTimeout time.Duration
+
 	// MaxLoopbacks defines the maximum number of loops that the proxy can execute when the routing table
 	// contains loop backends (<loopback>).
 	MaxLoopbacks int
 
+	// MaxLBRetries defines the maximum number of times a request is retried against the next
+	// endpoint of a load balanced route when dialing the backend failed.
+	MaxLBRetries int
+
 	// EnableBreakers enables the usage of the breakers in the route definitions without initializing any
 	// by default. It is a shortcut for setting the BreakerSettings to:
 	//
@@ -487,9 +604,20 @@ type Options struct {
 	// RatelimitSettings contain global and host specific settings for the ratelimiters.
 	RatelimitSettings []ratelimit.Settings
 
+	// RatelimitResponseHeaders enables the RateLimit headers in the
+	// responses to the requests allowed by the route rate limits.
+	RatelimitResponseHeaders bool
+
+	// RatelimitLegacyHeaders enables the X-RateLimit headers in addition
+	// to the RateLimit headers.
+	RatelimitLegacyHeaders bool
+
 	// EnableRouteLIFOMetrics enables metrics for the individual route LIFO queues, if any.
 	EnableRouteLIFOMetrics bool
 
+	// EnableRouteFIFOMetrics enables metrics for the individual route FIFO queues, if any.
+	EnableRouteFIFOMetrics bool
+
 	// OpenTracing enables opentracing
 	OpenTracing []string
 
@@ -535,6 +663,16 @@ type Options struct {
 	// for a request.
 	DefaultHTTPStatus int
 
+	// ForwardedHeaders defines how the X-Forwarded-* and the Forwarded
+	// headers are set on the requests sent to the backends.
+	ForwardedHeaders snet.ForwardedHeaders
+
+	// ClientIPForwardedDepth, when greater than zero, makes the ClientIP
+	// predicate take the client address from the X-Forwarded-For header
+	// at this depth. Otherwise, it skips the trusted proxies set in
+	// ForwardedHeaders.
+	ClientIPForwardedDepth int
+
 	// EnablePrometheusMetrics enables Prometheus format metrics.
 	//
 	// This option is *deprecated*. The recommended way to enable prometheus metrics is to
@@ -568,9 +706,34 @@ type Options struct {
 	// OAuthTokenintrospectionTimeout sets timeout duration while calling oauth tokenintrospection service
 	OAuthTokenintrospectionTimeout time.Duration
 
+	// OAuthTokeninfoCacheSize sets the maximum number of cached tokeninfo
+	// responses. The cache is disabled when it is 0.
+	OAuthTokeninfoCacheSize int
+
+	// OAuthTokeninfoCacheTTL sets the maximum duration of caching a
+	// tokeninfo response.
+	OAuthTokeninfoCacheTTL time.Duration
+
+	// OAuthTokenintrospectCacheSize sets the maximum number of cached
+	// tokenintrospection responses. The cache is disabled when it is 0.
+	OAuthTokenintrospectCacheSize int
+
+	// OAuthTokenintrospectCacheTTL sets the maximum duration of caching a
+	// tokenintrospection response.
+	OAuthTokenintrospectCacheTTL time.Duration
+
 	// OIDCSecretsFile path to the file containing key to encrypt OpenID token
 	OIDCSecretsFile string
 
+	// OIDCSessionStore sets where the OpenID Connect sessions are stored,
+	// "cookie" or "redis". The redis store uses the shards set by
+	// SwarmRedisURLs. Defaults to cookie.
+	OIDCSessionStore string
+
+	// CSRFSecretsFile is the path to the file containing the key to
+	// encrypt the CSRF tokens. Defaults to OIDCSecretsFile.
+	CSRFSecretsFile string
+
 	// SecretsRegistry to store and load secretsencrypt
 	SecretsRegistry *secrets.Registry
 
@@ -594,9 +757,37 @@ type Options struct {
 	// WebhookTimeout sets timeout duration while calling a custom webhook auth service
 	WebhookTimeout time.Duration
 
+	// OpaTimeout sets timeout duration while calling the OPA server in the
+	// opaAuthorizeRequest filters
+	OpaTimeout time.Duration
+
 	// MaxAuditBody sets the maximum read size of the body read by the audit log filter
 	MaxAuditBody int
 
+	// ResponseCacheMaxSize sets the maximum total size in bytes of the
+	// responses stored by the cacheResponse filter. Defaults to
+	// cache.DefaultMaxSize.
+	ResponseCacheMaxSize int64
+
+	// ResponseCacheMaxEntrySize sets the maximum size in bytes of a
+	// single response body stored by the cacheResponse filter. Defaults
+	// to cache.DefaultMaxEntrySize.
+	ResponseCacheMaxEntrySize int64
+
+	// GeoIPCountryDatabase is the path of a MaxMind database with the
+	// countries of the networks, e.g. GeoLite2-Country.mmdb. It enables
+	// the Country predicate and the setGeoHeaders filter.
+	GeoIPCountryDatabase string
+
+	// GeoIPASNDatabase is the path of a MaxMind database with the
+	// autonomous systems of the networks, e.g. GeoLite2-ASN.mmdb. It
+	// enables the setGeoHeaders filter.
+	GeoIPASNDatabase string
+
+	// GeoIPReloadInterval sets how often the GeoIP database files are
+	// checked for changes.
+	GeoIPReloadInterval time.Duration
+
 	// EnableSwarm enables skipper fleet communication, required by e.g.
 	// the cluster ratelimiter
 	EnableSwarm bool
@@ -619,6 +810,29 @@ type Options struct {
 	SwarmStaticOther string // 127.0.0.1:9002,127.0.0.1:9003
 
 	testOptions
+
+	// set by run(), reports readiness on the support listener
+	readiness *readiness
+}
+
+// readiness serves the /ready endpoint of the support listener. It
+// responds with 200 until the shutdown was triggered, and with 503
+// during the drain phase of the shutdown.
+type readiness struct {
+	draining int32
+}
+
+func (r *readiness) drain() {
+	atomic.StoreInt32(&r.draining, 1)
+}
+
+func (r *readiness) ServeHTTP(w http.ResponseWriter, _ *http.Request) {
+	if atomic.LoadInt32(&r.draining) != 0 {
+		w.WriteHeader(http.StatusServiceUnavailable)
+		return
+	}
+
+	w.WriteHeader(http.StatusOK)
 }
 
 func createDataClients(o Options, auth innkeeper.Authentication) ([]routing.DataClient, error) {
@@ -698,6 +912,8 @@ func createDataClients(o Options, auth innkeeper.Authentication) ([]routing.Data
 			KubernetesNamespace:        o.KubernetesNamespace,
 			KubernetesEnableEastWest:   o.KubernetesEnableEastWest,
 			KubernetesEastWestDomain:   o.KubernetesEastWestDomain,
+			KubernetesEnableGatewayAPI: o.KubernetesEnableGatewayAPI,
+			GatewayControllerName:      o.KubernetesGatewayController,
 			DefaultFiltersDir:          o.DefaultFiltersDir,
 			OriginMarker:               o.EnableRouteCreationMetrics,
 		})
@@ -760,13 +976,45 @@ func (o *Options) isHTTPS() bool {
 	return (o.ProxyTLS != nil) || (o.CertPathTLS != "" && o.KeyPathTLS != "")
 }
 
+// withClientCAs returns a copy of the TLS configuration, that verifies the
+// client certificates with the CA certificates loaded from the comma
+// separated paths. The client certificates are optional.
+func withClientCAs(cfg *tls.Config, paths string) (*tls.Config, error) {
+	pool := x509.NewCertPool()
+	for _, p := range strings.Split(paths, ",") {
+		pem, err := ioutil.ReadFile(p)
+		if err != nil {
+			return nil, fmt.Errorf("failed to read client CA certificates: %v", err)
+		}
+
+		if !pool.AppendCertsFromPEM(pem) {
+			return nil, fmt.Errorf("no client CA certificates found in %s", p)
+		}
+	}
+
+	if cfg == nil {
+		cfg = &tls.Config{}
+	} else {
+		cfg = cfg.Clone()
+	}
+
+	cfg.ClientCAs = pool
+	cfg.ClientAuth = tls.VerifyClientCertIfGiven
+	return cfg, nil
+}
+
 func listen(o *Options, mtr metrics.Metrics) (net.Listener, error) {
 	if o.Address == "" {
 		o.Address = ":http"
 	}
 
 	if !o.EnableTCPQueue {
-		return net.Listen("tcp", o.Address)
+		l, err := net.Listen("tcp", o.Address)
+		if err != nil {
+			return nil, err
+		}
+
+		return withProxyProtocol(l, o), nil
 	}
 
 	var memoryLimit int
@@ -798,7 +1046,7 @@ func listen(o *Options, mtr metrics.Metrics) (net.Listener, error) {
 		qto = o.ReadTimeoutServer
 	}
 
-	return queuelistener.Listen(queuelistener.Options{
+	l, err := queuelistener.Listen(queuelistener.Options{
 		Network:          "tcp",
 		Address:          o.Address,
 		MaxConcurrency:   o.MaxTCPListenerConcurrency,
@@ -808,26 +1056,34 @@ func listen(o *Options, mtr metrics.Metrics) (net.Listener, error) {
 		QueueTimeout:     qto,
 		Metrics:          mtr,
 	})
+	if err != nil {
+		return nil, err
+	}
+
+	return withProxyProtocol(l, o), nil
 }
 
-func listenAndServeQuit(
-	proxy http.Handler,
-	o *Options,
-	sigs chan os.Signal,
-	idleConnsCH chan struct{},
-	mtr metrics.Metrics,
-) error {
-	// create the access log handler
-	log.Infof("proxy listener on %v", o.Address)
+func withProxyProtocol(l net.Listener, o *Options) net.Listener {
+	if !o.EnableProxyProtocol {
+		return l
+	}
 
+	return proxyprotocol.NewListener(l, proxyprotocol.Options{
+		ReadHeaderTimeout: o.ReadHeaderTimeoutServer,
+		TrustedNetworks:   o.ProxyProtocolTrustedNetworks,
+	})
+}
+
+func newServer(o *Options, address string, handler http.Handler) *http.Server {
 	srv := &http.Server{
-		Addr:              o.Address,
-		Handler:           proxy,
+		Addr:              address,
+		Handler:           handler,
 		ReadTimeout:       o.ReadTimeoutServer,
 		ReadHeaderTimeout: o.ReadHeaderTimeoutServer,
 		WriteTimeout:      o.WriteTimeoutServer,
 		IdleTimeout:       o.IdleTimeoutServer,
 		MaxHeaderBytes:    o.MaxHeaderBytes,
+		ConnContext:       proxy.ConnContext,
 	}
 
 	if o.EnableConnMetricsServer {
@@ -837,6 +1093,83 @@ func listenAndServeQuit(
 		}
 	}
 
+	return srv
+}
+
+// listenAdditional creates the server and the network listener of an
+// additional proxy listener. The requests accepted by the listener are
+// marked with its name for the Listener predicate.
+func listenAdditional(o *Options, lo ListenerOptions, proxy http.Handler) (*http.Server, net.Listener, error) {
+	if lo.Name == "" || lo.Address == "" {
+		return nil, nil, fmt.Errorf("missing name or address of the additional listener: %q", lo.Name)
+	}
+
+	srv := newServer(o, lo.Address, listener.Handler(lo.Name, proxy))
+	if lo.CertPathTLS != "" || lo.KeyPathTLS != "" {
+		kp, err := tls.LoadX509KeyPair(lo.CertPathTLS, lo.KeyPathTLS)
+		if err != nil {
+			return nil, nil, fmt.Errorf("failed to load X509 keypair of listener %s: %v", lo.Name, err)
+		}
+
+		srv.TLSConfig = &tls.Config{Certificates: []tls.Certificate{kp}}
+	}
+
+	l, err := net.Listen("tcp", lo.Address)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	return srv, withProxyProtocol(l, o), nil
+}
+
+func listenAndServeQuit(
+	proxy http.Handler,
+	o *Options,
+	sigs chan os.Signal,
+	idleConnsCH chan struct{},
+	mtr metrics.Metrics,
+) error {
+	// create the access log handler
+	log.Infof("proxy listener on %v", o.Address)
+	if o.EnableProxyProtocol && len(o.ProxyProtocolTrustedNetworks) == 0 {
+		log.Warn("PROXY protocol is accepted from any peer, consider setting the trusted networks")
+	}
+
+	srv := newServer(o, o.Address, proxy)
+
+	servers := []*http.Server{srv}
+	for _, lo := range o.Listeners {
+		s, l, err := listenAdditional(o, lo, proxy)
+		if err != nil {
+			for _, si := range servers[1:] {
+				si.Close()
+			}
+
+			return err
+		}
+
+		log.Infof("proxy listener %s on %v", lo.Name, lo.Address)
+		go func(name string) {
+			var err error
+			if s.TLSConfig != nil {
+				err = s.ServeTLS(l, "", "")
+			} else {
+				err = s.Serve(l)
+			}
+
+			if err != nil && err != http.ErrServerClosed {
+				log.Errorf("Failed to serve listener %s: %v", name, err)
+			}
+		}(lo.Name)
+
+		servers = append(servers, s)
+	}
+
+	var (
+		serve func() error
+		h3    *http3Listener
+	)
+
 	if o.isHTTPS() {
 		if o.ProxyTLS != nil {
 			srv.TLSConfig = o.ProxyTLS
@@ -861,9 +1194,50 @@ func listenAndServeQuit(
 			o.KeyPathTLS = ""
 			srv.TLSConfig = tlsCfg
 		}
-		return srv.ListenAndServeTLS(o.CertPathTLS, o.KeyPathTLS)
+
+		if o.ClientCAPathTLS != "" {
+			tlsCfg, err := withClientCAs(srv.TLSConfig, o.ClientCAPathTLS)
+			if err != nil {
+				return err
+			}
+
+			srv.TLSConfig = tlsCfg
+		}
+
+		var err error
+		if h3, err = listenHTTP3(o, srv.TLSConfig, srv.Handler); err != nil {
+			return err
+		}
+
+		srv.Handler = h3.advertise(srv.Handler)
+		if o.EnableProxyProtocol {
+			address := o.Address
+			if address == "" {
+				address = ":https"
+			}
+
+			l, err := net.Listen("tcp", address)
+			if err != nil {
+				return err
+			}
+
+			serve = func() error { return srv.ServeTLS(withProxyProtocol(l, o), o.CertPathTLS, o.KeyPathTLS) }
+		} else {
+			serve = func() error { return srv.ListenAndServeTLS(o.CertPathTLS, o.KeyPathTLS) }
+		}
+	} else {
+		log.Infof("TLS settings not found, defaulting to HTTP")
+		if o.EnableHTTP3 {
+			log.Warn("HTTP/3 requires the TLS settings, the HTTP/3 listener is not started")
+		}
+
+		l, err := listen(o, mtr)
+		if err != nil {
+			return err
+		}
+
+		serve = func() error { return srv.Serve(l) }
 	}
-	log.Infof("TLS settings not found, defaulting to HTTP")
 
 	// making idleConnsCH and sigs optional parameters is required to be able to tear down a server
 	// from the tests
@@ -880,23 +1254,31 @@ func listenAndServeQuit(
 
 		<-sigs
 
+		if o.readiness != nil {
+			o.readiness.drain()
+		}
+
 		log.Infof("Got shutdown signal, wait %v for health check", o.WaitForHealthcheckInterval)
 		time.Sleep(o.WaitForHealthcheckInterval)
 
 		log.Info("Start shutdown")
-		if err := srv.Shutdown(context.Background()); err != nil {
-			log.Errorf("Failed to graceful shutdown: %v", err)
+		h3.close()
+		var wg sync.WaitGroup
+		for _, s := range servers {
+			wg.Add(1)
+			go func(s *http.Server) {
+				defer wg.Done()
+				shutdown(s, o.ShutdownTimeout)
+			}(s)
 		}
+
+		wg.Wait()
 		close(idleConnsCH)
 	}()
 
-	l, err := listen(o, mtr)
-	if err != nil {
-		return err
-	}
-
-	if err := srv.Serve(l); err != nil && err != http.ErrServerClosed {
+	if err := serve(); err != nil && err != http.ErrServerClosed {
 		log.Errorf("Failed to start to ListenAndServe: %v", err)
+		h3.close()
 		return err
 	}
 
@@ -905,6 +1287,26 @@ func listenAndServeQuit(
 	return nil
 }
 
+// shutdown stops accepting new connections, and waits for the in-flight
+// requests to complete. When the timeout is greater than 0, and it expires
+// before all the requests were completed, it closes the remaining
+// connections.
+func shutdown(srv *http.Server, timeout time.Duration) {
+	ctx := context.Background()
+	if timeout > 0 {
+		var cancel func()
+		ctx, cancel = context.WithTimeout(ctx, timeout)
+		defer cancel()
+	}
+
+	if err := srv.Shutdown(ctx); err != nil {
+		log.Errorf("Failed to graceful shutdown: %v", err)
+		if err := srv.Close(); err != nil {
+			log.Errorf("Failed to close server: %v", err)
+		}
+	}
+}
+
 func listenAndServe(proxy http.Handler, o *Options) error {
 	return listenAndServeQuit(proxy, o, nil, nil, nil)
 }
@@ -948,6 +1350,7 @@ func run(o Options, sig chan os.Signal, idleConnsCH chan struct{}) error {
 		EnableAllFiltersMetrics:            o.EnableAllFiltersMetrics,
 		EnableCombinedResponseMetrics:      o.EnableCombinedResponseMetrics,
 		EnableRouteResponseMetrics:         o.EnableRouteResponseMetrics,
+		EnableGRPCStatusMetrics:            o.EnableGRPCStatusMetrics,
 		EnableRouteBackendErrorsCounters:   o.EnableRouteBackendErrorsCounters,
 		EnableRouteStreamingErrorsCounters: o.EnableRouteStreamingErrorsCounters,
 		EnableRouteBackendMetrics:          o.EnableRouteBackendMetrics,
@@ -1016,6 +1419,8 @@ func run(o Options, sig chan os.Signal, idleConnsCH chan struct{}) error {
 			Timeout:      o.OAuthTokeninfoTimeout,
 			MaxIdleConns: o.IdleConnectionsPerHost,
 			Tracer:       tracer,
+			CacheSize:    o.OAuthTokeninfoCacheSize,
+			CacheTTL:     o.OAuthTokeninfoCacheTTL,
 		}
 
 		o.CustomFilters = append(o.CustomFilters,
@@ -1043,6 +1448,8 @@ func run(o Options, sig chan os.Signal, idleConnsCH chan struct{}) error {
 		Timeout:      o.OAuthTokenintrospectionTimeout,
 		MaxIdleConns: o.IdleConnectionsPerHost,
 		Tracer:       tracer,
+		CacheSize:    o.OAuthTokenintrospectCacheSize,
+		CacheTTL:     o.OAuthTokenintrospectCacheTTL,
 	}
 
 	who := auth.WebhookOptions{
@@ -1051,9 +1458,74 @@ func run(o Options, sig chan os.Signal, idleConnsCH chan struct{}) error {
 		Tracer:       tracer,
 	}
 
+	opo := auth.OpaOptions{
+		Timeout:      o.OpaTimeout,
+		MaxIdleConns: o.IdleConnectionsPerHost,
+		Tracer:       tracer,
+	}
+
+	csrfSecretsFile := o.CSRFSecretsFile
+	if csrfSecretsFile == "" {
+		csrfSecretsFile = o.OIDCSecretsFile
+	}
+
+	var oidco auth.OidcOptions
+	switch o.OIDCSessionStore {
+	case "", "cookie":
+	case "redis":
+		if len(o.SwarmRedisURLs) == 0 {
+			return fmt.Errorf("redis OIDC session store requires redis shards")
+		}
+
+		ring := ratelimit.NewRedisRing(&ratelimit.RedisOptions{
+			Addrs:        o.SwarmRedisURLs,
+			ReadTimeout:  o.SwarmRedisReadTimeout,
+			WriteTimeout: o.SwarmRedisWriteTimeout,
+			PoolTimeout:  o.SwarmRedisPoolTimeout,
+			MinIdleConns: o.SwarmRedisMinIdleConns,
+			MaxIdleConns: o.SwarmRedisMaxIdleConns,
+		})
+		defer ring.Close()
+		oidco.SessionStore = auth.NewRedisOidcSessionStore(ring)
+	default:
+		return fmt.Errorf("invalid OIDC session store: %s", o.OIDCSessionStore)
+	}
+
+	var geo *geoip.GeoIP
+	if o.GeoIPCountryDatabase != "" || o.GeoIPASNDatabase != "" {
+		var err error
+		geo, err = geoip.New(geoip.Options{
+			CountryDatabase: o.GeoIPCountryDatabase,
+			ASNDatabase:     o.GeoIPASNDatabase,
+			ReloadInterval:  o.GeoIPReloadInterval,
+			TrustedProxies:  o.ForwardedHeaders.TrustedProxies,
+			ForwardedDepth:  o.ClientIPForwardedDepth,
+		})
+		if err != nil {
+			return fmt.Errorf("failed to load GeoIP databases: %v", err)
+		}
+
+		defer geo.Close()
+		o.CustomFilters = append(o.CustomFilters, geofilter.NewSetGeoHeaders(geo))
+		o.CustomPredicates = append(o.CustomPredicates, pgeoip.NewCountry(geo))
+	}
+
+	jwtValidation := auth.NewJwtValidation()
+	defer jwtValidation.Close()
+
 	o.CustomFilters = append(o.CustomFilters,
 		logfilter.NewAuditLog(o.MaxAuditBody),
+		cachefilter.NewCacheResponse(cache.New(cache.Options{
+			MaxSize:      o.ResponseCacheMaxSize,
+			MaxEntrySize: o.ResponseCacheMaxEntrySize,
+			Metrics:      mtr,
+		})),
 		auth.NewBearerInjector(sp),
+		auth.NewBackendClientCertificate(sp),
+		auth.NewHmacSignRequest(sp),
+		auth.NewAwsSigV4(sp),
+		jwtValidation,
+		auth.NewApiKeyAuth(sp),
 		auth.TokenintrospectionWithOptions(auth.NewOAuthTokenintrospectionAnyClaims, tio),
 		auth.TokenintrospectionWithOptions(auth.NewOAuthTokenintrospectionAllClaims, tio),
 		auth.TokenintrospectionWithOptions(auth.NewOAuthTokenintrospectionAnyKV, tio),
@@ -1062,10 +1534,18 @@ func run(o Options, sig chan os.Signal, idleConnsCH chan struct{}) error {
 		auth.TokenintrospectionWithOptions(auth.NewSecureOAuthTokenintrospectionAllClaims, tio),
 		auth.TokenintrospectionWithOptions(auth.NewSecureOAuthTokenintrospectionAnyKV, tio),
 		auth.TokenintrospectionWithOptions(auth.NewSecureOAuthTokenintrospectionAllKV, tio),
+		auth.OAuthTokenExchangeWithOptions(auth.TokenExchangeOptions{
+			Timeout:      o.OAuthTokenintrospectionTimeout,
+			MaxIdleConns: o.IdleConnectionsPerHost,
+			Tracer:       tracer,
+		}),
 		auth.WebhookWithOptions(who),
-		auth.NewOAuthOidcUserInfos(o.OIDCSecretsFile, o.SecretsRegistry),
-		auth.NewOAuthOidcAnyClaims(o.OIDCSecretsFile, o.SecretsRegistry),
-		auth.NewOAuthOidcAllClaims(o.OIDCSecretsFile, o.SecretsRegistry),
+		auth.ForwardAuthWithOptions(who),
+		auth.OpaAuthorizeRequestWithOptions(opo),
+		auth.NewOAuthOidcUserInfosWithOptions(o.OIDCSecretsFile, o.SecretsRegistry, oidco),
+		auth.NewOAuthOidcAnyClaimsWithOptions(o.OIDCSecretsFile, o.SecretsRegistry, oidco),
+		auth.NewOAuthOidcAllClaimsWithOptions(o.OIDCSecretsFile, o.SecretsRegistry, oidco),
+		auth.NewCSRFProtection(csrfSecretsFile, o.SecretsRegistry),
 		apiusagemonitoring.NewApiUsageMonitoring(
 			o.ApiUsageMonitoringEnable,
 			o.ApiUsageMonitoringRealmKeys,
@@ -1103,24 +1583,43 @@ func run(o Options, sig chan os.Signal, idleConnsCH chan struct{}) error {
 	o.CustomPredicates = append(o.CustomPredicates,
 		source.New(),
 		source.NewFromLast(),
+		source.NewClientIP(source.ClientIPOptions{
+			TrustedProxies: o.ForwardedHeaders.TrustedProxies,
+			ForwardedDepth: o.ClientIPForwardedDepth,
+		}),
 		interval.NewBetween(),
 		interval.NewBefore(),
 		interval.NewAfter(),
 		cron.New(),
+		cron.NewSchedule(),
 		cookie.New(),
+		mediatype.NewContentType(),
+		mediatype.NewAccept(),
+		contentlength.New(),
+		tlscert.NewCN(),
+		tlscert.NewSAN(),
+		tlscert.NewIssuer(),
+		listener.New(),
 		query.New(),
+		query.NewInt(),
+		query.NewIn(),
 		traffic.New(),
+		traffic.NewHash(),
+		traffic.NewSegment(),
 		primitive.NewTrue(),
 		primitive.NewFalse(),
 		pauth.NewJWTPayloadAllKV(),
 		pauth.NewJWTPayloadAnyKV(),
 		pauth.NewJWTPayloadAllKVRegexp(),
 		pauth.NewJWTPayloadAnyKVRegexp(),
+		pauth.NewJWTClaim(),
+		pauth.NewJWTClaimRegexp(),
 	)
 
 	schedulerRegistry := scheduler.RegistryWith(scheduler.Options{
 		Metrics:                mtr,
 		EnableRouteLIFOMetrics: o.EnableRouteLIFOMetrics,
+		EnableRouteFIFOMetrics: o.EnableRouteFIFOMetrics,
 	})
 	defer schedulerRegistry.Close()
 
@@ -1149,27 +1648,34 @@ func run(o Options, sig chan os.Signal, idleConnsCH chan struct{}) error {
 
 	proxyFlags := proxy.Flags(o.ProxyOptions) | o.ProxyFlags
 	proxyParams := proxy.Params{
-		Routing:                  routing,
-		Flags:                    proxyFlags,
-		PriorityRoutes:           o.PriorityRoutes,
-		IdleConnectionsPerHost:   o.IdleConnectionsPerHost,
-		CloseIdleConnsPeriod:     o.CloseIdleConnsPeriod,
-		FlushInterval:            o.BackendFlushInterval,
-		ExperimentalUpgrade:      o.ExperimentalUpgrade,
-		ExperimentalUpgradeAudit: o.ExperimentalUpgradeAudit,
-		MaxLoopbacks:             o.MaxLoopbacks,
-		DefaultHTTPStatus:        o.DefaultHTTPStatus,
-		LoadBalancer:             lbInstance,
-		Timeout:                  o.TimeoutBackend,
-		ResponseHeaderTimeout:    o.ResponseHeaderTimeoutBackend,
-		ExpectContinueTimeout:    o.ExpectContinueTimeoutBackend,
-		KeepAlive:                o.KeepAliveBackend,
-		DualStack:                o.DualStackBackend,
-		TLSHandshakeTimeout:      o.TLSHandshakeTimeoutBackend,
-		MaxIdleConns:             o.MaxIdleConnsBackend,
-		DisableHTTPKeepalives:    o.DisableHTTPKeepalives,
-		AccessLogDisabled:        o.AccessLogDisabled,
-		ClientTLS:                o.ClientTLS,
+		Routing:                     routing,
+		Flags:                       proxyFlags,
+		PriorityRoutes:              o.PriorityRoutes,
+		IdleConnectionsPerHost:      o.IdleConnectionsPerHost,
+		CloseIdleConnsPeriod:        o.CloseIdleConnsPeriod,
+		FlushInterval:               o.BackendFlushInterval,
+		ResponseFlushInterval:       o.ResponseFlushInterval,
+		ServerSentEventsIdleTimeout: o.ServerSentEventsIdleTimeout,
+		ExperimentalUpgrade:         o.ExperimentalUpgrade,
+		ExperimentalUpgradeAudit:    o.ExperimentalUpgradeAudit,
+		WebSocketIdleTimeout:        o.WebSocketIdleTimeout,
+		MaxLoopbacks:                o.MaxLoopbacks,
+		MaxLBRetries:                o.MaxLBRetries,
+		DefaultHTTPStatus:           o.DefaultHTTPStatus,
+		ForwardedHeaders:            o.ForwardedHeaders,
+		LoadBalancer:                lbInstance,
+		Timeout:                     o.TimeoutBackend,
+		ResponseHeaderTimeout:       o.ResponseHeaderTimeoutBackend,
+		ExpectContinueTimeout:       o.ExpectContinueTimeoutBackend,
+		KeepAlive:                   o.KeepAliveBackend,
+		DualStack:                   o.DualStackBackend,
+		TLSHandshakeTimeout:         o.TLSHandshakeTimeoutBackend,
+		MaxIdleConns:                o.MaxIdleConnsBackend,
+		DisableHTTPKeepalives:       o.DisableHTTPKeepalives,
+		EnableHTTP2Backends:         o.EnableHTTP2Backends,
+		EgressProxy:                 o.EgressProxy,
+		AccessLogDisabled:           o.AccessLogDisabled,
+		ClientTLS:                   o.ClientTLS,
 	}
 
 	var swarmer ratelimit.Swarmer
@@ -1238,6 +1744,8 @@ func run(o Options, sig chan os.Signal, idleConnsCH chan struct{}) error {
 		reg := ratelimit.NewSwarmRegistry(swarmer, redisOptions, o.RatelimitSettings...)
 		defer reg.Close()
 		proxyParams.RateLimiters = reg
+		proxyParams.RatelimitResponseHeaders = o.RatelimitResponseHeaders
+		proxyParams.RatelimitLegacyHeaders = o.RatelimitLegacyHeaders
 	}
 
 	if o.EnableBreakers || len(o.BreakerSettings) > 0 {
@@ -1265,6 +1773,9 @@ func run(o Options, sig chan os.Signal, idleConnsCH chan struct{}) error {
 		mux.Handle("/routes", routing)
 		mux.Handle("/routes/", routing)
 
+		o.readiness = &readiness{}
+		mux.Handle("/ready", o.readiness)
+
 		metricsHandler := metrics.NewHandler(mtrOpts, mtr)
 		mux.Handle("/metrics", metricsHandler)
 		mux.Handle("/metrics/", metricsHandler)
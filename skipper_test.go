@@ -5,6 +5,7 @@ import (
 	"io/ioutil"
 	"net"
 	"net/http"
+	"net/http/httptest"
 	"os"
 	"os/signal"
 	"sync"
@@ -14,6 +15,7 @@ import (
 
 	"github.com/zalando/skipper/dataclients/routestring"
 	"github.com/zalando/skipper/filters/builtin"
+	"github.com/zalando/skipper/predicates/listener"
 	"github.com/zalando/skipper/proxy"
 	"github.com/zalando/skipper/routing"
 )
@@ -132,6 +134,30 @@ func TestWithWrongKeyPathFails(t *testing.T) {
 	}
 }
 
+func TestWithClientCAs(t *testing.T) {
+	proxyTLS := &tls.Config{MinVersion: tls.VersionTLS12}
+	cfg, err := withClientCAs(proxyTLS, "fixtures/test.crt")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if cfg.ClientCAs == nil || cfg.ClientAuth != tls.VerifyClientCertIfGiven || cfg.MinVersion != tls.VersionTLS12 {
+		t.Errorf("client CAs not configured: %v", cfg)
+	}
+
+	if proxyTLS.ClientCAs != nil {
+		t.Error("proxy TLS configuration modified")
+	}
+
+	if _, err := withClientCAs(nil, "fixtures/test.crt,fixtures/notFound.crt"); err == nil {
+		t.Error("failed to fail with a missing file")
+	}
+
+	if _, err := withClientCAs(nil, "fixtures/test.key"); err == nil {
+		t.Error("failed to fail without certificates")
+	}
+}
+
 // to run this test, set `-args listener` for the test command
 func TestHTTPSServer(t *testing.T) {
 	// TODO: figure why sometimes cannot connect
@@ -301,3 +327,195 @@ func TestHTTPServerShutdown(t *testing.T) {
 	wg.Wait()
 	time.Sleep(d)
 }
+
+func TestReadiness(t *testing.T) {
+	r := &readiness{}
+
+	w := httptest.NewRecorder()
+	r.ServeHTTP(w, httptest.NewRequest("GET", "/ready", nil))
+	if w.Code != http.StatusOK {
+		t.Errorf("Status code should be 200, instead got: %d", w.Code)
+	}
+
+	r.drain()
+
+	w = httptest.NewRecorder()
+	r.ServeHTTP(w, httptest.NewRequest("GET", "/ready", nil))
+	if w.Code != http.StatusServiceUnavailable {
+		t.Errorf("Status code should be 503, instead got: %d", w.Code)
+	}
+}
+
+func TestHTTPServerShutdownTimeout(t *testing.T) {
+	a, err := findAddress()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	o := Options{
+		Address:         a,
+		ShutdownTimeout: 100 * time.Millisecond,
+		readiness:       &readiness{},
+	}
+
+	// the request takes longer than the shutdown timeout
+	dc, err := routestring.New(`r0: * -> latency("3s") -> inlineContent("OK") -> status(200) -> <shunt>`)
+	if err != nil {
+		t.Fatalf("Failed to create dataclient: %v", err)
+	}
+
+	rt := routing.New(routing.Options{
+		FilterRegistry: builtin.MakeRegistry(),
+		DataClients: []routing.DataClient{
+			dc,
+		},
+	})
+	defer rt.Close()
+
+	proxy := proxy.New(rt, proxy.OptionsNone)
+	defer proxy.Close()
+
+	sigs := make(chan os.Signal, 1)
+	idleConnsCH := make(chan struct{})
+	done := make(chan error, 1)
+	go func() {
+		done <- listenAndServeQuit(proxy, &o, sigs, idleConnsCH, nil)
+	}()
+
+	requestErr := make(chan error, 1)
+	go func() {
+		rsp, err := waitConnGet("http://" + o.Address)
+		if err == nil {
+			rsp.Body.Close()
+		}
+
+		requestErr <- err
+	}()
+
+	// wait for the request to get in flight
+	time.Sleep(300 * time.Millisecond)
+	start := time.Now()
+	sigs <- syscall.SIGTERM
+
+	select {
+	case err := <-done:
+		if err != nil {
+			t.Errorf("Failed to listen and serve: %v", err)
+		}
+	case <-time.After(2 * time.Second):
+		t.Fatal("Failed to shut down before the timeout")
+	}
+
+	if d := time.Since(start); d > time.Second {
+		t.Errorf("Shutdown took too long: %v", d)
+	}
+
+	w := httptest.NewRecorder()
+	o.readiness.ServeHTTP(w, httptest.NewRequest("GET", "/ready", nil))
+	if w.Code != http.StatusServiceUnavailable {
+		t.Errorf("Readiness should report 503 after shutdown, instead got: %d", w.Code)
+	}
+
+	select {
+	case err := <-requestErr:
+		if err == nil {
+			t.Error("In-flight request should fail after the shutdown timeout")
+		}
+	case <-time.After(time.Second):
+		t.Error("In-flight request was not terminated")
+	}
+}
+
+func TestAdditionalListeners(t *testing.T) {
+	a, err := findAddress()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	internal, err := findAddress()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	public, err := findAddress()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	o := Options{
+		Address: a,
+		Listeners: []ListenerOptions{{
+			Name:    "internal",
+			Address: internal,
+		}, {
+			Name:        "public",
+			Address:     public,
+			CertPathTLS: "fixtures/test.crt",
+			KeyPathTLS:  "fixtures/test.key",
+		}},
+	}
+
+	dc, err := routestring.New(`
+		internal: Listener("internal") -> inlineContent("internal") -> <shunt>;
+		public: Listener("public") -> inlineContent("public") -> <shunt>;
+		main: * -> inlineContent("main") -> <shunt>;
+	`)
+	if err != nil {
+		t.Fatalf("Failed to create dataclient: %v", err)
+	}
+
+	rt := routing.New(routing.Options{
+		FilterRegistry: builtin.MakeRegistry(),
+		Predicates:     []routing.PredicateSpec{listener.New()},
+		DataClients:    []routing.DataClient{dc},
+	})
+	defer rt.Close()
+
+	proxy := proxy.New(rt, proxy.OptionsNone)
+	defer proxy.Close()
+
+	sigs := make(chan os.Signal, 1)
+	idleConnsCH := make(chan struct{})
+	done := make(chan error, 1)
+	go func() {
+		done <- listenAndServeQuit(proxy, &o, sigs, idleConnsCH, nil)
+	}()
+
+	for _, ti := range []struct {
+		url  string
+		body string
+	}{
+		{"http://" + a, "main"},
+		{"http://" + internal, "internal"},
+		{"https://" + public, "public"},
+	} {
+		r, err := waitConnGet(ti.url)
+		if err != nil {
+			t.Fatalf("Cannot connect to %s: %v", ti.url, err)
+		}
+
+		body, err := ioutil.ReadAll(r.Body)
+		r.Body.Close()
+		if err != nil {
+			t.Fatalf("Failed to read response body: %v", err)
+		}
+
+		if s := string(body); s != ti.body {
+			t.Errorf("Failed to get the right content from %s, expected: %s, got: %s", ti.url, ti.body, s)
+		}
+	}
+
+	sigs <- syscall.SIGTERM
+	select {
+	case err := <-done:
+		if err != nil {
+			t.Errorf("Failed to listen and serve: %v", err)
+		}
+	case <-time.After(time.Second):
+		t.Fatal("Failed to shut down")
+	}
+
+	if _, err := (&http.Client{}).Get("http://" + internal); err == nil {
+		t.Error("Can connect to a closed listener")
+	}
+}